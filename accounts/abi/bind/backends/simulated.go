@@ -45,6 +45,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/event"
 	"github.com/PlatONnetwork/PlatON-Go/params"
 	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
 	_ "github.com/PlatONnetwork/PlatON-Go/x/xcom"
 )
 
@@ -74,6 +75,8 @@ type SimulatedBackend struct {
 	events *filters.EventSystem // Event system for filtering log events live
 
 	config *params.ChainConfig
+
+	ppos *simulatedPpos // PPOS block production, nil if the plugins are not wired
 }
 
 // NewSimulatedBackendWithDatabase creates a new binding backend based on the given database
@@ -82,7 +85,7 @@ type SimulatedBackend struct {
 func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
 	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
 	genesis.MustCommit(database)
-	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, consensus.NewFakerWithDataBase(database), vm.Config{}, nil, nil)
+	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, consensus.NewFakerWithDataBase(database), vm.Config{WasmType: vm.Wagon}, nil, nil)
 
 	backend := &SimulatedBackend{
 		database:   database,
@@ -104,6 +107,9 @@ func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBac
 // Close terminates the underlying blockchain's update loop.
 func (b *SimulatedBackend) Close() error {
 	b.blockchain.Stop()
+	if b.ppos != nil {
+		b.closePpos()
+	}
 	return nil
 }
 
@@ -113,6 +119,13 @@ func (b *SimulatedBackend) Commit() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.ppos != nil {
+		if err := b.commitPpos(); err != nil {
+			panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+		}
+		b.rollback()
+		return
+	}
 	if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
 		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
 	}
@@ -130,6 +143,12 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback() {
+	if b.ppos != nil {
+		if err := b.preparePpos(); err != nil {
+			panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+		}
+		return
+	}
 	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.blockchain.Engine(), b.database, 1, func(int, *core.BlockGen) {})
 	stateDB, _ := b.blockchain.State()
 
@@ -559,7 +578,12 @@ func (b *SimulatedBackend) callContract(_ context.Context, call ethereum.CallMsg
 	evmContext := core.NewEVMBlockContext(block.Header(), b.blockchain)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
-	vmEnv := vm.NewEVM(evmContext, txContext, snapshotdb.Instance(), stateDB, b.config, vm.Config{})
+	sdb := snapshotdb.Instance()
+	if b.ppos != nil {
+		// Calls on the pending block must not leave traces in its ppos data
+		defer sdb.RevertToSnapshot(evmContext.BlockHash, sdb.Snapshot(evmContext.BlockHash))
+	}
+	vmEnv := vm.NewEVM(evmContext, txContext, sdb, stateDB, b.config, *b.blockchain.GetVMConfig())
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.NewStateTransition(vmEnv, msg, gasPool).TransitionDb()
@@ -578,6 +602,12 @@ func (b *SimulatedBackend) SendTransaction(_ context.Context, tx *types.Transact
 	if tx.Nonce() != nonce {
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
+	if b.ppos != nil {
+		if err := b.applyPpos(tx); err != nil {
+			panic(fmt.Errorf("invalid transaction: %v", err))
+		}
+		return nil
+	}
 
 	blocks, receipts := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.blockchain.Engine(), b.database, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
@@ -697,6 +727,12 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	if len(b.pendingBlock.Transactions()) != 0 {
 		return errors.New("Could not adjust time on non-empty block")
 	}
+	if b.ppos != nil {
+		// PPOS blocks are timestamped in milliseconds
+		b.ppos.header.Time += uint64(adjustment.Milliseconds())
+		b.pendingBlock = types.NewBlock(b.ppos.header, nil, nil, new(trie.Trie))
+		return nil
+	}
 
 	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.blockchain.Engine(), b.database, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
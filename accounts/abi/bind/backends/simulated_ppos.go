@@ -0,0 +1,423 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/evidence"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/eth/filters"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/handler"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+var (
+	errPposUnsupported = errors.New("simulatedBackend is not running the PPOS plugins")
+	errPposInUse       = errors.New("another PPOS simulatedBackend is still open")
+
+	// The plugins, the reactor and the snapshotdb are process wide singletons,
+	// so only one PPOS backend can be open at a time.
+	pposInUse int32
+
+	// pposGenesisNonce seeds the VRF of the first block, the VRF handler is a
+	// singleton as well so every PPOS backend shares the same seed.
+	pposGenesisNonce = hexutil.MustDecode("0x0376e56dffd12ab53bb149bda4e0cbce2b6aabe4cccc0df0b5a39e12977a2fcd23")
+
+	// pposRewardPoolIssue is the default balance of the reward pool, the same
+	// amount the public networks start with.
+	pposRewardPoolIssue, _ = new(big.Int).SetString("200000000000000000000000000", 10)
+)
+
+// PposValidator is a genesis validator of a PPOS simulated backend.
+type PposValidator struct {
+	NodeKey *ecdsa.PrivateKey // Key the validator signs its blocks with
+	BlsKey  *bls.SecretKey    // Consensus key, a random one is used if nil
+}
+
+// PposConfig configures a simulated backend which drives the PPOS plugins.
+type PposConfig struct {
+	// Validators are the genesis validators, a single random validator is
+	// used if empty.
+	Validators []PposValidator
+
+	// EconomicModel overrides the economic model, the unit test model with
+	// 40 blocks per consensus round and 360 blocks per epoch is used if nil.
+	EconomicModel *xcom.EconomicModel
+
+	// SnapshotDBPath is the directory of the snapshotdb, it is wiped on
+	// startup. The snapshotdb is kept in memory if empty.
+	SnapshotDBPath string
+}
+
+// simulatedPpos holds the state of a simulated backend producing its blocks
+// the way a CBFT validator does: every block runs through the BeginBlocker and
+// EndBlocker of the reactor, is sealed by the proposer of its view, flushed to
+// the snapshotdb and committed as if a QC had been received.
+type simulatedPpos struct {
+	reactor *core.BlockChainReactor
+	keys    map[discover.NodeID]*ecdsa.PrivateKey
+	genesis discover.NodeID // Fallback proposer if no validator key is known
+
+	signer  *ecdsa.PrivateKey // Proposer sealing the pending block
+	header  *types.Header     // Header of the pending block
+	txs     []*types.Transaction
+	gasPool *core.GasPool
+}
+
+// NewSimulatedPposBackend creates a simulated backend that runs the staking,
+// slashing, restricting, reward and governance plugins on every block, so the
+// PPOS system contracts behave as they do on a live network.
+//
+// Blocks are produced by the proposer of the current consensus round. Use
+// AddValidatorKey to let the validators staked after genesis produce their
+// blocks, the blocks of validators whose key is unknown are produced by the
+// next one, as a view change would do.
+func NewSimulatedPposBackend(alloc core.GenesisAlloc, gasLimit uint64, config *PposConfig) (*SimulatedBackend, error) {
+	return NewSimulatedPposBackendWithDatabase(rawdb.NewMemoryDatabase(), alloc, gasLimit, config)
+}
+
+// NewSimulatedPposBackendWithDatabase creates a new PPOS binding backend based
+// on the given database.
+func NewSimulatedPposBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64, config *PposConfig) (*SimulatedBackend, error) {
+	if !atomic.CompareAndSwapInt32(&pposInUse, 0, 1) {
+		return nil, errPposInUse
+	}
+	backend, err := newSimulatedPposBackend(database, alloc, gasLimit, config)
+	if err != nil {
+		snapshotdb.Close()
+		snapshotdb.SetDBMemory(false)
+		atomic.StoreInt32(&pposInUse, 0)
+		return nil, err
+	}
+	return backend, nil
+}
+
+func newSimulatedPposBackend(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64, config *PposConfig) (*SimulatedBackend, error) {
+	if config == nil {
+		config = new(PposConfig)
+	}
+	validators := config.Validators
+	if len(validators) == 0 {
+		validators = []PposValidator{{}}
+	}
+	ppos := &simulatedPpos{
+		keys: make(map[discover.NodeID]*ecdsa.PrivateKey),
+	}
+	nodes := make([]params.CbftNode, 0, len(validators))
+	for i, v := range validators {
+		nodeKey, blsKey := v.NodeKey, v.BlsKey
+		if nodeKey == nil {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return nil, err
+			}
+			nodeKey = key
+		}
+		if blsKey == nil {
+			blsKey = new(bls.SecretKey)
+			blsKey.SetByCSPRNG()
+		}
+		nodeID := discover.PubkeyID(&nodeKey.PublicKey)
+		if _, ok := ppos.keys[nodeID]; ok {
+			return nil, fmt.Errorf("duplicate validator %s", nodeID.TerminalString())
+		}
+		if i == 0 {
+			ppos.genesis = nodeID
+		}
+		ppos.keys[nodeID] = nodeKey
+		nodes = append(nodes, params.CbftNode{
+			Node:      *discover.NewNode(nodeID, net.IPv4(127, 0, 0, 1), uint16(16789+i), uint16(16789+i)),
+			BlsPubKey: *blsKey.GetPublicKey(),
+		})
+	}
+
+	// The economic model must be in place before the genesis is built since
+	// the genesis staking data depends on it.
+	xcom.GetEc(xcom.DefaultUnitTestNet)
+	if config.EconomicModel != nil {
+		xcom.ResetEconomicDefaultConfig(config.EconomicModel)
+	}
+
+	// Start from an empty snapshotdb, whatever instance was open before
+	snapshotdb.Close()
+	if config.SnapshotDBPath == "" {
+		snapshotdb.SetDBMemory(true)
+	} else {
+		snapshotdb.SetDBMemory(false)
+		snapshotdb.SetDBPathWithNode(config.SnapshotDBPath)
+	}
+	if err := snapshotdb.Instance().Clear(); err != nil {
+		return nil, err
+	}
+
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.Cbft = &params.CbftConfig{
+		InitialNodes:  nodes,
+		ValidatorMode: common.PPOS_VALIDATOR_MODE,
+	}
+	genesisAlloc := make(core.GenesisAlloc, len(alloc)+1)
+	genesisAlloc[cvm.RewardManagerPoolAddr] = core.GenesisAccount{Balance: pposRewardPoolIssue}
+	for addr, account := range alloc {
+		genesisAlloc[addr] = account
+	}
+	genesis := core.Genesis{
+		Config:        &chainConfig,
+		Nonce:         pposGenesisNonce,
+		GasLimit:      gasLimit,
+		Alloc:         genesisAlloc,
+		EconomicModel: xcom.GetEc(xcom.DefaultUnitTestNet),
+	}
+	if _, err := genesis.Commit(database, snapshotdb.Instance()); err != nil {
+		return nil, err
+	}
+	// The preimages of the trie keys are kept for the debug APIs resolving them,
+	// and every state is flushed like on an archive node for the offline tools
+	cacheConfig := &core.CacheConfig{
+		Disabled:        true,
+		TrieCleanLimit:  512,
+		TrieDirtyLimit:  256 * 1024 * 1024,
+		TrieTimeLimit:   5 * time.Minute,
+		Preimages:       true,
+		BodyCacheLimit:  256,
+		BlockCacheLimit: 256,
+		MaxFutureBlocks: 256,
+		BadBlockLimit:   10,
+		TriesInMemory:   128,
+		DBGCInterval:    86400,
+		DBGCTimeout:     time.Minute,
+	}
+	blockchain, err := core.NewBlockChain(database, cacheConfig, genesis.Config, consensus.NewFakerWithDataBase(database), vm.Config{WasmType: vm.Wagon}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	snapshotdb.SetDBBlockChain(blockchain)
+
+	// Wire the plugins into the reactor the same way a PPOS node does
+	reactor := core.NewBlockChainReactor(new(event.TypeMux), genesis.Config.ChainID)
+	reactor.SetValidatorMode(common.PPOS_VALIDATOR_MODE)
+	reactor.SetVRFhandler(handler.NewVrfHandler(pposGenesisNonce))
+	reactor.SetPluginEventMux()
+	reactor.SetPrivateKey(ppos.keys[ppos.genesis])
+
+	plugin.RewardMgrInstance().SetCurrentNodeID(reactor.NodeId)
+	reactor.RegisterPlugin(xcom.SlashingRule, plugin.SlashInstance())
+	plugin.SlashInstance().SetDecodeEvidenceFun(evidence.NewEvidence)
+	reactor.RegisterPlugin(xcom.StakingRule, plugin.StakingInstance())
+	reactor.RegisterPlugin(xcom.RestrictingRule, plugin.RestrictingInstance())
+	reactor.RegisterPlugin(xcom.RewardRule, plugin.RewardMgrInstance())
+	plugin.GovPluginInstance().SetChainID(genesis.Config.ChainID)
+	plugin.GovPluginInstance().SetChainDB(database)
+	reactor.RegisterPlugin(xcom.GovernanceRule, plugin.GovPluginInstance())
+	plugin.StakingInstance().SetChainDB(database, database)
+	reactor.SetBeginRule([]int{xcom.StakingRule, xcom.SlashingRule, xcom.CollectDeclareVersionRule, xcom.GovernanceRule})
+	reactor.SetEndRule([]int{xcom.CollectDeclareVersionRule, xcom.RestrictingRule, xcom.RewardRule, xcom.GovernanceRule, xcom.StakingRule})
+	gov.RegisterGovernParamVerifiers()
+	ppos.reactor = reactor
+
+	backend := &SimulatedBackend{
+		database:   database,
+		blockchain: blockchain,
+		config:     genesis.Config,
+		ppos:       ppos,
+		events:     filters.NewEventSystem(&filterBackend{database, blockchain}, false),
+	}
+	if err := backend.preparePpos(); err != nil {
+		blockchain.Stop()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// AddValidatorKey registers the node key of a validator staked after genesis,
+// so that the blocks of its views are sealed by it.
+func (b *SimulatedBackend) AddValidatorKey(key *ecdsa.PrivateKey) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ppos == nil {
+		return errPposUnsupported
+	}
+	b.ppos.keys[discover.PubkeyID(&key.PublicKey)] = key
+	return nil
+}
+
+// FastForwardToSettlement commits blocks until the last block of the current
+// settlement epoch is committed, the pending transactions are included in the
+// first of them. If the head already is the end of an epoch, it moves on to
+// the end of the next one.
+func (b *SimulatedBackend) FastForwardToSettlement() error {
+	return b.fastForward(xutil.IsEndOfEpoch)
+}
+
+// FastForwardToElection commits blocks until the next election block, where
+// the validators of the next consensus round are elected, is committed.
+func (b *SimulatedBackend) FastForwardToElection() error {
+	return b.fastForward(xutil.IsElection)
+}
+
+func (b *SimulatedBackend) fastForward(done func(blockNumber uint64) bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ppos == nil {
+		return errPposUnsupported
+	}
+	for {
+		if err := b.commitPpos(); err != nil {
+			return err
+		}
+		if err := b.preparePpos(); err != nil {
+			return err
+		}
+		if done(b.blockchain.CurrentBlock().NumberU64()) {
+			return nil
+		}
+	}
+}
+
+// proposer returns the key of the validator whose view the given block falls
+// in. Every validator of a consensus round proposes BlocksWillCreate blocks in
+// turn, in the order of their index.
+func (p *simulatedPpos) proposer(blockNumber uint64) *ecdsa.PrivateKey {
+	validators, err := p.reactor.GetValidator(blockNumber)
+	if err == nil && validators.Len() > 0 {
+		view := (blockNumber - validators.ValidBlockNumber) / xcom.BlocksWillCreate()
+		for i := 0; i < validators.Len(); i++ {
+			nodeID := validators.NodeID(int((view + uint64(i)) % uint64(validators.Len())))
+			if key, ok := p.keys[nodeID]; ok {
+				return key
+			}
+		}
+	}
+	return p.keys[p.genesis]
+}
+
+// preparePpos starts a new pending block on top of the head, running the
+// BeginBlocker of the reactor as the proposer of the block would.
+func (b *SimulatedBackend) preparePpos() error {
+	parent := b.blockchain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + xcom.Interval()*1000,
+	}
+	if err := b.blockchain.Engine().Prepare(b.blockchain, header); err != nil {
+		return err
+	}
+	stateDB, err := b.blockchain.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	proposer := b.ppos.proposer(header.Number.Uint64())
+	nodeID := discover.PubkeyID(&proposer.PublicKey)
+	b.ppos.reactor.SetPrivateKey(proposer)
+	plugin.RewardMgrInstance().SetCurrentNodeID(nodeID)
+
+	if err := b.ppos.reactor.BeginBlocker(header, stateDB); err != nil {
+		return err
+	}
+	b.ppos.reactor.SetWorkerCoinBase(header, nodeID)
+
+	b.ppos.signer = proposer
+	b.ppos.header = header
+	b.ppos.txs = nil
+	b.ppos.gasPool = new(core.GasPool).AddGas(header.GasLimit)
+	b.pendingReceipts = nil
+	b.pendingState = stateDB
+	b.pendingBlock = types.NewBlock(header, nil, nil, new(trie.Trie))
+	return nil
+}
+
+// applyPpos executes the transaction on top of the pending block.
+func (b *SimulatedBackend) applyPpos(tx *types.Transaction) error {
+	header := b.ppos.header
+	b.pendingState.Prepare(tx.Hash(), common.Hash{}, len(b.ppos.txs))
+	receipt, err := core.ApplyTransaction(b.config, b.blockchain, b.ppos.gasPool, b.pendingState, header, tx, &header.GasUsed, *b.blockchain.GetVMConfig())
+	if err != nil {
+		return err
+	}
+	b.ppos.txs = append(b.ppos.txs, tx)
+	b.pendingReceipts = append(b.pendingReceipts, receipt)
+	b.pendingBlock = types.NewBlock(header, b.ppos.txs, b.pendingReceipts, new(trie.Trie))
+	return nil
+}
+
+// commitPpos runs the EndBlocker on the pending block, seals it with the key
+// of its proposer and commits it to the chain and the snapshotdb.
+func (b *SimulatedBackend) commitPpos() error {
+	var (
+		header   = b.ppos.header
+		stateDB  = b.pendingState
+		receipts = b.pendingReceipts
+	)
+	if err := b.ppos.reactor.EndBlocker(header, stateDB); err != nil {
+		return err
+	}
+	block, err := b.blockchain.Engine().Finalize(b.blockchain, header, stateDB, b.ppos.txs, receipts)
+	if err != nil {
+		return err
+	}
+	sealed := block.Header()
+	sign, err := crypto.Sign(sealed.SealHash().Bytes(), b.ppos.signer)
+	if err != nil {
+		return err
+	}
+	copy(sealed.Extra[len(sealed.Extra)-consensus.ExtraSeal:], sign)
+	block = block.WithSeal(sealed)
+
+	if err := b.ppos.reactor.Flush(block.Header()); err != nil {
+		return err
+	}
+	if _, err := b.blockchain.WriteBlockWithState(block, receipts, nil, stateDB, true); err != nil {
+		return err
+	}
+	return b.ppos.reactor.OnCommit(block)
+}
+
+// closePpos hands the process wide singletons back.
+func (b *SimulatedBackend) closePpos() {
+	b.ppos.reactor.SetValidatorMode(common.STATIC_VALIDATOR_MODE)
+	snapshotdb.Close()
+	snapshotdb.SetDBMemory(false)
+	atomic.StoreInt32(&pposInUse, 0)
+}
@@ -0,0 +1,256 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/PlatONnetwork/PlatON-Go"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/node"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/restricting"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+// pposInput encodes a call of a PPOS system contract.
+func pposInput(t *testing.T, fn uint16, args ...interface{}) []byte {
+	input := make([][]byte, 0, len(args)+1)
+	for _, arg := range append([]interface{}{fn}, args...) {
+		enc, err := rlp.EncodeToBytes(arg)
+		if err != nil {
+			t.Fatalf("failed to encode %v: %v", arg, err)
+		}
+		input = append(input, enc)
+	}
+	data, err := rlp.EncodeToBytes(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func sendPpos(t *testing.T, sim *SimulatedBackend, key *ecdsa.PrivateKey, to common.Address, input []byte) *types.Transaction {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := sim.PendingNonceAt(context.Background(), from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(nonce, to, new(big.Int), 1000000, big.NewInt(params.GVon), input)
+	tx, err = types.SignTx(tx, types.NewEIP155Signer(sim.config.ChainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func callPpos(t *testing.T, sim *SimulatedBackend, to common.Address, input []byte, ret interface{}) {
+	out, err := sim.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: input}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res struct {
+		Code uint32
+		Ret  json.RawMessage
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("failed to decode %s: %v", out, err)
+	}
+	if res.Code != 0 {
+		t.Fatalf("call failed: %s", out)
+	}
+	if err := json.Unmarshal(res.Ret, ret); err != nil {
+		t.Fatalf("failed to decode %s: %v", res.Ret, err)
+	}
+}
+
+func TestSimulatedPposFastForward(t *testing.T) {
+	plain := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	if err := plain.FastForwardToElection(); err != errPposUnsupported {
+		t.Fatalf("plain backend: have %v, want %v", err, errPposUnsupported)
+	}
+	plain.Close()
+
+	sim, err := NewSimulatedPposBackend(core.GenesisAlloc{}, 100000000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	if _, err := NewSimulatedPposBackend(core.GenesisAlloc{}, 100000000, nil); err != errPposInUse {
+		t.Fatalf("second backend: have %v, want %v", err, errPposInUse)
+	}
+
+	if err := sim.FastForwardToElection(); err != nil {
+		t.Fatal(err)
+	}
+	election := sim.Blockchain().CurrentBlock().NumberU64()
+	if !xutil.IsElection(election) {
+		t.Fatalf("block %d is not an election block", election)
+	}
+	if err := sim.FastForwardToSettlement(); err != nil {
+		t.Fatal(err)
+	}
+	settlement := sim.Blockchain().CurrentBlock().NumberU64()
+	if !xutil.IsEndOfEpoch(settlement) || settlement != xutil.CalcBlocksEachEpoch() {
+		t.Fatalf("block %d is not the first settlement block", settlement)
+	}
+	// Blocks are sealed by the validator
+	var verifiers []struct{ NodeId discover.NodeID }
+	callPpos(t, sim, cvm.StakingContractAddr, pposInput(t, 1100), &verifiers)
+	if len(verifiers) != 1 {
+		t.Fatalf("verifier count mismatch: have %d, want 1", len(verifiers))
+	}
+	head := sim.Blockchain().CurrentHeader()
+	signer, err := crypto.SigToPub(head.SealHash().Bytes(), head.Extra[len(head.Extra)-65:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discover.PubkeyID(signer) != verifiers[0].NodeId {
+		t.Fatalf("block not sealed by the validator")
+	}
+}
+
+func TestSimulatedPposDelegateReward(t *testing.T) {
+	var (
+		stakerKey, _    = crypto.GenerateKey()
+		delegatorKey, _ = crypto.GenerateKey()
+		nodeKey, _      = crypto.GenerateKey()
+		benefit         = common.Address{0xbe}
+		staker          = crypto.PubkeyToAddress(stakerKey.PublicKey)
+		delegator       = crypto.PubkeyToAddress(delegatorKey.PublicKey)
+		nodeID          = discover.PubkeyID(&nodeKey.PublicKey)
+		lat             = big.NewInt(params.LAT)
+	)
+	sim, err := NewSimulatedPposBackend(core.GenesisAlloc{
+		staker:    {Balance: new(big.Int).Mul(big.NewInt(1000000), lat)},
+		delegator: {Balance: new(big.Int).Mul(big.NewInt(1000000), lat)},
+	}, 100000000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	if err := sim.AddValidatorKey(nodeKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stake a new node and delegate to it
+	var blsKey bls.SecretKey
+	blsKey.SetByCSPRNG()
+	var blsPub bls.PublicKeyHex
+	blsPub.UnmarshalText([]byte(hex.EncodeToString(blsKey.GetPublicKey().Serialize())))
+	proof, _ := blsKey.MakeSchnorrNIZKP()
+	proofText, _ := proof.MarshalText()
+	var blsProof bls.SchnorrProofHex
+	blsProof.UnmarshalText(proofText)
+
+	node.GetCryptoHandler().SetPrivateKey(nodeKey)
+	var versionSign common.VersionSign
+	versionSign.SetBytes(node.GetCryptoHandler().MustSign(params.GenesisVersion))
+
+	stake := new(big.Int).Mul(big.NewInt(200000), lat)
+	txs := []*types.Transaction{
+		sendPpos(t, sim, stakerKey, cvm.StakingContractAddr, pposInput(t, 1000, uint16(0), benefit, nodeID,
+			"", "simulated", "", "", stake, uint16(5000), params.GenesisVersion, versionSign, blsPub, blsProof)),
+	}
+	sim.Commit()
+	txs = append(txs, sendPpos(t, sim, delegatorKey, cvm.StakingContractAddr, pposInput(t, 1004, uint16(0), nodeID, new(big.Int).Mul(big.NewInt(1000), lat))))
+	sim.Commit()
+	for i, tx := range txs {
+		receipt, _ := sim.TransactionReceipt(context.Background(), tx.Hash())
+		if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("tx %d failed", i)
+		}
+	}
+
+	// The delegation is locked from the next epoch on, its reward can be
+	// queried once that epoch is settled.
+	for i := 0; i < 2; i++ {
+		if err := sim.FastForwardToSettlement(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sim.Commit()
+	var rewards []struct {
+		NodeID discover.NodeID `json:"nodeID"`
+		Reward *hexutil.Big    `json:"reward"`
+	}
+	callPpos(t, sim, cvm.DelegateRewardPoolAddr, pposInput(t, 5100, delegator, []discover.NodeID{}), &rewards)
+	if len(rewards) != 1 || rewards[0].NodeID != nodeID || rewards[0].Reward.ToInt().Sign() <= 0 {
+		t.Fatalf("unexpected delegate rewards %+v", rewards)
+	}
+	if balance, _ := sim.BalanceAt(context.Background(), benefit, nil); balance.Sign() <= 0 {
+		t.Fatalf("no reward paid to the benefit address")
+	}
+}
+
+// Tests that the committed blocks run again by both processors in replay mode
+// agree with their headers, the ppos data being read as of their parent.
+func TestSimulatedPposVerifyParallel(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sim, err := NewSimulatedPposBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.LAT))},
+	}, 100000000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	plan := []restricting.RestrictingPlan{{Epoch: 1, Amount: new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.LAT))}}
+	sendPpos(t, sim, key, cvm.RestrictingContractAddr, pposInput(t, 4000, common.Address{0x01}, plan))
+	sim.Commit()
+	if err := sim.FastForwardToElection(); err != nil {
+		t.Fatal(err)
+	}
+	chain := sim.Blockchain()
+	head := chain.CurrentBlock().NumberU64()
+	core.NewExecutor(chain.Config(), chain, vm.Config{}, nil)
+	sim.ppos.reactor.SetReplay(true)
+	defer sim.ppos.reactor.SetReplay(false)
+
+	for number := uint64(1); number <= head; number++ {
+		result, err := core.VerifyParallel(chain, chain.GetBlockByNumber(number))
+		if err != nil {
+			t.Fatalf("failed to verify block %d: %v", number, err)
+		}
+		if result.Diverged() {
+			t.Fatalf("block %d diverged: %v", number, result.Diffs)
+		}
+	}
+	// The replays leave the snapshotdb alone
+	sim.ppos.reactor.SetReplay(false)
+	sim.Commit()
+	if have := chain.CurrentBlock().NumberU64(); have != head+1 {
+		t.Fatalf("head mismatch: have %d, want %d", have, head+1)
+	}
+}
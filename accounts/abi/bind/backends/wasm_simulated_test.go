@@ -0,0 +1,296 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/bind"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// helloABI describes core/vm/testdata/contract_hello.wasm.
+const helloABI = `[
+	{"baseclass":[],"fields":[{"name":"head","type":"string"}],"name":"message","type":"struct"},
+	{"baseclass":["message"],"fields":[{"name":"body","type":"string"},{"name":"end","type":"string"}],"name":"my_message","type":"struct"},
+	{"constant":false,"input":[],"name":"init","output":"void","type":"Action"},
+	{"constant":false,"input":[{"name":"one_message","type":"my_message"}],"name":"add_message","output":"my_message[]","type":"Action"},
+	{"constant":true,"input":[{"name":"name","type":"string"}],"name":"get_message","output":"my_message[]","type":"Action"},
+	{"constant":true,"input":[],"name":"get_vector_size","output":"uint64","type":"Action"}
+]`
+
+type message struct {
+	Head string
+}
+
+type myMessage struct {
+	Message
+	Body string
+	End  string
+}
+
+// Message is embedded the way generated bindings embed base classes.
+type Message = message
+
+func newWasmTestBackend(t *testing.T) (*SimulatedBackend, *bind.TransactOpts) {
+	key, _ := crypto.GenerateKey()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.GasLimit = 8000000
+	sim := NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 80)}}, 10000000)
+	return sim, auth
+}
+
+func assertReceipt(t *testing.T, sim *SimulatedBackend, tx *types.Transaction) *types.Receipt {
+	t.Helper()
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to get receipt: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("transaction %x failed", tx.Hash())
+	}
+	return receipt
+}
+
+func TestSimulatedWasmContract(t *testing.T) {
+	sim, auth := newWasmTestBackend(t)
+	defer sim.Close()
+
+	code, err := ioutil.ReadFile("../../../../core/vm/testdata/contract_hello.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := wasm.JSON(strings.NewReader(helloABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, tx, contract, err := bind.DeployWasmContract(auth, parsed, code, sim)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	sim.Commit()
+	assertReceipt(t, sim, tx)
+
+	deployed, err := sim.CodeAt(context.Background(), addr, nil)
+	if err != nil || !reflect.DeepEqual(deployed, code) {
+		t.Fatalf("deployed code mismatch (%v)", err)
+	}
+
+	msg := myMessage{message{"Gavin"}, "I am gavin", "finished"}
+	tx, err = contract.Transact(auth, "add_message", msg)
+	if err != nil {
+		t.Fatalf("failed to transact: %v", err)
+	}
+	sim.Commit()
+	assertReceipt(t, sim, tx)
+
+	var size uint64
+	if err := contract.Call(nil, &size, "get_vector_size"); err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if size != 1 {
+		t.Fatalf("vector size mismatch: have %d, want 1", size)
+	}
+	var msgs []myMessage
+	if err := contract.Call(nil, &msgs, "get_message", "Gavin"); err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if !reflect.DeepEqual(msgs, []myMessage{msg}) {
+		t.Fatalf("messages mismatch: have %+v, want %+v", msgs, []myMessage{msg})
+	}
+
+	// A clone runs the same code with its own storage
+	cloneAddr, tx, clone, err := bind.CloneWasmContract(auth, parsed, addr, sim)
+	if err != nil {
+		t.Fatalf("failed to clone contract: %v", err)
+	}
+	sim.Commit()
+	assertReceipt(t, sim, tx)
+
+	if cloneAddr == addr {
+		t.Fatal("clone shares the address of the original contract")
+	}
+	if err := clone.Call(nil, &size, "get_vector_size"); err != nil {
+		t.Fatalf("failed to call clone: %v", err)
+	}
+	if size != 0 {
+		t.Fatalf("clone vector size mismatch: have %d, want 0", size)
+	}
+	if _, _, _, err := bind.CloneWasmContract(auth, parsed, common.HexToAddress("0xdead"), sim); err != bind.ErrNoCode {
+		t.Fatalf("clone of missing contract: have %v, want %v", err, bind.ErrNoCode)
+	}
+}
+
+// notifyABI describes the module built by notifyContract.
+const notifyABI = `[
+	{"baseclass":[],"fields":[{"name":"selector","type":"uint64"},{"name":"msg","type":"string"}],"name":"echo_result","type":"struct"},
+	{"constant":false,"input":[],"name":"init","output":"void","type":"Action"},
+	{"constant":false,"input":[{"name":"msg","type":"string"}],"name":"echo","output":"echo_result","type":"Action"},
+	{"anonymous":false,"input":[{"name":"kind","type":"uint32"},{"name":"selector","type":"uint64"},{"name":"msg","type":"string"}],"name":"Notify","topic":1,"type":"Event"}
+]`
+
+type echoResult struct {
+	Selector uint64
+	Msg      string
+}
+
+type notifyEvent struct {
+	Kind     common.Hash
+	Selector uint64
+	Msg      string
+}
+
+// notifyContract assembles a minimal WASM module whose invoke function emits
+// the given topics via platon_event with the raw call input as data, and then
+// returns the call input as-is:
+//
+//	(func $invoke (local $len i32)
+//	  (local.set $len (call $platon_get_input_length))
+//	  (call $platon_get_input (i32.const 0))
+//	  (call $platon_event (i32.const 1024) (i32.const len(topics)) (i32.const 0) (local.get $len))
+//	  (call $platon_return (i32.const 0) (local.get $len)))
+func notifyContract(topics []byte) []byte {
+	vec := func(items ...[]byte) []byte {
+		out := []byte{byte(len(items))}
+		for _, item := range items {
+			out = append(out, item...)
+		}
+		return out
+	}
+	name := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	section := func(id byte, payload []byte) []byte {
+		return append([]byte{id, byte(len(payload))}, payload...)
+	}
+	imp := func(field string, typ byte) []byte {
+		return append(append(name("env"), name(field)...), 0x00, typ)
+	}
+	body := []byte{
+		0x01, 0x01, 0x7f, // one i32 local
+		0x10, 0x00, 0x21, 0x00, // local.set 0 (call platon_get_input_length)
+		0x41, 0x00, 0x10, 0x01, // call platon_get_input (i32.const 0)
+		0x41, 0x80, 0x08, 0x41, byte(len(topics)), 0x41, 0x00, 0x20, 0x00, 0x10, 0x03, // call platon_event
+		0x41, 0x00, 0x20, 0x00, 0x10, 0x02, // call platon_return
+		0x0b,
+	}
+	data := append([]byte{0x00, 0x41, 0x80, 0x08, 0x0b, byte(len(topics))}, topics...)
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(0x01, vec(
+		[]byte{0x60, 0x00, 0x01, 0x7f},                   // () -> i32
+		[]byte{0x60, 0x01, 0x7f, 0x00},                   // (i32) -> ()
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},             // (i32, i32) -> ()
+		[]byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x00}, // (i32, i32, i32, i32) -> ()
+		[]byte{0x60, 0x00, 0x00},                         // () -> ()
+	))...)
+	module = append(module, section(0x02, vec(
+		imp("platon_get_input_length", 0),
+		imp("platon_get_input", 1),
+		imp("platon_return", 2),
+		imp("platon_event", 3),
+	))...)
+	module = append(module, section(0x03, vec([]byte{0x04}))...)
+	module = append(module, section(0x05, vec([]byte{0x00, 0x01}))...)
+	module = append(module, section(0x07, vec(
+		append(name("invoke"), 0x00, 0x04),
+		append(name("memory"), 0x02, 0x00),
+	))...)
+	module = append(module, section(0x0a, vec(append([]byte{byte(len(body))}, body...)))...)
+	module = append(module, section(0x0b, vec(data))...)
+	return module
+}
+
+func TestSimulatedWasmEvents(t *testing.T) {
+	sim, auth := newWasmTestBackend(t)
+	defer sim.Close()
+
+	parsed, err := wasm.JSON(strings.NewReader(notifyABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kind, err := wasm.MakeTopic(parsed.Events["Notify"].Inputs[0].Type, uint32(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics, _ := rlp.EncodeToBytes([][]byte{[]byte("Notify"), kind.Bytes()[common.HashLength-1:]})
+
+	_, tx, contract, err := bind.DeployWasmContract(auth, parsed, notifyContract(topics), sim)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	sim.Commit()
+	assertReceipt(t, sim, tx)
+
+	var res echoResult
+	if err := contract.Call(nil, &res, "echo", "hello"); err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if want := (echoResult{wasm.FuncID("echo"), "hello"}); res != want {
+		t.Fatalf("echo mismatch: have %+v, want %+v", res, want)
+	}
+
+	for _, msg := range []string{"first", "second"} {
+		tx, err = contract.Transact(auth, "echo", msg)
+		if err != nil {
+			t.Fatalf("failed to transact: %v", err)
+		}
+		sim.Commit()
+		assertReceipt(t, sim, tx)
+	}
+
+	// The deployment emitted an event too, skip it by starting at block 2
+	logs, sub, err := contract.FilterLogs(&bind.FilterOpts{Start: 2}, "Notify", []interface{}{uint32(7)})
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	<-sub.Err()
+	if len(logs) != 2 {
+		t.Fatalf("log count mismatch: have %d, want 2", len(logs))
+	}
+	for _, want := range []string{"first", "second"} {
+		log := <-logs
+		var ev notifyEvent
+		if err := contract.UnpackLog(&ev, "Notify", log); err != nil {
+			t.Fatalf("failed to unpack log: %v", err)
+		}
+		if ev.Kind != kind || ev.Selector != wasm.FuncID("echo") || ev.Msg != want {
+			t.Fatalf("event mismatch: have %+v", ev)
+		}
+	}
+	logs, sub, err = contract.FilterLogs(&bind.FilterOpts{Start: 2}, "Notify", []interface{}{uint32(8)})
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	<-sub.Err()
+	if len(logs) != 0 {
+		t.Fatal("unexpected logs for a mismatching topic")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"fmt"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+)
+
+// WasmBoundContract is the base wrapper object that reflects a WASM contract
+// on the platon network. It mirrors BoundContract, but packs calls with the
+// RLP based WASM call convention instead of the Solidity ABI.
+type WasmBoundContract struct {
+	*BoundContract
+	abi wasm.ABI // Reflect based WASM ABI to access the correct contract methods
+}
+
+// NewWasmBoundContract creates a low level WASM contract interface through
+// which calls and transactions may be made through.
+func NewWasmBoundContract(address common.Address, abi wasm.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *WasmBoundContract {
+	return &WasmBoundContract{
+		BoundContract: NewBoundContract(address, emptyABI, caller, transactor, filterer),
+		abi:           abi,
+	}
+}
+
+// emptyABI is the Solidity ABI of WASM contracts, which have none.
+var emptyABI abi.ABI
+
+// DeployWasmContract deploys a WASM contract onto the platon blockchain and
+// binds the deployment address with a Go wrapper. The params are passed to
+// the init method of the contract.
+func DeployWasmContract(opts *TransactOpts, abi wasm.ABI, code []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *WasmBoundContract, error) {
+	c := NewWasmBoundContract(common.Address{}, abi, backend, backend, backend)
+
+	input, err := c.abi.PackDeploy(code, params...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	tx, err := c.transact(opts, nil, input)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	c.address = crypto.CreateAddress(opts.From, tx.Nonce())
+	return c.address, tx, c, nil
+}
+
+// CloneWasmContract deploys a new instance of the code of an already deployed
+// WASM contract, the same way platon_clone does from within a contract. The
+// clone gets its own storage initialised by the init method with params.
+func CloneWasmContract(opts *TransactOpts, abi wasm.ABI, address common.Address, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *WasmBoundContract, error) {
+	code, err := backend.PendingCodeAt(ensureContext(opts.Context), address)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if len(code) == 0 {
+		return common.Address{}, nil, nil, ErrNoCode
+	}
+	return DeployWasmContract(opts, abi, code, backend, params...)
+}
+
+// ABI returns the WASM ABI the contract is bound with.
+func (c *WasmBoundContract) ABI() wasm.ABI {
+	return c.abi
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// decodes the return value into result, which must be a pointer to a value of
+// the output type or nil for methods returning void.
+func (c *WasmBoundContract) Call(opts *CallOpts, result interface{}, method string, params ...interface{}) error {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	output, err := c.call(opts, input)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return c.abi.Unpack(result, method, output)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (c *WasmBoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(opts, &c.address, input)
+}
+
+// FilterLogs filters contract logs for past blocks, returning the necessary
+// channels to construct a strongly typed bound iterator on top of them. The
+// query holds the filter values of the indexed inputs, in order.
+func (c *WasmBoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	topics, err := c.abi.MakeTopics(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.filterLogs(opts, topics)
+}
+
+// WatchLogs filters subscribes to contract logs for future blocks, returning a
+// subscription object that can be used to tear down the watcher.
+func (c *WasmBoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	topics, err := c.abi.MakeTopics(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.watchLogs(opts, topics)
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *WasmBoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	ev, exist := c.abi.Events[event]
+	if !exist {
+		return fmt.Errorf("event '%s' not found", event)
+	}
+	if !ev.Anonymous && (len(log.Topics) == 0 || log.Topics[0] != wasm.EventID(event)) {
+		return fmt.Errorf("log is not a %s event", event)
+	}
+	return c.abi.UnpackEvent(out, event, log.Topics, log.Data)
+}
+
+// UnpackLogIntoMap is not supported by WASM contracts, their events are
+// always unpacked into the generated structs.
+func (c *WasmBoundContract) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
+	return fmt.Errorf("wasm: unpacking event %s into a map is not supported", event)
+}
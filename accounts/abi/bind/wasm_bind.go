@@ -0,0 +1,260 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+)
+
+// BindWasm generates a Go wrapper around a WASM contract ABI. The bytecodes
+// are the hex encoded WASM modules, they are optional and only needed for
+// the generated deploy methods.
+func BindWasm(types []string, abis []string, bytecodes []string, pkg string, aliases map[string]string) (string, error) {
+	var (
+		contracts = make(map[string]*tmplWasmContract)
+		structs   = make(map[string]*tmplWasmStruct)
+	)
+	for i := 0; i < len(types); i++ {
+		parsed, err := wasm.JSON(strings.NewReader(abis[i]))
+		if err != nil {
+			return "", err
+		}
+		// Strip any whitespace from the JSON ABI
+		strippedABI := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, abis[i])
+
+		for _, s := range parsed.Structs {
+			bound, err := bindWasmStruct(s)
+			if err != nil {
+				return "", err
+			}
+			if prev, exist := structs[bound.Name]; exist && !prev.equal(bound) {
+				return "", fmt.Errorf("conflicting definitions of struct %s", s.Name)
+			}
+			structs[bound.Name] = bound
+		}
+		var (
+			calls     = make(map[string]*tmplWasmMethod)
+			transacts = make(map[string]*tmplWasmMethod)
+			events    = make(map[string]*tmplWasmEvent)
+
+			callIdentifiers     = make(map[string]bool)
+			transactIdentifiers = make(map[string]bool)
+			eventIdentifiers    = make(map[string]bool)
+		)
+		for _, original := range parsed.Methods {
+			identifiers := callIdentifiers
+			if !original.Constant {
+				identifiers = transactIdentifiers
+			}
+			method, err := bindWasmMethod(original, aliases)
+			if err != nil {
+				return "", err
+			}
+			if identifiers[method.Name] {
+				return "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\"), use --alias for renaming", original.Name, method.Name)
+			}
+			identifiers[method.Name] = true
+			if original.Constant {
+				calls[original.Name] = method
+			} else {
+				transacts[original.Name] = method
+			}
+		}
+		for _, original := range parsed.Events {
+			// Skip anonymous events as they don't support explicit filtering
+			if original.Anonymous {
+				continue
+			}
+			ev := &tmplWasmEvent{Original: original, Name: capitalise(alias(aliases, original.Name))}
+			if eventIdentifiers[ev.Name] {
+				return "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\"), use --alias for renaming", original.Name, ev.Name)
+			}
+			eventIdentifiers[ev.Name] = true
+			for j, input := range original.Inputs {
+				arg, err := bindWasmArg(input, j)
+				if err != nil {
+					return "", err
+				}
+				arg.Indexed = j < original.Topics
+				ev.Inputs = append(ev.Inputs, arg)
+			}
+			events[original.Name] = ev
+		}
+		constructor, err := bindWasmMethod(parsed.Constructor, nil)
+		if err != nil {
+			return "", err
+		}
+		var bin string
+		if i < len(bytecodes) {
+			bin = strings.TrimPrefix(strings.TrimSpace(bytecodes[i]), "0x")
+		}
+		contracts[types[i]] = &tmplWasmContract{
+			Type:        capitalise(types[i]),
+			InputABI:    strings.Replace(strippedABI, "\"", "\\\"", -1),
+			InputBin:    bin,
+			Constructor: constructor,
+			Calls:       calls,
+			Transacts:   transacts,
+			Events:      events,
+		}
+	}
+	data := &tmplWasmData{
+		Package:   pkg,
+		Contracts: contracts,
+		Structs:   structs,
+	}
+	buffer := new(bytes.Buffer)
+
+	funcs := map[string]interface{}{
+		"capitalise":   capitalise,
+		"decapitalise": decapitalise,
+	}
+	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSourceWasmGo))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// bindWasmMethod converts a WASM method into its binding representation.
+func bindWasmMethod(original wasm.Method, aliases map[string]string) (*tmplWasmMethod, error) {
+	method := &tmplWasmMethod{Original: original, Name: capitalise(alias(aliases, original.Name))}
+	for j, input := range original.Inputs {
+		arg, err := bindWasmArg(input, j)
+		if err != nil {
+			return nil, err
+		}
+		method.Inputs = append(method.Inputs, arg)
+	}
+	if original.Output != nil {
+		out, err := bindTypeWasmGo(*original.Output)
+		if err != nil {
+			return nil, err
+		}
+		method.Output = out
+	}
+	return method, nil
+}
+
+// bindWasmArg converts a WASM argument into a Go parameter, making sure the
+// name is a valid identifier.
+func bindWasmArg(arg wasm.Argument, index int) (tmplWasmArg, error) {
+	typ, err := bindTypeWasmGo(arg.Type)
+	if err != nil {
+		return tmplWasmArg{}, err
+	}
+	field := capitalise(arg.Name)
+	if field == "" {
+		field = fmt.Sprintf("Arg%d", index)
+	}
+	name := decapitalise(field)
+	if token.IsKeyword(name) || name == "opts" || name == "sink" || name == "source" {
+		name += "_"
+	}
+	return tmplWasmArg{Name: name, Field: field, Type: typ}, nil
+}
+
+// bindWasmStruct converts a user-defined WASM struct into a Go struct, the
+// base classes are embedded in declaration order ahead of the members.
+func bindWasmStruct(s *wasm.Struct) (*tmplWasmStruct, error) {
+	bound := &tmplWasmStruct{Name: capitalise(s.Name)}
+	for _, base := range s.Bases {
+		bound.Bases = append(bound.Bases, capitalise(base.Name))
+	}
+	for j, field := range s.Fields {
+		arg, err := bindWasmArg(field, j)
+		if err != nil {
+			return nil, err
+		}
+		bound.Fields = append(bound.Fields, arg)
+	}
+	return bound, nil
+}
+
+// bindTypeWasmGo converts WASM ABI types to Go ones.
+func bindTypeWasmGo(kind wasm.Type) (string, error) {
+	switch kind.T {
+	case wasm.BoolTy:
+		return "bool", nil
+	case wasm.StringTy:
+		return "string", nil
+	case wasm.UintTy, wasm.IntTy:
+		if kind.IsBig() {
+			return "*big.Int", nil
+		}
+		return kind.GetType().String(), nil
+	case wasm.BytesTy:
+		return "[]byte", nil
+	case wasm.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", kind.Size), nil
+	case wasm.AddressTy:
+		return "common.Address", nil
+	case wasm.SliceTy, wasm.ArrayTy:
+		elem, err := bindTypeWasmGo(*kind.Elem)
+		if err != nil {
+			return "", err
+		}
+		if kind.T == wasm.ArrayTy {
+			return fmt.Sprintf("[%d]%s", kind.Size, elem), nil
+		}
+		return "[]" + elem, nil
+	case wasm.MapTy:
+		key, err := bindTypeWasmGo(*kind.Key)
+		if err != nil {
+			return "", err
+		}
+		switch kind.Key.T {
+		case wasm.SliceTy, wasm.BytesTy, wasm.MapTy:
+			return "", fmt.Errorf("unsupported map key type %s", kind.Key)
+		}
+		elem, err := bindTypeWasmGo(*kind.Elem)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map[%s]%s", key, elem), nil
+	case wasm.PairTy:
+		first, err := bindTypeWasmGo(*kind.Pair[0])
+		if err != nil {
+			return "", err
+		}
+		second, err := bindTypeWasmGo(*kind.Pair[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("struct{ First %s; Second %s }", first, second), nil
+	case wasm.StructTy:
+		return capitalise(kind.Struct.Name), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", kind)
+}
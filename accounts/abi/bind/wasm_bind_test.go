@@ -0,0 +1,112 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+var bindWasmTests = []struct {
+	name     string
+	abi      string
+	bin      string
+	expected []string
+}{
+	{
+		`Hello`,
+		`[
+			{"baseclass":[],"fields":[{"name":"head","type":"string"}],"name":"message","type":"struct"},
+			{"baseclass":["message"],"fields":[{"name":"body","type":"string"},{"name":"end","type":"string"}],"name":"my_message","type":"struct"},
+			{"constant":false,"input":[],"name":"init","output":"void","type":"Action"},
+			{"constant":false,"input":[{"name":"one_message","type":"my_message"}],"name":"add_message","output":"my_message[]","type":"Action"},
+			{"constant":true,"input":[{"name":"name","type":"string"}],"name":"get_message","output":"my_message[]","type":"Action"},
+			{"constant":true,"input":[],"name":"get_vector_size","output":"uint64","type":"Action"}
+		]`,
+		`0x0061736d01000000`,
+		[]string{
+			"type MyMessage struct {\n\tMessage\n\tBody string\n\tEnd  string\n}",
+			"func DeployHello(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Hello, error)",
+			"func CloneHello(auth *bind.TransactOpts, backend bind.ContractBackend, source common.Address) (common.Address, *types.Transaction, *Hello, error)",
+			"func (_Hello *HelloTransactor) AddMessage(opts *bind.TransactOpts, oneMessage MyMessage) (*types.Transaction, error)",
+			"func (_Hello *HelloCaller) GetMessage(opts *bind.CallOpts, name string) ([]MyMessage, error)",
+			"func (_Hello *HelloCallerSession) GetVectorSize() (uint64, error)",
+		},
+	},
+	{
+		`Token`,
+		`[
+			{"constant":false,"input":[{"name":"symbol","type":"string"},{"name":"supply","type":"uint128"}],"name":"init","output":"void","type":"Action"},
+			{"constant":false,"input":[{"name":"to","type":"Address"},{"name":"amount","type":"uint128"},{"name":"type","type":"int8"}],"name":"transfer","output":"bool","type":"Action"},
+			{"constant":true,"input":[{"name":"owners","type":"list<Address>"}],"name":"balances","output":"map<Address,uint128>","type":"Action"},
+			{"constant":true,"input":[],"name":"pairs","output":"pair<string,FixedHash<32>>[2]","type":"Action"},
+			{"constant":false,"input":[],"name":"reset","output":"void","type":"Action"},
+			{"anonymous":false,"input":[{"name":"from","type":"Address"},{"name":"to","type":"Address"},{"name":"amount","type":"uint128"}],"name":"Transfer","topic":2,"type":"Event"}
+		]`,
+		``,
+		[]string{
+			"func CloneToken(auth *bind.TransactOpts, backend bind.ContractBackend, source common.Address, symbol string, supply *big.Int)",
+			"func (_Token *TokenTransactor) Transfer(opts *bind.TransactOpts, to common.Address, amount *big.Int, type_ int8) (*types.Transaction, error)",
+			"func (_Token *TokenCaller) Balances(opts *bind.CallOpts, owners []common.Address) (map[common.Address]*big.Int, error)",
+			"func (_Token *TokenCaller) Pairs(opts *bind.CallOpts) ([2]struct {",
+			"func (_Token *TokenTransactor) Reset(opts *bind.TransactOpts) (*types.Transaction, error)",
+			"type TokenTransfer struct {\n\tFrom   common.Hash\n\tTo     common.Hash\n\tAmount *big.Int\n\tRaw    types.Log",
+			"func (_Token *TokenFilterer) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address) (*TokenTransferIterator, error)",
+			"func (_Token *TokenFilterer) WatchTransfer(opts *bind.WatchOpts, sink chan<- *TokenTransfer, from []common.Address, to []common.Address) (event.Subscription, error)",
+			"func (_Token *TokenFilterer) ParseTransfer(log types.Log) (*TokenTransfer, error)",
+		},
+	},
+}
+
+func TestBindWasm(t *testing.T) {
+	for i, tt := range bindWasmTests {
+		code, err := BindWasm([]string{tt.name}, []string{tt.abi}, []string{tt.bin}, "bindtest", nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "", code, 0); err != nil {
+			t.Fatalf("test %d: generated binding does not parse: %v", i, err)
+		}
+		for _, want := range tt.expected {
+			if !strings.Contains(code, want) {
+				t.Errorf("test %d: binding misses %q", i, want)
+			}
+		}
+		if hasDeploy := strings.Contains(code, "func Deploy"+tt.name); hasDeploy != (tt.bin != "") {
+			t.Errorf("test %d: deploy method presence mismatch: have %v", i, hasDeploy)
+		}
+	}
+}
+
+func TestBindWasmErrors(t *testing.T) {
+	for i, abi := range []string{
+		`[{"constant":true,"input":[],"name":"get","output":"map<uint8[],bool>","type":"Action"}]`,
+		`[{"constant":true,"input":[],"name":"get","output":"uint8","type":"Action"},{"constant":true,"input":[],"name":"Get","output":"uint8","type":"Action"}]`,
+		`[{"input":[],"name":"get","type":"function"}]`,
+	} {
+		if _, err := BindWasm([]string{"Test"}, []string{abi}, nil, "bindtest", nil); err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+	// Aliases resolve identifier collisions
+	abi := `[{"constant":true,"input":[],"name":"get","output":"uint8","type":"Action"},{"constant":true,"input":[],"name":"Get","output":"uint8","type":"Action"}]`
+	if _, err := BindWasm([]string{"Test"}, []string{abi}, nil, "bindtest", map[string]string{"Get": "Get2"}); err != nil {
+		t.Errorf("alias failed: %v", err)
+	}
+}
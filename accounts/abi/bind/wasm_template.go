@@ -0,0 +1,450 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import "github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+
+// tmplWasmData is the data structure required to fill the WASM binding template.
+type tmplWasmData struct {
+	Package   string                       // Name of the package to place the generated file in
+	Contracts map[string]*tmplWasmContract // List of contracts to generate into this file
+	Structs   map[string]*tmplWasmStruct   // Contract struct type definitions
+}
+
+// tmplWasmContract contains the data needed to generate an individual WASM contract binding.
+type tmplWasmContract struct {
+	Type        string                     // Type name of the main contract binding
+	InputABI    string                     // JSON ABI used as the input to generate the binding from
+	InputBin    string                     // Optional WASM code used to generate deploy code from
+	Constructor *tmplWasmMethod            // Contract init method for deploy parametrization
+	Calls       map[string]*tmplWasmMethod // Contract calls that only read state data
+	Transacts   map[string]*tmplWasmMethod // Contract calls that write state data
+	Events      map[string]*tmplWasmEvent  // Contract events accessors
+}
+
+// tmplWasmMethod is a wrapper around a wasm.Method with the Go names and
+// types of its inputs and output.
+type tmplWasmMethod struct {
+	Original wasm.Method   // Original method as parsed by the wasm package
+	Name     string        // Normalized method name
+	Inputs   []tmplWasmArg // Normalized method inputs
+	Output   string        // Go type of the return value, empty for void
+}
+
+// tmplWasmEvent is a wrapper around a wasm.Event with the Go names and types
+// of its inputs.
+type tmplWasmEvent struct {
+	Original wasm.Event    // Original event as parsed by the wasm package
+	Name     string        // Normalized event name
+	Inputs   []tmplWasmArg // Normalized event inputs
+}
+
+// tmplWasmArg is a method input, event input or struct member.
+type tmplWasmArg struct {
+	Name    string // Parameter name, a valid Go identifier
+	Field   string // Exported struct field name
+	Type    string // Go type representation
+	Indexed bool   // Whether the event input is stored as a topic
+}
+
+// tmplWasmStruct is a user-defined struct of a WASM contract.
+type tmplWasmStruct struct {
+	Name   string        // Go type name of the struct
+	Bases  []string      // Embedded base classes, in declaration order
+	Fields []tmplWasmArg // Struct members
+}
+
+// equal reports whether two struct definitions bind to the same Go type.
+func (s *tmplWasmStruct) equal(other *tmplWasmStruct) bool {
+	if s.Name != other.Name || len(s.Bases) != len(other.Bases) || len(s.Fields) != len(other.Fields) {
+		return false
+	}
+	for i := range s.Bases {
+		if s.Bases[i] != other.Bases[i] {
+			return false
+		}
+	}
+	for i := range s.Fields {
+		if s.Fields[i].Field != other.Fields[i].Field || s.Fields[i].Type != other.Fields[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// tmplSourceWasmGo is the Go source template that the generated Go binding of
+// a WASM contract is based on.
+const tmplSourceWasmGo = `
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"math/big"
+	"strings"
+
+	platon "github.com/PlatONnetwork/PlatON-Go"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/bind"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = platon.NotFound
+	_ = bind.BindWasm
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+{{range .Structs}}
+	// {{.Name}} is an auto generated low-level Go binding around an user-defined struct.
+	type {{.Name}} struct { {{range .Bases}}
+	{{.}}{{end}}{{range .Fields}}
+	{{.Field}} {{.Type}}{{end}}
+	}
+{{end}}
+
+{{range $contract := .Contracts}}
+	// {{.Type}}ABI is the input ABI used to generate the binding from.
+	const {{.Type}}ABI = "{{.InputABI}}"
+
+	{{if .InputBin}}
+		// {{.Type}}Bin is the compiled WASM code used for deploying new contracts.
+		const {{.Type}}Bin = "0x{{.InputBin}}"
+
+		// Deploy{{.Type}} deploys a new WASM contract, binding an instance of {{.Type}} to it.
+		func Deploy{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend {{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Address, *types.Transaction, *{{.Type}}, error) {
+		  parsed, err := wasm.JSON(strings.NewReader({{.Type}}ABI))
+		  if err != nil {
+		    return common.Address{}, nil, nil, err
+		  }
+		  address, tx, contract, err := bind.DeployWasmContract(auth, parsed, common.FromHex({{.Type}}Bin), backend {{range .Constructor.Inputs}}, {{.Name}}{{end}})
+		  if err != nil {
+		    return common.Address{}, nil, nil, err
+		  }
+		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+		}
+	{{end}}
+
+	// Clone{{.Type}} deploys a new instance of the code of the {{.Type}} contract at
+	// the source address with its own storage, binding an instance of {{.Type}} to it.
+	func Clone{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend, source common.Address {{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Address, *types.Transaction, *{{.Type}}, error) {
+	  parsed, err := wasm.JSON(strings.NewReader({{.Type}}ABI))
+	  if err != nil {
+	    return common.Address{}, nil, nil, err
+	  }
+	  address, tx, contract, err := bind.CloneWasmContract(auth, parsed, source, backend {{range .Constructor.Inputs}}, {{.Name}}{{end}})
+	  if err != nil {
+	    return common.Address{}, nil, nil, err
+	  }
+	  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+	}
+
+	// {{.Type}} is an auto generated Go binding around a WASM contract.
+	type {{.Type}} struct {
+	  {{.Type}}Caller     // Read-only binding to the contract
+	  {{.Type}}Transactor // Write-only binding to the contract
+	  {{.Type}}Filterer   // Log filterer for contract events
+	}
+
+	// {{.Type}}Caller is an auto generated read-only Go binding around a WASM contract.
+	type {{.Type}}Caller struct {
+	  contract *bind.WasmBoundContract // Generic contract wrapper for the low level calls
+	}
+
+	// {{.Type}}Transactor is an auto generated write-only Go binding around a WASM contract.
+	type {{.Type}}Transactor struct {
+	  contract *bind.WasmBoundContract // Generic contract wrapper for the low level calls
+	}
+
+	// {{.Type}}Filterer is an auto generated log filtering Go binding around a WASM contract's events.
+	type {{.Type}}Filterer struct {
+	  contract *bind.WasmBoundContract // Generic contract wrapper for the low level calls
+	}
+
+	// {{.Type}}Session is an auto generated Go binding around a WASM contract,
+	// with pre-set call and transact options.
+	type {{.Type}}Session struct {
+	  Contract     *{{.Type}}        // Generic contract binding to set the session for
+	  CallOpts     bind.CallOpts     // Call options to use throughout this session
+	  TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+	}
+
+	// {{.Type}}CallerSession is an auto generated read-only Go binding around a WASM contract,
+	// with pre-set call options.
+	type {{.Type}}CallerSession struct {
+	  Contract *{{.Type}}Caller // Generic contract caller binding to set the session for
+	  CallOpts bind.CallOpts    // Call options to use throughout this session
+	}
+
+	// {{.Type}}TransactorSession is an auto generated write-only Go binding around a WASM contract,
+	// with pre-set transact options.
+	type {{.Type}}TransactorSession struct {
+	  Contract     *{{.Type}}Transactor // Generic contract transactor binding to set the session for
+	  TransactOpts bind.TransactOpts    // Transaction auth options to use throughout this session
+	}
+
+	// {{.Type}}Raw is an auto generated low-level Go binding around a WASM contract.
+	type {{.Type}}Raw struct {
+	  Contract *{{.Type}} // Generic contract binding to access the raw methods on
+	}
+
+	// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	  contract, err := bind{{.Type}}(address, backend, backend, backend)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+	}
+
+	// New{{.Type}}Caller creates a new read-only instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}Caller(address common.Address, caller bind.ContractCaller) (*{{.Type}}Caller, error) {
+	  contract, err := bind{{.Type}}(address, caller, nil, nil)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}Caller{contract: contract}, nil
+	}
+
+	// New{{.Type}}Transactor creates a new write-only instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}Transactor(address common.Address, transactor bind.ContractTransactor) (*{{.Type}}Transactor, error) {
+	  contract, err := bind{{.Type}}(address, nil, transactor, nil)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}Transactor{contract: contract}, nil
+	}
+
+	// New{{.Type}}Filterer creates a new log filterer instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}Filterer(address common.Address, filterer bind.ContractFilterer) (*{{.Type}}Filterer, error) {
+	  contract, err := bind{{.Type}}(address, nil, nil, filterer)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}Filterer{contract: contract}, nil
+	}
+
+	// bind{{.Type}} binds a generic wrapper to an already deployed contract.
+	func bind{{.Type}}(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.WasmBoundContract, error) {
+	  parsed, err := wasm.JSON(strings.NewReader({{.Type}}ABI))
+	  if err != nil {
+	    return nil, err
+	  }
+	  return bind.NewWasmBoundContract(address, parsed, caller, transactor, filterer), nil
+	}
+
+	// Call invokes the (constant) contract method with params as input values and
+	// decodes the return value into result.
+	func (_{{$contract.Type}} *{{$contract.Type}}Raw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+		return _{{$contract.Type}}.Contract.{{$contract.Type}}Caller.contract.Call(opts, result, method, params...)
+	}
+
+	// Transfer initiates a plain transaction to move funds to the contract.
+	func (_{{$contract.Type}} *{{$contract.Type}}Raw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return _{{$contract.Type}}.Contract.{{$contract.Type}}Transactor.contract.Transfer(opts)
+	}
+
+	// Transact invokes the (paid) contract method with params as input values.
+	func (_{{$contract.Type}} *{{$contract.Type}}Raw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+		return _{{$contract.Type}}.Contract.{{$contract.Type}}Transactor.contract.Transact(opts, method, params...)
+	}
+
+	{{range .Calls}}
+		// {{.Name}} is a free data retrieval call binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}Caller) {{.Name}}(opts *bind.CallOpts {{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{if .Output}}{{.Output}}, {{end}}error) {
+			{{if .Output}}var out {{.Output}}
+			err := _{{$contract.Type}}.contract.Call(opts, &out, "{{.Original.Name}}" {{range .Inputs}}, {{.Name}}{{end}})
+			return out, err
+			{{else}}return _{{$contract.Type}}.contract.Call(opts, nil, "{{.Original.Name}}" {{range .Inputs}}, {{.Name}}{{end}})
+			{{end}}
+		}
+
+		// {{.Name}} is a free data retrieval call binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}Session) {{.Name}}({{range $i, $_ := .Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{.Type}}{{end}}) ({{if .Output}}{{.Output}}, {{end}}error) {
+		  return _{{$contract.Type}}.Contract.{{.Name}}(&_{{$contract.Type}}.CallOpts {{range .Inputs}}, {{.Name}}{{end}})
+		}
+
+		// {{.Name}} is a free data retrieval call binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}CallerSession) {{.Name}}({{range $i, $_ := .Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{.Type}}{{end}}) ({{if .Output}}{{.Output}}, {{end}}error) {
+		  return _{{$contract.Type}}.Contract.{{.Name}}(&_{{$contract.Type}}.CallOpts {{range .Inputs}}, {{.Name}}{{end}})
+		}
+	{{end}}
+
+	{{range .Transacts}}
+		// {{.Name}} is a paid mutator transaction binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}Transactor) {{.Name}}(opts *bind.TransactOpts {{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*types.Transaction, error) {
+			return _{{$contract.Type}}.contract.Transact(opts, "{{.Original.Name}}" {{range .Inputs}}, {{.Name}}{{end}})
+		}
+
+		// {{.Name}} is a paid mutator transaction binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}Session) {{.Name}}({{range $i, $_ := .Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{.Type}}{{end}}) (*types.Transaction, error) {
+		  return _{{$contract.Type}}.Contract.{{.Name}}(&_{{$contract.Type}}.TransactOpts {{range .Inputs}}, {{.Name}}{{end}})
+		}
+
+		// {{.Name}} is a paid mutator transaction binding the contract method {{.Original.Name}} (0x{{printf "%x" .Original.ID}}).
+		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Name}}({{range $i, $_ := .Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{.Type}}{{end}}) (*types.Transaction, error) {
+		  return _{{$contract.Type}}.Contract.{{.Name}}(&_{{$contract.Type}}.TransactOpts {{range .Inputs}}, {{.Name}}{{end}})
+		}
+	{{end}}
+
+	{{range .Events}}
+		// {{$contract.Type}}{{.Name}}Iterator is returned from Filter{{.Name}} and is used to iterate over the raw logs and unpacked data for {{.Name}} events raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Name}}Iterator struct {
+			Event *{{$contract.Type}}{{.Name}} // Event containing the contract specifics and raw log
+
+			contract *bind.WasmBoundContract // Generic contract to use for unpacking event data
+			event    string                  // Event name to use for unpacking event data
+
+			logs chan types.Log      // Log channel receiving the found contract events
+			sub  platon.Subscription // Subscription for errors, completion and termination
+			done bool                // Whether the subscription completed delivering logs
+			fail error               // Occurred error to stop iteration
+		}
+		// Next advances the iterator to the subsequent event, returning whether there
+		// are any more events found. In case of a retrieval or parsing error, false is
+		// returned and Error() can be queried for the exact failure.
+		func (it *{{$contract.Type}}{{.Name}}Iterator) Next() bool {
+			// If the iterator failed, stop iterating
+			if (it.fail != nil) {
+				return false
+			}
+			// If the iterator completed, deliver directly whatever's available
+			if (it.done) {
+				select {
+				case log := <-it.logs:
+					it.Event = new({{$contract.Type}}{{.Name}})
+					if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+						it.fail = err
+						return false
+					}
+					it.Event.Raw = log
+					return true
+
+				default:
+					return false
+				}
+			}
+			// Iterator still in progress, wait for either a data or an error event
+			select {
+			case log := <-it.logs:
+				it.Event = new({{$contract.Type}}{{.Name}})
+				if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+					it.fail = err
+					return false
+				}
+				it.Event.Raw = log
+				return true
+
+			case err := <-it.sub.Err():
+				it.done = true
+				it.fail = err
+				return it.Next()
+			}
+		}
+		// Error returns any retrieval or parsing error occurred during filtering.
+		func (it *{{$contract.Type}}{{.Name}}Iterator) Error() error {
+			return it.fail
+		}
+		// Close terminates the iteration process, releasing any pending underlying
+		// resources.
+		func (it *{{$contract.Type}}{{.Name}}Iterator) Close() error {
+			it.sub.Unsubscribe()
+			return nil
+		}
+
+		// {{$contract.Type}}{{.Name}} represents a {{.Name}} event raised by the {{$contract.Type}} contract.
+		// Indexed inputs are only available as the topics they are stored as.
+		type {{$contract.Type}}{{.Name}} struct { {{range .Inputs}}
+			{{.Field}} {{if .Indexed}}common.Hash{{else}}{{.Type}}{{end}}; {{end}}
+			Raw types.Log // Blockchain specific contextual infos
+		}
+
+		// Filter{{.Name}} is a free log retrieval operation binding the contract event {{.Original.Name}}.
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Filter{{.Name}}(opts *bind.FilterOpts{{range .Inputs}}{{if .Indexed}}, {{.Name}} []{{.Type}}{{end}}{{end}}) (*{{$contract.Type}}{{.Name}}Iterator, error) {
+			{{range .Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.FilterLogs(opts, "{{.Original.Name}}"{{range .Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return &{{$contract.Type}}{{.Name}}Iterator{contract: _{{$contract.Type}}.contract, event: "{{.Original.Name}}", logs: logs, sub: sub}, nil
+		}
+
+		// Watch{{.Name}} is a free log subscription operation binding the contract event {{.Original.Name}}.
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Watch{{.Name}}(opts *bind.WatchOpts, sink chan<- *{{$contract.Type}}{{.Name}}{{range .Inputs}}{{if .Indexed}}, {{.Name}} []{{.Type}}{{end}}{{end}}) (event.Subscription, error) {
+			{{range .Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.WatchLogs(opts, "{{.Original.Name}}"{{range .Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						// New log arrived, parse the event and forward to the user
+						event := new({{$contract.Type}}{{.Name}})
+						if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+							return err
+						}
+						event.Raw = log
+
+						select {
+						case sink <- event:
+						case err := <-sub.Err():
+							return err
+						case <-quit:
+							return nil
+						}
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			}), nil
+		}
+
+		// Parse{{.Name}} is a log parse operation binding the contract event {{.Original.Name}}.
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Parse{{.Name}}(log types.Log) (*{{$contract.Type}}{{.Name}}, error) {
+			event := new({{$contract.Type}}{{.Name}})
+			if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+				return nil, err
+			}
+			event.Raw = log
+			return event, nil
+		}
+	{{end}}
+{{end}}
+`
@@ -0,0 +1,373 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package wasm implements the PlatON WASM contract ABI.
+//
+// WASM contracts do not use the Solidity ABI. A call is the RLP list of the
+// FNV-1 64 hash of the function name followed by the RLP encoded arguments,
+// a deployment prefixes the RLP list of the code and the init call with the
+// WASM magic number, and return values and event data are plain RLP.
+package wasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// InitMethod is the name of the constructor of a WASM contract.
+const InitMethod = "init"
+
+// Magic is the prefix of the deployment data of WASM contracts.
+var Magic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// Argument holds the name and the type of a method input, an event field
+// or a struct member.
+type Argument struct {
+	Name string
+	Type Type
+}
+
+// Method represents a callable exported by a WASM contract.
+type Method struct {
+	Name     string
+	Constant bool
+	Inputs   []Argument
+	Output   *Type // nil if the method returns void
+}
+
+// ID returns the 64 bit function identifier the contract dispatches on.
+func (m Method) ID() uint64 {
+	return FuncID(m.Name)
+}
+
+// Event is an event emitted through platon_event. The first Topics inputs
+// are indexed, the event name is always the first topic.
+type Event struct {
+	Name      string
+	Anonymous bool
+	Topics    int
+	Inputs    []Argument
+}
+
+// Struct is a user-defined type declared by a WASM contract ABI.
+type Struct struct {
+	Name   string
+	Bases  []*Struct
+	Fields []Argument
+}
+
+// ABI holds information about a WASM contract's context and available
+// invokable methods.
+type ABI struct {
+	Constructor Method
+	Methods     map[string]Method
+	Events      map[string]Event
+	Structs     map[string]*Struct
+}
+
+// FuncID returns the identifier of the function name.
+func FuncID(name string) uint64 {
+	h := fnv.New64()
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+type field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type entry struct {
+	Type      string   `json:"type"`
+	Name      string   `json:"name"`
+	Constant  bool     `json:"constant"`
+	Anonymous bool     `json:"anonymous"`
+	Topic     int      `json:"topic"`
+	Input     []field  `json:"input"`
+	Output    string   `json:"output"`
+	Baseclass []string `json:"baseclass"`
+	Fields    []field  `json:"fields"`
+}
+
+// JSON returns a parsed WASM contract ABI interface.
+func JSON(reader io.Reader) (ABI, error) {
+	dec := json.NewDecoder(reader)
+
+	var abi ABI
+	if err := dec.Decode(&abi); err != nil {
+		return ABI{}, err
+	}
+	return abi, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (abi *ABI) UnmarshalJSON(data []byte) error {
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	abi.Methods = make(map[string]Method)
+	abi.Events = make(map[string]Event)
+	abi.Structs = make(map[string]*Struct)
+
+	// Declare all structs first, members and bases may refer to structs
+	// declared further down.
+	for _, e := range entries {
+		if e.Type == "struct" {
+			if _, exist := abi.Structs[e.Name]; exist {
+				return fmt.Errorf("duplicate struct %s", e.Name)
+			}
+			abi.Structs[e.Name] = &Struct{Name: e.Name}
+		}
+	}
+	parseArgs := func(fields []field) ([]Argument, error) {
+		args := make([]Argument, len(fields))
+		for i, f := range fields {
+			typ, err := NewType(f.Type, abi.Structs)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = Argument{Name: f.Name, Type: typ}
+		}
+		return args, nil
+	}
+	for _, e := range entries {
+		switch e.Type {
+		case "struct":
+			s := abi.Structs[e.Name]
+			for _, base := range e.Baseclass {
+				b, ok := abi.Structs[base]
+				if !ok {
+					return fmt.Errorf("unknown base class %s of struct %s", base, e.Name)
+				}
+				s.Bases = append(s.Bases, b)
+			}
+			fields, err := parseArgs(e.Fields)
+			if err != nil {
+				return err
+			}
+			s.Fields = fields
+
+		case "Action":
+			inputs, err := parseArgs(e.Input)
+			if err != nil {
+				return err
+			}
+			method := Method{Name: e.Name, Constant: e.Constant, Inputs: inputs}
+			if e.Output != "" && e.Output != "void" {
+				out, err := NewType(e.Output, abi.Structs)
+				if err != nil {
+					return err
+				}
+				method.Output = &out
+			}
+			if e.Name == InitMethod {
+				abi.Constructor = method
+				continue
+			}
+			abi.Methods[e.Name] = method
+
+		case "Event":
+			inputs, err := parseArgs(e.Input)
+			if err != nil {
+				return err
+			}
+			if e.Topic < 0 || e.Topic > len(inputs) {
+				return fmt.Errorf("invalid topic count %d of event %s", e.Topic, e.Name)
+			}
+			abi.Events[e.Name] = Event{Name: e.Name, Anonymous: e.Anonymous, Topics: e.Topic, Inputs: inputs}
+
+		default:
+			return fmt.Errorf("unsupported abi entry type %q", e.Type)
+		}
+	}
+	if abi.Constructor.Name == "" {
+		abi.Constructor = Method{Name: InitMethod}
+	}
+	return checkCycles(abi.Structs)
+}
+
+// checkCycles rejects structs that contain themselves, those can never be
+// encoded.
+func checkCycles(structs map[string]*Struct) error {
+	var visit func(s *Struct, path map[*Struct]bool) error
+	visit = func(s *Struct, path map[*Struct]bool) error {
+		if path[s] {
+			return fmt.Errorf("struct %s contains itself", s.Name)
+		}
+		path[s] = true
+		defer delete(path, s)
+
+		for _, base := range s.Bases {
+			if err := visit(base, path); err != nil {
+				return err
+			}
+		}
+		for _, f := range s.Fields {
+			if f.Type.T == StructTy {
+				if err := visit(f.Type.Struct, path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, s := range structs {
+		if err := visit(s, make(map[*Struct]bool)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pack packs the call of the given method. Use Constructor.Name or an empty
+// name to pack the init call.
+func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
+	method := abi.Constructor
+	if name != "" && name != InitMethod {
+		m, exist := abi.Methods[name]
+		if !exist {
+			return nil, fmt.Errorf("method '%s' not found", name)
+		}
+		method = m
+	}
+	if len(args) != len(method.Inputs) {
+		return nil, fmt.Errorf("argument count mismatch: %d for %d", len(args), len(method.Inputs))
+	}
+	list := make([]interface{}, 0, len(args)+1)
+	list = append(list, method.ID())
+	for i, arg := range method.Inputs {
+		v, err := encodeValue(arg.Type, reflect.ValueOf(args[i]))
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", arg.Name, err)
+		}
+		list = append(list, v)
+	}
+	return rlp.EncodeToBytes(list)
+}
+
+// PackDeploy packs the deployment of the given WASM code with the given
+// init arguments.
+func (abi ABI) PackDeploy(code []byte, args ...interface{}) ([]byte, error) {
+	if !bytes.HasPrefix(code, Magic) {
+		return nil, errors.New("invalid wasm code")
+	}
+	input, err := abi.Pack(InitMethod, args...)
+	if err != nil {
+		return nil, err
+	}
+	// The layout matches the creation data platon_clone builds from an
+	// existing contract, so clones are deployed the very same way.
+	data, err := rlp.EncodeToBytes(struct {
+		Code     []byte
+		InitArgs []byte
+	}{code, input})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, Magic...), data...), nil
+}
+
+// Unpack decodes the return value of the given method into v, which must be
+// a pointer to a value of the output type.
+func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
+	method, exist := abi.Methods[name]
+	if !exist {
+		return fmt.Errorf("method '%s' not found", name)
+	}
+	if method.Output == nil {
+		return fmt.Errorf("method '%s' has no return value", name)
+	}
+	if len(output) == 0 {
+		return errors.New("wasm: attempting to unmarshall an empty string while arguments are expected")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("wasm: Unpack(non-pointer)")
+	}
+	return decodeValue(*method.Output, rlp.NewStream(bytes.NewReader(output), uint64(len(output))), rv.Elem())
+}
+
+// UnpackEvent decodes the data of the given event into v, which must be a
+// pointer to a struct. Indexed inputs can not be recovered from the data,
+// the topics are stored as-is into the matching fields if they are of type
+// common.Hash.
+func (abi ABI) UnpackEvent(v interface{}, name string, topics []common.Hash, data []byte) error {
+	event, exist := abi.Events[name]
+	if !exist {
+		return fmt.Errorf("event '%s' not found", name)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("wasm: UnpackEvent requires a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	offset := 1
+	if event.Anonymous {
+		offset = 0
+	}
+	if len(topics) != event.Topics+offset {
+		return fmt.Errorf("wasm: event %s requires %d topics, got %d", name, event.Topics+offset, len(topics))
+	}
+	for i, arg := range event.Inputs[:event.Topics] {
+		f, err := eventField(rv, arg.Name, i)
+		if err != nil {
+			return err
+		}
+		if f.Type() != reflect.TypeOf(common.Hash{}) {
+			return fmt.Errorf("wasm: indexed field %s must be a hash", arg.Name)
+		}
+		f.Set(reflect.ValueOf(topics[i+offset]))
+	}
+	s := rlp.NewStream(bytes.NewReader(data), uint64(len(data)))
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	for i, arg := range event.Inputs[event.Topics:] {
+		f, err := eventField(rv, arg.Name, event.Topics+i)
+		if err != nil {
+			return err
+		}
+		if err := decodeValue(arg.Type, s, f); err != nil {
+			return fmt.Errorf("%s: %v", arg.Name, err)
+		}
+	}
+	return s.ListEnd()
+}
+
+// eventField looks up the struct field an event input is unpacked into,
+// unnamed inputs are unpacked into ArgN.
+func eventField(v reflect.Value, name string, index int) (reflect.Value, error) {
+	field := abi.ToCamelCase(name)
+	if field == "" {
+		field = fmt.Sprintf("Arg%d", index)
+	}
+	f := v.FieldByName(field)
+	if !f.IsValid() {
+		return reflect.Value{}, fmt.Errorf("wasm: field %s can't be found in the given value", field)
+	}
+	return f, nil
+}
@@ -0,0 +1,324 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// helloABI describes core/vm/testdata/contract_hello.wasm.
+const helloABI = `[
+	{"baseclass":[],"fields":[{"name":"head","type":"string"}],"name":"message","type":"struct"},
+	{"baseclass":["message"],"fields":[{"name":"body","type":"string"},{"name":"end","type":"string"}],"name":"my_message","type":"struct"},
+	{"constant":false,"input":[],"name":"init","output":"void","type":"Action"},
+	{"constant":false,"input":[{"name":"one_message","type":"my_message"}],"name":"add_message","output":"my_message[]","type":"Action"},
+	{"constant":true,"input":[{"name":"name","type":"string"}],"name":"get_message","output":"my_message[]","type":"Action"},
+	{"constant":true,"input":[],"name":"get_vector_size","output":"uint64","type":"Action"},
+	{"anonymous":false,"input":[{"name":"from","type":"Address"},{"name":"memo","type":"string"},{"name":"amount","type":"int64"}],"name":"Transfer","topic":2,"type":"Event"}
+]`
+
+type message struct {
+	Head string
+}
+
+type myMessage struct {
+	Message message
+	Body    string
+	End     string
+}
+
+func TestParseABI(t *testing.T) {
+	abi, err := JSON(strings.NewReader(helloABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abi.Methods) != 3 || len(abi.Events) != 1 || len(abi.Structs) != 2 {
+		t.Fatalf("unexpected abi content: %d methods, %d events, %d structs", len(abi.Methods), len(abi.Events), len(abi.Structs))
+	}
+	if abi.Constructor.Name != InitMethod {
+		t.Errorf("constructor mismatch: have %q", abi.Constructor.Name)
+	}
+	if m := abi.Methods["get_vector_size"]; !m.Constant || m.Output == nil || m.Output.T != UintTy || m.Output.Size != 64 {
+		t.Errorf("get_vector_size mismatch: %+v", m)
+	}
+	if m := abi.Methods["add_message"]; m.Output.T != SliceTy || m.Output.Elem.Struct != abi.Structs["my_message"] {
+		t.Errorf("add_message output mismatch: %v", m.Output)
+	}
+	if s := abi.Structs["my_message"]; len(s.Bases) != 1 || s.Bases[0] != abi.Structs["message"] {
+		t.Errorf("my_message bases mismatch: %+v", s.Bases)
+	}
+}
+
+func TestParseABIErrors(t *testing.T) {
+	for i, tt := range []string{
+		`[{"input":[{"name":"a","type":"uint7"}],"name":"f","type":"Action"}]`,
+		`[{"input":[{"name":"a","type":"unknown"}],"name":"f","type":"Action"}]`,
+		`[{"baseclass":["missing"],"fields":[],"name":"s","type":"struct"}]`,
+		`[{"baseclass":[],"fields":[{"name":"s","type":"s"}],"name":"s","type":"struct"}]`,
+		`[{"input":[],"name":"e","topic":1,"type":"Event"}]`,
+		`[{"name":"f","type":"function"}]`,
+	} {
+		if _, err := JSON(strings.NewReader(tt)); err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+}
+
+// TestPackCompatibility checks that calls and deployments are encoded exactly
+// the way the wagon engine tests build them.
+func TestPackCompatibility(t *testing.T) {
+	abi, err := JSON(strings.NewReader(helloABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type M struct {
+		Head string
+	}
+	type Message struct {
+		M
+		Body string
+		End  string
+	}
+	hash := fnv.New64()
+	hash.Write([]byte("add_message"))
+	want, _ := rlp.EncodeToBytes(struct {
+		FuncName uint64
+		Msg      Message
+	}{hash.Sum64(), Message{M{"Gavin"}, "I am gavin", "finished"}})
+
+	have, err := abi.Pack("add_message", myMessage{message{"Gavin"}, "I am gavin", "finished"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Errorf("call mismatch:\nhave %x\nwant %x", have, want)
+	}
+
+	code, err := ioutil.ReadFile("../../../core/vm/testdata/contract_hello.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash = fnv.New64()
+	hash.Write([]byte("init"))
+	init, _ := rlp.EncodeToBytes(struct{ FuncName uint64 }{hash.Sum64()})
+	deploy, _ := rlp.EncodeToBytes([][]byte{code, init})
+	want = append([]byte{0x00, 0x61, 0x73, 0x6d}, deploy...)
+
+	have, err = abi.PackDeploy(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Error("deploy data mismatch")
+	}
+	if _, err := abi.PackDeploy([]byte{0x60, 0x80}); err == nil {
+		t.Error("expected error for non-wasm code")
+	}
+	if _, err := abi.Pack("add_message"); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if _, err := abi.Pack("add_message", "wrong"); err == nil {
+		t.Error("expected error for wrong argument type")
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	abi, err := JSON(strings.NewReader(helloABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []myMessage{{message{"a"}, "b", "c"}, {message{"d"}, "e", "f"}}
+	enc, err := rlp.EncodeToBytes([][]interface{}{{[]string{"a"}, "b", "c"}, {[]string{"d"}, "e", "f"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []myMessage
+	if err := abi.Unpack(&out, "get_message", enc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, msgs) {
+		t.Errorf("output mismatch: have %+v, want %+v", out, msgs)
+	}
+	var size uint64
+	if err := abi.Unpack(&size, "get_vector_size", []byte{0x02}); err != nil || size != 2 {
+		t.Errorf("size mismatch: have %d (%v)", size, err)
+	}
+	if err := abi.Unpack(&size, "get_vector_size", nil); err == nil {
+		t.Error("expected error for empty output")
+	}
+	if err := abi.Unpack(size, "get_vector_size", []byte{0x02}); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	abi, err := JSON(strings.NewReader(helloABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	memo := "this memo is longer than thirty-two bytes"
+
+	topics, err := abi.MakeTopics("Transfer", []interface{}{from}, []interface{}{memo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := rlp.EncodeToBytes(from)
+	want := [][]common.Hash{
+		{common.BytesToHash([]byte("Transfer"))},
+		{common.BytesToHash(addr)},
+		{crypto.Keccak256Hash([]byte(memo))},
+	}
+	if !reflect.DeepEqual(topics, want) {
+		t.Fatalf("topics mismatch:\nhave %x\nwant %x", topics, want)
+	}
+	if _, err := abi.MakeTopics("Transfer", nil, nil, nil); err == nil {
+		t.Error("expected error for filtering a non-indexed input")
+	}
+
+	data, _ := rlp.EncodeToBytes([]interface{}{zigzag(-5)})
+	var ev struct {
+		From   common.Hash
+		Memo   common.Hash
+		Amount int64
+	}
+	if err := abi.UnpackEvent(&ev, "Transfer", []common.Hash{want[0][0], want[1][0], want[2][0]}, data); err != nil {
+		t.Fatal(err)
+	}
+	if ev.From != want[1][0] || ev.Memo != want[2][0] || ev.Amount != -5 {
+		t.Errorf("event mismatch: %+v", ev)
+	}
+	if err := abi.UnpackEvent(&ev, "Transfer", []common.Hash{want[0][0]}, data); err == nil {
+		t.Error("expected error for missing topics")
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	structs := map[string]*Struct{}
+	structs["point"] = &Struct{Name: "point"}
+	x, _ := NewType("int32", structs)
+	y, _ := NewType("uint128", structs)
+	structs["point"].Fields = []Argument{{"x", x}, {"y", y}}
+
+	type point struct {
+		X int32
+		Y *big.Int
+	}
+	type pair struct {
+		First  string
+		Second []byte
+	}
+	tests := []struct {
+		typ   string
+		value interface{}
+	}{
+		{"bool", true},
+		{"bool", false},
+		{"uint8", uint8(255)},
+		{"uint16", uint16(65535)},
+		{"uint64", uint64(1) << 63},
+		{"int8", int8(-128)},
+		{"int32", int32(-1)},
+		{"int64", int64(-1) << 63},
+		{"uint128", new(big.Int).Lsh(big.NewInt(1), 127)},
+		{"int128", big.NewInt(-12345678901234)},
+		{"string", "hello"},
+		{"uint8[]", []byte{1, 2, 3}},
+		{"uint8[4]", [4]byte{1, 2, 3, 4}},
+		{"FixedHash<32>", common.HexToHash("0x01")},
+		{"Address", common.HexToAddress("0x02")},
+		{"int16[]", []int16{-1, 0, 1}},
+		{"list<string>", []string{"a", "b"}},
+		{"uint32[3]", [3]uint32{1, 2, 3}},
+		{"map<string,int64>", map[string]int64{"a": -1, "b": 2}},
+		{"pair<string,uint8[]>", pair{"k", []byte{9}}},
+		{"point", point{-7, big.NewInt(7)}},
+		{"point[]", []point{{1, big.NewInt(2)}, {-3, big.NewInt(4)}}},
+		{"map<uint8,list<point>>", map[uint8][]point{1: {{1, big.NewInt(1)}}}},
+	}
+	for i, tt := range tests {
+		typ, err := NewType(tt.typ, structs)
+		if err != nil {
+			t.Fatalf("test %d (%s): %v", i, tt.typ, err)
+		}
+		enc, err := encodeValue(typ, reflect.ValueOf(tt.value))
+		if err != nil {
+			t.Fatalf("test %d (%s): encode failed: %v", i, tt.typ, err)
+		}
+		b, err := rlp.EncodeToBytes(enc)
+		if err != nil {
+			t.Fatalf("test %d (%s): rlp failed: %v", i, tt.typ, err)
+		}
+		out := reflect.New(reflect.TypeOf(tt.value))
+		if err := decodeValue(typ, rlp.NewStream(bytes.NewReader(b), uint64(len(b))), out.Elem()); err != nil {
+			t.Fatalf("test %d (%s): decode failed: %v", i, tt.typ, err)
+		}
+		if !reflect.DeepEqual(out.Elem().Interface(), tt.value) {
+			t.Errorf("test %d (%s): have %v, want %v", i, tt.typ, out.Elem().Interface(), tt.value)
+		}
+	}
+}
+
+func TestZigzag(t *testing.T) {
+	for _, tt := range []struct {
+		in  int64
+		out uint64
+	}{{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2147483647, 4294967294}, {-2147483648, 4294967295}} {
+		if have := zigzag(tt.in); have != tt.out {
+			t.Errorf("zigzag(%d) = %d, want %d", tt.in, have, tt.out)
+		}
+		if have := unzigzag(tt.out); have != tt.in {
+			t.Errorf("unzigzag(%d) = %d, want %d", tt.out, have, tt.in)
+		}
+		if have := zigzagBig(big.NewInt(tt.in)); have.Uint64() != tt.out {
+			t.Errorf("zigzagBig(%d) = %v, want %d", tt.in, have, tt.out)
+		}
+		if have := unzigzagBig(new(big.Int).SetUint64(tt.out)); have.Int64() != tt.in {
+			t.Errorf("unzigzagBig(%d) = %v, want %d", tt.out, have, tt.in)
+		}
+	}
+}
+
+func TestEncodeOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		typ   string
+		value interface{}
+	}{
+		{"uint8", uint16(256)},
+		{"int8", int16(128)},
+		{"int8", int16(-129)},
+		{"uint128", new(big.Int).Lsh(big.NewInt(1), 128)},
+		{"uint128", big.NewInt(-1)},
+		{"int128", new(big.Int).Lsh(big.NewInt(1), 127)},
+		{"uint32[2]", []uint32{1}},
+	} {
+		typ, _ := NewType(tt.typ, nil)
+		if _, err := encodeValue(typ, reflect.ValueOf(tt.value)); err == nil {
+			t.Errorf("%s: expected overflow error for %v", tt.typ, tt.value)
+		}
+	}
+}
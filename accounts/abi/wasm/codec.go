@@ -0,0 +1,427 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+var (
+	bigT = new(big.Int)
+	one  = big.NewInt(1)
+)
+
+// zigzag maps signed integers onto unsigned ones the same way the contract
+// development toolkit does, so that small negative numbers stay small.
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func zigzagBig(v *big.Int) *big.Int {
+	r := new(big.Int).Lsh(v, 1)
+	if v.Sign() < 0 {
+		r.Neg(r).Sub(r, one)
+	}
+	return r
+}
+
+func unzigzagBig(v *big.Int) *big.Int {
+	r := new(big.Int).Rsh(v, 1)
+	if v.Bit(0) == 1 {
+		r.Add(r, one).Neg(r)
+	}
+	return r
+}
+
+// encodeValue converts v into a value the rlp package can encode, following
+// the wire layout of the abi type t.
+func encodeValue(t Type, v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("wasm: nil value for %s", t)
+		}
+		if _, ok := v.Interface().(*big.Int); ok {
+			break
+		}
+		v = v.Elem()
+	}
+	switch t.T {
+	case BoolTy:
+		if v.Kind() != reflect.Bool {
+			return nil, typeErr(t, v)
+		}
+		return v.Bool(), nil
+	case UintTy:
+		if t.IsBig() {
+			b, ok := v.Interface().(*big.Int)
+			if !ok {
+				return nil, typeErr(t, v)
+			}
+			if b.Sign() < 0 || b.BitLen() > t.Size {
+				return nil, fmt.Errorf("wasm: %v overflows %s", b, t)
+			}
+			return b, nil
+		}
+		switch v.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if t.Size < 64 && v.Uint()>>uint(t.Size) != 0 {
+				return nil, fmt.Errorf("wasm: %d overflows %s", v.Uint(), t)
+			}
+			return v.Uint(), nil
+		}
+		return nil, typeErr(t, v)
+	case IntTy:
+		if t.IsBig() {
+			b, ok := v.Interface().(*big.Int)
+			if !ok {
+				return nil, typeErr(t, v)
+			}
+			if b.BitLen() >= t.Size {
+				return nil, fmt.Errorf("wasm: %v overflows %s", b, t)
+			}
+			return zigzagBig(b), nil
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n := v.Int()
+			if t.Size < 64 && (n < -1<<uint(t.Size-1) || n >= 1<<uint(t.Size-1)) {
+				return nil, fmt.Errorf("wasm: %d overflows %s", n, t)
+			}
+			return zigzag(n), nil
+		}
+		return nil, typeErr(t, v)
+	case StringTy:
+		if v.Kind() != reflect.String {
+			return nil, typeErr(t, v)
+		}
+		return v.String(), nil
+	case BytesTy:
+		if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, typeErr(t, v)
+		}
+		return v.Bytes(), nil
+	case FixedBytesTy, AddressTy:
+		if v.Kind() != reflect.Array || v.Type().Elem().Kind() != reflect.Uint8 || v.Len() != t.Size {
+			return nil, typeErr(t, v)
+		}
+		b := make([]byte, t.Size)
+		reflect.Copy(reflect.ValueOf(b), v)
+		return b, nil
+	case SliceTy, ArrayTy:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, typeErr(t, v)
+		}
+		if t.T == ArrayTy && v.Len() != t.Size {
+			return nil, fmt.Errorf("wasm: %s requires %d elements, got %d", t, t.Size, v.Len())
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			elem, err := encodeValue(*t.Elem, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = elem
+		}
+		return list, nil
+	case MapTy:
+		if v.Kind() != reflect.Map {
+			return nil, typeErr(t, v)
+		}
+		keys := v.MapKeys()
+		sortKeys(keys)
+		list := make([]interface{}, len(keys))
+		for i, key := range keys {
+			k, err := encodeValue(*t.Key, key)
+			if err != nil {
+				return nil, err
+			}
+			e, err := encodeValue(*t.Elem, v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = []interface{}{k, e}
+		}
+		return list, nil
+	case PairTy:
+		if v.Kind() != reflect.Struct || v.NumField() != 2 {
+			return nil, typeErr(t, v)
+		}
+		first, err := encodeValue(*t.Pair[0], v.Field(0))
+		if err != nil {
+			return nil, err
+		}
+		second, err := encodeValue(*t.Pair[1], v.Field(1))
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{first, second}, nil
+	case StructTy:
+		if v.Kind() != reflect.Struct {
+			return nil, typeErr(t, v)
+		}
+		return encodeStruct(t.Struct, v)
+	}
+	return nil, fmt.Errorf("wasm: unknown type %s", t)
+}
+
+// encodeStruct encodes a user-defined struct. The Go struct carries one field
+// per base class followed by one field per member, the base classes are
+// encoded as nested lists.
+func encodeStruct(s *Struct, v reflect.Value) (interface{}, error) {
+	if want := len(s.Bases) + len(s.Fields); v.NumField() != want {
+		return nil, fmt.Errorf("wasm: struct %s requires %d fields, got %d", s.Name, want, v.NumField())
+	}
+	list := make([]interface{}, 0, v.NumField())
+	for i, base := range s.Bases {
+		elem, err := encodeStruct(base, v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, elem)
+	}
+	for i, field := range s.Fields {
+		elem, err := encodeValue(field.Type, v.Field(len(s.Bases)+i))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", s.Name, field.Name, err)
+		}
+		list = append(list, elem)
+	}
+	return list, nil
+}
+
+// sortKeys orders map keys so that the encoding is deterministic. Numbers
+// and strings are sorted naturally, which is the iteration order of the
+// ordered maps used by contracts.
+func sortKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return a.Uint() < b.Uint()
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		case reflect.Array:
+			if a.Type().Elem().Kind() == reflect.Uint8 {
+				x, y := make([]byte, a.Len()), make([]byte, b.Len())
+				reflect.Copy(reflect.ValueOf(x), a)
+				reflect.Copy(reflect.ValueOf(y), b)
+				return bytes.Compare(x, y) < 0
+			}
+		}
+		x, _ := rlp.EncodeToBytes(a.Interface())
+		y, _ := rlp.EncodeToBytes(b.Interface())
+		return bytes.Compare(x, y) < 0
+	})
+}
+
+// decodeValue reads the next value of abi type t from the stream into dst,
+// allocating pointers, slices and maps as needed.
+func decodeValue(t Type, s *rlp.Stream, dst reflect.Value) error {
+	if dst.Kind() == reflect.Ptr && dst.Type() != reflect.TypeOf(bigT) {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		dst = dst.Elem()
+	}
+	switch t.T {
+	case BoolTy:
+		if dst.Kind() != reflect.Bool {
+			return typeErr(t, dst)
+		}
+		b, err := s.Bool()
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case UintTy, IntTy:
+		if t.IsBig() {
+			if dst.Type() != reflect.TypeOf(bigT) {
+				return typeErr(t, dst)
+			}
+			b, err := s.BigInt()
+			if err != nil {
+				return err
+			}
+			if t.T == IntTy {
+				b = unzigzagBig(b)
+			}
+			dst.Set(reflect.ValueOf(b))
+			return nil
+		}
+		n, err := s.Uint()
+		if err != nil {
+			return err
+		}
+		switch dst.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if t.T != UintTy || dst.OverflowUint(n) {
+				return typeErr(t, dst)
+			}
+			dst.SetUint(n)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i := unzigzag(n)
+			if t.T != IntTy || dst.OverflowInt(i) {
+				return typeErr(t, dst)
+			}
+			dst.SetInt(i)
+		default:
+			return typeErr(t, dst)
+		}
+	case StringTy:
+		if dst.Kind() != reflect.String {
+			return typeErr(t, dst)
+		}
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		dst.SetString(string(b))
+	case BytesTy:
+		if dst.Kind() != reflect.Slice || dst.Type().Elem().Kind() != reflect.Uint8 {
+			return typeErr(t, dst)
+		}
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		dst.SetBytes(b)
+	case FixedBytesTy, AddressTy:
+		if dst.Kind() != reflect.Array || dst.Type().Elem().Kind() != reflect.Uint8 || dst.Len() != t.Size {
+			return typeErr(t, dst)
+		}
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		if len(b) != t.Size {
+			return fmt.Errorf("wasm: %s requires %d bytes, got %d", t, t.Size, len(b))
+		}
+		reflect.Copy(dst, reflect.ValueOf(b))
+	case SliceTy, ArrayTy:
+		if _, err := s.List(); err != nil {
+			return err
+		}
+		switch {
+		case dst.Kind() == reflect.Slice:
+			dst.Set(reflect.MakeSlice(dst.Type(), 0, 0))
+			for i := 0; s.MoreDataInList(); i++ {
+				dst.Set(reflect.Append(dst, reflect.Zero(dst.Type().Elem())))
+				if err := decodeValue(*t.Elem, s, dst.Index(i)); err != nil {
+					return err
+				}
+			}
+		case dst.Kind() == reflect.Array:
+			for i := 0; s.MoreDataInList(); i++ {
+				if i >= dst.Len() {
+					return fmt.Errorf("wasm: too many elements for %s", t)
+				}
+				if err := decodeValue(*t.Elem, s, dst.Index(i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return typeErr(t, dst)
+		}
+		return s.ListEnd()
+	case MapTy:
+		if dst.Kind() != reflect.Map {
+			return typeErr(t, dst)
+		}
+		if _, err := s.List(); err != nil {
+			return err
+		}
+		dst.Set(reflect.MakeMap(dst.Type()))
+		for s.MoreDataInList() {
+			if _, err := s.List(); err != nil {
+				return err
+			}
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := decodeValue(*t.Key, s, key); err != nil {
+				return err
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeValue(*t.Elem, s, elem); err != nil {
+				return err
+			}
+			if err := s.ListEnd(); err != nil {
+				return err
+			}
+			dst.SetMapIndex(key, elem)
+		}
+		return s.ListEnd()
+	case PairTy:
+		if dst.Kind() != reflect.Struct || dst.NumField() != 2 {
+			return typeErr(t, dst)
+		}
+		if _, err := s.List(); err != nil {
+			return err
+		}
+		if err := decodeValue(*t.Pair[0], s, dst.Field(0)); err != nil {
+			return err
+		}
+		if err := decodeValue(*t.Pair[1], s, dst.Field(1)); err != nil {
+			return err
+		}
+		return s.ListEnd()
+	case StructTy:
+		if dst.Kind() != reflect.Struct {
+			return typeErr(t, dst)
+		}
+		return decodeStruct(t.Struct, s, dst)
+	default:
+		return fmt.Errorf("wasm: unknown type %s", t)
+	}
+	return nil
+}
+
+func decodeStruct(st *Struct, s *rlp.Stream, dst reflect.Value) error {
+	if want := len(st.Bases) + len(st.Fields); dst.NumField() != want {
+		return fmt.Errorf("wasm: struct %s requires %d fields, got %d", st.Name, want, dst.NumField())
+	}
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	for i, base := range st.Bases {
+		if err := decodeStruct(base, s, dst.Field(i)); err != nil {
+			return err
+		}
+	}
+	for i, field := range st.Fields {
+		if err := decodeValue(field.Type, s, dst.Field(len(st.Bases)+i)); err != nil {
+			return fmt.Errorf("%s.%s: %v", st.Name, field.Name, err)
+		}
+	}
+	return s.ListEnd()
+}
+
+func typeErr(t Type, v reflect.Value) error {
+	return fmt.Errorf("wasm: cannot use %v as type %s", v.Type(), t)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// EventID returns the first topic of every non-anonymous event, which is the
+// event name itself.
+func EventID(name string) common.Hash {
+	return topicHash([]byte(name))
+}
+
+// MakeTopic converts a filter query argument into the topic platon_event
+// stores for an indexed input of type t. Strings are used as-is, every other
+// type is RLP encoded; values longer than a hash are hashed with Keccak256.
+func MakeTopic(t Type, v interface{}) (common.Hash, error) {
+	rv := reflect.ValueOf(v)
+	if t.T == StringTy {
+		if rv.Kind() != reflect.String {
+			return common.Hash{}, typeErr(t, rv)
+		}
+		return topicHash([]byte(rv.String())), nil
+	}
+	enc, err := encodeValue(t, rv)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	b, err := rlp.EncodeToBytes(enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return topicHash(b), nil
+}
+
+// MakeTopics converts the filter query arguments of the indexed inputs of
+// the given event into the topics of a log filter, the event name is
+// prepended unless the event is anonymous.
+func (abi ABI) MakeTopics(name string, query ...[]interface{}) ([][]common.Hash, error) {
+	event, exist := abi.Events[name]
+	if !exist {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	if len(query) > event.Topics {
+		return nil, fmt.Errorf("too many topic rules for event %s: %d for %d", name, len(query), event.Topics)
+	}
+	var topics [][]common.Hash
+	if !event.Anonymous {
+		topics = append(topics, []common.Hash{EventID(name)})
+	}
+	for i, filter := range query {
+		var rule []common.Hash
+		for _, v := range filter {
+			topic, err := MakeTopic(event.Inputs[i].Type, v)
+			if err != nil {
+				return nil, err
+			}
+			rule = append(rule, topic)
+		}
+		topics = append(topics, rule)
+	}
+	return topics, nil
+}
+
+func topicHash(b []byte) common.Hash {
+	if len(b) > common.HashLength {
+		return crypto.Keccak256Hash(b)
+	}
+	return common.BytesToHash(b)
+}
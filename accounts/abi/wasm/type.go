@@ -0,0 +1,257 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Type enumerator
+const (
+	BoolTy byte = iota
+	UintTy
+	IntTy
+	StringTy
+	BytesTy
+	FixedBytesTy
+	AddressTy
+	SliceTy
+	ArrayTy
+	MapTy
+	PairTy
+	StructTy
+)
+
+// Type is the reflection of a type found in a WASM contract ABI.
+type Type struct {
+	T    byte // Our own type checking
+	Size int  // Bit size of integers, byte size of fixed bytes, length of arrays
+
+	Elem   *Type    // Element type of slices and arrays, value type of maps
+	Key    *Type    // Key type of maps
+	Pair   [2]*Type // Member types of pairs
+	Struct *Struct  // Definition of user-defined structs
+
+	stringKind string // holds the unparsed string for deriving signatures
+}
+
+// NewType creates a new reflection type of the abi type given in t. The
+// structs are the user-defined types declared by the same ABI, they are
+// looked up by name.
+func NewType(t string, structs map[string]*Struct) (Type, error) {
+	t = strings.TrimSpace(t)
+	typ := Type{stringKind: t}
+
+	// Arrays and slices, the element type can be any other type
+	if strings.HasSuffix(t, "]") {
+		i := strings.LastIndex(t, "[")
+		if i <= 0 {
+			return Type{}, fmt.Errorf("invalid type %q", t)
+		}
+		elem, err := NewType(t[:i], structs)
+		if err != nil {
+			return Type{}, err
+		}
+		size := t[i+1 : len(t)-1]
+		switch {
+		case size == "" && elem.T == UintTy && elem.Size == 8:
+			typ.T = BytesTy
+		case size == "":
+			typ.T, typ.Elem = SliceTy, &elem
+		default:
+			n, err := strconv.Atoi(size)
+			if err != nil || n < 0 {
+				return Type{}, fmt.Errorf("invalid array size in %q", t)
+			}
+			typ.Size = n
+			if elem.T == UintTy && elem.Size == 8 {
+				typ.T = FixedBytesTy
+			} else {
+				typ.T, typ.Elem = ArrayTy, &elem
+			}
+		}
+		return typ, nil
+	}
+	// Templated containers: list<T>, set<T>, map<K,V>, pair<A,B> and FixedHash<N>
+	if i := strings.Index(t, "<"); i > 0 && strings.HasSuffix(t, ">") {
+		params := splitParams(t[i+1 : len(t)-1])
+		switch t[:i] {
+		case "list", "set":
+			if len(params) != 1 {
+				return Type{}, fmt.Errorf("invalid type %q", t)
+			}
+			elem, err := NewType(params[0], structs)
+			if err != nil {
+				return Type{}, err
+			}
+			typ.T, typ.Elem = SliceTy, &elem
+		case "map", "pair":
+			if len(params) != 2 {
+				return Type{}, fmt.Errorf("invalid type %q", t)
+			}
+			first, err := NewType(params[0], structs)
+			if err != nil {
+				return Type{}, err
+			}
+			second, err := NewType(params[1], structs)
+			if err != nil {
+				return Type{}, err
+			}
+			if t[:i] == "map" {
+				typ.T, typ.Key, typ.Elem = MapTy, &first, &second
+			} else {
+				typ.T, typ.Pair = PairTy, [2]*Type{&first, &second}
+			}
+		case "FixedHash":
+			if len(params) != 1 {
+				return Type{}, fmt.Errorf("invalid type %q", t)
+			}
+			n, err := strconv.Atoi(params[0])
+			if err != nil || n <= 0 {
+				return Type{}, fmt.Errorf("invalid hash size in %q", t)
+			}
+			typ.T, typ.Size = FixedBytesTy, n
+		default:
+			return Type{}, fmt.Errorf("unsupported container type %q", t)
+		}
+		return typ, nil
+	}
+	switch t {
+	case "bool":
+		typ.T = BoolTy
+	case "string":
+		typ.T = StringTy
+	case "Address":
+		typ.T, typ.Size = AddressTy, 20
+	case "uint8", "uint16", "uint32", "uint64", "uint128":
+		typ.T = UintTy
+		typ.Size, _ = strconv.Atoi(t[4:])
+	case "int8", "int16", "int32", "int64", "int128":
+		typ.T = IntTy
+		typ.Size, _ = strconv.Atoi(t[3:])
+	default:
+		s, ok := structs[t]
+		if !ok {
+			return Type{}, fmt.Errorf("unsupported arg type: %s", t)
+		}
+		typ.T, typ.Struct = StructTy, s
+	}
+	return typ, nil
+}
+
+// splitParams splits the template parameters of a container type, nested
+// containers are kept together.
+func splitParams(s string) []string {
+	var (
+		params []string
+		depth  int
+		start  int
+	)
+	for i, c := range s {
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(params, strings.TrimSpace(s[start:]))
+}
+
+// String implements Stringer.
+func (t Type) String() string {
+	return t.stringKind
+}
+
+// IsBig reports whether the type is wider than 64 bits and is represented by
+// a *big.Int in Go.
+func (t Type) IsBig() bool {
+	return (t.T == UintTy || t.T == IntTy) && t.Size > 64
+}
+
+// GetType returns the reflection type of the ABI type, user-defined structs
+// have no reflection type of their own and are returned as nil.
+func (t Type) GetType() reflect.Type {
+	switch t.T {
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case UintTy, IntTy:
+		if t.IsBig() {
+			return reflect.TypeOf(bigT)
+		}
+		return reflectIntType(t.T == UintTy, t.Size)
+	case BytesTy:
+		return reflect.SliceOf(reflect.TypeOf(byte(0)))
+	case FixedBytesTy, AddressTy:
+		return reflect.ArrayOf(t.Size, reflect.TypeOf(byte(0)))
+	case SliceTy:
+		if elem := t.Elem.GetType(); elem != nil {
+			return reflect.SliceOf(elem)
+		}
+	case ArrayTy:
+		if elem := t.Elem.GetType(); elem != nil {
+			return reflect.ArrayOf(t.Size, elem)
+		}
+	case MapTy:
+		key, elem := t.Key.GetType(), t.Elem.GetType()
+		if key != nil && elem != nil {
+			return reflect.MapOf(key, elem)
+		}
+	case PairTy:
+		first, second := t.Pair[0].GetType(), t.Pair[1].GetType()
+		if first != nil && second != nil {
+			return reflect.StructOf([]reflect.StructField{
+				{Name: "First", Type: first},
+				{Name: "Second", Type: second},
+			})
+		}
+	}
+	return nil
+}
+
+func reflectIntType(unsigned bool, size int) reflect.Type {
+	if unsigned {
+		switch size {
+		case 8:
+			return reflect.TypeOf(uint8(0))
+		case 16:
+			return reflect.TypeOf(uint16(0))
+		case 32:
+			return reflect.TypeOf(uint32(0))
+		}
+		return reflect.TypeOf(uint64(0))
+	}
+	switch size {
+	case 8:
+		return reflect.TypeOf(int8(0))
+	case 16:
+		return reflect.TypeOf(int16(0))
+	case 32:
+		return reflect.TypeOf(int32(0))
+	}
+	return reflect.TypeOf(int64(0))
+}
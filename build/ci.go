@@ -194,6 +194,7 @@ func doInstall(cmdline []string) {
 		gcflags = flag.String("gcflags", "", "Turn off compiler code optimization and function inlining")
 		vc      = flag.String("vc", "off", "Switch of vc , on for compiling VC, off for without compiling")
 		mv      = flag.String("mv", "off", "Switch of mv , on for compilingMPC and VC, off for without compiling")
+		blsgo   = flag.String("blsgo", "off", "Switch of blsgo , on for using the pure Go BLS backend instead of the mcl library")
 	)
 	flag.CommandLine.Parse(cmdline)
 	env := build.Env()
@@ -220,6 +221,9 @@ func doInstall(cmdline []string) {
 
 	if *arch == "" || *arch == runtime.GOARCH {
 		goinstall := goTool("install", buildFlags(env)...)
+		if *blsgo == "on" {
+			goinstall.Args = append(goinstall.Args, "-tags=blsgo")
+		}
 		if runtime.GOARCH == "arm64" {
 			goinstall.Args = append(goinstall.Args, "-p", "1")
 		}
@@ -231,17 +235,21 @@ func doInstall(cmdline []string) {
 			if packages[index] == "github.com/PlatONnetwork/PlatON-Go/cmd/platon" || packages[index] == "./cmd/platon" {
 				goplatoninstall := goTool("install", buildFlags(env)...)
 				goplatoninstall.Args = append(goplatoninstall.Args, "-v")
-				if *mpc == "on" {
-					goplatoninstall.Args = append(goplatoninstall.Args, "-tags=mpcon")
+				var tags []string
+				if *mpc == "on" || *mv == "on" {
+					tags = append(tags, "mpcon")
 				}
-				if *gcflags == "on" {
-					goplatoninstall.Args = append(goplatoninstall.Args, "-gcflags=-N -l")
+				if *vc == "on" || *mv == "on" {
+					tags = append(tags, "vcon")
 				}
-				if *vc == "on" {
-					goplatoninstall.Args = append(goplatoninstall.Args, "-tags=vcon")
+				if *blsgo == "on" {
+					tags = append(tags, "blsgo")
 				}
-				if *mv == "on" {
-					goplatoninstall.Args = append(goplatoninstall.Args, "-tags=mpcon vcon")
+				if len(tags) > 0 {
+					goplatoninstall.Args = append(goplatoninstall.Args, "-tags="+strings.Join(tags, " "))
+				}
+				if *gcflags == "on" {
+					goplatoninstall.Args = append(goplatoninstall.Args, "-gcflags=-N -l")
 				}
 				packages3 := []string{"./cmd/platon"}
 				goplatoninstall.Args = append(goplatoninstall.Args, packages3...)
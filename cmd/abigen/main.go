@@ -17,6 +17,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -30,6 +32,7 @@ import (
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/bind"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
 	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
 	"github.com/PlatONnetwork/PlatON-Go/common/compiler"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
@@ -100,6 +103,10 @@ var (
 		Name:  "alias",
 		Usage: "Comma separated aliases for function and event renaming, e.g. foo=bar",
 	}
+	wasmFlag = cli.BoolFlag{
+		Name:  "wasm",
+		Usage: "Bind a WASM contract ABI (--abi), the --bin file may be the raw .wasm module or its hex encoding",
+	}
 )
 
 func init() {
@@ -118,6 +125,7 @@ func init() {
 		outFlag,
 		langFlag,
 		aliasFlag,
+		wasmFlag,
 	}
 	app.Action = utils.MigrateFlags(abigen)
 	cli.CommandHelpTemplate = utils.OriginCommandHelpTemplate
@@ -128,6 +136,9 @@ func abigen(c *cli.Context) error {
 	if c.GlobalString(pkgFlag.Name) == "" {
 		utils.Fatalf("No destination package specified (--pkg)")
 	}
+	if c.GlobalBool(wasmFlag.Name) {
+		return abigenWasm(c)
+	}
 	var lang bind.Lang
 	switch c.GlobalString(langFlag.Name) {
 	case "go":
@@ -244,6 +255,67 @@ func abigen(c *cli.Context) error {
 		}
 	}
 	// Extract all aliases from the flags
+	parseAliases(c, aliases)
+
+	// Generate the contract binding
+	code, err := bind.Bind(types, abis, bins, sigs, c.GlobalString(pkgFlag.Name), lang, libs, aliases)
+	if err != nil {
+		utils.Fatalf("Failed to generate ABI binding: %v", err)
+	}
+	return writeBinding(c, code)
+}
+
+// abigenWasm generates the binding of a WASM contract ABI. The contract
+// development toolkit emits the ABI and the module as separate files, so only
+// the --abi source is supported.
+func abigenWasm(c *cli.Context) error {
+	if !c.GlobalIsSet(abiFlag.Name) {
+		utils.Fatalf("WASM bindings require the contract ABI (--abi)")
+	}
+	if c.GlobalString(langFlag.Name) != "go" {
+		utils.Fatalf("WASM bindings are only supported for Go (--lang)")
+	}
+	var (
+		abi []byte
+		err error
+	)
+	input := c.GlobalString(abiFlag.Name)
+	if input == "-" {
+		abi, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		abi, err = ioutil.ReadFile(input)
+	}
+	if err != nil {
+		utils.Fatalf("Failed to read input ABI: %v", err)
+	}
+	var bin string
+	if binFile := c.GlobalString(binFlag.Name); binFile != "" {
+		code, err := ioutil.ReadFile(binFile)
+		if err != nil {
+			utils.Fatalf("Failed to read input WASM code: %v", err)
+		}
+		if bytes.HasPrefix(code, wasm.Magic) {
+			bin = hex.EncodeToString(code)
+		} else {
+			bin = strings.TrimSpace(string(code))
+		}
+	}
+	kind := c.GlobalString(typeFlag.Name)
+	if kind == "" {
+		kind = c.GlobalString(pkgFlag.Name)
+	}
+	aliases := make(map[string]string)
+	parseAliases(c, aliases)
+
+	code, err := bind.BindWasm([]string{kind}, []string{string(abi)}, []string{bin}, c.GlobalString(pkgFlag.Name), aliases)
+	if err != nil {
+		utils.Fatalf("Failed to generate ABI binding: %v", err)
+	}
+	return writeBinding(c, code)
+}
+
+// parseAliases extracts the function and event aliases from the flags.
+func parseAliases(c *cli.Context, aliases map[string]string) {
 	if c.GlobalIsSet(aliasFlag.Name) {
 		// We support multi-versions for aliasing
 		// e.g.
@@ -255,12 +327,11 @@ func abigen(c *cli.Context) error {
 			aliases[match[1]] = match[2]
 		}
 	}
-	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, sigs, c.GlobalString(pkgFlag.Name), lang, libs, aliases)
-	if err != nil {
-		utils.Fatalf("Failed to generate ABI binding: %v", err)
-	}
-	// Either flush it out to a file or display on the standard output
+}
+
+// writeBinding either flushes the binding out to a file or displays it on
+// the standard output.
+func writeBinding(c *cli.Context, code string) error {
 	if !c.GlobalIsSet(outFlag.Name) {
 		fmt.Printf("%s\n", code)
 		return nil
@@ -0,0 +1,80 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+// cbftsigner holds the consensus keys of a validator and signs the blocks
+// and consensus messages for a node started with --cbft.signer.
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/signer"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+)
+
+func main() {
+	var (
+		nodeKeyFile = flag.String("nodekey", "", "node private key filename")
+		blsKeyFile  = flag.String("blskey", "", "BLS private key filename")
+		datadir     = flag.String("datadir", ".", "directory of the signing watermarks")
+		ipcPath     = flag.String("ipcpath", "cbftsigner.ipc", "filename of the IPC endpoint, relative to datadir")
+		verbosity   = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-5)")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	if *nodeKeyFile == "" || *blsKeyFile == "" {
+		utils.Fatalf("Use -nodekey and -blskey to specify the consensus keys")
+	}
+	nodeKey, err := crypto.LoadECDSA(*nodeKeyFile)
+	if err != nil {
+		utils.Fatalf("-nodekey: %v", err)
+	}
+	blsKey, err := bls.LoadBLS(*blsKeyFile)
+	if err != nil {
+		utils.Fatalf("-blskey: %v", err)
+	}
+	if err := os.MkdirAll(*datadir, 0700); err != nil {
+		utils.Fatalf("-datadir: %v", err)
+	}
+
+	service, err := signer.NewService(nodeKey, blsKey, filepath.Join(*datadir, "watermarks.json"))
+	if err != nil {
+		utils.Fatalf("Failed to load the watermarks: %v", err)
+	}
+	endpoint := *ipcPath
+	if !filepath.IsAbs(endpoint) {
+		endpoint = filepath.Join(*datadir, endpoint)
+	}
+	if err := service.Start(endpoint); err != nil {
+		utils.Fatalf("Failed to start the signer: %v", err)
+	}
+	defer service.Stop()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	<-sigc
+}
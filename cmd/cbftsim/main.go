@@ -0,0 +1,120 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+// cbftsim runs a network of CBFT validators in one process, injects the
+// faults of a scenario and reports whether the consensus stayed safe and
+// live. The validators run in inner mode without the PPOS plugins, see the
+// simulator package.
+//
+// A scenario is a JSON file like
+//
+//	{
+//	  "nodes": 4, "period": 2000, "amount": 5, "duration": "2m",
+//	  "steps": [
+//	    {"at": "10s", "action": "latency", "latency": "50ms", "jitter": "20ms"},
+//	    {"at": "20s", "action": "crash", "nodes": [3]},
+//	    {"at": "40s", "action": "restart", "nodes": [3]},
+//	    {"at": "60s", "action": "partition", "groups": [[0, 1], [2, 3]]},
+//	    {"at": "70s", "action": "heal"},
+//	    {"at": "80s", "action": "drop", "rate": 0.1},
+//	    {"at": "90s", "action": "reorder", "rate": 0.2, "latency": "200ms"},
+//	    {"at": "100s", "action": "equivocate", "nodes": [2]},
+//	    {"at": "105s", "action": "elect", "nodes": [0, 1, 2]}
+//	  ]
+//	}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/simulator"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+)
+
+func main() {
+	var (
+		scenario   = flag.String("scenario", "", "scenario file, the flags below override it")
+		nodes      = flag.Int("nodes", simulator.DefaultConfig.Nodes, "number of validators")
+		period     = flag.Uint64("period", simulator.DefaultConfig.Period, "milliseconds per view")
+		amount     = flag.Uint("amount", uint(simulator.DefaultConfig.Amount), "blocks per view")
+		duration   = flag.Duration("duration", time.Duration(simulator.DefaultConfig.Duration), "length of the run")
+		seed       = flag.Int64("seed", simulator.DefaultConfig.Seed, "seed of the injected faults")
+		stall      = flag.Duration("stall", 0, "longest stall allowed while a quorum is connected (default 10 views)")
+		datadir    = flag.String("datadir", "", "directory of the WAL and evidences of the nodes (default temporary)")
+		reportFile = flag.String("report", "", "write the report as JSON to the file")
+		verbosity  = flag.Int("verbosity", int(log.LvlCrit), "log verbosity (0-5)")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	config := simulator.DefaultConfig
+	if *scenario != "" {
+		loaded, err := simulator.LoadConfig(*scenario)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		config = *loaded
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "nodes":
+			config.Nodes = *nodes
+		case "period":
+			config.Period = *period
+		case "amount":
+			config.Amount = uint32(*amount)
+		case "duration":
+			config.Duration = simulator.Duration(*duration)
+		case "seed":
+			config.Seed = *seed
+		case "stall":
+			config.StallTimeout = simulator.Duration(*stall)
+		}
+	})
+	config.DataDir = *datadir
+
+	sim, err := simulator.New(&config)
+	if err != nil {
+		utils.Fatalf("Failed to create the simulation: %v", err)
+	}
+	report, err := sim.Run()
+	if err != nil {
+		utils.Fatalf("Simulation failed: %v", err)
+	}
+	fmt.Print(report)
+
+	if *reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode the report: %v", err)
+		}
+		if err := ioutil.WriteFile(*reportFile, data, 0644); err != nil {
+			utils.Fatalf("Failed to write the report: %v", err)
+		}
+	}
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
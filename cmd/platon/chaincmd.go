@@ -37,12 +37,19 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
 	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/evidence"
 	"github.com/PlatONnetwork/PlatON-Go/core"
 	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
 	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
 	"github.com/PlatONnetwork/PlatON-Go/event"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/handler"
+	xplugin "github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
 )
 
 var (
@@ -158,6 +165,80 @@ Use "ethereum dump 0" to dump the genesis block.`,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 	}
+	checkpointCommand = cli.Command{
+		Action:    utils.MigrateFlags(checkpoint),
+		Name:      "checkpoint",
+		Usage:     "Print a checkpoint to start the fast sync of a new node from",
+		ArgsUsage: "[<blockNum>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The checkpoint command prints the number, the hash and the digest of the validators
+of a block finalized by cbft, to be passed to the --checkpoint flag of a new node.
+The block defaults to the base block of the ppos data, the validators of an older
+block are only known while its round is still kept in the ppos data.`,
+	}
+	verifyParallelCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyParallel),
+		Name:      "verify-parallel",
+		Usage:     "Re-execute blocks with both the serial and the parallel processor and compare the results",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			verifyFromFlag,
+			verifyToFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-parallel command re-executes the blocks from --from to --to on top of
+their parent state, once with the serial and once with the parallel state processor,
+and compares the receipts, the logs, the gas used and the state roots. For every
+block the processors disagree on, the differences, the accounts left in different
+states and the transaction DAG of the parallel processor are printed as JSON.
+
+The state roots are compared with the block headers too. On a PPOS chain the ppos
+plugins run the blocks again on top of the ppos data of their parent, read from the
+snapshot db of the node. It keeps the data from its base block on only, so the range
+must lie within the blocks following the base and the highest block of the snapshot
+db, the command fails otherwise. Blocks whose parent state isn't available are
+skipped, run it on an archive node (--db.nogc) to verify every block. The blocks,
+the states and the ppos data aren't written.`,
+	}
+	dumpPPOSCommand = cli.Command{
+		Action:    utils.MigrateFlags(dumpPPOS),
+		Name:      "dump-ppos",
+		Usage:     "Dump the ppos data committed to the snapshot db",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The dump-ppos command prints as JSON the ppos data committed to the base of the
+snapshot db: the candidates, the delegations, the validators of the epochs and
+the rounds, the votes and the delegate reward per records, the entries of the
+other families being printed undecoded. The proposals and the restricting plans,
+kept in the storage of the system contracts, are read from the state of the base
+block if it's available. The restricting accounts are found through the preimages
+of the trie keys, the command fails if some are missing.`,
+	}
+	verifyFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to verify",
+		Value: 1,
+	}
+	verifyToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to verify (default = current head)",
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -438,6 +519,105 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+func verifyParallel(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, chainDb := utils.MakeChain(ctx, stack, true)
+	defer chainDb.Close()
+	defer chain.Stop()
+	core.NewExecutor(chain.Config(), chain, vm.Config{}, nil)
+
+	from, to := ctx.Uint64(verifyFromFlag.Name), chain.CurrentBlock().NumberU64()
+	if ctx.IsSet(verifyToFlag.Name) {
+		to = ctx.Uint64(verifyToFlag.Name)
+	}
+	if from == 0 {
+		from = 1 // The genesis isn't executed
+	}
+	if from > to {
+		utils.Fatalf("Invalid block range: %d > %d", from, to)
+	}
+	// The ppos plugins run the blocks again on top of the ppos data of their
+	// parent, which the snapshot db of the node keeps from its base on only.
+	if config := chain.Config(); config.Cbft != nil && config.Cbft.ValidatorMode == common.PPOS_VALIDATOR_MODE {
+		snapshotdb.SetDBPathWithNode(stack.ResolvePath(snapshotdb.DBPath))
+		snapshotdb.SetDBBlockChain(chain)
+		snapshotdb.SetDBFrozen(true)
+		defer snapshotdb.Close()
+
+		current := snapshotdb.Instance().GetCurrent()
+		base, highest := current.GetBase(false).Num.Uint64(), current.GetHighest(false).Num.Uint64()
+		if from-1 < base || to-1 > highest {
+			utils.Fatalf("Invalid block range: the snapshot db holds the ppos data to run the blocks %d-%d only", base+1, highest+1)
+		}
+		replayPlugins(chain, chainDb)
+	}
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		diverged int
+		skipped  int
+	)
+	for number := from; number <= to; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			utils.Fatalf("Block %d not found", number)
+		}
+		// Only archive nodes keep the states of all the blocks.
+		if !chain.HasBlockAndState(block.ParentHash(), number-1) {
+			log.Warn("Skipping block without parent state", "number", number, "hash", block.Hash())
+			skipped++
+			continue
+		}
+		result, err := core.VerifyParallel(chain, block)
+		if err != nil {
+			utils.Fatalf("Failed to verify block %d: %v", number, err)
+		}
+		if result.Diverged() {
+			diverged++
+			out, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(out))
+			log.Error("Parallel execution diverged", "number", number, "hash", block.Hash(), "diffs", len(result.Diffs), "accounts", len(result.Accounts))
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying parallel execution", "number", number, "diverged", diverged, "skipped", skipped, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Verified parallel execution", "from", from, "to", to, "diverged", diverged, "skipped", skipped, "elapsed", common.PrettyDuration(time.Since(start)))
+	if diverged > 0 {
+		return fmt.Errorf("parallel execution diverged on %d blocks", diverged)
+	}
+	return nil
+}
+
+// replayPlugins wires the ppos plugins into the reactor the same way a PPOS node
+// does, in replay mode. The plugins write to a throwaway database rather than to
+// the chain database.
+func replayPlugins(chain *core.BlockChain, chainDb ethdb.Database) {
+	discard := rawdb.NewMemoryDatabase()
+
+	reactor := core.NewBlockChainReactor(new(event.TypeMux), chain.Config().ChainID)
+	reactor.SetValidatorMode(common.PPOS_VALIDATOR_MODE)
+	reactor.SetReplay(true)
+	reactor.SetVRFhandler(handler.NewVrfHandler(chain.Genesis().Nonce()))
+	reactor.SetPluginEventMux()
+
+	reactor.RegisterPlugin(xcom.SlashingRule, xplugin.SlashInstance())
+	xplugin.SlashInstance().SetDecodeEvidenceFun(evidence.NewEvidence)
+	reactor.RegisterPlugin(xcom.StakingRule, xplugin.StakingInstance())
+	reactor.RegisterPlugin(xcom.RestrictingRule, xplugin.RestrictingInstance())
+	reactor.RegisterPlugin(xcom.RewardRule, xplugin.RewardMgrInstance())
+	xplugin.GovPluginInstance().SetChainID(reactor.GetChainID())
+	xplugin.GovPluginInstance().SetChainDB(discard)
+	reactor.RegisterPlugin(xcom.GovernanceRule, xplugin.GovPluginInstance())
+	xplugin.StakingInstance().SetChainDB(chainDb, discard)
+	reactor.SetBeginRule([]int{xcom.StakingRule, xcom.SlashingRule, xcom.CollectDeclareVersionRule, xcom.GovernanceRule})
+	reactor.SetEndRule([]int{xcom.CollectDeclareVersionRule, xcom.RestrictingRule, xcom.RewardRule, xcom.GovernanceRule, xcom.StakingRule})
+	gov.RegisterGovernParamVerifiers()
+}
+
 func inspect(ctx *cli.Context) error {
 	node, _ := makeConfigNode(ctx)
 	defer node.Close()
@@ -448,6 +628,81 @@ func inspect(ctx *cli.Context) error {
 	return rawdb.InspectDatabase(chainDb)
 }
 
+func checkpoint(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+	sdb, err := snapshotdb.Open(stack.ResolvePath(snapshotdb.DBPath), 0, 0, false)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshotdb: %v", err)
+	}
+	defer sdb.Close()
+
+	var number uint64
+	if ctx.NArg() > 0 {
+		if number, err = strconv.ParseUint(ctx.Args().First(), 10, 64); err != nil {
+			utils.Fatalf("Invalid block number: %v", err)
+		}
+	} else {
+		base, err := sdb.BaseNum()
+		if err != nil {
+			utils.Fatalf("Failed to read the base block of snapshotdb: %v", err)
+		}
+		number = base.Uint64()
+	}
+	hash := rawdb.ReadCanonicalHash(chainDb, number)
+	if hash == (common.Hash{}) {
+		utils.Fatalf("Block %d not found", number)
+	}
+	validators, err := xplugin.LoadValidators(sdb, number)
+	if err != nil {
+		utils.Fatalf("Failed to load the validators of block %d: %v", number, err)
+	}
+	cp := &downloader.Checkpoint{Number: number, Hash: hash, Validators: validators.Digest()}
+	fmt.Println(cp)
+	return nil
+}
+
+func dumpPPOS(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+	sdb, err := snapshotdb.Open(stack.ResolvePath(snapshotdb.DBPath), 0, 0, false)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshotdb: %v", err)
+	}
+	defer sdb.Close()
+
+	stateAt := func(number uint64) (xcom.StateDB, error) {
+		hash := rawdb.ReadCanonicalHash(chainDb, number)
+		header := rawdb.ReadHeader(chainDb, hash, number)
+		if header == nil {
+			log.Warn("Base block not found, leaving the proposals and the restricting plans out", "number", number)
+			return nil, nil
+		}
+		statedb, err := state.New(header.Root, state.NewDatabase(chainDb))
+		if err != nil {
+			log.Warn("Base block state not available, leaving the proposals and the restricting plans out", "number", number, "err", err)
+			return nil, nil
+		}
+		return statedb, nil
+	}
+	dump, err := xplugin.DumpPPOS(sdb, stateAt)
+	if err != nil {
+		utils.Fatalf("Failed to dump the ppos data: %v", err)
+	}
+	out, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
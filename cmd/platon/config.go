@@ -183,6 +183,10 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	snapshotdb.SetDBPathWithNode(stack.ResolvePath(snapshotdb.DBPath))
 
 	backend := utils.RegisterEthService(stack, &cfg.Eth)
+	if backend == nil {
+		// The light client provides its own APIs only.
+		return stack, nil
+	}
 
 	// Configure GraphQL if requested
 	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
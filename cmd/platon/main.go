@@ -64,7 +64,7 @@ var (
 		utils.PasswordFileFlag,
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
-		//	utils.BootnodesV5Flag,
+		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
 		utils.KeyStoreDirFlag,
@@ -73,6 +73,7 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolRemoteJournalFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
@@ -82,6 +83,8 @@ var (
 		utils.TxPoolLifetimeFlag,
 		utils.TxPoolCacheSizeFlag,
 		utils.SyncModeFlag,
+		utils.LightServeFlag,
+		utils.CheckpointFlag,
 		utils.TxLookupLimitFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
@@ -102,6 +105,7 @@ var (
 		utils.NetrestrictFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
+		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.MainFlag,
 		utils.TestnetFlag,
@@ -122,6 +126,9 @@ var (
 		utils.HTTPPortFlag,
 		utils.HTTPCORSDomainFlag,
 		utils.HTTPVirtualHostsFlag,
+		utils.HTTPJWTSecretFlag,
+		utils.HTTPRateLimitFlag,
+		utils.HTTPRateBurstFlag,
 		utils.LegacyRPCEnabledFlag,
 		utils.LegacyRPCListenAddrFlag,
 		utils.LegacyRPCPortFlag,
@@ -141,6 +148,9 @@ var (
 		utils.WSApiFlag,
 		utils.LegacyWSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.WSJWTSecretFlag,
+		utils.WSRateLimitFlag,
+		utils.WSRateBurstFlag,
 		utils.LegacyWSAllowedOriginsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
@@ -168,6 +178,7 @@ var (
 		utils.CbftWalDisabledFlag,
 		utils.CbftMaxPingLatency,
 		utils.CbftBlsPriKeyFileFlag,
+		utils.CbftSignerFlag,
 		utils.CbftBlacklistDeadlineFlag,
 	}
 
@@ -203,6 +214,10 @@ func init() {
 		dumpCommand,
 		dumpGenesisCommand,
 		inspectCommand,
+		checkpointCommand,
+		verifyParallelCommand,
+		dumpPPOSCommand,
+		pposBisectCommand,
 		// See accountcmd.go:
 		accountCommand,
 		// See consolecmd.go:
@@ -364,7 +379,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend) {
 	// Start auxiliary services if enabled
 	// Mining only makes sense if a full Ethereum node is running
 	if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
-		utils.Fatalf("Light clients do not support mining")
+		return
 	}
 	ethBackend, ok := backend.(*eth.EthAPIBackend)
 	if !ok {
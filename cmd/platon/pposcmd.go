@@ -0,0 +1,253 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/node"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	xplugin "github.com/PlatONnetwork/PlatON-Go/x/plugin"
+)
+
+var (
+	pposBisectCommand = cli.Command{
+		Action:    utils.MigrateFlags(pposBisect),
+		Name:      "ppos-bisect",
+		Usage:     "Find the first ppos key two nodes disagree on",
+		ArgsUsage: "<endpoint|datadir> <endpoint|datadir>",
+		Flags: []cli.Flag{
+			pposBlockFlag,
+			pposPrefixFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The ppos-bisect command compares the ppos data kept in the snapshot db of two
+nodes at a block, first per plugin domain, then key prefix by key prefix with
+debug_pposStateHash until it reaches the first key they disagree on, whose
+values are printed.
+
+Each node is given either as an RPC endpoint exposing the debug API (an IPC
+path, an http or a ws URL) or as the data directory of a stopped node. The block
+must be a canonical block of both nodes lying between the base and the highest
+block of their snapshot db, the lower of their latest blocks being used by
+default. A running node keeps compacting its snapshot db, its base trailing the
+head by some blocks only: pick a recent block, the command fails if the block
+falls below the base of a node during the walk.`,
+	}
+	pposBlockFlag = cli.StringFlag{
+		Name:  "block",
+		Usage: "Block number to compare the nodes at",
+		Value: "latest",
+	}
+	pposPrefixFlag = cli.StringFlag{
+		Name:  "prefix",
+		Usage: "Hex encoded key prefix to restrict the comparison to",
+	}
+)
+
+// pposSource computes the ppos digests of a node, at its latest block if the
+// number is nil.
+type pposSource interface {
+	stateHash(number *uint64, prefix []byte) (*xplugin.PPOSStateHash, error)
+	close()
+}
+
+// rpcPPOSSource asks a running node for the digests.
+type rpcPPOSSource struct {
+	client *rpc.Client
+}
+
+func (s *rpcPPOSSource) stateHash(number *uint64, prefix []byte) (*xplugin.PPOSStateHash, error) {
+	block := "latest"
+	if number != nil {
+		block = hexutil.EncodeUint64(*number)
+	}
+	var result *xplugin.PPOSStateHash
+	if err := s.client.Call(&result, "debug_pposStateHash", block, hexutil.Bytes(prefix)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *rpcPPOSSource) close() {
+	s.client.Close()
+}
+
+// localPPOSSource computes the digests from the databases of a stopped node.
+type localPPOSSource struct {
+	chainDb ethdb.Database
+	sdb     snapshotdb.DB
+}
+
+func (s *localPPOSSource) stateHash(number *uint64, prefix []byte) (*xplugin.PPOSStateHash, error) {
+	if number == nil {
+		highest := s.sdb.GetCurrent().GetHighest(true)
+		return xplugin.PPOSStateHashAt(s.sdb, highest.Num.Uint64(), highest.Hash, prefix)
+	}
+	hash := rawdb.ReadCanonicalHash(s.chainDb, *number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block #%d not found", *number)
+	}
+	return xplugin.PPOSStateHashAt(s.sdb, *number, hash, prefix)
+}
+
+func (s *localPPOSSource) close() {
+	s.sdb.Close()
+	s.chainDb.Close()
+}
+
+// openPPOSSource opens the data directory of a stopped node if the argument is
+// a directory, or dials the RPC endpoint otherwise.
+func openPPOSSource(arg string) (pposSource, error) {
+	if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+		client, err := dialRPC(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &rpcPPOSSource{client: client}, nil
+	}
+	cfg := &node.Config{DataDir: arg, Name: clientIdentifier}
+	chaindata := cfg.ResolvePath("chaindata")
+	chainDb, err := rawdb.NewLevelDBDatabaseWithFreezer(chaindata, 0, 0, filepath.Join(chaindata, "ancient"), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the chain db, is the node running? %v", err)
+	}
+	sdb, err := snapshotdb.Open(cfg.ResolvePath(snapshotdb.DBPath), 0, 0, false)
+	if err != nil {
+		chainDb.Close()
+		return nil, fmt.Errorf("failed to open the snapshot db: %v", err)
+	}
+	return &localPPOSSource{chainDb: chainDb, sdb: sdb}, nil
+}
+
+// pposBisect compares the ppos digests of two nodes and bisects them down to the
+// first differing key.
+func pposBisect(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	var prefix []byte
+	if hex := ctx.String(pposPrefixFlag.Name); hex != "" {
+		var err error
+		if prefix, err = hexutil.Decode(hex); err != nil {
+			utils.Fatalf("Invalid prefix: %v", err)
+		}
+	}
+	var number *uint64
+	if block := ctx.String(pposBlockFlag.Name); block != "latest" {
+		n, err := strconv.ParseUint(block, 0, 64)
+		if err != nil {
+			utils.Fatalf("Invalid block number %q", block)
+		}
+		number = &n
+	}
+
+	var sources [2]pposSource
+	for i := range sources {
+		var err error
+		if sources[i], err = openPPOSSource(ctx.Args().Get(i)); err != nil {
+			utils.Fatalf("Failed to open %s: %v", ctx.Args().Get(i), err)
+		}
+		defer sources[i].close()
+	}
+	// Pin the block so that both nodes are compared at the same height, by
+	// default the lower of their latest blocks which both of them hold
+	ha, err := sources[0].stateHash(number, prefix)
+	if err != nil {
+		utils.Fatalf("Failed to hash the first node: %v", err)
+	}
+	hb, err := sources[1].stateHash(number, prefix)
+	if err != nil {
+		utils.Fatalf("Failed to hash the second node: %v", err)
+	}
+	switch {
+	case ha.Number < hb.Number:
+		hb, err = sources[1].stateHash(&ha.Number, prefix)
+	case hb.Number < ha.Number:
+		ha, err = sources[0].stateHash(&hb.Number, prefix)
+	}
+	if err != nil {
+		utils.Fatalf("Failed to hash the nodes at the lower latest block: %v", err)
+	}
+	number = &ha.Number
+	fmt.Printf("Block #%d\n  a: %#x\n  b: %#x\n", ha.Number, ha.Hash, hb.Hash)
+	if ha.Hash != hb.Hash {
+		fmt.Println("The nodes are on different blocks at this height")
+	}
+	printPPOSDomains(ha, hb)
+
+	hashAt := func(source pposSource) func([]byte) (*xplugin.PPOSStateHash, error) {
+		return func(prefix []byte) (*xplugin.PPOSStateHash, error) {
+			return source.stateHash(number, prefix)
+		}
+	}
+	div, err := xplugin.BisectPPOS(hashAt(sources[0]), hashAt(sources[1]), prefix)
+	if err != nil {
+		utils.Fatalf("Failed to bisect: %v", err)
+	}
+	if div == nil {
+		fmt.Printf("No divergence under prefix %s (%d entries)\n", hexutil.Encode(prefix), ha.Count)
+		return nil
+	}
+	fmt.Printf("First differing key %s (%s)\n  a: %s\n  b: %s\n", div.Key, div.Domain, div.A, div.B)
+	return nil
+}
+
+// printPPOSDomains prints the digests of the domains of both nodes.
+func printPPOSDomains(a, b *xplugin.PPOSStateHash) {
+	names := make(map[string]struct{})
+	for name := range a.Domains {
+		names[name] = struct{}{}
+	}
+	for name := range b.Domains {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	empty := new(xplugin.PPOSDigest)
+	for _, name := range sorted {
+		da, db := a.Domains[name], b.Domains[name]
+		if da == nil {
+			da = empty
+		}
+		if db == nil {
+			db = empty
+		}
+		status := "equal"
+		if da.Digest != db.Digest || da.Count != db.Count {
+			status = "differ"
+		}
+		fmt.Printf("Domain %-8s %-6s a: %d entries %#x, b: %d entries %#x\n", name, status, da.Count, da.Digest, db.Count, db.Digest)
+	}
+}
@@ -75,6 +75,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MainFlag,
 			utils.TestnetFlag,
 			utils.SyncModeFlag,
+			utils.LightServeFlag,
+			utils.CheckpointFlag,
 			//	utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.TxLookupLimitFlag,
@@ -84,6 +86,7 @@ var AppHelpFlagGroups = []flagGroup{
 	{
 		Name: "DEVELOPER CHAIN",
 		Flags: []cli.Flag{
+			utils.DeveloperFlag,
 			utils.DeveloperPeriodFlag,
 		},
 	},
@@ -94,6 +97,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolRemoteJournalFlag,
 			utils.TxPoolPriceBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
@@ -136,11 +140,17 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.HTTPEnabledEthCompatibleFlag,
 			utils.HTTPCORSDomainFlag,
 			utils.HTTPVirtualHostsFlag,
+			utils.HTTPJWTSecretFlag,
+			utils.HTTPRateLimitFlag,
+			utils.HTTPRateBurstFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.WSJWTSecretFlag,
+			utils.WSRateLimitFlag,
+			utils.WSRateBurstFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
@@ -157,7 +167,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.BootnodesFlag,
 			utils.BootnodesV4Flag,
-			//	utils.BootnodesV5Flag,
+			utils.BootnodesV5Flag,
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxConsensusPeersFlag,
@@ -201,6 +211,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CbftWalDisabledFlag,
 			utils.CbftMaxPingLatency,
 			utils.CbftBlsPriKeyFileFlag,
+			utils.CbftSignerFlag,
 			utils.CbftBlacklistDeadlineFlag,
 		},
 	},
@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/graphql"
 	"github.com/PlatONnetwork/PlatON-Go/internal/ethapi"
+	"github.com/PlatONnetwork/PlatON-Go/light"
 
 	"github.com/PlatONnetwork/PlatON-Go/miner"
 
@@ -46,6 +48,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/common/fdlimit"
 	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/signer"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/core"
 	types2 "github.com/PlatONnetwork/PlatON-Go/core/types"
@@ -63,9 +66,11 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/node"
 	"github.com/PlatONnetwork/PlatON-Go/p2p"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discv5"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/nat"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/netutil"
 	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
 )
 
 var (
@@ -174,6 +179,10 @@ var (
 		Name:  "addressHRP",
 		Usage: "set the address hrp,if not set,use default address hrp",
 	}
+	DeveloperFlag = cli.BoolFlag{
+		Name:  "dev",
+		Usage: "Ephemeral single validator network with a pre-funded developer account, mining enabled",
+	}
 	DeveloperPeriodFlag = cli.IntFlag{
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
@@ -193,6 +202,14 @@ var (
 		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
+	LightServeFlag = cli.BoolFlag{
+		Name:  "light.serve",
+		Usage: "Serve the light clients by the plight protocol",
+	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Trusted cbft finalized block to start the fast sync from (number:hash:validators, see 'platon checkpoint')",
+	}
 	TxLookupLimitFlag = cli.Uint64Flag{
 		Name:  "txlookuplimit",
 		Usage: "Number of recent blocks to maintain transactions index by-hash for (default = index all blocks)",
@@ -221,6 +238,10 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote transactions to survive node restarts, regenerated at the rejournal interval (disabled if empty)",
+	}
 	TxPoolPriceBumpFlag = cli.Uint64Flag{
 		Name:  "txpool.pricebump",
 		Usage: "Price bump percentage to replace an already existing transaction",
@@ -377,6 +398,18 @@ var (
 		Name:  "http.ethcompatible",
 		Usage: "Enable eth compatible",
 	}
+	HTTPJWTSecretFlag = cli.StringFlag{
+		Name:  "http.jwtsecret",
+		Usage: "Path of the hex encoded secret of the JWT tokens required by the HTTP-RPC server (generated if missing)",
+	}
+	HTTPRateLimitFlag = cli.Float64Flag{
+		Name:  "http.ratelimit",
+		Usage: "Calls per second allowed to each HTTP-RPC client (0 = unlimited)",
+	}
+	HTTPRateBurstFlag = cli.IntFlag{
+		Name:  "http.rateburst",
+		Usage: "Calls each HTTP-RPC client may make in a burst (0 = rate limit)",
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -415,6 +448,18 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	WSJWTSecretFlag = cli.StringFlag{
+		Name:  "ws.jwtsecret",
+		Usage: "Path of the hex encoded secret of the JWT tokens required by the WS-RPC server (generated if missing)",
+	}
+	WSRateLimitFlag = cli.Float64Flag{
+		Name:  "ws.ratelimit",
+		Usage: "Calls per second allowed to each WS-RPC client (0 = unlimited)",
+	}
+	WSRateBurstFlag = cli.IntFlag{
+		Name:  "ws.rateburst",
+		Usage: "Calls each WS-RPC client may make in a burst (0 = rate limit)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -459,12 +504,11 @@ var (
 		Usage: "Comma separated enode URLs for P2P v4 discovery bootstrap (light server, full nodes)",
 		Value: "",
 	}
-	/*
-		BootnodesV5Flag = cli.StringFlag{
-			Name:  "bootnodesv5",
-			Usage: "Comma separated enode URLs for P2P v5 discovery bootstrap (light server, light nodes)",
-			Value: "",
-		}*/
+	BootnodesV5Flag = cli.StringFlag{
+		Name:  "bootnodesv5",
+		Usage: "Comma separated enode URLs for P2P v5 discovery bootstrap",
+		Value: "",
+	}
 	NodeKeyFileFlag = cli.StringFlag{
 		Name:  "nodekey",
 		Usage: "P2P node key file",
@@ -596,6 +640,11 @@ var (
 		Usage: "BLS key file",
 	}
 
+	CbftSignerFlag = cli.StringFlag{
+		Name:  "cbft.signer",
+		Usage: "Endpoint of the consensus signer holding the node and BLS keys (IPC path or http/ws url)",
+	}
+
 	CbftBlacklistDeadlineFlag = cli.StringFlag{
 		Name:  "cbft.blacklist_deadline",
 		Usage: "Blacklist effective time. uint:minute",
@@ -707,6 +756,8 @@ func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 		}
 	case ctx.GlobalBool(TestnetFlag.Name):
 		urls = params.TestnetBootnodes
+	case ctx.GlobalBool(DeveloperFlag.Name):
+		urls = nil // --dev mode can't use p2p networking.
 	case cfg.BootstrapNodes != nil:
 		return // already set, don't apply defaults.
 	}
@@ -724,8 +775,8 @@ func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 }
 
 // setBootstrapNodesV5 creates a list of bootstrap nodes from the command line
-// flags, reverting to pre-configured ones if none have been specified.
-/*
+// flags, reverting to pre-configured ones if none have been specified. The v4
+// bootnodes are used if there are no v5 ones, both protocols share the port.
 func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 	urls := params.DiscoveryV5Bootnodes
 	switch {
@@ -741,6 +792,9 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 
 	cfg.BootstrapNodesV5 = make([]*discv5.Node, 0, len(urls))
 	for _, url := range urls {
+		if url == "" {
+			continue
+		}
 		node, err := discv5.ParseNode(url)
 		if err != nil {
 			log.Error("Bootstrap URL invalid", "enode", url, "err", err)
@@ -748,7 +802,12 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.BootstrapNodesV5 = append(cfg.BootstrapNodesV5, node)
 	}
-}*/
+	if len(cfg.BootstrapNodesV5) == 0 {
+		for _, n := range cfg.BootstrapNodes {
+			cfg.BootstrapNodesV5 = append(cfg.BootstrapNodesV5, discv5.NewNode(discv5.NodeID(n.ID), n.IP, n.UDP, n.TCP))
+		}
+	}
+}
 
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
@@ -837,6 +896,16 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
+
+	if ctx.GlobalIsSet(HTTPJWTSecretFlag.Name) {
+		cfg.HTTPAuth.JWTSecret = ctx.GlobalString(HTTPJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPRateLimitFlag.Name) {
+		cfg.HTTPAuth.RateLimit = ctx.GlobalFloat64(HTTPRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPRateBurstFlag.Name) {
+		cfg.HTTPAuth.RateBurst = ctx.GlobalInt(HTTPRateBurstFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -886,6 +955,16 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSApiFlag.Name) {
 		cfg.WSModules = SplitAndTrim(ctx.GlobalString(WSApiFlag.Name))
 	}
+
+	if ctx.GlobalIsSet(WSJWTSecretFlag.Name) {
+		cfg.WSAuth.JWTSecret = ctx.GlobalString(WSJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSRateLimitFlag.Name) {
+		cfg.WSAuth.RateLimit = ctx.GlobalFloat64(WSRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSRateBurstFlag.Name) {
+		cfg.WSAuth.RateBurst = ctx.GlobalInt(WSRateBurstFlag.Name)
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -965,7 +1044,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setNAT(ctx, cfg)
 	setListenAddress(ctx, cfg)
 	setBootstrapNodes(ctx, cfg)
-	// setBootstrapNodesV5(ctx, cfg)
+	setBootstrapNodesV5(ctx, cfg)
 
 	if ctx.GlobalIsSet(MaxConsensusPeersFlag.Name) {
 		cfg.MaxConsensusPeers = ctx.GlobalInt(MaxConsensusPeersFlag.Name)
@@ -1012,6 +1091,14 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
+	if ctx.GlobalBool(DeveloperFlag.Name) {
+		// --dev mode can't use p2p networking.
+		cfg.MaxPeers = 0
+		cfg.MaxConsensusPeers = 0
+		cfg.ListenAddr = ":0"
+		cfg.NoDiscovery = true
+		cfg.DiscoveryV5 = false
+	}
 }
 
 // SetNodeConfig applies node-related command line flags to the config.
@@ -1028,6 +1115,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = ctx.GlobalString(DataDirFlag.Name)
 	case ctx.GlobalBool(TestnetFlag.Name):
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "testnet")
+	case ctx.GlobalBool(DeveloperFlag.Name):
+		// The snapshotdb and the WAL live on disk, so the ephemeral developer
+		// chain runs in a fresh temporary directory.
+		dir, err := ioutil.TempDir("", "platon-dev-")
+		if err != nil {
+			Fatalf("Failed to create the developer data directory: %v", err)
+		}
+		cfg.DataDir = dir
 	}
 
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
@@ -1076,6 +1171,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
 	}
@@ -1111,7 +1209,7 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	// Avoid conflicting network flags
-	CheckExclusive(ctx, TestnetFlag)
+	CheckExclusive(ctx, DeveloperFlag, TestnetFlag)
 
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
@@ -1125,6 +1223,16 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightServeFlag.Name) {
+		cfg.LightServe = ctx.GlobalBool(LightServeFlag.Name)
+	}
+	if ctx.GlobalIsSet(CheckpointFlag.Name) {
+		cp, err := downloader.ParseCheckpoint(ctx.GlobalString(CheckpointFlag.Name))
+		if err != nil {
+			Fatalf("Invalid --%s: %v", CheckpointFlag.Name, err)
+		}
+		cfg.Checkpoint = cp
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
@@ -1176,6 +1284,58 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 			cfg.NetworkId = 2000
 		}
 		cfg.Genesis = core.DefaultTestnetGenesisBlock()
+
+	case ctx.GlobalBool(DeveloperFlag.Name):
+		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
+			cfg.NetworkId = 1337
+		}
+		// Create new developer account or reuse existing one
+		var (
+			developer  accounts.Account
+			passphrase string
+			err        error
+		)
+		if list := MakePasswordList(ctx); len(list) > 0 {
+			passphrase = list[0]
+		}
+		ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+		if len(ks.Accounts()) > 0 {
+			developer = ks.Accounts()[0]
+		} else {
+			developer, err = ks.NewAccount(passphrase)
+			if err != nil {
+				Fatalf("Failed to create developer account: %v", err)
+			}
+		}
+		if err := ks.Unlock(developer, passphrase); err != nil {
+			Fatalf("Failed to unlock developer account: %v", err)
+		}
+		log.Info("Using developer account", "address", developer.Address)
+
+		// The node is the only validator, with the keys the consensus signs with
+		nodeID := cfg.CbftConfig.NodeID
+		if (nodeID == discover.NodeID{}) {
+			nodeID = discover.PubkeyID(&stack.Config().NodeKey().PublicKey)
+		}
+		validator := params.CbftNode{
+			Node:      *discover.NewNode(nodeID, net.IPv4(127, 0, 0, 1), 0, 0),
+			BlsPubKey: stack.Config().P2P.BlsPublicKey,
+		}
+		period := uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name))
+		if period == 0 {
+			// Seal a block per second, only when there are transactions to include
+			period, cfg.Miner.OnDemand = 1, true
+		}
+		cfg.Genesis = core.DeveloperGenesisBlock(period, validator, developer.Address)
+
+		// The genesis checks and the plugins read the economic model from xcom
+		_, ece := xcom.DeveloperEconomicModel()
+		xcom.ResetEconomicDefaultConfig(cfg.Genesis.EconomicModel)
+		xcom.ResetEconomicExtendConfig(ece)
+		xcom.SetPerRoundBlocks(uint64(cfg.Genesis.Config.Cbft.Amount))
+		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) {
+			cfg.Miner.GasPrice = big.NewInt(1)
+		}
 	}
 
 	if ctx.GlobalIsSet(DBNoGCFlag.Name) {
@@ -1223,16 +1383,28 @@ func SetCbft(ctx *cli.Context, cfg *types.OptionsConfig, nodeCfg *node.Config) {
 		cfg.NodeID = discover.PubkeyID(&cfg.NodePriKey.PublicKey)
 	}
 
-	if ctx.GlobalIsSet(CbftBlsPriKeyFileFlag.Name) {
+	if ctx.GlobalIsSet(CbftSignerFlag.Name) {
+		remote, err := signer.Dial(ctx.GlobalString(CbftSignerFlag.Name))
+		if err != nil {
+			Fatalf("Failed to connect to the consensus signer: %v", err)
+		}
+		cfg.Signer = remote
+		cfg.NodeID = remote.NodeID()
+		if cfg.NodePriKey != nil && cfg.NodeID != discover.PubkeyID(&cfg.NodePriKey.PublicKey) {
+			log.Warn("Consensus signer signs for another node id than the p2p key", "signer", cfg.NodeID.TerminalString())
+		}
+		nodeCfg.P2P.BlsPublicKey = *remote.BlsPublicKey()
+	} else if ctx.GlobalIsSet(CbftBlsPriKeyFileFlag.Name) {
 		priKey, err := bls.LoadBLS(ctx.GlobalString(CbftBlsPriKeyFileFlag.Name))
 		if err != nil {
 			Fatalf("Failed to load bls key from file: %v", err)
 		}
 		cfg.BlsPriKey = priKey
+		nodeCfg.P2P.BlsPublicKey = *(cfg.BlsPriKey.GetPublicKey())
 	} else {
 		cfg.BlsPriKey = nodeCfg.BlsKey()
+		nodeCfg.P2P.BlsPublicKey = *(cfg.BlsPriKey.GetPublicKey())
 	}
-	nodeCfg.P2P.BlsPublicKey = *(cfg.BlsPriKey.GetPublicKey())
 
 	if ctx.GlobalIsSet(CbftWalDisabledFlag.Name) {
 		cfg.WalMode = !ctx.GlobalBool(CbftWalDisabledFlag.Name)
@@ -1254,13 +1426,20 @@ func SetCbft(ctx *cli.Context, cfg *types.OptionsConfig, nodeCfg *node.Config) {
 // RegisterEthService adds an Ethereum client to the stack.
 func RegisterEthService(stack *node.Node, cfg *eth.Config) ethapi.Backend {
 	if cfg.SyncMode == downloader.LightSync {
-		Fatalf("Failed to register the Platon service: not les")
+		if _, err := light.New(stack, cfg); err != nil {
+			Fatalf("Failed to register the light client: %v", err)
+		}
 		return nil
 	} else {
 		backend, err := eth.New(stack, cfg)
 		if err != nil {
 			Fatalf("Failed to register the Ethereum service: %v", err)
 		}
+		if cfg.LightServe {
+			server := light.NewServer(backend.BlockChain(), backend.ChainDb(), cfg.NetworkId)
+			stack.RegisterProtocols(server.Protocols())
+			stack.RegisterLifecycle(server)
+		}
 		return backend.APIBackend
 	}
 }
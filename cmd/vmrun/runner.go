@@ -0,0 +1,325 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+	"github.com/PlatONnetwork/PlatON-Go/cmd/utils"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm/runtime"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+var runCommand = cli.Command{
+	Action:    runCmd,
+	Name:      "run",
+	Usage:     "run arbitrary EVM or WASM code",
+	ArgsUsage: "<code>",
+	Description: `The run command runs arbitrary EVM or WASM code. WASM code is told apart by its magic number, a WASM
+creation deploys the module and calls its init function with the input as arguments.`,
+}
+
+// setupVM prepares the process wide state the virtual machines rely on.
+func setupVM(ctx *cli.Context) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	// The precompiled system contracts read the economic model and the
+	// snapshot db, keep the latter in memory.
+	xcom.GetEc(xcom.DefaultMainNet)
+	snapshotdb.SetDBMemory(true)
+}
+
+// readGenesis loads the prestate file.
+func readGenesis(path string) (*core.Genesis, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gen := new(core.Genesis)
+	if err := json.Unmarshal(data, gen); err != nil {
+		return nil, fmt.Errorf("invalid prestate %s: %v", path, err)
+	}
+	return gen, nil
+}
+
+// makeState creates an in-memory state holding the accounts. The preimages of
+// the keys are recorded so that the state can be dumped.
+func makeState(alloc core.GenesisAlloc) (*state.StateDB, error) {
+	sdb := state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Preimages: true})
+	statedb, err := state.New(common.Hash{}, sdb)
+	if err != nil {
+		return nil, err
+	}
+	for addr, account := range alloc {
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance)
+		}
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key.Bytes(), value.Bytes())
+		}
+	}
+	return statedb, nil
+}
+
+// readCode returns the code of the code flags, nil if none is set. WASM
+// binaries are read as is, everything else is hex decoded.
+func readCode(ctx *cli.Context) ([]byte, error) {
+	var data []byte
+	switch {
+	case ctx.GlobalString(CodeFileFlag.Name) == "-":
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not load code from stdin: %v", err)
+		}
+		data = input
+	case ctx.GlobalString(CodeFileFlag.Name) != "":
+		input, err := ioutil.ReadFile(ctx.GlobalString(CodeFileFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("could not load code from file: %v", err)
+		}
+		data = input
+	case ctx.GlobalString(CodeFlag.Name) != "":
+		data = []byte(ctx.GlobalString(CodeFlag.Name))
+	case ctx.Args().First() != "":
+		data = []byte(ctx.Args().First())
+	default:
+		return nil, nil
+	}
+	if bytes.HasPrefix(data, wasm.Magic) {
+		return data, nil
+	}
+	return decodeHex(string(data))
+}
+
+// readInput returns the input of the input flags.
+func readInput(ctx *cli.Context) ([]byte, error) {
+	if path := ctx.GlobalString(InputFileFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load input from file: %v", err)
+		}
+		return decodeHex(string(data))
+	}
+	return decodeHex(ctx.GlobalString(InputFlag.Name))
+}
+
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	return hex.DecodeString(s)
+}
+
+// wasmCall packs the call of the WASM function, the arguments being the RLP
+// encoded values one after the other.
+func wasmCall(name string, args []byte) ([]byte, error) {
+	if _, err := rlp.CountValues(args); err != nil {
+		return nil, fmt.Errorf("invalid WASM arguments: %v", err)
+	}
+	return rlp.EncodeToBytes([]interface{}{wasm.FuncID(name), rlp.RawValue(args)})
+}
+
+// wasmDeploy packs the deployment of the WASM module, the init function being
+// called with the arguments.
+func wasmDeploy(code, args []byte) ([]byte, error) {
+	init, err := wasmCall(wasm.InitMethod, args)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes([][]byte{code, init})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, wasm.Magic...), data...), nil
+}
+
+// parseAddress parses the address flag, returning the fallback if it's unset.
+func parseAddress(ctx *cli.Context, flag cli.StringFlag, fallback string) (common.Address, error) {
+	if ctx.GlobalString(flag.Name) == "" {
+		return common.BytesToAddress([]byte(fallback)), nil
+	}
+	var addr common.Address
+	if err := addr.UnmarshalText([]byte(ctx.GlobalString(flag.Name))); err != nil {
+		return common.Address{}, fmt.Errorf("invalid --%s: %v", flag.Name, err)
+	}
+	return addr, nil
+}
+
+func runCmd(ctx *cli.Context) error {
+	setupVM(ctx)
+
+	logconfig := &vm.LogConfig{
+		DisableMemory:     ctx.GlobalBool(DisableMemoryFlag.Name),
+		DisableStack:      ctx.GlobalBool(DisableStackFlag.Name),
+		DisableStorage:    ctx.GlobalBool(DisableStorageFlag.Name),
+		DisableReturnData: ctx.GlobalBool(DisableReturnDataFlag.Name),
+	}
+	var (
+		tracer      vm.Tracer
+		debugLogger *vm.StructLogger
+		jsonOutput  = ctx.GlobalBool(MachineFlag.Name)
+		chainConfig *params.ChainConfig
+		genesis     = new(core.Genesis)
+	)
+	if jsonOutput {
+		tracer = vm.NewJSONLogger(logconfig, os.Stdout)
+	} else if ctx.GlobalBool(DebugFlag.Name) {
+		debugLogger = vm.NewStructLogger(logconfig)
+		tracer = debugLogger
+	}
+	if path := ctx.GlobalString(GenesisFlag.Name); path != "" {
+		gen, err := readGenesis(path)
+		if err != nil {
+			return err
+		}
+		genesis, chainConfig = gen, gen.Config
+	}
+	statedb, err := makeState(genesis.Alloc)
+	if err != nil {
+		return err
+	}
+	sender, err := parseAddress(ctx, SenderFlag, "sender")
+	if err != nil {
+		return err
+	}
+	receiver, err := parseAddress(ctx, ReceiverFlag, "receiver")
+	if err != nil {
+		return err
+	}
+	code, err := readCode(ctx)
+	if err != nil {
+		return err
+	}
+	input, err := readInput(ctx)
+	if err != nil {
+		return fmt.Errorf("invalid input: %v", err)
+	}
+	create := ctx.GlobalBool(CreateFlag.Name)
+	if code == nil {
+		// Without code, call the one the prestate holds at the receiver.
+		if create || len(statedb.GetCode(receiver)) == 0 {
+			return errors.New("no code specified")
+		}
+		code = statedb.GetCode(receiver)
+	}
+	isWasm := vm.CanUseWASMInterp(code)
+	switch {
+	case isWasm && create:
+		if input, err = wasmDeploy(code, input); err != nil {
+			return err
+		}
+	case isWasm && ctx.GlobalString(WasmFuncFlag.Name) != "":
+		if input, err = wasmCall(ctx.GlobalString(WasmFuncFlag.Name), input); err != nil {
+			return err
+		}
+	case create:
+		input = append(code, input...)
+	}
+
+	runtimeConfig := &runtime.Config{
+		Origin:      sender,
+		State:       statedb,
+		GasLimit:    ctx.GlobalUint64(GasFlag.Name),
+		GasPrice:    utils.GlobalBig(ctx, PriceFlag.Name),
+		Value:       utils.GlobalBig(ctx, ValueFlag.Name),
+		Coinbase:    genesis.Coinbase,
+		BlockNumber: new(big.Int).SetUint64(genesis.Number),
+		ChainConfig: chainConfig,
+		EVMConfig: vm.Config{
+			Tracer: tracer,
+			Debug:  tracer != nil,
+		},
+	}
+	if genesis.Timestamp != 0 {
+		runtimeConfig.Time = new(big.Int).SetUint64(genesis.Timestamp)
+	}
+
+	var (
+		output      []byte
+		address     common.Address
+		leftOverGas uint64
+	)
+	start := time.Now()
+	if create {
+		output, address, leftOverGas, err = runtime.Create(input, runtimeConfig)
+	} else {
+		statedb.SetCode(receiver, code)
+		output, leftOverGas, err = runtime.Call(receiver, input, runtimeConfig)
+	}
+	execTime := time.Since(start)
+	gasUsed := runtimeConfig.GasLimit - leftOverGas
+
+	if ctx.GlobalBool(DumpFlag.Name) {
+		statedb.Commit(true)
+		statedb.IntermediateRoot(true)
+		fmt.Println(string(statedb.Dump(&state.DumpConfig{})))
+	}
+	if debugLogger != nil && !isWasm {
+		// WASM executions aren't traced per instruction.
+		fmt.Fprintln(os.Stderr, "#### TRACE ####")
+		vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+	}
+	logs := statedb.Logs()
+	if jsonOutput {
+		// The tracer already reported the output, the gas used and the error.
+		if len(logs) > 0 {
+			json.NewEncoder(os.Stdout).Encode(struct {
+				Logs []*types.Log `json:"logs"`
+			}{logs})
+		}
+		return nil
+	}
+	fmt.Printf("output:   0x%x\n", output)
+	if create {
+		fmt.Printf("address:  %s\n", address.String())
+	}
+	fmt.Printf("gas used: %d\n", gasUsed)
+	fmt.Printf("time:     %v\n", execTime)
+	if err != nil {
+		fmt.Printf("error:    %v\n", err)
+	}
+	if len(logs) > 0 {
+		fmt.Println("#### LOGS ####")
+		vm.WriteLogs(os.Stdout, logs)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// Tests that the WASM calls and deployments are packed like the ABI does.
+func TestWasmPacking(t *testing.T) {
+	abi, err := wasm.JSON(bytes.NewReader([]byte(`[
+		{"type": "Action", "name": "init", "input": [{"name": "n", "type": "uint64"}]},
+		{"type": "Action", "name": "set", "input": [{"name": "key", "type": "string"}, {"name": "value", "type": "uint32"}]}
+	]`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := append(mustEncode(t, "key"), mustEncode(t, uint32(7))...)
+	have, err := wasmCall("set", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := abi.Pack("set", "key", uint32(7))
+	if !bytes.Equal(have, want) {
+		t.Errorf("call mismatch:\nhave %x\nwant %x", have, want)
+	}
+	if _, err := wasmCall("set", []byte{0x83, 0x01}); err == nil {
+		t.Error("truncated arguments accepted")
+	}
+
+	code, err := ioutil.ReadFile("../../core/vm/testdata/contract_hello.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err = wasmDeploy(code, mustEncode(t, uint64(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = abi.PackDeploy(code, uint64(3))
+	if !bytes.Equal(have, want) {
+		t.Error("deploy data mismatch")
+	}
+}
+
+func mustEncode(t *testing.T, v interface{}) []byte {
+	enc, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of PlatON-Go.
+//
+// PlatON-Go is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// PlatON-Go is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with PlatON-Go. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/tests"
+)
+
+var stateTestCommand = cli.Command{
+	Action:    stateTestCmd,
+	Name:      "statetest",
+	Usage:     "executes the given state tests",
+	ArgsUsage: "<file>",
+	Description: `The statetest command runs the state tests of the JSON file. Without file, the names of the files are
+read from stdin, one per line.`,
+}
+
+// StatetestResult contains the execution status after running a state test, any
+// error that might have occurred and a dump of the final state if requested.
+type StatetestResult struct {
+	Name  string       `json:"name"`
+	Pass  bool         `json:"pass"`
+	Root  *common.Hash `json:"stateRoot,omitempty"`
+	Fork  string       `json:"fork"`
+	Error string       `json:"error,omitempty"`
+	State *state.Dump  `json:"state,omitempty"`
+}
+
+func stateTestCmd(ctx *cli.Context) error {
+	setupVM(ctx)
+
+	if len(ctx.Args().First()) != 0 {
+		return runStateTest(ctx, ctx.Args().First())
+	}
+	// Read the names of the test files from stdin.
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fname := scanner.Text()
+		if len(fname) == 0 {
+			return nil
+		}
+		if err := runStateTest(ctx, fname); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// runStateTest runs the state tests of the file and prints their results.
+func runStateTest(ctx *cli.Context, fname string) error {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	var tests map[string]tests.StateTest
+	if err := json.Unmarshal(src, &tests); err != nil {
+		return err
+	}
+	// Run the tests in a stable order.
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logconfig := &vm.LogConfig{
+		DisableMemory:     ctx.GlobalBool(DisableMemoryFlag.Name),
+		DisableStack:      ctx.GlobalBool(DisableStackFlag.Name),
+		DisableStorage:    ctx.GlobalBool(DisableStorageFlag.Name),
+		DisableReturnData: ctx.GlobalBool(DisableReturnDataFlag.Name),
+	}
+	var (
+		results []StatetestResult
+		failed  int
+	)
+	for _, name := range names {
+		test := tests[name]
+		for _, st := range test.Subtests() {
+			cfg := vm.Config{WasmType: vm.Wagon}
+			var debugLogger *vm.StructLogger
+			if ctx.GlobalBool(MachineFlag.Name) {
+				cfg.Debug, cfg.Tracer = true, vm.NewJSONLogger(logconfig, os.Stderr)
+			} else if ctx.GlobalBool(DebugFlag.Name) {
+				debugLogger = vm.NewStructLogger(logconfig)
+				cfg.Debug, cfg.Tracer = true, debugLogger
+			}
+			result := StatetestResult{Name: name, Fork: st.Fork, Pass: true}
+			statedb, err := test.Run(st, cfg)
+			if err != nil {
+				result.Pass, result.Error = false, err.Error()
+				failed++
+			}
+			if statedb != nil {
+				root := statedb.IntermediateRoot(true)
+				result.Root = &root
+				if ctx.GlobalBool(DumpFlag.Name) {
+					dump := statedb.RawDump(&state.DumpConfig{})
+					result.State = &dump
+				}
+			}
+			if debugLogger != nil {
+				fmt.Fprintf(os.Stderr, "#### TRACE %s/%s/%d ####\n", name, st.Fork, st.Index)
+				vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+			}
+			results = append(results, result)
+		}
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	if failed > 0 {
+		return errors.New("state tests failed")
+	}
+	return nil
+}
@@ -358,8 +358,17 @@ func NewMixedcaseAddressFromString(hexaddr string) (*MixedcaseAddress, error) {
 	return &MixedcaseAddress{addr: BytesToAddress(a), original: hexaddr}, nil
 }
 
-// UnmarshalJSON parses MixedcaseAddress
+// UnmarshalJSON parses MixedcaseAddress, in hex or bech32 format
 func (ma *MixedcaseAddress) UnmarshalJSON(input []byte) error {
+	var original string
+	if err := json.Unmarshal(input, &original); err == nil && !has0xPrefix(original) && IsBech32Address(original) {
+		addr, err := Bech32ToAddress(original)
+		if err != nil {
+			return err
+		}
+		ma.addr, ma.original = addr, original
+		return nil
+	}
 	if err := hexutil.UnmarshalFixedJSON(addressT, input, ma.addr[:]); err != nil {
 		return err
 	}
@@ -368,6 +377,9 @@ func (ma *MixedcaseAddress) UnmarshalJSON(input []byte) error {
 
 // MarshalJSON marshals the original value
 func (ma *MixedcaseAddress) MarshalJSON() ([]byte, error) {
+	if ma.isBech32() {
+		return json.Marshal(ma.original)
+	}
 	if strings.HasPrefix(ma.original, "0x") || strings.HasPrefix(ma.original, "0X") {
 		return json.Marshal(fmt.Sprintf("0x%s", ma.original[2:]))
 	}
@@ -389,7 +401,12 @@ func (ma *MixedcaseAddress) String() string {
 
 // ValidChecksum returns true if the address has valid checksum
 func (ma *MixedcaseAddress) ValidChecksum() bool {
-	return ma.original == ma.addr.Hex()
+	return ma.original == ma.addr.Hex() || ma.original == ma.addr.Bech32()
+}
+
+// isBech32 returns true if the original string is in bech32 format
+func (ma *MixedcaseAddress) isBech32() bool {
+	return !has0xPrefix(ma.original) && IsBech32Address(ma.original)
 }
 
 // Original returns the mixed-case input string
@@ -193,6 +193,19 @@ func TestMixedcaseAccount_Address(t *testing.T) {
 		}
 	}
 
+	// Bech32 addresses carry their own checksum
+	bech32Addr := HexToAddress("0xAe967917c465db8578ca9024c205720b1a3651A9").Bech32()
+	var ma MixedcaseAddress
+	if err := json.Unmarshal([]byte(`"`+bech32Addr+`"`), &ma); err != nil {
+		t.Fatal(err)
+	}
+	if ma.Address() != HexToAddress("0xAe967917c465db8578ca9024c205720b1a3651A9") || !ma.ValidChecksum() {
+		t.Errorf("Bech32 address %v not parsed, got %v", bech32Addr, ma.String())
+	}
+	if enc, _ := json.Marshal(&ma); string(enc) != `"`+bech32Addr+`"` {
+		t.Errorf("Bech32 address %v not kept, got %s", bech32Addr, enc)
+	}
+
 	//These should throw exceptions:
 	var r2 []MixedcaseAddress
 	for _, r := range []string{
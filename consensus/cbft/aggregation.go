@@ -0,0 +1,277 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package cbft
+
+import (
+	"fmt"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+)
+
+// Vote aggregation.
+//
+// Once the chain has activated the version enabling it by a VersionProposal,
+// the validators send their PrepareVote to a few aggregators of the view
+// instead of broadcasting it. The aggregators combine the BLS signatures and
+// broadcast the BlockQuorumCert as soon as it reaches the threshold. While the
+// votes are missing, the aggregators exchange the votes they have as a
+// partial BlockQuorumCert. Nothing changes for the validity of a QC, and the
+// validators falling behind still sync the votes by GetPrepareVote.
+
+// voteAggregatorCount is the number of the validators collecting the votes of a view.
+const voteAggregatorCount = 3
+
+// stateReader reads the state of a block, it is implemented by the block chain cache.
+type stateReader interface {
+	GetState(header *types.Header) (*state.StateDB, error)
+}
+
+// checkVoteAggregation activates the vote aggregation from the epoch if the
+// version of the chain active at the block enables it. Each epoch is checked
+// once, the aggregation stays on once activated.
+func (cbft *Cbft) checkVoteAggregation(epoch uint64, block *types.Block) {
+	if cbft.aggregationEpoch != 0 || epoch <= cbft.aggregationChecked {
+		return
+	}
+	cbft.aggregationChecked = epoch
+	reader, ok := cbft.blockCacheWriter.(stateReader)
+	if !ok {
+		return
+	}
+	st, err := reader.GetState(block.Header())
+	if err != nil {
+		cbft.log.Warn("Failed to read the state for the vote aggregation", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return
+	}
+	if gov.Gte150VersionState(st) {
+		cbft.aggregationEpoch = epoch
+		cbft.log.Info("Vote aggregation is active", "epoch", epoch, "number", block.NumberU64(), "hash", block.Hash())
+	}
+}
+
+// voteAggregationActive returns whether the votes of the current epoch are aggregated.
+func (cbft *Cbft) voteAggregationActive() bool {
+	return cbft.aggregationEpoch != 0 && cbft.state.Epoch() >= cbft.aggregationEpoch
+}
+
+// voteAggregators returns the validators collecting the votes of the view,
+// they rotate with the view starting from the proposer of the next view.
+func (cbft *Cbft) voteAggregators(epoch, viewNumber uint64) []*cbfttypes.ValidateNode {
+	length := cbft.validatorPool.Len(epoch)
+	if length == 0 {
+		return nil
+	}
+	count := voteAggregatorCount
+	if count > length {
+		count = length
+	}
+	nodes := make([]*cbfttypes.ValidateNode, 0, count)
+	for i := 0; i < count; i++ {
+		index := (viewNumber + 1 + uint64(i)) % uint64(length)
+		if node, err := cbft.validatorPool.GetValidatorByIndex(epoch, uint32(index)); err == nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// isVoteAggregator returns whether the local node collects the votes of the view.
+func (cbft *Cbft) isVoteAggregator(epoch, viewNumber uint64) bool {
+	for _, node := range cbft.voteAggregators(epoch, viewNumber) {
+		if node.NodeID == cbft.config.Option.NodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastPrepareVote sends the vote to the aggregators of the view if the
+// vote aggregation is active, otherwise or if no aggregator is reachable the
+// vote is broadcast.
+func (cbft *Cbft) broadcastPrepareVote(vote *protocols.PrepareVote) {
+	if !cbft.voteAggregationActive() {
+		cbft.network.Broadcast(vote)
+		return
+	}
+	sent := false
+	for _, node := range cbft.voteAggregators(vote.Epoch, vote.ViewNumber) {
+		if node.NodeID == cbft.config.Option.NodeID {
+			sent = true
+			continue
+		}
+		if id := node.NodeID.TerminalString(); cbft.network.IsConnected(id) {
+			cbft.network.Send(id, vote)
+			sent = true
+		}
+	}
+	if !sent {
+		cbft.log.Debug("No vote aggregator is connected, broadcast the vote", "vote", vote.String())
+		cbft.network.Broadcast(vote)
+	}
+}
+
+// forwardable returns whether a consensus message is forwarded to the peers,
+// the votes are not forwarded while they are aggregated.
+func (cbft *Cbft) forwardable(msg ctypes.Message) bool {
+	_, isVote := msg.(*protocols.PrepareVote)
+	return !isVote || !cbft.voteAggregationActive()
+}
+
+// sendPartialQC sends the votes collected by the local aggregator for the
+// block index as a partial QC to the other aggregators of the view.
+func (cbft *Cbft) sendPartialQC(index uint32) {
+	epoch, viewNumber := cbft.state.Epoch(), cbft.state.ViewNumber()
+	if !cbft.voteAggregationActive() || !cbft.isVoteAggregator(epoch, viewNumber) {
+		return
+	}
+	qc := cbft.partialPrepareQC(index)
+	if qc == nil {
+		return
+	}
+	for _, node := range cbft.voteAggregators(epoch, viewNumber) {
+		if node.NodeID != cbft.config.Option.NodeID {
+			cbft.network.Send(node.NodeID.TerminalString(), &protocols.BlockQuorumCert{BlockQC: qc})
+		}
+	}
+	cbft.log.Debug("Send partial qc to the vote aggregators", "qc", qc.String())
+}
+
+// partialPrepareQC combines the local votes of the block index with the
+// partial QC received for it.
+func (cbft *Cbft) partialPrepareQC(index uint32) *ctypes.QuorumCert {
+	votes := cbft.state.AllPrepareVoteByIndex(index)
+	partial := cbft.partialQCs[index]
+	if partial == nil {
+		if len(votes) == 0 {
+			return nil
+		}
+		return cbft.generatePrepareQC(votes)
+	}
+	block := cbft.state.ViewBlockByIndex(index)
+	if block == nil || block.Hash() != partial.BlockHash {
+		return nil
+	}
+	return combinePrepareQC(partial, votes)
+}
+
+// aggregatedPrepareQC returns the QC of the block index combined from the
+// partial QC and the local votes, or nil if it does not reach the threshold.
+func (cbft *Cbft) aggregatedPrepareQC(index uint32) *ctypes.QuorumCert {
+	if !cbft.voteAggregationActive() || cbft.partialQCs[index] == nil {
+		return nil
+	}
+	qc := cbft.partialPrepareQC(index)
+	if qc == nil || qc.Len() < cbft.threshold(cbft.currentValidatorLen()) {
+		return nil
+	}
+	return qc
+}
+
+// onPartialQC verifies a partial QC of a block of the current view and
+// keeps it to combine it with the votes.
+func (cbft *Cbft) onPartialQC(id string, block *types.Block, qc *ctypes.QuorumCert) error {
+	if err := cbft.verifyPartialQC(block.NumberU64(), block.Hash(), qc); err != nil {
+		cbft.log.Error("Failed to verify partial qc", "peer", id, "err", err.Error())
+		return err
+	}
+	cbft.addPartialQC(qc)
+	cbft.log.Debug("Receive partial qc", "peer", id, "qc", qc.String())
+	cbft.findQCBlock()
+	return nil
+}
+
+// verifyPartialQC verifies the signature of a QC which has not reached the threshold.
+func (cbft *Cbft) verifyPartialQC(oriNum uint64, oriHash common.Hash, qc *ctypes.QuorumCert) error {
+	if err := cbft.validatorPool.EnableVerifyEpoch(qc.Epoch); err != nil {
+		return err
+	}
+	if qc.ValidatorSet == nil || qc.ValidatorSet.Size() != uint32(cbft.validatorPool.Len(qc.Epoch)) || qc.Len() == 0 {
+		return authFailedError{err: fmt.Errorf("invalid validator set of partial qc")}
+	}
+	if oriNum != qc.BlockNumber || oriHash != qc.BlockHash {
+		return handleError{err: fmt.Errorf("verify partial qc failed,not the corresponding qc,oriNum:%d,oriHash:%s,qcNum:%d,qcHash:%s",
+			oriNum, oriHash.String(), qc.BlockNumber, qc.BlockHash.String())}
+	}
+	return cbft.verifyQCSign(qc)
+}
+
+// addPartialQC keeps the partial QC of the block index. The partial QCs of
+// disjoint validators are merged, otherwise the one with more signers is kept.
+func (cbft *Cbft) addPartialQC(qc *ctypes.QuorumCert) {
+	if cbft.partialQCs == nil {
+		cbft.partialQCs = make(map[uint32]*ctypes.QuorumCert)
+	}
+	old := cbft.partialQCs[qc.BlockIndex]
+	switch {
+	case old == nil || old.BlockHash != qc.BlockHash:
+		cbft.partialQCs[qc.BlockIndex] = qc
+	case old.ValidatorSet.And(qc.ValidatorSet).IsEmpty():
+		if merged := mergePrepareQC(old, qc); merged != nil {
+			cbft.partialQCs[qc.BlockIndex] = merged
+		}
+	case qc.Len() > old.Len():
+		cbft.partialQCs[qc.BlockIndex] = qc
+	}
+}
+
+// combinePrepareQC returns a copy of the QC with the signatures of the votes
+// of the same block it does not cover yet.
+func combinePrepareQC(qc *ctypes.QuorumCert, votes map[uint32]*protocols.PrepareVote) *ctypes.QuorumCert {
+	var aggSig bls.Sign
+	if err := aggSig.Deserialize(qc.Signature.Bytes()); err != nil {
+		return nil
+	}
+	vSet := qc.ValidatorSet.Copy()
+	for _, vote := range votes {
+		if vSet.GetIndex(vote.NodeIndex()) || vote.BlockHash != qc.BlockHash || vote.BlockNumber != qc.BlockNumber {
+			continue
+		}
+		var sig bls.Sign
+		if err := sig.Deserialize(vote.Sign()); err != nil {
+			return nil
+		}
+		aggSig.Add(&sig)
+		vSet.SetIndex(vote.NodeIndex(), true)
+	}
+	combined := *qc
+	combined.ValidatorSet = vSet
+	combined.Signature.SetBytes(aggSig.Serialize())
+	return &combined
+}
+
+// mergePrepareQC merges two QCs of the same block signed by disjoint validators.
+func mergePrepareQC(a, b *ctypes.QuorumCert) *ctypes.QuorumCert {
+	var aggSig, sig bls.Sign
+	if err := aggSig.Deserialize(a.Signature.Bytes()); err != nil {
+		return nil
+	}
+	if err := sig.Deserialize(b.Signature.Bytes()); err != nil {
+		return nil
+	}
+	aggSig.Add(&sig)
+	merged := *a
+	merged.ValidatorSet = a.ValidatorSet.Or(b.ValidatorSet)
+	merged.Signature.SetBytes(aggSig.Serialize())
+	return &merged
+}
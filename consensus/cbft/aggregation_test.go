@@ -0,0 +1,210 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package cbft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/state"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+)
+
+func mockAggregationVotes(view *testView, block *types.Block, indexes ...uint32) map[uint32]*protocols.PrepareVote {
+	votes := make(map[uint32]*protocols.PrepareVote)
+	for _, index := range indexes {
+		engine := view.allCbft[index]
+		votes[index] = mockPrepareVote(engine.config.Option.BlsPriKey, engine.state.Epoch(), engine.state.ViewNumber(),
+			0, index, block.Hash(), block.NumberU64(), nil)
+	}
+	return votes
+}
+
+func TestVoteAggregators(t *testing.T) {
+	view := newTestView(false, testNodeNumber)
+	engine := view.firstCbft
+	epoch := engine.state.Epoch()
+
+	for viewNumber := uint64(0); viewNumber < testNodeNumber; viewNumber++ {
+		nodes := engine.voteAggregators(epoch, viewNumber)
+		assert.Len(t, nodes, voteAggregatorCount)
+		for i, node := range nodes {
+			assert.Equal(t, (viewNumber+1+uint64(i))%testNodeNumber, uint64(node.Index))
+		}
+	}
+	aggregators := 0
+	for _, c := range view.allCbft {
+		if c.isVoteAggregator(epoch, 0) {
+			aggregators++
+		}
+	}
+	assert.Equal(t, voteAggregatorCount, aggregators)
+
+	// The votes are forwarded until the aggregation is active.
+	vote := &protocols.PrepareVote{}
+	assert.False(t, engine.voteAggregationActive())
+	assert.True(t, engine.forwardable(vote))
+	engine.aggregationEpoch = epoch
+	assert.True(t, engine.voteAggregationActive())
+	assert.False(t, engine.forwardable(vote))
+	assert.True(t, engine.forwardable(&protocols.ViewChange{}))
+}
+
+func TestCombinePrepareQC(t *testing.T) {
+	view := newTestView(false, testNodeNumber)
+	engine := view.firstCbft
+	block := NewBlockWithSign(view.genesisBlock.Hash(), 1, view.allNode[0])
+	votes := mockAggregationVotes(view, block, 0, 1, 2, 3)
+
+	partial := mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{0: votes[0], 1: votes[1]})
+	assert.Nil(t, engine.verifyPartialQC(block.NumberU64(), block.Hash(), partial))
+	assert.NotNil(t, engine.verifyPrepareQC(block.NumberU64(), block.Hash(), partial))
+
+	// The votes already covered by the partial qc are skipped.
+	qc := combinePrepareQC(partial, map[uint32]*protocols.PrepareVote{1: votes[1], 2: votes[2]})
+	assert.Equal(t, 3, qc.Len())
+	assert.Equal(t, 2, partial.Len())
+	assert.Nil(t, engine.verifyPrepareQC(block.NumberU64(), block.Hash(), qc))
+
+	// The partial qcs of disjoint validators are merged.
+	engine.addPartialQC(partial)
+	engine.addPartialQC(mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{3: votes[3]}))
+	merged := engine.partialQCs[0]
+	assert.Equal(t, 3, merged.Len())
+	assert.Nil(t, engine.verifyPrepareQC(block.NumberU64(), block.Hash(), merged))
+
+	// Otherwise the one with more signers is kept.
+	engine.addPartialQC(mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{2: votes[2], 3: votes[3]}))
+	assert.Equal(t, merged, engine.partialQCs[0])
+	engine.addPartialQC(mockPrepareQC(testNodeNumber, votes))
+	assert.Equal(t, testNodeNumber, engine.partialQCs[0].Len())
+
+	// A partial qc with a bad signature is rejected.
+	forged := mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{0: votes[0]})
+	forged.ValidatorSet.SetIndex(1, true)
+	assert.NotNil(t, engine.verifyPartialQC(block.NumberU64(), block.Hash(), forged))
+}
+
+// mockActivatedBlock returns a block on top of the genesis whose state has
+// activated the version enabling the vote aggregation, the state is written
+// to the chain of every node.
+func mockActivatedBlock(t *testing.T, view *testView) *types.Block {
+	var root common.Hash
+	for _, node := range view.allNode {
+		st, err := node.chain.StateAt(view.genesisBlock.Root())
+		if err != nil {
+			t.Fatalf("failed to open the genesis state: %v", err)
+		}
+		if err := gov.AddActiveVersion(params.FORKVERSION_1_5_0, 1, st); err != nil {
+			t.Fatalf("failed to activate the version: %v", err)
+		}
+		if root, err = st.Commit(true); err != nil {
+			t.Fatalf("failed to commit the state: %v", err)
+		}
+	}
+	block := NewBlockWithSign(view.genesisBlock.Hash(), 1, view.allNode[0])
+	header := block.Header()
+	header.Root = root
+	return types.NewBlockWithHeader(header)
+}
+
+func TestVoteAggregationMultiNode(t *testing.T) {
+	view := newTestView(false, testNodeNumber)
+	epoch := view.Epoch()
+	activated := mockActivatedBlock(t, view)
+	activatedQC := mockBlockQC(view.allNode, activated, 0, nil).BlockQC
+
+	// The epoch was checked when the engines started, the version activated
+	// within the epoch only enables the aggregation from the next one.
+	for _, c := range view.allCbft {
+		c.checkVoteAggregation(epoch, activated)
+		assert.False(t, c.voteAggregationActive())
+	}
+	for _, c := range view.allCbft {
+		insertBlock(c, activated, activatedQC)
+		assert.Nil(t, c.validatorPool.Update(activated.NumberU64(), epoch+1, c.eventMux))
+		c.changeView(epoch+1, state.DefaultViewNumber, activated, activatedQC, nil)
+		assert.Equal(t, epoch+1, c.aggregationEpoch)
+		assert.True(t, c.voteAggregationActive())
+	}
+
+	block := NewBlockWithSign(activated.Hash(), 2, view.allNode[0])
+	pb := mockPrepareBlock(view.allCbft[0].config.Option.BlsPriKey, epoch+1, state.DefaultViewNumber, 0, 0, block, activatedQC, nil)
+	for _, c := range view.allCbft {
+		c.state.AddPrepareBlock(pb)
+	}
+	votes := mockAggregationVotes(view, block, 0, 1, 2, 3)
+
+	// The validators 1, 2 and 3 aggregate the votes of the view.
+	aggregator := view.allCbft[1]
+	assert.True(t, aggregator.isVoteAggregator(epoch+1, state.DefaultViewNumber))
+	assert.False(t, view.allCbft[0].isVoteAggregator(epoch+1, state.DefaultViewNumber))
+	msgCh := make(chan *ctypes.MsgPackage, 16)
+	aggregator.network.SetSendQueueHook(func(msg *ctypes.MsgPackage) {
+		if _, ok := msg.Message().(*protocols.BlockQuorumCert); ok {
+			msgCh <- msg
+		}
+	})
+	aggregator.state.HadSendPrepareVote().Push(votes[1])
+	aggregator.state.AddPrepareVote(1, votes[1])
+
+	// A byzantine peer sends a qc below the threshold, it is kept as a partial
+	// qc and does not make the block a qc block even with the local vote.
+	byzantine := mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{0: votes[0]})
+	assert.Nil(t, aggregator.OnBlockQuorumCert("byzantine", &protocols.BlockQuorumCert{BlockQC: byzantine}))
+	assert.Equal(t, byzantine, aggregator.partialQCs[0])
+	_, qc := aggregator.blockTree.FindBlockAndQC(block.Hash(), block.NumberU64())
+	assert.Nil(t, qc)
+	assert.Nil(t, aggregator.aggregatedPrepareQC(0))
+
+	// A forged partial qc is rejected.
+	forged := mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{0: votes[0]})
+	forged.ValidatorSet.SetIndex(3, true)
+	assert.NotNil(t, aggregator.OnBlockQuorumCert("byzantine", &protocols.BlockQuorumCert{BlockQC: forged}))
+	assert.Equal(t, byzantine, aggregator.partialQCs[0])
+
+	// The partial qc is sent to the other aggregators together with the local vote.
+	aggregator.sendPartialQC(0)
+	peers := make(map[string]bool)
+	for i := 0; i < voteAggregatorCount-1; i++ {
+		select {
+		case msg := <-msgCh:
+			peers[msg.PeerID()] = true
+			assert.Equal(t, 2, msg.Message().(*protocols.BlockQuorumCert).BlockQC.Len())
+		case <-time.After(time.Second):
+			t.Fatal("partial qc not sent")
+		}
+	}
+	assert.True(t, peers[view.allCbft[2].NodeID().TerminalString()])
+	assert.True(t, peers[view.allCbft[3].NodeID().TerminalString()])
+
+	// The partial qc of another aggregator is merged and completes the qc.
+	partial := mockPrepareQC(testNodeNumber, map[uint32]*protocols.PrepareVote{2: votes[2]})
+	assert.Nil(t, aggregator.OnBlockQuorumCert(view.allCbft[2].NodeID().TerminalString(), &protocols.BlockQuorumCert{BlockQC: partial}))
+	_, qc = aggregator.blockTree.FindBlockAndQC(block.Hash(), block.NumberU64())
+	if assert.NotNil(t, qc) {
+		assert.Equal(t, 3, qc.Len())
+		assert.Nil(t, aggregator.verifyPrepareQC(block.NumberU64(), block.Hash(), qc))
+	}
+}
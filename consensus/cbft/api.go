@@ -19,8 +19,11 @@ package cbft
 
 import (
 	"encoding/json"
+	"io"
+	"os"
 
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/state"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/trace"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
 )
@@ -37,6 +40,16 @@ type API interface {
 	Evidences() string
 	GetPrepareQC(number uint64) *types.QuorumCert
 	GetSchnorrNIZKProve() (*bls.SchnorrProof, error)
+	Trace(epoch, viewNumber uint64) *trace.ViewTrace
+	RejectedTrace() []*trace.RejectedEvent
+	ExportTrace(w io.Writer) (int, error)
+}
+
+// ViewTrace is the timeline of the consensus messages of a view, with the
+// validators whose votes were not seen for each block.
+type ViewTrace struct {
+	*trace.ViewTrace
+	MissingVotes map[uint32][]uint32 `json:"missingVotes"`
 }
 
 // PublicDebugConsensusAPI provides an API to access the PlatON blockchain.
@@ -67,6 +80,36 @@ func (s *PublicDebugConsensusAPI) GetPrepareQC(number uint64) *types.QuorumCert
 	return s.engine.GetPrepareQC(number)
 }
 
+// CbftTrace returns the timeline of the consensus messages of the view.
+func (s *PublicDebugConsensusAPI) CbftTrace(epoch, viewNumber uint64) *ViewTrace {
+	view := s.engine.Trace(epoch, viewNumber)
+	if view == nil {
+		return nil
+	}
+	return &ViewTrace{ViewTrace: view, MissingVotes: view.MissingVotes()}
+}
+
+// CbftRejectedTrace returns the last consensus messages which failed
+// verification, they're kept out of the timelines of the views.
+func (s *PublicDebugConsensusAPI) CbftRejectedTrace() []*trace.RejectedEvent {
+	return s.engine.RejectedTrace()
+}
+
+// ExportCbftTrace writes the timelines of all recorded views to the file,
+// one JSON object per line, and returns the number of views written.
+func (s *PublicDebugConsensusAPI) ExportCbftTrace(file string) (int, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	n, err := s.engine.ExportTrace(f)
+	if err != nil {
+		f.Close()
+		return n, err
+	}
+	return n, f.Close()
+}
+
 // PublicPlatonConsensusAPI provides an API to access the PlatON blockchain.
 // It offers only methods that operate on public data that
 // is freely available to anyone.
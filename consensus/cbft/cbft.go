@@ -47,11 +47,13 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/rules"
 	cstate "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/state"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/trace"
 	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/validator"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/wal"
 	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
@@ -116,6 +118,7 @@ type Cbft struct {
 	eventMux         *event.TypeMux
 	closeOnce        sync.Once
 	exitCh           chan struct{}
+	loopWg           sync.WaitGroup
 	txPool           consensus.TxPoolReset
 	blockChain       consensus.ChainReader
 	blockCacheWriter consensus.BlockCacheWriter
@@ -181,6 +184,18 @@ type Cbft struct {
 	netLatencyMap  map[string]*list.List
 	netLatencyLock sync.RWMutex
 
+	// Timeline of the consensus messages of the last views
+	tracer *trace.Tracer
+
+	// Metrics labeled with the epoch and the role of the node
+	epochMetrics epochMetrics
+
+	// Vote aggregation, aggregationEpoch is the epoch from which the votes
+	// are aggregated, zero if the aggregation is not active.
+	aggregationEpoch   uint64
+	aggregationChecked uint64
+	partialQCs         map[uint32]*ctypes.QuorumCert
+
 	//test
 	insertBlockQCHook  func(block *types.Block, qc *ctypes.QuorumCert)
 	executeFinishHook  func(index uint32)
@@ -209,6 +224,8 @@ func New(sysConfig *params.CbftConfig, optConfig *ctypes.OptionsConfig, eventMux
 		statQueues:         make(map[common.Hash]map[string]int),
 		messageHashCache:   mapset.NewSet(),
 		netLatencyMap:      make(map[string]*list.List),
+		tracer:             trace.NewTracer(trace.DefaultViews, trace.DefaultEventsPerView),
+		partialQCs:         make(map[uint32]*ctypes.QuorumCert),
 	}
 
 	if evPool, err := evidence.NewEvidencePool(ctx.ResolvePath, optConfig.EvidenceDir); err == nil {
@@ -258,10 +275,16 @@ func (cbft *Cbft) Start(chain consensus.ChainReader, blockCacheWriter consensus.
 	// init handler and router to process message.
 	// cbft -> handler -> router.
 	cbft.network = network.NewEngineManger(cbft) // init engineManager as handler.
+	// Exchange the fork IDs in the handshake when the chain tracks the active versions.
+	if forkChain, ok := chain.(forkid.Blockchain); ok {
+		cbft.network.SetForkID(func() forkid.ID { return forkid.NewIDFromChain(forkChain) }, forkid.NewFilter(forkChain))
+	}
 	// Start the handler to process the message.
 	go cbft.network.Start()
 
-	if cbft.config.Option.NodePriKey == nil {
+	if cbft.config.Option.Signer != nil {
+		cbft.config.Option.NodeID = cbft.config.Option.Signer.NodeID()
+	} else if cbft.config.Option.NodePriKey == nil {
 		cbft.config.Option.NodePriKey = cbft.nodeServiceContext.Config().NodeKey()
 		cbft.config.Option.NodeID = discover.PubkeyID(&cbft.config.Option.NodePriKey.PublicKey)
 	}
@@ -295,6 +318,7 @@ func (cbft *Cbft) Start(chain consensus.ChainReader, blockCacheWriter consensus.
 	}
 	utils.SetFalse(&cbft.loading)
 
+	cbft.loopWg.Add(1)
 	go cbft.receiveLoop()
 
 	cbft.fetcher.Start()
@@ -331,6 +355,7 @@ func (cbft *Cbft) ReceiveMessage(msg *ctypes.MsgInfo) error {
 	err := cbft.recordMessage(msg)
 	if err != nil {
 		cbft.log.Warn("ReceiveMessage failed", "err", err)
+		cbft.network.ReportPeer(msg.PeerID, network.ScoreSpam)
 		return err
 	}
 
@@ -440,6 +465,7 @@ func (cbft *Cbft) ReceiveSyncMsg(msg *ctypes.MsgInfo) error {
 	err := cbft.recordMessage(msg)
 	if err != nil {
 		cbft.log.Warn("ReceiveMessage failed", "err", err)
+		cbft.network.ReportPeer(msg.PeerID, network.ScoreSpam)
 		return err
 	}
 
@@ -487,6 +513,7 @@ func (cbft *Cbft) LoadWal() (err error) {
 
 // receiveLoop receives all consensus related messages, all processing logic in the same goroutine
 func (cbft *Cbft) receiveLoop() {
+	defer cbft.loopWg.Done()
 
 	// Responsible for handling consensus message logic.
 	consensusMessageHandler := func(msg *ctypes.MsgInfo) {
@@ -494,16 +521,18 @@ func (cbft *Cbft) receiveLoop() {
 			err := cbft.handleConsensusMsg(msg)
 			if err == nil {
 				cbft.network.MarkHistoryMessageHash(msg.Msg.MsgHash())
-				if err := cbft.network.Forwarding(msg.PeerID, msg.Msg); err != nil {
-					cbft.log.Debug("Forward message failed", "err", err)
+				cbft.network.ReportPeer(msg.PeerID, network.ScoreUseful)
+				if cbft.forwardable(msg.Msg) {
+					if err := cbft.network.Forwarding(msg.PeerID, msg.Msg); err != nil {
+						cbft.log.Debug("Forward message failed", "err", err)
+					}
 				}
 			} else if e, ok := err.(HandleError); ok && e.AuthFailed() {
 				// If the verification signature is abnormal,
 				// the peer node is added to the local blacklist
 				// and disconnected.
 				cbft.log.Error("Verify signature failed, will add to blacklist", "peerID", msg.PeerID, "err", err)
-				cbft.network.MarkBlacklist(msg.PeerID)
-				cbft.network.RemovePeer(msg.PeerID)
+				cbft.network.ReportPeer(msg.PeerID, network.ScoreInvalidSignature)
 			}
 		} else {
 			//cbft.log.Trace("The message has been processed, discard it", "msgHash", msg.Msg.MsgHash(), "peerID", msg.PeerID)
@@ -529,8 +558,7 @@ func (cbft *Cbft) receiveLoop() {
 				if err, ok := err.(HandleError); ok {
 					if err.AuthFailed() {
 						cbft.log.Error("Verify signature failed to sync message, will add to blacklist", "peerID", msg.PeerID)
-						cbft.network.MarkBlacklist(msg.PeerID)
-						cbft.network.RemovePeer(msg.PeerID)
+						cbft.network.ReportPeer(msg.PeerID, network.ScoreInvalidSignature)
 					}
 				}
 			}
@@ -548,6 +576,8 @@ func (cbft *Cbft) receiveLoop() {
 			cbft.OnViewTimeout()
 		case err := <-cbft.commitErrCh:
 			cbft.OnCommitError(err)
+		case <-cbft.exitCh:
+			return
 		}
 	}
 }
@@ -565,11 +595,14 @@ func (cbft *Cbft) handleConsensusMsg(info *ctypes.MsgInfo) error {
 	case *protocols.PrepareBlock:
 		cbft.csPool.AddPrepareBlock(msg.BlockIndex, ctypes.NewInnerMsgInfo(info.Msg, info.PeerID))
 		err = cbft.OnPrepareBlock(id, msg)
+		cbft.traceMsg(trace.Recv, id, msg, err)
 	case *protocols.PrepareVote:
 		cbft.csPool.AddPrepareVote(msg.BlockIndex, msg.ValidatorIndex, ctypes.NewInnerMsgInfo(info.Msg, info.PeerID))
 		err = cbft.OnPrepareVote(id, msg)
+		cbft.traceMsg(trace.Recv, id, msg, err)
 	case *protocols.ViewChange:
 		err = cbft.OnViewChange(id, msg)
+		cbft.traceMsg(trace.Recv, id, msg, err)
 	}
 
 	if err != nil {
@@ -597,6 +630,7 @@ func (cbft *Cbft) handleSyncMsg(info *ctypes.MsgInfo) error {
 		case *protocols.BlockQuorumCert:
 			cbft.csPool.AddPrepareQC(msg.BlockQC.Epoch, msg.BlockQC.ViewNumber, msg.BlockQC.BlockIndex, ctypes.NewInnerMsgInfo(info.Msg, info.PeerID))
 			err = cbft.OnBlockQuorumCert(id, msg)
+			cbft.traceQC(trace.Recv, id, msg.BlockQC, err)
 
 		case *protocols.GetPrepareVote:
 			err = cbft.OnGetPrepareVote(id, msg)
@@ -621,6 +655,7 @@ func (cbft *Cbft) handleSyncMsg(info *ctypes.MsgInfo) error {
 
 		case *protocols.ViewChangeQuorumCert:
 			err = cbft.OnViewChangeQuorumCert(id, msg)
+			cbft.traceViewChangeQC(trace.Recv, id, msg.ViewChangeQC, err)
 
 		case *protocols.ViewChanges:
 			err = cbft.OnViewChanges(id, msg)
@@ -728,7 +763,15 @@ func (cbft *Cbft) Seal(chain consensus.ChainReader, block *types.Block, results
 		return ErrorUnKnowBlock
 	}
 
-	sign, err := cbft.signFn(header.SealHash().Bytes())
+	var (
+		sign []byte
+		err  error
+	)
+	if signer := cbft.config.Option.Signer; signer != nil {
+		sign, err = signer.SignSeal(cbft.state.Epoch(), cbft.state.ViewNumber(), header)
+	} else {
+		sign, err = cbft.signFn(header.SealHash().Bytes())
+	}
 	if err != nil {
 		cbft.log.Error("Seal block sign fail", "number", block.Number(), "parentHash", block.ParentHash(), "err", err)
 		return err
@@ -804,6 +847,7 @@ func (cbft *Cbft) OnSeal(block *types.Block, results chan<- *types.Block, stop <
 		cbft.bridge.SendPrepareBlock(prepareBlock)
 	}
 	cbft.network.Broadcast(prepareBlock)
+	cbft.traceMsg(trace.Send, "", prepareBlock, nil)
 	cbft.log.Info("Broadcast PrepareBlock", "prepareBlock", prepareBlock.String())
 
 	if err := cbft.signBlock(block.Hash(), block.NumberU64(), prepareBlock.BlockIndex); err != nil {
@@ -1083,6 +1127,16 @@ func (cbft *Cbft) Close() error {
 			return
 		}
 		close(cbft.exitCh)
+		if cbft.network != nil {
+			cbft.network.Close()
+		}
+		// The wal and the evidences are written by the receive loop, wait for
+		// the message being handled before closing them. The evidence db is
+		// released for an engine restarted in the same process.
+		cbft.loopWg.Wait()
+		if cbft.evPool != nil {
+			cbft.evPool.Close()
+		}
 	})
 	cbft.bridge.Close()
 	return nil
@@ -1231,6 +1285,11 @@ func (cbft *Cbft) IsConsensusNode() bool {
 	return cbft.validatorPool.IsValidator(cbft.state.Epoch(), cbft.config.Option.NodeID)
 }
 
+// CurrentView returns the epoch and the view number of the current view.
+func (cbft *Cbft) CurrentView() (uint64, uint64) {
+	return cbft.state.Epoch(), cbft.state.ViewNumber()
+}
+
 // GetBlock returns the block corresponding to the specified number and hash.
 func (cbft *Cbft) GetBlock(hash common.Hash, number uint64) *types.Block {
 	result := make(chan *types.Block, 1)
@@ -1260,9 +1319,11 @@ func (cbft *Cbft) IsSignedBySelf(sealHash common.Hash, header *types.Header) boo
 	return cbft.verifySelfSigned(sealHash.Bytes(), header.Signature())
 }
 
-// TracingSwitch will be abandoned in the future.
+// TracingSwitch turns the recording of the consensus messages on for a
+// positive flag and off otherwise.
 func (cbft *Cbft) TracingSwitch(flag int8) {
-	panic("implement me")
+	cbft.tracer.Enable(flag > 0)
+	cbft.log.Info("Switch consensus tracing", "enabled", flag > 0)
 }
 
 // Config returns the configuration information of the consensus engine.
@@ -1339,6 +1400,10 @@ func (cbft *Cbft) verifySelfSigned(m []byte, sig []byte) bool {
 		return false
 	}
 
+	if signer := cbft.config.Option.Signer; signer != nil {
+		nodeID := signer.NodeID()
+		return bytes.Equal(nodeID[:], recPubKey[1:])
+	}
 	pubKey := cbft.config.Option.NodePriKey.PublicKey
 	pbytes := elliptic.Marshal(pubKey.Curve, pubKey.X, pubKey.Y)
 	return bytes.Equal(pbytes, recPubKey)
@@ -1355,8 +1420,16 @@ func (cbft *Cbft) signFnByBls(m []byte) ([]byte, error) {
 	return sign.Serialize(), nil
 }
 
-// signMsg use bls private key to sign msg.
+// signMsg use bls private key to sign msg, or the signer if configured.
 func (cbft *Cbft) signMsgByBls(msg ctypes.ConsensusMsg) error {
+	if signer := cbft.config.Option.Signer; signer != nil {
+		sign, err := signer.SignMsg(msg)
+		if err != nil {
+			return err
+		}
+		msg.SetSign(sign)
+		return nil
+	}
 	buf, err := msg.CannibalizeBytes()
 	if err != nil {
 		return err
@@ -1737,7 +1810,11 @@ func (cbft *Cbft) verifyPrepareQC(oriNum uint64, oriHash common.Hash, qc *ctypes
 			err: fmt.Errorf("verify prepare qc failed,not the corresponding qc,oriNum:%d,oriHash:%s,qcNum:%d,qcHash:%s",
 				oriNum, oriHash.String(), qc.BlockNumber, qc.BlockHash.String())}
 	}
+	return cbft.verifyQCSign(qc)
+}
 
+// verifyQCSign verifies the aggregated signature of the qc.
+func (cbft *Cbft) verifyQCSign(qc *ctypes.QuorumCert) error {
 	var cb []byte
 	var err error
 	if cb, err = qc.CannibalizeBytes(); err != nil {
@@ -1836,6 +1913,9 @@ func (cbft *Cbft) avgRTT() time.Duration {
 }
 
 func (cbft *Cbft) GetSchnorrNIZKProve() (*bls.SchnorrProof, error) {
+	if cbft.config.Option.BlsPriKey == nil {
+		return nil, errors.New("the bls key is held by the consensus signer")
+	}
 	return cbft.config.Option.BlsPriKey.MakeSchnorrNIZKP()
 }
 
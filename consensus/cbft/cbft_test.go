@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
 	"testing"
 	"time"
 
@@ -33,7 +34,9 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/evidence"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/signer"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/state"
 	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/validator"
@@ -94,6 +97,26 @@ func TestBls(t *testing.T) {
 	assert.Nil(t, cbft.validatorPool.Verify(0, 0, msg, pb.Sign()))
 }
 
+func TestSignerBls(t *testing.T) {
+	bls.Init(bls.BLS12_381)
+	pk, sk, nodes := GenerateCbftNode(4)
+	agency := validator.NewStaticAgency(nodes)
+
+	cbft := &Cbft{
+		validatorPool: validator.NewValidatorPool(agency, 0, 0, nodes[0].Node.ID),
+		config: ctypes.Config{
+			Option: &ctypes.OptionsConfig{
+				Signer: signer.NewLocalSigner(pk[0], sk[0]),
+			},
+		},
+	}
+
+	pb := &protocols.PrepareVote{}
+	assert.Nil(t, cbft.signMsgByBls(pb))
+	msg, _ := pb.CannibalizeBytes()
+	assert.Nil(t, cbft.validatorPool.Verify(0, 0, msg, pb.Sign()))
+}
+
 func TestPrepareBlockBls(t *testing.T) {
 	bls.Init(bls.BLS12_381)
 	pk, sk := GenerateKeys(1)
@@ -760,3 +783,57 @@ func Test_StatMessage(t *testing.T) {
 	}
 	assert.Equal(t, 199, len(node.engine.statQueues))
 }
+
+// Tests that Close waits for the message being handled by the receive loop
+// before closing the wal and the evidences, and releases the evidence db.
+func TestCloseOrdering(t *testing.T) {
+	pk, sk, cbftnodes := GenerateCbftNode(1)
+	node := MockNode(pk[0], sk[0], cbftnodes, 10000, 10)
+	dir := path()
+	defer os.RemoveAll(dir)
+	evPool, err := evidence.NewBaseEvidencePool(dir)
+	assert.Nil(t, err)
+	node.engine.evPool = evPool
+	assert.Nil(t, node.Start())
+
+	// Block the receive loop in a handler
+	handling, release := make(chan struct{}), make(chan struct{})
+	node.engine.asyncCallCh <- func() {
+		close(handling)
+		<-release
+	}
+	<-handling
+	closed := make(chan struct{})
+	go func() {
+		node.engine.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("engine closed while a message was being handled")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("engine not closed after the message was handled")
+	}
+
+	// Nothing is handled after closing
+	handled := make(chan struct{})
+	select {
+	case node.engine.asyncCallCh <- func() { close(handled) }:
+	case <-time.After(100 * time.Millisecond):
+	}
+	select {
+	case <-handled:
+		t.Fatal("message handled after closing")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// The evidence db can be reopened and closing again is harmless
+	reopened, err := evidence.NewBaseEvidencePool(dir)
+	assert.Nil(t, err)
+	reopened.Close()
+	assert.Nil(t, node.engine.Close())
+}
@@ -34,9 +34,11 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common/math"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/state"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/trace"
 	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
 )
 
 // OnPrepareBlock performs security rule verification，store in blockTree,
@@ -175,6 +177,7 @@ func (cbft *Cbft) OnViewChange(id string, msg *protocols.ViewChange) error {
 // OnViewTimeout performs timeout logic for view.
 func (cbft *Cbft) OnViewTimeout() {
 	cbft.log.Info("Current view timeout", "view", cbft.state.ViewString())
+	cbft.tracer.Record(cbft.state.Epoch(), cbft.state.ViewNumber(), &trace.Event{Direction: trace.Local, Type: trace.Timeout})
 	node, err := cbft.isCurrentValidator()
 	if err != nil {
 		cbft.log.Info("ViewTimeout local node is not validator")
@@ -210,6 +213,7 @@ func (cbft *Cbft) OnViewTimeout() {
 
 	cbft.state.AddViewChange(uint32(node.Index), viewChange)
 	cbft.network.Broadcast(viewChange)
+	cbft.traceMsg(trace.Send, "", viewChange, nil)
 	cbft.log.Info("Local add viewChange", "index", node.Index, "viewChange", viewChange.String(), "total", cbft.state.ViewChangeLen())
 
 	cbft.tryChangeView()
@@ -260,6 +264,8 @@ func (cbft *Cbft) insertQCBlock(block *types.Block, qc *ctypes.QuorumCert) {
 		}
 	}
 
+	cbft.recordQC(block, qc)
+
 	lock, commit := cbft.blockTree.InsertQCBlock(block, qc)
 	cbft.TrySetHighestQCBlock(block)
 	isOwn := func() bool {
@@ -427,7 +433,8 @@ func (cbft *Cbft) trySendPrepareVote() {
 				cbft.bridge.SendPrepareVote(block, p)
 			}
 
-			cbft.network.Broadcast(p)
+			cbft.broadcastPrepareVote(p)
+			cbft.traceMsg(trace.Send, "", p, nil)
 		} else {
 			break
 		}
@@ -486,21 +493,26 @@ func (cbft *Cbft) findQCBlock() {
 	next := index + 1
 	size := cbft.state.PrepareVoteLenByIndex(next)
 
-	prepareQC := func() bool {
-		return size >= cbft.threshold(cbft.currentValidatorLen()) && cbft.state.HadSendPrepareVote().Had(next)
+	prepareQC := func() *ctypes.QuorumCert {
+		if !cbft.state.HadSendPrepareVote().Had(next) {
+			return nil
+		}
+		if size >= cbft.threshold(cbft.currentValidatorLen()) {
+			return cbft.generatePrepareQC(cbft.state.AllPrepareVoteByIndex(next))
+		}
+		// The votes may reach the threshold together with the partial qc of the aggregators.
+		return cbft.aggregatedPrepareQC(next)
 	}
 
-	if prepareQC() {
+	if qc := prepareQC(); qc != nil {
 		block := cbft.state.ViewBlockByIndex(next)
-		qc := cbft.generatePrepareQC(cbft.state.AllPrepareVoteByIndex(next))
-		if qc != nil {
-			cbft.log.Info("New qc block have been created", "qc", qc.String())
-			cbft.insertQCBlock(block, qc)
-			cbft.network.Broadcast(&protocols.BlockQuorumCert{BlockQC: qc})
-			// metrics
-			blockQCCollectedGauage.Update(int64(block.Time()))
-			cbft.trySendPrepareVote()
-		}
+		cbft.log.Info("New qc block have been created", "qc", qc.String())
+		cbft.insertQCBlock(block, qc)
+		cbft.network.Broadcast(&protocols.BlockQuorumCert{BlockQC: qc})
+		cbft.traceQC(trace.Send, "", qc, nil)
+		// metrics
+		blockQCCollectedGauage.Update(int64(block.Time()))
+		cbft.trySendPrepareVote()
 	}
 
 	cbft.tryChangeView()
@@ -599,6 +611,7 @@ func (cbft *Cbft) tryChangeView() {
 
 	if viewChangeQC() {
 		viewChangeQC := cbft.generateViewChangeQC(cbft.state.AllViewChange())
+		cbft.traceViewChangeQC(trace.Local, "", viewChangeQC, nil)
 		cbft.log.Info("Receive enough viewchange, try change view by viewChangeQC", "view", cbft.state.ViewString(), "viewChangeQC", viewChangeQC.String())
 		cbft.tryChangeViewByViewChange(viewChangeQC)
 	}
@@ -737,18 +750,26 @@ func (cbft *Cbft) changeView(epoch, viewNumber uint64, block *types.Block, qc *c
 	// when cbft is started or fast synchronization ends, the preEpoch, preViewNumber defaults to 0, 0
 	// but cbft is now in the loading state and lastViewChangeQC is nil, does not save the lastViewChangeQC
 	preEpoch, preViewNumber := cbft.state.Epoch(), cbft.state.ViewNumber()
+	cbft.recordMissedProposals(preEpoch, preViewNumber)
 	// syncingCache is belong to last view request, clear all sync cache
 	cbft.syncingCache.Purge()
 	cbft.csPool.Purge(epoch, viewNumber)
 
 	cbft.state.ResetView(epoch, viewNumber)
 	cbft.state.SetViewTimer(interval())
+	cbft.partialQCs = make(map[uint32]*ctypes.QuorumCert)
+	cbft.checkVoteAggregation(epoch, block)
+	cbft.tracer.NewView(epoch, viewNumber, cbft.currentValidatorLen())
 	cbft.state.SetLastViewChangeQC(viewChangeQC)
 
 	// metrics.
 	viewNumberGauage.Update(int64(viewNumber))
 	epochNumberGauage.Update(int64(epoch))
 	viewChangedTimer.UpdateSince(time.Unix(int64(block.Time()), 0))
+	cbft.resetEpochMetrics()
+	if viewChangeQC != nil && !cbft.isLoading() {
+		cbft.epochMetrics.viewChanged()
+	}
 
 	// write confirmed viewChange info to wal
 	if !cbft.isLoading() {
@@ -762,6 +783,56 @@ func (cbft *Cbft) changeView(epoch, viewNumber uint64, block *types.Block, qc *c
 	cbft.log.Info("Success to change view, current view deadline", "deadline", cbft.state.Deadline())
 }
 
+// resetEpochMetrics moves the epoch metrics to the current epoch and the
+// current role of the node.
+func (cbft *Cbft) resetEpochMetrics() {
+	if !metrics.Enabled {
+		return
+	}
+	epoch := cbft.state.Epoch()
+	role := roleObserver
+	if _, err := cbft.isCurrentValidator(); err == nil {
+		role = roleValidator
+	}
+	validators := make(map[uint32]string)
+	for i := 0; i < cbft.validatorPool.Len(epoch); i++ {
+		if node, err := cbft.validatorPool.GetValidatorByIndex(epoch, uint32(i)); err == nil {
+			validators[node.Index] = node.NodeID.TerminalString()
+		}
+	}
+	cbft.epochMetrics.reset(epoch, role, validators)
+}
+
+// recordMissedProposals counts the blocks the local node didn't propose
+// in the view it leaves, if it was the proposer of the view.
+func (cbft *Cbft) recordMissedProposals(epoch, viewNumber uint64) {
+	if !metrics.Enabled || cbft.isLoading() || epoch == 0 {
+		return
+	}
+	node, err := cbft.isCurrentValidator()
+	if err != nil || !cbft.isProposer(epoch, viewNumber, node.Index) {
+		return
+	}
+	if proposed := uint32(cbft.state.ViewBlockSize()); proposed < cbft.config.Sys.Amount {
+		cbft.epochMetrics.missedProposals(cbft.config.Sys.Amount - proposed)
+	}
+}
+
+// recordQC records the latency and the votes of a QC of the current view.
+func (cbft *Cbft) recordQC(block *types.Block, qc *ctypes.QuorumCert) {
+	if !metrics.Enabled || qc.Epoch != cbft.state.Epoch() || qc.ViewNumber != cbft.state.ViewNumber() {
+		return
+	}
+	latency := time.Duration(common.Millis(time.Now())-int64(block.Time())) * time.Millisecond
+	var voted []uint32
+	for i := uint32(0); i < qc.ValidatorSet.Size(); i++ {
+		if qc.ValidatorSet.GetIndex(i) {
+			voted = append(voted, i)
+		}
+	}
+	cbft.epochMetrics.qcCollected(latency, voted)
+}
+
 // Clean up invalid blocks in the previous view
 func (cbft *Cbft) clearInvalidBlocks(newBlock *types.Block) {
 	var rollback []*types.Block
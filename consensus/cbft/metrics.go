@@ -18,6 +18,9 @@
 package cbft
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/PlatONnetwork/PlatON-Go/metrics"
 )
 
@@ -44,3 +47,74 @@ var (
 	highestLockedNumberGauage = metrics.NewRegisteredGauge("cbft/gauage/block/locked/number", nil)
 	highestCommitNumberGauage = metrics.NewRegisteredGauge("cbft/gauage/block/commit/number", nil)
 )
+
+// The roles of the local node in the labels of the epoch metrics.
+const (
+	roleValidator = "validator"
+	roleObserver  = "observer"
+)
+
+// epochMetrics are the consensus metrics labeled with the epoch and the role
+// of the local node. The series of an epoch are dropped when the next epoch
+// starts, so only those of the current epoch are exported.
+type epochMetrics struct {
+	set        *metrics.LabeledSet
+	epoch      uint64
+	role       string
+	validators map[uint32]string // the node IDs of the validators of the epoch by index
+
+	qcs   uint64            // QCs of the epoch
+	votes map[uint32]uint64 // votes of each validator in the QCs of the epoch
+}
+
+// reset moves the metrics to the epoch and the role.
+func (m *epochMetrics) reset(epoch uint64, role string, validators map[uint32]string) {
+	if !metrics.Enabled || (m.set != nil && m.epoch == epoch && m.role == role) {
+		return
+	}
+	if m.set == nil {
+		m.set = metrics.NewLabeledSet(nil)
+	}
+	m.set.Reset()
+	m.epoch, m.role, m.validators = epoch, role, validators
+	m.qcs, m.votes = 0, make(map[uint32]uint64)
+}
+
+func (m *epochMetrics) labels(labels ...string) []string {
+	return append([]string{"epoch", strconv.FormatUint(m.epoch, 10), "role", m.role}, labels...)
+}
+
+// viewChanged counts a view ended by a view change QC.
+func (m *epochMetrics) viewChanged() {
+	if m.set == nil {
+		return
+	}
+	m.set.Counter("cbft/epoch/view_changes", m.labels()...).Inc(1)
+}
+
+// missedProposals counts the blocks the local node should have proposed
+// in its view but didn't.
+func (m *epochMetrics) missedProposals(missed uint32) {
+	if m.set == nil {
+		return
+	}
+	m.set.Counter("cbft/epoch/missed_proposals", m.labels()...).Inc(int64(missed))
+}
+
+// qcCollected records the latency of a QC from the time of its block and
+// the validators that voted for it. The participation of a validator is the
+// share of the QCs of the epoch it voted in.
+func (m *epochMetrics) qcCollected(latency time.Duration, voted []uint32) {
+	if m.set == nil {
+		return
+	}
+	m.set.Timer("cbft/epoch/qc_latency", m.labels()...).Update(latency)
+	m.qcs++
+	for _, index := range voted {
+		m.votes[index]++
+	}
+	for index, id := range m.validators {
+		participation := float64(m.votes[index]) / float64(m.qcs)
+		m.set.GaugeFloat64("cbft/epoch/vote_participation", m.labels("validator", id)...).Update(participation)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package cbft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
+)
+
+func TestEpochMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	var m epochMetrics
+	m.reset(1, roleValidator, map[uint32]string{0: "a", 1: "b"})
+	defer m.set.Reset()
+	m.viewChanged()
+	m.missedProposals(3)
+	m.qcCollected(100*time.Millisecond, []uint32{0, 1})
+	m.qcCollected(200*time.Millisecond, []uint32{0})
+
+	labels := []string{"epoch", "1", "role", roleValidator}
+	get := func(name string, extra ...string) interface{} {
+		return metrics.DefaultRegistry.Get(metrics.LabeledName(name, append(labels, extra...)...))
+	}
+	assert.Equal(t, int64(1), get("cbft/epoch/view_changes").(metrics.Counter).Count())
+	assert.Equal(t, int64(3), get("cbft/epoch/missed_proposals").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), get("cbft/epoch/qc_latency").(metrics.Timer).Count())
+	assert.Equal(t, 1.0, get("cbft/epoch/vote_participation", "validator", "a").(metrics.GaugeFloat64).Value())
+	assert.Equal(t, 0.5, get("cbft/epoch/vote_participation", "validator", "b").(metrics.GaugeFloat64).Value())
+
+	// The series of the previous epoch are dropped
+	m.reset(2, roleObserver, map[uint32]string{0: "a"})
+	assert.Nil(t, get("cbft/epoch/view_changes"))
+	m.qcCollected(100*time.Millisecond, nil)
+	assert.Equal(t, 0.0, metrics.DefaultRegistry.Get(metrics.LabeledName("cbft/epoch/vote_participation",
+		"epoch", "2", "role", roleObserver, "validator", "a")).(metrics.GaugeFloat64).Value())
+}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/log"
 	"github.com/PlatONnetwork/PlatON-Go/p2p"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
@@ -42,7 +43,10 @@ const (
 	CbftProtocolName = "cbft"
 
 	// CbftProtocolVersion is protocol version of CBFT.
-	CbftProtocolVersion = 1
+	CbftProtocolVersion = 2
+
+	// cbft1 is the protocol version of CBFT without fork ID in the handshake.
+	cbft1 = 1
 
 	// CbftProtocolLength are the number of implemented message corresponding to cbft protocol versions.
 	CbftProtocolLength = 40
@@ -90,8 +94,15 @@ type EngineManager struct {
 	sendQueueHook      func(*types.MsgPackage)
 	historyMessageHash *lru.ARCCache // Consensus message record that has been processed successfully.
 	blacklist          *lru.Cache    // Save node blacklist.
+	scores             *scoreBook    // Reputation of the peers.
+
+	forkID     func() forkid.ID // Fork ID of the chain head advertised in the handshake, nil if unknown
+	forkFilter forkid.Filter    // Fork ID filter validating the peers, nil to accept all of them
 }
 
+// cbftProtocolVersions are the supported versions of the CBFT protocol (first is primary).
+var cbftProtocolVersions = []uint{CbftProtocolVersion, cbft1}
+
 // NewEngineManger returns a new handler and do some initialization.
 func NewEngineManger(engine Cbft) *EngineManager {
 	cache, err := lru.NewARC(maxHistoryMessageHash)
@@ -104,6 +115,7 @@ func NewEngineManger(engine Cbft) *EngineManager {
 		sendQueue:          make(chan *types.MsgPackage, sendQueueSize),
 		quitSend:           make(chan struct{}),
 		historyMessageHash: cache,
+		scores:             newScoreBook(),
 	}
 	handler.blacklist, _ = lru.New(maxBlacklist)
 	// init router
@@ -111,6 +123,12 @@ func NewEngineManger(engine Cbft) *EngineManager {
 	return handler
 }
 
+// SetForkID sets the source of the fork ID advertised in the handshake and the
+// filter validating the fork IDs of the peers.
+func (h *EngineManager) SetForkID(forkID func() forkid.ID, forkFilter forkid.Filter) {
+	h.forkID, h.forkFilter = forkID, forkFilter
+}
+
 // Start the loop to send message.
 func (h *EngineManager) Start() {
 	// Launch goroutine loop release separately.
@@ -288,13 +306,15 @@ func (h *EngineManager) Forwarding(nodeID string, msg types.Message) error {
 
 // Protocols implemented the Protocols method and returned basic information about the CBFT protocol.
 func (h *EngineManager) Protocols() []p2p.Protocol {
-	return []p2p.Protocol{
-		{
+	protocols := make([]p2p.Protocol, 0, len(cbftProtocolVersions))
+	for _, version := range cbftProtocolVersions {
+		version := version // Closure for the run
+		protocols = append(protocols, p2p.Protocol{
 			Name:    CbftProtocolName,
-			Version: CbftProtocolVersion,
+			Version: version,
 			Length:  CbftProtocolLength,
 			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-				return h.handler(p, rw)
+				return h.handler(int(version), p, rw)
 			},
 			NodeInfo: func() interface{} {
 				return h.NodeInfo()
@@ -305,8 +325,9 @@ func (h *EngineManager) Protocols() []p2p.Protocol {
 				}
 				return nil
 			},
-		},
+		})
 	}
+	return protocols
 }
 
 // AliveConsensusNodeIDs returns all NodeID to alive peer.
@@ -354,9 +375,8 @@ func (h *EngineManager) NodeInfo() *NodeInfo {
 
 // After the node is successfully connected and the message belongs
 // to the cbft protocol message, the method is called.
-func (h *EngineManager) handler(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-	// Further confirm if the version number needs to be read from the configuration.
-	peer := newPeer(CbftProtocolVersion, p, newMeteredMsgWriter(rw))
+func (h *EngineManager) handler(version int, p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer := newPeer(version, p, newMeteredMsgWriter(rw))
 
 	// execute handshake
 	// 1.need qcBn/qcHash/lockedBn/lockedHash/commitBn/commitHash from cbft.
@@ -371,7 +391,7 @@ func (h *EngineManager) handler(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 	handshake := func() error {
 		// Build a new CbftStatusData object as a handshake parameter
 		cbftStatus := &protocols.CbftStatusData{
-			ProtocolVersion: CbftProtocolVersion,
+			ProtocolVersion: uint32(version),
 			QCBn:            new(big.Int).SetUint64(uint64(qcBn)),
 			QCBlock:         qcHash,
 			LockBn:          new(big.Int).SetUint64(uint64(lockedBn)),
@@ -379,6 +399,9 @@ func (h *EngineManager) handler(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 			CmtBn:           new(big.Int).SetUint64(uint64(commitBn)),
 			CmtBlock:        commitHash,
 		}
+		if version > cbft1 && h.forkID != nil {
+			cbftStatus.ForkID = h.forkID()
+		}
 		// do handshake
 		remoteStatus, err := peer.Handshake(cbftStatus)
 		if err != nil {
@@ -386,6 +409,14 @@ func (h *EngineManager) handler(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 			return err
 		}
 
+		// Reject the peers unable to follow the versions activated on the chain.
+		if version > cbft1 && h.forkFilter != nil {
+			if err := h.forkFilter(remoteStatus.ForkID); err != nil {
+				p.Log().Info("Rejected CBFT peer on an incompatible fork", "forkid", remoteStatus.ForkID, "err", err)
+				return types.ErrResp(types.ErrForkIDRejected, "%v", err)
+			}
+		}
+
 		// Blacklist check.
 		if h.ContainsBlacklist(peer.PeerID()) {
 			p.Log().Error("CBFT handshake, peer that are forbidden to connect")
@@ -417,6 +448,7 @@ func (h *EngineManager) handler(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 	}
 
 	// The newly established node is registered to the neighbor node list.
+	h.scores.attach(peer)
 	if err := h.peers.Register(peer); err != nil {
 		p.Log().Error("Cbft peer registration failed", "err", err)
 		return err
@@ -463,6 +495,9 @@ func (h *EngineManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&request); err != nil {
 			return types.ErrResp(types.ErrDecode, "%v: %v", msg, err)
 		}
+		if !h.scoreResponse(p, protocols.GetPrepareBlockMsg) {
+			h.checkDuplicate(p, (&request).MsgHash())
+		}
 		p.MarkMessageHash((&request).MsgHash())
 		request.Block.ReceivedAt = msg.ReceivedAt
 		request.Block.ReceivedFrom = p
@@ -474,6 +509,7 @@ func (h *EngineManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&request); err != nil {
 			return types.ErrResp(types.ErrDecode, "%v: %v", msg, err)
 		}
+		h.checkDuplicate(p, (&request).MsgHash())
 		p.MarkMessageHash((&request).MsgHash())
 		return h.engine.ReceiveMessage(types.NewMsgInfo(&request, p.PeerID()))
 
@@ -482,6 +518,7 @@ func (h *EngineManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&request); err != nil {
 			return types.ErrResp(types.ErrDecode, "%v: %v", msg, err)
 		}
+		h.checkDuplicate(p, (&request).MsgHash())
 		p.MarkMessageHash((&request).MsgHash())
 		return h.engine.ReceiveMessage(types.NewMsgInfo(&request, p.PeerID()))
 
@@ -534,6 +571,7 @@ func (h *EngineManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&request); err != nil {
 			return types.ErrResp(types.ErrDecode, "%v: %v", msg, err)
 		}
+		h.scoreResponse(p, protocols.GetPrepareVoteMsg)
 		return h.engine.ReceiveSyncMsg(types.NewMsgInfo(&request, p.PeerID()))
 
 	case msg.Code == protocols.QCBlockListMsg:
@@ -601,6 +639,7 @@ func (h *EngineManager) handleMsg(p *peer) error {
 					// Record the latency in metrics and output it. unit: second.
 					log.Trace("Latency", "time", latency)
 					h.engine.OnPong(p.id, latency)
+					p.score.observeLatency(latency)
 					propPeerLatencyMeter.Mark(latency)
 					break
 				}
@@ -625,6 +664,82 @@ func (h *EngineManager) handleMsg(p *peer) error {
 	}
 }
 
+// checkDuplicate penalizes the peer if it has sent the consensus message before.
+// The consensus nodes are spared, they resend their messages to the peers
+// falling behind.
+func (h *EngineManager) checkDuplicate(p *peer, hash common.Hash) {
+	if !p.MarkReceivedHash(hash) {
+		messageDuplicateMeter.Mark(1)
+		if !h.isConsensusPeer(p.id) {
+			h.ReportPeer(p.id, ScoreDuplicate)
+		}
+	}
+}
+
+// scoreResponse scores the response time of the peer to the pending
+// sync request of the type, it returns false if there is none.
+func (h *EngineManager) scoreResponse(p *peer, request uint64) bool {
+	elapsed, ok := p.Responded(request)
+	if !ok {
+		return false
+	}
+	if elapsed > slowResponse {
+		h.ReportPeer(p.id, ScoreSlowResponse)
+	} else {
+		h.ReportPeer(p.id, ScoreTimelyResponse)
+	}
+	return true
+}
+
+// ReportPeer records an event about the behaviour of the peer. A peer
+// with an invalid signature is added to the blacklist and disconnected
+// whether it is registered or not. A peer whose score falls to banScore
+// is blacklisted and disconnected, and one whose score falls to
+// disconnectScore is disconnected, unless it is a consensus node.
+func (h *EngineManager) ReportPeer(peerID string, ev ScoreEvent) {
+	if ev == ScoreInvalidSignature {
+		log.Warn("Ban CBFT peer", "peer", peerID, "event", ev)
+		peerBanMeter.Mark(1)
+		if p, err := h.peers.get(peerID); err == nil {
+			p.score.add(ev)
+		}
+		h.MarkBlacklist(peerID)
+		h.RemovePeer(peerID)
+		return
+	}
+	p, err := h.peers.get(peerID)
+	if err != nil {
+		return
+	}
+	score := p.score.add(ev)
+	if score > disconnectScore || h.isConsensusPeer(peerID) {
+		return
+	}
+	if score <= banScore {
+		log.Warn("Ban CBFT peer", "peer", peerID, "event", ev, "score", score)
+		peerBanMeter.Mark(1)
+		h.MarkBlacklist(peerID)
+	} else {
+		log.Warn("Disconnect CBFT peer with low score", "peer", peerID, "event", ev, "score", score)
+		peerDropMeter.Mark(1)
+	}
+	h.RemovePeer(peerID)
+}
+
+// isConsensusPeer returns whether the peer is a node of the current validator set.
+func (h *EngineManager) isConsensusPeer(peerID string) bool {
+	nodes, err := h.engine.ConsensusNodes()
+	if err != nil {
+		return false
+	}
+	for _, node := range nodes {
+		if node.TerminalString() == peerID {
+			return true
+		}
+	}
+	return false
+}
+
 // MarkHistoryMessageHash is used to record the hash value of each message from the peer node.
 // If the queue is full, remove the bottom element and add a new one.
 func (h *EngineManager) MarkHistoryMessageHash(hash common.Hash) {
@@ -644,6 +759,12 @@ func (h *EngineManager) MarkBlacklist(peerID string) {
 	h.blacklist.Add(peerID, time.Now().Add(deadline))
 }
 
+// IsConnected returns whether the specified node is connected.
+func (h *EngineManager) IsConnected(peerID string) bool {
+	_, err := h.peers.get(peerID)
+	return err == nil
+}
+
 // ContainsBlacklist returns whether the specified node is blacklisted.
 func (h *EngineManager) ContainsBlacklist(peerID string) bool {
 	return h.blacklist.Contains(peerID)
@@ -32,6 +32,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 
 	"github.com/stretchr/testify/assert"
 
@@ -211,7 +212,7 @@ func Test_EngineManager_Handle(t *testing.T) {
 	// First send a status message and then to
 	// send consensus messages for processing.
 	go func() {
-		status := &protocols.CbftStatusData{ProtocolVersion: 1, QCBn: big.NewInt(1), QCBlock: common.Hash{},
+		status := &protocols.CbftStatusData{ProtocolVersion: CbftProtocolVersion, QCBn: big.NewInt(1), QCBlock: common.Hash{},
 			LockBn: big.NewInt(2), LockBlock: common.Hash{}, CmtBn: big.NewInt(3), CmtBlock: common.Hash{}}
 		p2p.Send(fake.localPeer.rw, protocols.CBFTStatusMsg, status)
 		t.Log("send status success.")
@@ -233,6 +234,44 @@ func Test_EngineManager_Handle(t *testing.T) {
 	}
 }
 
+func Test_EngineManager_HandleForkID(t *testing.T) {
+	h, fake := newHandle(t)
+	fakePeer := fake.peers[0]
+	local := forkid.ID{Hash: [4]byte{0x01}, Version: params.FORKVERSION_1_5_0, Code: params.FORKVERSION_1_5_0}
+	h.SetForkID(func() forkid.ID { return local }, func(id forkid.ID) error {
+		if id != local {
+			return forkid.ErrLocalIncompatibleOrStale
+		}
+		return nil
+	})
+	// Exchange the status with a peer on another fork.
+	statusCh := make(chan *protocols.CbftStatusData, 1)
+	go func() {
+		msg, err := fake.localPeer.ReadWriter().ReadMsg()
+		if err != nil {
+			statusCh <- nil
+			return
+		}
+		var status protocols.CbftStatusData
+		msg.Decode(&status)
+		statusCh <- &status
+	}()
+	go func() {
+		status := &protocols.CbftStatusData{ProtocolVersion: CbftProtocolVersion, QCBn: big.NewInt(1), QCBlock: common.Hash{},
+			LockBn: big.NewInt(2), LockBlock: common.Hash{}, CmtBn: big.NewInt(3), CmtBlock: common.Hash{},
+			ForkID: forkid.ID{Hash: [4]byte{0x02}, Version: params.FORKVERSION_1_5_0, Code: params.FORKVERSION_1_5_0}}
+		p2p.Send(fake.localPeer.rw, protocols.CBFTStatusMsg, status)
+	}()
+	err := h.handler(CbftProtocolVersion, fakePeer.Peer, fakePeer.rw)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), types.ErrResp(types.ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale).Error())
+	}
+	status := <-statusCh
+	if assert.NotNil(t, status) {
+		assert.Equal(t, local, status.ForkID)
+	}
+}
+
 func Test_EngineManager_Forwarding(t *testing.T) {
 	handle, fake := newHandle(t)
 	peers := fake.peers
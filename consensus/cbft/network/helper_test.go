@@ -249,7 +249,7 @@ func newFakePeer(name string, version int, pm *EngineManager, shake bool) (*fake
 	errc := make(chan error, 1)
 	go func() {
 		//
-		errc <- pm.handler(peer.Peer, peer.rw)
+		errc <- pm.handler(version, peer.Peer, peer.rw)
 	}()
 	tp := &fakePeer{app: app, net: net, peer: peer}
 	return tp, errc
@@ -104,6 +104,11 @@ var (
 	messageGossipMeter = metrics.NewRegisteredMeter("cbft/meter/message/gossip", nil)
 	messageRepeatMeter = metrics.NewRegisteredMeter("cbft/meter/message/repeat", nil)
 
+	// Peer reputation
+	messageDuplicateMeter = metrics.NewRegisteredMeter("cbft/meter/message/duplicate", nil)
+	peerDropMeter         = metrics.NewRegisteredMeter("cbft/meter/peer/drop", nil)
+	peerBanMeter          = metrics.NewRegisteredMeter("cbft/meter/peer/ban", nil)
+
 	neighborPeerGauage = metrics.NewRegisteredGauge("cbft/gauage/peer/value", nil)
 )
 
@@ -83,28 +83,41 @@ type peer struct {
 	// record is popped up and then added.
 	knownMessageHash mapset.Set
 
+	// Record the consensus messages received from the peer,
+	// to find the peers sending the same message repeatedly.
+	receivedMessageHash mapset.Set
+
 	pingList *list.List
 	listLock sync.RWMutex
 
 	// Message sending queue, the queue stores
 	// messages to be sent to the peer.
 	sendQueue chan *types.MsgPackage
+
+	// Reputation of the peer and the sync requests
+	// waiting for its response.
+	score    *peerScore
+	requests map[uint64]time.Time
+	reqLock  sync.Mutex
 }
 
 // newPeer creates a new peer.
 func newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	return &peer{
-		Peer:             p,
-		rw:               rw,
-		id:               p.ID().TerminalString(),
-		term:             make(chan struct{}),
-		version:          pv,
-		highestQCBn:      new(big.Int),
-		lockedBn:         new(big.Int),
-		commitBn:         new(big.Int),
-		knownMessageHash: mapset.NewSet(),
-		pingList:         list.New(),
-		sendQueue:        make(chan *types.MsgPackage, maxQueueSize),
+		Peer:                p,
+		rw:                  rw,
+		id:                  p.ID().TerminalString(),
+		term:                make(chan struct{}),
+		version:             pv,
+		highestQCBn:         new(big.Int),
+		lockedBn:            new(big.Int),
+		commitBn:            new(big.Int),
+		knownMessageHash:    mapset.NewSet(),
+		receivedMessageHash: mapset.NewSet(),
+		pingList:            list.New(),
+		sendQueue:           make(chan *types.MsgPackage, maxQueueSize),
+		score:               newPeerScore(),
+		requests:            make(map[uint64]time.Time),
 	}
 }
 
@@ -251,6 +264,19 @@ func (p *peer) MarkMessageHash(hash common.Hash) {
 	p.knownMessageHash.Add(hash)
 }
 
+// MarkReceivedHash records the hash of a consensus message received from the peer,
+// it returns false if the peer has sent the message before.
+func (p *peer) MarkReceivedHash(hash common.Hash) bool {
+	if p.receivedMessageHash.Contains(hash) {
+		return false
+	}
+	for p.receivedMessageHash.Cardinality() >= maxKnownMessageHash {
+		p.receivedMessageHash.Pop()
+	}
+	p.receivedMessageHash.Add(hash)
+	return true
+}
+
 // ContainsMessageHash determines if the specified message hash is included.
 func (p *peer) ContainsMessageHash(hash common.Hash) bool {
 	return p.knownMessageHash.Contains(hash)
@@ -261,6 +287,30 @@ func (p *peer) RemoveMessageHash(hash common.Hash) {
 	p.knownMessageHash.Remove(hash)
 }
 
+// MarkRequest records the time a sync request of the message type was sent to the peer.
+func (p *peer) MarkRequest(msgType uint64) {
+	p.reqLock.Lock()
+	defer p.reqLock.Unlock()
+	if sent, ok := p.requests[msgType]; !ok || time.Since(sent) >= responseTimeout {
+		p.requests[msgType] = time.Now()
+	}
+}
+
+// Responded clears the sync request of the message type and returns how
+// long the peer took to answer it. It returns false if no request of the
+// type is pending or the request has timed out.
+func (p *peer) Responded(msgType uint64) (time.Duration, bool) {
+	p.reqLock.Lock()
+	defer p.reqLock.Unlock()
+	sent, ok := p.requests[msgType]
+	if !ok {
+		return 0, false
+	}
+	delete(p.requests, msgType)
+	elapsed := time.Since(sent)
+	return elapsed, elapsed < responseTimeout
+}
+
 // Close terminates the running state of the peer.
 func (p *peer) Close() {
 	close(p.term)
@@ -384,10 +434,11 @@ func (p *peer) sendLoop() {
 
 // PeerInfo represents the node information of the CBFT protocol.
 type PeerInfo struct {
-	ProtocolVersion int    `json:"protocolVersion"`
-	HighestQCBn     uint64 `json:"highestQCBn"`
-	LockedBn        uint64 `json:"lockedBn"`
-	CommitBn        uint64 `json:"commitBn"`
+	ProtocolVersion int            `json:"protocolVersion"`
+	HighestQCBn     uint64         `json:"highestQCBn"`
+	LockedBn        uint64         `json:"lockedBn"`
+	CommitBn        uint64         `json:"commitBn"`
+	Reputation      *PeerScoreInfo `json:"reputation"`
 }
 
 // Info output status information of the current peer.
@@ -398,6 +449,7 @@ func (p *peer) Info() *PeerInfo {
 		HighestQCBn:     qc,
 		LockedBn:        locked,
 		CommitBn:        commit,
+		Reputation:      p.score.info(),
 	}
 }
 
@@ -102,7 +102,8 @@ func (r *router) Gossip(m *types.MsgPackage) {
 	// recipients to reduce network consumption.
 	switch m.Mode() {
 	case types.PartMode:
-		transfer := kRandomNodes(int(math.Sqrt(float64(len(peers)))), peers, common.Hash{}, nil)
+		k := int(math.Sqrt(float64(len(peers))))
+		transfer := kRandomNodes(k, preferredPeers(k, peers), common.Hash{}, nil)
 		peers = transfer
 	}
 
@@ -117,6 +118,9 @@ func (r *router) Gossip(m *types.MsgPackage) {
 		//} else {
 		//	peer.MarkMessageHash(msgHash)
 		//}
+		if scoredRequests[msgType] {
+			peer.MarkRequest(msgType)
+		}
 		peer.Send(m)
 	}
 }
@@ -131,6 +135,9 @@ func (r *router) SendMessage(m *types.MsgPackage) {
 		//	log.Error("Send Peer error")
 		//	r.unregister(m.PeerID())
 		//}
+		if msgType := protocols.MessageType(m.Message()); scoredRequests[msgType] {
+			peer.MarkRequest(msgType)
+		}
 		peer.Send(m)
 	}
 }
@@ -232,8 +239,8 @@ func (r *router) kMixingRandomNodes(condition common.Hash, filterFn func(*peer,
 		}
 	}
 	log.Debug("kMixingRandomNodes select node", "msgHash", condition, "cNodesLen", len(cNodes), "ncNodesLen", len(nonconsensusPeers), "peerSetLen", len(existsPeers))
-	// Obtain random nodes from non-consensus nodes.
-	kNonconsensusNodes := kRandomNodes(DefaultFanOut, nonconsensusPeers, condition, filterFn)
+	// Obtain random nodes from the better non-consensus nodes.
+	kNonconsensusNodes := kRandomNodes(DefaultFanOut, preferredPeers(DefaultFanOut, nonconsensusPeers), condition, filterFn)
 	// Summary target peers and return.
 	consensusPeers = append(consensusPeers, kNonconsensusNodes...)
 	return consensusPeers, nil
@@ -0,0 +1,249 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+)
+
+// ScoreEvent is an observation about the behaviour of a peer
+// which moves its score up or down.
+type ScoreEvent int
+
+const (
+	// ScoreUseful means the peer delivered a consensus message first.
+	ScoreUseful ScoreEvent = iota
+
+	// ScoreTimelyResponse means the peer answered a sync request in time.
+	ScoreTimelyResponse
+
+	// ScoreDuplicate means the peer sent a message it had sent already.
+	ScoreDuplicate
+
+	// ScoreSlowResponse means the peer answered a sync request late.
+	ScoreSlowResponse
+
+	// ScoreSpam means the peer exceeded the message allowance of the engine.
+	ScoreSpam
+
+	// ScoreInvalidSignature means the peer sent a message with a bad signature,
+	// the peer is banned right away.
+	ScoreInvalidSignature
+
+	scoreEventCount
+)
+
+var scoreEventNames = [scoreEventCount]string{
+	ScoreUseful:           "useful",
+	ScoreTimelyResponse:   "timely response",
+	ScoreDuplicate:        "duplicate",
+	ScoreSlowResponse:     "slow response",
+	ScoreSpam:             "spam",
+	ScoreInvalidSignature: "invalid signature",
+}
+
+func (ev ScoreEvent) String() string {
+	if ev < 0 || ev >= scoreEventCount {
+		return "unknown"
+	}
+	return scoreEventNames[ev]
+}
+
+// scoreWeights are the amounts each event adds to the score.
+var scoreWeights = [scoreEventCount]float64{
+	ScoreUseful:           1,
+	ScoreTimelyResponse:   2,
+	ScoreDuplicate:        -1,
+	ScoreSlowResponse:     -5,
+	ScoreSpam:             -20,
+	ScoreInvalidSignature: minScore,
+}
+
+const (
+	// The bounds of the score of a peer.
+	maxScore = 100
+	minScore = -100
+
+	// Peers at or below disconnectScore are dropped and the ones at or
+	// below banScore are blacklisted too, unless they are consensus nodes.
+	disconnectScore = -50
+	banScore        = -90
+
+	// scoreHalfLife is the time it takes a score to decay to half of
+	// its value, so that the peers recover from past faults.
+	scoreHalfLife = 5 * time.Minute
+
+	// Sync requests answered later than slowResponse are penalized,
+	// the ones without an answer within responseTimeout are forgotten.
+	slowResponse    = time.Second
+	responseTimeout = 10 * time.Second
+
+	// Weight of the latest sample in the moving average of the latency.
+	latencyWeight = 0.2
+
+	// The maximum number of peers whose score is remembered
+	// after they disconnect.
+	maxScoreRecords = 1024
+)
+
+// scoredRequests are the sync requests whose response time is scored.
+var scoredRequests = map[uint64]bool{
+	protocols.GetPrepareBlockMsg: true,
+	protocols.GetPrepareVoteMsg:  true,
+}
+
+// peerScore records the reputation of a peer.
+type peerScore struct {
+	lock    sync.Mutex
+	value   float64
+	updated time.Time
+	latency float64 // Moving average of the ping latency (unit: millisecond).
+	counts  [scoreEventCount]uint64
+}
+
+func newPeerScore() *peerScore {
+	return &peerScore{updated: time.Now()}
+}
+
+// decay applies the decay of the score since the last update, the lock must be held.
+func (s *peerScore) decay(now time.Time) {
+	if elapsed := now.Sub(s.updated); elapsed > 0 {
+		s.value *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+		s.updated = now
+	}
+}
+
+// add records the event and returns the new score.
+func (s *peerScore) add(ev ScoreEvent) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decay(time.Now())
+	s.counts[ev]++
+	s.value = math.Max(minScore, math.Min(maxScore, s.value+scoreWeights[ev]))
+	return s.value
+}
+
+// observeLatency folds a latency sample into the moving average.
+func (s *peerScore) observeLatency(latency int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.latency == 0 {
+		s.latency = float64(latency)
+	} else {
+		s.latency += latencyWeight * (float64(latency) - s.latency)
+	}
+}
+
+// rank returns the current score and the average latency.
+func (s *peerScore) rank() (float64, float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decay(time.Now())
+	return s.value, s.latency
+}
+
+// PeerScoreInfo represents the reputation of a peer in the CBFT protocol.
+type PeerScoreInfo struct {
+	Score            float64 `json:"score"`
+	Latency          int64   `json:"latency"`
+	Useful           uint64  `json:"useful"`
+	TimelyResponse   uint64  `json:"timelyResponse"`
+	Duplicate        uint64  `json:"duplicate"`
+	SlowResponse     uint64  `json:"slowResponse"`
+	Spam             uint64  `json:"spam"`
+	InvalidSignature uint64  `json:"invalidSignature"`
+}
+
+// info returns the reputation for the peer info.
+func (s *peerScore) info() *PeerScoreInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decay(time.Now())
+	return &PeerScoreInfo{
+		Score:            math.Round(s.value*100) / 100,
+		Latency:          int64(s.latency),
+		Useful:           s.counts[ScoreUseful],
+		TimelyResponse:   s.counts[ScoreTimelyResponse],
+		Duplicate:        s.counts[ScoreDuplicate],
+		SlowResponse:     s.counts[ScoreSlowResponse],
+		Spam:             s.counts[ScoreSpam],
+		InvalidSignature: s.counts[ScoreInvalidSignature],
+	}
+}
+
+// scoreBook keeps the scores of the peers across reconnections.
+type scoreBook struct {
+	scores *lru.Cache
+}
+
+func newScoreBook() *scoreBook {
+	cache, _ := lru.New(maxScoreRecords)
+	return &scoreBook{scores: cache}
+}
+
+// attach hands the remembered score of the peer over to it,
+// or remembers the score of a new peer.
+func (b *scoreBook) attach(p *peer) {
+	if v, ok := b.scores.Get(p.id); ok {
+		p.score = v.(*peerScore)
+		return
+	}
+	b.scores.Add(p.id, p.score)
+}
+
+// rankPeers sorts the peers by the score rounded to an integer,
+// the peers of the same score are sorted by the latency.
+func rankPeers(peers []*peer) []*peer {
+	type ranked struct {
+		p       *peer
+		score   float64
+		latency float64
+	}
+	list := make([]ranked, 0, len(peers))
+	for _, p := range peers {
+		score, latency := p.score.rank()
+		list = append(list, ranked{p, math.Round(score), latency})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].latency < list[j].latency
+	})
+	sorted := make([]*peer, 0, len(list))
+	for _, r := range list {
+		sorted = append(sorted, r.p)
+	}
+	return sorted
+}
+
+// preferredPeers returns the better half of the candidates for a random
+// pick of k peers, so that the pick steers towards the well behaved and
+// fast peers while still spreading over several of them.
+func preferredPeers(k int, peers []*peer) []*peer {
+	if k <= 0 || len(peers) <= 2*k {
+		return peers
+	}
+	return rankPeers(peers)[:2*k]
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+)
+
+func Test_PeerScore(t *testing.T) {
+	s := newPeerScore()
+	assert.InDelta(t, 1, s.add(ScoreUseful), 0.01)
+	assert.InDelta(t, 3, s.add(ScoreTimelyResponse), 0.01)
+	assert.InDelta(t, -2, s.add(ScoreSlowResponse), 0.01)
+	for i := 0; i < 10; i++ {
+		s.add(ScoreSpam)
+	}
+	score, _ := s.rank()
+	assert.InDelta(t, minScore, score, 0.01)
+
+	// The score halves over the half life.
+	s.updated = s.updated.Add(-scoreHalfLife)
+	score, _ = s.rank()
+	assert.InDelta(t, minScore/2, score, 0.01)
+
+	s.observeLatency(100)
+	s.observeLatency(200)
+	info := s.info()
+	assert.Equal(t, int64(120), info.Latency)
+	assert.Equal(t, uint64(1), info.Useful)
+	assert.Equal(t, uint64(1), info.TimelyResponse)
+	assert.Equal(t, uint64(1), info.SlowResponse)
+	assert.Equal(t, uint64(10), info.Spam)
+}
+
+func Test_PreferredPeers(t *testing.T) {
+	var peers []*peer
+	for i := 0; i < 8; i++ {
+		p, _ := newTestPeer(1, fmt.Sprintf("p%d", i))
+		peers = append(peers, p)
+	}
+	peers[5].score.add(ScoreTimelyResponse)
+	peers[2].score.add(ScoreUseful)
+	peers[0].score.add(ScoreSlowResponse)
+	peers[3].score.observeLatency(10)
+	peers[4].score.observeLatency(500)
+
+	ranked := rankPeers(peers)
+	assert.Equal(t, peers[5].id, ranked[0].id)
+	assert.Equal(t, peers[2].id, ranked[1].id)
+	assert.Equal(t, peers[0].id, ranked[len(ranked)-1].id)
+	assert.Equal(t, peers[4].id, ranked[len(ranked)-2].id)
+
+	preferred := preferredPeers(2, peers)
+	assert.Len(t, preferred, 4)
+	for _, p := range preferred {
+		assert.NotEqual(t, peers[0].id, p.id)
+	}
+	assert.Len(t, preferredPeers(4, peers), len(peers))
+}
+
+func Test_EngineManager_ReportPeer(t *testing.T) {
+	h, fake := newHandle(t)
+	for _, p := range fake.peers {
+		h.scores.attach(p)
+		h.peers.Register(p)
+	}
+	// peers with an even index are not consensus nodes.
+	observer, validator, liar := fake.peers[0], fake.peers[1], fake.peers[2]
+
+	for i := 0; i < 3; i++ {
+		h.ReportPeer(observer.id, ScoreSpam)
+		h.ReportPeer(validator.id, ScoreSpam)
+	}
+	_, err := h.peers.get(observer.id)
+	assert.Equal(t, errNotRegistered, err)
+	assert.False(t, h.ContainsBlacklist(observer.id))
+	_, err = h.peers.get(validator.id)
+	assert.Nil(t, err)
+
+	// Consensus nodes are kept whatever their score.
+	for i := 0; i < 2; i++ {
+		h.ReportPeer(validator.id, ScoreSpam)
+	}
+	_, err = h.peers.get(validator.id)
+	assert.Nil(t, err)
+	assert.False(t, h.ContainsBlacklist(validator.id))
+	assert.Equal(t, uint64(5), validator.Info().Reputation.Spam)
+
+	// But banned on an invalid signature, as are unregistered peers.
+	h.ReportPeer(validator.id, ScoreInvalidSignature)
+	_, err = h.peers.get(validator.id)
+	assert.Equal(t, errNotRegistered, err)
+	assert.True(t, h.ContainsBlacklist(validator.id))
+	h.ReportPeer("unregistered", ScoreInvalidSignature)
+	assert.True(t, h.ContainsBlacklist("unregistered"))
+
+	liar.score.add(ScoreUseful)
+	h.ReportPeer(liar.id, ScoreInvalidSignature)
+	assert.True(t, h.ContainsBlacklist(liar.id))
+
+	// The score is remembered across reconnections.
+	p, _ := newTestPeer(1, "reconnect")
+	p.id = observer.id
+	h.scores.attach(p)
+	assert.Equal(t, uint64(3), p.Info().Reputation.Spam)
+}
+
+func Test_EngineManager_ScoreMessages(t *testing.T) {
+	h, fake := newHandle(t)
+	p := fake.peers[0]
+	h.scores.attach(p)
+	h.peers.Register(p)
+
+	hash := common.BytesToHash([]byte("duplicate"))
+	h.checkDuplicate(p, hash)
+	h.checkDuplicate(p, hash)
+	assert.Equal(t, uint64(1), p.Info().Reputation.Duplicate)
+
+	// The resends of a consensus node aren't penalized.
+	validator := fake.peers[1]
+	h.scores.attach(validator)
+	h.peers.Register(validator)
+	for i := 0; i < 3; i++ {
+		h.checkDuplicate(validator, hash)
+	}
+	assert.Equal(t, uint64(0), validator.Info().Reputation.Duplicate)
+
+	assert.False(t, h.scoreResponse(p, protocols.GetPrepareVoteMsg))
+	p.MarkRequest(protocols.GetPrepareVoteMsg)
+	assert.True(t, h.scoreResponse(p, protocols.GetPrepareVoteMsg))
+	assert.Equal(t, uint64(1), p.Info().Reputation.TimelyResponse)
+
+	p.MarkRequest(protocols.GetPrepareBlockMsg)
+	p.requests[protocols.GetPrepareBlockMsg] = time.Now().Add(-2 * slowResponse)
+	assert.True(t, h.scoreResponse(p, protocols.GetPrepareBlockMsg))
+	assert.Equal(t, uint64(1), p.Info().Reputation.SlowResponse)
+
+	p.MarkRequest(protocols.GetPrepareBlockMsg)
+	p.requests[protocols.GetPrepareBlockMsg] = time.Now().Add(-responseTimeout)
+	assert.False(t, h.scoreResponse(p, protocols.GetPrepareBlockMsg))
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/rlp"
@@ -369,13 +370,14 @@ func (ViewChanges) BHash() common.Hash {
 
 // CbftStatusData implement Message and including status information about peer.
 type CbftStatusData struct {
-	ProtocolVersion uint32       `json:"protocolVersion"` // CBFT protocol version number.
-	QCBn            *big.Int     `json:"qcBn"`            // The highest local block number for collecting block signatures.
-	QCBlock         common.Hash  `json:"qcBlock"`         // The highest local block hash for collecting block signatures.
-	LockBn          *big.Int     `json:"lockBn"`          // Locally locked block number.
-	LockBlock       common.Hash  `json:"lockBlock"`       // Locally locked block hash.
-	CmtBn           *big.Int     `json:"cmtBn"`           // Locally submitted block number.
-	CmtBlock        common.Hash  `json:"cmtBlock"`        // Locally submitted block hash.
+	ProtocolVersion uint32       `json:"protocolVersion"`       // CBFT protocol version number.
+	QCBn            *big.Int     `json:"qcBn"`                  // The highest local block number for collecting block signatures.
+	QCBlock         common.Hash  `json:"qcBlock"`               // The highest local block hash for collecting block signatures.
+	LockBn          *big.Int     `json:"lockBn"`                // Locally locked block number.
+	LockBlock       common.Hash  `json:"lockBlock"`             // Locally locked block hash.
+	CmtBn           *big.Int     `json:"cmtBn"`                 // Locally submitted block number.
+	CmtBlock        common.Hash  `json:"cmtBlock"`              // Locally submitted block hash.
+	ForkID          forkid.ID    `json:"forkID" rlp:"optional"` // Fork identifier of the chain, exchanged since cbft/2.
 	messageHash     atomic.Value `rlp:"-"`
 }
 
@@ -0,0 +1,127 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+)
+
+// The signature is needed within the view, don't wait longer for it.
+const defaultTimeout = 3 * time.Second
+
+var errInvalidSignature = errors.New("invalid signature returned by the signer")
+
+// RemoteSigner signs through a signer service.
+type RemoteSigner struct {
+	client  *rpc.Client
+	timeout time.Duration
+	nodeID  discover.NodeID
+	blsPub  bls.PublicKey
+}
+
+// Dial connects to the signer service at the endpoint, which is the path of
+// an IPC endpoint or an http/ws url, and fetches the public keys it signs for.
+func Dial(endpoint string) (*RemoteSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	s := &RemoteSigner{client: client, timeout: defaultTimeout}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var blsPub hexutil.Bytes
+	if err := client.CallContext(ctx, &s.nodeID, Namespace+"_nodeID"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.CallContext(ctx, &blsPub, Namespace+"_blsPublicKey"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := s.blsPub.Deserialize(blsPub); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the connection to the signer service.
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func (s *RemoteSigner) NodeID() discover.NodeID {
+	return s.nodeID
+}
+
+func (s *RemoteSigner) BlsPublicKey() *bls.PublicKey {
+	return &s.blsPub
+}
+
+func (s *RemoteSigner) SignSeal(epoch, viewNumber uint64, header *types.Header) ([]byte, error) {
+	req, err := NewSealRequest(epoch, viewNumber, header)
+	if err != nil {
+		return nil, err
+	}
+	var sign hexutil.Bytes
+	if err := s.call(&sign, "_signSeal", req); err != nil {
+		return nil, err
+	}
+	pub, err := crypto.Ecrecover(header.SealHash().Bytes(), sign)
+	if err != nil || !bytes.Equal(pub[1:], s.nodeID[:]) {
+		return nil, errInvalidSignature
+	}
+	return sign, nil
+}
+
+func (s *RemoteSigner) SignMsg(msg ctypes.ConsensusMsg) ([]byte, error) {
+	req, err := NewSignRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := msg.CannibalizeBytes()
+	if err != nil {
+		return nil, err
+	}
+	var sign hexutil.Bytes
+	if err := s.call(&sign, "_signMsg", req); err != nil {
+		return nil, err
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(sign); err != nil || !sig.Verify(&s.blsPub, string(digest)) {
+		return nil, errInvalidSignature
+	}
+	return sign, nil
+}
+
+func (s *RemoteSigner) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.client.CallContext(ctx, result, Namespace+method, args...)
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"fmt"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// The kinds of the signed objects, each kind has its own watermark.
+const (
+	KindSeal         = "seal"
+	KindPrepareBlock = "prepareBlock"
+	KindPrepareVote  = "prepareVote"
+	KindViewChange   = "viewChange"
+)
+
+// SealRequest asks for the signature of a block proposed in the given view.
+type SealRequest struct {
+	Epoch      uint64        `json:"epoch"`
+	ViewNumber uint64        `json:"viewNumber"`
+	Header     hexutil.Bytes `json:"header"` // RLP encoded header
+}
+
+// NewSealRequest creates the request for the seal of the header.
+func NewSealRequest(epoch, viewNumber uint64, header *types.Header) (*SealRequest, error) {
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &SealRequest{Epoch: epoch, ViewNumber: viewNumber, Header: enc}, nil
+}
+
+// header decodes the header to seal.
+func (r *SealRequest) header() (*types.Header, error) {
+	var header types.Header
+	if err := rlp.DecodeBytes(r.Header, &header); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// SignRequest carries the fields of a consensus message that go into its
+// signature, the signer computes the digest from them itself instead of
+// signing whatever the node sends.
+type SignRequest struct {
+	Kind        string      `json:"kind"`
+	Epoch       uint64      `json:"epoch"`
+	ViewNumber  uint64      `json:"viewNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockIndex  uint32      `json:"blockIndex"`
+
+	// PrepareBlock only
+	BlockDataHash common.Hash `json:"blockDataHash"`
+	ProposalIndex uint32      `json:"proposalIndex"`
+
+	// ViewChange only, the view of the prepareQC
+	QCEpoch      uint64 `json:"qcEpoch"`
+	QCViewNumber uint64 `json:"qcViewNumber"`
+}
+
+// NewSignRequest creates the request for the signature of the message.
+func NewSignRequest(msg ctypes.ConsensusMsg) (*SignRequest, error) {
+	switch m := msg.(type) {
+	case *protocols.PrepareBlock:
+		blockData, err := rlp.EncodeToBytes(m.Block)
+		if err != nil {
+			return nil, err
+		}
+		return &SignRequest{
+			Kind:          KindPrepareBlock,
+			Epoch:         m.Epoch,
+			ViewNumber:    m.ViewNumber,
+			BlockHash:     m.Block.Hash(),
+			BlockNumber:   m.Block.NumberU64(),
+			BlockIndex:    m.BlockIndex,
+			BlockDataHash: crypto.Keccak256Hash(blockData),
+			ProposalIndex: m.ProposalIndex,
+		}, nil
+	case *protocols.PrepareVote:
+		return &SignRequest{
+			Kind:        KindPrepareVote,
+			Epoch:       m.Epoch,
+			ViewNumber:  m.ViewNumber,
+			BlockHash:   m.BlockHash,
+			BlockNumber: m.BlockNumber,
+			BlockIndex:  m.BlockIndex,
+		}, nil
+	case *protocols.ViewChange:
+		req := &SignRequest{
+			Kind:        KindViewChange,
+			Epoch:       m.Epoch,
+			ViewNumber:  m.ViewNumber,
+			BlockHash:   m.BlockHash,
+			BlockNumber: m.BlockNumber,
+		}
+		if m.PrepareQC != nil {
+			req.QCEpoch, req.QCViewNumber = m.PrepareQC.Epoch, m.PrepareQC.ViewNumber
+		}
+		return req, nil
+	}
+	return nil, fmt.Errorf("unsupported consensus message %T", msg)
+}
+
+// digest returns the bytes signed for the message, it must match the
+// CannibalizeBytes of the message.
+func (r *SignRequest) digest() ([]byte, error) {
+	var fields []interface{}
+	switch r.Kind {
+	case KindPrepareBlock:
+		fields = []interface{}{r.Epoch, r.ViewNumber, r.BlockHash, r.BlockNumber, r.BlockDataHash.Bytes(), r.BlockIndex, r.ProposalIndex}
+	case KindPrepareVote:
+		fields = []interface{}{r.Epoch, r.ViewNumber, r.BlockHash, r.BlockNumber, r.BlockIndex}
+	case KindViewChange:
+		fields = []interface{}{r.Epoch, r.ViewNumber, r.BlockHash, r.BlockNumber, r.QCEpoch, r.QCViewNumber}
+	default:
+		return nil, fmt.Errorf("unknown message kind %q", r.Kind)
+	}
+	buf, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(buf), nil
+}
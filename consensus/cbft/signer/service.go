@@ -0,0 +1,136 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+)
+
+// Namespace is the rpc namespace of the signer service.
+const Namespace = "cbftsigner"
+
+// Service holds the consensus keys away from the node and signs for it
+// over rpc, every signature is checked against the watermarks first.
+type Service struct {
+	signer *LocalSigner
+	marks  *watermarks
+
+	lock     sync.Mutex
+	listener net.Listener
+	server   *rpc.Server
+}
+
+// NewService creates the signer service for the keys, the watermarks are
+// persisted in the file at watermarkPath.
+func NewService(nodeKey *ecdsa.PrivateKey, blsKey *bls.SecretKey, watermarkPath string) (*Service, error) {
+	marks, err := loadWatermarks(watermarkPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{signer: NewLocalSigner(nodeKey, blsKey), marks: marks}, nil
+}
+
+// APIs returns the rpc apis of the signer.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: Namespace,
+			Version:   "1.0",
+			Service:   &PublicSignerAPI{s},
+			Public:    true,
+		},
+	}
+}
+
+// Start serves the apis on the IPC endpoint, a unix socket or a windows
+// named pipe.
+func (s *Service) Start(endpoint string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	listener, server, err := rpc.StartIPCEndpoint(endpoint, s.APIs())
+	if err != nil {
+		return err
+	}
+	s.listener, s.server = listener, server
+	log.Info("Consensus signer started", "endpoint", listener.Addr(), "nodeID", s.signer.NodeID().TerminalString())
+	return nil
+}
+
+// Stop closes the endpoint and the connections of the clients.
+func (s *Service) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.listener != nil {
+		s.listener.Close()
+		s.server.Stop()
+		s.listener, s.server = nil, nil
+		log.Info("Consensus signer stopped")
+	}
+}
+
+// PublicSignerAPI is the api of the signer service.
+type PublicSignerAPI struct {
+	s *Service
+}
+
+// NodeID returns the node id of the validator.
+func (api *PublicSignerAPI) NodeID() discover.NodeID {
+	return api.s.signer.NodeID()
+}
+
+// BlsPublicKey returns the serialized BLS public key of the validator.
+func (api *PublicSignerAPI) BlsPublicKey() hexutil.Bytes {
+	return api.s.signer.BlsPublicKey().Serialize()
+}
+
+// SignSeal signs the seal hash of the header.
+func (api *PublicSignerAPI) SignSeal(req SealRequest) (hexutil.Bytes, error) {
+	header, err := req.header()
+	if err != nil {
+		return nil, err
+	}
+	hash := header.SealHash()
+	if err := api.s.marks.update(KindSeal, req.Epoch, req.ViewNumber, header.Number.Uint64(), hash); err != nil {
+		log.Warn("Refuse to sign seal", "number", header.Number, "sealHash", hash, "err", err)
+		return nil, err
+	}
+	return api.s.signer.signHash(hash.Bytes())
+}
+
+// SignMsg signs the consensus message described by the request.
+func (api *PublicSignerAPI) SignMsg(req SignRequest) (hexutil.Bytes, error) {
+	digest, err := req.digest()
+	if err != nil {
+		return nil, err
+	}
+	if err := api.s.marks.update(req.Kind, req.Epoch, req.ViewNumber, req.BlockNumber, common.BytesToHash(digest)); err != nil {
+		log.Warn("Refuse to sign consensus message", "kind", req.Kind, "number", req.BlockNumber, "hash", req.BlockHash, "err", err)
+		return nil, err
+	}
+	return api.s.signer.signDigest(digest), nil
+}
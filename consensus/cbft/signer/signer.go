@@ -0,0 +1,75 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package signer implements the signers of the consensus messages, either
+// with the keys held by the node or by a signer service the node connects to.
+package signer
+
+import (
+	"crypto/ecdsa"
+
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+)
+
+// LocalSigner signs with the keys held in memory.
+type LocalSigner struct {
+	nodeKey *ecdsa.PrivateKey
+	blsKey  *bls.SecretKey
+	nodeID  discover.NodeID
+}
+
+// NewLocalSigner creates a signer for the given keys.
+func NewLocalSigner(nodeKey *ecdsa.PrivateKey, blsKey *bls.SecretKey) *LocalSigner {
+	return &LocalSigner{
+		nodeKey: nodeKey,
+		blsKey:  blsKey,
+		nodeID:  discover.PubkeyID(&nodeKey.PublicKey),
+	}
+}
+
+func (s *LocalSigner) NodeID() discover.NodeID {
+	return s.nodeID
+}
+
+func (s *LocalSigner) BlsPublicKey() *bls.PublicKey {
+	return s.blsKey.GetPublicKey()
+}
+
+func (s *LocalSigner) SignSeal(epoch, viewNumber uint64, header *types.Header) ([]byte, error) {
+	return s.signHash(header.SealHash().Bytes())
+}
+
+func (s *LocalSigner) SignMsg(msg ctypes.ConsensusMsg) ([]byte, error) {
+	digest, err := msg.CannibalizeBytes()
+	if err != nil {
+		return nil, err
+	}
+	return s.signDigest(digest), nil
+}
+
+// signHash signs the hash with the ECDSA key.
+func (s *LocalSigner) signHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.nodeKey)
+}
+
+// signDigest signs the digest of a consensus message with the BLS key.
+func (s *LocalSigner) signDigest(digest []byte) []byte {
+	return s.blsKey.Sign(string(digest)).Serialize()
+}
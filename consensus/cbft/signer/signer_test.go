@@ -0,0 +1,204 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+)
+
+func newTestKeys(t *testing.T) *LocalSigner {
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	var blsKey bls.SecretKey
+	blsKey.SetByCSPRNG()
+	return NewLocalSigner(nodeKey, &blsKey)
+}
+
+func newTestBlock(number int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(number),
+		ParentHash: common.Hash{byte(number)},
+		Time:       uint64(number),
+		Extra:      make([]byte, 97),
+		GasLimit:   10000000,
+	})
+}
+
+func newTestVote(epoch, viewNumber uint64, block *types.Block, index uint32) *protocols.PrepareVote {
+	return &protocols.PrepareVote{
+		Epoch:       epoch,
+		ViewNumber:  viewNumber,
+		BlockHash:   block.Hash(),
+		BlockNumber: block.NumberU64(),
+		BlockIndex:  index,
+	}
+}
+
+// startStandIn runs a signer service on a unix socket, like a signer
+// process next to the node would.
+func startStandIn(t *testing.T, keys *LocalSigner, dir string) (*Service, *RemoteSigner) {
+	service, err := NewService(keys.nodeKey, keys.blsKey, filepath.Join(dir, "watermarks.json"))
+	require.NoError(t, err)
+	endpoint := filepath.Join(dir, "signer.ipc")
+	require.NoError(t, service.Start(endpoint))
+	remote, err := Dial(endpoint)
+	if err != nil {
+		service.Stop()
+		t.Fatal(err)
+	}
+	return service, remote
+}
+
+func TestSignRequestDigest(t *testing.T) {
+	block := newTestBlock(1)
+	qc := &ctypes.QuorumCert{Epoch: 1, ViewNumber: 2, BlockHash: block.Hash(), BlockNumber: 1}
+	msgs := []ctypes.ConsensusMsg{
+		&protocols.PrepareBlock{Epoch: 1, ViewNumber: 3, Block: block, BlockIndex: 4, ProposalIndex: 5},
+		newTestVote(1, 3, block, 4),
+		&protocols.ViewChange{Epoch: 1, ViewNumber: 3, BlockHash: block.Hash(), BlockNumber: 1},
+		&protocols.ViewChange{Epoch: 1, ViewNumber: 3, BlockHash: block.Hash(), BlockNumber: 1, PrepareQC: qc},
+	}
+	for _, msg := range msgs {
+		req, err := NewSignRequest(msg)
+		require.NoError(t, err)
+		have, err := req.digest()
+		require.NoError(t, err)
+		want, err := msg.CannibalizeBytes()
+		require.NoError(t, err)
+		assert.Equal(t, want, have, "%T", msg)
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cbftsigner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys := newTestKeys(t)
+	service, remote := startStandIn(t, keys, dir)
+	defer service.Stop()
+	defer remote.Close()
+
+	assert.Equal(t, keys.NodeID(), remote.NodeID())
+	assert.True(t, keys.BlsPublicKey().IsEqual(remote.BlsPublicKey()))
+
+	// The signatures are the same as the ones of the local keys
+	block := newTestBlock(1)
+	have, err := remote.SignSeal(1, 0, block.Header())
+	require.NoError(t, err)
+	want, err := keys.SignSeal(1, 0, block.Header())
+	require.NoError(t, err)
+	assert.Equal(t, want, have)
+
+	msgs := []ctypes.ConsensusMsg{
+		&protocols.PrepareBlock{Epoch: 1, ViewNumber: 0, Block: block},
+		newTestVote(1, 0, block, 0),
+		&protocols.ViewChange{Epoch: 1, ViewNumber: 0, BlockHash: block.Hash(), BlockNumber: 1},
+	}
+	for _, msg := range msgs {
+		have, err := remote.SignMsg(msg)
+		require.NoError(t, err)
+		want, err := keys.SignMsg(msg)
+		require.NoError(t, err)
+		assert.Equal(t, want, have, "%T", msg)
+	}
+}
+
+func TestRemoteSignerWatermark(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cbftsigner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys := newTestKeys(t)
+	service, remote := startStandIn(t, keys, dir)
+
+	block1, block2 := newTestBlock(1), newTestBlock(2)
+	fork := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), Time: 100, Extra: make([]byte, 97)})
+
+	_, err = remote.SignMsg(newTestVote(1, 1, block1, 0))
+	require.NoError(t, err)
+	_, err = remote.SignMsg(newTestVote(1, 1, block2, 1))
+	require.NoError(t, err)
+	// Signing the same vote again is fine
+	_, err = remote.SignMsg(newTestVote(1, 1, block2, 1))
+	require.NoError(t, err)
+	// A conflicting vote, or one for an older view, is not
+	_, err = remote.SignMsg(newTestVote(1, 1, fork, 1))
+	assert.Error(t, err)
+	_, err = remote.SignMsg(newTestVote(1, 0, newTestBlock(3), 2))
+	assert.Error(t, err)
+
+	_, err = remote.SignSeal(1, 1, block2.Header())
+	require.NoError(t, err)
+	_, err = remote.SignSeal(1, 1, fork.Header())
+	assert.Error(t, err)
+	_, err = remote.SignSeal(1, 2, fork.Header())
+	require.NoError(t, err)
+
+	_, err = remote.SignMsg(&protocols.ViewChange{Epoch: 1, ViewNumber: 1, BlockHash: block1.Hash(), BlockNumber: 1})
+	require.NoError(t, err)
+	_, err = remote.SignMsg(&protocols.ViewChange{Epoch: 1, ViewNumber: 1, BlockHash: block2.Hash(), BlockNumber: 2})
+	assert.Error(t, err)
+
+	// The watermarks survive a restart of the signer
+	remote.Close()
+	service.Stop()
+	service, remote = startStandIn(t, keys, dir)
+	defer service.Stop()
+	defer remote.Close()
+
+	_, err = remote.SignMsg(newTestVote(1, 1, fork, 1))
+	assert.Error(t, err)
+	_, err = remote.SignMsg(newTestVote(1, 1, block2, 1))
+	require.NoError(t, err)
+	_, err = remote.SignMsg(newTestVote(2, 0, fork, 0))
+	require.NoError(t, err)
+}
+
+func TestWatermarks(t *testing.T) {
+	marks, err := loadWatermarks("")
+	require.NoError(t, err)
+
+	require.NoError(t, marks.update(KindPrepareVote, 1, 1, 10, common.Hash{1}))
+	assert.True(t, errors.Is(marks.update(KindPrepareVote, 1, 1, 10, common.Hash{2}), ErrDoubleSign))
+	assert.True(t, errors.Is(marks.update(KindPrepareVote, 1, 1, 9, common.Hash{3}), ErrDoubleSign))
+	assert.True(t, errors.Is(marks.update(KindPrepareVote, 1, 0, 11, common.Hash{4}), ErrStaleView))
+	assert.True(t, errors.Is(marks.update(KindPrepareVote, 0, 5, 11, common.Hash{5}), ErrStaleView))
+	require.NoError(t, marks.update(KindPrepareVote, 1, 1, 11, common.Hash{6}))
+	require.NoError(t, marks.update(KindPrepareVote, 1, 1, 10, common.Hash{1}))
+	// A new view starts over, the kinds are independent
+	require.NoError(t, marks.update(KindPrepareVote, 1, 2, 10, common.Hash{7}))
+	require.NoError(t, marks.update(KindPrepareBlock, 1, 1, 10, common.Hash{8}))
+
+	require.NoError(t, marks.update(KindViewChange, 1, 1, 10, common.Hash{9}))
+	assert.True(t, errors.Is(marks.update(KindViewChange, 1, 1, 11, common.Hash{10}), ErrDoubleSign))
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+)
+
+var (
+	ErrDoubleSign = errors.New("conflicting signature refused")
+	ErrStaleView  = errors.New("signature for a past view refused")
+)
+
+// watermark is the position of the last signature of a kind. The digests
+// signed in that view are kept so that a message can be signed again, for
+// example when the node replays its wal.
+type watermark struct {
+	Epoch       uint64        `json:"epoch"`
+	ViewNumber  uint64        `json:"viewNumber"`
+	BlockNumber uint64        `json:"blockNumber"`
+	Digests     []common.Hash `json:"digests"`
+}
+
+func (w *watermark) signed(digest common.Hash) bool {
+	for _, d := range w.Digests {
+		if d == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// watermarks guards against double signing, like the safety rules of the
+// node it only allows signing forward:
+//   - nothing is signed for a view before the one of the watermark.
+//   - in the view of the watermark, seals, blocks and votes are only signed
+//     for higher block numbers, and only one view change is signed.
+//
+// The watermarks are persisted before a signature is handed out.
+type watermarks struct {
+	path  string
+	lock  sync.Mutex
+	marks map[string]*watermark
+}
+
+// loadWatermarks reads the watermarks from the file, an empty path keeps
+// them in memory only.
+func loadWatermarks(path string) (*watermarks, error) {
+	w := &watermarks{path: path, marks: make(map[string]*watermark)}
+	if path == "" {
+		return w, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return w, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &w.marks); err != nil {
+		return nil, fmt.Errorf("invalid watermark file %s: %v", path, err)
+	}
+	return w, nil
+}
+
+// update moves the watermark of the kind to the signature of the digest,
+// it fails if the signature could conflict with one handed out before.
+func (w *watermarks) update(kind string, epoch, viewNumber, blockNumber uint64, digest common.Hash) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	mark := w.marks[kind]
+	var next *watermark
+	switch {
+	case mark == nil || epoch > mark.Epoch || (epoch == mark.Epoch && viewNumber > mark.ViewNumber):
+		next = &watermark{Epoch: epoch, ViewNumber: viewNumber, BlockNumber: blockNumber, Digests: []common.Hash{digest}}
+
+	case epoch == mark.Epoch && viewNumber == mark.ViewNumber:
+		if mark.signed(digest) {
+			return nil
+		}
+		if kind == KindViewChange || blockNumber <= mark.BlockNumber {
+			return fmt.Errorf("%w: %s at epoch %d view %d number %d, signed up to number %d",
+				ErrDoubleSign, kind, epoch, viewNumber, blockNumber, mark.BlockNumber)
+		}
+		next = &watermark{Epoch: epoch, ViewNumber: viewNumber, BlockNumber: blockNumber, Digests: append(append([]common.Hash{}, mark.Digests...), digest)}
+
+	default:
+		return fmt.Errorf("%w: %s at epoch %d view %d, signed up to epoch %d view %d",
+			ErrStaleView, kind, epoch, viewNumber, mark.Epoch, mark.ViewNumber)
+	}
+
+	w.marks[kind] = next
+	if err := w.flush(); err != nil {
+		w.marks[kind] = mark
+		return err
+	}
+	return nil
+}
+
+// flush writes the watermarks to the file, the rename makes sure that a
+// crash never leaves a partial file behind.
+func (w *watermarks) flush() error {
+	if w.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(w.marks)
+	if err != nil {
+		return err
+	}
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.path)
+}
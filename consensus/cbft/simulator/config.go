@@ -0,0 +1,203 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+)
+
+// The actions of a scenario step.
+const (
+	ActionLatency    = "latency"    // delay the messages of Nodes, or all messages, by Latency plus up to Jitter
+	ActionPartition  = "partition"  // split the network into Groups
+	ActionHeal       = "heal"       // remove the partition
+	ActionDrop       = "drop"       // drop each message with probability Rate
+	ActionReorder    = "reorder"    // delay each message with probability Rate by up to Latency
+	ActionCrash      = "crash"      // stop Nodes
+	ActionRestart    = "restart"    // start the crashed Nodes again, they recover from their WAL
+	ActionEquivocate = "equivocate" // make Nodes send a conflicting vote after each vote
+	ActionElect      = "elect"      // elect Nodes as the validators of the rounds following the next switch
+)
+
+// Duration is a time.Duration written as a string like "1.5s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Step is a fault injected at a point of the run.
+type Step struct {
+	At      Duration `json:"at"`
+	Action  string   `json:"action"`
+	Nodes   []int    `json:"nodes,omitempty"`
+	Groups  [][]int  `json:"groups,omitempty"`
+	Latency Duration `json:"latency,omitempty"`
+	Jitter  Duration `json:"jitter,omitempty"`
+	Rate    float64  `json:"rate,omitempty"`
+}
+
+func (s *Step) String() string {
+	switch s.Action {
+	case ActionLatency:
+		if len(s.Nodes) == 0 {
+			return fmt.Sprintf("latency %v+%v", time.Duration(s.Latency), time.Duration(s.Jitter))
+		}
+		return fmt.Sprintf("latency %v+%v on nodes %v", time.Duration(s.Latency), time.Duration(s.Jitter), s.Nodes)
+	case ActionPartition:
+		return fmt.Sprintf("partition %v", s.Groups)
+	case ActionDrop:
+		return fmt.Sprintf("drop %.2f", s.Rate)
+	case ActionReorder:
+		return fmt.Sprintf("reorder %.2f up to %v", s.Rate, time.Duration(s.Latency))
+	case ActionCrash, ActionRestart, ActionEquivocate, ActionElect:
+		return fmt.Sprintf("%s %v", s.Action, s.Nodes)
+	}
+	return s.Action
+}
+
+// Config is the network and the scenario of a run.
+type Config struct {
+	Nodes  int    `json:"nodes"`
+	Period uint64 `json:"period"` // milliseconds per view
+	Amount uint32 `json:"amount"` // blocks per view
+
+	Duration Duration `json:"duration"`
+	Seed     int64    `json:"seed"`
+
+	// StallTimeout is the longest time without a new committed block while
+	// a quorum of the nodes can reach each other, 10 views if zero.
+	StallTimeout Duration `json:"stallTimeout,omitempty"`
+
+	// DataDir holds the WAL and the evidences of the nodes, a temporary
+	// directory is used if empty.
+	DataDir string `json:"-"`
+
+	Steps []*Step `json:"steps"`
+}
+
+// DefaultConfig is a four node network without faults.
+var DefaultConfig = Config{
+	Nodes:    4,
+	Period:   2000,
+	Amount:   10,
+	Duration: Duration(time.Minute),
+	Seed:     1,
+}
+
+// LoadConfig reads a scenario file, the fields it doesn't set are taken
+// from DefaultConfig.
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := DefaultConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %v", file, err)
+	}
+	return &config, nil
+}
+
+func (c *Config) stallTimeout() time.Duration {
+	if c.StallTimeout > 0 {
+		return time.Duration(c.StallTimeout)
+	}
+	return 10 * time.Duration(c.Period) * time.Millisecond
+}
+
+func (c *Config) validate() error {
+	if c.Nodes < 1 {
+		return errors.New("at least one node is required")
+	}
+	if c.Period == 0 || c.Amount == 0 {
+		return errors.New("period and amount must be positive")
+	}
+	if c.Period/uint64(c.Amount) == 0 {
+		return errors.New("period must be at least one millisecond per block")
+	}
+	if c.Duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+	checkNodes := func(s *Step, nodes []int) error {
+		for _, i := range nodes {
+			if i < 0 || i >= c.Nodes {
+				return fmt.Errorf("step %q: node %d out of range", s.Action, i)
+			}
+		}
+		return nil
+	}
+	for _, s := range c.Steps {
+		if s.At < 0 || s.At > c.Duration {
+			return fmt.Errorf("step %q at %v is outside the run", s.Action, time.Duration(s.At))
+		}
+		switch s.Action {
+		case ActionLatency, ActionHeal:
+		case ActionPartition:
+			if len(s.Groups) == 0 {
+				return fmt.Errorf("step %q: no groups", s.Action)
+			}
+			for _, g := range s.Groups {
+				if err := checkNodes(s, g); err != nil {
+					return err
+				}
+			}
+		case ActionDrop, ActionReorder:
+			if s.Rate < 0 || s.Rate > 1 {
+				return fmt.Errorf("step %q: rate %v out of [0, 1]", s.Action, s.Rate)
+			}
+		case ActionCrash, ActionRestart, ActionEquivocate:
+			if len(s.Nodes) == 0 {
+				return fmt.Errorf("step %q: no nodes", s.Action)
+			}
+		case ActionElect:
+			if len(s.Nodes) == 0 {
+				return fmt.Errorf("step %q: no nodes", s.Action)
+			}
+			// The miners leave a block empty if the round trip estimate
+			// takes the whole time of the block
+			if c.Period/uint64(c.Amount) <= 2*protocols.DefaultAvgLatency {
+				return fmt.Errorf("step %q: blocks of %dms can't pack transactions, more than %dms are required", s.Action, c.Period/uint64(c.Amount), 2*protocols.DefaultAvgLatency)
+			}
+		default:
+			return fmt.Errorf("unknown action %q", s.Action)
+		}
+		if err := checkNodes(s, s.Nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"bytes"
+	"container/heap"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// MsgStats counts the consensus messages sent by a node.
+type MsgStats struct {
+	Sent          uint64 `json:"sent"`
+	Dropped       uint64 `json:"dropped"`
+	Delayed       uint64 `json:"delayed"`
+	Reordered     uint64 `json:"reordered"`
+	Equivocations uint64 `json:"equivocations"`
+}
+
+// network holds the faults applied to the messages between the nodes.
+type network struct {
+	lock sync.Mutex
+	rand *rand.Rand
+
+	latency      time.Duration
+	jitter       time.Duration
+	slow         map[int]bool // the nodes the latency applies to, all if empty
+	dropRate     float64
+	reorderRate  float64
+	reorderDelay time.Duration
+	groups       map[int]int // the partition group of each node, nil if not partitioned
+
+	equivocators map[int]func(*protocols.PrepareVote) error
+	stats        []MsgStats
+}
+
+func newNetwork(nodes int, seed int64) *network {
+	return &network{
+		rand:         rand.New(rand.NewSource(seed)),
+		slow:         make(map[int]bool),
+		equivocators: make(map[int]func(*protocols.PrepareVote) error),
+		stats:        make([]MsgStats, nodes),
+	}
+}
+
+func (n *network) setLatency(latency, jitter time.Duration, nodes []int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.latency, n.jitter = latency, jitter
+	n.slow = make(map[int]bool)
+	for _, i := range nodes {
+		n.slow[i] = true
+	}
+}
+
+func (n *network) setDrop(rate float64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.dropRate = rate
+}
+
+func (n *network) setReorder(rate float64, delay time.Duration) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.reorderRate, n.reorderDelay = rate, delay
+}
+
+// partition splits the nodes into the groups, the nodes not in any group
+// form a group of their own.
+func (n *network) partition(groups [][]int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = make(map[int]int)
+	for g, nodes := range groups {
+		for _, i := range nodes {
+			n.groups[i] = g + 1
+		}
+	}
+}
+
+func (n *network) heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = nil
+}
+
+func (n *network) equivocate(node int, sign func(*protocols.PrepareVote) error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.equivocators[node] = sign
+}
+
+func (n *network) connected(a, b int) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.groups == nil || n.groups[a] == n.groups[b]
+}
+
+// route decides the fate of a message from a node to another one.
+func (n *network) route(from, to int) (drop bool, delay time.Duration) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	stats := &n.stats[from]
+	stats.Sent++
+	if n.groups != nil && n.groups[from] != n.groups[to] {
+		stats.Dropped++
+		return true, 0
+	}
+	if n.dropRate > 0 && n.rand.Float64() < n.dropRate {
+		stats.Dropped++
+		return true, 0
+	}
+	if n.latency > 0 && (len(n.slow) == 0 || n.slow[from] || n.slow[to]) {
+		delay = n.latency
+		if n.jitter > 0 {
+			delay += time.Duration(n.rand.Int63n(int64(n.jitter)))
+		}
+		stats.Delayed++
+	}
+	if n.reorderRate > 0 && n.rand.Float64() < n.reorderRate {
+		delay += time.Duration(n.rand.Int63n(int64(n.reorderDelay) + 1))
+		stats.Reordered++
+	}
+	return false, delay
+}
+
+// conflictingVote returns a vote for another block signed by the node if it
+// equivocates.
+func (n *network) conflictingVote(from int, payload []byte) []byte {
+	var hash common.Hash
+	n.lock.Lock()
+	sign, ok := n.equivocators[from]
+	n.rand.Read(hash[:])
+	n.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	var vote protocols.PrepareVote
+	if err := rlp.DecodeBytes(payload, &vote); err != nil {
+		return nil
+	}
+	vote.BlockHash = hash
+	if err := sign(&vote); err != nil {
+		log.Warn("Failed to sign the conflicting vote", "node", from, "err", err)
+		return nil
+	}
+	conflicting, err := rlp.EncodeToBytes(&vote)
+	if err != nil {
+		return nil
+	}
+	n.lock.Lock()
+	n.stats[from].Equivocations++
+	n.lock.Unlock()
+	return conflicting
+}
+
+func (n *network) msgStats() []MsgStats {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]MsgStats(nil), n.stats...)
+}
+
+type pending struct {
+	msg       p2p.Msg
+	payload   []byte
+	deliverAt time.Time
+	seq       uint64
+}
+
+type pendingQueue []*pending
+
+func (q pendingQueue) Len() int { return len(q) }
+func (q pendingQueue) Less(i, j int) bool {
+	if q[i].deliverAt.Equal(q[j].deliverAt) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].deliverAt.Before(q[j].deliverAt)
+}
+func (q pendingQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pendingQueue) Push(x interface{}) { *q = append(*q, x.(*pending)) }
+func (q *pendingQueue) Pop() interface{} {
+	old := *q
+	p := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return p
+}
+
+// faultRW is the end of a pipe between two nodes, the messages written to
+// it are dropped or delayed by the network.
+type faultRW struct {
+	net      *network
+	from, to int
+	pipe     *p2p.MsgPipeRW
+
+	lock   sync.Mutex
+	queue  pendingQueue
+	seq    uint64
+	wake   chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFaultRW(net *network, from, to int, pipe *p2p.MsgPipeRW) *faultRW {
+	rw := &faultRW{
+		net:    net,
+		from:   from,
+		to:     to,
+		pipe:   pipe,
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	go rw.loop()
+	return rw
+}
+
+func (rw *faultRW) ReadMsg() (p2p.Msg, error) {
+	return rw.pipe.ReadMsg()
+}
+
+func (rw *faultRW) WriteMsg(msg p2p.Msg) error {
+	// The handshake is never faulted, a partition is simulated by dropping
+	// the messages after it.
+	if msg.Code == protocols.CBFTStatusMsg {
+		return rw.pipe.WriteMsg(msg)
+	}
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-rw.closed:
+		return p2p.ErrPipeClosed
+	default:
+	}
+	rw.send(msg, payload)
+	if msg.Code == protocols.PrepareVoteMsg {
+		if conflicting := rw.net.conflictingVote(rw.from, payload); conflicting != nil {
+			rw.send(p2p.Msg{Code: msg.Code, Size: uint32(len(conflicting))}, conflicting)
+		}
+	}
+	return nil
+}
+
+func (rw *faultRW) send(msg p2p.Msg, payload []byte) {
+	drop, delay := rw.net.route(rw.from, rw.to)
+	if drop {
+		return
+	}
+	rw.lock.Lock()
+	rw.seq++
+	heap.Push(&rw.queue, &pending{msg: msg, payload: payload, deliverAt: time.Now().Add(delay), seq: rw.seq})
+	rw.lock.Unlock()
+	select {
+	case rw.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop delivers the queued messages when they are due.
+func (rw *faultRW) loop() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		rw.lock.Lock()
+		var next *pending
+		wait := time.Hour
+		if len(rw.queue) > 0 {
+			if wait = time.Until(rw.queue[0].deliverAt); wait <= 0 {
+				next = heap.Pop(&rw.queue).(*pending)
+			}
+		}
+		rw.lock.Unlock()
+
+		if next != nil {
+			next.msg.Payload = bytes.NewReader(next.payload)
+			if err := rw.pipe.WriteMsg(next.msg); err != nil {
+				return
+			}
+			continue
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-rw.wake:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-rw.closed:
+			return
+		}
+	}
+}
+
+func (rw *faultRW) Close() {
+	rw.once.Do(func() {
+		close(rw.closed)
+		rw.pipe.Close()
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/signer"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/validator"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/eth"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/miner"
+	"github.com/PlatONnetwork/PlatON-Go/node"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+)
+
+// simNode is a validator of the simulated network. The chain database
+// outlives the node so that it finds its chain and WAL again on restart.
+type simNode struct {
+	index   int
+	nodeKey *ecdsa.PrivateKey
+	blsKey  *bls.SecretKey
+	id      discover.NodeID
+	datadir string
+	db      ethdb.Database
+
+	stack  *node.Node
+	engine *cbft.Cbft
+	chain  *core.BlockChain
+	cache  *core.BlockChainCache
+	txPool *core.TxPool
+	miner  *miner.Miner
+
+	running  bool
+	restarts int
+}
+
+func (n *simNode) BlockChain() *core.BlockChain { return n.chain }
+func (n *simNode) TxPool() *core.TxPool         { return n.txPool }
+
+func (n *simNode) name() string {
+	return fmt.Sprintf("node%d", n.index)
+}
+
+// start brings the node up like eth.New does in inner validator mode.
+func (n *simNode) start(config *Config, chainConfig *params.ChainConfig) error {
+	stack, err := node.New(&node.Config{Name: n.name(), DataDir: n.datadir, P2P: p2p.Config{PrivateKey: n.nodeKey}})
+	if err != nil {
+		return err
+	}
+	opt := eth.DefaultConfig.CbftConfig
+	opt.NodePriKey, opt.NodeID, opt.BlsPriKey = n.nodeKey, n.id, n.blsKey
+	opt.Period, opt.Amount = config.Period, config.Amount
+	engine := cbft.New(chainConfig.Cbft, &opt, stack.EventMux(), stack)
+	if engine == nil {
+		stack.Close()
+		return errors.New("failed to open the evidence pool")
+	}
+	chain, err := core.NewBlockChain(n.db, nil, chainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		engine.Close()
+		stack.Close()
+		return err
+	}
+	cache := core.NewBlockChainCache(chain)
+
+	poolConfig := eth.DefaultConfig.TxPool
+	poolConfig.Journal = ""
+	txPool := core.NewTxPool(poolConfig, chainConfig, core.NewTxPoolBlockChain(cache))
+
+	mining := &core.MiningConfig{
+		MiningLogAtDepth:       eth.DefaultConfig.MiningLogAtDepth,
+		TxChanSize:             eth.DefaultConfig.TxChanSize,
+		ChainHeadChanSize:      eth.DefaultConfig.ChainHeadChanSize,
+		ChainSideChanSize:      eth.DefaultConfig.ChainSideChanSize,
+		ResultQueueSize:        eth.DefaultConfig.ResultQueueSize,
+		ResubmitAdjustChanSize: eth.DefaultConfig.ResubmitAdjustChanSize,
+		MinRecommitInterval:    eth.DefaultConfig.MinRecommitInterval,
+		MaxRecommitInterval:    eth.DefaultConfig.MaxRecommitInterval,
+		IntervalAdjustRatio:    eth.DefaultConfig.IntervalAdjustRatio,
+		IntervalAdjustBias:     eth.DefaultConfig.IntervalAdjustBias,
+		StaleThreshold:         eth.DefaultConfig.StaleThreshold,
+		DefaultCommitRatio:     eth.DefaultConfig.DefaultCommitRatio,
+	}
+	minerConfig := eth.DefaultConfig.Miner
+	n.stack, n.engine, n.chain, n.cache, n.txPool = stack, engine, chain, cache, txPool
+	n.miner = miner.New(n, &minerConfig, chainConfig, mining, stack.EventMux(), engine,
+		func(*types.Block) bool { return false }, cache, eth.DefaultConfig.VmTimeoutDuration)
+
+	blocksPerNode := int(chainConfig.Cbft.Amount)
+	agency := validator.NewInnerAgency(chainConfig.Cbft.InitialNodes, chain, blocksPerNode, blocksPerNode*2)
+	if err := engine.Start(chain, cache, txPool, agency); err != nil {
+		n.stop()
+		return err
+	}
+	n.miner.Start()
+	n.running = true
+	return nil
+}
+
+// stop shuts the node down. Unlike eth.Stop the miner and the event mux are
+// stopped before the consensus engine, the worker would otherwise update the
+// wal of a closed engine, and the engine is closed before the tx pool, its
+// receive loop may still be waiting for the pool.
+func (n *simNode) stop() {
+	n.running = false
+	n.miner.Stop()
+	n.miner.Close()
+	n.stack.EventMux().Stop()
+	n.engine.Close()
+	n.engine.Stop()
+	n.txPool.Stop()
+	n.chain.Stop()
+	n.stack.Close()
+}
+
+// signVote signs a vote with the consensus key of the node.
+func (n *simNode) signVote(vote *protocols.PrepareVote) error {
+	sign, err := signer.NewLocalSigner(n.nodeKey, n.blsKey).SignMsg(vote)
+	if err != nil {
+		return err
+	}
+	vote.SetSign(sign)
+	return nil
+}
+
+// duplicateVotes returns the validators the node has evidence of
+// equivocating votes against.
+func (n *simNode) duplicateVotes() []uint32 {
+	// Only the fields of evidence.EvidenceData needed here
+	var data struct {
+		DV []struct {
+			VoteA struct {
+				ValidateNode struct {
+					Index uint32 `json:"index"`
+				} `json:"validateNode"`
+			} `json:"voteA"`
+		} `json:"duplicateVote"`
+	}
+	if err := json.Unmarshal([]byte(n.engine.Evidences()), &data); err != nil {
+		return nil
+	}
+	indexes := make([]uint32, 0, len(data.DV))
+	for _, dv := range data.DV {
+		indexes = append(indexes, dv.VoteA.ValidateNode.Index)
+	}
+	return indexes
+}
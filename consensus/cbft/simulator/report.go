@@ -0,0 +1,124 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+)
+
+// The invariants checked during a run.
+const (
+	// Safety is violated if two nodes commit different blocks at a height.
+	Safety = "safety"
+	// Finality is violated if a node replaces a block it committed.
+	Finality = "finality"
+	// Liveness is violated if no block is committed for longer than the
+	// stall timeout while a quorum of the nodes can reach each other.
+	Liveness = "liveness"
+	// Accountability is violated if the votes of an equivocating node are
+	// never reported as evidence by the other nodes.
+	Accountability = "accountability"
+)
+
+// Violation is a broken invariant.
+type Violation struct {
+	At        Duration `json:"at"`
+	Invariant string   `json:"invariant"`
+	Detail    string   `json:"detail"`
+}
+
+// Event is a fault injected during the run.
+type Event struct {
+	At    Duration `json:"at"`
+	Fault string   `json:"fault"`
+	Error string   `json:"error,omitempty"`
+}
+
+// NodeReport is the state of a node at the end of the run.
+type NodeReport struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Running  bool   `json:"running"`
+	Restarts int    `json:"restarts"`
+	// Validator reports whether the node is a validator of its current
+	// epoch, it is false for a stopped node.
+	Validator bool        `json:"validator"`
+	Head      uint64      `json:"head"`
+	HeadHash  common.Hash `json:"headHash"`
+	Messages  MsgStats    `json:"messages"`
+	// DuplicateVotes are the validators this node holds evidence of
+	// equivocating votes against.
+	DuplicateVotes []uint32 `json:"duplicateVotes,omitempty"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Nodes    int      `json:"nodes"`
+	Period   uint64   `json:"period"`
+	Amount   uint32   `json:"amount"`
+	Duration Duration `json:"duration"`
+	Seed     int64    `json:"seed"`
+	// Mode is the validator mode of the nodes, the PPOS plugins aren't run.
+	Mode string `json:"mode"`
+
+	// Committed is the highest block committed by any node.
+	Committed  uint64        `json:"committed"`
+	Timeline   []*Event      `json:"timeline"`
+	NodeStates []*NodeReport `json:"nodeStates"`
+	Violations []*Violation  `json:"violations"`
+	Passed     bool          `json:"passed"`
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	result := "PASSED"
+	if !r.Passed {
+		result = "FAILED"
+	}
+	fmt.Fprintf(&b, "CBFT simulation %s: %d nodes, period %dms, amount %d, %v, seed %d, %s validator mode\n",
+		result, r.Nodes, r.Period, r.Amount, time.Duration(r.Duration), r.Seed, r.Mode)
+	fmt.Fprintf(&b, "Highest committed block: %d\n", r.Committed)
+	if len(r.Timeline) > 0 {
+		fmt.Fprintf(&b, "\nFaults:\n")
+		for _, e := range r.Timeline {
+			fmt.Fprintf(&b, "  %8v  %s", time.Duration(e.At).Round(time.Millisecond), e.Fault)
+			if e.Error != "" {
+				fmt.Fprintf(&b, " (error: %s)", e.Error)
+			}
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&b, "\nNodes:\n")
+	fmt.Fprintf(&b, "  %-5s %-18s %-8s %-9s %-8s %-8s %-8s %-8s %-8s %-8s %s\n",
+		"node", "id", "running", "validator", "restarts", "head", "sent", "dropped", "delayed", "equivoc", "evidence")
+	for _, n := range r.NodeStates {
+		fmt.Fprintf(&b, "  %-5d %-18s %-8t %-9t %-8d %-8d %-8d %-8d %-8d %-8d %v\n",
+			n.Index, n.ID, n.Running, n.Validator, n.Restarts, n.Head, n.Messages.Sent, n.Messages.Dropped,
+			n.Messages.Delayed, n.Messages.Equivocations, n.DuplicateVotes)
+	}
+	if len(r.Violations) > 0 {
+		fmt.Fprintf(&b, "\nViolations:\n")
+		for _, v := range r.Violations {
+			fmt.Fprintf(&b, "  %8v  %-14s %s\n", time.Duration(v.At).Round(time.Millisecond), v.Invariant, v.Detail)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,657 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulator runs a network of CBFT validators in one process,
+// injects faults into it and checks the safety and liveness of the
+// consensus.
+//
+// Every node runs the real consensus engine, miner, block chain and WAL,
+// the nodes are connected by message pipes through which latency,
+// partitions, drops and reordering are applied. A node lagging behind
+// imports the committed blocks of a node it can reach, standing in for the
+// block sync of the eth protocol. The validators are elected through the
+// inner validator contract like on a network in inner mode, the miners
+// switching the set at the end of each round.
+//
+// The PPOS plugins aren't run, so the staking, slashing and election
+// incidents of a network can't be reproduced, only the consensus ones. The
+// plugins, the block chain reactor and the snapshot database they keep their
+// state in are process wide instances, which take the blocks of a single chain
+// in order, while the nodes here execute the same blocks concurrently and at
+// their own pace. Running them needs those instances per node first. The
+// report states the validator mode of the run.
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+const (
+	// checkInterval is the interval of the invariant checks and of the
+	// reconnection of the nodes.
+	checkInterval = 200 * time.Millisecond
+	// finalityDepth is the number of committed blocks of a node rechecked
+	// on each check.
+	finalityDepth = 64
+	// syncDistance is how far a node may lag behind a node it can reach
+	// before it imports the committed blocks of that node. The consensus
+	// only fetches the blocks a few ahead of its highest QC block, the
+	// nodes of a network catch up further through the block sync of the
+	// eth protocol, which isn't run here.
+	syncDistance = 3
+	// syncBatch is the maximum number of blocks imported per check.
+	syncBatch = 64
+)
+
+// innerAccount is the account the miners send the validator switches from.
+var innerAccount = common.HexToAddress("0x795Ed7D9811BddbccC728c301aC3BbC0c58d1EA2")
+
+var (
+	setupOnce sync.Once
+
+	// executorChain is the chain of the transaction executor, which is
+	// process wide like the reactor.
+	executorChain = new(simChain)
+)
+
+// setup prepares the process wide state shared by the nodes.
+func setup() {
+	setupOnce.Do(func() {
+		xcom.GetEc(xcom.DefaultUnitTestNet)
+		snapshotdb.SetDBMemory(true)
+		core.NewBlockChainReactor(new(event.TypeMux), params.TestnetChainConfig.ChainID).Start(common.INNER_VALIDATOR_MODE)
+		core.NewExecutor(params.TestnetChainConfig, executorChain, vm.Config{}, nil)
+	})
+}
+
+// simChain reads the headers and the blocks the transactions executed by the
+// nodes of the current simulation refer to.
+type simChain struct {
+	lock  sync.RWMutex
+	nodes []*simNode
+}
+
+func (c *simChain) set(nodes []*simNode) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.nodes = nodes
+}
+
+func (c *simChain) Engine() consensus.Engine {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, n := range c.nodes {
+		if n.running {
+			return n.engine
+		}
+	}
+	return nil
+}
+
+func (c *simChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, n := range c.nodes {
+		if header := rawdb.ReadHeader(n.db, hash, number); header != nil {
+			return header
+		}
+	}
+	return nil
+}
+
+type link struct {
+	a, b *faultRW
+}
+
+func (l *link) close() {
+	l.a.Close()
+	l.b.Close()
+}
+
+// Simulator runs a scenario on a simulated network.
+type Simulator struct {
+	config      *Config
+	chainConfig *params.ChainConfig
+	validators  []params.CbftNode
+	nodes       []*simNode
+	net         *network
+	tempDir     string
+
+	electKey   *ecdsa.PrivateKey // the account sending the elections
+	electNonce uint64
+
+	lock  sync.Mutex
+	links map[[2]int]*link
+
+	start        time.Time
+	timeline     []*Event
+	violations   []*Violation
+	hashes       [][]common.Hash // the committed hashes of each node by height
+	forked       map[uint64]bool
+	best         uint64
+	progress     time.Duration
+	stalled      bool
+	equivocators map[int]bool
+	evidence     []map[uint32]bool // the validators each node caught equivocating
+}
+
+// New creates the nodes of the simulated network.
+func New(config *Config) (*Simulator, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	setup()
+
+	s := &Simulator{
+		config:       config,
+		net:          newNetwork(config.Nodes, config.Seed),
+		links:        make(map[[2]int]*link),
+		hashes:       make([][]common.Hash, config.Nodes),
+		forked:       make(map[uint64]bool),
+		equivocators: make(map[int]bool),
+		evidence:     make([]map[uint32]bool, config.Nodes),
+	}
+	datadir := config.DataDir
+	if datadir == "" {
+		tmp, err := ioutil.TempDir("", "cbftsim")
+		if err != nil {
+			return nil, err
+		}
+		s.tempDir, datadir = tmp, tmp
+	}
+	electKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	s.electKey = electKey
+
+	s.nodes = make([]*simNode, config.Nodes)
+	s.validators = make([]params.CbftNode, config.Nodes)
+	for i := range s.nodes {
+		nodeKey, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		var blsKey bls.SecretKey
+		blsKey.SetByCSPRNG()
+		id := discover.PubkeyID(&nodeKey.PublicKey)
+		s.nodes[i] = &simNode{
+			index:   i,
+			nodeKey: nodeKey,
+			blsKey:  &blsKey,
+			id:      id,
+			datadir: filepath.Join(datadir, fmt.Sprintf("node%d", i)),
+			db:      rawdb.NewMemoryDatabase(),
+		}
+		s.validators[i] = params.CbftNode{Node: *discover.NewNode(id, nil, 0, 0), BlsPubKey: *blsKey.GetPublicKey()}
+		s.evidence[i] = make(map[uint32]bool)
+	}
+	executorChain.set(s.nodes)
+
+	// The accounts sending the switches and the elections pay for the gas
+	balance := new(big.Int).Mul(big.NewInt(1e6), big.NewInt(params.LAT))
+	chainConfig := *params.TestnetChainConfig
+	chainConfig.Cbft = &params.CbftConfig{
+		Period:        config.Period,
+		Amount:        config.Amount,
+		InitialNodes:  s.validators,
+		ValidatorMode: common.INNER_VALIDATOR_MODE,
+	}
+	s.chainConfig = &chainConfig
+	genesis := core.Genesis{
+		Config: s.chainConfig,
+		Alloc: core.GenesisAlloc{
+			xcom.PlatONFundAccount():                   {Balance: xcom.PlatONFundBalance()},
+			cvm.RewardManagerPoolAddr:                  {Balance: xcom.PlatONFundBalance()},
+			innerAccount:                               {Balance: balance},
+			crypto.PubkeyToAddress(electKey.PublicKey): {Balance: balance},
+		},
+		EconomicModel: xcom.GetEc(xcom.DefaultUnitTestNet),
+	}
+	for _, n := range s.nodes {
+		genesis.MustCommit(n.db)
+	}
+	return s, nil
+}
+
+// Run runs the scenario and checks the invariants until the end of it.
+func (s *Simulator) Run() (*Report, error) {
+	defer s.cleanup()
+
+	s.start = time.Now()
+	for _, n := range s.nodes {
+		if err := n.start(s.config, s.chainConfig); err != nil {
+			s.stopAll()
+			return nil, fmt.Errorf("failed to start node %d: %v", n.index, err)
+		}
+	}
+	s.connectAll()
+
+	steps := make([]*Step, len(s.config.Steps))
+	copy(steps, s.config.Steps)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	end := time.NewTimer(time.Duration(s.config.Duration))
+	defer end.Stop()
+	for {
+		var next <-chan time.Time
+		if len(steps) > 0 {
+			next = time.After(time.Until(s.start.Add(time.Duration(steps[0].At))))
+		}
+		select {
+		case <-next:
+			s.apply(steps[0])
+			steps = steps[1:]
+		case <-ticker.C:
+			s.connectAll()
+			s.syncAll()
+			s.check()
+		case <-end.C:
+			s.check()
+			report := s.report()
+			s.stopAll()
+			return report, nil
+		}
+	}
+}
+
+func (s *Simulator) elapsed() Duration {
+	return Duration(time.Since(s.start))
+}
+
+func (s *Simulator) violate(invariant, format string, args ...interface{}) {
+	v := &Violation{At: s.elapsed(), Invariant: invariant, Detail: fmt.Sprintf(format, args...)}
+	log.Error("Invariant violated", "invariant", invariant, "detail", v.Detail)
+	s.violations = append(s.violations, v)
+}
+
+// apply injects the fault of the step.
+func (s *Simulator) apply(step *Step) {
+	log.Info("Injecting fault", "fault", step)
+	event := &Event{At: s.elapsed(), Fault: step.String()}
+	s.timeline = append(s.timeline, event)
+
+	switch step.Action {
+	case ActionLatency:
+		s.net.setLatency(time.Duration(step.Latency), time.Duration(step.Jitter), step.Nodes)
+	case ActionPartition:
+		s.net.partition(step.Groups)
+	case ActionHeal:
+		s.net.heal()
+	case ActionDrop:
+		s.net.setDrop(step.Rate)
+	case ActionReorder:
+		s.net.setReorder(step.Rate, time.Duration(step.Latency))
+	case ActionCrash:
+		for _, i := range step.Nodes {
+			s.crash(s.nodes[i])
+		}
+	case ActionRestart:
+		for _, i := range step.Nodes {
+			if err := s.restart(s.nodes[i]); err != nil {
+				log.Error("Failed to restart node", "node", i, "err", err)
+				event.Error = err.Error()
+			}
+		}
+	case ActionEquivocate:
+		for _, i := range step.Nodes {
+			s.net.equivocate(i, s.nodes[i].signVote)
+			s.equivocators[i] = true
+		}
+	case ActionElect:
+		if err := s.elect(step.Nodes); err != nil {
+			log.Error("Failed to elect validators", "nodes", step.Nodes, "err", err)
+			event.Error = err.Error()
+		}
+	}
+}
+
+// elect sends the update of the next validators to the inner validator
+// contract. The tx pools aren't connected, so the transaction is added to
+// the pool of every running node for the next proposer to pack it.
+func (s *Simulator) elect(nodes []int) error {
+	var next vm.Validators
+	for i, index := range nodes {
+		next.ValidateNodes = append(next.ValidateNodes, &vm.ValidateNode{
+			Index:     uint(i),
+			NodeID:    s.validators[index].Node.ID,
+			BlsPubKey: s.validators[index].BlsPubKey,
+		})
+	}
+	input, err := json.Marshal(&next)
+	if err != nil {
+		return err
+	}
+	data, err := rlp.EncodeToBytes([][]byte{common.Int64ToBytes(2000), []byte("UpdateValidators"), input})
+	if err != nil {
+		return err
+	}
+	tx := types.NewTransaction(s.electNonce, cvm.ValidatorInnerContractAddr, big.NewInt(0), 1000000, big.NewInt(params.GVon), data)
+
+	added := 0
+	for _, n := range s.nodes {
+		if !n.running {
+			continue
+		}
+		state, err := n.chain.State()
+		if err != nil {
+			return err
+		}
+		signer := types.MakeSigner(s.chainConfig, gov.Gte120VersionState(state), gov.Gte140VersionState(state))
+		signed, err := types.SignTx(tx, signer, s.electKey)
+		if err != nil {
+			return err
+		}
+		if err := n.txPool.AddLocal(signed); err != nil {
+			return fmt.Errorf("node %d rejected the election: %v", n.index, err)
+		}
+		added++
+	}
+	if added == 0 {
+		return errors.New("no running node")
+	}
+	s.electNonce++
+	return nil
+}
+
+func (s *Simulator) crash(n *simNode) {
+	if !n.running {
+		return
+	}
+	s.disconnectNode(n.index)
+	n.stop()
+}
+
+func (s *Simulator) restart(n *simNode) error {
+	if n.running {
+		return nil
+	}
+	n.restarts++
+	return n.start(s.config, s.chainConfig)
+}
+
+func (s *Simulator) stopAll() {
+	for _, n := range s.nodes {
+		s.crash(n)
+	}
+}
+
+func (s *Simulator) cleanup() {
+	if s.tempDir != "" {
+		os.RemoveAll(s.tempDir)
+	}
+}
+
+// connectAll connects the running nodes which are not connected, a link is
+// dropped when the handler of either side returns.
+func (s *Simulator) connectAll() {
+	for i, a := range s.nodes {
+		for j := i + 1; j < len(s.nodes); j++ {
+			b := s.nodes[j]
+			if !a.running || !b.running {
+				continue
+			}
+			key := [2]int{i, j}
+			s.lock.Lock()
+			_, ok := s.links[key]
+			if !ok {
+				pa, pb := p2p.MsgPipe()
+				l := &link{a: newFaultRW(s.net, i, j, pa), b: newFaultRW(s.net, j, i, pb)}
+				s.links[key] = l
+				go s.run(key, l, a, b, l.a)
+				go s.run(key, l, b, a, l.b)
+			}
+			s.lock.Unlock()
+		}
+	}
+}
+
+func (s *Simulator) run(key [2]int, l *link, local, remote *simNode, rw *faultRW) {
+	protocol := local.engine.Protocols()[0]
+	err := protocol.Run(p2p.NewPeer(remote.id, remote.name(), nil), rw)
+	log.Debug("Simulated link closed", "local", local.index, "remote", remote.index, "err", err)
+
+	s.lock.Lock()
+	if s.links[key] == l {
+		delete(s.links, key)
+	}
+	s.lock.Unlock()
+	l.close()
+}
+
+func (s *Simulator) disconnectNode(i int) {
+	s.lock.Lock()
+	var links []*link
+	for key, l := range s.links {
+		if key[0] == i || key[1] == i {
+			links = append(links, l)
+			delete(s.links, key)
+		}
+	}
+	s.lock.Unlock()
+	for _, l := range links {
+		l.close()
+	}
+}
+
+// syncAll makes each running node import the committed blocks of the most
+// advanced node it can reach, if it lags too far behind.
+func (s *Simulator) syncAll() {
+	for _, n := range s.nodes {
+		if !n.running {
+			continue
+		}
+		var (
+			head = n.chain.CurrentBlock().NumberU64()
+			peer *simNode
+			best = head + syncDistance
+		)
+		for _, other := range s.nodes {
+			if other == n || !other.running || !s.net.connected(n.index, other.index) {
+				continue
+			}
+			if number := other.chain.CurrentBlock().NumberU64(); number > best {
+				peer, best = other, number
+			}
+		}
+		if peer == nil {
+			continue
+		}
+		blocks := make(types.Blocks, 0, syncBatch)
+		for number := head + 1; number <= best && len(blocks) < syncBatch; number++ {
+			block := rawdb.ReadBlock(peer.db, rawdb.ReadCanonicalHash(peer.db, number), number)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		if _, err := n.chain.InsertChain(blocks); err != nil {
+			log.Debug("Failed to sync simulated node", "node", n.index, "peer", peer.index, "err", err)
+		}
+	}
+}
+
+// check checks the invariants against the current state of the nodes.
+func (s *Simulator) check() {
+	now := time.Duration(s.elapsed())
+	var best uint64
+	for _, n := range s.nodes {
+		head := s.checkCommitted(n)
+		if head > best {
+			best = head
+		}
+		if n.running {
+			for _, index := range n.duplicateVotes() {
+				s.evidence[n.index][index] = true
+			}
+		}
+	}
+
+	if best > s.best {
+		s.best, s.progress, s.stalled = best, now, false
+	}
+	if !s.healthy() {
+		s.progress = now
+		return
+	}
+	if stall := now - s.progress; !s.stalled && stall > s.config.stallTimeout() {
+		s.violate(Liveness, "no block committed for %v after block %d with a quorum connected", stall.Round(time.Millisecond), s.best)
+		s.stalled = true
+	}
+}
+
+// checkCommitted compares the committed blocks of the node with its earlier
+// ones and with the other nodes, it returns the head of the node.
+func (s *Simulator) checkCommitted(n *simNode) uint64 {
+	number := rawdb.ReadHeaderNumber(n.db, rawdb.ReadHeadBlockHash(n.db))
+	if number == nil {
+		return 0
+	}
+	head := *number
+	hashes := s.hashes[n.index]
+	from := uint64(len(hashes))
+	if from > finalityDepth {
+		from -= finalityDepth
+	} else {
+		from = 1
+	}
+	for h := from; h <= head; h++ {
+		hash := rawdb.ReadCanonicalHash(n.db, h)
+		if h < uint64(len(hashes)) {
+			if hashes[h] != hash {
+				s.violate(Finality, "node %d replaced committed block %d %s with %s", n.index, h, hashes[h].TerminalString(), hash.TerminalString())
+				hashes[h] = hash
+			}
+		} else {
+			for uint64(len(hashes)) < h {
+				hashes = append(hashes, common.Hash{})
+			}
+			hashes = append(hashes, hash)
+		}
+		if s.forked[h] {
+			continue
+		}
+		for _, other := range s.nodes {
+			if other == n || uint64(len(s.hashes[other.index])) <= h {
+				continue
+			}
+			if theirs := s.hashes[other.index][h]; theirs != hash {
+				s.violate(Safety, "nodes %d and %d committed different blocks at %d: %s and %s", n.index, other.index, h, hash.TerminalString(), theirs.TerminalString())
+				s.forked[h] = true
+				break
+			}
+		}
+	}
+	s.hashes[n.index] = hashes
+	return head
+}
+
+// healthy returns whether a quorum of the running nodes can reach each other.
+func (s *Simulator) healthy() bool {
+	threshold := len(s.nodes) - (len(s.nodes)-1)/3
+	for _, a := range s.nodes {
+		if !a.running {
+			continue
+		}
+		reachable := 0
+		for _, b := range s.nodes {
+			if b.running && s.net.connected(a.index, b.index) {
+				reachable++
+			}
+		}
+		if reachable >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Simulator) report() *Report {
+	for i := range s.equivocators {
+		detected := false
+		for _, caught := range s.evidence {
+			detected = detected || caught[uint32(i)]
+		}
+		if !detected {
+			s.violate(Accountability, "no node reported the conflicting votes of node %d", i)
+		}
+	}
+
+	report := &Report{
+		Nodes:      s.config.Nodes,
+		Period:     s.config.Period,
+		Amount:     s.config.Amount,
+		Duration:   s.config.Duration,
+		Seed:       s.config.Seed,
+		Mode:       common.INNER_VALIDATOR_MODE,
+		Committed:  s.best,
+		Timeline:   s.timeline,
+		Violations: s.violations,
+		Passed:     len(s.violations) == 0,
+	}
+	stats := s.net.msgStats()
+	for _, n := range s.nodes {
+		state := &NodeReport{
+			Index:     n.index,
+			ID:        n.id.TerminalString(),
+			Running:   n.running,
+			Restarts:  n.restarts,
+			Validator: n.running && n.engine.IsConsensusNode(),
+			HeadHash:  rawdb.ReadHeadBlockHash(n.db),
+			Messages:  stats[n.index],
+		}
+		if number := rawdb.ReadHeaderNumber(n.db, state.HeadHash); number != nil {
+			state.Head = *number
+		}
+		for index := range s.evidence[n.index] {
+			state.DuplicateVotes = append(state.DuplicateVotes, index)
+		}
+		sort.Slice(state.DuplicateVotes, func(i, j int) bool { return state.DuplicateVotes[i] < state.DuplicateVotes[j] })
+		report.NodeStates = append(report.NodeStates, state)
+	}
+	return report
+}
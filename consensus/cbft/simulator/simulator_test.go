@@ -0,0 +1,135 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cbftsim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "scenario.json")
+	scenario := `{"nodes": 7, "duration": "2m", "steps": [
+		{"at": "10s", "action": "partition", "groups": [[0, 1, 2, 3, 4], [5, 6]]},
+		{"at": "20s", "action": "heal"},
+		{"at": "30s", "action": "latency", "latency": "100ms", "jitter": "50ms"}
+	]}`
+	require.NoError(t, ioutil.WriteFile(file, []byte(scenario), 0600))
+	config, err := LoadConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, 7, config.Nodes)
+	assert.Equal(t, DefaultConfig.Period, config.Period)
+	assert.Equal(t, Duration(2*time.Minute), config.Duration)
+	require.Len(t, config.Steps, 3)
+	assert.Equal(t, Duration(100*time.Millisecond), config.Steps[2].Latency)
+	assert.NoError(t, config.validate())
+
+	config.Steps = append(config.Steps, &Step{At: Duration(time.Second), Action: ActionCrash, Nodes: []int{7}})
+	assert.Error(t, config.validate())
+	config.Steps[3] = &Step{At: Duration(time.Second), Action: "flood"}
+	assert.Error(t, config.validate())
+	config.Steps[3] = &Step{At: Duration(3 * time.Minute), Action: ActionHeal}
+	assert.Error(t, config.validate())
+	// Blocks of 200ms are left empty
+	config.Steps[3] = &Step{At: Duration(time.Second), Action: ActionElect, Nodes: []int{0, 1, 2}}
+	assert.Error(t, config.validate())
+	config.Amount = 5
+	assert.NoError(t, config.validate())
+}
+
+func TestSimulator(t *testing.T) {
+	config := &Config{
+		Nodes:    4,
+		Period:   1000,
+		Amount:   5,
+		Duration: Duration(25 * time.Second),
+		Seed:     1,
+		Steps: []*Step{
+			{At: Duration(1 * time.Second), Action: ActionEquivocate, Nodes: []int{1}},
+			{At: Duration(2 * time.Second), Action: ActionLatency, Latency: Duration(20 * time.Millisecond), Jitter: Duration(20 * time.Millisecond)},
+			{At: Duration(4 * time.Second), Action: ActionCrash, Nodes: []int{3}},
+			{At: Duration(9 * time.Second), Action: ActionRestart, Nodes: []int{3}},
+			{At: Duration(14 * time.Second), Action: ActionPartition, Groups: [][]int{{0, 1}, {2, 3}}},
+			{At: Duration(17 * time.Second), Action: ActionHeal},
+		},
+	}
+	sim, err := New(config)
+	require.NoError(t, err)
+	report, err := sim.Run()
+	require.NoError(t, err)
+	t.Log(report)
+
+	assert.True(t, report.Passed, "violations: %v", report.Violations)
+	assert.True(t, report.Committed > 10)
+	require.Len(t, report.Timeline, 6)
+	require.Len(t, report.NodeStates, 4)
+	assert.Equal(t, 1, report.NodeStates[3].Restarts)
+	for _, n := range report.NodeStates {
+		assert.True(t, n.Running)
+		assert.True(t, n.Messages.Delayed > 0)
+		if n.Index != 1 {
+			assert.Contains(t, n.DuplicateVotes, uint32(1))
+		}
+	}
+	// The restarted node caught up with the others
+	assert.True(t, report.NodeStates[3].Head+uint64(config.Amount)*2 >= report.Committed)
+	assert.True(t, report.NodeStates[1].Messages.Equivocations > 0)
+	assert.True(t, report.NodeStates[0].Messages.Dropped > 0)
+
+	_, err = json.Marshal(report)
+	assert.NoError(t, err)
+}
+
+func TestSimulatorElection(t *testing.T) {
+	config := &Config{
+		Nodes:    4,
+		Period:   2000,
+		Amount:   5,
+		Duration: Duration(14 * time.Second),
+		Seed:     1,
+		Steps: []*Step{
+			{At: Duration(time.Second), Action: ActionElect, Nodes: []int{0, 1, 2}},
+		},
+	}
+	sim, err := New(config)
+	require.NoError(t, err)
+	report, err := sim.Run()
+	require.NoError(t, err)
+	t.Log(report)
+
+	assert.True(t, report.Passed, "violations: %v", report.Violations)
+	require.Len(t, report.Timeline, 1)
+	assert.Empty(t, report.Timeline[0].Error)
+	// The switch packed at block 10 lets the elected set take over at block 21
+	assert.True(t, report.Committed > 30)
+	for _, n := range report.NodeStates {
+		assert.Equal(t, n.Index != 3, n.Validator, "node %d", n.Index)
+	}
+	// The removed node keeps following the chain
+	assert.True(t, report.NodeStates[3].Head+uint64(config.Amount)*2 >= report.Committed)
+}
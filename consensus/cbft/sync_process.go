@@ -290,6 +290,13 @@ func (cbft *Cbft) OnBlockQuorumCert(id string, msg *protocols.BlockQuorumCert) e
 		cbft.log.Debug("Block not exist", "msg", msg.String())
 		return fmt.Errorf("block not exist")
 	}
+	// The aggregators exchange the votes they collected as a partial qc.
+	if cbft.voteAggregationActive() && msg.BlockQC.Len() < cbft.threshold(cbft.currentValidatorLen()) {
+		if err := cbft.onPartialQC(id, block, msg.BlockQC); err != nil {
+			return &authFailedError{err}
+		}
+		return nil
+	}
 	if err := cbft.verifyPrepareQC(block.NumberU64(), block.Hash(), msg.BlockQC); err != nil {
 		cbft.log.Error("Failed to verify prepareQC", "err", err.Error())
 		return &authFailedError{err}
@@ -719,6 +726,7 @@ func (cbft *Cbft) MissingPrepareVote() (v *protocols.GetPrepareVote, err error)
 
 			// We need sync prepare votes when a long time not arrived QC.
 			if size < cbft.threshold(len) && time.Since(blockTime) >= syncPrepareVotesInterval { // need sync prepare votes
+				cbft.sendPartialQC(index)
 				knownVotes := cbft.state.AllPrepareVoteByIndex(index)
 				unKnownSet := utils.NewBitArray(uint32(len))
 				for i := uint32(0); i < unKnownSet.Size(); i++ {
@@ -0,0 +1,332 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package trace records the timeline of the consensus messages of each view.
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+)
+
+const (
+	// DefaultViews is the number of views kept by default.
+	DefaultViews = 256
+	// DefaultEventsPerView is the number of events kept per view by default,
+	// later events of the view are counted as dropped.
+	DefaultEventsPerView = 4096
+	// DefaultRejected is the number of rejected messages kept, the oldest
+	// ones are overwritten.
+	DefaultRejected = 1024
+)
+
+// The direction of an event.
+const (
+	Recv  = "recv"
+	Send  = "send"
+	Local = "local"
+)
+
+// The types of the traced events.
+const (
+	PrepareBlock = "PrepareBlock"
+	PrepareVote  = "PrepareVote"
+	ViewChange   = "ViewChange"
+	PrepareQC    = "PrepareQC"
+	ViewChangeQC = "ViewChangeQC"
+	Timeout      = "Timeout"
+)
+
+// Event is a consensus message received or sent, or something that
+// happened locally in the view.
+type Event struct {
+	Time           time.Time   `json:"time"`
+	Offset         int64       `json:"offset"` // milliseconds since the start of the view
+	Direction      string      `json:"direction"`
+	Type           string      `json:"type"`
+	Peer           string      `json:"peer,omitempty"`
+	ValidatorIndex *uint32     `json:"validatorIndex,omitempty"`
+	BlockIndex     uint32      `json:"blockIndex"`
+	BlockNumber    uint64      `json:"blockNumber"`
+	BlockHash      common.Hash `json:"blockHash"`
+	Error          string      `json:"error,omitempty"`
+}
+
+// ViewTrace is the timeline of a view.
+type ViewTrace struct {
+	Epoch      uint64    `json:"epoch"`
+	ViewNumber uint64    `json:"viewNumber"`
+	Validators int       `json:"validators"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end,omitempty"`
+	Timeout    bool      `json:"timeout"`
+	Events     []*Event  `json:"events"`
+	Dropped    int       `json:"dropped"`
+}
+
+// Votes returns the validators whose votes were seen for each block index,
+// the first vote of a validator counts.
+func (v *ViewTrace) Votes() map[uint32][]uint32 {
+	seen := make(map[uint32]map[uint32]bool)
+	votes := make(map[uint32][]uint32)
+	for _, e := range v.Events {
+		if e.Type != PrepareVote || e.ValidatorIndex == nil || e.Error != "" {
+			continue
+		}
+		if seen[e.BlockIndex] == nil {
+			seen[e.BlockIndex] = make(map[uint32]bool)
+		}
+		if !seen[e.BlockIndex][*e.ValidatorIndex] {
+			seen[e.BlockIndex][*e.ValidatorIndex] = true
+			votes[e.BlockIndex] = append(votes[e.BlockIndex], *e.ValidatorIndex)
+		}
+	}
+	return votes
+}
+
+// MissingVotes returns the validators whose votes were not seen for each
+// block index a vote was seen for.
+func (v *ViewTrace) MissingVotes() map[uint32][]uint32 {
+	missing := make(map[uint32][]uint32)
+	for index, voters := range v.Votes() {
+		voted := make(map[uint32]bool, len(voters))
+		for _, i := range voters {
+			voted[i] = true
+		}
+		for i := 0; i < v.Validators; i++ {
+			if !voted[uint32(i)] {
+				missing[index] = append(missing[index], uint32(i))
+			}
+		}
+	}
+	return missing
+}
+
+func (v *ViewTrace) copy() *ViewTrace {
+	cpy := *v
+	cpy.Events = make([]*Event, len(v.Events))
+	for i, e := range v.Events {
+		event := *e
+		cpy.Events[i] = &event
+	}
+	return &cpy
+}
+
+// RejectedEvent is a message which failed verification, along with the view
+// claimed by its sender.
+type RejectedEvent struct {
+	Epoch      uint64 `json:"epoch"`
+	ViewNumber uint64 `json:"viewNumber"`
+	*Event
+}
+
+type viewKey struct {
+	epoch, viewNumber uint64
+}
+
+// Tracer keeps the timelines of the last views in a ring buffer. Rejected
+// messages are kept apart in a bounded ring of their own, so that messages
+// claiming made-up views can't push the timelines of the real views out.
+type Tracer struct {
+	enabled   int32
+	maxEvents int
+
+	lock    sync.RWMutex
+	ring    []*ViewTrace
+	next    int
+	views   map[viewKey]*ViewTrace
+	current *ViewTrace
+
+	rejected     []*RejectedEvent
+	rejectedNext int
+}
+
+// NewTracer creates a tracer keeping the timelines of up to views views,
+// with up to events events each. The tracer starts enabled.
+func NewTracer(views, events int) *Tracer {
+	if views <= 0 {
+		views = DefaultViews
+	}
+	if events <= 0 {
+		events = DefaultEventsPerView
+	}
+	return &Tracer{
+		enabled:   1,
+		maxEvents: events,
+		ring:      make([]*ViewTrace, views),
+		views:     make(map[viewKey]*ViewTrace),
+		rejected:  make([]*RejectedEvent, DefaultRejected),
+	}
+}
+
+// Enable switches the recording on or off, the recorded views are kept.
+func (t *Tracer) Enable(enable bool) {
+	if enable {
+		atomic.StoreInt32(&t.enabled, 1)
+	} else {
+		atomic.StoreInt32(&t.enabled, 0)
+	}
+}
+
+// Enabled returns whether events are recorded, nothing is recorded by a
+// nil tracer.
+func (t *Tracer) Enabled() bool {
+	return t != nil && atomic.LoadInt32(&t.enabled) == 1
+}
+
+// NewView marks the start of the view, the view before it ends.
+func (t *Tracer) NewView(epoch, viewNumber uint64, validators int) {
+	if !t.Enabled() {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	if t.current != nil {
+		t.current.End = now
+	}
+	view := t.view(epoch, viewNumber, now)
+	view.Start, view.End, view.Validators = now, time.Time{}, validators
+	t.current = view
+	// Messages of the view may have been recorded before it started
+	for _, e := range view.Events {
+		e.Offset = e.Time.Sub(now).Milliseconds()
+	}
+}
+
+// Record adds the event to the timeline of the view.
+func (t *Tracer) Record(epoch, viewNumber uint64, e *Event) {
+	if !t.Enabled() {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	view := t.view(epoch, viewNumber, e.Time)
+	if e.Type == Timeout {
+		view.Timeout = true
+	}
+	if len(view.Events) >= t.maxEvents {
+		view.Dropped++
+		return
+	}
+	e.Offset = e.Time.Sub(view.Start).Milliseconds()
+	view.Events = append(view.Events, e)
+}
+
+// RecordRejected adds the event of a message which failed verification to
+// the rejected messages, overwriting the oldest one if full. The view is the
+// one claimed by the sender, no timeline is created for it.
+func (t *Tracer) RecordRejected(epoch, viewNumber uint64, e *Event) {
+	if !t.Enabled() {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	t.rejected[t.rejectedNext] = &RejectedEvent{Epoch: epoch, ViewNumber: viewNumber, Event: e}
+	t.rejectedNext = (t.rejectedNext + 1) % len(t.rejected)
+}
+
+// Rejected returns copies of the kept rejected messages, oldest first.
+func (t *Tracer) Rejected() []*RejectedEvent {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	rejected := make([]*RejectedEvent, 0, len(t.rejected))
+	for i := range t.rejected {
+		r := t.rejected[(t.rejectedNext+i)%len(t.rejected)]
+		if r == nil {
+			continue
+		}
+		event := *r.Event
+		rejected = append(rejected, &RejectedEvent{Epoch: r.Epoch, ViewNumber: r.ViewNumber, Event: &event})
+	}
+	return rejected
+}
+
+// view returns the timeline of the view, a new one replaces the oldest
+// timeline in the ring.
+func (t *Tracer) view(epoch, viewNumber uint64, start time.Time) *ViewTrace {
+	key := viewKey{epoch, viewNumber}
+	if view, ok := t.views[key]; ok {
+		return view
+	}
+	if old := t.ring[t.next]; old != nil {
+		delete(t.views, viewKey{old.Epoch, old.ViewNumber})
+	}
+	view := &ViewTrace{Epoch: epoch, ViewNumber: viewNumber, Start: start}
+	t.ring[t.next] = view
+	t.next = (t.next + 1) % len(t.ring)
+	t.views[key] = view
+	return view
+}
+
+// Trace returns a copy of the timeline of the view, or nil if the view is
+// not recorded.
+func (t *Tracer) Trace(epoch, viewNumber uint64) *ViewTrace {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if view, ok := t.views[viewKey{epoch, viewNumber}]; ok {
+		return view.copy()
+	}
+	return nil
+}
+
+// Traces returns copies of all recorded timelines, ordered by view.
+func (t *Tracer) Traces() []*ViewTrace {
+	t.lock.RLock()
+	traces := make([]*ViewTrace, 0, len(t.views))
+	for _, view := range t.views {
+		traces = append(traces, view.copy())
+	}
+	t.lock.RUnlock()
+
+	sort.Slice(traces, func(i, j int) bool {
+		if traces[i].Epoch != traces[j].Epoch {
+			return traces[i].Epoch < traces[j].Epoch
+		}
+		return traces[i].ViewNumber < traces[j].ViewNumber
+	})
+	return traces
+}
+
+// Export writes all recorded timelines as JSON, one view per line, and
+// returns the number of views written.
+func (t *Tracer) Export(w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	traces := t.Traces()
+	for i, view := range traces {
+		if err := enc.Encode(view); err != nil {
+			return i, err
+		}
+	}
+	return len(traces), nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func vote(validator, blockIndex uint32) *Event {
+	return &Event{Direction: Recv, Type: PrepareVote, Peer: "peer", ValidatorIndex: &validator, BlockIndex: blockIndex}
+}
+
+func TestTracerRing(t *testing.T) {
+	tracer := NewTracer(3, 0)
+	for view := uint64(0); view < 5; view++ {
+		tracer.NewView(1, view, 4)
+		tracer.Record(1, view, vote(0, 0))
+	}
+	// Only the last three views are kept
+	assert.Nil(t, tracer.Trace(1, 0))
+	assert.Nil(t, tracer.Trace(1, 1))
+	traces := tracer.Traces()
+	require.Len(t, traces, 3)
+	for i, view := range traces {
+		assert.Equal(t, uint64(i+2), view.ViewNumber)
+		assert.Len(t, view.Events, 1)
+	}
+	// The views before the current one have ended
+	assert.False(t, traces[1].End.IsZero())
+	assert.True(t, traces[2].End.IsZero())
+}
+
+func TestTracerRecord(t *testing.T) {
+	tracer := NewTracer(0, 3)
+
+	// Messages of a future view are kept until the view starts
+	early := vote(1, 0)
+	tracer.Record(1, 2, early)
+	time.Sleep(10 * time.Millisecond)
+	tracer.NewView(1, 2, 4)
+	view := tracer.Trace(1, 2)
+	require.NotNil(t, view)
+	require.Len(t, view.Events, 1)
+	assert.True(t, view.Events[0].Offset < 0)
+
+	tracer.Record(1, 2, vote(2, 0))
+	tracer.Record(1, 2, &Event{Direction: Local, Type: Timeout})
+	tracer.Record(1, 2, vote(3, 0))
+	view = tracer.Trace(1, 2)
+	assert.True(t, view.Timeout)
+	assert.Len(t, view.Events, 3)
+	assert.Equal(t, 1, view.Dropped)
+
+	// Nothing is recorded while disabled
+	tracer.Enable(false)
+	tracer.NewView(1, 3, 4)
+	tracer.Record(1, 3, vote(0, 0))
+	assert.Nil(t, tracer.Trace(1, 3))
+	tracer.Enable(true)
+
+	var nilTracer *Tracer
+	assert.False(t, nilTracer.Enabled())
+}
+
+func TestViewTraceVotes(t *testing.T) {
+	tracer := NewTracer(0, 0)
+	tracer.NewView(1, 1, 4)
+	tracer.Record(1, 1, vote(0, 0))
+	tracer.Record(1, 1, vote(2, 0))
+	tracer.Record(1, 1, vote(2, 0))
+	tracer.Record(1, 1, vote(1, 1))
+	failed := vote(3, 1)
+	failed.Error = "invalid signature"
+	tracer.Record(1, 1, failed)
+
+	view := tracer.Trace(1, 1)
+	assert.Equal(t, map[uint32][]uint32{0: {0, 2}, 1: {1}}, view.Votes())
+	assert.Equal(t, map[uint32][]uint32{0: {1, 3}, 1: {0, 2, 3}}, view.MissingVotes())
+}
+
+func TestTracerExport(t *testing.T) {
+	tracer := NewTracer(0, 0)
+	for view := uint64(0); view < 3; view++ {
+		tracer.NewView(2, view, 4)
+		tracer.Record(2, view, vote(uint32(view), 0))
+	}
+	var buf bytes.Buffer
+	n, err := tracer.Export(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	scanner := bufio.NewScanner(&buf)
+	var views []*ViewTrace
+	for scanner.Scan() {
+		var view ViewTrace
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &view))
+		views = append(views, &view)
+	}
+	require.Len(t, views, 3)
+	for i, view := range views {
+		assert.Equal(t, uint64(2), view.Epoch)
+		assert.Equal(t, uint64(i), view.ViewNumber)
+		require.Len(t, view.Events, 1)
+		assert.Equal(t, uint32(i), *view.Events[0].ValidatorIndex)
+	}
+}
+
+func TestTracerRejected(t *testing.T) {
+	tracer := NewTracer(2, 0)
+	tracer.NewView(1, 0, 4)
+	tracer.Record(1, 0, vote(0, 0))
+
+	// Rejected messages claiming other views don't evict the timelines
+	for view := uint64(1); view <= DefaultRejected+10; view++ {
+		tracer.RecordRejected(1, view, vote(1, 0))
+	}
+	require.NotNil(t, tracer.Trace(1, 0))
+	assert.Nil(t, tracer.Trace(1, 1))
+	assert.Len(t, tracer.Traces(), 1)
+
+	// Only the last rejected messages are kept, oldest first
+	rejected := tracer.Rejected()
+	require.Len(t, rejected, DefaultRejected)
+	assert.Equal(t, uint64(11), rejected[0].ViewNumber)
+	assert.Equal(t, uint64(DefaultRejected+10), rejected[len(rejected)-1].ViewNumber)
+}
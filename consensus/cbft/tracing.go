@@ -0,0 +1,109 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package cbft
+
+import (
+	"io"
+
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/trace"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+)
+
+// traceEvent records the event in the timeline of the view, or among the
+// rejected messages if its verification failed: the view of a rejected
+// message is whatever the peer claimed.
+func (cbft *Cbft) traceEvent(epoch, viewNumber uint64, e *trace.Event, err error) {
+	if err != nil {
+		e.Error = err.Error()
+		cbft.tracer.RecordRejected(epoch, viewNumber, e)
+		return
+	}
+	cbft.tracer.Record(epoch, viewNumber, e)
+}
+
+// traceMsg records a prepareBlock, prepareVote or viewChange in the
+// timeline of its view.
+func (cbft *Cbft) traceMsg(direction, peer string, msg ctypes.ConsensusMsg, err error) {
+	if !cbft.tracer.Enabled() {
+		return
+	}
+	index := msg.NodeIndex()
+	e := &trace.Event{Direction: direction, Peer: peer, ValidatorIndex: &index, BlockNumber: msg.BlockNum()}
+	switch msg := msg.(type) {
+	case *protocols.PrepareBlock:
+		e.Type, e.BlockIndex, e.BlockHash = trace.PrepareBlock, msg.BlockIndex, msg.Block.Hash()
+	case *protocols.PrepareVote:
+		e.Type, e.BlockIndex, e.BlockHash = trace.PrepareVote, msg.BlockIndex, msg.BlockHash
+	case *protocols.ViewChange:
+		e.Type, e.BlockHash = trace.ViewChange, msg.BlockHash
+	default:
+		return
+	}
+	cbft.traceEvent(msg.EpochNum(), msg.ViewNum(), e, err)
+}
+
+// traceQC records a prepareQC in the timeline of its view.
+func (cbft *Cbft) traceQC(direction, peer string, qc *ctypes.QuorumCert, err error) {
+	if !cbft.tracer.Enabled() || qc == nil {
+		return
+	}
+	e := &trace.Event{
+		Direction:   direction,
+		Type:        trace.PrepareQC,
+		Peer:        peer,
+		BlockIndex:  qc.BlockIndex,
+		BlockNumber: qc.BlockNumber,
+		BlockHash:   qc.BlockHash,
+	}
+	cbft.traceEvent(qc.Epoch, qc.ViewNumber, e, err)
+}
+
+// traceViewChangeQC records a viewChangeQC in the timeline of the view it
+// ends, the block is the highest block of the qc.
+func (cbft *Cbft) traceViewChangeQC(direction, peer string, qc *ctypes.ViewChangeQC, err error) {
+	if !cbft.tracer.Enabled() || qc == nil {
+		return
+	}
+	epoch, viewNumber, _, _, hash, number := qc.MaxBlock()
+	e := &trace.Event{
+		Direction:   direction,
+		Type:        trace.ViewChangeQC,
+		Peer:        peer,
+		BlockNumber: number,
+		BlockHash:   hash,
+	}
+	cbft.traceEvent(epoch, viewNumber, e, err)
+}
+
+// Trace returns the timeline of the consensus messages of the view, or nil
+// if the view is not recorded.
+func (cbft *Cbft) Trace(epoch, viewNumber uint64) *trace.ViewTrace {
+	return cbft.tracer.Trace(epoch, viewNumber)
+}
+
+// RejectedTrace returns the last consensus messages which failed
+// verification, oldest first.
+func (cbft *Cbft) RejectedTrace() []*trace.RejectedEvent {
+	return cbft.tracer.Rejected()
+}
+
+// ExportTrace writes the timelines of all recorded views, one JSON object
+// per line, and returns the number of views written.
+func (cbft *Cbft) ExportTrace(w io.Writer) (int, error) {
+	return cbft.tracer.Export(w)
+}
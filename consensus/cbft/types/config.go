@@ -29,6 +29,7 @@ type OptionsConfig struct {
 	NodePriKey *ecdsa.PrivateKey `json:"-"`
 	NodeID     discover.NodeID   `json:"nodeID"`
 	BlsPriKey  *bls.SecretKey    `json:"-"`
+	Signer     Signer            `json:"-"` // Signs with NodePriKey and BlsPriKey if not set
 	WalMode    bool              `json:"walMode"`
 
 	PeerMsgQueueSize  uint64 `json:"peerMsgQueueSize"`
@@ -39,6 +39,7 @@ const (
 	ErrCbftProtocolVersionMismatch
 	ErrNoStatusMsg
 	ErrForkedBlock
+	ErrForkIDRejected
 )
 
 type ErrCode int
@@ -55,6 +56,7 @@ var errorToString = map[int]string{
 	ErrCbftProtocolVersionMismatch: "CBFT Protocol version mismatch",
 	ErrNoStatusMsg:                 "No status message",
 	ErrForkedBlock:                 "Forked Block",
+	ErrForkIDRejected:              "Fork ID rejected",
 }
 
 // Build an error object based on the error code.
@@ -0,0 +1,40 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+)
+
+// Signer holds the consensus keys of the local validator and signs
+// the sealed blocks and the consensus messages sent by it.
+type Signer interface {
+	// NodeID returns the node id derived from the ECDSA key.
+	NodeID() discover.NodeID
+
+	// BlsPublicKey returns the public key of the BLS key.
+	BlsPublicKey() *bls.PublicKey
+
+	// SignSeal signs the seal hash of a block proposed in the given view
+	// with the ECDSA key.
+	SignSeal(epoch, viewNumber uint64, header *types.Header) ([]byte, error)
+
+	// SignMsg signs the consensus message with the BLS key.
+	SignMsg(msg ConsensusMsg) ([]byte, error)
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/evidence"
 
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/protocols"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/trace"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/stretchr/testify/suite"
 )
@@ -73,6 +75,40 @@ func (suit *ViewChangeTestSuite) TestViewChangeBuild() {
 	suit.Equal(1, suit.view.secondProposer().state.ViewChangeLen())
 }
 
+// Initiate viewChange
+// Verify the timeout and the sent viewChange are traced
+func (suit *ViewChangeTestSuite) TestViewChangeTrace() {
+	time.Sleep((testPeriod + 200) * time.Millisecond)
+	node := suit.view.secondProposer()
+	view := node.Trace(node.state.Epoch(), node.state.ViewNumber())
+	suit.NotNil(view)
+	suit.True(view.Timeout)
+	suit.Equal(testNodeNumber, view.Validators)
+	var sent bool
+	for _, e := range view.Events {
+		if e.Type == trace.ViewChange && e.Direction == trace.Send {
+			suit.Equal(uint32(suit.view.secondProposerIndex()), *e.ValidatorIndex)
+			sent = true
+		}
+	}
+	suit.True(sent)
+}
+
+// An unsigned viewChange claiming a far future view
+// Verify it's traced as rejected and no timeline is created for the view
+func (suit *ViewChangeTestSuite) TestViewChangeRejectedTrace() {
+	node := suit.view.secondProposer()
+	epoch, viewNumber := node.state.Epoch(), node.state.ViewNumber()+1000
+	viewChange := mockViewChange(nil, epoch, viewNumber, suit.view.genesisBlock.Hash(), 0, suit.view.firstProposerIndex(), nil)
+	suit.NotNil(node.handleConsensusMsg(ctypes.NewMsgInfo(viewChange, "peer")))
+	suit.Nil(node.Trace(epoch, viewNumber))
+	rejected := node.RejectedTrace()
+	suit.Len(rejected, 1)
+	suit.Equal(viewNumber, rejected[0].ViewNumber)
+	suit.Equal(trace.ViewChange, rejected[0].Type)
+	suit.NotEmpty(rejected[0].Error)
+}
+
 // Initiate viewChange
 // Non-consensus nodes do not Initiate viewChange, check local viewChangeLen=0
 func (suit *ViewChangeTestSuite) TestViewChangeBuildWithNotConsensus() {
@@ -33,6 +33,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
 	"github.com/PlatONnetwork/PlatON-Go/common/prque"
 	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
 	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
@@ -735,6 +736,28 @@ func (bc *BlockChain) Genesis() *types.Block {
 	return bc.genesisBlock
 }
 
+// ActiveVersions returns the versions activated by the governance at the head
+// of the chain, oldest first.
+func (bc *BlockChain) ActiveVersions() []forkid.Activation {
+	head := bc.CurrentBlock()
+	statedb, err := bc.StateAt(head.Root())
+	if err != nil {
+		log.Warn("Failed to open the state of the active versions", "number", head.NumberU64(), "hash", head.Hash(), "err", err)
+		return nil
+	}
+	avList, err := statedb.ListActiveVersion()
+	if err != nil {
+		log.Warn("Failed to list the active versions", "number", head.NumberU64(), "hash", head.Hash(), "err", err)
+		return nil
+	}
+	// The governance keeps the latest activation first
+	history := make([]forkid.Activation, len(avList))
+	for i, av := range avList {
+		history[len(avList)-1-i] = forkid.Activation{Version: av.ActiveVersion, Block: av.ActiveBlock}
+	}
+	return history
+}
+
 // GetBody retrieves a block body (transactions) from the database by
 // hash, caching it if found.
 func (bc *BlockChain) GetBody(hash common.Hash) *types.Body {
@@ -1050,6 +1073,16 @@ type numberHash struct {
 	hash   common.Hash
 }
 
+// InsertCheckpoint writes the header of a trusted checkpoint as the canonical
+// header of its number, a fast sync from the checkpoint starts the header chain
+// on top of it.
+func (bc *BlockChain) InsertCheckpoint(header *types.Header) error {
+	batch := bc.db.NewBatch()
+	rawdb.WriteHeader(batch, header)
+	rawdb.WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64())
+	return batch.Write()
+}
+
 // InsertReceiptChain attempts to complete an already existing header chain with
 // transaction and receipt data.
 func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts, ancientLimit uint64) (int, error) {
@@ -1423,6 +1456,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		log.Error("check block is EIP158 error", "hash", block.Hash(), "number", block.NumberU64())
 		return NonStatTy, err
 	}
+	if bcr := GetReactorInstance(); bcr != nil {
+		bcr.WritePPOSHashProof(block.Header(), state)
+	}
 
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.Disabled {
@@ -28,9 +28,11 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
 	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
 	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
 	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 	"github.com/PlatONnetwork/PlatON-Go/x/handler"
 	"github.com/PlatONnetwork/PlatON-Go/x/staking"
@@ -55,6 +57,12 @@ type BlockChainReactor struct {
 	exitCh        chan chan struct{}        // Used to receive an exit signal
 	exitOnce      sync.Once
 	chainID       *big.Int
+
+	// Records the ppos writes of the election blocks for the light clients, nil if not serving them
+	pposWritesDB ethdb.KeyValueWriter
+
+	// Runs the blocks on snapshotdb replay blocks, executing the committed blocks again
+	replay bool
 }
 
 var (
@@ -76,7 +84,7 @@ func NewBlockChainReactor(mux *event.TypeMux, chainId *big.Int) *BlockChainReact
 }
 
 func (bcr *BlockChainReactor) Start(mode string) {
-	bcr.setValidatorMode(mode)
+	bcr.SetValidatorMode(mode)
 	if mode == common.PPOS_VALIDATOR_MODE {
 		// Subscribe events for confirmed blocks
 		bcr.bftResultSub = bcr.eventMux.Subscribe(cbfttypes.CbftResult{})
@@ -170,7 +178,9 @@ func (bcr *BlockChainReactor) SetPluginEventMux() {
 	plugin.StakingInstance().SetEventMux(bcr.eventMux)
 }
 
-func (bcr *BlockChainReactor) setValidatorMode(mode string) {
+// SetValidatorMode switches the mode without starting the loop that commits the
+// confirmed blocks, block producers running in process commit through OnCommit.
+func (bcr *BlockChainReactor) SetValidatorMode(mode string) {
 	bcr.validatorMode = mode
 }
 
@@ -188,6 +198,49 @@ func (bcr *BlockChainReactor) SetPrivateKey(privateKey *ecdsa.PrivateKey) {
 	}
 }
 
+// SetPPOSWritesDB makes the reactor record the ppos writes of the election
+// blocks into db, the light clients and the checkpoint syncs prove the
+// validators of the next round by them.
+func (bcr *BlockChainReactor) SetPPOSWritesDB(db ethdb.KeyValueWriter) {
+	bcr.pposWritesDB = db
+}
+
+// WritePPOSHashProof records the proofs of the ppos hash in the committed state
+// of an election block, the recorded ppos writes of the block are still proven
+// by them once the state is pruned.
+func (bcr *BlockChainReactor) WritePPOSHashProof(header *types.Header, state *state.StateDB) {
+	if bcr.pposWritesDB == nil || bcr.validatorMode != common.PPOS_VALIDATOR_MODE || !xutil.IsElection(header.Number.Uint64()) {
+		return
+	}
+	accountProof, err := state.GetProof(cvm.StakingContractAddr)
+	if err != nil {
+		log.Error("Failed to prove the staking contract", "number", header.Number, "hash", header.Hash(), "err", err)
+		return
+	}
+	storageProof, err := state.GetRawStorageProof(cvm.StakingContractAddr, staking.GetPPOSHASHKey())
+	if err != nil {
+		log.Error("Failed to prove the ppos hash", "number", header.Number, "hash", header.Hash(), "err", err)
+		return
+	}
+	rawdb.WritePPOSHashProof(bcr.pposWritesDB, header.Hash(), accountProof, storageProof)
+}
+
+// SetReplay makes BeginBlocker open a snapshotdb replay block instead of a new
+// one, so that the committed blocks can be executed again on top of the ppos
+// data of their parent. The replay block is dropped by DiscardReplay.
+func (bcr *BlockChainReactor) SetReplay(replay bool) {
+	bcr.replay = replay
+}
+
+// DiscardReplay drops the snapshotdb replay block BeginBlocker opened for the
+// header in replay mode.
+func (bcr *BlockChainReactor) DiscardReplay(header *types.Header) error {
+	if bcr.validatorMode != common.PPOS_VALIDATOR_MODE || !bcr.replay {
+		return nil
+	}
+	return snapshotdb.Instance().DiscardReplayBlock(header.CacheHash())
+}
+
 func (bcr *BlockChainReactor) SetBeginRule(rule []int) {
 	bcr.beginRule = rule
 }
@@ -245,9 +298,8 @@ func (bcr *BlockChainReactor) BeginBlocker(header *types.Header, state xcom.Stat
 		} else {
 			header.Nonce = types.EncodeNonce(value)
 		}
-	} else {
-		blockHash = header.CacheHash()
-		// Verify vrf proof
+	} else if blockHash = header.CacheHash(); !bcr.replay {
+		// Verify vrf proof, the replayed blocks were verified on insertion
 		pk := header.CachePublicKey()
 		if pk == nil {
 			return errors.New("failed to get the public key of the block producer")
@@ -257,15 +309,48 @@ func (bcr *BlockChainReactor) BeginBlocker(header *types.Header, state xcom.Stat
 		}
 	}
 
-	log.Debug("Call snapshotDB newBlock on blockchain_reactor", "blockNumber", header.Number.Uint64(),
-		"hash", blockHash, "parentHash", header.ParentHash)
-	if err := snapshotdb.Instance().NewBlock(header.Number, header.ParentHash, blockHash); nil != err {
-		log.Error("Failed to call snapshotDB newBlock on blockchain_reactor", "blockNumber",
-			header.Number.Uint64(), "hash", hex.EncodeToString(blockHash.Bytes()), "parentHash",
-			hex.EncodeToString(header.ParentHash.Bytes()), "err", err)
+	if bcr.replay {
+		if err := snapshotdb.Instance().NewReplayBlock(header.Number, header.ParentHash, blockHash); nil != err {
+			log.Error("Failed to call snapshotDB newReplayBlock on blockchain_reactor", "blockNumber",
+				header.Number.Uint64(), "hash", hex.EncodeToString(blockHash.Bytes()), "parentHash",
+				hex.EncodeToString(header.ParentHash.Bytes()), "err", err)
+			return err
+		}
+	} else {
+		log.Debug("Call snapshotDB newBlock on blockchain_reactor", "blockNumber", header.Number.Uint64(),
+			"hash", blockHash, "parentHash", header.ParentHash)
+		if err := snapshotdb.Instance().NewBlock(header.Number, header.ParentHash, blockHash); nil != err {
+			log.Error("Failed to call snapshotDB newBlock on blockchain_reactor", "blockNumber",
+				header.Number.Uint64(), "hash", hex.EncodeToString(blockHash.Bytes()), "parentHash",
+				hex.EncodeToString(header.ParentHash.Bytes()), "err", err)
+			return err
+		}
+	}
+
+	if err := bcr.beginBlock(blockHash, header, state); nil != err {
 		return err
 	}
 
+	// This must not be deleted
+	root := state.IntermediateRoot(true)
+	log.Debug("BeginBlock StateDB root, end", "blockHash", header.Hash(), "blockNumber",
+		header.Number.Uint64(), "root", root, "pointer", fmt.Sprintf("%p", state))
+
+	return nil
+}
+
+// ReplayBeginBlocker runs the plugins of BeginBlocker again over a block of the
+// chain, on top of the parent state and of the snapshotdb replay block of the
+// given hash. The vrf proof was verified on insertion and isn't checked again,
+// and the state is left unfinalised for the caller to gather the changes.
+func (bcr *BlockChainReactor) ReplayBeginBlocker(replayHash common.Hash, header *types.Header, state xcom.StateDB) error {
+	if bcr.validatorMode != common.PPOS_VALIDATOR_MODE {
+		return nil
+	}
+	return bcr.beginBlock(replayHash, header, state)
+}
+
+func (bcr *BlockChainReactor) beginBlock(blockHash common.Hash, header *types.Header, state xcom.StateDB) error {
 	for _, pluginRule := range bcr.beginRule {
 		if plugin, ok := bcr.basePluginMap[pluginRule]; ok {
 			if err := plugin.BeginBlock(blockHash, header, state); nil != err {
@@ -273,12 +358,6 @@ func (bcr *BlockChainReactor) BeginBlocker(header *types.Header, state xcom.Stat
 			}
 		}
 	}
-
-	// This must not be deleted
-	root := state.IntermediateRoot(true)
-	log.Debug("BeginBlock StateDB root, end", "blockHash", header.Hash(), "blockNumber",
-		header.Number.Uint64(), "root", root, "pointer", fmt.Sprintf("%p", state))
-
 	return nil
 }
 
@@ -298,17 +377,49 @@ func (bcr *BlockChainReactor) EndBlocker(header *types.Header, state xcom.StateD
 		blockHash = header.CacheHash()
 	}
 
+	pposHash, err := bcr.endBlock(blockHash, header, state)
+	if nil != err {
+		return err
+	}
+	if len(pposHash) != 0 && bcr.pposWritesDB != nil && !bcr.replay && xutil.IsElection(header.Number.Uint64()) {
+		rawdb.WritePPOSWrites(bcr.pposWritesDB, common.BytesToHash(pposHash), snapshotdb.Instance().GetBlockWrites(blockHash))
+	}
+
+	// This must not be deleted
+	root := state.IntermediateRoot(true)
+	log.Debug("EndBlock StateDB root, end", "blockHash", blockHash, "blockNumber",
+		header.Number.Uint64(), "root", root, "pointer", fmt.Sprintf("%p", state))
+
+	return nil
+}
+
+// ReplayEndBlocker runs EndBlocker again over a block of the chain, on the
+// snapshotdb replay block of the given hash. The ppos writes of the election
+// blocks were recorded on insertion and aren't recorded again, and the state is
+// left unfinalised for the caller to gather the changes.
+func (bcr *BlockChainReactor) ReplayEndBlocker(replayHash common.Hash, header *types.Header, state xcom.StateDB) error {
+	if bcr.validatorMode != common.PPOS_VALIDATOR_MODE {
+		return nil
+	}
+	_, err := bcr.endBlock(replayHash, header, state)
+	return err
+}
+
+// endBlock stores the vrf nonce, runs the plugins of EndBlocker and stores the
+// hash of the ppos data of the block, which it returns, nil if the block didn't
+// change the ppos data.
+func (bcr *BlockChainReactor) endBlock(blockHash common.Hash, header *types.Header, state xcom.StateDB) ([]byte, error) {
 	// Store the previous vrf random number
 	if err := bcr.vh.Storage(header.Number, header.ParentHash, blockHash, header.Nonce.Bytes()); nil != err {
 		log.Error("blockchain_reactor Storage proof failed", "blockNumber", header.Number.Uint64(),
 			"blockHash", hex.EncodeToString(blockHash.Bytes()), "err", err)
-		return err
+		return nil, err
 	}
 
 	for _, pluginRule := range bcr.endRule {
 		if plugin, ok := bcr.basePluginMap[pluginRule]; ok {
 			if err := plugin.EndBlock(blockHash, header, state); nil != err {
-				return err
+				return nil, err
 			}
 		}
 	}
@@ -316,19 +427,14 @@ func (bcr *BlockChainReactor) EndBlocker(header *types.Header, state xcom.StateD
 	// storage the ppos k-v Hash
 	pposHash := snapshotdb.Instance().GetLastKVHash(blockHash)
 
-	if len(pposHash) != 0 && !bytes.Equal(pposHash, make([]byte, len(pposHash))) {
-		// store hash about ppos
-		state.SetState(cvm.StakingContractAddr, staking.GetPPOSHASHKey(), pposHash)
-		log.Debug("Store ppos hash", "blockHash", blockHash, "blockNumber", header.Number.Uint64(),
-			"pposHash", hex.EncodeToString(pposHash))
+	if len(pposHash) == 0 || bytes.Equal(pposHash, make([]byte, len(pposHash))) {
+		return nil, nil
 	}
-
-	// This must not be deleted
-	root := state.IntermediateRoot(true)
-	log.Debug("EndBlock StateDB root, end", "blockHash", blockHash, "blockNumber",
-		header.Number.Uint64(), "root", root, "pointer", fmt.Sprintf("%p", state))
-
-	return nil
+	// store hash about ppos
+	state.SetState(cvm.StakingContractAddr, staking.GetPPOSHASHKey(), pposHash)
+	log.Debug("Store ppos hash", "blockHash", blockHash, "blockNumber", header.Number.Uint64(),
+		"pposHash", hex.EncodeToString(pposHash))
+	return pposHash, nil
 }
 
 func (bcr *BlockChainReactor) VerifyTx(tx *types.Transaction, to common.Address) error {
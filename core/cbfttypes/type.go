@@ -34,6 +34,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 )
@@ -254,6 +255,19 @@ func (vs *Validators) Equal(rsh *Validators) bool {
 	return equal
 }
 
+// Digest returns the hash of the node ids and the bls public keys of the
+// validators in the order of their indexes, which identifies the validator set.
+func (vs *Validators) Digest() common.Hash {
+	if len(vs.sortedNodes) == 0 {
+		vs.sort()
+	}
+	data := make([][]byte, 0, 2*len(vs.sortedNodes))
+	for _, node := range vs.sortedNodes {
+		data = append(data, node.NodeID.Bytes(), node.BlsPubKey.Serialize())
+	}
+	return crypto.Keccak256Hash(data...)
+}
+
 func (vs *Validators) sort() {
 	for _, node := range vs.Nodes {
 		vs.sortedNodes = append(vs.sortedNodes, node)
@@ -71,6 +71,17 @@ func (b *BlockGen) SetNonce(nonce types.BlockNonce) {
 	b.header.Nonce = nonce
 }
 
+// SetState sets a storage slot of the account in the state of the generated block.
+func (b *BlockGen) SetState(addr common.Address, key, value []byte) {
+	b.statedb.SetState(addr, key, value)
+}
+
+// AddBalance adds the amount to the balance of the account in the state of the
+// generated block.
+func (b *BlockGen) AddBalance(addr common.Address, amount *big.Int) {
+	b.statedb.AddBalance(addr, amount)
+}
+
 // AddTx adds a transaction to the generated block. If no coinbase has
 // been set, the block's coinbase is set to the zero address.
 //
@@ -95,8 +106,12 @@ func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
 	if b.gasPool == nil {
 		b.SetCoinbase(common.Address{})
 	}
+	vmConfig := vm.Config{}
+	if bc != nil {
+		vmConfig = *bc.GetVMConfig()
+	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	receipt, err := ApplyTransaction(b.config, bc, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
+	receipt, err := ApplyTransaction(b.config, bc, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vmConfig)
 	if err != nil {
 		panic(err)
 	}
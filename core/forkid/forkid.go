@@ -0,0 +1,163 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements the identifier of the versions activated on a chain
+// by the governance, so that the peers unable to follow each other are rejected
+// during the handshake instead of failing on block import.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+)
+
+var (
+	// ErrRemoteStale is returned by the validator if a remote fork checksum is a
+	// subset of our already activated versions, but the remote node is running a
+	// code unable to process the versions activated since.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by the validator if a remote fork
+	// checksum doesn't match any of our activated version histories, either
+	// because the chains diverged or because the remote activated a version our
+	// code is unable to process.
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// Blockchain defines all necessary method to build a forkID.
+type Blockchain interface {
+	// Genesis retrieves the chain's genesis block.
+	Genesis() *types.Block
+
+	// ActiveVersions returns the versions activated at the head of the chain,
+	// oldest first.
+	ActiveVersions() []Activation
+}
+
+// Activation is a version activated on the chain by a version proposal.
+type Activation struct {
+	Version uint32 // Activated version
+	Block   uint64 // Number of the block the version is active from
+}
+
+// ID is a fork identifier as defined by the governance of the chain.
+type ID struct {
+	Hash    [4]byte // CRC32 checksum of the genesis block and the activated versions
+	Version uint32  // Version active at the head of the chain
+	Code    uint32  // Highest version the code of the node is able to process
+}
+
+// String implements fmt.Stringer.
+func (id ID) String() string {
+	return fmt.Sprintf("%x/%s/%s", id.Hash, params.FormatVersion(id.Version), params.FormatVersion(id.Code))
+}
+
+// Filter is a fork id filter to validate a remotely advertised ID.
+type Filter func(id ID) error
+
+// NewID calculates the fork ID from the genesis hash and the versions activated
+// on the chain, oldest first.
+func NewID(genesis common.Hash, history []Activation) ID {
+	sums := checksums(genesis, history)
+	return ID{Hash: sums[len(sums)-1], Version: activeVersion(history), Code: params.CodeVersion()}
+}
+
+// NewIDFromChain calculates the fork ID of the head of the chain.
+func NewIDFromChain(chain Blockchain) ID {
+	return NewID(chain.Genesis().Hash(), chain.ActiveVersions())
+}
+
+// NewFilter creates a filter validating the remote fork IDs against the head of
+// the chain at the time of the validation.
+func NewFilter(chain Blockchain) Filter {
+	return newFilter(chain.Genesis().Hash(), chain.ActiveVersions, params.CodeVersion())
+}
+
+// newFilter is the internal version of NewFilter, taking closures as its input
+// instead of a chain to allow testing it.
+func newFilter(genesis common.Hash, headfn func() []Activation, code uint32) Filter {
+	return func(id ID) error {
+		// Calculate the checksums of every prefix of the local history. The last
+		// one is the checksum of the head, the others are the ones of the nodes
+		// not synced up to the latest activations yet.
+		var (
+			history = headfn()
+			sums    = checksums(genesis, history)
+			active  = activeVersion(history)
+		)
+		for i, sum := range sums {
+			if sum != id.Hash {
+				continue
+			}
+			// The remote history is a subset of the local one, make sure its
+			// code is able to process the versions activated since.
+			if i < len(sums)-1 && id.Code < active {
+				return ErrRemoteStale
+			}
+			return nil
+		}
+		// The remote history is unknown. It's fine as long as the remote is
+		// ahead of us with a version our code is able to process, the histories
+		// diverged otherwise.
+		if id.Version > active && id.Version <= code {
+			return nil
+		}
+		return ErrLocalIncompatibleOrStale
+	}
+}
+
+// checksums returns the checksum of the genesis followed by the checksums after
+// each activation.
+func checksums(genesis common.Hash, history []Activation) [][4]byte {
+	sums := make([][4]byte, 0, len(history)+1)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums = append(sums, checksumToBytes(hash))
+	for _, av := range history {
+		hash = checksumUpdate(hash, av)
+		sums = append(sums, checksumToBytes(hash))
+	}
+	return sums
+}
+
+// activeVersion returns the latest activated version, zero if none.
+func activeVersion(history []Activation) uint32 {
+	if len(history) == 0 {
+		return 0
+	}
+	return history[len(history)-1].Version
+}
+
+// checksumUpdate calculates the next IEEE CRC32 checksum based on the previous
+// one and an activation.
+func checksumUpdate(hash uint32, av Activation) uint32 {
+	var blob [12]byte
+	binary.BigEndian.PutUint32(blob[:4], av.Version)
+	binary.BigEndian.PutUint64(blob[4:], av.Block)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
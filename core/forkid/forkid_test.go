@@ -0,0 +1,115 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+var (
+	testGenesis = common.HexToHash("0x1234")
+	testHistory = []Activation{
+		{Version: params.FORKVERSION_1_3_0, Block: 0},
+		{Version: params.FORKVERSION_1_4_0, Block: 1000},
+		{Version: params.FORKVERSION_1_5_0, Block: 2000},
+	}
+)
+
+// Tests that the fork IDs change with the genesis and with every activation.
+func TestCreation(t *testing.T) {
+	seen := make(map[[4]byte]bool)
+	for i := 0; i <= len(testHistory); i++ {
+		id := NewID(testGenesis, testHistory[:i])
+		if seen[id.Hash] {
+			t.Errorf("activation %d: duplicate checksum %x", i, id.Hash)
+		}
+		seen[id.Hash] = true
+		if want := activeVersion(testHistory[:i]); id.Version != want {
+			t.Errorf("activation %d: version mismatch: have %d, want %d", i, id.Version, want)
+		}
+	}
+	if id := NewID(common.HexToHash("0x5678"), testHistory); seen[id.Hash] {
+		t.Errorf("checksum %x of another genesis collides", id.Hash)
+	}
+	moved := append([]Activation{}, testHistory...)
+	moved[2].Block++
+	if id := NewID(testGenesis, moved); seen[id.Hash] {
+		t.Errorf("checksum %x of another activation block collides", id.Hash)
+	}
+}
+
+// Tests that the remote fork IDs are validated against the local history.
+func TestValidation(t *testing.T) {
+	var (
+		code   = params.FORKVERSION_1_5_0
+		behind = NewID(testGenesis, testHistory[:2])
+		head   = NewID(testGenesis, testHistory)
+	)
+	tests := []struct {
+		id  ID
+		err error
+	}{
+		// Same history, compatible
+		{ID{Hash: head.Hash, Version: params.FORKVERSION_1_5_0, Code: code}, nil},
+
+		// Remote behind, running a code able to process the local history
+		{ID{Hash: behind.Hash, Version: params.FORKVERSION_1_4_0, Code: code}, nil},
+
+		// Remote behind, running a code unable to process the local history
+		{ID{Hash: behind.Hash, Version: params.FORKVERSION_1_4_0, Code: params.FORKVERSION_1_4_0}, ErrRemoteStale},
+
+		// Remote ahead with a version the local code is unable to process
+		{ID{Hash: [4]byte{0x01}, Version: params.FORKVERSION_1_5_0 + 1<<8, Code: params.FORKVERSION_1_5_0 + 1<<8}, ErrLocalIncompatibleOrStale},
+
+		// Remote diverged on the same version
+		{ID{Hash: [4]byte{0x01}, Version: params.FORKVERSION_1_5_0, Code: code}, ErrLocalIncompatibleOrStale},
+
+		// Remote on another genesis
+		{NewID(common.HexToHash("0x5678"), testHistory), ErrLocalIncompatibleOrStale},
+	}
+	filter := newFilter(testGenesis, func() []Activation { return testHistory }, code)
+	for i, tt := range tests {
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Remote ahead with a version the local code is able to process
+	ahead := newFilter(testGenesis, func() []Activation { return testHistory[:2] }, code)
+	if err := ahead(head); err != nil {
+		t.Errorf("remote ahead rejected: %v", err)
+	}
+}
+
+// Tests that the fork IDs round trip through RLP.
+func TestEncoding(t *testing.T) {
+	id := NewID(testGenesis, testHistory)
+	blob, err := rlp.EncodeToBytes(id)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	var have ID
+	if err := rlp.DecodeBytes(blob, &have); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if have != id {
+		t.Errorf("id mismatch: have %v, want %v", have, id)
+	}
+}
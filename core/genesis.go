@@ -633,6 +633,44 @@ func DefaultGrapeGenesisBlock() *Genesis {
 	}
 }
 
+// DeveloperGenesisBlock returns the genesis block of a single node developer
+// network. The node is the only validator, the faucet is funded with the same
+// amount as the general account of the public networks. The developer mode
+// installs the economic model of the genesis, the constructor leaves the global
+// xcom configuration untouched.
+func DeveloperGenesisBlock(period uint64, node params.CbftNode, faucet common.Address) *Genesis {
+	const amount = 5 // Blocks per view, the per round blocks of the developer economic model
+
+	faucetBalance, _ := new(big.Int).SetString("9718188019000000000000000000", 10)
+	rewardMgrPoolIssue, _ := new(big.Int).SetString("200000000000000000000000000", 10)
+
+	// All the forks known to the code are active from the genesis
+	config := *params.AllEthashProtocolChanges
+	config.PIP7ChainID = new(big.Int).Set(params.PrivatePIP7ChainID)
+	config.GenesisVersion = params.CodeVersion()
+	config.EmptyBlock = "on"
+	config.Cbft = &params.CbftConfig{
+		Period:        period * 1000 * amount,
+		Amount:        amount,
+		InitialNodes:  []params.CbftNode{node},
+		ValidatorMode: common.PPOS_VALIDATOR_MODE,
+	}
+
+	// The economic model keeps its own block interval, so that the rounds and
+	// epochs span the same number of blocks whatever the period.
+	ec, _ := xcom.DeveloperEconomicModel()
+	return &Genesis{
+		Config:   &config,
+		Nonce:    hexutil.MustDecode("0x0376e56dffd12ab53bb149bda4e0cbce2b6aabe4cccc0df0b5a39e12977a2fcd23"),
+		GasLimit: params.GenesisGasLimit,
+		Alloc: map[common.Address]GenesisAccount{
+			vm.RewardManagerPoolAddr: {Balance: rewardMgrPoolIssue},
+			faucet:                   {Balance: faucetBalance},
+		},
+		EconomicModel: ec,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
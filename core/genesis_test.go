@@ -17,7 +17,17 @@
 package core
 
 import (
+	"net"
 	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
 )
 
 func TestDefaultGenesisBlock(t *testing.T) {
@@ -153,3 +163,39 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}*/
 }
+
+func TestDeveloperGenesisBlock(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var blsKey bls.SecretKey
+	blsKey.SetByCSPRNG()
+	node := params.CbftNode{
+		Node:      *discover.NewNode(discover.PubkeyID(&key.PublicKey), net.IPv4(127, 0, 0, 1), 16789, 16789),
+		BlsPubKey: *blsKey.GetPublicKey(),
+	}
+	faucet := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// The constructor must leave the global economic model untouched
+	ec := xcom.GetEc(xcom.DefaultUnitTestNet)
+	genesis := DeveloperGenesisBlock(3, node, faucet)
+	if xcom.GetEc(xcom.DefaultUnitTestNet) != ec || genesis.EconomicModel == ec {
+		t.Fatalf("the developer genesis replaced the global economic model")
+	}
+	if period := genesis.Config.Cbft.Period; period != 15000 {
+		t.Errorf("view period mismatch: have %d, want 15000", period)
+	}
+	if genesis.Config.EmptyBlock != "on" {
+		t.Errorf("empty block mismatch: have %s, want on", genesis.Config.EmptyBlock)
+	}
+
+	defer xcom.ResetEconomicDefaultConfig(ec)
+	xcom.ResetEconomicDefaultConfig(genesis.EconomicModel)
+	if _, _, err := SetupGenesisBlock(rawdb.NewMemoryDatabase(), snapshotdb.NewMemBaseDB(), genesis); err != nil {
+		t.Fatalf("failed to set up the genesis: %v", err)
+	}
+	if size := xcom.ConsensusSize(); size != 20 {
+		t.Errorf("consensus round mismatch: have %d blocks, want 20", size)
+	}
+	if size := xcom.EpochSize(); size != 4 {
+		t.Errorf("epoch mismatch: have %d rounds, want 4", size)
+	}
+}
@@ -40,6 +40,7 @@ type ParallelContext struct {
 	blockDeadline      time.Time
 	packNewBlock       bool
 	wg                 sync.WaitGroup
+	busy               int64 // nanoseconds the workers spent on the transactions, accessed atomically
 	signer             types.Signer
 	tempContractCache  map[common.Address]struct{}
 }
@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PlatONnetwork/PlatON-Go/x/gov"
@@ -16,6 +17,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/core/vm"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
 	"github.com/PlatONnetwork/PlatON-Go/params"
 )
 
@@ -30,6 +32,9 @@ var (
 	EIP155Signer types.Signer
 	PIP7Signer   types.Signer
 	PIP11Signer  types.Signer
+
+	// the time the workers spent on the transactions of a block divided by the time the batches took
+	parallelSpeedupGauge = metrics.NewRegisteredGaugeFloat64("chain/parallel/speedup", nil)
 )
 
 type Executor struct {
@@ -57,7 +62,9 @@ func NewExecutor(chainConfig *params.ChainConfig, chainContext ChainContext, vmC
 			ctx := args.ctx
 			idx := args.idx
 			intrinsicGas := args.intrinsicGas
+			start := time.Now()
 			executor.executeParallelTx(ctx, idx, intrinsicGas)
+			atomic.AddInt64(&ctx.busy, int64(time.Since(start)))
 			ctx.wg.Done()
 		})
 		executor.chainConfig = chainConfig
@@ -103,6 +110,7 @@ func (exe *Executor) ExecuteTransactions(ctx *ParallelContext) error {
 
 		start = time.Now()
 		batchNo := 0
+		var parallelTime time.Duration
 		for !ctx.IsTimeout() && txDag.HasNext() {
 			parallelTxIdxs := txDag.Next()
 
@@ -113,6 +121,7 @@ func (exe *Executor) ExecuteTransactions(ctx *ParallelContext) error {
 			if len(parallelTxIdxs) == 1 && txDag.IsContract(parallelTxIdxs[0]) {
 				exe.executeContractTransaction(ctx, parallelTxIdxs[0])
 			} else {
+				batchStart := time.Now()
 				for _, originIdx := range parallelTxIdxs {
 					tx := ctx.GetTx(originIdx)
 					if ctx.packNewBlock {
@@ -145,12 +154,16 @@ func (exe *Executor) ExecuteTransactions(ctx *ParallelContext) error {
 				}
 				// waiting for current batch done
 				ctx.wg.Wait()
+				parallelTime += time.Since(batchStart)
 				ctx.batchMerge(parallelTxIdxs)
 				batchNo++
 			}
 		}
 		// all transactions executed
 		log.Trace("Execute transactions cost", "number", ctx.header.Number, "time", time.Since(start))
+		if parallelTime > 0 {
+			parallelSpeedupGauge.Update(float64(atomic.LoadInt64(&ctx.busy)) / float64(parallelTime))
+		}
 		//add balance for miner
 		if ctx.GetEarnings().Cmp(big.NewInt(0)) > 0 {
 			ctx.state.AddMinerEarnings(ctx.header.Coinbase, ctx.GetEarnings())
@@ -0,0 +1,303 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+)
+
+// ParallelVerification is the outcome of executing a block with both the serial
+// and the parallel state processor on top of the same parent state.
+type ParallelVerification struct {
+	Number       uint64
+	Hash         common.Hash
+	SerialRoot   common.Hash
+	ParallelRoot common.Hash
+	SerialGas    uint64
+	ParallelGas  uint64
+
+	Diffs    []string       // Differences between the processors, empty if they agree
+	Accounts []*AccountDiff // Accounts the processors left in different states
+	Dag      string         // Transaction DAG of the parallel processor, set on divergence
+}
+
+// Diverged returns whether the processors disagree on the block.
+func (v *ParallelVerification) Diverged() bool {
+	return len(v.Diffs) > 0
+}
+
+// AccountDiff is an account the serial and the parallel processor left in
+// different states, a nil state meaning the account doesn't exist.
+type AccountDiff struct {
+	Address  common.Address
+	Serial   *AccountState
+	Parallel *AccountState
+}
+
+// AccountState is the state of an account after the execution of a block.
+type AccountState struct {
+	Balance     *big.Int
+	Nonce       uint64
+	CodeHash    common.Hash
+	StorageRoot common.Hash
+}
+
+// VerifyParallel executes the block with both the serial and the parallel state
+// processor on top of its parent state and compares the receipts, the logs, the
+// gas used and the state roots they produce, the roots with the header too. The
+// blocks and states aren't written.
+//
+// The parallel executor must have been created beforehand. The ppos plugins are
+// only run if a blockchain reactor is registered, it must be in replay mode to
+// execute the committed blocks again.
+func VerifyParallel(bc *BlockChain, block *types.Block) (*ParallelVerification, error) {
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	// Execute the block into separate databases, keeping the preimages to
+	// tell the differing accounts.
+	serialDB := state.NewDatabaseWithConfig(bc.db, &trie.Config{Preimages: true})
+	serialState, err := state.New(parent.Root(), serialDB)
+	if err != nil {
+		return nil, err
+	}
+	parallelDB := state.NewDatabaseWithConfig(bc.db, &trie.Config{Preimages: true})
+	parallelState, err := state.New(parent.Root(), parallelDB)
+	if err != nil {
+		return nil, err
+	}
+	serialReceipts, serialLogs, serialGas, serialErr := NewStateProcessor(bc.chainConfig, bc, bc.engine).Process(block, serialState, bc.vmConfig)
+	if err := discardReplay(block, serialErr); err != nil {
+		return nil, err
+	}
+	parallelReceipts, parallelLogs, parallelGas, parallelErr := NewParallelStateProcessor(bc.chainConfig, bc, bc.engine).Process(block, parallelState, bc.vmConfig)
+	if err := discardReplay(block, parallelErr); err != nil {
+		return nil, err
+	}
+
+	result := &ParallelVerification{
+		Number:      block.NumberU64(),
+		Hash:        block.Hash(),
+		SerialGas:   serialGas,
+		ParallelGas: parallelGas,
+	}
+	switch {
+	case serialErr != nil && parallelErr != nil:
+		if serialErr.Error() == parallelErr.Error() {
+			return nil, fmt.Errorf("block %d: %v", block.NumberU64(), serialErr)
+		}
+		result.Diffs = append(result.Diffs, fmt.Sprintf("error: serial %v, parallel %v", serialErr, parallelErr))
+		return result, nil
+	case serialErr != nil || parallelErr != nil:
+		result.Diffs = append(result.Diffs, fmt.Sprintf("error: serial %v, parallel %v", serialErr, parallelErr))
+		return result, nil
+	}
+	if serialGas != parallelGas {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("gas used: serial %d, parallel %d", serialGas, parallelGas))
+	}
+	result.Diffs = append(result.Diffs, diffReceipts(serialReceipts, parallelReceipts)...)
+	if len(serialLogs) != len(parallelLogs) {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("logs: serial %d, parallel %d", len(serialLogs), len(parallelLogs)))
+	}
+	if result.SerialRoot, err = serialState.Commit(true); err != nil {
+		return nil, err
+	}
+	if result.ParallelRoot, err = parallelState.Commit(true); err != nil {
+		return nil, err
+	}
+	if result.SerialRoot != result.ParallelRoot {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("state root: serial %x, parallel %x", result.SerialRoot, result.ParallelRoot))
+		if result.Accounts, err = diffAccounts(serialDB, result.SerialRoot, serialState, parallelDB, result.ParallelRoot, parallelState); err != nil {
+			return nil, err
+		}
+	}
+	if result.SerialRoot != block.Root() {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("state root: serial %x, header %x", result.SerialRoot, block.Root()))
+	}
+	if result.ParallelRoot != block.Root() {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("state root: parallel %x, header %x", result.ParallelRoot, block.Root()))
+	}
+	if result.Diverged() {
+		dag, err := parallelDag(bc, block, parent)
+		if err != nil {
+			return nil, err
+		}
+		result.Dag = dag
+	}
+	return result, nil
+}
+
+// discardReplay drops the snapshotdb replay block a processor ran the plugins of
+// the block on. A processor failing early may not have opened it.
+func discardReplay(block *types.Block, processErr error) error {
+	if bcr == nil {
+		return nil
+	}
+	if err := bcr.DiscardReplay(block.Header()); err != nil && (processErr == nil || err == snapshotdb.ErrReplayStale) {
+		return fmt.Errorf("block %d: %v", block.NumberU64(), err)
+	}
+	return nil
+}
+
+// diffReceipts returns the differences between the consensus fields of the
+// receipts.
+func diffReceipts(serial, parallel types.Receipts) []string {
+	var diffs []string
+	if len(serial) != len(parallel) {
+		diffs = append(diffs, fmt.Sprintf("receipts: serial %d, parallel %d", len(serial), len(parallel)))
+	}
+	for i := 0; i < len(serial) && i < len(parallel); i++ {
+		s, p := serial[i], parallel[i]
+		if s.TxHash != p.TxHash {
+			diffs = append(diffs, fmt.Sprintf("receipt %d: tx serial %x, parallel %x", i, s.TxHash, p.TxHash))
+			continue
+		}
+		if s.Status != p.Status {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): status serial %d, parallel %d", i, s.TxHash, s.Status, p.Status))
+		}
+		if s.GasUsed != p.GasUsed {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): gas used serial %d, parallel %d", i, s.TxHash, s.GasUsed, p.GasUsed))
+		}
+		if s.CumulativeGasUsed != p.CumulativeGasUsed {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): cumulative gas used serial %d, parallel %d", i, s.TxHash, s.CumulativeGasUsed, p.CumulativeGasUsed))
+		}
+		if s.ContractAddress != p.ContractAddress {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): contract serial %x, parallel %x", i, s.TxHash, s.ContractAddress, p.ContractAddress))
+		}
+		if s.Bloom != p.Bloom {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): bloom mismatch", i, s.TxHash))
+		}
+		if len(s.Logs) != len(p.Logs) {
+			diffs = append(diffs, fmt.Sprintf("receipt %d (%x): logs serial %d, parallel %d", i, s.TxHash, len(s.Logs), len(p.Logs)))
+			continue
+		}
+		for j := range s.Logs {
+			if !equalLogs(s.Logs[j], p.Logs[j]) {
+				diffs = append(diffs, fmt.Sprintf("receipt %d (%x): log %d mismatch", i, s.TxHash, j))
+			}
+		}
+	}
+	return diffs
+}
+
+// equalLogs returns whether the consensus fields of the logs are the same.
+func equalLogs(a, b *types.Log) bool {
+	if a.Address != b.Address || !bytes.Equal(a.Data, b.Data) || len(a.Topics) != len(b.Topics) {
+		return false
+	}
+	for i := range a.Topics {
+		if a.Topics[i] != b.Topics[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffAccounts returns the accounts which differ between the committed states.
+func diffAccounts(serialDB state.Database, serialRoot common.Hash, serialState *state.StateDB, parallelDB state.Database, parallelRoot common.Hash, parallelState *state.StateDB) ([]*AccountDiff, error) {
+	serialTrie, err := trie.NewSecure(serialRoot, serialDB.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	parallelTrie, err := trie.NewSecure(parallelRoot, parallelDB.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	// Gather the keys changed in either direction, deleted accounts only show
+	// up when iterating the trie holding them.
+	var (
+		keys = make(map[string]struct{})
+		diff []*AccountDiff
+	)
+	for _, its := range [][2]trie.NodeIterator{
+		{serialTrie.NodeIterator(nil), parallelTrie.NodeIterator(nil)},
+		{parallelTrie.NodeIterator(nil), serialTrie.NodeIterator(nil)},
+	} {
+		it, _ := trie.NewDifferenceIterator(its[0], its[1])
+		iter := trie.NewIterator(it)
+		for iter.Next() {
+			if _, ok := keys[string(iter.Key)]; ok {
+				continue
+			}
+			keys[string(iter.Key)] = struct{}{}
+
+			preimage := serialTrie.GetKey(iter.Key)
+			if preimage == nil {
+				preimage = parallelTrie.GetKey(iter.Key)
+			}
+			if preimage == nil {
+				return nil, fmt.Errorf("no preimage found for hash %x", iter.Key)
+			}
+			addr := common.BytesToAddress(preimage)
+			diff = append(diff, &AccountDiff{
+				Address:  addr,
+				Serial:   accountState(serialState, addr),
+				Parallel: accountState(parallelState, addr),
+			})
+		}
+	}
+	return diff, nil
+}
+
+// accountState returns the state of the account, nil if it doesn't exist.
+func accountState(statedb *state.StateDB, addr common.Address) *AccountState {
+	if !statedb.Exist(addr) {
+		return nil
+	}
+	account := &AccountState{
+		Balance:  statedb.GetBalance(addr),
+		Nonce:    statedb.GetNonce(addr),
+		CodeHash: statedb.GetCodeHash(addr),
+	}
+	if storage := statedb.StorageTrie(addr); storage != nil {
+		account.StorageRoot = storage.Hash()
+	}
+	return account
+}
+
+// parallelDag returns the transaction DAG the parallel processor follows to
+// execute the block.
+func parallelDag(bc *BlockChain, block, parent *types.Block) (string, error) {
+	statedb, err := state.New(parent.Root(), bc.stateCache)
+	if err != nil {
+		return "", err
+	}
+	exe := GetExecutor()
+	gp := new(GasPool).AddGas(block.GasLimit())
+	ctx := NewParallelContext(statedb, block.Header(), block.Hash(), gp, false, exe.MakeSigner(statedb), make(map[common.Address]struct{}))
+	ctx.SetTxList(block.Transactions())
+
+	txDag := NewTxDag(exe.Signer())
+	if err := txDag.MakeDagGraph(ctx, exe); err != nil {
+		return "", err
+	}
+	buffer, err := txDag.dag.Print()
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	cvm "github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+)
+
+// Tests that the serial and the parallel processor agree on blocks of transfers.
+func TestVerifyParallel(t *testing.T) {
+	// Leave the ppos plugins out like the offline verification does
+	defer func(reactor *BlockChainReactor) { bcr = reactor }(bcr)
+	bcr = nil
+
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		keys  = make([]*ecdsa.PrivateKey, 4)
+		alloc = GenesisAlloc{}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = GenesisAccount{Balance: big.NewInt(balance)}
+	}
+	gspec := &Genesis{Config: chainConfig, Alloc: alloc}
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := GenerateChain(chainConfig, genesis, consensus.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		for j, key := range keys {
+			// Chain the transfers so that the dag holds dependencies
+			to := crypto.PubkeyToAddress(keys[(j+1)%len(keys)].PublicKey)
+			if j == len(keys)-1 {
+				to = common.Address{byte(i + 1)}
+			}
+			tx, err := types.SignTx(types.NewTransaction(b.TxNonce(crypto.PubkeyToAddress(key.PublicKey)), to, big.NewInt(1000), 21000, gasPrice, nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			b.AddTx(tx)
+		}
+	})
+	blockchain, err := NewBlockChain(db, nil, gspec.Config, consensus.NewFaker(), cvm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	// The generated states are already written, the blocks only are missing
+	for _, block := range blocks {
+		rawdb.WriteBlock(db, block)
+	}
+	NewExecutor(chainConfig, blockchain, blockchain.vmConfig, nil)
+
+	for _, block := range blocks {
+		result, err := VerifyParallel(blockchain, block)
+		if err != nil {
+			t.Fatalf("failed to verify block %d: %v", block.NumberU64(), err)
+		}
+		if result.Diverged() {
+			t.Fatalf("block %d diverged: %v", block.NumberU64(), result.Diffs)
+		}
+		if result.SerialRoot != block.Root() {
+			t.Errorf("block %d root mismatch: have %x, want %x", block.NumberU64(), result.SerialRoot, block.Root())
+		}
+		if result.SerialGas != block.GasUsed() {
+			t.Errorf("block %d gas mismatch: have %d, want %d", block.NumberU64(), result.SerialGas, block.GasUsed())
+		}
+	}
+	// A header root both processors miss is reported
+	header := blocks[0].Header()
+	header.Root = common.Hash{0x01}
+	result, err := VerifyParallel(blockchain, types.NewBlockWithHeader(header).WithBody(blocks[0].Transactions(), blocks[0].ExtraData()))
+	if err != nil {
+		t.Fatalf("failed to verify block with a wrong root: %v", err)
+	}
+	if len(result.Diffs) != 2 || len(result.Accounts) != 0 {
+		t.Errorf("wrong root: diffs mismatch: have %v, accounts %d", result.Diffs, len(result.Accounts))
+	}
+}
+
+// Tests that differing receipts are reported.
+func TestDiffReceipts(t *testing.T) {
+	serial := types.Receipts{
+		{TxHash: common.Hash{1}, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, CumulativeGasUsed: 21000},
+		{TxHash: common.Hash{2}, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, CumulativeGasUsed: 42000,
+			Logs: []*types.Log{{Address: common.Address{1}, Topics: []common.Hash{{1}}}}},
+	}
+	if diffs := diffReceipts(serial, serial); len(diffs) != 0 {
+		t.Fatalf("equal receipts reported as different: %v", diffs)
+	}
+	parallel := types.Receipts{
+		{TxHash: common.Hash{1}, Status: types.ReceiptStatusFailed, GasUsed: 21000, CumulativeGasUsed: 21000},
+		{TxHash: common.Hash{2}, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, CumulativeGasUsed: 42000,
+			Logs: []*types.Log{{Address: common.Address{1}, Topics: []common.Hash{{2}}}}},
+	}
+	if diffs := diffReceipts(serial, parallel); len(diffs) != 2 {
+		t.Fatalf("differences mismatch: have %v, want 2", diffs)
+	}
+	if diffs := diffReceipts(serial, parallel[:1]); len(diffs) != 2 {
+		t.Fatalf("differences mismatch: have %v, want 2", diffs)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/ethdb"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
 )
 
 // ReadPreimage retrieves a single preimage of the provided hash.
@@ -94,3 +95,60 @@ func DeleteTrieNode(db ethdb.KeyValueWriter, hash common.Hash) {
 		log.Crit("Failed to delete trie node", "err", err)
 	}
 }
+
+// ReadPPOSWrites retrieves the ordered ppos writes of a block by the ppos kv hash they build.
+func ReadPPOSWrites(db ethdb.KeyValueReader, hash common.Hash) [][2][]byte {
+	data, _ := db.Get(pposWritesKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var writes [][2][]byte
+	if err := rlp.DecodeBytes(data, &writes); err != nil {
+		log.Error("Invalid ppos writes RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return writes
+}
+
+// WritePPOSWrites stores the ordered ppos writes of a block by the ppos kv hash they build.
+func WritePPOSWrites(db ethdb.KeyValueWriter, hash common.Hash, writes [][2][]byte) {
+	data, err := rlp.EncodeToBytes(writes)
+	if err != nil {
+		log.Crit("Failed to RLP encode ppos writes", "err", err)
+	}
+	if err := db.Put(pposWritesKey(hash), data); err != nil {
+		log.Crit("Failed to store ppos writes", "err", err)
+	}
+}
+
+// pposHashProof is the stored form of the proofs of the ppos hash of a block.
+type pposHashProof struct {
+	AccountProof [][]byte
+	StorageProof [][]byte
+}
+
+// ReadPPOSHashProof retrieves the proofs of the ppos hash in the state of a
+// block, the proof of the staking contract account and the one of the slot.
+func ReadPPOSHashProof(db ethdb.KeyValueReader, hash common.Hash) ([][]byte, [][]byte) {
+	data, _ := db.Get(pposHashProofKey(hash))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var proof pposHashProof
+	if err := rlp.DecodeBytes(data, &proof); err != nil {
+		log.Error("Invalid ppos hash proof RLP", "hash", hash, "err", err)
+		return nil, nil
+	}
+	return proof.AccountProof, proof.StorageProof
+}
+
+// WritePPOSHashProof stores the proofs of the ppos hash in the state of a block.
+func WritePPOSHashProof(db ethdb.KeyValueWriter, hash common.Hash, accountProof, storageProof [][]byte) {
+	data, err := rlp.EncodeToBytes(&pposHashProof{AccountProof: accountProof, StorageProof: storageProof})
+	if err != nil {
+		log.Crit("Failed to RLP encode ppos hash proof", "err", err)
+	}
+	if err := db.Put(pposHashProofKey(hash), data); err != nil {
+		log.Crit("Failed to store ppos hash proof", "err", err)
+	}
+}
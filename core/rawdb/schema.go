@@ -66,6 +66,8 @@ var (
 	configPrefix              = []byte("ethereum-config-")         // config prefix for the db
 	economicModelPrefix       = []byte("economicModel-key-")       // economicModel prefix for the db
 	economicModelExtendPrefix = []byte("economicModelExtend-key-") // economicModelExtend prefix for the db
+	pposWritesPrefix          = []byte("ppos-writes-")             // pposWritesPrefix + ppos kv hash -> ordered ppos writes of a block
+	pposHashProofPrefix       = []byte("ppos-hash-proof-")         // pposHashProofPrefix + block hash -> proofs of the ppos hash in the state of the block
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
@@ -162,6 +164,16 @@ func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
 }
 
+// pposWritesKey = pposWritesPrefix + hash
+func pposWritesKey(hash common.Hash) []byte {
+	return append(pposWritesPrefix, hash.Bytes()...)
+}
+
+// pposHashProofKey = pposHashProofPrefix + hash
+func pposHashProofKey(hash common.Hash) []byte {
+	return append(pposHashProofPrefix, hash.Bytes()...)
+}
+
 // codeKey = codePrefix + hash
 func codeKey(hash common.Hash) []byte {
 	return append(codePrefix, hash.Bytes()...)
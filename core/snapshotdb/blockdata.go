@@ -38,6 +38,7 @@ type blockData struct {
 	data       *memdb.DB
 	readOnly   bool
 	kvHash     common.Hash
+	replay     bool // Runs a block of the chain again, see NewReplayBlock
 
 	//only use for not commit block
 	journal        []journalEntry // Current changes tracked by the journal
@@ -178,6 +179,9 @@ type journalEntry struct {
 
 type unCommitBlocks struct {
 	blocks map[common.Hash]*blockData
+	// replayParents counts the replay blocks on top of each parent, the reads
+	// keyed by such a parent see the data as of it
+	replayParents map[common.Hash]int
 	sync.RWMutex
 }
 
@@ -0,0 +1,162 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshotdb
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var errBackwardIteration = errors.New("snapshotDB: the merged iterator only moves forward")
+
+// mergedIterator walks the keys of several iterators forward in key order. A
+// key held by several iterators takes the value of the first of them, the
+// newest block being first, and the keys deleted by an empty value are skipped.
+type mergedIterator struct {
+	util.BasicReleaser
+	iters []iterator.Iterator
+	valid []bool
+
+	key     []byte
+	value   []byte
+	current bool
+	started bool
+	err     error
+}
+
+func newMergedIterator(iters []iterator.Iterator) *mergedIterator {
+	return &mergedIterator{iters: iters, valid: make([]bool, len(iters))}
+}
+
+// settle moves to the lowest key left, skipping the deleted ones.
+func (it *mergedIterator) settle() bool {
+	for {
+		index := -1
+		for x, iter := range it.iters {
+			if it.valid[x] && (index < 0 || bytes.Compare(iter.Key(), it.iters[index].Key()) < 0) {
+				index = x
+			}
+		}
+		if index < 0 {
+			it.current = false
+			return false
+		}
+		it.key = append(it.key[:0], it.iters[index].Key()...)
+		it.value = it.iters[index].Value()
+		if len(it.value) > 0 {
+			it.current = true
+			return true
+		}
+		it.skip()
+	}
+}
+
+// skip moves the iterators past the current key.
+func (it *mergedIterator) skip() {
+	for x, iter := range it.iters {
+		if it.valid[x] && bytes.Equal(iter.Key(), it.key) {
+			it.valid[x] = iter.Next()
+		}
+	}
+}
+
+func (it *mergedIterator) First() bool {
+	if it.err != nil {
+		return false
+	}
+	for x, iter := range it.iters {
+		it.valid[x] = iter.First()
+	}
+	it.started = true
+	return it.settle()
+}
+
+func (it *mergedIterator) Seek(key []byte) bool {
+	if it.err != nil {
+		return false
+	}
+	for x, iter := range it.iters {
+		it.valid[x] = iter.Seek(key)
+	}
+	it.started = true
+	return it.settle()
+}
+
+func (it *mergedIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.started {
+		return it.First()
+	}
+	if !it.current {
+		return false
+	}
+	it.skip()
+	return it.settle()
+}
+
+func (it *mergedIterator) Last() bool {
+	it.err, it.current = errBackwardIteration, false
+	return false
+}
+
+func (it *mergedIterator) Prev() bool {
+	it.err, it.current = errBackwardIteration, false
+	return false
+}
+
+func (it *mergedIterator) Valid() bool {
+	return it.current
+}
+
+func (it *mergedIterator) Key() []byte {
+	if !it.current {
+		return nil
+	}
+	return it.key
+}
+
+func (it *mergedIterator) Value() []byte {
+	if !it.current {
+		return nil
+	}
+	return it.value
+}
+
+func (it *mergedIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	for _, iter := range it.iters {
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (it *mergedIterator) Release() {
+	for _, iter := range it.iters {
+		iter.Release()
+	}
+	it.current = false
+	it.BasicReleaser.Release()
+}
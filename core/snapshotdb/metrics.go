@@ -27,6 +27,12 @@ import (
 var (
 	dbSizeGauge = metrics.NewRegisteredGauge("snapshotdb/basedb/size", nil)
 	dbForkGauge = metrics.NewRegisteredGauge("snapshotdb/fork", nil)
+
+	// the blocks above the highest committed block
+	unconfirmedDepthGauge = metrics.NewRegisteredGauge("snapshotdb/unconfirmed/depth", nil)
+	// the committed blocks in the journal waiting for the compaction into the basedb
+	journalBlocksGauge = metrics.NewRegisteredGauge("snapshotdb/journal/blocks", nil)
+	journalSizeGauge   = metrics.NewRegisteredGauge("snapshotdb/journal/size", nil)
 )
 
 func walkDir(dir string) int64 {
@@ -48,13 +54,19 @@ func walkDir(dir string) int64 {
 
 func (s *snapshotDB) metrics() {
 	// metric size
-	size := walkDir(s.path)
-	dbSizeGauge.Update(size)
+	if !s.memory {
+		size := walkDir(s.path)
+		dbSizeGauge.Update(size)
+	}
 	// metric fork num
 	forkNumList := make(map[int64]int)
 	var forkMax int
+	var unconfirmedMax int64
 	s.unCommit.RLock()
 	for _, value := range s.unCommit.blocks {
+		if value.Number != nil && value.Number.Int64() > unconfirmedMax {
+			unconfirmedMax = value.Number.Int64()
+		}
 		if forkSum, ok := forkNumList[value.Number.Int64()]; ok {
 			forkIncr := forkSum + 1
 			forkNumList[value.Number.Int64()] = forkIncr
@@ -67,4 +79,21 @@ func (s *snapshotDB) metrics() {
 	}
 	s.unCommit.RUnlock()
 	dbForkGauge.Update(int64(forkMax))
+
+	// metric unconfirmed depth and journal
+	var journalSize int
+	s.commitLock.RLock()
+	highest := s.current.GetHighest(false).Num.Int64()
+	journalBlocks := len(s.committed)
+	for _, block := range s.committed {
+		journalSize += block.data.Size()
+	}
+	s.commitLock.RUnlock()
+	if unconfirmedMax > highest {
+		unconfirmedDepthGauge.Update(unconfirmedMax - highest)
+	} else {
+		unconfirmedDepthGauge.Update(0)
+	}
+	journalBlocksGauge.Update(int64(journalBlocks))
+	journalSizeGauge.Update(int64(journalSize))
 }
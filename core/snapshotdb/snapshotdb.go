@@ -36,6 +36,7 @@ import (
 	leveldbError "github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
@@ -76,6 +77,10 @@ type DB interface {
 	Has(hash common.Hash, key []byte) (bool, error)
 	Flush(hash common.Hash, blocknumber *big.Int) error
 	Ranking(hash common.Hash, key []byte, ranges int) iterator.Iterator
+	// NewIterator walks the entries under the prefix as of the block of the hash
+	// in key order, reading the blocks and the base db as it moves where Ranking
+	// loads them in memory first. It only moves forward and must be released.
+	NewIterator(hash common.Hash, prefix []byte) iterator.Iterator
 	//notice , iter.key or iter.value is slice，if you want to save it to a slice,you can use copy
 	// container:=make([]byte,0)
 	// for iter.next{
@@ -85,6 +90,8 @@ type DB interface {
 	// }
 	//
 	WalkBaseDB(slice *util.Range, f func(num *big.Int, iter iterator.Iterator) error) error
+	// GetBaseSnapshot returns a snapshot of the base db kept until it is released.
+	GetBaseSnapshot() (*BaseSnapshot, error)
 	Commit(hash common.Hash) error
 
 	// Clear close db , remove all db file
@@ -93,6 +100,7 @@ type DB interface {
 	BaseDB
 
 	GetLastKVHash(blockHash common.Hash) []byte
+	GetBlockWrites(blockHash common.Hash) [][2][]byte
 	BaseNum() (*big.Int, error)
 	Close() error
 	Compaction() error
@@ -101,6 +109,14 @@ type DB interface {
 	//ues to Revert failed tx
 	RevertToSnapshot(hash common.Hash, revid int)
 	Snapshot(hash common.Hash) int
+
+	// NewReplayBlock and DiscardReplayBlock bracket the replay of a block of the
+	// chain on top of its parent
+	NewReplayBlock(blockNumber *big.Int, parentHash common.Hash, hash common.Hash) error
+	DiscardReplayBlock(hash common.Hash) error
+	// IsReplayBlock tells the replay blocks, whose plugins must leave alone
+	// everything but the snapshotdb and the state
+	IsReplayBlock(hash common.Hash) bool
 }
 
 type BaseDB interface {
@@ -126,17 +142,29 @@ var (
 	baseDBcache   int
 	baseDBhandles int
 
+	// baseDBMemory keeps the base db of the instance in memory instead of on disk
+	baseDBMemory bool
+
+	// baseDBFrozen keeps the committed blocks of the instance out of the base db
+	baseDBFrozen bool
+
 	logger = log.Root().New("package", "snapshotdb")
 
 	//ErrNotFound when db not found
 	ErrNotFound = errors.New("snapshotDB: not found")
 
 	ErrBlockTooLow = errors.New("the block is less than commit highest block")
+
+	// ErrReplayStale is returned when the base moved past the parent of a replay
+	// block while it was in use, so that its reads may have seen later data.
+	ErrReplayStale = errors.New("the base moved past the parent of the replay block")
 )
 
 type snapshotDB struct {
 	path string
 
+	memory bool
+
 	snapshotLockC int32
 
 	current *current
@@ -184,6 +212,20 @@ func SetDBOptions(cache int, handles int) {
 	baseDBhandles = handles
 }
 
+// SetDBMemory makes the instance opened afterwards keep its base db in memory,
+// nothing is written to the db path and all data is lost once it is closed.
+// It is intended for tests and simulated chains.
+func SetDBMemory(memory bool) {
+	baseDBMemory = memory
+}
+
+// SetDBFrozen makes the instance opened afterwards skip the compaction, the
+// committed blocks aren't moved to the base db and the base stays where it
+// was. It is intended for the tools replaying the committed blocks offline.
+func SetDBFrozen(frozen bool) {
+	baseDBFrozen = frozen
+}
+
 //Instance return the Instance of the db
 func Instance() DB {
 	instance.Lock()
@@ -230,10 +272,26 @@ func openBaseDB(snapshotDBPath string, cache int, handles int) (*leveldb.DB, err
 	return baseDB, nil
 }
 
+func openMemoryBaseDB() (*leveldb.DB, error) {
+	return leveldb.Open(storage.NewMemStorage(), nil)
+}
+
 func open(path string, cache int, handles int, baseOnly bool) (*snapshotDB, error) {
-	logger.Info("open snapshot db Allocated cache and file handles", "cache", cache, "handles", handles, "baseDB", baseOnly)
+	return openWithBaseDB(path, cache, handles, baseOnly, false)
+}
+
+func openWithBaseDB(path string, cache int, handles int, baseOnly bool, memory bool) (*snapshotDB, error) {
+	logger.Info("open snapshot db Allocated cache and file handles", "cache", cache, "handles", handles, "baseDB", baseOnly, "memory", memory)
 
-	baseDB, err := openBaseDB(path, cache, handles)
+	var (
+		baseDB *leveldb.DB
+		err    error
+	)
+	if memory {
+		baseDB, err = openMemoryBaseDB()
+	} else {
+		baseDB, err = openBaseDB(path, cache, handles)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +300,7 @@ func open(path string, cache int, handles int, baseOnly bool) (*snapshotDB, erro
 	unCommitBlock.blocks = make(map[common.Hash]*blockData)
 	db := &snapshotDB{
 		path:          path,
+		memory:        memory,
 		unCommit:      unCommitBlock,
 		committed:     make([]*blockData, 0),
 		baseDB:        baseDB,
@@ -290,6 +349,7 @@ func Open(path string, cache int, handles int, baseOnly bool) (DB, error) {
 
 func copyDB(from, to *snapshotDB) {
 	to.path = from.path
+	to.memory = from.memory
 	to.current = from.current
 	to.baseDB = from.baseDB
 	to.unCommit = from.unCommit
@@ -302,11 +362,15 @@ func copyDB(from, to *snapshotDB) {
 }
 
 func initDB(path string, sdb *snapshotDB) error {
-	dbInterface, err := open(path, baseDBcache, baseDBhandles, false)
+	dbInterface, err := openWithBaseDB(path, baseDBcache, baseDBhandles, false, baseDBMemory)
 	if err != nil {
 		return err
 	}
 	copyDB(dbInterface, sdb)
+	if baseDBFrozen {
+		go sdb.loopWriteWal()
+		return nil
+	}
 	if err := sdb.Start(); err != nil {
 		return err
 	}
@@ -411,6 +475,21 @@ func (s *snapshotDB) GetLastKVHash(blockHash common.Hash) []byte {
 	return block.kvHash.Bytes()
 }
 
+// GetBlockWrites returns the writes of a block not committed yet in the
+// order they were made, the kv hash of the block is built in this order.
+// It returns nil once the journal of the block is cleaned.
+func (s *snapshotDB) GetBlockWrites(blockHash common.Hash) [][2][]byte {
+	block := s.unCommit.Get(blockHash)
+	if block == nil {
+		return nil
+	}
+	writes := make([][2][]byte, 0, len(block.journal))
+	for _, entry := range block.journal {
+		writes = append(writes, [2][]byte{common.CopyBytes(entry.key), common.CopyBytes(entry.newVal)})
+	}
+	return writes
+}
+
 // Del del key,val from  snapshotDB
 // if hash is nil, unRecognizedBlockData > recognizedBlockData
 // if hash is not nil,it will del in recognized BlockData
@@ -456,7 +535,7 @@ func (s *snapshotDB) rmExpireForkBlock() {
 	if len(s.unCommit.blocks) > UnBlockNeedClean {
 		currentBase := s.current.GetBase(false).Num
 		for key, value := range s.unCommit.blocks {
-			if currentBase.Cmp(value.Number) >= 0 {
+			if !value.replay && currentBase.Cmp(value.Number) >= 0 {
 				delete(s.unCommit.blocks, key)
 				logger.Debug("compaction delete no need blocks", "num", value.Number, "hash", value.BlockHash.String())
 			}
@@ -564,6 +643,84 @@ func (s *snapshotDB) NewBlock(blockNumber *big.Int, parentHash common.Hash, hash
 	return nil
 }
 
+// NewReplayBlock creates a block to run a block of the chain again on top of
+// its parent, which must be the base block or a committed block. The replay
+// block reads the data as of the parent and its writes never reach the chain,
+// it must be removed by DiscardReplayBlock once done. The hash must tell the
+// replay block from the blocks of the chain.
+func (s *snapshotDB) NewReplayBlock(blockNumber *big.Int, parentHash common.Hash, hash common.Hash) error {
+	if blockNumber == nil || blockNumber.Sign() <= 0 {
+		return errors.New("[SnapshotDB]the replay blockNumber must be positive")
+	}
+	if hash == s.getUnRecognizedHash() {
+		return errors.New("[SnapshotDB]the replay block hash must not be empty")
+	}
+	parentNum := new(big.Int).Sub(blockNumber, common.Big1)
+	s.commitLock.RLock()
+	baseNum := s.current.GetBase(false).Num
+	found := parentNum.Cmp(baseNum) == 0
+	for i := len(s.committed) - 1; i >= 0 && !found; i-- {
+		found = s.committed[i].BlockHash == parentHash && s.committed[i].Number.Cmp(parentNum) == 0
+	}
+	s.commitLock.RUnlock()
+	if !found {
+		return fmt.Errorf("[SnapshotDB]the parent %v of the replay block %v is neither the base %v nor committed", parentHash.String(), blockNumber, baseNum)
+	}
+
+	block := new(blockData)
+	block.Number = new(big.Int).Set(blockNumber)
+	block.ParentHash = parentHash
+	block.BlockHash = hash
+	block.replay = true
+	block.data = memdb.New(DefaultComparer, 100)
+	block.journal = make([]journalEntry, 0)
+	block.validRevisions = make([]revision, 0)
+
+	s.unCommit.Lock()
+	defer s.unCommit.Unlock()
+	if _, ok := s.unCommit.blocks[hash]; ok {
+		return fmt.Errorf("[SnapshotDB]the replay block hash %v is in use", hash.String())
+	}
+	s.unCommit.blocks[hash] = block
+	if s.unCommit.replayParents == nil {
+		s.unCommit.replayParents = make(map[common.Hash]int)
+	}
+	s.unCommit.replayParents[parentHash]++
+	logger.Debug("NewReplayBlock", "num", block.Number, "hash", hash, "parent", parentHash)
+	return nil
+}
+
+// DiscardReplayBlock removes the replay block, returning ErrReplayStale if the
+// base moved past its parent meanwhile.
+func (s *snapshotDB) DiscardReplayBlock(hash common.Hash) error {
+	s.unCommit.Lock()
+	block, ok := s.unCommit.blocks[hash]
+	if !ok || !block.replay {
+		s.unCommit.Unlock()
+		return fmt.Errorf("[SnapshotDB]the replay block %v is not found", hash.String())
+	}
+	delete(s.unCommit.blocks, hash)
+	if s.unCommit.replayParents[block.ParentHash]--; s.unCommit.replayParents[block.ParentHash] == 0 {
+		delete(s.unCommit.replayParents, block.ParentHash)
+	}
+	s.unCommit.Unlock()
+
+	s.commitLock.RLock()
+	defer s.commitLock.RUnlock()
+	if s.current.GetBase(false).Num.Cmp(block.Number) >= 0 {
+		return ErrReplayStale
+	}
+	return nil
+}
+
+// IsReplayBlock returns whether the hash is the one of a replay block.
+func (s *snapshotDB) IsReplayBlock(hash common.Hash) bool {
+	s.unCommit.RLock()
+	defer s.unCommit.RUnlock()
+	block, ok := s.unCommit.blocks[hash]
+	return ok && block.replay
+}
+
 func (s *snapshotDB) RevertToSnapshot(hash common.Hash, revid int) {
 	s.unCommit.Lock()
 	defer s.unCommit.Unlock()
@@ -633,10 +790,66 @@ func (s *snapshotDB) getFromCommit(key []byte) ([]byte, error) {
 	return nil, ErrNotFound
 }
 
+// getFromReplay looks the key up in the replay block, then in the committed
+// block of its parent and the ancestors of it. The reads keyed by the parent
+// of a replay block start from the parent, the replayed plugins look up the
+// data of the previous block that way.
+func (s *snapshotDB) getFromReplay(hash common.Hash, key []byte) ([]byte, bool, error) {
+	s.unCommit.RLock()
+	block, ok := s.unCommit.blocks[hash]
+	replayParent := s.unCommit.replayParents[hash] > 0
+	s.unCommit.RUnlock()
+
+	parentHash := hash
+	switch {
+	case ok && block.replay:
+		v, err := block.data.Get(key)
+		if err == nil {
+			return v, true, nil
+		}
+		if err != memdb.ErrNotFound {
+			return nil, true, err
+		}
+		parentHash = block.ParentHash
+	case !replayParent:
+		return nil, false, nil
+	}
+	s.commitLock.RLock()
+	defer s.commitLock.RUnlock()
+	for i := len(s.committed) - 1; i >= 0; i-- {
+		if s.committed[i].BlockHash != parentHash {
+			continue
+		}
+		v, err := s.committed[i].data.Get(key)
+		if err == nil {
+			return v, true, nil
+		}
+		if err != memdb.ErrNotFound {
+			return nil, true, err
+		}
+		parentHash = s.committed[i].ParentHash
+	}
+	return nil, true, ErrNotFound
+}
+
 // Get get key,val from  snapshotDB
 // if hash is nil, unRecognizedBlockData > RecognizedBlockData > CommittedBlockData > baseDB
 // if hash is not nil,it will find from the chain, RecognizedBlockData > CommittedBlockData > baseDB
+// if hash is a replay block, ReplayBlockData > CommittedBlockData up to its parent > baseDB
+// if hash is the parent of a replay block, CommittedBlockData up to it > baseDB
 func (s *snapshotDB) Get(hash common.Hash, key []byte) ([]byte, error) {
+	if v, ok, err := s.getFromReplay(hash, key); ok {
+		if err == ErrNotFound {
+			return s.GetBaseDB(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, ErrNotFound
+		}
+		return v, nil
+	}
 	v, err := s.getFromUnCommit(hash, key)
 	if err != nil && err != ErrNotFound {
 		return nil, err
@@ -738,6 +951,9 @@ func (s *snapshotDB) Commit(hash common.Hash) error {
 	if !ok {
 		return errors.New("[snapshotdb]commit fail, not found block from recognized :" + hash.String())
 	}
+	if block.replay {
+		return errors.New("[snapshotdb]commit fail, the block is a replay block :" + hash.String())
+	}
 	if s.theBlockIsCommit(block) {
 		s.unCommit.Lock()
 		delete(s.unCommit.blocks, hash)
@@ -804,6 +1020,31 @@ func (s *snapshotDB) WalkBaseDB(slice *util.Range, f func(num *big.Int, iter ite
 	return f(s.current.GetBase(true).Num, t)
 }
 
+// BaseSnapshot is a frozen snapshot of the base db at the base block, which
+// can be walked by many iterators until it is released.
+type BaseSnapshot struct {
+	Num      *big.Int
+	snapshot *leveldb.Snapshot
+}
+
+// NewIterator returns an iterator over the key range of the snapshot.
+func (b *BaseSnapshot) NewIterator(slice *util.Range) iterator.Iterator {
+	return b.snapshot.NewIterator(slice, nil)
+}
+
+// Release releases the snapshot, the iterators must be released before.
+func (b *BaseSnapshot) Release() {
+	b.snapshot.Release()
+}
+
+func (s *snapshotDB) GetBaseSnapshot() (*BaseSnapshot, error) {
+	snapshot, err := s.baseDB.GetSnapshot()
+	if err != nil {
+		return nil, errors.New("[snapshotdb] get snapshot fail:" + err.Error())
+	}
+	return &BaseSnapshot{Num: new(big.Int).Set(s.current.GetBase(true).Num), snapshot: snapshot}, nil
+}
+
 // Clear close db , remove all db file
 func (s *snapshotDB) Clear() error {
 	if s == nil {
@@ -812,6 +1053,9 @@ func (s *snapshotDB) Clear() error {
 	if err := s.Close(); err != nil {
 		return err
 	}
+	if s.memory {
+		return nil
+	}
 	logger.Info("begin clear file", "path", s.path)
 	if err := os.RemoveAll(s.path); err != nil {
 		return err
@@ -827,13 +1071,43 @@ func (s *snapshotDB) Clear() error {
 // Also read Iterator documentation of the leveldb/iterator package.
 func (s *snapshotDB) Ranking(hash common.Hash, key []byte, rangeNumber int) iterator.Iterator {
 	prefix := util.BytesPrefix(key)
+	itrs := s.blockIterators(hash, prefix)
+	//put  unCommit and commit itr to heap
+	rankingHeap := newRankingHeap(rangeNumber)
+	for i := 0; i < len(itrs); i++ {
+		rankingHeap.itr2Heap(itrs[i], false, false)
+	}
+	//put baseDB itr to heap
+	itr := s.baseDB.NewIterator(prefix, nil)
+	rankingHeap.itr2Heap(itr, true, true)
+	//generate memdb Iterator
+	mdb := memdb.New(DefaultComparer, rangeNumber)
+	for rankingHeap.heap.Len() > 0 {
+		kv := heap.Pop(&rankingHeap.heap).(kv)
+		if err := mdb.Put(kv.key, kv.value); err != nil {
+			return iterator.NewEmptyIterator(errors.New("put to mdb fail" + err.Error()))
+		}
+	}
+	rankingHeap = nil
+	return mdb.NewIterator(nil)
+}
+
+func (s *snapshotDB) NewIterator(hash common.Hash, prefix []byte) iterator.Iterator {
+	slice := util.BytesPrefix(prefix)
+	itrs := s.blockIterators(hash, slice)
+	return newMergedIterator(append(itrs, s.baseDB.NewIterator(slice, nil)))
+}
+
+// blockIterators returns the iterators over the blocks from the block of the
+// hash down to the base, the newest first.
+func (s *snapshotDB) blockIterators(hash common.Hash, slice *util.Range) []iterator.Iterator {
 	var itrs []iterator.Iterator
 	var parentHash common.Hash
 	parentHash = hash
 	s.unCommit.RLock()
 	for {
 		if block, ok := s.unCommit.blocks[parentHash]; ok {
-			itrs = append(itrs, block.data.NewIterator(prefix))
+			itrs = append(itrs, block.data.NewIterator(slice))
 			parentHash = block.ParentHash
 		} else {
 			break
@@ -844,29 +1118,12 @@ func (s *snapshotDB) Ranking(hash common.Hash, key []byte, rangeNumber int) iter
 	for i := len(s.committed) - 1; i >= 0; i-- {
 		block := s.committed[i]
 		if block.BlockHash == hash || block.BlockHash == parentHash {
-			itrs = append(itrs, block.data.NewIterator(prefix))
+			itrs = append(itrs, block.data.NewIterator(slice))
 			parentHash = block.ParentHash
 		}
 	}
 	s.commitLock.RUnlock()
-	//put  unCommit and commit itr to heap
-	rankingHeap := newRankingHeap(rangeNumber)
-	for i := 0; i < len(itrs); i++ {
-		rankingHeap.itr2Heap(itrs[i], false, false)
-	}
-	//put baseDB itr to heap
-	itr := s.baseDB.NewIterator(prefix, nil)
-	rankingHeap.itr2Heap(itr, true, true)
-	//generate memdb Iterator
-	mdb := memdb.New(DefaultComparer, rangeNumber)
-	for rankingHeap.heap.Len() > 0 {
-		kv := heap.Pop(&rankingHeap.heap).(kv)
-		if err := mdb.Put(kv.key, kv.value); err != nil {
-			return iterator.NewEmptyIterator(errors.New("put to mdb fail" + err.Error()))
-		}
-	}
-	rankingHeap = nil
-	return mdb.NewIterator(nil)
+	return itrs
 }
 
 func (s *snapshotDB) Close() error {
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"sort"
 	"sync"
 	"testing"
@@ -364,6 +365,152 @@ func TestSnapshotDB_GetFromCommitedBlock(t *testing.T) {
 	})
 }
 
+func TestSnapshotDB_ReplayBlock(t *testing.T) {
+	ch := newTestchain(dbpath)
+	defer ch.clear()
+	var (
+		keyA, keyB = []byte("ka"), []byte("kb")
+		replayHash = generateHash("replay")
+	)
+	if err := ch.insert(true, []kv{{key: keyA, value: []byte("1")}}, newBlockBaseDB); err != nil {
+		t.Fatal(err)
+	}
+	base := ch.CurrentHeader()
+	if err := ch.insert(true, []kv{{key: keyA, value: []byte("2")}, {key: keyB, value: []byte("2")}}, newBlockCommited); err != nil {
+		t.Fatal(err)
+	}
+	parent := ch.CurrentHeader()
+	if err := ch.insert(true, []kv{{key: keyA, value: []byte("3")}}, newBlockCommited); err != nil {
+		t.Fatal(err)
+	}
+	head := ch.CurrentHeader()
+
+	t.Run("read as of a committed parent", func(t *testing.T) {
+		if err := ch.db.NewReplayBlock(head.Number, parent.Hash(), replayHash); err != nil {
+			t.Fatal(err)
+		}
+		if val, err := ch.db.Get(replayHash, keyA); err != nil || string(val) != "2" {
+			t.Error("must read the parent value", string(val), err)
+		}
+		if val, err := ch.db.Get(parent.Hash(), keyA); err != nil || string(val) != "2" {
+			t.Error("must read the parent value by its hash", string(val), err)
+		}
+		if err := ch.db.Put(replayHash, keyA, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		if val, err := ch.db.Get(replayHash, keyA); err != nil || string(val) != "x" {
+			t.Error("must read the replay value", string(val), err)
+		}
+		if val, err := ch.db.GetFromCommittedBlock(keyA); err != nil || string(val) != "3" {
+			t.Error("the replay must not reach the chain", string(val), err)
+		}
+		itr := ch.db.Ranking(replayHash, []byte("k"), 0)
+		var got []string
+		for itr.Next() {
+			got = append(got, string(itr.Key())+"="+string(itr.Value()))
+		}
+		itr.Release()
+		if len(got) != 2 || got[0] != "ka=x" || got[1] != "kb=2" {
+			t.Error("ranking must see the replay on top of its parent", got)
+		}
+		if err := ch.db.Commit(replayHash); err == nil {
+			t.Error("a replay block must not be committed")
+		}
+		if !ch.db.IsReplayBlock(replayHash) || ch.db.IsReplayBlock(parent.Hash()) {
+			t.Error("only the replay block must be told a replay block")
+		}
+		if err := ch.db.DiscardReplayBlock(replayHash); err != nil {
+			t.Error(err)
+		}
+		if err := ch.db.DiscardReplayBlock(replayHash); err == nil {
+			t.Error("a discarded replay block must be gone")
+		}
+		if ch.db.IsReplayBlock(replayHash) {
+			t.Error("a discarded replay block must not be told a replay block")
+		}
+		if val, err := ch.db.Get(parent.Hash(), keyA); err != nil || string(val) != "3" {
+			t.Error("must read the latest value once discarded", string(val), err)
+		}
+	})
+	t.Run("read as of the base", func(t *testing.T) {
+		if err := ch.db.NewReplayBlock(parent.Number, base.Hash(), replayHash); err != nil {
+			t.Fatal(err)
+		}
+		defer ch.db.DiscardReplayBlock(replayHash)
+		if val, err := ch.db.Get(replayHash, keyA); err != nil || string(val) != "1" {
+			t.Error("must read the base value", string(val), err)
+		}
+		if _, err := ch.db.Get(replayHash, keyB); err != ErrNotFound {
+			t.Error("must not read the later blocks", err)
+		}
+		if _, err := ch.db.Get(base.Hash(), keyB); err != ErrNotFound {
+			t.Error("must not read the later blocks by the base hash", err)
+		}
+	})
+	t.Run("unknown parent", func(t *testing.T) {
+		if err := ch.db.NewReplayBlock(head.Number, generateHash("unknown"), replayHash); err == nil {
+			t.Error("the parent must be committed")
+		}
+		if err := ch.db.NewReplayBlock(new(big.Int).Add(head.Number, common.Big1), head.Hash(), common.ZeroHash); err == nil {
+			t.Error("the replay hash must not be empty")
+		}
+	})
+}
+
+func TestSnapshotDB_NewIterator(t *testing.T) {
+	ch := newTestchain(dbpath)
+	defer ch.clear()
+	if err := ch.insert(true, kvs{{key: []byte("ka"), value: []byte("1")}, {key: []byte("kb"), value: []byte("1")}, {key: []byte("kc"), value: []byte("1")}}, newBlockBaseDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.insert(true, kvs{{key: []byte("kb"), value: []byte("2")}, {key: []byte("kd"), value: []byte("2")}}, newBlockCommited); err != nil {
+		t.Fatal(err)
+	}
+	committed := ch.CurrentHeader().Hash()
+	if err := ch.insert(true, kvs{{key: []byte("ka"), value: []byte("3")}, {key: []byte("ke"), value: []byte("3")}}, newBlockRecognizedDirect); err != nil {
+		t.Fatal(err)
+	}
+	recognized := ch.CurrentHeader().Hash()
+	if err := ch.db.Del(recognized, []byte("kc")); err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(itr iterator.Iterator) []string {
+		defer itr.Release()
+		var got []string
+		for itr.Next() {
+			got = append(got, string(itr.Key())+"="+string(itr.Value()))
+		}
+		if err := itr.Error(); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	for _, test := range []struct {
+		hash common.Hash
+		want []string
+	}{
+		{committed, []string{"ka=1", "kb=2", "kc=1", "kd=2"}},
+		{recognized, []string{"ka=3", "kb=2", "kd=2", "ke=3"}},
+	} {
+		got := walk(ch.db.NewIterator(test.hash, []byte("k")))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("entries mismatch: have %v, want %v", got, test.want)
+		}
+		if ranking := walk(ch.db.Ranking(test.hash, []byte("k"), 0)); !reflect.DeepEqual(got, ranking) {
+			t.Errorf("entries differ from the ranking: have %v, want %v", got, ranking)
+		}
+	}
+	itr := ch.db.NewIterator(recognized, []byte("k"))
+	defer itr.Release()
+	if !itr.Seek([]byte("kc")) || string(itr.Key()) != "kd" {
+		t.Errorf("seek must skip the deleted key: have %s", itr.Key())
+	}
+	if itr.Prev() || itr.Error() == nil {
+		t.Error("the iterator must not move backward")
+	}
+}
+
 func TestSnapshotDB_Del(t *testing.T) {
 	ch := newTestchain(dbpath)
 	defer ch.clear()
@@ -801,6 +948,42 @@ func TestSnapshotDB_WalkBaseDB(t *testing.T) {
 	})
 }
 
+func TestSnapshotDB_GetBaseSnapshot(t *testing.T) {
+	ch := newTestchain(dbpath)
+	defer ch.clear()
+	var prefix = util.BytesPrefix([]byte("a"))
+
+	kvsWithA := generatekvWithPrefix(100, "a")
+	if err := ch.insert(true, kvsWithA, newBlockBaseDB); err != nil {
+		t.Error(err)
+	}
+	snapshot, err := ch.db.GetBaseSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Release()
+	if snapshot.Num.Int64() != 1 {
+		t.Errorf("basenum is wrong:%v,should be 1", snapshot.Num)
+	}
+	// The later writes of the base db are not seen by the snapshot
+	if err := ch.insert(true, generatekvWithPrefix(100, "a"), newBlockBaseDB); err != nil {
+		t.Error(err)
+	}
+	var kvGetFromSnapshot kvs
+	iter := snapshot.NewIterator(prefix)
+	for iter.Next() {
+		k, v := make([]byte, len(iter.Key())), make([]byte, len(iter.Value()))
+		copy(k, iter.Key())
+		copy(v, iter.Value())
+		kvGetFromSnapshot = append(kvGetFromSnapshot, kv{key: k, value: v})
+	}
+	iter.Release()
+	sort.Sort(kvGetFromSnapshot)
+	if err := kvsWithA.compareWithkvs(kvGetFromSnapshot); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestSnapshotDB_GetLastKVHash(t *testing.T) {
 	ch := newTestchain(dbpath)
 	defer ch.clear()
@@ -839,6 +1022,35 @@ func TestSnapshotDB_GetLastKVHash(t *testing.T) {
 	})
 }
 
+func TestSnapshotDB_GetBlockWrites(t *testing.T) {
+	ch := newTestchain(dbpath)
+	defer ch.clear()
+	var (
+		arr            = [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+		recognizedHash = generateHash("recognizedHash")
+	)
+	ch.db.NewBlock(big.NewInt(10), common.ZeroHash, recognizedHash)
+	ch.db.Put(recognizedHash, arr[0], arr[0])
+	ch.db.Put(recognizedHash, arr[1], arr[1])
+	ch.db.Put(recognizedHash, arr[0], arr[2])
+	ch.db.Del(recognizedHash, arr[1])
+
+	writes := ch.db.GetBlockWrites(recognizedHash)
+	if len(writes) != 4 {
+		t.Fatal("writes must be tracked in order", len(writes))
+	}
+	var kvhash common.Hash
+	for _, kv := range writes {
+		kvhash = common.GenerateKVHash(kv[0], kv[1], kvhash)
+	}
+	if bytes.Compare(ch.db.GetLastKVHash(recognizedHash), kvhash.Bytes()) != 0 {
+		t.Error("kv hash of the writes must be same", kvhash)
+	}
+	if ch.db.GetBlockWrites(generateHash("unknown")) != nil {
+		t.Error("writes of an unknown block must be nil")
+	}
+}
+
 func TestSnapshotDB_BaseNum(t *testing.T) {
 	ch := newTestchain(dbpath)
 	defer ch.clear()
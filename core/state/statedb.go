@@ -365,6 +365,21 @@ func (s *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byte,
 	return [][]byte(proof), err
 }
 
+// GetRawStorageProof returns the StorageProof for the given raw storage key,
+// the slots of the storage trie are keyed by the hash of the raw key. Unlike
+// StorageTrie, the storage trie is opened if no slot of the account is loaded yet.
+func (s *StateDB) GetRawStorageProof(a common.Address, key []byte) ([][]byte, error) {
+	var proof proofList
+	stateObject := s.getStateObject(a)
+	if stateObject == nil {
+		return proof, errors.New("storage trie for requested address does not exist")
+	}
+	cpy := stateObject.deepCopy(s)
+	cpy.updateTrie(s.db)
+	err := cpy.getTrie(s.db).Prove(crypto.Keccak256(key), 0, &proof)
+	return [][]byte(proof), err
+}
+
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (s *StateDB) GetCommittedState(addr common.Address, key []byte) []byte {
 	stateObject := s.getStateObject(addr)
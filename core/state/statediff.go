@@ -0,0 +1,49 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/PlatONnetwork/PlatON-Go/common"
+)
+
+// Modified returns the accounts changed since the last finalisation, together
+// with the storage keys they changed.
+func (s *StateDB) Modified() map[common.Address][][]byte {
+	var (
+		modified = make(map[common.Address][][]byte, len(s.journal.dirties))
+		seen     = make(map[common.Address]map[string]struct{})
+	)
+	for addr := range s.journal.dirties {
+		modified[addr] = nil
+	}
+	for _, entry := range s.journal.entries {
+		change, ok := entry.(storageChange)
+		if !ok {
+			continue
+		}
+		addr := *change.account
+		if seen[addr] == nil {
+			seen[addr] = make(map[string]struct{})
+		}
+		if _, ok := seen[addr][string(change.key)]; ok {
+			continue
+		}
+		seen[addr][string(change.key)] = struct{}{}
+		modified[addr] = append(modified[addr], change.key)
+	}
+	return modified
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+)
+
+// sortedKeys returns the storage keys as sorted strings.
+func sortedKeys(keys [][]byte) []string {
+	sorted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, string(key))
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Tests that the changes since the last finalisation are reported once.
+func TestStateModified(t *testing.T) {
+	statedb, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+
+	var (
+		a = common.Address{1}
+		b = common.Address{2}
+	)
+	statedb.AddBalance(a, big.NewInt(1))
+	statedb.SetState(b, []byte("x"), []byte("1"))
+	statedb.SetState(b, []byte("x"), []byte("2"))
+	statedb.SetState(b, []byte("y"), []byte("1"))
+
+	modified := statedb.Modified()
+	if len(modified) != 2 {
+		t.Fatalf("modified accounts mismatch: have %d, want 2", len(modified))
+	}
+	if keys := modified[a]; len(keys) != 0 {
+		t.Errorf("keys of %x mismatch: have %q, want none", a, keys)
+	}
+	if keys := sortedKeys(modified[b]); len(keys) != 2 || keys[0] != "x" || keys[1] != "y" {
+		t.Errorf("keys of %x mismatch: have %q, want [x y]", b, keys)
+	}
+	statedb.Finalise(true)
+	if modified := statedb.Modified(); len(modified) != 0 {
+		t.Errorf("modified accounts after finalisation: %v", modified)
+	}
+}
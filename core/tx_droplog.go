@@ -0,0 +1,129 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+)
+
+// The reasons of the transactions dropped by the pool. Transactions leaving
+// the pool because they were included in a block are not dropped.
+const (
+	DropReasonUnderpriced  = "underpriced"   // Evicted by better priced transactions or below the price limit
+	DropReasonReplaced     = "replaced"      // Replaced by a transaction with the same nonce
+	DropReasonExpired      = "expired"       // Queued for longer than the pool lifetime
+	DropReasonUnpayable    = "unpayable"     // Balance or block gas limit too low
+	DropReasonAccountLimit = "account limit" // Above the queued slots of the account
+	DropReasonPoolLimit    = "pool limit"    // Above the pending or queued slots of the pool
+)
+
+// dropLogLimit is the number of dropped transactions the pool remembers.
+const dropLogLimit = 4096
+
+// DroppedTx describes a transaction dropped by the pool.
+type DroppedTx struct {
+	Hash       common.Hash
+	From       common.Address
+	Nonce      uint64
+	Reason     string
+	ReplacedBy *common.Hash
+	Time       time.Time
+}
+
+// txDropLog remembers the latest dropped transactions, forgetting the oldest
+// ones once full, and buffers the drops not yet announced.
+type txDropLog struct {
+	limit  int
+	drops  map[common.Hash]*DroppedTx
+	order  []common.Hash // Hashes in drop order, the oldest first
+	unsent []*DroppedTx  // Drops not yet announced to the subscribers
+	lock   sync.Mutex
+}
+
+// newTxDropLog creates a drop log remembering up to limit transactions.
+func newTxDropLog(limit int) *txDropLog {
+	return &txDropLog{
+		limit: limit,
+		drops: make(map[common.Hash]*DroppedTx),
+	}
+}
+
+// add records a dropped transaction.
+func (l *txDropLog) add(drop *DroppedTx) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.drops[drop.Hash]; !ok {
+		if len(l.order) >= l.limit {
+			delete(l.drops, l.order[0])
+			l.order = l.order[1:]
+		}
+		l.order = append(l.order, drop.Hash)
+	}
+	l.drops[drop.Hash] = drop
+	l.unsent = append(l.unsent, drop)
+}
+
+// get returns the drop of the transaction, nil if it's unknown.
+func (l *txDropLog) get(hash common.Hash) *DroppedTx {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.drops[hash]
+}
+
+// forget removes the transaction from the log, it entered the pool again.
+func (l *txDropLog) forget(hash common.Hash) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.drops[hash]; !ok {
+		return
+	}
+	delete(l.drops, hash)
+	for i, h := range l.order {
+		if h == hash {
+			l.order = append(l.order[:i], l.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// list returns the remembered drops, the oldest first.
+func (l *txDropLog) list() []*DroppedTx {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	drops := make([]*DroppedTx, 0, len(l.order))
+	for _, hash := range l.order {
+		drops = append(drops, l.drops[hash])
+	}
+	return drops
+}
+
+// flush returns and clears the drops not yet announced.
+func (l *txDropLog) flush() []*DroppedTx {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	drops := l.unsent
+	l.unsent = nil
+	return drops
+}
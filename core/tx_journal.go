@@ -44,6 +44,7 @@ func (*devNull) Close() error                      { return nil }
 // created transactions to allow non-executed ones to survive node restarts.
 type txJournal struct {
 	path   string         // Filesystem path to store the transactions at
+	kind   string         // Kind of the journaled transactions, local or remote
 	writer io.WriteCloser // Output stream to write new transactions into
 }
 
@@ -51,6 +52,16 @@ type txJournal struct {
 func newTxJournal(path string) *txJournal {
 	return &txJournal{
 		path: path,
+		kind: "local",
+	}
+}
+
+// newRemoteTxJournal creates a journal snapshotting the remote transactions of
+// the pool. Transactions aren't inserted into it, it's only rewritten.
+func newRemoteTxJournal(path string) *txJournal {
+	return &txJournal{
+		path: path,
+		kind: "remote",
 	}
 }
 
@@ -111,7 +122,7 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 			batch = batch[:0]
 		}
 	}
-	log.Info("Loaded local transaction journal", "transactions", total, "dropped", dropped)
+	log.Info("Loaded "+journal.kind+" transaction journal", "transactions", total, "dropped", dropped)
 
 	return failure
 }
@@ -137,6 +148,19 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		}
 		journal.writer = nil
 	}
+	if err := journal.write(all); err != nil {
+		return err
+	}
+	sink, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND, 0755)
+	if err != nil {
+		return err
+	}
+	journal.writer = sink
+	return nil
+}
+
+// write replaces the journal on disk with the given transactions.
+func (journal *txJournal) write(all map[common.Address]types.Transactions) error {
 	// Generate a new journal with the contents of the current pool
 	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
 	if err != nil {
@@ -158,13 +182,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 	if err = os.Rename(journal.path+".new", journal.path); err != nil {
 		return err
 	}
-	sink, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND, 0755)
-	if err != nil {
-		return err
-	}
-	journal.writer = sink
-	log.Info("Regenerated local transaction journal", "transactions", journaled, "accounts", len(all))
-
+	log.Info("Regenerated "+journal.kind+" transaction journal", "transactions", journaled, "accounts", len(all))
 	return nil
 }
 
@@ -69,6 +69,10 @@ var (
 	// ErrInvalidSender is returned if the transaction contains an invalid signature.
 	ErrInvalidSender = errors.New("invalid sender")
 
+	// errAccountLimit is returned if a journaled remote transaction is reloaded
+	// past the slots the pool allows its account.
+	errAccountLimit = errors.New("account transaction limit exceeded")
+
 	// ErrNonceTooLow is returned if the nonce of a transaction is lower than the
 	// one present in the local chain.
 	ErrNonceTooLow = errors.New("nonce too low")
@@ -195,6 +199,8 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	RemoteJournal string // Journal of remote transactions to survive node restarts, disabled if empty
+
 	PriceBump uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
 	AccountSlots  uint64 // Number of executable transaction slots guaranteed per account
@@ -275,6 +281,7 @@ type TxPool struct {
 	chain    txPoolBlockChain
 	gasPrice *big.Int
 	txFeed   event.Feed
+	dropFeed event.Feed
 	scope    event.SubscriptionScope
 
 	signer types.Signer
@@ -287,6 +294,10 @@ type TxPool struct {
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
 
+	remoteJournal *txJournal // Journal of remote transactions to back up to disk
+
+	dropLog *txDropLog // Latest transactions dropped from the pool
+
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
@@ -340,6 +351,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain txPoo
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
 		all:         newTxLookup(),
+		dropLog:     newTxDropLog(dropLogLimit),
 
 		gasPrice:  new(big.Int),
 		resetHead: chain.CurrentBlock(),
@@ -377,6 +389,14 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain txPoo
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, reload the remotes of the last run
+	if config.RemoteJournal != "" {
+		pool.remoteJournal = newRemoteTxJournal(config.RemoteJournal)
+
+		if err := pool.remoteJournal.load(pool.journaledRemotesAdder()); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+	}
 
 	// Start the event loop and return
 	pool.wg.Add(1)
@@ -445,11 +465,13 @@ func (pool *TxPool) loop() {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true)
+						pool.dropTx(tx, DropReasonExpired, nil)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
 			pool.mu.Unlock()
+			pool.sendDrops()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -460,6 +482,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remoteJournal != nil {
+				pool.mu.RLock()
+				if err := pool.remoteJournal.write(pool.remote()); err != nil {
+					log.Warn("Failed to write remote tx journal", "err", err)
+				}
+				pool.mu.RUnlock()
+			}
 		}
 	}
 }
@@ -488,6 +517,8 @@ func (pool *TxPool) ForkedReset(newHeader *types.Header, rollback []*types.Block
 		return
 	}
 	log.Warn("Reset rollback block", "hash", newHeader.Hash(), "number", newHeader.Number.Uint64(), "rollback", len(rollback))
+	defer pool.sendDrops()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -550,6 +581,13 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.remoteJournal != nil {
+		pool.mu.RLock()
+		if err := pool.remoteJournal.write(pool.remote()); err != nil {
+			log.Warn("Failed to write remote tx journal", "err", err)
+		}
+		pool.mu.RUnlock()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -559,6 +597,51 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxsEvent registers a subscription of DroppedTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeDroppedTxsEvent(ch chan<- DroppedTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// Dropped returns why the transaction was dropped from the pool, nil if it
+// isn't among the latest dropped transactions.
+func (pool *TxPool) Dropped(hash common.Hash) *DroppedTx {
+	return pool.dropLog.get(hash)
+}
+
+// DropLog returns the latest transactions dropped from the pool, the oldest
+// first.
+func (pool *TxPool) DropLog() []*DroppedTx {
+	return pool.dropLog.list()
+}
+
+// dropTx records the transaction as dropped for the reason, the replacement
+// being the transaction which took its nonce if any.
+func (pool *TxPool) dropTx(tx *types.Transaction, reason string, replacement *types.Transaction) {
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	drop := &DroppedTx{
+		Hash:   tx.Hash(),
+		From:   from,
+		Nonce:  tx.Nonce(),
+		Reason: reason,
+		Time:   time.Now(),
+	}
+	if replacement != nil {
+		hash := replacement.Hash()
+		drop.ReplacedBy = &hash
+	}
+	pool.dropLog.add(drop)
+}
+
+// sendDrops announces the transactions dropped since the last call.
+//
+// Note, this method must be called without the pool lock held!
+func (pool *TxPool) sendDrops() {
+	if drops := pool.dropLog.flush(); len(drops) > 0 {
+		pool.dropFeed.Send(DroppedTxsEvent{drops})
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -570,12 +653,15 @@ func (pool *TxPool) GasPrice() *big.Int {
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
+	defer pool.sendDrops()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
 		pool.removeTx(tx.Hash(), false)
+		pool.dropTx(tx, DropReasonUnderpriced, nil)
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
@@ -629,6 +715,23 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool, returning the
+// pending as well as queued transactions of this address, sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var pending types.Transactions
+	if list, ok := pool.pending[addr]; ok {
+		pending = list.Flatten()
+	}
+	var queued types.Transactions
+	if list, ok := pool.queue[addr]; ok {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -688,6 +791,59 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves all currently known remote transactions, grouped by origin
+// account and sorted by nonce, the pending ones first.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, pending := range pool.pending {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], pending.Flatten()...)
+		}
+	}
+	for addr, queued := range pool.queue {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], queued.Flatten()...)
+		}
+	}
+	return txs
+}
+
+// journaledRemotesAdder returns the function adding the remote transactions
+// reloaded from the journal. They are revalidated against the current head and
+// only the lowest nonces fitting in the executable and non-executable slots of
+// each account are kept.
+func (pool *TxPool) journaledRemotesAdder() func([]*types.Transaction) []error {
+	var (
+		limit  = pool.config.AccountSlots + pool.config.AccountQueue
+		counts = make(map[common.Address]uint64)
+	)
+	return func(txs []*types.Transaction) []error {
+		var (
+			errs  = make([]error, len(txs))
+			kept  = make([]*types.Transaction, 0, len(txs))
+			index = make([]int, 0, len(txs))
+		)
+		for i, tx := range txs {
+			from, err := types.Sender(pool.signer, tx)
+			if err != nil {
+				errs[i] = ErrInvalidSender
+				continue
+			}
+			if counts[from] >= limit {
+				errs[i] = errAccountLimit
+				continue
+			}
+			counts[from]++
+			kept = append(kept, tx)
+			index = append(index, i)
+		}
+		for i, err := range pool.AddRemotesSync(kept) {
+			errs[index[i]] = err
+		}
+		return errs
+	}
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -791,6 +947,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			}
 			underpricedTxMeter.Mark(1)
 			pool.removeTx(tx.Hash(), false)
+			pool.dropTx(tx, DropReasonUnderpriced, nil)
 
 			//Prevent some transactions that can be packaged from being deleted,Then  cannot enter the trading pool within 8s
 			pool.knowns.Delete(tx.Hash())
@@ -810,7 +967,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.dropTx(old, DropReasonReplaced, tx)
 		}
+		pool.dropLog.forget(hash)
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
 		pool.journalTx(from, tx)
@@ -827,6 +986,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.dropLog.forget(hash)
 	// Mark local addresses and journal local transactions
 	if local {
 		if !pool.locals.contains(from) {
@@ -865,6 +1025,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.dropTx(old, DropReasonReplaced, tx)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -905,6 +1066,7 @@ func (pool *TxPool) promoteTx(addr common.Address, list *txList, hash common.Has
 		pool.priced.Removed(1)
 
 		pendingDiscardMeter.Mark(1)
+		pool.dropTx(tx, DropReasonUnderpriced, nil)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -913,6 +1075,7 @@ func (pool *TxPool) promoteTx(addr common.Address, list *txList, hash common.Has
 		pool.priced.Removed(1)
 
 		pendingReplaceMeter.Mark(1)
+		pool.dropTx(old, DropReasonReplaced, tx)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -1044,6 +1207,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		}
 	}
 	pool.mu.Unlock()
+	pool.sendDrops()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -1312,6 +1476,9 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	// Update all accounts to the latest known pending nonce
 	pool.mu.Unlock()
 
+	// Notify subsystems for dropped transactions
+	pool.sendDrops()
+
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
 		addr, _ := types.Sender(pool.signer, tx)
@@ -1472,6 +1639,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.dropTx(tx, DropReasonUnpayable, nil)
 		}
 		if log.GetWasmLogLevel() == log.LvlTrace {
 			log.Trace("Removed unpayable queued transactions", "count", len(drops))
@@ -1507,6 +1675,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
+				pool.dropTx(tx, DropReasonAccountLimit, nil)
 				if log.GetWasmLogLevel() == log.LvlTrace {
 					log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 				}
@@ -1573,6 +1742,7 @@ func (pool *TxPool) truncatePending() {
 						hash := tx.Hash()
 						pool.all.Remove(hash)
 						pool.knowns.Delete(hash)
+						pool.dropTx(tx, DropReasonPoolLimit, nil)
 
 						// Update the account nonce to the dropped transaction
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
@@ -1603,6 +1773,7 @@ func (pool *TxPool) truncatePending() {
 					hash := tx.Hash()
 					pool.all.Remove(hash)
 					pool.knowns.Delete(hash)
+					pool.dropTx(tx, DropReasonPoolLimit, nil)
 
 					// Update the account nonce to the dropped transaction
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1653,6 +1824,7 @@ func (pool *TxPool) truncateQueue() {
 			for _, tx := range list.Flatten() {
 				pool.removeTx(tx.Hash(), true)
 				pool.knowns.Delete(tx.Hash())
+				pool.dropTx(tx, DropReasonPoolLimit, nil)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1663,6 +1835,7 @@ func (pool *TxPool) truncateQueue() {
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
 			pool.knowns.Delete(txs[i].Hash())
+			pool.dropTx(txs[i], DropReasonPoolLimit, nil)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1694,6 +1867,7 @@ func (pool *TxPool) demoteUnexecutables() {
 				log.Trace("Removed unpayable pending transaction", "hash", hash)
 			}
 			pool.all.Remove(hash)
+			pool.dropTx(tx, DropReasonUnpayable, nil)
 		}
 		pool.priced.Removed(len(olds) + len(drops))
 		pendingNofundsMeter.Mark(int64(len(drops)))
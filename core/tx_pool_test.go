@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -1618,6 +1619,110 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that replaced and evicted transactions are recorded in the drop log with
+// their reason and announced to the subscribers.
+func TestTransactionDropLog(t *testing.T) {
+	t.Parallel()
+
+	pool := newTestTxPool(testTxPoolConfig, params.TestChainConfig)
+	defer pool.Stop()
+
+	drops := make(chan DroppedTxsEvent, 32)
+	sub := pool.SubscribeDroppedTxsEvent(drops)
+	defer sub.Unsubscribe()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	// Replace a pending and a queued transaction
+	pending := pricedTransaction(0, 100000, big.NewInt(1), key, pool.chainconfig.ChainID)
+	pendingBump := pricedTransaction(0, 100000, big.NewInt(2), key, pool.chainconfig.ChainID)
+	queued := pricedTransaction(2, 100000, big.NewInt(1), key, pool.chainconfig.ChainID)
+	queuedBump := pricedTransaction(2, 100000, big.NewInt(2), key, pool.chainconfig.ChainID)
+
+	for i, tx := range []*types.Transaction{pending, queued, pendingBump, queuedBump} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	for _, test := range []struct {
+		tx, by *types.Transaction
+	}{{pending, pendingBump}, {queued, queuedBump}} {
+		drop := pool.Dropped(test.tx.Hash())
+		if drop == nil {
+			t.Fatalf("replaced transaction %x missing from the drop log", test.tx.Hash())
+		}
+		if drop.Reason != DropReasonReplaced || drop.From != from || drop.Nonce != test.tx.Nonce() {
+			t.Errorf("drop mismatch: have %s %x %d, want %s %x %d", drop.Reason, drop.From, drop.Nonce, DropReasonReplaced, from, test.tx.Nonce())
+		}
+		if drop.ReplacedBy == nil || *drop.ReplacedBy != test.by.Hash() {
+			t.Errorf("replacement mismatch: have %v, want %x", drop.ReplacedBy, test.by.Hash())
+		}
+	}
+	// Overflow the queue of the account
+	for i := uint64(3); i < 3+testTxPoolConfig.AccountQueue; i++ {
+		if err := pool.addRemoteSync(transaction(i, 100000, key, pool.chainconfig.ChainID)); err != nil {
+			t.Fatalf("failed to add queued transaction %d: %v", i, err)
+		}
+	}
+	capped := transaction(2+testTxPoolConfig.AccountQueue, 100000, key, pool.chainconfig.ChainID)
+	if drop := pool.Dropped(capped.Hash()); drop == nil || drop.Reason != DropReasonAccountLimit {
+		t.Fatalf("capped transaction drop mismatch: have %v, want %s", drop, DropReasonAccountLimit)
+	}
+	if logged := pool.DropLog(); len(logged) != 3 {
+		t.Fatalf("drop log size mismatch: have %d, want %d", len(logged), 3)
+	}
+	// All the drops should have been announced
+	var announced []*DroppedTx
+	for len(announced) < 3 {
+		select {
+		case ev := <-drops:
+			announced = append(announced, ev.Drops...)
+		case <-time.After(time.Second):
+			t.Fatalf("drop events missing: have %d, want %d", len(announced), 3)
+		}
+	}
+	if len(announced) != 3 || announced[0].Hash != pending.Hash() || announced[1].Hash != queued.Hash() || announced[2].Hash != capped.Hash() {
+		t.Fatalf("announced drops mismatch")
+	}
+	// The content of the account shouldn't contain the dropped ones
+	pendingTxs, queuedTxs := pool.ContentFrom(from)
+	if len(pendingTxs) != 1 || pendingTxs[0].Hash() != pendingBump.Hash() {
+		t.Errorf("pending content mismatch: have %d transactions, want %d", len(pendingTxs), 1)
+	}
+	if len(queuedTxs) != int(testTxPoolConfig.AccountQueue) {
+		t.Errorf("queued content mismatch: have %d transactions, want %d", len(queuedTxs), testTxPoolConfig.AccountQueue)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the drop log forgets the oldest drops once full.
+func TestTxDropLogLimit(t *testing.T) {
+	dropLog := newTxDropLog(2)
+	for i := byte(1); i <= 3; i++ {
+		dropLog.add(&DroppedTx{Hash: common.Hash{i}, Reason: DropReasonUnderpriced})
+	}
+	if dropLog.get(common.Hash{1}) != nil {
+		t.Errorf("oldest drop not forgotten")
+	}
+	if drops := dropLog.list(); len(drops) != 2 || drops[0].Hash != (common.Hash{2}) || drops[1].Hash != (common.Hash{3}) {
+		t.Errorf("drop log content mismatch")
+	}
+	if unsent := dropLog.flush(); len(unsent) != 3 {
+		t.Errorf("unsent drops mismatch: have %d, want %d", len(unsent), 3)
+	}
+	if unsent := dropLog.flush(); len(unsent) != 0 {
+		t.Errorf("unsent drops not cleared: have %d", len(unsent))
+	}
+	dropLog.forget(common.Hash{2})
+	if drops := dropLog.list(); len(drops) != 1 || drops[0].Hash != (common.Hash{3}) {
+		t.Errorf("forgotten drop still logged")
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -1729,6 +1834,71 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the remote transactions are journaled on shutdown and reloaded on
+// restart, revalidated against the new head and capped per account.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testTxPoolConfig
+	config.RemoteJournal = filepath.Join(dir, "remotes.rlp")
+	config.AccountSlots = 2
+	config.AccountQueue = 2
+
+	pool := newTestTxPool(config, params.TestChainConfig)
+
+	local, _ := crypto.GenerateKey()
+	remoteA, _ := crypto.GenerateKey()
+	remoteB, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{local, remoteA, remoteB} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	if err := pool.AddLocal(transaction(0, 100000, local, pool.chainconfig.ChainID)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	// Five executable transactions of the first remote and two queued ones of the second
+	var txs types.Transactions
+	for nonce := uint64(0); nonce < 5; nonce++ {
+		txs = append(txs, transaction(nonce, 100000, remoteA, pool.chainconfig.ChainID))
+	}
+	txs = append(txs, transaction(2, 100000, remoteB, pool.chainconfig.ChainID))
+	txs = append(txs, transaction(3, 100000, remoteB, pool.chainconfig.ChainID))
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 6 || queued != 2 {
+		t.Fatalf("transactions mismatched: have %d pending %d queued, want 6 pending 2 queued", pending, queued)
+	}
+	pool.Stop()
+
+	// Include the first transaction of the remote and restart, only the four
+	// lowest nonces of the remote fit its slots and the first is stale.
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(remoteA.PublicKey), 1)
+	blockchain := &testBlockChain{pool.currentState, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 3 || queued != 2 {
+		t.Fatalf("transactions mismatched: have %d pending %d queued, want 3 pending 2 queued", pending, queued)
+	}
+	if pool.Nonce(crypto.PubkeyToAddress(remoteA.PublicKey)) != 4 {
+		t.Errorf("remote pending nonce mismatch: have %d, want 4", pool.Nonce(crypto.PubkeyToAddress(remoteA.PublicKey)))
+	}
+	if pool.Nonce(crypto.PubkeyToAddress(local.PublicKey)) != 0 {
+		t.Error("local transaction journaled as remote")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
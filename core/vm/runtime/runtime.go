@@ -75,6 +75,9 @@ func setDefaults(cfg *Config) {
 	if cfg.Value == nil {
 		cfg.Value = new(big.Int)
 	}
+	if cfg.EVMConfig.WasmType == vm.Unknown {
+		cfg.EVMConfig.WasmType = vm.Wagon
+	}
 	if cfg.BlockNumber == nil {
 		cfg.BlockNumber = new(big.Int)
 	}
@@ -104,7 +107,9 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 		vmenv   = NewEnv(cfg)
 		sender  = vm.AccountRef(cfg.Origin)
 	)
-	vmenv.Context.Ctx = context.TODO()
+	cancel := setContext(vmenv)
+	defer cancel()
+
 	cfg.State.CreateAccount(address)
 	// set the receiver's (the executing contract) code for execution.
 	cfg.State.SetCode(address, code)
@@ -134,6 +139,8 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 		vmenv  = NewEnv(cfg)
 		sender = vm.AccountRef(cfg.Origin)
 	)
+	cancel := setContext(vmenv)
+	defer cancel()
 
 	// Call the code with the given configuration.
 	code, address, leftOverGas, err := vmenv.Create(
@@ -154,6 +161,8 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 	setDefaults(cfg)
 
 	vmenv := NewEnv(cfg)
+	cancel := setContext(vmenv)
+	defer cancel()
 
 	sender := cfg.State.GetOrNewStateObject(cfg.Origin)
 	// Call the code with the given configuration.
@@ -167,3 +176,11 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 
 	return ret, leftOverGas, err
 }
+
+// setContext sets the context both interpreters watch to abort the execution,
+// the returned function releases it once the execution is over.
+func setContext(vmenv *vm.EVM) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	vmenv.Context.Ctx = ctx
+	return cancel
+}
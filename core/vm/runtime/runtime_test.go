@@ -18,6 +18,7 @@ package runtime
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"strings"
@@ -29,8 +30,10 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
 
 	"github.com/PlatONnetwork/PlatON-Go/accounts/abi"
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/wasm"
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/core/vm"
 )
@@ -123,6 +126,36 @@ func TestCall(t *testing.T) {
 	}*/
 }
 
+// Tests that WASM contracts can be created and called without configuring the
+// WASM engine.
+func TestWasm(t *testing.T) {
+	code, err := ioutil.ReadFile("../testdata/contract_hello.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	init, _ := rlp.EncodeToBytes([]interface{}{wasm.FuncID(wasm.InitMethod)})
+	deploy, _ := rlp.EncodeToBytes([][]byte{code, init})
+
+	cfg := &Config{GasLimit: 100000000}
+	cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	_, address, _, err := Create(append(wasm.Magic, deploy...), cfg)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	input, _ := rlp.EncodeToBytes([]interface{}{wasm.FuncID("get_vector_size")})
+	ret, leftOverGas, err := Call(address, input, cfg)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	var size uint64
+	if err := rlp.DecodeBytes(ret, &size); err != nil || size != 0 {
+		t.Errorf("return value mismatch: have %x (%v), want 0", ret, err)
+	}
+	if leftOverGas >= cfg.GasLimit {
+		t.Error("no gas used")
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -1,9 +1,5 @@
 package bls
 
-/*
-#include <bls/bls.h>
-*/
-import "C"
 import (
 	"bytes"
 	"encoding/hex"
@@ -20,32 +16,11 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 )
 
-// Init --
-// call this function before calling all the other operations
-// this function is not thread safe
-func Init(curve int) error {
-	err := C.blsInit(C.int(curve), C.MCLBN_COMPILED_TIME_VAR)
-	if err != 0 {
-		return fmt.Errorf("ERR Init curve=%d", curve)
-	}
-	err = C.mclBn_init(C.int(curve), C.MCLBN_COMPILED_TIME_VAR)
-	if err != 0 {
-		return fmt.Errorf("ERR mclBn_init curve=%d", curve)
-	}
-	return nil
-}
-
 // ID --
 type ID struct {
 	v Fr
 }
 
-// getPointer --
-func (id *ID) getPointer() (p *C.blsId) {
-	// #nosec
-	return (*C.blsId)(unsafe.Pointer(id))
-}
-
 // GetLittleEndian --
 func (id *ID) GetLittleEndian() []byte {
 	return id.v.Serialize()
@@ -126,12 +101,6 @@ func (sec *SecretKey) Deserialize(buf []byte) error {
 	return sec.v.Deserialize(buf)
 }
 
-// getPointer --
-func (sec *SecretKey) getPointer() (p *C.blsSecretKey) {
-	// #nosec
-	return (*C.blsSecretKey)(unsafe.Pointer(sec))
-}
-
 // GetLittleEndian --
 func (sec *SecretKey) GetLittleEndian() []byte {
 	return sec.v.Serialize()
@@ -219,13 +188,6 @@ func (sec *SecretKey) Recover(secVec []SecretKey, idVec []ID) error {
 	return FrLagrangeInterpolation(&sec.v, *(*[]Fr)(unsafe.Pointer(&idVec)), *(*[]Fr)(unsafe.Pointer(&secVec)))
 }
 
-// GetPop --
-func (sec *SecretKey) GetPop() (sign *Sign) {
-	sign = new(Sign)
-	C.blsGetPop(sign.getPointer(), sec.getPointer())
-	return sign
-}
-
 // PublicKey --
 type PublicKey struct {
 	v G2
@@ -277,17 +239,6 @@ func (pe *PublicKeyHex) ParseBlsPubKey() (*PublicKey, error) {
 	return &blsPk, nil
 }
 
-func (pub *PublicKey) getQ() (p *C.blsPublicKey) {
-	// #nosec
-	return (*C.blsPublicKey)(unsafe.Pointer(pub))
-}
-
-// getPointer --
-func (pub *PublicKey) getPointer() (p *C.blsPublicKey) {
-	// #nosec
-	return (*C.blsPublicKey)(unsafe.Pointer(pub))
-}
-
 // Serialize --
 func (pub *PublicKey) Serialize() []byte {
 	return pub.v.Serialize()
@@ -363,12 +314,6 @@ type Sign struct {
 	v G1
 }
 
-// getPointer --
-func (sign *Sign) getPointer() (p *C.blsSignature) {
-	// #nosec
-	return (*C.blsSignature)(unsafe.Pointer(sign))
-}
-
 // Serialize --
 func (sign *Sign) Serialize() []byte {
 	return sign.v.Serialize()
@@ -394,59 +339,12 @@ func (sign *Sign) IsEqual(rhs *Sign) bool {
 	return sign.v.IsEqual(&rhs.v)
 }
 
-// GetPublicKey --
-func (sec *SecretKey) GetPublicKey() (pub *PublicKey) {
-	pub = new(PublicKey)
-	C.blsGetPublicKey(pub.getPointer(), sec.getPointer())
-	return pub
-}
-
-// Sign -- Constant Time version
-func (sec *SecretKey) Sign(m string) (sign *Sign) {
-	sign = new(Sign)
-	buf := []byte(m)
-	// #nosec
-	C.blsSign(sign.getPointer(), sec.getPointer(), unsafe.Pointer(&buf[0]), C.size_t(len(buf)))
-	return sign
-}
-
-// Add --
-func (sign *Sign) Add(rhs *Sign) {
-	C.blsSignatureAdd(sign.getPointer(), rhs.getPointer())
-}
-
 // Recover --
 func (sign *Sign) Recover(signVec []Sign, idVec []ID) error {
 	// #nosec
 	return G1LagrangeInterpolation(&sign.v, *(*[]Fr)(unsafe.Pointer(&idVec)), *(*[]G1)(unsafe.Pointer(&signVec)))
 }
 
-// Verify --
-func (sign *Sign) Verify(pub *PublicKey, m string) bool {
-	buf := []byte(m)
-	// #nosec
-	return C.blsVerify(sign.getPointer(), pub.getPointer(), unsafe.Pointer(&buf[0]), C.size_t(len(buf))) == 1
-}
-
-// VerifyPop --
-func (sign *Sign) VerifyPop(pub *PublicKey) bool {
-	return C.blsVerifyPop(sign.getPointer(), pub.getPointer()) == 1
-}
-
-// DHKeyExchange --
-func DHKeyExchange(sec *SecretKey, pub *PublicKey) (out PublicKey) {
-	C.blsDHKeyExchange(out.getPointer(), sec.getPointer(), pub.getPointer())
-	return out
-}
-
-//add@20190716
-//get G2
-func GetGeneratorOfG2() (pub *PublicKey) {
-	pub = new(PublicKey)
-	C.blsGetGeneratorOfPublicKey(pub.getPointer())
-	return pub
-}
-
 // PubBatchAdd --
 func PubkeyBatchAdd(pkVec []PublicKey) (pub PublicKey) {
 	var pk PublicKey
@@ -592,11 +490,6 @@ func SynthSameMsg(curve int, pkVec []PublicKey, mVec []string) ([]PublicKey, []s
 	return newPkVec, newMVec, index, nil
 }
 
-// IsValid --
-func G2IsValid(rhs *PublicKey) bool {
-	return C.mclBnG2_isValid((&rhs.v).getPointer()) == 1
-}
-
 func Schnorr_test(curve int, r, c SecretKey, G, V, P PublicKey) error {
 	err := Init(curve)
 	if err != nil {
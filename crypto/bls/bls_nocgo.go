@@ -0,0 +1,103 @@
+//go:build !cgo || blsgo
+// +build !cgo blsgo
+
+package bls
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls12381"
+)
+
+var (
+	generatorOnce sync.Once
+	generatorQ    G2
+)
+
+// getQ returns the generator of G2 used for public keys, like the cgo
+// backend it is the image of 1 under the map to G2.
+func getQ() *G2 {
+	generatorOnce.Do(func() {
+		p, err := mapToG2(fp2{big.NewInt(1), new(big.Int)})
+		if err != nil {
+			panic(err)
+		}
+		generatorQ.v = *p
+	})
+	return &generatorQ
+}
+
+// Init --
+// only BLS12_381 is supported by the pure Go backend
+func Init(curve int) error {
+	if curve != BLS12_381 {
+		return fmt.Errorf("ERR Init curve=%d", curve)
+	}
+	getQ()
+	return nil
+}
+
+// GetPop --
+func (sec *SecretKey) GetPop() (sign *Sign) {
+	return sec.Sign(string(sec.GetPublicKey().Serialize()))
+}
+
+// GetPublicKey --
+func (sec *SecretKey) GetPublicKey() (pub *PublicKey) {
+	pub = new(PublicKey)
+	G2Mul(&pub.v, getQ(), &sec.v)
+	return pub
+}
+
+// Sign --
+func (sec *SecretKey) Sign(m string) (sign *Sign) {
+	sign = new(Sign)
+	if err := sign.v.HashAndMapTo([]byte(m)); err != nil {
+		panic(err)
+	}
+	G1Mul(&sign.v, &sign.v, &sec.v)
+	return sign
+}
+
+// Add --
+func (sign *Sign) Add(rhs *Sign) {
+	G1Add(&sign.v, &sign.v, &rhs.v)
+}
+
+// Verify --
+// checks e(sign, Q) == e(H(m), pub)
+func (sign *Sign) Verify(pub *PublicKey, m string) bool {
+	var hm G1
+	if err := hm.HashAndMapTo([]byte(m)); err != nil {
+		return false
+	}
+	s, q, pk := sign.v.v, getQ().v, pub.v.v
+	return bls12381.NewPairingEngine().AddPair(&s, &q).AddPairInv(&hm.v, &pk).Check()
+}
+
+// VerifyPop --
+func (sign *Sign) VerifyPop(pub *PublicKey) bool {
+	return sign.Verify(pub, string(pub.Serialize()))
+}
+
+// DHKeyExchange --
+func DHKeyExchange(sec *SecretKey, pub *PublicKey) (out PublicKey) {
+	G2Mul(&out.v, &pub.v, &sec.v)
+	return out
+}
+
+// GetGeneratorOfG2 --
+func GetGeneratorOfG2() (pub *PublicKey) {
+	pub = new(PublicKey)
+	pub.v = *getQ()
+	return pub
+}
+
+// G2IsValid --
+// Deserialize only checks that a point is on the curve, the subgroup
+// check is left to this function as it is with the cgo backend.
+func G2IsValid(rhs *PublicKey) bool {
+	return rhs.v.isValid()
+}
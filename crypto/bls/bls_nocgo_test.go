@@ -0,0 +1,284 @@
+//go:build !cgo || blsgo
+// +build !cgo blsgo
+
+package bls
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// The pure Go backend only implements BLS12_381, the tests of the mcl
+// backend that run over the BN curves are mirrored here for that curve.
+
+func initNocgo(t *testing.T) {
+	if err := Init(BLS12_381); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNocgoInit(t *testing.T) {
+	for _, c := range []int{CurveFp254BNb, CurveFp382_1, CurveFp382_2} {
+		if err := Init(c); err == nil {
+			t.Errorf("curve %d should not be supported", c)
+		}
+	}
+	initNocgo(t)
+}
+
+func TestNocgoSignVerify(t *testing.T) {
+	initNocgo(t)
+	var sec SecretKey
+	sec.SetByCSPRNG()
+	pub := sec.GetPublicKey()
+	for i := 0; i < 5; i++ {
+		m := fmt.Sprintf("hello%d", i)
+		sign := sec.Sign(m)
+		if !sign.Verify(pub, m) {
+			t.Fatalf("signature of %q does not verify", m)
+		}
+		if sign.Verify(pub, m+"x") {
+			t.Fatalf("signature of %q verifies another message", m)
+		}
+	}
+	var other SecretKey
+	other.SetByCSPRNG()
+	if sec.Sign("abc").Verify(other.GetPublicKey(), "abc") {
+		t.Fatal("signature verifies with another key")
+	}
+}
+
+func TestNocgoShareRecover(t *testing.T) {
+	initNocgo(t)
+	const k, n = 3, 5
+	var sec SecretKey
+	sec.SetByCSPRNG()
+	msk := sec.GetMasterSecretKey(k)
+	mpk := GetMasterPublicKey(msk)
+	m := "doremi"
+
+	idVec := make([]ID, n)
+	secVec := make([]SecretKey, n)
+	pubVec := make([]PublicKey, n)
+	signVec := make([]Sign, n)
+	for i := range idVec {
+		if err := idVec[i].SetLittleEndian([]byte{byte(i + 1), 2, 3}); err != nil {
+			t.Fatal(err)
+		}
+		if err := secVec[i].Set(msk, &idVec[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := pubVec[i].Set(mpk, &idVec[i]); err != nil {
+			t.Fatal(err)
+		}
+		if !pubVec[i].IsEqual(secVec[i].GetPublicKey()) {
+			t.Fatalf("share %d: public key mismatch", i)
+		}
+		signVec[i] = *secVec[i].Sign(m)
+		if !signVec[i].Verify(&pubVec[i], m) {
+			t.Fatalf("share %d: signature does not verify", i)
+		}
+	}
+
+	var sec2 SecretKey
+	if err := sec2.Recover(secVec[1:1+k], idVec[1:1+k]); err != nil {
+		t.Fatal(err)
+	}
+	if !sec.IsEqual(&sec2) {
+		t.Error("recovered secret key mismatch")
+	}
+	var pub2 PublicKey
+	if err := pub2.Recover(pubVec[2:2+k], idVec[2:2+k]); err != nil {
+		t.Fatal(err)
+	}
+	if !sec.GetPublicKey().IsEqual(&pub2) {
+		t.Error("recovered public key mismatch")
+	}
+	var sign2 Sign
+	if err := sign2.Recover(signVec[:k], idVec[:k]); err != nil {
+		t.Fatal(err)
+	}
+	if !sec.Sign(m).IsEqual(&sign2) {
+		t.Error("recovered signature mismatch")
+	}
+}
+
+func TestNocgoAggregate(t *testing.T) {
+	initNocgo(t)
+	const n = 4
+	m := "test test"
+	secVec := make([]SecretKey, n)
+	pubVec := make([]PublicKey, n)
+	signVec := make([]Sign, n)
+	msgVec := make([]string, n)
+	for i := 0; i < n; i++ {
+		secVec[i].SetByCSPRNG()
+		pubVec[i] = *secVec[i].GetPublicKey()
+		signVec[i] = *secVec[i].Sign(m)
+		msgVec[i] = fmt.Sprintf("msg%d", i)
+	}
+	if err := BatchVerifySameMsg(BLS12_381, m, pubVec, AggregateSign(signVec)); err != nil {
+		t.Fatal(err)
+	}
+	sum := SeckeyBatchAdd(secVec)
+	pub := PubkeyBatchAdd(pubVec)
+	if !sum.GetPublicKey().IsEqual(&pub) {
+		t.Fatal("aggregated public key mismatch")
+	}
+
+	for i := 0; i < n; i++ {
+		signVec[i] = *secVec[i].Sign(msgVec[i])
+	}
+	hashes, err := MsgsToHashToG1(msgVec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BatchVerifyDistinctMsg(BLS12_381, pubVec, hashes, AggregateSign(signVec)); err != nil {
+		t.Fatal(err)
+	}
+	if err := BatchVerifyDistinctMsg(BLS12_381, pubVec, hashes, signVec[0]); err == nil {
+		t.Fatal("partial aggregate verifies")
+	}
+}
+
+func TestNocgoPopAndDH(t *testing.T) {
+	initNocgo(t)
+	var sec1, sec2 SecretKey
+	sec1.SetByCSPRNG()
+	sec2.SetByCSPRNG()
+	pub1, pub2 := sec1.GetPublicKey(), sec2.GetPublicKey()
+	if !sec1.GetPop().VerifyPop(pub1) {
+		t.Error("pop does not verify")
+	}
+	if sec1.GetPop().VerifyPop(pub2) {
+		t.Error("pop verifies with another key")
+	}
+	a, b := DHKeyExchange(&sec1, pub2), DHKeyExchange(&sec2, pub1)
+	if !a.IsEqual(&b) {
+		t.Error("DH shared keys mismatch")
+	}
+}
+
+func TestNocgoSerialize(t *testing.T) {
+	initNocgo(t)
+	var sec, sec2 SecretKey
+	sec.SetByCSPRNG()
+	if err := sec2.SetLittleEndian(sec.GetLittleEndian()); err != nil || !sec.IsEqual(&sec2) {
+		t.Fatal("secret key little endian round trip", err)
+	}
+	if err := sec2.SetHexString(sec.GetHexString()); err != nil || !sec.IsEqual(&sec2) {
+		t.Fatal("secret key hex round trip", err)
+	}
+	if err := sec2.SetDecString(sec.GetDecString()); err != nil || !sec.IsEqual(&sec2) {
+		t.Fatal("secret key dec round trip", err)
+	}
+
+	pub := sec.GetPublicKey()
+	var pub2 PublicKey
+	if err := pub2.Deserialize(pub.Serialize()); err != nil || !pub.IsEqual(&pub2) {
+		t.Fatal("public key round trip", err)
+	}
+	if err := pub2.SetHexString(pub.GetHexString()); err != nil || !pub.IsEqual(&pub2) {
+		t.Fatal("public key hex round trip", err)
+	}
+	enc, err := rlp.EncodeToBytes(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rlp.DecodeBytes(enc, &pub2); err != nil || !pub.IsEqual(&pub2) {
+		t.Fatal("public key rlp round trip", err)
+	}
+
+	sign := sec.Sign("doremi")
+	var sign2 Sign
+	if err := sign2.Deserialize(sign.Serialize()); err != nil || !sign.IsEqual(&sign2) {
+		t.Fatal("signature round trip", err)
+	}
+
+	// coordinates not below the field order must be rejected
+	buf := make([]byte, len(pub.Serialize()))
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	if err := pub2.Deserialize(buf); err == nil {
+		t.Fatal("invalid public key decoded")
+	}
+}
+
+func TestNocgoPairing(t *testing.T) {
+	initNocgo(t)
+	var a, b, ab Fr
+	a.SetByCSPRNG()
+	b.SetByCSPRNG()
+	FrMul(&ab, &a, &b)
+
+	var P, aP G1
+	if err := P.HashAndMapTo([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	G1Mul(&aP, &P, &a)
+	Q := GetGeneratorOfG2().v
+	var bQ G2
+	G2Mul(&bQ, &Q, &b)
+
+	var e, e1, e2 GT
+	Pairing(&e, &P, &Q)
+	Pairing(&e1, &aP, &bQ)
+	GTPow(&e2, &e, &ab)
+	if !e1.IsEqual(&e2) {
+		t.Fatal("pairing is not bilinear")
+	}
+
+	var ml GT
+	MillerLoop(&ml, &aP, &bQ)
+	FinalExp(&ml, &ml)
+	if !ml.IsEqual(&e1) {
+		t.Fatal("miller loop and final exponentiation do not match pairing")
+	}
+
+	Qbuf := make([]uint64, GetUint64NumToPrecompute())
+	PrecomputeG2(Qbuf, &bQ)
+	PrecomputedMillerLoop(&ml, &aP, Qbuf)
+	FinalExp(&ml, &ml)
+	if !ml.IsEqual(&e1) {
+		t.Fatal("precomputed miller loop does not match pairing")
+	}
+
+	var e3 GT
+	if err := e3.Deserialize(e1.Serialize()); err != nil || !e3.IsEqual(&e1) {
+		t.Fatal("GT round trip", err)
+	}
+}
+
+func TestNocgoSchnorrNIZK(t *testing.T) {
+	initNocgo(t)
+	var sec SecretKey
+	sec.SetByCSPRNG()
+	pub := sec.GetPublicKey()
+
+	proof, err := sec.MakeSchnorrNIZKP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.VerifySchnorrNIZK(*pub); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := SchnorrNIZKProve(BLS12_381, sec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := rlp.EncodeToBytes(pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pf2 Proof
+	if err := rlp.DecodeBytes(enc, &pf2); err != nil {
+		t.Fatal(err)
+	}
+	if err := SchnorrNIZKVerify(BLS12_381, pf2, *pub); err != nil {
+		t.Fatal(err)
+	}
+}
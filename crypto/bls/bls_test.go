@@ -1,3 +1,6 @@
+//go:build cgo && !blsgo
+// +build cgo,!blsgo
+
 package bls
 
 import (
@@ -0,0 +1,104 @@
+package bls
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Vectors produced by the mcl backend, both backends must reproduce them
+// byte for byte. The signatures are taken from the duplicate prepare
+// evidences used by the slashing tests, the messages are the keccak256
+// digests returned by EvidencePrepare.CannibalizeBytes.
+var compatVectors = []struct {
+	sec string
+	pub string
+	sig []struct{ msg, sig string }
+}{
+	{
+		sec: "72fc21a19510d93d726746602344f96bf181efdd8d6d95be1a2a2de59bd59501",
+		pub: "f9b5e5b333418f5f6cb23ad092d2321c49a6fc17dfa2e5899a0fa0a6ab96bc44482552c9149f5909ec7772a902094401912576fdd78497bf57399c711566284ae2f5db3f8e611ac21dbc53cf7c1ff881ab760c0f1e5954b9cd2602b98007ef05",
+		sig: []struct{ msg, sig string }{
+			{"f1c499e69f979fb77e62174d977b0f7a63431cfa81777c678d9be972e1261629", "cd01a6ed0ee36d346fc0cf2eaa0151b775e22ffd97a8c9c5fada22f43deee2940776a3da82e2ba9ea4499037c4a33212"},
+			{"f215b1b4fbd1e8cf21ce1e7a23d1c2d58770f65350c9ef9eedd28ee5ba40b687", "06ebc53e4227a89c6a7f2adf978436cb829fbc47d4e6189569fb240a2c8c1f0a2b3b63fdbf905aaa3f1ffe7b0b4d7e8e"},
+		},
+	},
+	{
+		sec: "b36d4c3c3e8ee7fba3fbedcda4e0493e699cd95b68594093a8498c618680480a",
+		pub: "752fe419bbdc2d2222009e450f2932657bbc2370028d396ba556a49439fe1cc11903354dcb6dac552a124e0b3db0d90edcd334d7aabda0c3f1ade12ca22372f876212ac456d549dbbd04d2c8c8fb3e33760215e114b4d60313c142f7b8bbfd87",
+		sig: []struct{ msg, sig string }{
+			{"f6495be94a970a752ec18f62f647f89bfc3936a5233199a3e8ed71a25a625640", "36015fee15253487e8125b86505377d8540b1a95d1a6b13f714baa55b12bd06ec7d5755a98230cdc88858470afa8cb00"},
+			{"e54ed2104de255f621d36a72d474bcbae420684955ea9485ad986edb7e9ea17a", "783892b9b766f9f4c2a1d45b1fd53ca9ea56a82e38a998939edc17bc7fd756267d3c145c03bc6c1412302cf590645d82"},
+		},
+	},
+}
+
+func TestCompatVectors(t *testing.T) {
+	if err := Init(BLS12_381); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range compatVectors {
+		var sec SecretKey
+		b, _ := hex.DecodeString(v.sec)
+		if err := sec.SetLittleEndian(b); err != nil {
+			t.Fatal(err)
+		}
+		pub := sec.GetPublicKey()
+		if have := hex.EncodeToString(pub.Serialize()); have != v.pub {
+			t.Fatalf("public key mismatch: have %s, want %s", have, v.pub)
+		}
+		var pub2 PublicKey
+		b, _ = hex.DecodeString(v.pub)
+		if err := pub2.Deserialize(b); err != nil {
+			t.Fatal(err)
+		}
+		if !pub.IsEqual(&pub2) || !G2IsValid(&pub2) {
+			t.Fatalf("public key %s does not round trip", v.pub)
+		}
+		for _, s := range v.sig {
+			msg, _ := hex.DecodeString(s.msg)
+			sign := sec.Sign(string(msg))
+			if have := hex.EncodeToString(sign.Serialize()); have != s.sig {
+				t.Fatalf("signature mismatch: have %s, want %s", have, s.sig)
+			}
+			var sign2 Sign
+			b, _ = hex.DecodeString(s.sig)
+			if err := sign2.Deserialize(b); err != nil {
+				t.Fatal(err)
+			}
+			if !sign2.Verify(&pub2, string(msg)) {
+				t.Fatalf("signature %s does not verify", s.sig)
+			}
+			if sign2.Verify(&pub2, string(msg[1:])) {
+				t.Fatalf("signature %s verifies a different message", s.sig)
+			}
+		}
+	}
+}
+
+func TestCompatSchnorrNIZK(t *testing.T) {
+	if err := Init(BLS12_381); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range compatVectors {
+		var sec SecretKey
+		b, _ := hex.DecodeString(v.sec)
+		if err := sec.SetLittleEndian(b); err != nil {
+			t.Fatal(err)
+		}
+		proof, err := sec.MakeSchnorrNIZKP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := proof.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded SchnorrProof
+		if err := decoded.UnmarshalText(raw); err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.VerifySchnorrNIZK(*sec.GetPublicKey()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
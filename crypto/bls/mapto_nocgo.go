@@ -0,0 +1,380 @@
+//go:build !cgo || blsgo
+// +build !cgo blsgo
+
+package bls
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls12381"
+)
+
+// The helpers in this file reproduce the parts of mcl that are visible on the
+// wire when the library runs BLS12_381 in its default (non-ETH) mode:
+//   - field elements are serialized little-endian, points are compressed to x
+//     with the parity of y (of y.a for G2) kept in the most significant bit;
+//   - hash-to-curve hashes the message into Fp and maps it with the
+//     Fouque-Tibouchi encoding (mcl's calcBN), G1 is cleared by the full
+//     cofactor and G2 by the Budroni-Pintore effective cofactor;
+//   - the generator of G2 is the image of 1 under that map.
+// Keeping these bit-exact is what lets the pure Go backend read and produce
+// the same keys, signatures and proofs as the cgo backend.
+
+var (
+	fieldOrder, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	curveOrder, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+	// g1Cofactor is (z-1)^2/3, mcl clears the cofactor of G1 by a plain multiplication.
+	g1Cofactor, _ = new(big.Int).SetString("396c8c005555e1568c00aaab0000aaab", 16)
+
+	fpSqrtExp  = new(big.Int).Rsh(new(big.Int).Add(fieldOrder, big.NewInt(1)), 2)
+	fpHalf     = new(big.Int).ModInverse(big.NewInt(2), fieldOrder)
+	fpCurveB   = big.NewInt(4)
+	fp2CurveB  = fp2{big.NewInt(4), big.NewInt(4)}
+	sqrtMinus3 = mustFpSqrt(fpNeg(big.NewInt(3)))
+	// mapToC2 is (-1 + sqrt(-3)) / 2
+	mapToC2 = fpMul(fpSub(sqrtMinus3, big.NewInt(1)), fpHalf)
+)
+
+const (
+	fpByteSize = 48
+	frByteSize = 32
+	fpBitSize  = 381
+	frBitSize  = 255
+)
+
+var (
+	errInvalidPoint = errors.New("invalid point")
+	errMapToCurve   = errors.New("failed to map to curve")
+)
+
+func fpMod(x *big.Int) *big.Int         { return x.Mod(x, fieldOrder) }
+func fpAdd(x, y *big.Int) *big.Int      { return fpMod(new(big.Int).Add(x, y)) }
+func fpSub(x, y *big.Int) *big.Int      { return fpMod(new(big.Int).Sub(x, y)) }
+func fpMul(x, y *big.Int) *big.Int      { return fpMod(new(big.Int).Mul(x, y)) }
+func fpNeg(x *big.Int) *big.Int         { return fpMod(new(big.Int).Neg(x)) }
+func fpInv(x *big.Int) *big.Int         { return new(big.Int).ModInverse(x, fieldOrder) }
+func fpIsOdd(x *big.Int) bool           { return x.Bit(0) == 1 }
+func fpLegendre(x *big.Int) int         { return big.Jacobi(x, fieldOrder) }
+func fpEqual(x, y *big.Int) bool        { return x.Cmp(y) == 0 }
+func fpCube(x *big.Int) *big.Int        { return fpMul(fpMul(x, x), x) }
+func fpWeierstrass(x *big.Int) *big.Int { return fpAdd(fpCube(x), fpCurveB) }
+
+// fpSqrt returns x^((p+1)/4), which is the root mcl picks since p = 3 mod 4.
+func fpSqrt(x *big.Int) (*big.Int, bool) {
+	y := new(big.Int).Exp(x, fpSqrtExp, fieldOrder)
+	return y, fpEqual(fpMul(y, y), fpMod(new(big.Int).Set(x)))
+}
+
+func mustFpSqrt(x *big.Int) *big.Int {
+	y, ok := fpSqrt(x)
+	if !ok {
+		panic("bls: no square root")
+	}
+	return y
+}
+
+// fp2 is an element a + b*i of Fp2 = Fp[i]/(i^2+1).
+type fp2 struct {
+	a, b *big.Int
+}
+
+func (x fp2) isZero() bool         { return x.a.Sign() == 0 && x.b.Sign() == 0 }
+func (x fp2) equal(y fp2) bool     { return fpEqual(x.a, y.a) && fpEqual(x.b, y.b) }
+func (x fp2) add(y fp2) fp2        { return fp2{fpAdd(x.a, y.a), fpAdd(x.b, y.b)} }
+func (x fp2) sub(y fp2) fp2        { return fp2{fpSub(x.a, y.a), fpSub(x.b, y.b)} }
+func (x fp2) neg() fp2             { return fp2{fpNeg(x.a), fpNeg(x.b)} }
+func (x fp2) mulFp(y *big.Int) fp2 { return fp2{fpMul(x.a, y), fpMul(x.b, y)} }
+
+func (x fp2) mul(y fp2) fp2 {
+	return fp2{
+		fpSub(fpMul(x.a, y.a), fpMul(x.b, y.b)),
+		fpAdd(fpMul(x.a, y.b), fpMul(x.b, y.a)),
+	}
+}
+
+// norm returns a^2 + b^2.
+func (x fp2) norm() *big.Int {
+	return fpAdd(fpMul(x.a, x.a), fpMul(x.b, x.b))
+}
+
+func (x fp2) inv() fp2 {
+	n := fpInv(x.norm())
+	return fp2{fpMul(x.a, n), fpNeg(fpMul(x.b, n))}
+}
+
+func (x fp2) weierstrass() fp2 {
+	return x.mul(x).mul(x).add(fp2CurveB)
+}
+
+// sqrt follows mcl's Fp2::squareRoot so that the map to G2 picks the same root.
+func (x fp2) sqrt() (fp2, bool) {
+	if x.b.Sign() == 0 {
+		if t, ok := fpSqrt(x.a); ok {
+			return fp2{t, new(big.Int)}, true
+		}
+		t, ok := fpSqrt(fpNeg(x.a))
+		return fp2{new(big.Int), t}, ok
+	}
+	t1, ok := fpSqrt(x.norm())
+	if !ok {
+		return fp2{}, false
+	}
+	t2, ok := fpSqrt(fpMul(fpAdd(x.a, t1), fpHalf))
+	if !ok {
+		if t2, ok = fpSqrt(fpMul(fpSub(x.a, t1), fpHalf)); !ok {
+			return fp2{}, false
+		}
+	}
+	y := fp2{t2, fpMul(x.b, fpInv(fpAdd(t2, t2)))}
+	return y, y.mul(y).equal(x)
+}
+
+func reverseBytes(buf []byte) []byte {
+	out := make([]byte, len(buf))
+	for i := range buf {
+		out[i] = buf[len(buf)-1-i]
+	}
+	return out
+}
+
+func bigFromLittleEndian(buf []byte) *big.Int {
+	return new(big.Int).SetBytes(reverseBytes(buf))
+}
+
+func bigToLittleEndian(x *big.Int, size int) []byte {
+	buf := make([]byte, size)
+	x.FillBytes(buf)
+	return reverseBytes(buf)
+}
+
+func bigToBigEndian(x *big.Int, size int) []byte {
+	buf := make([]byte, size)
+	x.FillBytes(buf)
+	return buf
+}
+
+// setArrayMask mirrors mcl's setArrayMask: the little-endian input is
+// truncated to the element size and masked to bitSize bits, if the result
+// is still not below the modulus the top bit is cleared as well.
+func setArrayMask(buf []byte, byteSize, bitSize int, modulus *big.Int) *big.Int {
+	if len(buf) > byteSize {
+		buf = buf[:byteSize]
+	}
+	x := bigFromLittleEndian(buf)
+	for _, n := range []int{bitSize, bitSize - 1} {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(n)), big.NewInt(1))
+		if x.And(x, mask).Cmp(modulus) < 0 {
+			break
+		}
+	}
+	return x
+}
+
+// hashToFp is mcl's Fp::setHashOf, SHA-512 is used because Fp is wider than 256 bits.
+func hashToFp(msg []byte) *big.Int {
+	h := sha512.Sum512(msg)
+	return setArrayMask(h[:], fpByteSize, fpBitSize, fieldOrder)
+}
+
+// hashToFr is mcl's Fr::setHashOf.
+func hashToFr(msg []byte) *big.Int {
+	h := sha256.Sum256(msg)
+	return setArrayMask(h[:], frByteSize, frBitSize, curveOrder)
+}
+
+// calcBNFp maps t to a point of y^2 = x^3 + 4 over Fp.
+func calcBNFp(t *big.Int) (*big.Int, *big.Int, bool) {
+	if t.Sign() == 0 {
+		return nil, nil, false
+	}
+	negative := fpLegendre(t) < 0
+	w := fpAdd(fpAdd(fpMul(t, t), fpCurveB), big.NewInt(1))
+	if w.Sign() == 0 {
+		return nil, nil, false
+	}
+	w = fpMul(fpMul(fpInv(w), sqrtMinus3), t)
+	var x *big.Int
+	for i := 0; i < 3; i++ {
+		switch i {
+		case 0:
+			x = fpAdd(fpNeg(fpMul(t, w)), mapToC2)
+		case 1:
+			x = fpSub(fpNeg(x), big.NewInt(1))
+		case 2:
+			x = fpAdd(fpInv(fpMul(w, w)), big.NewInt(1))
+		}
+		if y, ok := fpSqrt(fpWeierstrass(x)); ok {
+			if negative {
+				y = fpNeg(y)
+			}
+			return x, y, true
+		}
+	}
+	return nil, nil, false
+}
+
+// calcBNFp2 maps t to a point of the twist y^2 = x^3 + 4(1+i) over Fp2.
+func calcBNFp2(t fp2) (fp2, fp2, bool) {
+	if t.isZero() {
+		return fp2{}, fp2{}, false
+	}
+	negative := fpLegendre(t.norm()) < 0
+	w := t.mul(t).add(fp2CurveB)
+	w.a = fpAdd(w.a, big.NewInt(1))
+	if w.isZero() {
+		return fp2{}, fp2{}, false
+	}
+	w = w.inv().mulFp(sqrtMinus3).mul(t)
+	var x fp2
+	for i := 0; i < 3; i++ {
+		switch i {
+		case 0:
+			x = t.mul(w).neg()
+			x.a = fpAdd(x.a, mapToC2)
+		case 1:
+			x = x.neg()
+			x.a = fpSub(x.a, big.NewInt(1))
+		case 2:
+			x = w.mul(w).inv()
+			x.a = fpAdd(x.a, big.NewInt(1))
+		}
+		if y, ok := x.weierstrass().sqrt(); ok {
+			if negative {
+				y = y.neg()
+			}
+			return x, y, true
+		}
+	}
+	return fp2{}, fp2{}, false
+}
+
+func newG1Point(x, y *big.Int) (*bls12381.PointG1, error) {
+	return bls12381.NewG1().FromBytes(append(bigToBigEndian(x, fpByteSize), bigToBigEndian(y, fpByteSize)...))
+}
+
+func newG2Point(x, y fp2) (*bls12381.PointG2, error) {
+	buf := make([]byte, 0, 4*fpByteSize)
+	for _, v := range []*big.Int{x.b, x.a, y.b, y.a} {
+		buf = append(buf, bigToBigEndian(v, fpByteSize)...)
+	}
+	return bls12381.NewG2().FromBytes(buf)
+}
+
+func mapToG1(t *big.Int) (*bls12381.PointG1, error) {
+	x, y, ok := calcBNFp(t)
+	if !ok {
+		return nil, errMapToCurve
+	}
+	p, err := newG1Point(x, y)
+	if err != nil {
+		return nil, err
+	}
+	g := bls12381.NewG1()
+	return g.MulScalar(p, p, g1Cofactor), nil
+}
+
+func mapToG2(t fp2) (*bls12381.PointG2, error) {
+	x, y, ok := calcBNFp2(t)
+	if !ok {
+		return nil, errMapToCurve
+	}
+	p, err := newG2Point(x, y)
+	if err != nil {
+		return nil, err
+	}
+	bls12381.NewG2().ClearCofactor(p)
+	return p, nil
+}
+
+// g1Affine returns the affine coordinates of p, p itself is left untouched.
+func g1Affine(p *bls12381.PointG1) (x, y *big.Int) {
+	q := *p
+	buf := bls12381.NewG1().ToBytes(&q)
+	return new(big.Int).SetBytes(buf[:fpByteSize]), new(big.Int).SetBytes(buf[fpByteSize:])
+}
+
+// g2Affine returns the affine coordinates of p, p itself is left untouched.
+func g2Affine(p *bls12381.PointG2) (x, y fp2) {
+	q := *p
+	buf := bls12381.NewG2().ToBytes(&q)
+	elem := func(i int) *big.Int { return new(big.Int).SetBytes(buf[i*fpByteSize : (i+1)*fpByteSize]) }
+	return fp2{elem(1), elem(0)}, fp2{elem(3), elem(2)}
+}
+
+func g1Compress(p *bls12381.PointG1) []byte {
+	if bls12381.NewG1().IsZero(p) {
+		return make([]byte, fpByteSize)
+	}
+	x, y := g1Affine(p)
+	buf := bigToLittleEndian(x, fpByteSize)
+	if fpIsOdd(y) {
+		buf[fpByteSize-1] |= 0x80
+	}
+	return buf
+}
+
+func g1Decompress(buf []byte) (*bls12381.PointG1, error) {
+	if len(buf) < fpByteSize {
+		return nil, errInvalidPoint
+	}
+	xb := make([]byte, fpByteSize)
+	copy(xb, buf[:fpByteSize])
+	odd := xb[fpByteSize-1]&0x80 != 0
+	xb[fpByteSize-1] &= 0x7f
+	x := bigFromLittleEndian(xb)
+	if x.Sign() == 0 && !odd {
+		return bls12381.NewG1().Zero(), nil
+	}
+	if x.Cmp(fieldOrder) >= 0 {
+		return nil, errInvalidPoint
+	}
+	y, ok := fpSqrt(fpWeierstrass(x))
+	if !ok {
+		return nil, errInvalidPoint
+	}
+	if fpIsOdd(y) != odd {
+		y = fpNeg(y)
+	}
+	return newG1Point(x, y)
+}
+
+func g2Compress(p *bls12381.PointG2) []byte {
+	if bls12381.NewG2().IsZero(p) {
+		return make([]byte, 2*fpByteSize)
+	}
+	x, y := g2Affine(p)
+	buf := append(bigToLittleEndian(x.a, fpByteSize), bigToLittleEndian(x.b, fpByteSize)...)
+	if fpIsOdd(y.a) {
+		buf[2*fpByteSize-1] |= 0x80
+	}
+	return buf
+}
+
+func g2Decompress(buf []byte) (*bls12381.PointG2, error) {
+	if len(buf) < 2*fpByteSize {
+		return nil, errInvalidPoint
+	}
+	xb := make([]byte, 2*fpByteSize)
+	copy(xb, buf[:2*fpByteSize])
+	odd := xb[2*fpByteSize-1]&0x80 != 0
+	xb[2*fpByteSize-1] &= 0x7f
+	x := fp2{bigFromLittleEndian(xb[:fpByteSize]), bigFromLittleEndian(xb[fpByteSize:])}
+	if x.isZero() && !odd {
+		return bls12381.NewG2().Zero(), nil
+	}
+	if x.a.Cmp(fieldOrder) >= 0 || x.b.Cmp(fieldOrder) >= 0 {
+		return nil, errInvalidPoint
+	}
+	y, ok := x.weierstrass().sqrt()
+	if !ok {
+		return nil, errInvalidPoint
+	}
+	if fpIsOdd(y.a) != odd {
+		y = y.neg()
+	}
+	return newG2Point(x, y)
+}
@@ -0,0 +1,862 @@
+//go:build !cgo || blsgo
+// +build !cgo blsgo
+
+package bls
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls12381"
+)
+
+// CurveFp254BNb -- 254 bit curve
+const CurveFp254BNb = 0
+
+// CurveFp382_1 -- 382 bit curve 1
+const CurveFp382_1 = 1
+
+// CurveFp382_2 -- 382 bit curve 2
+const CurveFp382_2 = 2
+
+// BLS12_381
+const BLS12_381 = 5
+
+// IoSerializeHexStr
+const IoSerializeHexStr = 2048
+
+// GetFrUnitSize() --
+func GetFrUnitSize() int {
+	return 6
+}
+
+// GetFpUnitSize() --
+// same as GetMaxOpUnitSize()
+func GetFpUnitSize() int {
+	return 6
+}
+
+// GetMaxOpUnitSize --
+func GetMaxOpUnitSize() int {
+	return 6
+}
+
+// GetOpUnitSize --
+// the length of Fr is GetOpUnitSize() * 8 bytes
+func GetOpUnitSize() int {
+	return 6
+}
+
+// GetCurveOrder --
+// return the order of G1
+func GetCurveOrder() string {
+	return curveOrder.String()
+}
+
+// GetFieldOrder --
+// return the characteristic of the field where a curve is defined
+func GetFieldOrder() string {
+	return fieldOrder.String()
+}
+
+// parseString parses s in the given base into [0, order), a leading '-'
+// is taken modulo order as mcl does.
+func parseString(s string, base int, order *big.Int) (*big.Int, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if base == 16 {
+		s = strings.TrimPrefix(s, "0x")
+	}
+	switch base {
+	case 2, 10, 16:
+	default:
+		return nil, fmt.Errorf("unsupported base %d", base)
+	}
+	v, ok := new(big.Int).SetString(s, base)
+	if !ok || v.Cmp(order) >= 0 {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	if neg {
+		v.Neg(v).Mod(v, order)
+	}
+	return v, nil
+}
+
+// Fr --
+type Fr struct {
+	v [4]uint64
+}
+
+// getBig --
+func (x *Fr) getBig() *big.Int {
+	return bigFromLittleEndian(x.Serialize())
+}
+
+// setBig -- v must be reduced modulo the curve order
+func (x *Fr) setBig(v *big.Int) {
+	buf := bigToLittleEndian(v, frByteSize)
+	for i := range x.v {
+		x.v[i] = binary.LittleEndian.Uint64(buf[i*8:])
+	}
+}
+
+// Clear --
+func (x *Fr) Clear() {
+	x.v = [4]uint64{}
+}
+
+// SetInt64 --
+func (x *Fr) SetInt64(v int64) {
+	x.setBig(new(big.Int).Mod(big.NewInt(v), curveOrder))
+}
+
+// SetString --
+func (x *Fr) SetString(s string, base int) error {
+	if base == IoSerializeHexStr {
+		buf, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return x.Deserialize(buf)
+	}
+	v, err := parseString(s, base, curveOrder)
+	if err != nil {
+		return fmt.Errorf("err Fr.SetString %v", err)
+	}
+	x.setBig(v)
+	return nil
+}
+
+// Deserialize --
+func (x *Fr) Deserialize(buf []byte) error {
+	if len(buf) < frByteSize {
+		return fmt.Errorf("err Fr.Deserialize %x", buf)
+	}
+	v := bigFromLittleEndian(buf[:frByteSize])
+	if v.Cmp(curveOrder) >= 0 {
+		return fmt.Errorf("err Fr.Deserialize %x", buf)
+	}
+	x.setBig(v)
+	return nil
+}
+
+// SetLittleEndian --
+func (x *Fr) SetLittleEndian(buf []byte) error {
+	if len(buf) == 0 {
+		return errors.New("err Fr.SetLittleEndian empty buffer")
+	}
+	x.setBig(setArrayMask(buf, frByteSize, frBitSize, curveOrder))
+	return nil
+}
+
+// IsEqual --
+func (x *Fr) IsEqual(rhs *Fr) bool {
+	return x.v == rhs.v
+}
+
+// IsZero --
+func (x *Fr) IsZero() bool {
+	return x.v == [4]uint64{}
+}
+
+// IsOne --
+func (x *Fr) IsOne() bool {
+	return x.v == [4]uint64{1}
+}
+
+// SetByCSPRNG --
+func (x *Fr) SetByCSPRNG() {
+	v, err := rand.Int(rand.Reader, curveOrder)
+	if err != nil {
+		panic("err Fr.SetByCSPRNG")
+	}
+	x.setBig(v)
+}
+
+// SetHashOf --
+// the result mirrors the cgo wrapper, which reports mcl's status code
+// compared to 1, so false is returned on success.
+func (x *Fr) SetHashOf(buf []byte) bool {
+	x.setBig(hashToFr(buf))
+	return false
+}
+
+// GetString --
+func (x *Fr) GetString(base int) string {
+	if base == IoSerializeHexStr {
+		return hex.EncodeToString(x.Serialize())
+	}
+	return x.getBig().Text(base)
+}
+
+// Serialize --
+func (x *Fr) Serialize() []byte {
+	buf := make([]byte, frByteSize)
+	for i, v := range x.v {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+	return buf
+}
+
+// FrNeg --
+func FrNeg(out *Fr, x *Fr) {
+	v := x.getBig()
+	out.setBig(v.Neg(v).Mod(v, curveOrder))
+}
+
+// FrInv --
+func FrInv(out *Fr, x *Fr) {
+	v := x.getBig()
+	if v.Sign() == 0 {
+		out.Clear()
+		return
+	}
+	out.setBig(v.ModInverse(v, curveOrder))
+}
+
+// FrAdd --
+func FrAdd(out *Fr, x *Fr, y *Fr) {
+	v := new(big.Int).Add(x.getBig(), y.getBig())
+	out.setBig(v.Mod(v, curveOrder))
+}
+
+// FrSub --
+func FrSub(out *Fr, x *Fr, y *Fr) {
+	v := new(big.Int).Sub(x.getBig(), y.getBig())
+	out.setBig(v.Mod(v, curveOrder))
+}
+
+// FrMul --
+func FrMul(out *Fr, x *Fr, y *Fr) {
+	v := new(big.Int).Mul(x.getBig(), y.getBig())
+	out.setBig(v.Mod(v, curveOrder))
+}
+
+// FrDiv --
+func FrDiv(out *Fr, x *Fr, y *Fr) {
+	var inv Fr
+	FrInv(&inv, y)
+	FrMul(out, x, &inv)
+}
+
+// pointString formats an affine point the way mcl's getStr does.
+func pointString(zero bool, base int, coords ...*big.Int) string {
+	if zero {
+		return "0"
+	}
+	s := []string{"1"}
+	for _, c := range coords {
+		s = append(s, c.Text(base))
+	}
+	return strings.Join(s, " ")
+}
+
+// parsePoint parses the mcl getStr format into its header and coordinates.
+func parsePoint(s string, base int, n int) (string, []*big.Int, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", nil, errInvalidPoint
+	}
+	switch fields[0] {
+	case "0":
+		return "0", nil, nil
+	case "1":
+		if len(fields) != 1+2*n {
+			return "", nil, errInvalidPoint
+		}
+	case "2", "3":
+		if len(fields) != 1+n {
+			return "", nil, errInvalidPoint
+		}
+	default:
+		return "", nil, errInvalidPoint
+	}
+	coords := make([]*big.Int, 0, len(fields)-1)
+	for _, f := range fields[1:] {
+		v, err := parseString(f, base, fieldOrder)
+		if err != nil {
+			return "", nil, err
+		}
+		coords = append(coords, v)
+	}
+	return fields[0], coords, nil
+}
+
+// G1 --
+type G1 struct {
+	v bls12381.PointG1
+}
+
+// Clear --
+func (x *G1) Clear() {
+	x.v.Zero()
+}
+
+// SetString --
+func (x *G1) SetString(s string, base int) error {
+	if base == IoSerializeHexStr {
+		buf, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return x.Deserialize(buf)
+	}
+	header, c, err := parsePoint(s, base, 1)
+	if err != nil {
+		return fmt.Errorf("err G1.SetString %v", err)
+	}
+	var p *bls12381.PointG1
+	switch header {
+	case "0":
+		p = bls12381.NewG1().Zero()
+	case "1":
+		p, err = newG1Point(c[0], c[1])
+	default:
+		buf := bigToLittleEndian(c[0], fpByteSize)
+		if header == "3" {
+			buf[fpByteSize-1] |= 0x80
+		}
+		p, err = g1Decompress(buf)
+	}
+	if err != nil {
+		return fmt.Errorf("err G1.SetString %v", err)
+	}
+	x.v = *p
+	return nil
+}
+
+// Deserialize --
+func (x *G1) Deserialize(buf []byte) error {
+	p, err := g1Decompress(buf)
+	if err != nil {
+		return fmt.Errorf("err G1.Deserialize %x", buf)
+	}
+	x.v = *p
+	return nil
+}
+
+// IsEqual --
+func (x *G1) IsEqual(rhs *G1) bool {
+	return bls12381.NewG1().Equal(&x.v, &rhs.v)
+}
+
+// IsZero --
+func (x *G1) IsZero() bool {
+	return bls12381.NewG1().IsZero(&x.v)
+}
+
+// isValid -- on the curve and in the subgroup of order r
+func (x *G1) isValid() bool {
+	g := bls12381.NewG1()
+	return g.IsOnCurve(&x.v) && g.InCorrectSubgroup(&x.v)
+}
+
+// HashAndMapTo --
+func (x *G1) HashAndMapTo(buf []byte) error {
+	p, err := mapToG1(hashToFp(buf))
+	if err != nil {
+		return fmt.Errorf("err G1.HashAndMapTo %v", err)
+	}
+	x.v = *p
+	return nil
+}
+
+// GetString --
+func (x *G1) GetString(base int) string {
+	if base == IoSerializeHexStr {
+		return hex.EncodeToString(x.Serialize())
+	}
+	if x.IsZero() {
+		return pointString(true, base)
+	}
+	px, py := g1Affine(&x.v)
+	return pointString(false, base, px, py)
+}
+
+// Serialize --
+func (x *G1) Serialize() []byte {
+	return g1Compress(&x.v)
+}
+
+// G1Neg --
+func G1Neg(out *G1, x *G1) {
+	bls12381.NewG1().Neg(&out.v, &x.v)
+}
+
+// G1Dbl --
+func G1Dbl(out *G1, x *G1) {
+	bls12381.NewG1().Double(&out.v, &x.v)
+}
+
+// G1Add --
+func G1Add(out *G1, x *G1, y *G1) {
+	bls12381.NewG1().Add(&out.v, &x.v, &y.v)
+}
+
+// G1Sub --
+func G1Sub(out *G1, x *G1, y *G1) {
+	bls12381.NewG1().Sub(&out.v, &x.v, &y.v)
+}
+
+// G1Mul --
+func G1Mul(out *G1, x *G1, y *Fr) {
+	bls12381.NewG1().MulScalar(&out.v, &x.v, y.getBig())
+}
+
+// G1MulCT -- constant time (depending on bit lengh of y)
+func G1MulCT(out *G1, x *G1, y *Fr) {
+	G1Mul(out, x, y)
+}
+
+// G2 --
+type G2 struct {
+	v bls12381.PointG2
+}
+
+// Clear --
+func (x *G2) Clear() {
+	x.v.Zero()
+}
+
+// SetString --
+func (x *G2) SetString(s string, base int) error {
+	if base == IoSerializeHexStr {
+		buf, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return x.Deserialize(buf)
+	}
+	header, c, err := parsePoint(s, base, 2)
+	if err != nil {
+		return fmt.Errorf("err G2.SetString %v", err)
+	}
+	var p *bls12381.PointG2
+	switch header {
+	case "0":
+		p = bls12381.NewG2().Zero()
+	case "1":
+		p, err = newG2Point(fp2{c[0], c[1]}, fp2{c[2], c[3]})
+	default:
+		buf := append(bigToLittleEndian(c[0], fpByteSize), bigToLittleEndian(c[1], fpByteSize)...)
+		if header == "3" {
+			buf[2*fpByteSize-1] |= 0x80
+		}
+		p, err = g2Decompress(buf)
+	}
+	if err != nil {
+		return fmt.Errorf("err G2.SetString %v", err)
+	}
+	x.v = *p
+	return nil
+}
+
+// Deserialize --
+func (x *G2) Deserialize(buf []byte) error {
+	p, err := g2Decompress(buf)
+	if err != nil {
+		return fmt.Errorf("err G2.Deserialize %x", buf)
+	}
+	x.v = *p
+	return nil
+}
+
+// IsEqual --
+func (x *G2) IsEqual(rhs *G2) bool {
+	return bls12381.NewG2().Equal(&x.v, &rhs.v)
+}
+
+// IsZero --
+func (x *G2) IsZero() bool {
+	return bls12381.NewG2().IsZero(&x.v)
+}
+
+// isValid -- on the curve and in the subgroup of order r
+func (x *G2) isValid() bool {
+	g := bls12381.NewG2()
+	return g.IsOnCurve(&x.v) && g.InCorrectSubgroup(&x.v)
+}
+
+// HashAndMapTo --
+func (x *G2) HashAndMapTo(buf []byte) error {
+	p, err := mapToG2(fp2{hashToFp(buf), new(big.Int)})
+	if err != nil {
+		return fmt.Errorf("err G2.HashAndMapTo %v", err)
+	}
+	x.v = *p
+	return nil
+}
+
+// GetString --
+func (x *G2) GetString(base int) string {
+	if base == IoSerializeHexStr {
+		return hex.EncodeToString(x.Serialize())
+	}
+	if x.IsZero() {
+		return pointString(true, base)
+	}
+	px, py := g2Affine(&x.v)
+	return pointString(false, base, px.a, px.b, py.a, py.b)
+}
+
+// Serialize --
+func (x *G2) Serialize() []byte {
+	return g2Compress(&x.v)
+}
+
+// G2Neg --
+func G2Neg(out *G2, x *G2) {
+	bls12381.NewG2().Neg(&out.v, &x.v)
+}
+
+// G2Dbl --
+func G2Dbl(out *G2, x *G2) {
+	bls12381.NewG2().Double(&out.v, &x.v)
+}
+
+// G2Add --
+func G2Add(out *G2, x *G2, y *G2) {
+	bls12381.NewG2().Add(&out.v, &x.v, &y.v)
+}
+
+// G2Sub --
+func G2Sub(out *G2, x *G2, y *G2) {
+	bls12381.NewG2().Sub(&out.v, &x.v, &y.v)
+}
+
+// G2Mul --
+func G2Mul(out *G2, x *G2, y *Fr) {
+	bls12381.NewG2().MulScalar(&out.v, &x.v, y.getBig())
+}
+
+// GT --
+type GT struct {
+	v bls12381.E
+}
+
+const gtByteSize = 12 * fpByteSize
+
+// Clear --
+func (x *GT) Clear() {
+	x.v = bls12381.E{}
+}
+
+// SetInt64 --
+func (x *GT) SetInt64(v int64) {
+	buf := make([]byte, gtByteSize)
+	copy(buf, bigToLittleEndian(new(big.Int).Mod(big.NewInt(v), fieldOrder), fpByteSize))
+	if err := x.Deserialize(buf); err != nil {
+		panic(err)
+	}
+}
+
+// SetString --
+func (x *GT) SetString(s string, base int) error {
+	if base == IoSerializeHexStr {
+		buf, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return x.Deserialize(buf)
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 12 {
+		return fmt.Errorf("err GT.SetString %q", s)
+	}
+	buf := make([]byte, 0, gtByteSize)
+	for _, f := range fields {
+		v, err := parseString(f, base, fieldOrder)
+		if err != nil {
+			return fmt.Errorf("err GT.SetString %v", err)
+		}
+		buf = append(buf, bigToLittleEndian(v, fpByteSize)...)
+	}
+	return x.Deserialize(buf)
+}
+
+// Deserialize --
+// mcl stores the twelve Fp coefficients little-endian from the lowest one,
+// which is the byte-wise reverse of the big-endian layout used by bls12381.
+func (x *GT) Deserialize(buf []byte) error {
+	if len(buf) < gtByteSize {
+		return fmt.Errorf("err GT.Deserialize %x", buf)
+	}
+	// GT membership is not checked, the element is returned along with the error
+	e, err := bls12381.NewGT().FromBytes(reverseBytes(buf[:gtByteSize]))
+	if e == nil {
+		return fmt.Errorf("err GT.Deserialize %v", err)
+	}
+	x.v = *e
+	return nil
+}
+
+// IsEqual --
+func (x *GT) IsEqual(rhs *GT) bool {
+	return x.v.Equal(&rhs.v)
+}
+
+// IsZero --
+func (x *GT) IsZero() bool {
+	return x.v.Equal(new(bls12381.E))
+}
+
+// IsOne --
+func (x *GT) IsOne() bool {
+	return x.v.IsOne()
+}
+
+// GetString --
+func (x *GT) GetString(base int) string {
+	buf := x.Serialize()
+	if base == IoSerializeHexStr {
+		return hex.EncodeToString(buf)
+	}
+	s := make([]string, 0, 12)
+	for i := 0; i < gtByteSize; i += fpByteSize {
+		s = append(s, bigFromLittleEndian(buf[i:i+fpByteSize]).Text(base))
+	}
+	return strings.Join(s, " ")
+}
+
+// Serialize --
+func (x *GT) Serialize() []byte {
+	return reverseBytes(bls12381.NewGT().ToBytes(&x.v))
+}
+
+// GTNeg --
+func GTNeg(out *GT, x *GT) {
+	bls12381.NewGT().Sub(&out.v, new(bls12381.E), &x.v)
+}
+
+// GTInv --
+func GTInv(out *GT, x *GT) {
+	bls12381.NewGT().Inverse(&out.v, &x.v)
+}
+
+// GTAdd --
+func GTAdd(out *GT, x *GT, y *GT) {
+	bls12381.NewGT().Add(&out.v, &x.v, &y.v)
+}
+
+// GTSub --
+func GTSub(out *GT, x *GT, y *GT) {
+	bls12381.NewGT().Sub(&out.v, &x.v, &y.v)
+}
+
+// GTMul --
+func GTMul(out *GT, x *GT, y *GT) {
+	bls12381.NewGT().Mul(&out.v, &x.v, &y.v)
+}
+
+// GTDiv --
+func GTDiv(out *GT, x *GT, y *GT) {
+	var inv GT
+	GTInv(&inv, y)
+	GTMul(out, x, &inv)
+}
+
+// GTPow --
+func GTPow(out *GT, x *GT, y *Fr) {
+	bls12381.NewGT().Exp(&out.v, &x.v, y.getBig())
+}
+
+// Pairing --
+func Pairing(out *GT, x *G1, y *G2) {
+	p, q := x.v, y.v
+	out.v = *bls12381.NewPairingEngine().AddPair(&p, &q).Result()
+}
+
+// FinalExp --
+func FinalExp(out *GT, x *GT) {
+	out.v = *bls12381.NewPairingEngine().FinalExp(&x.v)
+}
+
+// MillerLoop --
+func MillerLoop(out *GT, x *G1, y *G2) {
+	p, q := x.v, y.v
+	out.v = *bls12381.NewPairingEngine().AddPair(&p, &q).MillerLoop()
+}
+
+// GetUint64NumToPrecompute --
+// the pure Go backend keeps the affine coordinates of Q instead of the line
+// coefficients, the buffer is only meant to be passed back to this package.
+func GetUint64NumToPrecompute() int {
+	return 4 * fpByteSize / 8
+}
+
+// PrecomputeG2 --
+func PrecomputeG2(Qbuf []uint64, Q *G2) {
+	q := Q.v
+	buf := bls12381.NewG2().ToBytes(&q)
+	for i := 0; i < len(buf)/8; i++ {
+		Qbuf[i] = binary.BigEndian.Uint64(buf[i*8:])
+	}
+}
+
+func loadPrecomputedG2(Qbuf []uint64) *bls12381.PointG2 {
+	buf := make([]byte, 4*fpByteSize)
+	for i := 0; i < len(buf)/8; i++ {
+		binary.BigEndian.PutUint64(buf[i*8:], Qbuf[i])
+	}
+	q, err := bls12381.NewG2().FromBytes(buf)
+	if err != nil {
+		panic("err loadPrecomputedG2")
+	}
+	return q
+}
+
+// PrecomputedMillerLoop --
+func PrecomputedMillerLoop(out *GT, P *G1, Qbuf []uint64) {
+	p := P.v
+	out.v = *bls12381.NewPairingEngine().AddPair(&p, loadPrecomputedG2(Qbuf)).MillerLoop()
+}
+
+// PrecomputedMillerLoop2 --
+func PrecomputedMillerLoop2(out *GT, P1 *G1, Q1buf []uint64, P2 *G1, Q2buf []uint64) {
+	p1, p2 := P1.v, P2.v
+	e := bls12381.NewPairingEngine()
+	e.AddPair(&p1, loadPrecomputedG2(Q1buf)).AddPair(&p2, loadPrecomputedG2(Q2buf))
+	out.v = *e.MillerLoop()
+}
+
+// FrEvaluatePolynomial -- y = c[0] + c[1] * x + c[2] * x^2 + ...
+func FrEvaluatePolynomial(y *Fr, c []Fr, x *Fr) error {
+	if len(c) == 0 {
+		return fmt.Errorf("err FrEvaluatePolynomial")
+	}
+	s := c[len(c)-1]
+	for i := len(c) - 2; i >= 0; i-- {
+		FrMul(&s, &s, x)
+		FrAdd(&s, &s, &c[i])
+	}
+	*y = s
+	return nil
+}
+
+// G1EvaluatePolynomial -- y = c[0] + c[1] * x + c[2] * x^2 + ...
+func G1EvaluatePolynomial(y *G1, c []G1, x *Fr) error {
+	if len(c) == 0 {
+		return fmt.Errorf("err G1EvaluatePolynomial")
+	}
+	s := c[len(c)-1]
+	for i := len(c) - 2; i >= 0; i-- {
+		G1Mul(&s, &s, x)
+		G1Add(&s, &s, &c[i])
+	}
+	*y = s
+	return nil
+}
+
+// G2EvaluatePolynomial -- y = c[0] + c[1] * x + c[2] * x^2 + ...
+func G2EvaluatePolynomial(y *G2, c []G2, x *Fr) error {
+	if len(c) == 0 {
+		return fmt.Errorf("err G2EvaluatePolynomial")
+	}
+	s := c[len(c)-1]
+	for i := len(c) - 2; i >= 0; i-- {
+		G2Mul(&s, &s, x)
+		G2Add(&s, &s, &c[i])
+	}
+	*y = s
+	return nil
+}
+
+// lagrangeCoefficients returns delta_i = prod_{j != i} x_j / (x_j - x_i),
+// the coefficients of the interpolation at zero.
+func lagrangeCoefficients(xVec []Fr) ([]Fr, error) {
+	n := len(xVec)
+	if n == 0 {
+		return nil, errors.New("empty vector")
+	}
+	delta := make([]Fr, n)
+	if n == 1 {
+		delta[0].SetInt64(1)
+		return delta, nil
+	}
+	var a Fr
+	a.SetInt64(1)
+	for i := range xVec {
+		if xVec[i].IsZero() {
+			return nil, errors.New("zero x")
+		}
+		FrMul(&a, &a, &xVec[i])
+	}
+	for i := range xVec {
+		b := xVec[i]
+		for j := range xVec {
+			if j == i {
+				continue
+			}
+			var d Fr
+			FrSub(&d, &xVec[j], &xVec[i])
+			if d.IsZero() {
+				return nil, errors.New("same x")
+			}
+			FrMul(&b, &b, &d)
+		}
+		FrDiv(&delta[i], &a, &b)
+	}
+	return delta, nil
+}
+
+// FrLagrangeInterpolation --
+func FrLagrangeInterpolation(out *Fr, xVec []Fr, yVec []Fr) error {
+	if len(xVec) != len(yVec) {
+		return fmt.Errorf("err FrLagrangeInterpolation:bad size")
+	}
+	delta, err := lagrangeCoefficients(xVec)
+	if err != nil {
+		return fmt.Errorf("err FrLagrangeInterpolation")
+	}
+	var s Fr
+	for i := range yVec {
+		var t Fr
+		FrMul(&t, &yVec[i], &delta[i])
+		FrAdd(&s, &s, &t)
+	}
+	*out = s
+	return nil
+}
+
+// G1LagrangeInterpolation --
+func G1LagrangeInterpolation(out *G1, xVec []Fr, yVec []G1) error {
+	if len(xVec) != len(yVec) {
+		return fmt.Errorf("err G1LagrangeInterpolation:bad size")
+	}
+	delta, err := lagrangeCoefficients(xVec)
+	if err != nil {
+		return fmt.Errorf("err G1LagrangeInterpolation")
+	}
+	var s G1
+	for i := range yVec {
+		var t G1
+		G1Mul(&t, &yVec[i], &delta[i])
+		G1Add(&s, &s, &t)
+	}
+	*out = s
+	return nil
+}
+
+// G2LagrangeInterpolation --
+func G2LagrangeInterpolation(out *G2, xVec []Fr, yVec []G2) error {
+	if len(xVec) != len(yVec) {
+		return fmt.Errorf("err G2LagrangeInterpolation:bad size")
+	}
+	delta, err := lagrangeCoefficients(xVec)
+	if err != nil {
+		return fmt.Errorf("err G2LagrangeInterpolation")
+	}
+	var s G2
+	for i := range yVec {
+		var t G2
+		G2Mul(&t, &yVec[i], &delta[i])
+		G2Add(&s, &s, &t)
+	}
+	*out = s
+	return nil
+}
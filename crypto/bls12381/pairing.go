@@ -276,6 +276,24 @@ func (e *Engine) Result() *E {
 	return r
 }
 
+// MillerLoop computes the miller loop over the added pairs without applying
+// the final exponentiation and returns the result as target group element.
+func (e *Engine) MillerLoop() *E {
+	f := e.fp12.one()
+	if len(e.pairs) != 0 {
+		e.millerLoop(f)
+	}
+	e.Reset()
+	return f
+}
+
+// FinalExp applies the final exponentiation to the output of a miller loop.
+func (e *Engine) FinalExp(f *E) *E {
+	r := new(E).Set(f)
+	e.finalExp(r)
+	return r
+}
+
 // GT returns target group instance.
 func (e *Engine) GT() *GT {
 	return NewGT()
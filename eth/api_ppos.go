@@ -0,0 +1,67 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	xplugin "github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+// DumpPPOS returns the decoded ppos data committed to the base of the snapshot
+// db, along with the proposals and the restricting plans of the base block. The
+// restricting accounts are found through the preimages of the trie keys, the
+// dump fails if the node didn't record them.
+func (api *PrivateDebugAPI) DumpPPOS(ctx context.Context) (*xplugin.PPOSDump, error) {
+	return xplugin.DumpPPOS(snapshotdb.Instance(), api.stateAt)
+}
+
+// PposStateHash returns the digests of the ppos data kept in the snapshot db
+// under the key prefix at the block, per plugin domain and per next byte of the
+// keys. Comparing the digests of two nodes level by level leads to the first
+// key they disagree on. The block must lie between the base and the highest block
+// of the snapshot db.
+func (api *PrivateDebugAPI) PposStateHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, prefix hexutil.Bytes) (*xplugin.PPOSStateHash, error) {
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	// Only the canonical chain is committed to the snapshot db
+	number, hash := header.Number.Uint64(), header.Hash()
+	if api.eth.blockchain.GetCanonicalHash(number) != hash {
+		return nil, fmt.Errorf("block %#x is not canonical", hash)
+	}
+	return xplugin.PPOSStateHashAt(snapshotdb.Instance(), number, hash, prefix)
+}
+
+// stateAt returns the state of the canonical block of the number.
+func (api *PrivateDebugAPI) stateAt(number uint64) (xcom.StateDB, error) {
+	block := api.eth.blockchain.GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return api.eth.blockchain.StateAt(block.Root())
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/vm"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+)
+
+// The kinds of steps of a state diff.
+const (
+	stateDiffTransaction = "transaction" // Changes made by a transaction
+	stateDiffSystem      = "system"      // Changes made by the ppos plugins around the transactions
+)
+
+// stateDiffReplays numbers the replays to give each its own snapshotdb block.
+var stateDiffReplays uint64
+
+// StateDiffStep is the set of accounts changed by one step of a block.
+type StateDiffStep struct {
+	Type     string                               `json:"type"`
+	TxHash   *common.Hash                         `json:"txHash,omitempty"`
+	TxIndex  *hexutil.Uint                        `json:"txIndex,omitempty"`
+	Accounts map[common.Address]*AccountStateDiff `json:"accounts"`
+}
+
+// AccountStateDiff is the change of an account, a nil state meaning the account
+// doesn't exist. Storage changes are keyed by the hex encoded storage key.
+type AccountStateDiff struct {
+	Pre     *AccountStateSnapshot     `json:"pre"`
+	Post    *AccountStateSnapshot     `json:"post"`
+	Storage map[string]*StorageChange `json:"storage,omitempty"`
+}
+
+// AccountStateSnapshot is the state of an account besides its storage.
+type AccountStateSnapshot struct {
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// StorageChange is the change of a storage slot, an empty value meaning the
+// slot isn't set.
+type StorageChange struct {
+	Pre  hexutil.Bytes `json:"pre"`
+	Post hexutil.Bytes `json:"post"`
+}
+
+// StateDiffByNumber returns the accounts changed by the block, step by step: a
+// system step for BeginBlocker, one step per transaction and a system step for
+// EndBlocker.
+//
+// The block is replayed on top of the parent state, the ppos plugins and
+// contracts reading the snapshotdb as of the parent, and the replayed state must
+// end up with the root of the block. The state of the parent and, in ppos mode,
+// the parent in the snapshotdb must be available.
+func (api *PrivateDebugAPI) StateDiffByNumber(ctx context.Context, number rpc.BlockNumber) ([]*StateDiffStep, error) {
+	var block *types.Block
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		block = api.eth.blockchain.CurrentBlock()
+	} else {
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return api.stateDiffBlock(ctx, block)
+}
+
+// StateDiffByHash returns the accounts changed by the block, step by step. See
+// StateDiffByNumber.
+func (api *PrivateDebugAPI) StateDiffByHash(ctx context.Context, hash common.Hash) ([]*StateDiffStep, error) {
+	block := api.eth.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", hash)
+	}
+	return api.stateDiffBlock(ctx, block)
+}
+
+// StateDiffTransaction returns the accounts changed by the transaction, replayed
+// as part of its block. See StateDiffByNumber.
+func (api *PrivateDebugAPI) StateDiffTransaction(ctx context.Context, hash common.Hash) (*StateDiffStep, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block := api.eth.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	steps, err := api.stateDiffBlock(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if step.TxHash != nil && *step.TxHash == hash {
+			return step, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction index %d out of range for block %#x", index, blockHash)
+}
+
+// stateDiffBlock replays the block the way the state processor runs it and
+// gathers the changes of each step.
+func (api *PrivateDebugAPI) stateDiffBlock(ctx context.Context, block *types.Block) (steps []*StateDiffStep, err error) {
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := api.eth.blockchain.StateAt(parent.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state of parent %#x unavailable: %v", parent.Hash(), err)
+	}
+	var (
+		config   = api.eth.blockchain.Config()
+		header   = block.Header()
+		blockCtx = core.NewEVMBlockContext(header, api.eth.blockchain)
+		sdb      = snapshotdb.Instance()
+		reactor  = core.GetReactorInstance()
+	)
+	steps = make([]*StateDiffStep, 0, len(block.Transactions())+2)
+
+	// The plugins and the ppos contracts write to a replay block of the
+	// snapshotdb, which reads the data as of the parent
+	ppos := config.Cbft != nil && config.Cbft.ValidatorMode == common.PPOS_VALIDATOR_MODE && reactor != nil
+	if ppos {
+		blockCtx.BlockHash = crypto.Keccak256Hash(block.Hash().Bytes(), new(big.Int).SetUint64(atomic.AddUint64(&stateDiffReplays, 1)).Bytes())
+		if err := sdb.NewReplayBlock(block.Number(), block.ParentHash(), blockCtx.BlockHash); err != nil {
+			return nil, fmt.Errorf("ppos data of parent %#x unavailable: %v", parent.Hash(), err)
+		}
+		defer func() {
+			if discardErr := sdb.DiscardReplayBlock(blockCtx.BlockHash); discardErr != nil && err == nil {
+				steps, err = nil, fmt.Errorf("ppos data of parent %#x unavailable: %v", parent.Hash(), discardErr)
+			}
+		}()
+	}
+
+	pre := statedb.Copy()
+	if ppos {
+		if err := reactor.ReplayBeginBlocker(blockCtx.BlockHash, header, statedb); err != nil {
+			return nil, fmt.Errorf("BeginBlocker of block %#x failed: %v", block.Hash(), err)
+		}
+	}
+	steps = append(steps, &StateDiffStep{
+		Type:     stateDiffSystem,
+		Accounts: diffStates(pre, statedb, statedb.Modified()),
+	})
+	statedb.Finalise(true)
+
+	for i, tx := range block.Transactions() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		msg, err := tx.AsMessage(types.MakeSigner(config, gov.Gte120VersionState(statedb), gov.Gte140VersionState(statedb)))
+		if err != nil {
+			return nil, err
+		}
+		step, err := api.stateDiffTx(msg, blockCtx, sdb, statedb, tx.Hash(), block.Hash(), i)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	pre = statedb.Copy()
+	if ppos {
+		if err := reactor.ReplayEndBlocker(blockCtx.BlockHash, header, statedb); err != nil {
+			return nil, fmt.Errorf("EndBlocker of block %#x failed: %v", block.Hash(), err)
+		}
+	}
+	modified := statedb.Modified()
+	// The engine finalises the block with the root of the state as it is now
+	if root := statedb.IntermediateRoot(true); root != block.Root() {
+		return nil, fmt.Errorf("replayed state root %#x mismatches the root %#x of block %#x", root, block.Root(), block.Hash())
+	}
+	steps = append(steps, &StateDiffStep{
+		Type:     stateDiffSystem,
+		Accounts: diffStates(pre, statedb, modified),
+	})
+	return steps, nil
+}
+
+// stateDiffTx applies the message on top of the state and returns the changes
+// it made. The state is left finalised after the message.
+func (api *PrivateDebugAPI) stateDiffTx(msg core.Message, blockCtx vm.BlockContext, sdb snapshotdb.DB, statedb *state.StateDB, txHash, blockHash common.Hash, index int) (*StateDiffStep, error) {
+	pre := statedb.Copy()
+
+	statedb.Prepare(txHash, blockHash, index)
+	vmenv := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), sdb, statedb, api.eth.blockchain.Config(), vm.Config{})
+	if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+		return nil, fmt.Errorf("transaction %#x failed: %v", txHash, err)
+	}
+	// Gather the changes before finalising drops the journal
+	modified := statedb.Modified()
+	statedb.Finalise(true)
+
+	txIndex := hexutil.Uint(index)
+	return &StateDiffStep{
+		Type:     stateDiffTransaction,
+		TxHash:   &txHash,
+		TxIndex:  &txIndex,
+		Accounts: diffStates(pre, statedb, modified),
+	}, nil
+}
+
+// diffStates compares the given accounts and storage slots of the states,
+// leaving out the ones which didn't change.
+func diffStates(pre, post *state.StateDB, modified map[common.Address][][]byte) map[common.Address]*AccountStateDiff {
+	diffs := make(map[common.Address]*AccountStateDiff)
+	for addr, keys := range modified {
+		diff := &AccountStateDiff{
+			Pre:  accountSnapshot(pre, addr),
+			Post: accountSnapshot(post, addr),
+		}
+		for _, key := range keys {
+			preValue, postValue := pre.GetState(addr, key), post.GetState(addr, key)
+			if bytes.Equal(preValue, postValue) {
+				continue
+			}
+			if diff.Storage == nil {
+				diff.Storage = make(map[string]*StorageChange)
+			}
+			diff.Storage[hexutil.Encode(key)] = &StorageChange{Pre: preValue, Post: postValue}
+		}
+		if len(diff.Storage) == 0 && equalSnapshots(diff.Pre, diff.Post) {
+			continue
+		}
+		diffs[addr] = diff
+	}
+	return diffs
+}
+
+// accountSnapshot returns the state of the account, nil if it doesn't exist.
+func accountSnapshot(statedb *state.StateDB, addr common.Address) *AccountStateSnapshot {
+	if !statedb.Exist(addr) {
+		return nil
+	}
+	return &AccountStateSnapshot{
+		Balance:  (*hexutil.Big)(new(big.Int).Set(statedb.GetBalance(addr))),
+		Nonce:    hexutil.Uint64(statedb.GetNonce(addr)),
+		CodeHash: statedb.GetCodeHash(addr),
+	}
+}
+
+// equalSnapshots returns whether the account states are the same.
+func equalSnapshots(a, b *AccountStateSnapshot) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Balance.ToInt().Cmp(b.Balance.ToInt()) == 0 && a.Nonce == b.Nonce && a.CodeHash == b.CodeHash
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/accounts/abi/bind/backends"
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/x/restricting"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+)
+
+// Tests that the state diff of a block running the ppos plugins and holding a
+// ppos contract transaction is served step by step over RPC.
+func TestStateDiffPpos(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		from      = crypto.PubkeyToAddress(key.PublicKey)
+		to        = common.Address{0x01}
+		locked    = common.Address{0x02}
+		amount    = big.NewInt(params.LAT)
+		planFunds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.LAT))
+		db        = rawdb.NewMemoryDatabase()
+	)
+	sim, err := backends.NewSimulatedPposBackendWithDatabase(db, core.GenesisAlloc{
+		from: {Balance: new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.LAT))},
+	}, 100000000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	signer := types.NewEIP155Signer(sim.Blockchain().Config().ChainID)
+	send := func(nonce uint64, to common.Address, value *big.Int, data []byte) *types.Transaction {
+		tx, err := types.SignTx(types.NewTransaction(nonce, to, value, 1000000, big.NewInt(params.GVon), data), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sim.SendTransaction(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	input, err := rlp.EncodeToBytes([][]byte{
+		mustEncode(t, uint16(4000)),
+		mustEncode(t, locked),
+		mustEncode(t, []restricting.RestrictingPlan{{Epoch: 1, Amount: planFunds}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := send(0, to, amount, nil)
+	plan := send(1, cvm.RestrictingContractAddr, new(big.Int), input)
+	sim.Commit()
+	block := sim.Blockchain().CurrentBlock()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("debug", NewPrivateDebugAPI(&Ethereum{blockchain: sim.Blockchain(), chainDb: db})); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var steps []*StateDiffStep
+	if err := client.Call(&steps, "debug_stateDiffByNumber", hexutil.Uint64(block.NumberU64())); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 4 {
+		t.Fatalf("step count mismatch: have %d, want 4", len(steps))
+	}
+	for i, want := range []string{stateDiffSystem, stateDiffTransaction, stateDiffTransaction, stateDiffSystem} {
+		if steps[i].Type != want {
+			t.Errorf("step %d: type mismatch: have %s, want %s", i, steps[i].Type, want)
+		}
+	}
+	if steps[1].TxHash == nil || *steps[1].TxHash != transfer.Hash() || steps[2].TxHash == nil || *steps[2].TxHash != plan.Hash() {
+		t.Fatalf("transaction steps out of order")
+	}
+	// The transfer
+	if diff := steps[1].Accounts[to]; diff == nil || diff.Pre != nil || diff.Post.Balance.ToInt().Cmp(amount) != 0 {
+		t.Errorf("transfer: recipient diff mismatch: %+v", diff)
+	}
+	// The restricting plan, replayed on top of the ppos data of the parent
+	diff := steps[2].Accounts[cvm.RestrictingContractAddr]
+	if diff == nil || len(diff.Storage) == 0 {
+		t.Fatalf("restricting plan: contract diff missing")
+	}
+	if funds := new(big.Int).Sub(diff.Post.Balance.ToInt(), diff.Pre.Balance.ToInt()); funds.Cmp(planFunds) != 0 {
+		t.Errorf("restricting plan: locked funds mismatch: have %v, want %v", funds, planFunds)
+	}
+	if diff := steps[2].Accounts[from]; diff == nil || diff.Pre.Nonce != 1 || diff.Post.Nonce != 2 {
+		t.Errorf("restricting plan: sender diff mismatch: %+v", diff)
+	}
+	// EndBlocker stores the hash of the ppos data of the block
+	if diff := steps[3].Accounts[cvm.StakingContractAddr]; diff == nil || diff.Storage[hexutil.Encode(staking.GetPPOSHASHKey())] == nil {
+		t.Errorf("ppos hash change missing from the last system step")
+	}
+
+	var step *StateDiffStep
+	if err := client.Call(&step, "debug_stateDiffTransaction", plan.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(step, steps[2]) {
+		t.Errorf("transaction diff mismatch: have %+v, want %+v", step, steps[2])
+	}
+	// The replay leaves the chain alone
+	sim.Commit()
+	if head := sim.Blockchain().CurrentBlock().NumberU64(); head != block.NumberU64()+1 {
+		t.Errorf("head mismatch: have %d, want %d", head, block.NumberU64()+1)
+	}
+}
+
+func mustEncode(t *testing.T, val interface{}) []byte {
+	enc, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
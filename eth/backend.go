@@ -27,6 +27,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/wal"
 
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
 	"github.com/PlatONnetwork/PlatON-Go/x/gov"
 
 	"github.com/PlatONnetwork/PlatON-Go/x/handler"
@@ -100,7 +101,7 @@ type Ethereum struct {
 func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	// Ensure configuration values are compatible and sane
 	if config.SyncMode == downloader.LightSync {
-		return nil, errors.New("can't run PlatON in light sync mode, use les.LightPlatON")
+		return nil, errors.New("can't run PlatON in light sync mode, use light.LightPlatON")
 	}
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
@@ -280,6 +281,9 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = stack.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, core.NewTxPoolBlockChain(blockChainCache))
 
 	core.SenderCacher.SetTxPool(eth.txPool)
@@ -301,6 +305,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		eth.isLocalBlock, blockChainCache, config.VmTimeoutDuration)
 
 	reactor := core.NewBlockChainReactor(eth.EventMux(), eth.blockchain.Config().ChainID)
+	reactor.SetPPOSWritesDB(chainDb)
 	node.GetCryptoHandler().SetPrivateKey(stack.Config().NodeKey())
 
 	if engine, ok := eth.engine.(consensus.Bft); ok {
@@ -348,6 +353,17 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit); err != nil {
 		return nil, err
 	}
+	switch chainConfig.Cbft.ValidatorMode {
+	case "", common.STATIC_VALIDATOR_MODE:
+		eth.protocolManager.downloader.SetVerifier(verify.NewStaticVerifier(verify.GenesisValidators(chainConfig.Cbft.InitialNodes, 0)))
+	case common.PPOS_VALIDATOR_MODE:
+		eth.protocolManager.downloader.SetVerifier(verify.NewVerifier(verify.GenesisValidators(chainConfig.Cbft.InitialNodes, int(xcom.MaxConsensusVals()))))
+	}
+	if config.Checkpoint != nil {
+		if err := eth.protocolManager.downloader.SetCheckpoint(config.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
 	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil}
 	if eth.APIBackend.allowUnprotectedTxs {
 		log.Info("Unprotected transactions allowed")
@@ -569,6 +585,11 @@ func (s *Ethereum) Start() error {
 
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
+	// Advertise the archive nodes, so that they're found for the history
+	if s.config.NoPruning {
+		s.p2pServer.SetNodeRole(p2p.RoleArchive)
+	}
+	s.startForkIDUpdate()
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 
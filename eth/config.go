@@ -109,6 +109,12 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Checkpoint to start the fast sync from instead of the genesis
+	Checkpoint *downloader.Checkpoint `toml:",omitempty"`
+
+	// Light client options
+	LightServe bool `toml:",omitempty"` // Whether to serve the light clients
+
 	// Database options
 	SkipBcVersionCheck      bool `toml:"-"`
 	DatabaseHandles         int  `toml:"-"`
@@ -0,0 +1,281 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+var (
+	errCheckpointMismatch   = errors.New("checkpoint block mismatch")
+	errCheckpointAhead      = errors.New("checkpoint is ahead of the pivot of the peer")
+	errCheckpointValidators = errors.New("checkpoint validators mismatch")
+	errInvalidBlockProof    = errors.New("retrieved block proof is invalid")
+	errNoBlockProofs        = errors.New("peer can't serve the block proofs")
+)
+
+// Checkpoint is a trusted block finalized by cbft. A fast syncing node with a
+// checkpoint starts the header chain from the checkpoint instead of the genesis,
+// the validators of the round of the checkpoint are proven against the digest.
+type Checkpoint struct {
+	Number     uint64
+	Hash       common.Hash
+	Validators common.Hash // Digest of the validators of the round of the block
+}
+
+// ParseCheckpoint parses a checkpoint in the form of number:hash:validators.
+func ParseCheckpoint(s string) (*Checkpoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid checkpoint %q, want number:hash:validators", s)
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint number: %v", err)
+	}
+	var cp Checkpoint
+	cp.Number = number
+	if err := cp.Hash.UnmarshalText([]byte(parts[1])); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint hash: %v", err)
+	}
+	if err := cp.Validators.UnmarshalText([]byte(parts[2])); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint validators: %v", err)
+	}
+	return &cp, nil
+}
+
+// String implements the stringer interface, the result can be parsed by ParseCheckpoint.
+func (cp *Checkpoint) String() string {
+	return fmt.Sprintf("%d:%s:%s", cp.Number, cp.Hash.Hex(), cp.Validators.Hex())
+}
+
+func (cp *Checkpoint) MarshalText() ([]byte, error) {
+	return []byte(cp.String()), nil
+}
+
+func (cp *Checkpoint) UnmarshalText(text []byte) error {
+	parsed, err := ParseCheckpoint(string(text))
+	if err != nil {
+		return err
+	}
+	*cp = *parsed
+	return nil
+}
+
+// SetVerifier sets the verifier of the finality of the blocks synced from a
+// checkpoint, it knows the validators of the genesis or the static ones.
+func (d *Downloader) SetVerifier(verifier *verify.Verifier) {
+	d.verifier = verifier
+}
+
+// SetCheckpoint sets the trusted checkpoint of the fast sync. The checkpoint
+// and the validators up to the pivot are proven by the verifier, which must be
+// set beforehand.
+func (d *Downloader) SetCheckpoint(cp *Checkpoint) error {
+	if d.verifier == nil {
+		return errors.New("checkpoint sync is not supported by the validator mode")
+	}
+	d.checkpoint = cp
+	return nil
+}
+
+// verifyCheckpoint proves the finality of the checkpoint and the validators of
+// the rounds from the checkpoint up to the pivot. The validators of the round of
+// the checkpoint are proven by the election block preceding the round and have
+// to match the digest of the checkpoint, the QC of the checkpoint is signed by
+// them. The validators of each following round are proven by its election block,
+// which is finalized by the validators already proven. The blocks of the first
+// round are signed by the genesis validators, no election precedes them.
+func (d *Downloader) verifyCheckpoint(p *peerConnection, pivot *types.Header) error {
+	var (
+		cp      = d.syncCheckpoint
+		static  = d.verifier.Static()
+		start   = roundStart(cp.Number)
+		numbers []uint64
+	)
+	if !static && start > 1 {
+		numbers = append(numbers, start-xcom.ElectionDistance()-1)
+	}
+	numbers = append(numbers, cp.Number)
+	if !static {
+		for number := cp.Number + 1; number <= pivot.Number.Uint64(); number++ {
+			if xutil.IsElection(number) {
+				numbers = append(numbers, number)
+				number += xutil.ConsensusSize() - 1
+			}
+		}
+	}
+	proofs, err := d.fetchBlockProofs(p, numbers)
+	if err != nil {
+		return err
+	}
+	validators := d.verifier.Validators(1)
+	if !static && start > 1 {
+		if validators, err = verifyElection(proofs[0]); err != nil {
+			return err
+		}
+		proofs = proofs[1:]
+	}
+	if digest := validators.Digest(); digest != cp.Validators {
+		return fmt.Errorf("%w: have %s, want %s", errCheckpointValidators, digest.Hex(), cp.Validators.Hex())
+	}
+	if proofs[0].Header.Hash() != cp.Hash {
+		return errCheckpointMismatch
+	}
+	elections := make(map[uint64]common.Hash)
+	for i, proof := range proofs {
+		var (
+			number = proof.Header.Number.Uint64()
+			qc     *ctypes.QuorumCert
+			err    error
+		)
+		if i == 0 {
+			qc, err = d.verifier.VerifyCheckpoint(proof.Header, proof.Extra, validators)
+		} else {
+			qc, err = d.verifier.VerifyHeader(proof.Header, proof.Extra)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: block %d: %v", errInvalidBlockProof, number, err)
+		}
+		if static || !xutil.IsElection(number) || number > pivot.Number.Uint64() {
+			continue
+		}
+		elected, err := verifyElection(proof)
+		if err != nil {
+			return err
+		}
+		d.verifier.AddValidators(qc.Epoch+1, elected)
+		elections[number] = proof.Header.Hash()
+	}
+	d.syncElections = elections
+	log.Info("Proved the checkpoint", "number", cp.Number, "hash", cp.Hash, "elections", len(elections))
+	return nil
+}
+
+// verifyElection returns the validators elected at the block of the proof.
+func verifyElection(proof *BlockProof) (*cbfttypes.Validators, error) {
+	if proof.Election == nil {
+		return nil, fmt.Errorf("%w: election of block %d", errNoBlockProofs, proof.Header.Number.Uint64())
+	}
+	validators, err := verify.VerifyElection(proof.Header, proof.Election)
+	if err != nil {
+		return nil, fmt.Errorf("%w: election of block %d: %v", errInvalidBlockProof, proof.Header.Number.Uint64(), err)
+	}
+	return validators, nil
+}
+
+// roundStart returns the first block of the consensus round of the block.
+func roundStart(number uint64) uint64 {
+	if number == 0 {
+		return 1
+	}
+	return (number-1)/xutil.ConsensusSize()*xutil.ConsensusSize() + 1
+}
+
+// verifyFinality checks the QCs of the blocks synced from the checkpoint up to
+// the pivot, and that the election blocks are the ones proven along with the
+// checkpoint. The blocks after the pivot are executed on import.
+func (d *Downloader) verifyFinality(results []*fetchResult, pivot uint64) error {
+	for _, result := range results {
+		number := result.Header.Number.Uint64()
+		if number > pivot {
+			break
+		}
+		if _, err := d.verifier.VerifyHeader(result.Header, result.ExtraData); err != nil {
+			return fmt.Errorf("%w: block %d not finalized: %v", errInvalidChain, number, err)
+		}
+		if hash, ok := d.syncElections[number]; ok && hash != result.Header.Hash() {
+			return fmt.Errorf("%w: election block %d mismatch", errInvalidChain, number)
+		}
+	}
+	return nil
+}
+
+// DeliverBlockProofs injects a batch of block proofs received from a remote node.
+func (d *Downloader) DeliverBlockProofs(id string, packet *BlockProofsPacket) error {
+	return d.deliver(id, d.blockProofCh, &blockProofsPack{id, packet}, headerInMeter, headerDropMeter)
+}
+
+// fetchBlockProofs retrieves the proofs of the canonical blocks of the numbers
+// from the peer.
+func (d *Downloader) fetchBlockProofs(p *peerConnection, numbers []uint64) ([]*BlockProof, error) {
+	var proofs []*BlockProof
+	for len(numbers) > 0 {
+		n := len(numbers)
+		if n > MaxProofFetch {
+			n = MaxProofFetch
+		}
+		id := d.nextRangeID()
+		if err := p.peer.RequestBlockProofs(id, numbers[:n]); err != nil {
+			return nil, err
+		}
+		packet, err := d.waitBlockProofs(p, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(packet.Proofs) == 0 {
+			return nil, errNoBlockProofs
+		}
+		if len(packet.Proofs) > n {
+			return nil, fmt.Errorf("%w: unrequested proofs", errInvalidBlockProof)
+		}
+		for i, proof := range packet.Proofs {
+			if proof == nil || proof.Header == nil || proof.Header.Number.Uint64() != numbers[i] {
+				return nil, fmt.Errorf("%w: unrequested block", errInvalidBlockProof)
+			}
+		}
+		proofs = append(proofs, packet.Proofs...)
+		numbers = numbers[len(packet.Proofs):]
+	}
+	return proofs, nil
+}
+
+// waitBlockProofs waits for the response of the block proofs request from the peer.
+func (d *Downloader) waitBlockProofs(p *peerConnection, id uint64) (*BlockProofsPacket, error) {
+	ttl := d.requestTTL()
+	timeout := time.NewTimer(ttl)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+		case <-timeout.C:
+			p.log.Error("Waiting for block proofs timed out", "elapsed", ttl)
+			return nil, errTimeout
+		case pack := <-d.blockProofCh:
+			// Discard the stale responses of the previous requests
+			if pack.PeerId() != p.id || pack.(rangePack).requestID() != id {
+				log.Debug("Unrequested block proofs", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			return pack.(*blockProofsPack).packet, nil
+		}
+	}
+}
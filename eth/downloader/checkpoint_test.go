@@ -0,0 +1,199 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+func TestParseCheckpoint(t *testing.T) {
+	cp := &Checkpoint{Number: 1024, Hash: common.Hash{1}, Validators: common.Hash{2}}
+	parsed, err := ParseCheckpoint(cp.String())
+	if err != nil {
+		t.Fatalf("failed to parse checkpoint: %v", err)
+	}
+	if *parsed != *cp {
+		t.Fatalf("checkpoint mismatch: have %v, want %v", parsed, cp)
+	}
+	for _, s := range []string{
+		"",
+		"1024",
+		"1024:" + common.Hash{1}.Hex(),
+		"x:" + common.Hash{1}.Hex() + ":" + common.Hash{2}.Hex(),
+		"1024:0x01:" + common.Hash{2}.Hex(),
+	} {
+		if _, err := ParseCheckpoint(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+// newCheckpointTester creates a tester verifying the finality of the QC test chain.
+func newCheckpointTester() *downloadTester {
+	tester := newTester()
+	tester.downloader.SetVerifier(verify.NewVerifier(verify.GenesisValidators(testValidatorNodes, 0)))
+	return tester
+}
+
+// testCheckpoint returns the checkpoint of the block of the QC test chain.
+func testCheckpoint(number uint64) *Checkpoint {
+	return &Checkpoint{Number: number, Hash: testChainQC.chain[number], Validators: testRoundValidators(number).Digest()}
+}
+
+// Tests that a fast sync with a checkpoint starts the chain from the checkpoint,
+// including a checkpoint many rounds older than the pivot.
+func TestCheckpointSynchronisation(t *testing.T) {
+	t.Parallel()
+
+	pivot := uint64(testChainQC.baseNum)
+	for _, number := range []uint64{5, 45, pivot - 40, pivot - 10} {
+		tester := newCheckpointTester()
+		tester.newPeer("peer", rangeSyncVersion, testChainQC)
+		if err := tester.downloader.SetCheckpoint(testCheckpoint(number)); err != nil {
+			t.Fatalf("failed to set checkpoint: %v", err)
+		}
+		if err := tester.sync("peer", nil, FastSync); err != nil {
+			t.Fatalf("checkpoint %d: failed to synchronise blocks: %v", number, err)
+		}
+		if head := tester.CurrentFastBlock().NumberU64(); head != uint64(testChainQC.len()-1) {
+			t.Fatalf("checkpoint %d: fast block head mismatch: have %d, want %d", number, head, testChainQC.len()-1)
+		}
+		// The blocks before the checkpoint are never downloaded
+		if tester.GetHeaderByHash(testChainQC.chain[number-1]) != nil {
+			t.Fatalf("checkpoint %d: header before the checkpoint downloaded", number)
+		}
+		// The genesis, the checkpoint and the blocks after it
+		if hashes := len(tester.ownHashes); hashes != testChainQC.len()-int(number)+1 {
+			t.Fatalf("checkpoint %d: synchronised headers mismatch: have %d, want %d", number, hashes, testChainQC.len()-int(number)+1)
+		}
+		tester.terminate()
+	}
+}
+
+// Tests that a checkpoint not matching the chain or the validators, or a chain
+// not finalized by the validators proven from the checkpoint fails the sync.
+func TestCheckpointMismatch(t *testing.T) {
+	t.Parallel()
+
+	number := uint64(45)
+	forgeQC := func(n uint64) *testChain {
+		chain := testChainQC.copy(testChainQC.len())
+		block := chain.blockm[chain.chain[n]]
+		chain.blockm[block.Hash()] = block.WithBody(block.Transactions(), signTestQC(block.Header(), 0, 1))
+		return chain
+	}
+	tests := []struct {
+		checkpoint *Checkpoint
+		chain      *testChain
+		err        error
+	}{
+		// Unknown checkpoint block
+		{&Checkpoint{Number: number, Hash: common.Hash{1}, Validators: testCheckpoint(number).Validators}, testChainQC, errCheckpointMismatch},
+		// Unknown validators
+		{&Checkpoint{Number: number, Hash: testChainQC.chain[number], Validators: common.Hash{1}}, testChainQC, errCheckpointValidators},
+		// Validators of another round
+		{&Checkpoint{Number: number, Hash: testChainQC.chain[number], Validators: testCheckpoint(number + xutil.ConsensusSize()).Validators}, testChainQC, errCheckpointValidators},
+		// The checkpoint is not finalized
+		{testCheckpoint(number), forgeQC(number), errInvalidBlockProof},
+		// An election block after the checkpoint is not finalized
+		{testCheckpoint(number), forgeQC(100), errInvalidBlockProof},
+		// A block after the checkpoint is not finalized
+		{testCheckpoint(number), forgeQC(150), errInvalidChain},
+	}
+	for i, tt := range tests {
+		tester := newCheckpointTester()
+		tester.newPeer("peer", rangeSyncVersion, tt.chain)
+		tester.downloader.SetCheckpoint(tt.checkpoint)
+		if err := tester.sync("peer", nil, FastSync); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		tester.terminate()
+	}
+}
+
+// Tests that the validators elected after the checkpoint are proven by the ppos
+// writes of the election blocks.
+func TestCheckpointElections(t *testing.T) {
+	t.Parallel()
+
+	number := uint64(45)
+	tests := []struct {
+		forge func(writes [][2][]byte) [][2][]byte
+		err   error
+	}{
+		// The writes are not recorded by the peer
+		{func([][2][]byte) [][2][]byte { return nil }, errNoBlockProofs},
+		// The writes don't match the ppos hash
+		{func(writes [][2][]byte) [][2][]byte {
+			return [][2][]byte{{writes[0][0], append(common.CopyBytes(writes[0][1]), 0x01)}}
+		}, errInvalidBlockProof},
+	}
+	for i, tt := range tests {
+		tester := newCheckpointTester()
+
+		// The peer serves the recorded proofs, but the writes of the second
+		// election after the checkpoint are forged.
+		tester.peerDb = rawdb.NewMemoryDatabase()
+		for n := uint64(1); n < uint64(testChainQC.len()); n++ {
+			if !xutil.IsElection(n) {
+				continue
+			}
+			hash := testChainQC.chain[n]
+			accountProof, storageProof := rawdb.ReadPPOSHashProof(testDB, hash)
+			rawdb.WritePPOSHashProof(tester.peerDb, hash, accountProof, storageProof)
+
+			pposHash, err := verify.PPOSHash(testChainQC.headerm[hash], accountProof, storageProof)
+			if err != nil {
+				t.Fatalf("election %d: failed to prove ppos hash: %v", n, err)
+			}
+			writes := rawdb.ReadPPOSWrites(testDB, pposHash)
+			if n == 100 {
+				writes = tt.forge(writes)
+			}
+			if len(writes) > 0 {
+				rawdb.WritePPOSWrites(tester.peerDb, pposHash, writes)
+			}
+		}
+		tester.newPeer("peer", rangeSyncVersion, testChainQC)
+		tester.downloader.SetCheckpoint(testCheckpoint(number))
+		if err := tester.sync("peer", nil, FastSync); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		tester.terminate()
+	}
+}
+
+// Tests that the checkpoint can't be synced from the peers not serving the
+// block proofs.
+func TestCheckpointLegacyPeer(t *testing.T) {
+	t.Parallel()
+
+	tester := newCheckpointTester()
+	defer tester.terminate()
+
+	tester.newPeer("peer", 64, testChainQC)
+	tester.downloader.SetCheckpoint(testCheckpoint(45))
+	if err := tester.sync("peer", nil, FastSync); !errors.Is(err, errNoBlockProofs) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoBlockProofs)
+	}
+}
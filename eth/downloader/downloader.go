@@ -27,6 +27,7 @@ import (
 
 	ethereum "github.com/PlatONnetwork/PlatON-Go"
 	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
 	"github.com/PlatONnetwork/PlatON-Go/trie"
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
@@ -55,6 +56,7 @@ var (
 	MaxBodyFetch    = 128 // Amount of block bodies to be fetched per retrieval request
 	MaxReceiptFetch = 256 // Amount of transaction receipts to allow fetching per request
 	MaxStateFetch   = 384 // Amount of node state values to allow fetching per request
+	MaxProofFetch   = 16  // Amount of block proofs to allow fetching per request
 
 	rttMinEstimate   = 2 * time.Second  // Minimum round-trip time to target for download requests
 	rttMaxEstimate   = 20 * time.Second // Maximum round-trip time to target for download requests
@@ -105,6 +107,7 @@ type Downloader struct {
 	// see https://golang.org/pkg/sync/atomic/#pkg-note-BUG.
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
+	rangeReqID    uint64 // Last request ID of the range sync (eth/66)
 
 	mode uint32         // Synchronisation mode defining the strategy used (per sync cycle), use d.getMode() to get the SyncMode
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
@@ -135,6 +138,12 @@ type Downloader struct {
 	committed       int32
 	ancientLimit    uint64 // The maximum block number which can be regarded as ancient data.
 
+	// Checkpoint sync
+	verifier       *verify.Verifier       // Verifies the finality of the blocks synced from a checkpoint
+	checkpoint     *Checkpoint            // Trusted block to start the header chain of the fast sync from
+	syncCheckpoint *Checkpoint            // Checkpoint used by the current sync cycle, nil if synced from the local head
+	syncElections  map[uint64]common.Hash // Election blocks proven from the checkpoint by the current sync cycle
+
 	// Channels
 	headerCh          chan dataPack        // [eth/62] Channel receiving inbound block headers
 	bodyCh            chan dataPack        // [eth/62] Channel receiving inbound block bodies
@@ -146,11 +155,14 @@ type Downloader struct {
 	pposStorageCh     chan dataPack        // [eth/63] Channel receiving inbound ppos storage
 	pposStorageDoneCh chan struct{}        // Channel to signal termination completion
 	originAndPivotCh  chan dataPack        // [eth/63] Channel receiving origin and pivot block
+	pposRangeCh       chan dataPack        // [eth/66] Channel receiving inbound ranges of ppos storage
+	blockProofCh      chan dataPack        // [eth/66] Channel receiving inbound block proofs
 
 	// for stateFetcher
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [eth/63] Channel receiving inbound node state data
+	stateRangeCh   chan dataPack // [eth/66] Channel receiving inbound ranges of state data
 
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
@@ -210,6 +222,9 @@ type BlockChain interface {
 
 	// InsertReceiptChain inserts a batch of receipts into the local chain.
 	InsertReceiptChain(types.Blocks, []types.Receipts, uint64) (int, error)
+
+	// InsertCheckpoint inserts the header of a trusted checkpoint into the local chain.
+	InsertCheckpoint(*types.Header) error
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
@@ -237,8 +252,11 @@ func New(stateDb ethdb.Database, snapshotDB snapshotdb.DB, stateBloom *trie.Sync
 		pposStorageCh:    make(chan dataPack, 1),
 		pposInfoCh:       make(chan dataPack, 1),
 		originAndPivotCh: make(chan dataPack, 1),
+		pposRangeCh:      make(chan dataPack, 1),
+		blockProofCh:     make(chan dataPack, 1),
 		quitCh:           make(chan struct{}),
 		stateCh:          make(chan dataPack),
+		stateRangeCh:     make(chan dataPack, 1),
 		stateSyncStart:   make(chan *stateSync),
 		syncStatsState: stateSyncStats{
 			processed: rawdb.ReadFastTrieProgress(stateDb),
@@ -345,7 +363,8 @@ func (d *Downloader) Synchronise(id string, head common.Hash, bn *big.Int, mode
 
 	if errors.Is(err, errInvalidChain) || errors.Is(err, errBadPeer) || errors.Is(err, errTimeout) ||
 		errors.Is(err, errStallingPeer) || errors.Is(err, errEmptyHeaderSet) || errors.Is(err, errPeersUnavailable) ||
-		errors.Is(err, errTooOld) || errors.Is(err, errInvalidAncestor) {
+		errors.Is(err, errTooOld) || errors.Is(err, errInvalidAncestor) || errors.Is(err, errInvalidBlockProof) ||
+		errors.Is(err, errCheckpointMismatch) || errors.Is(err, errCheckpointValidators) || errors.Is(err, errInvalidPPOSRange) {
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
 		if d.dropPeer == nil {
 			// The dropPeer method is nil when `--copydb` is used for a local copy.
@@ -468,6 +487,17 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, bn *big.I
 	}
 	origin = originh.Number.Uint64()
 
+	if d.syncCheckpoint != nil {
+		// The header chain starts from the checkpoint, the blocks before are never
+		// downloaded, so the pivot state must be at or after the checkpoint.
+		if pivoth.Number.Uint64() < origin {
+			return errCheckpointAhead
+		}
+		if p.version < rangeSyncVersion {
+			return errNoBlockProofs
+		}
+	}
+
 	log.Info("synchronising findOrigin", "peer", p.id, "origin", origin, "pivot", pivoth.Number)
 	// Ensure our origin point is below any fast sync pivot point
 	d.committed = 1
@@ -479,6 +509,18 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, bn *big.I
 				return err
 			}
 			d.committed = 0
+
+			// The finality of the checkpoint and the validators up to the pivot
+			// are proven before anything is fetched.
+			if d.syncCheckpoint != nil {
+				if err := d.verifyCheckpoint(p, pivoth); err != nil {
+					return err
+				}
+				if err := d.blockchain.InsertCheckpoint(originh); err != nil {
+					return err
+				}
+				log.Info("Synchronising from the checkpoint", "number", origin, "hash", originh.Hash())
+			}
 		} else {
 			log.Info("no need synchronising", "peer", p.id, "origin", origin, "pivot", pivoth.Number)
 			d.committed = 0
@@ -521,7 +563,6 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, bn *big.I
 		// The peer would start to feed us valid blocks until head, resulting in all of
 		// the blocks might be written into the ancient store. A following mini-reorg
 		// could cause issues.
-		//todo checkpoint mod need add
 		if height > maxForkAncestry+1 {
 			d.ancientLimit = height - maxForkAncestry - 1
 		} else {
@@ -529,8 +570,12 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, bn *big.I
 		}
 		frozen, _ := d.stateDB.Ancients() // Ignore the error here since light client can also hit here.
 		// If a part of blockchain data has already been written into active store,
-		// disable the ancient style insertion explicitly.
-		if origin >= frozen && frozen != 0 {
+		// disable the ancient style insertion explicitly. The ancient store can't
+		// hold the chain starting from a checkpoint either.
+		if d.syncCheckpoint != nil {
+			d.ancientLimit = 0
+			log.Info("Disabling direct-ancient mode", "checkpoint", origin)
+		} else if origin >= frozen && frozen != 0 {
 			d.ancientLimit = 0
 			log.Info("Disabling direct-ancient mode", "origin", origin, "ancient", frozen-1)
 		} else if d.ancientLimit > 0 {
@@ -567,7 +612,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, bn *big.I
 			p.log.Error("set snapshotdb current fail", "err", err)
 			return errors.New("set current fail")
 		}
-		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest, pivoth.Number.Uint64()) })
+		fetchers = append(fetchers, func() error { return d.processFastSyncContent(p, latest, pivoth) })
 		fetchers = append(fetchers, func() error { return d.fetchPPOSStorage(p, pivoth) })
 	} else if mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -590,7 +635,14 @@ func (d *Downloader) findOrigin(p *peerConnection) (*types.Header, *types.Header
 	} else {
 		current = d.lightchain.CurrentHeader()
 	}
-	currentNumber := current.Number.Uint64()
+	currentNumber, currentHash := current.Number.Uint64(), current.Hash()
+
+	// A fast sync behind the checkpoint starts from the checkpoint instead.
+	d.syncCheckpoint = nil
+	if mode == FastSync && d.checkpoint != nil && currentNumber < d.checkpoint.Number {
+		d.syncCheckpoint = d.checkpoint
+		currentNumber, currentHash = d.checkpoint.Number, d.checkpoint.Hash
+	}
 	go p.peer.RequestOriginAndPivotByCurrent(currentNumber)
 
 	ttl := d.requestTTL()
@@ -620,8 +672,11 @@ func (d *Downloader) findOrigin(p *peerConnection) (*types.Header, *types.Header
 				p.log.Error("not find  current block")
 				return nil, nil, errors.New("not find  current block")
 			}
-			if headers[0].Number.Uint64() != currentNumber || headers[0].Hash() != current.Hash() {
-				p.log.Error("retrieved hash chain is invalid", "current num", currentNumber, "remote current num", headers[0].Number.Uint64(), "current hash", current.Hash(), "remote current hash", headers[0].Hash())
+			if headers[0].Number.Uint64() != currentNumber || headers[0].Hash() != currentHash {
+				p.log.Error("retrieved hash chain is invalid", "current num", currentNumber, "remote current num", headers[0].Number.Uint64(), "current hash", currentHash, "remote current hash", headers[0].Hash())
+				if d.syncCheckpoint != nil {
+					return nil, nil, errCheckpointMismatch
+				}
 				return nil, nil, errInvalidChain
 			}
 			if headers[1] == nil {
@@ -643,10 +698,10 @@ func (d *Downloader) findOrigin(p *peerConnection) (*types.Header, *types.Header
 
 // Latest is the  remote currentHeader, pivot is remote snapshotDB base num
 func (d *Downloader) fetchPPOSInfo(p *peerConnection) (latest *types.Header, pivot *types.Header, err error) {
+	if p.version >= rangeSyncVersion {
+		return d.fetchPPOSRangeInfo(p)
+	}
 	p.log.Debug("Retrieving latest ppos info cache from remote peer")
-	var current *types.Block
-
-	current = d.blockchain.CurrentFastBlock()
 
 	timeout := time.NewTimer(0) // timer to dump a non-responsive active peer
 	<-timeout.C                 // timeout channel should be initially empty
@@ -674,15 +729,8 @@ func (d *Downloader) fetchPPOSInfo(p *peerConnection) (latest *types.Header, piv
 				p.log.Error("pivot should not be nil")
 				return nil, nil, errors.New("pivot should not be nil")
 			}
-			pivotNumber := pposDada.pivot.Number
-			if pivotNumber.Cmp(pposDada.latest.Number) > 0 {
-				p.log.Error("pivotNumber is larger than latestNumber", "pivotNumber", pivotNumber.Uint64(), "latestNumber", pposDada.latest.Number.Uint64())
-				return nil, nil, errors.New("pivotNumber is larger than latestNumber")
-			}
-
-			if current.NumberU64() >= pposDada.pivot.Number.Uint64() {
-				p.log.Error("current is larger than pposDada.pivot", "current", current.NumberU64(), "pposDada.pivot", pposDada.pivot)
-				return nil, nil, errors.New("pivotNumber is larger than latestNumber")
+			if err := d.verifyPPOSInfo(p, pposDada.latest, pposDada.pivot); err != nil {
+				return nil, nil, err
 			}
 			latest = pposDada.latest
 			return latest, pposDada.pivot, nil
@@ -695,6 +743,22 @@ func (d *Downloader) fetchPPOSInfo(p *peerConnection) (latest *types.Header, piv
 	}
 }
 
+// verifyPPOSInfo checks the pivot of the peer against its latest header and
+// the local fast block.
+func (d *Downloader) verifyPPOSInfo(p *peerConnection, latest, pivot *types.Header) error {
+	pivotNumber := pivot.Number
+	if pivotNumber.Cmp(latest.Number) > 0 {
+		p.log.Error("pivotNumber is larger than latestNumber", "pivotNumber", pivotNumber.Uint64(), "latestNumber", latest.Number.Uint64())
+		return errors.New("pivotNumber is larger than latestNumber")
+	}
+	current := d.blockchain.CurrentFastBlock()
+	if current.NumberU64() >= pivotNumber.Uint64() {
+		p.log.Error("current is larger than pposDada.pivot", "current", current.NumberU64(), "pposDada.pivot", pivot)
+		return errors.New("pivotNumber is larger than latestNumber")
+	}
+	return nil
+}
+
 // setFastSyncStatus set status to snapshot db when fast sync begin
 // if  the user close platon when sync not finish,set status fail
 // if the sync is complete,will del the key
@@ -738,6 +802,9 @@ func (d *Downloader) fetchPPOSStorage(p *peerConnection, pivot *types.Header) (e
 	if err := d.setFastSyncStatus(FastSyncBegin); err != nil {
 		return err
 	}
+	if p.version >= rangeSyncVersion {
+		return d.fetchPPOSRanges(p, pivot)
+	}
 
 	var count int64
 	for {
@@ -1551,10 +1618,17 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 
 // processFastSyncContent takes fetch results from the queue and writes them to the
 // database. It also controls the synchronisation of state nodes of the pivot block.
-func (d *Downloader) processFastSyncContent(latest *types.Header, pivot uint64) error {
+func (d *Downloader) processFastSyncContent(p *peerConnection, latest *types.Header, pivot *types.Header) error {
+	// The state ranges are only served for the pivot block, of which the ppos
+	// storage is synced too.
+	startSync, root := d.syncState, latest.Root
+	if p.version >= rangeSyncVersion {
+		startSync = func(root common.Hash) *stateSync { return d.syncStateRanges(p, root) }
+		root = pivot.Root
+	}
 	// Start syncing state of the reported head block. This should get us most of
 	// the state of the pivot block.
-	sync := d.syncState(latest.Root)
+	sync := startSync(root)
 	defer func() {
 		// The `sync` object is replaced every time the pivot moves. We need to
 		// defer close the very last active one, hence the lazy evaluation vs.
@@ -1594,21 +1668,28 @@ func (d *Downloader) processFastSyncContent(latest *types.Header, pivot uint64)
 		if d.chainInsertHook != nil {
 			d.chainInsertHook(results)
 		}
+		if d.syncCheckpoint != nil {
+			if err := d.verifyFinality(results, pivot.Number.Uint64()); err != nil {
+				return err
+			}
+		}
 		if oldPivot != nil {
 			results = append(append([]*fetchResult{oldPivot}, oldTail...), results...)
 		}
 
-		P, beforeP, afterP := splitAroundPivot(pivot, results)
+		P, beforeP, afterP := splitAroundPivot(pivot.Number.Uint64(), results)
 		if err := d.commitFastSyncData(beforeP, sync); err != nil {
 			return err
 		}
 		if P != nil {
 			// If new pivot block found, cancel old state retrieval and restart
 			if oldPivot != P {
-				sync.Cancel()
-				sync = d.syncState(P.Header.Root)
+				if sync.root != P.Header.Root {
+					sync.Cancel()
+					sync = startSync(P.Header.Root)
 
-				go closeOnErr(sync)
+					go closeOnErr(sync)
+				}
 				oldPivot = P
 			}
 			// Wait for completion, occasionally checking for pivot staleness
@@ -1625,6 +1706,9 @@ func (d *Downloader) processFastSyncContent(latest *types.Header, pivot uint64)
 				if sync.err != nil {
 					return sync.err
 				}
+				if err := d.verifyPPOSBase(p, P.Header); err != nil {
+					return err
+				}
 				if err := d.commitPivotBlock(P); err != nil {
 					return err
 				}
@@ -37,6 +37,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb/memorydb"
 	"github.com/PlatONnetwork/PlatON-Go/event"
 	"github.com/PlatONnetwork/PlatON-Go/trie"
 	_ "github.com/PlatONnetwork/PlatON-Go/x/xcom"
@@ -71,6 +72,8 @@ type downloadTester struct {
 	ancientBlocks   map[common.Hash]*types.Block   // Ancient blocks belonging to the tester
 	ancientReceipts map[common.Hash]types.Receipts // Ancient receipts belonging to the tester
 
+	checkpoint common.Hash // Checkpoint header inserted without its block
+
 	lock sync.RWMutex
 }
 
@@ -349,6 +352,18 @@ func (dl *downloadTester) InsertChain(blocks types.Blocks) (int, error) {
 	return len(blocks), nil
 }
 
+// InsertCheckpoint injects the header of a checkpoint into the simulated chain.
+func (dl *downloadTester) InsertCheckpoint(header *types.Header) error {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	hash := header.Hash()
+	dl.ownHashes = append(dl.ownHashes, hash)
+	dl.ownHeaders[hash] = header
+	dl.checkpoint = hash
+	return nil
+}
+
 // InsertReceiptChain injects a new batch of receipts into the simulated chain.
 func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []types.Receipts, ancientLimit uint64) (i int, err error) {
 	dl.lock.Lock()
@@ -359,7 +374,8 @@ func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []typ
 			return i, errors.New("unknown owner")
 		}
 		if _, ok := dl.ancientBlocks[blocks[i].ParentHash()]; !ok {
-			if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok {
+			// The blocks of a checkpoint sync start after the checkpoint
+			if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok && blocks[i].ParentHash() != dl.checkpoint {
 				return i, errors.New("unknown parent")
 			}
 		}
@@ -575,6 +591,72 @@ func (dlp *downloadTesterPeer) RequestOriginAndPivotByCurrent(c uint64) error {
 	return nil
 }
 
+// RequestAccountRange constructs a getAccountRange method associated with a
+// particular peer in the download tester, serving the state of the peer database.
+func (dlp *downloadTesterPeer) RequestAccountRange(id uint64, root, origin common.Hash, bytes uint64) error {
+	accounts, proof := ServeAccountRange(trie.NewDatabase(dlp.dl.peerDb), root, origin, bytes)
+	go dlp.dl.downloader.DeliverAccountRange(dlp.id, &AccountRangePacket{ID: id, Accounts: accounts, Proof: proof})
+	return nil
+}
+
+// RequestStorageRanges constructs a getStorageRanges method associated with a
+// particular peer in the download tester.
+func (dlp *downloadTesterPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	slots, proof := ServeStorageRanges(trie.NewDatabase(dlp.dl.peerDb), root, accounts, origin, bytes)
+	go dlp.dl.downloader.DeliverStorageRanges(dlp.id, &StorageRangesPacket{ID: id, Slots: slots, Proof: proof})
+	return nil
+}
+
+// RequestByteCodes constructs a getByteCodes method associated with a particular
+// peer in the download tester.
+func (dlp *downloadTesterPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	codes := ServeByteCodes(dlp.dl.peerDb, hashes, bytes)
+	go dlp.dl.downloader.DeliverByteCodes(dlp.id, &ByteCodesPacket{ID: id, Codes: codes})
+	return nil
+}
+
+// RequestPPOSRange constructs a getPPOSRange method associated with a particular
+// peer in the download tester, serving the ppos data of the chain as the base of
+// the pivot.
+func (dlp *downloadTesterPeer) RequestPPOSRange(id uint64, number uint64, origin []byte, bytes uint64) error {
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	packet := &PPOSRangePacket{ID: id}
+	pivot := dlp.chain.headerm[dlp.chain.chain[dlp.chain.baseNum]]
+	if number == 0 || number == pivot.Number.Uint64() {
+		db := memorydb.New()
+		for _, kv := range dlp.chain.pposData {
+			db.Put(kv[0], kv[1])
+		}
+		iter := db.NewIterator(nil, origin)
+		packet.KVs, packet.More = ServePPOSRange(iter, bytes)
+		iter.Release()
+		packet.Checksum = PPOSRangeChecksum(packet.KVs)
+		packet.Latest, packet.Pivot = dlp.chain.headBlock().Header(), pivot
+	}
+	go dlp.dl.downloader.DeliverPPOSRange(dlp.id, packet)
+	return nil
+}
+
+// RequestBlockProofs constructs a getBlockProofs method associated with a
+// particular peer in the download tester, serving the proofs recorded in the
+// peer database.
+func (dlp *downloadTesterPeer) RequestBlockProofs(id uint64, numbers []uint64) error {
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	packet := &BlockProofsPacket{ID: id}
+	for _, number := range numbers {
+		if number >= uint64(dlp.chain.len()) {
+			break
+		}
+		packet.Proofs = append(packet.Proofs, ServeBlockProof(dlp.dl.peerDb, dlp.chain.blockm[dlp.chain.chain[number]]))
+	}
+	go dlp.dl.downloader.DeliverBlockProofs(dlp.id, packet)
+	return nil
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int, base int64) {
@@ -634,6 +716,8 @@ func TestCanonicalSynchronisation63Fast(t *testing.T) { testCanonicalSynchronisa
 
 func TestCanonicalSynchronisation64Full(t *testing.T) { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T) { testCanonicalSynchronisation(t, 64, FastSync) }
+func TestCanonicalSynchronisation66Full(t *testing.T) { testCanonicalSynchronisation(t, 66, FullSync) }
+func TestCanonicalSynchronisation66Fast(t *testing.T) { testCanonicalSynchronisation(t, 66, FastSync) }
 
 func TestCanonicalSynchronisation64Light(t *testing.T) {
 	testCanonicalSynchronisation(t, 64, LightSync)
@@ -1661,6 +1745,26 @@ func (ftp *floodingTestPeer) RequestOriginAndPivotByCurrent(d uint64) error {
 	return ftp.peer.RequestOriginAndPivotByCurrent(d)
 }
 
+func (ftp *floodingTestPeer) RequestAccountRange(id uint64, root, origin common.Hash, bytes uint64) error {
+	return ftp.peer.RequestAccountRange(id, root, origin, bytes)
+}
+
+func (ftp *floodingTestPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	return ftp.peer.RequestStorageRanges(id, root, accounts, origin, bytes)
+}
+
+func (ftp *floodingTestPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	return ftp.peer.RequestByteCodes(id, hashes, bytes)
+}
+
+func (ftp *floodingTestPeer) RequestPPOSRange(id uint64, number uint64, origin []byte, bytes uint64) error {
+	return ftp.peer.RequestPPOSRange(id, number, origin, bytes)
+}
+
+func (ftp *floodingTestPeer) RequestBlockProofs(id uint64, numbers []uint64) error {
+	return ftp.peer.RequestBlockProofs(id, numbers)
+}
+
 func (ftp *floodingTestPeer) RequestHeadersByNumber(from uint64, count, skip int, reverse bool) error {
 	deliveriesDone := make(chan struct{}, 500)
 	for i := 0; i < cap(deliveriesDone); i++ {
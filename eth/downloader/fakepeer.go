@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
 	"github.com/PlatONnetwork/PlatON-Go/log"
@@ -30,6 +31,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
 )
 
 // FakePeer is a mock downloader peer that operates on a local database instance
@@ -250,3 +252,66 @@ func (p *FakePeer) RequestOriginAndPivotByCurrent(m uint64) error {
 	}
 	return nil
 }
+
+// RequestAccountRange implements downloader.Peer, returning a range of accounts
+// of the state trie starting at the origin.
+func (p *FakePeer) RequestAccountRange(id uint64, root, origin common.Hash, bytes uint64) error {
+	accounts, proof := ServeAccountRange(trie.NewDatabase(p.db), root, origin, bytes)
+	p.dl.DeliverAccountRange(p.id, &AccountRangePacket{ID: id, Accounts: accounts, Proof: proof})
+	return nil
+}
+
+// RequestStorageRanges implements downloader.Peer, returning the storage ranges
+// of the accounts.
+func (p *FakePeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	slots, proof := ServeStorageRanges(trie.NewDatabase(p.db), root, accounts, origin, bytes)
+	p.dl.DeliverStorageRanges(p.id, &StorageRangesPacket{ID: id, Slots: slots, Proof: proof})
+	return nil
+}
+
+// RequestByteCodes implements downloader.Peer, returning a batch of contract
+// codes corresponding to the hashes.
+func (p *FakePeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.dl.DeliverByteCodes(p.id, &ByteCodesPacket{ID: id, Codes: ServeByteCodes(p.db, hashes, bytes)})
+	return nil
+}
+
+// RequestPPOSRange implements downloader.Peer, returning a range of the current
+// snapshotdb base, which is nil if the base is no longer the requested one.
+func (p *FakePeer) RequestPPOSRange(id uint64, number uint64, origin []byte, bytes uint64) error {
+	snapshot, err := p.snapshotDB.GetBaseSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snapshot.Release()
+
+	packet := &PPOSRangePacket{ID: id}
+	if number == 0 || number == snapshot.Num.Uint64() {
+		iter := snapshot.NewIterator(&util.Range{Start: origin})
+		packet.KVs, packet.More = ServePPOSRange(iter, bytes)
+		iter.Release()
+		packet.Checksum = PPOSRangeChecksum(packet.KVs)
+		packet.Latest, packet.Pivot = p.hc.CurrentHeader(), p.hc.GetHeaderByNumber(snapshot.Num.Uint64())
+	}
+	p.dl.DeliverPPOSRange(p.id, packet)
+	return nil
+}
+
+// RequestBlockProofs implements downloader.Peer, returning the proofs of the
+// canonical blocks of the numbers.
+func (p *FakePeer) RequestBlockProofs(id uint64, numbers []uint64) error {
+	packet := &BlockProofsPacket{ID: id}
+	for _, number := range numbers {
+		header := p.hc.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		block := rawdb.ReadBlock(p.db, header.Hash(), number)
+		if block == nil {
+			break
+		}
+		packet.Proofs = append(packet.Proofs, ServeBlockProof(p.db, block))
+	}
+	p.dl.DeliverBlockProofs(p.id, packet)
+	return nil
+}
@@ -91,6 +91,13 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 	RequestPPOSStorage() error
 	RequestOriginAndPivotByCurrent(uint64) error
+
+	// [eth/66] range sync
+	RequestAccountRange(id uint64, root, origin common.Hash, bytes uint64) error
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+	RequestPPOSRange(id uint64, number uint64, origin []byte, bytes uint64) error
+	RequestBlockProofs(id uint64, numbers []uint64) error
 }
 
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
@@ -123,6 +130,26 @@ func (w *lightPeerWrapper) RequestOriginAndPivotByCurrent(uint64) error {
 	panic("RequestOriginAndPivotByCurrent not supported in light client mode sync")
 }
 
+func (w *lightPeerWrapper) RequestAccountRange(uint64, common.Hash, common.Hash, uint64) error {
+	panic("RequestAccountRange not supported in light client mode sync")
+}
+
+func (w *lightPeerWrapper) RequestStorageRanges(uint64, common.Hash, []common.Hash, common.Hash, uint64) error {
+	panic("RequestStorageRanges not supported in light client mode sync")
+}
+
+func (w *lightPeerWrapper) RequestByteCodes(uint64, []common.Hash, uint64) error {
+	panic("RequestByteCodes not supported in light client mode sync")
+}
+
+func (w *lightPeerWrapper) RequestPPOSRange(uint64, uint64, []byte, uint64) error {
+	panic("RequestPPOSRange not supported in light client mode sync")
+}
+
+func (w *lightPeerWrapper) RequestBlockProofs(uint64, []uint64) error {
+	panic("RequestBlockProofs not supported in light client mode sync")
+}
+
 // newPeerConnection creates a new downloader peer.
 func newPeerConnection(id string, version int, peer Peer, logger log.Logger) *peerConnection {
 	return &peerConnection{
@@ -480,7 +507,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 65, idle, throughput)
+	return ps.idlePeers(62, 66, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -494,7 +521,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 65, idle, throughput)
+	return ps.idlePeers(62, 66, idle, throughput)
 }
 
 // ReceiptIdlePeers retrieves a flat list of all the currently receipt-idle peers
@@ -508,7 +535,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 65, idle, throughput)
+	return ps.idlePeers(63, 66, idle, throughput)
 }
 
 // NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
@@ -522,7 +549,7 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 65, idle, throughput)
+	return ps.idlePeers(63, 66, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
@@ -0,0 +1,306 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb/memorydb"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+// AccountData is an account of the state trie in a range response.
+type AccountData struct {
+	Hash common.Hash  // Hash of the account address
+	Body rlp.RawValue // RLP encoded account as stored in the trie
+}
+
+// StorageData is a slot of a storage trie in a range response.
+type StorageData struct {
+	Hash common.Hash // Key of the slot in the storage trie
+	Body []byte      // Value of the slot as stored in the trie
+}
+
+// AccountRangePacket is a contiguous range of accounts of the state trie, with
+// the boundary proofs of the range.
+type AccountRangePacket struct {
+	ID       uint64
+	Accounts []*AccountData
+	Proof    [][]byte
+}
+
+// StorageRangesPacket is the storage of a list of accounts. Only the last one
+// might be incomplete, in which case it's sent along with the boundary proofs.
+type StorageRangesPacket struct {
+	ID    uint64
+	Slots [][]*StorageData
+	Proof [][]byte
+}
+
+// ByteCodesPacket is a batch of contract codes.
+type ByteCodesPacket struct {
+	ID    uint64
+	Codes [][]byte
+}
+
+// PPOSRangePacket is a contiguous range of the snapshotdb base of the pivot,
+// along with the checksum of its key values computed by the serving peer.
+type PPOSRangePacket struct {
+	ID       uint64
+	Latest   *types.Header `rlp:"nil"`
+	Pivot    *types.Header `rlp:"nil"` // Nil if the base of the requested pivot is no longer served
+	KVs      []PPOSStorageKV
+	More     bool
+	Checksum common.Hash // Unauthenticated, see PPOSRangeChecksum
+}
+
+// BlockProof is a header along with the QC of the block, and the proof of the
+// validators elected at the block if it's an election block.
+type BlockProof struct {
+	Header   *types.Header
+	Extra    []byte                // Extra data of the block holding its QC
+	Election *verify.ElectionProof `rlp:"nil"` // Nil if not an election block or the election can't be proven
+}
+
+// BlockProofsPacket is a batch of block proofs in the order of the request, it
+// stops at the first unknown block.
+type BlockProofsPacket struct {
+	ID     uint64
+	Proofs []*BlockProof
+}
+
+// ServeAccountRange retrieves the accounts of the state trie starting at the
+// origin, until the soft size limit is reached. The boundary proofs are omitted
+// if the whole trie is returned.
+func ServeAccountRange(db *trie.Database, root, origin common.Hash, limit uint64) ([]*AccountData, [][]byte) {
+	tr, err := trie.New(root, db)
+	if err != nil {
+		log.Debug("Failed to open account trie", "root", root, "err", err)
+		return nil, nil
+	}
+	var (
+		accounts []*AccountData
+		size     uint64
+		abort    bool
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for it.Next() {
+		if size > 0 && size >= limit {
+			abort = true
+			break
+		}
+		hash, body := common.BytesToHash(it.Key), common.CopyBytes(it.Value)
+		accounts = append(accounts, &AccountData{Hash: hash, Body: body})
+		size += uint64(common.HashLength + len(body))
+	}
+	if it.Err != nil {
+		log.Debug("Failed to iterate account trie", "root", root, "err", it.Err)
+		return nil, nil
+	}
+	if !abort && origin == (common.Hash{}) {
+		return accounts, nil
+	}
+	var last []byte
+	if len(accounts) > 0 {
+		last = accounts[len(accounts)-1].Hash[:]
+	}
+	proof, err := proveRange(tr, origin[:], last)
+	if err != nil {
+		log.Debug("Failed to prove account range", "root", root, "err", err)
+		return nil, nil
+	}
+	return accounts, proof
+}
+
+// ServeStorageRanges retrieves the storage of the accounts of the state trie,
+// the origin only applies to the first account. The retrieval stops at the soft
+// size limit or after the first account if the origin is not empty, the last
+// account is proved if it's incomplete or started from the origin.
+func ServeStorageRanges(db *trie.Database, root common.Hash, accounts []common.Hash, origin common.Hash, limit uint64) ([][]*StorageData, [][]byte) {
+	accTrie, err := trie.New(root, db)
+	if err != nil {
+		log.Debug("Failed to open account trie", "root", root, "err", err)
+		return nil, nil
+	}
+	var (
+		slots [][]*StorageData
+		size  uint64
+	)
+	for i, account := range accounts {
+		if size > 0 && size >= limit {
+			break
+		}
+		blob, err := accTrie.TryGet(account[:])
+		if err != nil || len(blob) == 0 {
+			break
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			break
+		}
+		st, err := trie.New(acc.Root, db)
+		if err != nil {
+			break
+		}
+		var (
+			start   common.Hash
+			storage []*StorageData
+			abort   bool
+		)
+		if i == 0 {
+			start = origin
+		}
+		it := trie.NewIterator(st.NodeIterator(start[:]))
+		for it.Next() {
+			if size > 0 && size >= limit {
+				abort = true
+				break
+			}
+			hash, body := common.BytesToHash(it.Key), common.CopyBytes(it.Value)
+			storage = append(storage, &StorageData{Hash: hash, Body: body})
+			size += uint64(common.HashLength + len(body))
+		}
+		if it.Err != nil {
+			break
+		}
+		if abort || start != (common.Hash{}) {
+			var last []byte
+			if len(storage) > 0 {
+				last = storage[len(storage)-1].Hash[:]
+			}
+			proof, err := proveRange(st, start[:], last)
+			if err != nil {
+				log.Debug("Failed to prove storage range", "root", acc.Root, "err", err)
+				return slots, nil
+			}
+			return append(slots, storage), proof
+		}
+		slots = append(slots, storage)
+	}
+	return slots, nil
+}
+
+// ServeByteCodes retrieves the contract codes of the hashes until the soft size
+// limit is reached, the unknown codes are skipped.
+func ServeByteCodes(db ethdb.KeyValueReader, hashes []common.Hash, limit uint64) [][]byte {
+	var (
+		codes [][]byte
+		size  uint64
+	)
+	for _, hash := range hashes {
+		if size >= limit {
+			break
+		}
+		if hash == emptyCodeHash {
+			codes = append(codes, []byte{})
+			continue
+		}
+		if code := rawdb.ReadCode(db, hash); len(code) > 0 {
+			codes = append(codes, code)
+			size += uint64(len(code))
+		}
+	}
+	return codes
+}
+
+// ServePPOSRange retrieves the key values of the snapshotdb base from the
+// iterator until the soft size limit is reached, the keys only meaningful to
+// the local snapshotdb are skipped.
+func ServePPOSRange(iter ethdb.Iterator, limit uint64) (kvs []PPOSStorageKV, more bool) {
+	var size uint64
+	for iter.Next() {
+		key := iter.Key()
+		if bytes.Equal(key, []byte(snapshotdb.CurrentHighestBlock)) || bytes.Equal(key, []byte(snapshotdb.CurrentBaseNum)) || bytes.HasPrefix(key, []byte(snapshotdb.WalKeyPrefix)) {
+			continue
+		}
+		if size >= limit {
+			return kvs, true
+		}
+		kvs = append(kvs, PPOSStorageKV{common.CopyBytes(key), common.CopyBytes(iter.Value())})
+		size += uint64(len(key) + len(iter.Value()))
+	}
+	return kvs, false
+}
+
+// ServeBlockProof returns the proof of the block. The election of an election
+// block is proven by the recorded proofs of the ppos hash and the recorded ppos
+// writes, it's left out if they are not recorded.
+func ServeBlockProof(db ethdb.KeyValueReader, block *types.Block) *BlockProof {
+	proof := &BlockProof{Header: block.Header(), Extra: block.ExtraData()}
+	if !xutil.IsElection(block.NumberU64()) {
+		return proof
+	}
+	accountProof, storageProof := rawdb.ReadPPOSHashProof(db, block.Hash())
+	if len(accountProof) == 0 {
+		return proof
+	}
+	pposHash, err := verify.PPOSHash(proof.Header, accountProof, storageProof)
+	if err != nil {
+		log.Debug("Failed to prove ppos hash", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return proof
+	}
+	if writes := rawdb.ReadPPOSWrites(db, pposHash); len(writes) > 0 {
+		proof.Election = &verify.ElectionProof{AccountProof: accountProof, StorageProof: storageProof, Writes: writes}
+	}
+	return proof
+}
+
+// PPOSRangeChecksum returns the checksum of a range of the snapshotdb base. The
+// serving peer computes it over the key values it sends, so it only detects the
+// ranges corrupted in transit. Nothing in the chain commits to the whole base: a
+// malicious peer can forge the candidates, the delegations or the restricting
+// plans of a range along with its checksum, only the validator lists are checked
+// against the chain by verifyPPOSBase.
+func PPOSRangeChecksum(kvs []PPOSStorageKV) (h common.Hash) {
+	hw := sha3.NewLegacyKeccak256()
+	rlp.Encode(hw, kvs)
+	hw.Sum(h[:0])
+	return h
+}
+
+// proveRange creates the boundary proofs of the range from the origin to the
+// last key of the trie.
+func proveRange(tr *trie.Trie, origin, last []byte) ([][]byte, error) {
+	proof := memorydb.New()
+	if err := tr.Prove(origin, 0, proof); err != nil {
+		return nil, err
+	}
+	if last != nil {
+		if err := tr.Prove(last, 0, proof); err != nil {
+			return nil, err
+		}
+	}
+	var nodes [][]byte
+	it := proof.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		nodes = append(nodes, common.CopyBytes(it.Value()))
+	}
+	return nodes, nil
+}
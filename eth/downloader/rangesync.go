@@ -0,0 +1,596 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb/memorydb"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+const (
+	rangeSyncVersion   = 66         // Minimum protocol version of the peers serving the range sync
+	rangeResponseBytes = 512 * 1024 // Soft size limit of a range response requested from the peers
+	maxStorageAccounts = 128        // Amount of accounts to fetch the storage of per request
+)
+
+var (
+	errRangeUnavailable  = errors.New("peer can't serve the requested range")
+	errInvalidStateRange = errors.New("retrieved state range is invalid")
+	errInvalidPPOSRange  = errors.New("retrieved ppos range is invalid")
+	errPPOSRangePivot    = errors.New("ppos range of unexpected pivot")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// rangePack is a response of the range sync, matched to the request by the ID.
+type rangePack interface {
+	dataPack
+	requestID() uint64
+}
+
+// DeliverAccountRange injects a range of accounts received from a remote node.
+func (d *Downloader) DeliverAccountRange(id string, packet *AccountRangePacket) error {
+	return d.deliver(id, d.stateRangeCh, &accountRangePack{id, packet}, stateInMeter, stateDropMeter)
+}
+
+// DeliverStorageRanges injects a batch of storage ranges received from a remote node.
+func (d *Downloader) DeliverStorageRanges(id string, packet *StorageRangesPacket) error {
+	return d.deliver(id, d.stateRangeCh, &storageRangesPack{id, packet}, stateInMeter, stateDropMeter)
+}
+
+// DeliverByteCodes injects a batch of contract codes received from a remote node.
+func (d *Downloader) DeliverByteCodes(id string, packet *ByteCodesPacket) error {
+	return d.deliver(id, d.stateRangeCh, &byteCodesPack{id, packet}, stateInMeter, stateDropMeter)
+}
+
+// DeliverPPOSRange injects a range of ppos storage received from a remote node.
+func (d *Downloader) DeliverPPOSRange(id string, packet *PPOSRangePacket) error {
+	return d.deliver(id, d.pposRangeCh, &pposRangePack{id, packet}, pposStorageInMeter, pposStorageDropMeter)
+}
+
+// nextRangeID returns a new request ID of the range sync.
+func (d *Downloader) nextRangeID() uint64 {
+	return atomic.AddUint64(&d.rangeReqID, 1)
+}
+
+// syncStateRanges starts downloading state with the given root hash from the
+// ranges served by the peer.
+func (d *Downloader) syncStateRanges(p *peerConnection, root common.Hash) *stateSync {
+	s := newStateSync(d, root)
+	s.peer = p
+	return d.startStateSync(s)
+}
+
+// rangeLoop is the main loop of a state sync from the ranges of a peer. The
+// accounts are retrieved range by range along with the storage and the codes
+// they refer to, and the tries are rebuilt from the verified ranges.
+func (s *stateSync) rangeLoop() (err error) {
+	close(s.started)
+	if s.root == types.EmptyRootHash {
+		return nil
+	}
+	if ok, _ := s.d.stateDB.Has(s.root[:]); ok {
+		return nil
+	}
+	w := newRangeWriter(s.d.stateDB, s.d.stateBloom)
+	defer func() {
+		if cerr := w.flush(true); err == nil {
+			err = cerr
+		}
+	}()
+	var (
+		accTrie = trie.NewStackTrie(w)
+		known   = make(map[common.Hash]struct{}) // Storage roots and codes synced by this loop
+		origin  common.Hash
+	)
+	for {
+		start := time.Now()
+		accounts, more, err := s.fetchAccountRange(origin)
+		if err != nil {
+			return err
+		}
+		var (
+			storages, roots []common.Hash
+			codes           []common.Hash
+		)
+		for _, account := range accounts {
+			var acc state.Account
+			if err := rlp.DecodeBytes(account.Body, &acc); err != nil {
+				return fmt.Errorf("%w: %v", errInvalidStateRange, err)
+			}
+			if _, ok := known[acc.Root]; !ok && acc.Root != types.EmptyRootHash {
+				if ok, _ := s.d.stateDB.Has(acc.Root[:]); !ok {
+					storages, roots = append(storages, account.Hash), append(roots, acc.Root)
+				}
+				known[acc.Root] = struct{}{}
+			}
+			codeHash := common.BytesToHash(acc.CodeHash)
+			if _, ok := known[codeHash]; !ok && codeHash != emptyCodeHash {
+				if len(rawdb.ReadCode(s.d.stateDB, codeHash)) == 0 {
+					codes = append(codes, codeHash)
+				}
+				known[codeHash] = struct{}{}
+			}
+		}
+		slots, err := s.syncStorageRanges(w, storages, roots)
+		if err != nil {
+			return err
+		}
+		if err := s.syncByteCodes(w, codes); err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			accTrie.TryUpdate(account.Hash[:], account.Body)
+		}
+		if err := w.flush(false); err != nil {
+			return err
+		}
+		s.updateRangeStats(len(accounts)+slots+len(codes), time.Since(start))
+		if !more {
+			break
+		}
+		origin = incHash(accounts[len(accounts)-1].Hash)
+	}
+	root, err := accTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if root != s.root {
+		return fmt.Errorf("%w: state root mismatch, have %x, want %x", errInvalidStateRange, root, s.root)
+	}
+	return nil
+}
+
+// fetchAccountRange retrieves the range of accounts starting at the origin and
+// verifies it against the state root, returning whether there are more accounts.
+func (s *stateSync) fetchAccountRange(origin common.Hash) ([]*AccountData, bool, error) {
+	id := s.d.nextRangeID()
+	if err := s.peer.peer.RequestAccountRange(id, s.root, origin, rangeResponseBytes); err != nil {
+		return nil, false, err
+	}
+	pack, err := s.waitRange(id)
+	if err != nil {
+		return nil, false, err
+	}
+	packet := pack.(*accountRangePack).packet
+	if len(packet.Accounts) == 0 && len(packet.Proof) == 0 {
+		return nil, false, errRangeUnavailable
+	}
+	// The whole trie is expected if there is no proof
+	if len(packet.Proof) == 0 && origin != (common.Hash{}) {
+		return nil, false, fmt.Errorf("%w: unproved account range", errInvalidStateRange)
+	}
+	keys := make([][]byte, len(packet.Accounts))
+	values := make([][]byte, len(packet.Accounts))
+	for i, account := range packet.Accounts {
+		keys[i], values[i] = common.CopyBytes(account.Hash[:]), account.Body
+	}
+	last := origin[:]
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	err, more := trie.VerifyRangeProof(s.root, origin[:], last, keys, values, proofDB(packet.Proof))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", errInvalidStateRange, err)
+	}
+	return packet.Accounts, more, nil
+}
+
+// syncStorageRanges retrieves the storage tries of the accounts, the storage of
+// an account might be split into several ranges. The number of the synced slots
+// is returned.
+func (s *stateSync) syncStorageRanges(w *rangeWriter, accounts []common.Hash, roots []common.Hash) (int, error) {
+	var (
+		origin common.Hash     // Origin of the first account continued from the last response
+		st     *trie.StackTrie // Trie of the first account continued from the last response
+		slots  int
+	)
+	for len(accounts) > 0 {
+		n := len(accounts)
+		if n > maxStorageAccounts {
+			n = maxStorageAccounts
+		}
+		id := s.d.nextRangeID()
+		if err := s.peer.peer.RequestStorageRanges(id, s.root, accounts[:n], origin, rangeResponseBytes); err != nil {
+			return slots, err
+		}
+		pack, err := s.waitRange(id)
+		if err != nil {
+			return slots, err
+		}
+		packet := pack.(*storageRangesPack).packet
+		if len(packet.Slots) == 0 {
+			return slots, errRangeUnavailable
+		}
+		if len(packet.Slots) > n {
+			return slots, fmt.Errorf("%w: unrequested storage", errInvalidStateRange)
+		}
+		var (
+			done = len(packet.Slots)
+			next common.Hash
+			cont *trie.StackTrie
+		)
+		for i, storage := range packet.Slots {
+			keys := make([][]byte, len(storage))
+			values := make([][]byte, len(storage))
+			for j, slot := range storage {
+				keys[j], values[j] = common.CopyBytes(slot.Hash[:]), slot.Body
+			}
+			tr := st
+			if i > 0 || tr == nil {
+				tr = trie.NewStackTrie(w)
+			}
+			// Only the last storage might be incomplete, which must be proved
+			if i == len(packet.Slots)-1 && len(packet.Proof) > 0 {
+				var start common.Hash
+				if i == 0 {
+					start = origin
+				}
+				last := start[:]
+				if len(keys) > 0 {
+					last = keys[len(keys)-1]
+				}
+				err, more := trie.VerifyRangeProof(roots[i], start[:], last, keys, values, proofDB(packet.Proof))
+				if err != nil {
+					return slots, fmt.Errorf("%w: %v", errInvalidStateRange, err)
+				}
+				if more {
+					for j := range keys {
+						tr.TryUpdate(keys[j], values[j])
+					}
+					slots += len(keys)
+					done, next, cont = i, incHash(common.BytesToHash(last)), tr
+					break
+				}
+			} else if i == 0 && origin != (common.Hash{}) {
+				return slots, fmt.Errorf("%w: unproved storage range", errInvalidStateRange)
+			}
+			for j := range keys {
+				tr.TryUpdate(keys[j], values[j])
+			}
+			slots += len(keys)
+			if root, err := tr.Commit(); err != nil {
+				return slots, err
+			} else if root != roots[i] {
+				return slots, fmt.Errorf("%w: storage root mismatch, have %x, want %x", errInvalidStateRange, root, roots[i])
+			}
+		}
+		accounts, roots = accounts[done:], roots[done:]
+		origin, st = next, cont
+
+		if err := w.flush(false); err != nil {
+			return slots, err
+		}
+	}
+	return slots, nil
+}
+
+// syncByteCodes retrieves the contract codes of the hashes.
+func (s *stateSync) syncByteCodes(w *rangeWriter, hashes []common.Hash) error {
+	for len(hashes) > 0 {
+		n := len(hashes)
+		if n > MaxStateFetch {
+			n = MaxStateFetch
+		}
+		id := s.d.nextRangeID()
+		if err := s.peer.peer.RequestByteCodes(id, hashes[:n], rangeResponseBytes); err != nil {
+			return err
+		}
+		pack, err := s.waitRange(id)
+		if err != nil {
+			return err
+		}
+		pending := make(map[common.Hash]struct{}, n)
+		for _, hash := range hashes[:n] {
+			pending[hash] = struct{}{}
+		}
+		for _, code := range pack.(*byteCodesPack).packet.Codes {
+			hash := crypto.Keccak256Hash(code)
+			if _, ok := pending[hash]; !ok {
+				return fmt.Errorf("%w: unrequested code %x", errInvalidStateRange, hash)
+			}
+			w.writeCode(hash, code)
+			delete(pending, hash)
+		}
+		if len(pending) == n {
+			return errRangeUnavailable
+		}
+		var rest []common.Hash
+		for _, hash := range hashes[:n] {
+			if _, ok := pending[hash]; ok {
+				rest = append(rest, hash)
+			}
+		}
+		hashes = append(rest, hashes[n:]...)
+	}
+	return nil
+}
+
+// waitRange waits for the response of the range request from the peer.
+func (s *stateSync) waitRange(id uint64) (dataPack, error) {
+	timeout := time.NewTimer(s.d.requestTTL())
+	defer timeout.Stop()
+	for {
+		select {
+		case pack := <-s.d.stateRangeCh:
+			// Discard the stale responses of the previous requests
+			if pack.PeerId() != s.peer.id || pack.(rangePack).requestID() != id {
+				log.Debug("Unrequested state range", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			return pack, nil
+		case <-timeout.C:
+			s.peer.log.Warn("State range request timed out", "id", id)
+			return nil, errTimeout
+		case <-s.cancel:
+			return nil, errCancelStateFetch
+		case <-s.d.cancelCh:
+			return nil, errCanceled
+		}
+	}
+}
+
+// updateRangeStats bumps the state sync progress counters and displays a log
+// message for the user to see.
+func (s *stateSync) updateRangeStats(written int, duration time.Duration) {
+	s.d.syncStatsLock.Lock()
+	defer s.d.syncStatsLock.Unlock()
+
+	s.d.syncStatsState.processed += uint64(written)
+	log.Info("Imported new state ranges", "count", written, "elapsed", common.PrettyDuration(duration), "processed", s.d.syncStatsState.processed)
+	if written > 0 {
+		rawdb.WriteFastTrieProgress(s.d.stateDB, s.d.syncStatsState.processed)
+	}
+}
+
+// rangeWriter writes the trie nodes rebuilt from the ranges into the database
+// in batches. The stack tries only use the Put method of the store.
+type rangeWriter struct {
+	ethdb.KeyValueStore
+	batch ethdb.Batch
+	bloom *trie.SyncBloom
+}
+
+func newRangeWriter(db ethdb.KeyValueStore, bloom *trie.SyncBloom) *rangeWriter {
+	return &rangeWriter{KeyValueStore: db, batch: db.NewBatch(), bloom: bloom}
+}
+
+// Put writes a trie node into the batch.
+func (w *rangeWriter) Put(key []byte, value []byte) error {
+	if w.bloom != nil {
+		w.bloom.Add(key)
+	}
+	return w.batch.Put(key, value)
+}
+
+// writeCode writes a contract code into the batch.
+func (w *rangeWriter) writeCode(hash common.Hash, code []byte) {
+	if w.bloom != nil {
+		w.bloom.Add(hash[:])
+	}
+	rawdb.WriteCode(w.batch, hash, code)
+}
+
+// flush writes the batch into the database once it's large enough, or always
+// if forced.
+func (w *rangeWriter) flush(force bool) error {
+	if !force && w.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := w.batch.Write(); err != nil {
+		return err
+	}
+	w.batch.Reset()
+	return nil
+}
+
+// fetchPPOSRangeInfo retrieves the latest header and the pivot of the peer, the
+// peer keeps the snapshotdb base of the pivot for the following range requests.
+func (d *Downloader) fetchPPOSRangeInfo(p *peerConnection) (*types.Header, *types.Header, error) {
+	p.log.Debug("Retrieving latest ppos range info from remote peer")
+	id := d.nextRangeID()
+	if err := p.peer.RequestPPOSRange(id, 0, nil, 0); err != nil {
+		return nil, nil, err
+	}
+	packet, err := d.waitPPOSRange(p, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if packet.Latest == nil || packet.Pivot == nil {
+		p.log.Error("pivot should not be nil")
+		return nil, nil, errors.New("pivot should not be nil")
+	}
+	if err := d.verifyPPOSInfo(p, packet.Latest, packet.Pivot); err != nil {
+		return nil, nil, err
+	}
+	return packet.Latest, packet.Pivot, nil
+}
+
+// fetchPPOSRanges retrieves the snapshotdb base of the pivot range by range and
+// writes it into the local base.
+func (d *Downloader) fetchPPOSRanges(p *peerConnection, pivot *types.Header) error {
+	var (
+		origin []byte
+		count  int
+	)
+	for {
+		id := d.nextRangeID()
+		if err := p.peer.RequestPPOSRange(id, pivot.Number.Uint64(), origin, rangeResponseBytes); err != nil {
+			return err
+		}
+		packet, err := d.waitPPOSRange(p, id)
+		if err != nil {
+			return err
+		}
+		if packet.Pivot == nil || packet.Pivot.Hash() != pivot.Hash() {
+			return errPPOSRangePivot
+		}
+		if sum := PPOSRangeChecksum(packet.KVs); sum != packet.Checksum {
+			return fmt.Errorf("%w: checksum mismatch, have %x, want %x", errInvalidPPOSRange, sum, packet.Checksum)
+		}
+		kvs := make([][2][]byte, len(packet.KVs))
+		for i, kv := range packet.KVs {
+			if i == 0 && bytes.Compare(kv[0], origin) < 0 || i > 0 && bytes.Compare(packet.KVs[i-1][0], kv[0]) >= 0 {
+				return fmt.Errorf("%w: range is not monotonically increasing", errInvalidPPOSRange)
+			}
+			kvs[i] = kv
+		}
+		if len(kvs) > 0 {
+			if err := d.snapshotDB.WriteBaseDB(kvs); err != nil {
+				p.log.Error("write to base db fail", "err", err)
+				return errors.New("write to base db fail")
+			}
+		}
+		count += len(kvs)
+		if !packet.More {
+			log.Info("fetchPPOSStorage has finish", "kvs", count)
+			return nil
+		}
+		if len(kvs) == 0 {
+			return fmt.Errorf("%w: empty range", errInvalidPPOSRange)
+		}
+		origin = append(common.CopyBytes(kvs[len(kvs)-1][0]), 0x00)
+	}
+}
+
+// verifyPPOSBase binds the synced snapshotdb base to the chain by the validators
+// proven at the election blocks. The ppos hash in the state of a block only
+// commits to the ppos writes of the block, not to the whole base, so the lists
+// of the validators of the pivot round and of the round elected up to the pivot
+// are the parts of the base checked. The rest of the base is taken on trust from
+// the peer the ranges were retrieved from.
+func (d *Downloader) verifyPPOSBase(p *peerConnection, pivot *types.Header) error {
+	if d.verifier == nil || d.verifier.Static() || p.version < rangeSyncVersion {
+		return nil
+	}
+	var (
+		number = pivot.Number.Uint64()
+		start  = roundStart(number)
+		starts = []uint64{start}
+	)
+	if next := start + xutil.ConsensusSize(); next-xcom.ElectionDistance()-1 <= number {
+		starts = append(starts, next)
+	}
+	for _, start := range starts {
+		validators, err := d.provenValidators(p, pivot, start)
+		if err != nil {
+			return err
+		}
+		value, err := d.snapshotDB.GetBaseDB(staking.GetRoundValArrKey(start, start+xutil.ConsensusSize()-1))
+		if err != nil || len(value) == 0 {
+			return fmt.Errorf("%w: validators of round %d missing", errInvalidPPOSRange, start)
+		}
+		var queue staking.ValidatorQueue
+		if err := rlp.DecodeBytes(value, &queue); err != nil {
+			return fmt.Errorf("%w: validators of round %d: %v", errInvalidPPOSRange, start, err)
+		}
+		if plugin.BuildCbftValidators(start, queue).Digest() != validators.Digest() {
+			return fmt.Errorf("%w: validators of round %d mismatch", errInvalidPPOSRange, start)
+		}
+	}
+	return nil
+}
+
+// provenValidators returns the validators of the round starting at the block.
+// They are either known to the verifier, e.g. proven along with the checkpoint,
+// or proven by the election block of the round in the local chain.
+func (d *Downloader) provenValidators(p *peerConnection, pivot *types.Header, start uint64) (*cbfttypes.Validators, error) {
+	if validators := d.verifier.Validators((start-1)/xutil.ConsensusSize() + 1); validators != nil && validators.ValidBlockNumber == start {
+		return validators, nil
+	}
+	election := start - xcom.ElectionDistance() - 1
+	header := pivot
+	for header != nil && header.Number.Uint64() > election {
+		header = d.lightchain.GetHeaderByHash(header.ParentHash)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("%w: election block %d unknown", errInvalidChain, election)
+	}
+	proofs, err := d.fetchBlockProofs(p, []uint64{election})
+	if err != nil {
+		return nil, err
+	}
+	if proofs[0].Header.Hash() != header.Hash() {
+		return nil, fmt.Errorf("%w: election block %d mismatch", errInvalidBlockProof, election)
+	}
+	return verifyElection(proofs[0])
+}
+
+// waitPPOSRange waits for the response of the ppos range request from the peer.
+func (d *Downloader) waitPPOSRange(p *peerConnection, id uint64) (*PPOSRangePacket, error) {
+	ttl := d.requestTTL()
+	timeout := time.NewTimer(ttl)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+		case <-timeout.C:
+			p.log.Error("Waiting for ppos range timed out", "elapsed", ttl)
+			return nil, errTimeout
+		case pack := <-d.pposRangeCh:
+			// Discard the stale responses of the previous requests
+			if pack.PeerId() != p.id || pack.(rangePack).requestID() != id {
+				log.Debug("Unrequested ppos range", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			return pack.(*pposRangePack).packet, nil
+		}
+	}
+}
+
+// proofDB returns the database of the proof nodes, nil if there is no proof.
+func proofDB(proof [][]byte) ethdb.KeyValueReader {
+	if len(proof) == 0 {
+		return nil
+	}
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// incHash returns the next hash in lexicographical order.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
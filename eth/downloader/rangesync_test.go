@@ -0,0 +1,327 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb/memorydb"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+// Tests that a fast sync from an eth/66 peer retrieves the state and the ppos
+// storage of the pivot by ranges.
+func TestRangeSynchronisation(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// The pivot is the head, the state of the pivot isn't touched by the tester
+	// importing the blocks after it.
+	chain := testChainBase.shorten(snapshotDBBaseNum + 1)
+	tester.newPeer("peer", rangeSyncVersion, chain)
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len(), snapshotDBBaseNum)
+
+	// The whole state of the pivot must be available locally
+	pivot := chain.headerm[chain.chain[chain.baseNum]]
+	if accounts, _, _ := checkStateRanges(t, tester.stateDb, pivot.Root); accounts == 0 {
+		t.Fatalf("no account synchronised")
+	}
+	// So is the ppos storage
+	for _, kv := range chain.pposData {
+		if value, err := tester.snapshotdb.GetBaseDB(kv[0]); err != nil || !bytes.Equal(value, kv[1]) {
+			t.Fatalf("ppos storage %x mismatch: have %x, want %x, err %v", kv[0], value, kv[1], err)
+		}
+	}
+}
+
+// Tests that the state with contracts is rebuilt from the ranges, including the
+// storage split into several ranges.
+func TestRangeStateSync(t *testing.T) {
+	t.Parallel()
+
+	peerDb := rawdb.NewMemoryDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(peerDb))
+	for i := 0; i < 500; i++ {
+		addr := common.BytesToAddress(crypto.Keccak256(common.Int64ToBytes(int64(i))))
+		statedb.AddBalance(addr, big.NewInt(int64(i+1)))
+		if i%10 != 0 {
+			continue
+		}
+		statedb.SetCode(addr, append([]byte{0x60, 0x00}, common.Int64ToBytes(int64(i%30))...))
+		slots := 10
+		if i == 0 {
+			// Large enough for several storage ranges
+			slots = 20000
+		}
+		for j := 0; j < slots; j++ {
+			key := crypto.Keccak256(common.Int64ToBytes(int64(j)))
+			statedb.SetState(addr, key, common.Int64ToBytes(int64(i*j+1)))
+		}
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	statedb.Database().TrieDB().Commit(root, false, false)
+
+	tester := newTester()
+	defer tester.terminate()
+	tester.peerDb = peerDb
+	tester.newPeer("peer", rangeSyncVersion, testChainBase)
+
+	// The deliveries are only accepted during a sync
+	tester.downloader.cancelLock.Lock()
+	tester.downloader.cancelCh = make(chan struct{})
+	tester.downloader.cancelLock.Unlock()
+
+	s := tester.downloader.syncStateRanges(tester.downloader.peers.Peer("peer"), root)
+	if err := s.Wait(); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	accounts, storages, codes := checkStateRanges(t, tester.stateDb, root)
+	if accounts != 500 || storages != 50 || codes != 50 {
+		t.Fatalf("state mismatch: have %d accounts, %d storages, %d codes, want 500, 50, 50", accounts, storages, codes)
+	}
+}
+
+// checkStateRanges checks that the whole state of the root is available in the
+// database, returning the number of accounts, storage tries and codes.
+func checkStateRanges(t *testing.T, diskdb ethdb.Database, root common.Hash) (accounts, storages, codes int) {
+	t.Helper()
+
+	db := trie.NewDatabase(diskdb)
+	accTrie, err := trie.New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open state trie: %v", err)
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(nil))
+	for it.Next() {
+		accounts++
+		var acc state.Account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			t.Fatalf("failed to decode account: %v", err)
+		}
+		if acc.Root != types.EmptyRootHash {
+			storages++
+			st, err := trie.New(acc.Root, db)
+			if err != nil {
+				t.Fatalf("failed to open storage trie: %v", err)
+			}
+			sit := st.NodeIterator(nil)
+			for sit.Next(true) {
+			}
+			if sit.Error() != nil {
+				t.Fatalf("storage trie incomplete: %v", sit.Error())
+			}
+		}
+		if codeHash := common.BytesToHash(acc.CodeHash); codeHash != emptyCodeHash {
+			codes++
+			if code := rawdb.ReadCode(diskdb, codeHash); crypto.Keccak256Hash(code) != codeHash {
+				t.Fatalf("code %x missing", codeHash)
+			}
+		}
+	}
+	if it.Err != nil {
+		t.Fatalf("state trie incomplete: %v", it.Err)
+	}
+	return accounts, storages, codes
+}
+
+// Tests that the account ranges served in small pieces are proved and cover the
+// whole trie.
+func TestServeAccountRange(t *testing.T) {
+	db := trie.NewDatabase(memorydb.New())
+	tr, _ := trie.New(common.Hash{}, db)
+	for i := 0; i < 1000; i++ {
+		key := crypto.Keccak256(common.Int64ToBytes(int64(i)))
+		tr.Update(key, common.Int64ToBytes(int64(i)))
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	db.Commit(root, false, false)
+
+	// The whole trie is returned without proof
+	if accounts, proof := ServeAccountRange(db, root, common.Hash{}, 1024*1024); len(accounts) != 1000 || proof != nil {
+		t.Fatalf("whole trie mismatch: have %d accounts, %d proof nodes", len(accounts), len(proof))
+	}
+	var (
+		origin common.Hash
+		ranges int
+		st     = trie.NewStackTrie(nil)
+	)
+	for {
+		accounts, proof := ServeAccountRange(db, root, origin, 1024)
+		keys, values := make([][]byte, len(accounts)), make([][]byte, len(accounts))
+		for i, account := range accounts {
+			keys[i], values[i] = common.CopyBytes(account.Hash[:]), account.Body
+			st.Update(keys[i], values[i])
+		}
+		last := origin[:]
+		if len(keys) > 0 {
+			last = keys[len(keys)-1]
+		}
+		err, more := trie.VerifyRangeProof(root, origin[:], last, keys, values, proofDB(proof))
+		if err != nil {
+			t.Fatalf("range %d: failed to verify: %v", ranges, err)
+		}
+		// A tampered range must be rejected
+		if len(values) > 1 {
+			tampered := append([][]byte{}, values...)
+			tampered[0] = []byte{0x01}
+			if err, _ := trie.VerifyRangeProof(root, origin[:], last, keys, tampered, proofDB(proof)); err == nil {
+				t.Fatalf("range %d: tampered range verified", ranges)
+			}
+		}
+		ranges++
+		if !more {
+			break
+		}
+		origin = incHash(common.BytesToHash(last))
+	}
+	if ranges < 2 {
+		t.Fatalf("too few ranges: %d", ranges)
+	}
+	if st.Hash() != root {
+		t.Fatalf("rebuilt root mismatch: have %x, want %x", st.Hash(), root)
+	}
+}
+
+// Tests that the ppos ranges skip the local keys of the snapshotdb and cover the
+// whole base.
+func TestServePPOSRange(t *testing.T) {
+	db := memorydb.New()
+	var want []PPOSStorageKV
+	for i := 0; i < 100; i++ {
+		kv := PPOSStorageKV{common.Int64ToBytes(int64(i)), bytes.Repeat([]byte{byte(i)}, 10)}
+		db.Put(kv[0], kv[1])
+		want = append(want, kv)
+	}
+	db.Put([]byte(snapshotdb.CurrentBaseNum), []byte{0x01})
+	db.Put([]byte(snapshotdb.CurrentHighestBlock), []byte{0x01})
+	db.Put(append([]byte(snapshotdb.WalKeyPrefix), 0x01), []byte{0x01})
+
+	var (
+		have   []PPOSStorageKV
+		origin []byte
+	)
+	for {
+		iter := db.NewIterator(nil, origin)
+		kvs, more := ServePPOSRange(iter, 100)
+		iter.Release()
+		have = append(have, kvs...)
+		if !more {
+			break
+		}
+		origin = append(common.CopyBytes(kvs[len(kvs)-1][0]), 0x00)
+	}
+	if PPOSRangeChecksum(have) != PPOSRangeChecksum(want) {
+		t.Fatalf("ppos ranges mismatch: have %d kvs, want %d", len(have), len(want))
+	}
+}
+
+// Tests that the validators in the synced ppos storage are bound to the ones
+// proven at the election blocks, from the genesis or from a checkpoint.
+func TestRangeSyncProvenValidators(t *testing.T) {
+	t.Parallel()
+
+	for _, checkpoint := range []*Checkpoint{nil, testCheckpoint(45)} {
+		tester := newCheckpointTester()
+		tester.newPeer("peer", rangeSyncVersion, testChainQC)
+		if checkpoint != nil {
+			tester.downloader.SetCheckpoint(checkpoint)
+		}
+		if err := tester.sync("peer", nil, FastSync); err != nil {
+			t.Fatalf("checkpoint %v: failed to synchronise blocks: %v", checkpoint, err)
+		}
+		tester.terminate()
+	}
+}
+
+// Tests that forged validators in the ppos storage fail the sync and the peer
+// is dropped.
+func TestRangeSyncForgedValidators(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pivot = uint64(testChainQC.baseNum)
+		start = roundStart(pivot)
+		next  = start + xutil.ConsensusSize()
+	)
+	forge := func(key []byte, value []byte) *testChain {
+		chain := testChainQC.copy(testChainQC.len())
+		chain.pposData = nil
+		for _, kv := range testChainQC.pposData {
+			if bytes.Equal(kv[0], key) {
+				if value == nil {
+					continue
+				}
+				kv = [2][]byte{kv[0], value}
+			}
+			chain.pposData = append(chain.pposData, kv)
+		}
+		return chain
+	}
+	encode := func(queue staking.ValidatorQueue) []byte {
+		value, err := rlp.EncodeToBytes(queue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	roundKey := func(start uint64) []byte {
+		return staking.GetRoundValArrKey(start, start+xutil.ConsensusSize()-1)
+	}
+	tests := []*testChain{
+		// The validators of the pivot round are the ones of another round
+		forge(roundKey(start), encode(testRoundQueue(next))),
+		// The validators of the round elected at the pivot are reordered
+		forge(roundKey(next), encode(append(testRoundQueue(next)[1:], testRoundQueue(next)[0]))),
+		// The validators of the pivot round are left out
+		forge(roundKey(start), nil),
+	}
+	for i, chain := range tests {
+		tester := newCheckpointTester()
+		tester.newPeer("peer", rangeSyncVersion, chain)
+		if err := tester.downloader.Synchronise("peer", chain.headBlock().Hash(), big.NewInt(1), FastSync); !errors.Is(err, errInvalidPPOSRange) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, errInvalidPPOSRange)
+		}
+		if _, ok := tester.peers["peer"]; ok {
+			t.Errorf("test %d: peer with forged ppos storage not dropped", i)
+		}
+		tester.terminate()
+	}
+}
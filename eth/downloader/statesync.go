@@ -60,8 +60,11 @@ type stateSyncStats struct {
 
 // syncState starts downloading state with the given root hash.
 func (d *Downloader) syncState(root common.Hash) *stateSync {
-	// Create the state sync
-	s := newStateSync(d, root)
+	return d.startStateSync(newStateSync(d, root))
+}
+
+// startStateSync hands the state sync over to the state fetcher.
+func (d *Downloader) startStateSync(s *stateSync) *stateSync {
 	select {
 	case d.stateSyncStart <- s:
 		// If we tell the statesync to restart with a new root, we also need
@@ -86,6 +89,7 @@ func (d *Downloader) stateFetcher() {
 			}
 		case <-d.stateCh:
 			// Ignore state responses while no sync is running.
+		case <-d.stateRangeCh:
 		case <-d.quitCh:
 			return
 		}
@@ -99,7 +103,11 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 		active   = make(map[string]*stateReq) // Currently in-flight requests
 		finished []*stateReq                  // Completed or failed requests
 		timeout  = make(chan *stateReq)       // Timed out active requests
+		rangeCh  chan dataPack                // Stale state ranges to drop, the range sync consumes them itself
 	)
+	if s.peer == nil {
+		rangeCh = d.stateRangeCh
+	}
 
 	// Run the state sync.
 	log.Trace("State sync starting", "root", s.root)
@@ -154,6 +162,9 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			finished = append(finished, req)
 			delete(active, pack.PeerId())
 
+		case <-rangeCh:
+			// Ignore state ranges while no range sync is running.
+
 		// Handle dropped peer connections:
 		case p := <-peerDrop:
 			// Skip if no request is currently pending
@@ -265,6 +276,7 @@ type stateSync struct {
 	err        error          // Any error hit during sync (set before completion)
 
 	root common.Hash
+	peer *peerConnection // Peer serving the state ranges, nil if synced by node data
 }
 
 // stateTask represents a single trie node download task, containing a set of
@@ -293,7 +305,11 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
-	s.err = s.loop()
+	if s.peer != nil {
+		s.err = s.rangeLoop()
+	} else {
+		s.err = s.loop()
+	}
 	close(s.done)
 }
 
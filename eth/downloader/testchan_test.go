@@ -8,12 +8,24 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
 	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
 	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
 	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
 )
 
 // Test chain parameters.
@@ -42,6 +54,14 @@ const (
 // The common prefix of all test chains:
 var testChainBase = newTestChain(blockSyncItems, testGenesis)
 
+// The validators finalizing the blocks of the QC test chain, the order of the
+// validators is rotated every consensus round.
+var testValidatorNodes, testValidatorKeys = newTestValidators(4)
+
+// A chain finalized by the QCs of the test validators, the validators of every
+// round are elected at the election block preceding the round:
+var testChainQC = newQCTestChain(snapshotDBBaseNum + 100)
+
 // Different forks on top of the base chain:
 //var testChainForkLightA, testChainForkLightB, testChainForkHeavy *testChain
 
@@ -76,6 +96,150 @@ func newTestChain(length int, genesis *types.Block) *testChain {
 	return tc
 }
 
+// newTestValidators creates the nodes of the validators and their bls keys.
+func newTestValidators(n int) ([]params.CbftNode, []*bls.SecretKey) {
+	bls.Init(bls.BLS12_381)
+	nodes := make([]params.CbftNode, n)
+	keys := make([]*bls.SecretKey, n)
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			panic(err)
+		}
+		var sk bls.SecretKey
+		sk.SetByCSPRNG()
+		nodes[i].Node = *discover.NewNode(discover.PubkeyID(&key.PublicKey), nil, 0, 0)
+		nodes[i].BlsPubKey = *sk.GetPublicKey()
+		keys[i] = &sk
+	}
+	return nodes, keys
+}
+
+// testRoundQueue returns the test validators of the round starting at the block.
+func testRoundQueue(start uint64) staking.ValidatorQueue {
+	round := int((start - 1) / xutil.ConsensusSize())
+	queue := make(staking.ValidatorQueue, len(testValidatorNodes))
+	for i := range queue {
+		node := testValidatorNodes[(i+round)%len(testValidatorNodes)]
+		var blsPubKey bls.PublicKeyHex
+		copy(blsPubKey[:], node.BlsPubKey.Serialize())
+		pubKey, _ := node.Node.ID.Pubkey()
+		queue[i] = &staking.Validator{
+			NodeAddress: crypto.PubkeyToNodeAddress(*pubKey),
+			NodeId:      node.Node.ID,
+			BlsPubKey:   blsPubKey,
+		}
+	}
+	return queue
+}
+
+// testRoundValidators returns the test validators of the round of the block.
+func testRoundValidators(number uint64) *cbfttypes.Validators {
+	start := roundStart(number)
+	return plugin.BuildCbftValidators(start, testRoundQueue(start))
+}
+
+// signTestQC returns the extra data holding the QC of the header, signed by the
+// test validators of the indexes in the round of the header.
+func signTestQC(header *types.Header, indexes ...uint32) []byte {
+	number := header.Number.Uint64()
+	round := (number - 1) / xutil.ConsensusSize()
+	qc := &ctypes.QuorumCert{
+		Epoch:        round + 1,
+		BlockHash:    header.Hash(),
+		BlockNumber:  number,
+		ValidatorSet: utils.NewBitArray(uint32(len(testValidatorKeys))),
+	}
+	msg, err := qc.CannibalizeBytes()
+	if err != nil {
+		panic(err)
+	}
+	var sig bls.Sign
+	for i, index := range indexes {
+		key := testValidatorKeys[(int(index)+int(round))%len(testValidatorKeys)]
+		if i == 0 {
+			sig = *key.Sign(string(msg))
+		} else {
+			sig.Add(key.Sign(string(msg)))
+		}
+		qc.ValidatorSet.SetIndex(index, true)
+	}
+	qc.Signature.SetBytes(sig.Serialize())
+	extra, err := ctypes.EncodeExtra(1, qc)
+	if err != nil {
+		panic(err)
+	}
+	return extra
+}
+
+// newQCTestChain creates a blockchain of the given length finalized by the QCs
+// of the test validators. The ppos hash of every election block commits to the
+// validators of the next round, the peers record the ppos writes and the proofs
+// of the ppos hash as a ppos node does.
+func newQCTestChain(length int) *testChain {
+	tc := new(testChain).copy(length)
+	tc.genesis = testGenesis
+	tc.chain = append(tc.chain, testGenesis.Hash())
+	tc.headerm[testGenesis.Hash()] = testGenesis.Header()
+	tc.blockm[testGenesis.Hash()] = testGenesis
+
+	blocks, receipts := core.GenerateChain(params.TestChainConfig, testGenesis, &consensus.BftMock{}, testDB, length-1, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(common.Address{0xcb})
+		number := block.Number().Uint64()
+		if !xutil.IsElection(number) {
+			return
+		}
+		start := number + xcom.ElectionDistance() + 1
+		value, err := rlp.EncodeToBytes(testRoundQueue(start))
+		if err != nil {
+			panic(err)
+		}
+		writes := [][2][]byte{{staking.GetRoundValArrKey(start, start+xutil.ConsensusSize()-1), value}}
+		pposHash := common.ZeroHash
+		for _, kv := range writes {
+			pposHash = common.GenerateKVHash(kv[0], kv[1], pposHash)
+		}
+		// The staking contract holds the deposits, it's never an empty account
+		block.AddBalance(cvm.StakingContractAddr, big.NewInt(1))
+		block.SetState(cvm.StakingContractAddr, staking.GetPPOSHASHKey(), pposHash.Bytes())
+		rawdb.WritePPOSWrites(testDB, pposHash, writes)
+	})
+	for i, b := range blocks {
+		b = b.WithBody(b.Transactions(), signTestQC(b.Header(), 0, 1, 2))
+		hash := b.Hash()
+		tc.chain = append(tc.chain, hash)
+		tc.blockm[hash] = b
+		tc.headerm[hash] = b.Header()
+		tc.receiptm[hash] = receipts[i]
+
+		if xutil.IsElection(b.NumberU64()) {
+			statedb, err := state.New(b.Root(), state.NewDatabase(testDB))
+			if err != nil {
+				panic(err)
+			}
+			accountProof, err := statedb.GetProof(cvm.StakingContractAddr)
+			if err != nil {
+				panic(err)
+			}
+			storageProof, err := statedb.GetRawStorageProof(cvm.StakingContractAddr, staking.GetPPOSHASHKey())
+			if err != nil {
+				panic(err)
+			}
+			rawdb.WritePPOSHashProof(testDB, hash, accountProof, storageProof)
+		}
+	}
+	// The base of the pivot holds the validators of the rounds elected so far
+	tc.baseNum = snapshotDBBaseNum
+	for start := uint64(1); start <= uint64(tc.baseNum)+xcom.ElectionDistance()+1; start += xutil.ConsensusSize() {
+		value, err := rlp.EncodeToBytes(testRoundQueue(start))
+		if err != nil {
+			panic(err)
+		}
+		tc.pposData = append(tc.pposData, [2][]byte{staking.GetRoundValArrKey(start, start+xutil.ConsensusSize()-1), value})
+	}
+	return tc
+}
+
 // makeFork creates a fork on top of the test chain.
 func (tc *testChain) makeFork(length int, heavy bool, seed byte) *testChain {
 	fork := tc.copy(tc.len() + length)
@@ -107,8 +271,10 @@ func (tc *testChain) copy(newlen int) *testChain {
 		cpy.headerm[hash] = tc.headerm[hash]
 		cpy.receiptm[hash] = tc.receiptm[hash]
 	}
-	if len(tc.pposData) > 0 {
+	if len(tc.pposData) > newlen {
 		cpy.pposData = tc.pposData[0:newlen]
+	} else {
+		cpy.pposData = tc.pposData
 	}
 	if newlen < tc.baseNum {
 		cpy.baseNum = newlen - 1
@@ -197,10 +363,11 @@ func (tc *testChain) receipts(hashes []common.Hash) [][]*types.Receipt {
 // bodies returns the block bodies of the given block hashes.
 func (tc *testChain) bodies(hashes []common.Hash) ([][]*types.Transaction, [][]byte) {
 	transactions := make([][]*types.Transaction, 0, len(hashes))
-	ex := make([][]byte, len(hashes))
+	ex := make([][]byte, 0, len(hashes))
 	for _, hash := range hashes {
 		if block, ok := tc.blockm[hash]; ok {
 			transactions = append(transactions, block.Transactions())
+			ex = append(ex, block.ExtraData())
 		}
 	}
 	return transactions, ex
@@ -106,3 +106,58 @@ type pposInfoPack struct {
 func (p *pposInfoPack) PeerId() string { return p.peerID }
 func (p *pposInfoPack) Items() int     { return 1 }
 func (p *pposInfoPack) Stats() string  { return fmt.Sprint(1) }
+
+// accountRangePack is a range of accounts returned by a peer.
+type accountRangePack struct {
+	peerID string
+	packet *AccountRangePacket
+}
+
+func (p *accountRangePack) PeerId() string    { return p.peerID }
+func (p *accountRangePack) Items() int        { return len(p.packet.Accounts) }
+func (p *accountRangePack) Stats() string     { return fmt.Sprintf("%d", len(p.packet.Accounts)) }
+func (p *accountRangePack) requestID() uint64 { return p.packet.ID }
+
+// storageRangesPack is a batch of storage ranges returned by a peer.
+type storageRangesPack struct {
+	peerID string
+	packet *StorageRangesPacket
+}
+
+func (p *storageRangesPack) PeerId() string    { return p.peerID }
+func (p *storageRangesPack) Items() int        { return len(p.packet.Slots) }
+func (p *storageRangesPack) Stats() string     { return fmt.Sprintf("%d", len(p.packet.Slots)) }
+func (p *storageRangesPack) requestID() uint64 { return p.packet.ID }
+
+// byteCodesPack is a batch of contract codes returned by a peer.
+type byteCodesPack struct {
+	peerID string
+	packet *ByteCodesPacket
+}
+
+func (p *byteCodesPack) PeerId() string    { return p.peerID }
+func (p *byteCodesPack) Items() int        { return len(p.packet.Codes) }
+func (p *byteCodesPack) Stats() string     { return fmt.Sprintf("%d", len(p.packet.Codes)) }
+func (p *byteCodesPack) requestID() uint64 { return p.packet.ID }
+
+// pposRangePack is a range of ppos storage returned by a peer.
+type pposRangePack struct {
+	peerID string
+	packet *PPOSRangePacket
+}
+
+func (p *pposRangePack) PeerId() string    { return p.peerID }
+func (p *pposRangePack) Items() int        { return len(p.packet.KVs) }
+func (p *pposRangePack) Stats() string     { return fmt.Sprintf("%d", len(p.packet.KVs)) }
+func (p *pposRangePack) requestID() uint64 { return p.packet.ID }
+
+// blockProofsPack is a batch of block proofs returned by a peer.
+type blockProofsPack struct {
+	peerID string
+	packet *BlockProofsPacket
+}
+
+func (p *blockProofsPack) PeerId() string    { return p.peerID }
+func (p *blockProofsPack) Items() int        { return len(p.packet.Proofs) }
+func (p *blockProofsPack) Stats() string     { return fmt.Sprintf("%d", len(p.packet.Proofs)) }
+func (p *blockProofsPack) requestID() uint64 { return p.packet.ID }
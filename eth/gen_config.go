@@ -20,8 +20,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NetworkId                uint64
 		SyncMode                 downloader.SyncMode
 		NoPruning                bool
-		SkipBcVersionCheck       bool `toml:"-"`
-		DatabaseHandles          int  `toml:"-"`
+		Checkpoint               *downloader.Checkpoint `toml:",omitempty"`
+		LightServe               bool                   `toml:",omitempty"`
+		SkipBcVersionCheck       bool                   `toml:"-"`
+		DatabaseHandles          int                    `toml:"-"`
 		DatabaseCache            int
 		TrieCleanCacheJournal    string        `toml:",omitempty"`
 		TrieCleanCacheRejournal  time.Duration `toml:",omitempty"`
@@ -72,6 +74,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
 	enc.NoPruning = c.NoPruning
+	enc.Checkpoint = c.Checkpoint
+	enc.LightServe = c.LightServe
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
@@ -128,8 +132,10 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NetworkId                *uint64
 		SyncMode                 *downloader.SyncMode
 		NoPruning                *bool
-		SkipBcVersionCheck       *bool `toml:"-"`
-		DatabaseHandles          *int  `toml:"-"`
+		Checkpoint               *downloader.Checkpoint `toml:",omitempty"`
+		LightServe               *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck       *bool                  `toml:"-"`
+		DatabaseHandles          *int                   `toml:"-"`
 		DatabaseCache            *int
 		TrieCleanCacheJournal    *string        `toml:",omitempty"`
 		TrieCleanCacheRejournal  *time.Duration `toml:",omitempty"`
@@ -193,6 +199,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NoPruning != nil {
 		c.NoPruning = *dec.NoPruning
 	}
+	if dec.Checkpoint != nil {
+		c.Checkpoint = dec.Checkpoint
+	}
+	if dec.LightServe != nil {
+		c.LightServe = *dec.LightServe
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -33,6 +33,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/consensus"
 	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
@@ -110,7 +111,10 @@ type ProtocolManager struct {
 	wg        sync.WaitGroup
 	peerWG    sync.WaitGroup
 
-	engine consensus.Engine
+	engine     consensus.Engine
+	forkFilter forkid.Filter // Fork ID filter validating the peers against the chain head
+
+	pposSnapshots *pposSnapshots // Snapshotdb bases pinned for the range syncing peers
 }
 
 // NewProtocolManager returns a new PlatON sub protocol manager. The PlatON sub protocol manages peers capable
@@ -128,6 +132,9 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		engine:      engine,
+		forkFilter:  forkid.NewFilter(blockchain),
+
+		pposSnapshots: newPPOSSnapshots(),
 	}
 	// If fast sync was requested and our database is empty, grant it
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() == 0 {
@@ -253,6 +260,14 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	go pm.txsyncLoop()
 }
 
+// responseBytes caps the soft size limit requested by a remote peer.
+func responseBytes(bytes uint64) uint64 {
+	if bytes > softResponseLimit {
+		return softResponseLimit
+	}
+	return bytes
+}
+
 func (pm *ProtocolManager) Stop() {
 	log.Info("Stopping PlatON protocol")
 
@@ -270,6 +285,7 @@ func (pm *ProtocolManager) Stop() {
 	// will exit when they try to register.
 	pm.peers.Close()
 	pm.peerWG.Wait()
+	pm.pposSnapshots.close()
 
 	log.Info("PlatON protocol stopped")
 }
@@ -301,8 +317,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		genesis = pm.blockchain.Genesis()
 		head    = pm.blockchain.CurrentHeader()
 		hash    = head.CacheHash()
+		forkID  = forkid.NewIDFromChain(pm.blockchain)
 	)
-	if err := p.Handshake(pm.networkID, head.Number, hash, genesis.Hash(), pm); err != nil {
+	if err := p.Handshake(pm.networkID, head.Number, hash, genesis.Hash(), forkID, pm.forkFilter, pm); err != nil {
 		p.Log().Debug("PlatON handshake failed", "err", err)
 		return err
 	}
@@ -696,6 +713,112 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Failed to deliver node state data", "err", err)
 		}
 
+	case p.version >= eth66 && msg.Code == GetAccountRangeMsg:
+		var req GetAccountRangePacket
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		accounts, proof := downloader.ServeAccountRange(pm.blockchain.StateCache().TrieDB(), req.Root, req.Origin, responseBytes(req.Bytes))
+		return p.SendAccountRange(&downloader.AccountRangePacket{ID: req.ID, Accounts: accounts, Proof: proof})
+
+	case p.version >= eth66 && msg.Code == AccountRangeMsg:
+		var res downloader.AccountRangePacket
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverAccountRange(p.id, &res); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case p.version >= eth66 && msg.Code == GetStorageRangesMsg:
+		var req GetStorageRangesPacket
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		slots, proof := downloader.ServeStorageRanges(pm.blockchain.StateCache().TrieDB(), req.Root, req.Accounts, req.Origin, responseBytes(req.Bytes))
+		return p.SendStorageRanges(&downloader.StorageRangesPacket{ID: req.ID, Slots: slots, Proof: proof})
+
+	case p.version >= eth66 && msg.Code == StorageRangesMsg:
+		var res downloader.StorageRangesPacket
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverStorageRanges(p.id, &res); err != nil {
+			log.Debug("Failed to deliver storage ranges", "err", err)
+		}
+
+	case p.version >= eth66 && msg.Code == GetByteCodesMsg:
+		var req GetByteCodesPacket
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(req.Hashes) > downloader.MaxStateFetch {
+			req.Hashes = req.Hashes[:downloader.MaxStateFetch]
+		}
+		codes := downloader.ServeByteCodes(pm.chaindb, req.Hashes, responseBytes(req.Bytes))
+		return p.SendByteCodes(&downloader.ByteCodesPacket{ID: req.ID, Codes: codes})
+
+	case p.version >= eth66 && msg.Code == ByteCodesMsg:
+		var res downloader.ByteCodesPacket
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverByteCodes(p.id, &res); err != nil {
+			log.Debug("Failed to deliver byte codes", "err", err)
+		}
+
+	case p.version >= eth66 && msg.Code == GetPPOSRangeMsg:
+		var req GetPPOSRangePacket
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// The pivot is left empty if the requested base is no longer served
+		res := &downloader.PPOSRangePacket{ID: req.ID}
+		number, kvs, more, err := pm.pposSnapshots.serve(req.Number, req.Origin, responseBytes(req.Bytes))
+		if err != nil {
+			p.Log().Debug("Failed to serve ppos range", "number", req.Number, "err", err)
+		} else {
+			res.Latest, res.Pivot = pm.blockchain.CurrentHeader(), pm.blockchain.GetHeaderByNumber(number)
+			res.KVs, res.More, res.Checksum = kvs, more, downloader.PPOSRangeChecksum(kvs)
+		}
+		return p.SendPPOSRange(res)
+
+	case p.version >= eth66 && msg.Code == PPOSRangeMsg:
+		var res downloader.PPOSRangePacket
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverPPOSRange(p.id, &res); err != nil {
+			log.Debug("Failed to deliver ppos range", "err", err)
+		}
+
+	case p.version >= eth66 && msg.Code == GetBlockProofsMsg:
+		var req GetBlockProofsPacket
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(req.Numbers) > downloader.MaxProofFetch {
+			req.Numbers = req.Numbers[:downloader.MaxProofFetch]
+		}
+		res := &downloader.BlockProofsPacket{ID: req.ID}
+		for _, number := range req.Numbers {
+			block := pm.blockchain.GetBlockByNumber(number)
+			if block == nil {
+				break
+			}
+			res.Proofs = append(res.Proofs, downloader.ServeBlockProof(pm.chaindb, block))
+		}
+		return p.SendBlockProofs(res)
+
+	case p.version >= eth66 && msg.Code == BlockProofsMsg:
+		var res downloader.BlockProofsPacket
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverBlockProofs(p.id, &res); err != nil {
+			log.Debug("Failed to deliver block proofs", "err", err)
+		}
+
 	case p.version >= eth63 && msg.Code == GetReceiptsMsg:
 		// Decode the retrieval message
 		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
@@ -34,6 +34,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
@@ -205,14 +206,14 @@ func newTestPeer(name string, version int, pm *ProtocolManager, shake bool) (*te
 			genesis = pm.blockchain.Genesis()
 			head    = pm.blockchain.CurrentHeader()
 		)
-		tp.handshake(nil, head.Number, head.Hash(), genesis.Hash())
+		tp.handshake(nil, head.Number, head.Hash(), genesis.Hash(), forkid.NewIDFromChain(pm.blockchain))
 	}
 	return tp, errc
 }
 
 // handshake simulates a trivial handshake that expects the same state from the
 // remote side as we are simulating locally.
-func (p *testPeer) handshake(t *testing.T, bn *big.Int, head common.Hash, genesis common.Hash) {
+func (p *testPeer) handshake(t *testing.T, bn *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID) {
 	msg := &statusData{
 		ProtocolVersion: uint32(p.version),
 		NetworkId:       DefaultConfig.NetworkId,
@@ -220,6 +221,9 @@ func (p *testPeer) handshake(t *testing.T, bn *big.Int, head common.Hash, genesi
 		GenesisBlock:    genesis,
 		BN:              bn,
 	}
+	if p.version >= eth66 {
+		msg.ForkID = forkID
+	}
 	if err := p2p.ExpectMsg(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
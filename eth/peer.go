@@ -28,6 +28,8 @@ import (
 	"github.com/deckarep/golang-set"
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/p2p"
 	"github.com/PlatONnetwork/PlatON-Go/rlp"
@@ -358,6 +360,31 @@ func (p *peer) SendOriginAndPivot(data []*types.Header) error {
 	return p2p.Send(p.rw, OriginAndPivotMsg, data)
 }
 
+// SendAccountRange sends a range of accounts to the remote peer.
+func (p *peer) SendAccountRange(packet *downloader.AccountRangePacket) error {
+	return p2p.Send(p.rw, AccountRangeMsg, packet)
+}
+
+// SendStorageRanges sends a batch of storage ranges to the remote peer.
+func (p *peer) SendStorageRanges(packet *downloader.StorageRangesPacket) error {
+	return p2p.Send(p.rw, StorageRangesMsg, packet)
+}
+
+// SendByteCodes sends a batch of contract codes to the remote peer.
+func (p *peer) SendByteCodes(packet *downloader.ByteCodesPacket) error {
+	return p2p.Send(p.rw, ByteCodesMsg, packet)
+}
+
+// SendPPOSRange sends a range of the snapshotdb base to the remote peer.
+func (p *peer) SendPPOSRange(packet *downloader.PPOSRangePacket) error {
+	return p2p.Send(p.rw, PPOSRangeMsg, packet)
+}
+
+// SendBlockProofs sends a batch of block proofs to the remote peer.
+func (p *peer) SendBlockProofs(packet *downloader.BlockProofsPacket) error {
+	return p2p.Send(p.rw, BlockProofsMsg, packet)
+}
+
 // SendNewBlock propagates an entire block to a remote peer.
 func (p *peer) SendNewBlock(block *types.Block) error {
 	// Mark all the block hash as known, but ensure we don't overflow our limits
@@ -470,6 +497,40 @@ func (p *peer) RequestOriginAndPivotByCurrent(current uint64) error {
 	return nil
 }
 
+// RequestAccountRange fetches a range of accounts of the state trie from a
+// remote node.
+func (p *peer) RequestAccountRange(id uint64, root, origin common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "reqid", id, "root", root, "origin", origin, "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetAccountRangeMsg, &GetAccountRangePacket{ID: id, Root: root, Origin: origin, Bytes: bytes})
+}
+
+// RequestStorageRanges fetches the storage ranges of a batch of accounts from a
+// remote node.
+func (p *peer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching ranges of storage slots", "reqid", id, "root", root, "accounts", len(accounts), "origin", origin, "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetStorageRangesMsg, &GetStorageRangesPacket{ID: id, Root: root, Accounts: accounts, Origin: origin, Bytes: bytes})
+}
+
+// RequestByteCodes fetches a batch of contract codes from a remote node.
+func (p *peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching set of byte codes", "reqid", id, "hashes", len(hashes), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetByteCodesMsg, &GetByteCodesPacket{ID: id, Hashes: hashes, Bytes: bytes})
+}
+
+// RequestPPOSRange fetches a range of the snapshotdb base of the pivot from a
+// remote node.
+func (p *peer) RequestPPOSRange(id uint64, number uint64, origin []byte, bytes uint64) error {
+	p.Log().Debug("Fetching range of ppos storage", "reqid", id, "number", number, "origin", hexutil.Bytes(origin), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetPPOSRangeMsg, &GetPPOSRangePacket{ID: id, Number: number, Origin: origin, Bytes: bytes})
+}
+
+// RequestBlockProofs fetches the proofs of a batch of canonical blocks from a
+// remote node.
+func (p *peer) RequestBlockProofs(id uint64, numbers []uint64) error {
+	p.Log().Debug("Fetching batch of block proofs", "reqid", id, "count", len(numbers))
+	return p2p.Send(p.rw, GetBlockProofsMsg, &GetBlockProofsPacket{ID: id, Numbers: numbers})
+}
+
 // RequestTxs fetches a batch of transactions from a remote node.
 func (p *peer) RequestTxs(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of transactions", "count", len(hashes))
@@ -477,23 +538,27 @@ func (p *peer) RequestTxs(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, bn *big.Int, head common.Hash, genesis common.Hash, pm *ProtocolManager) error {
+// network IDs, difficulties, head and genesis blocks, and fork IDs since eth/66.
+func (p *peer) Handshake(network uint64, bn *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter, pm *ProtocolManager) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 	var status statusData // safe to read after two values have been received from errc
 
+	out := &statusData{
+		ProtocolVersion: uint32(p.version),
+		NetworkId:       network,
+		BN:              bn,
+		CurrentBlock:    head,
+		GenesisBlock:    genesis,
+	}
+	if p.version >= eth66 {
+		out.ForkID = forkID
+	}
 	go func() {
-		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
-			ProtocolVersion: uint32(p.version),
-			NetworkId:       network,
-			BN:              bn,
-			CurrentBlock:    head,
-			GenesisBlock:    genesis,
-		})
+		errc <- p2p.Send(p.rw, StatusMsg, out)
 	}()
 	go func() {
-		errc <- p.readStatus(network, &status, genesis)
+		errc <- p.readStatus(network, &status, genesis, forkFilter)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
 	defer timeout.Stop()
@@ -520,7 +585,7 @@ func (p *peer) Handshake(network uint64, bn *big.Int, head common.Hash, genesis
 	return nil
 }
 
-func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
+func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash, forkFilter forkid.Filter) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return err
@@ -544,6 +609,12 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis common.Has
 	if int(status.ProtocolVersion) != p.version {
 		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
 	}
+	if p.version >= eth66 && forkFilter != nil {
+		if err := forkFilter(status.ForkID); err != nil {
+			p.Log().Info("Rejected peer on an incompatible fork", "forkid", status.ForkID, "err", err)
+			return errResp(ErrForkIDRejected, "%v", err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,128 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
+)
+
+const (
+	pposSnapshotTTL  = 10 * time.Minute // Time a pinned base is kept since it was last served
+	maxPPOSSnapshots = 4                // Maximum number of bases pinned at the same time
+)
+
+// errPPOSSnapshotMissing is returned if the requested base is no longer pinned.
+var errPPOSSnapshotMissing = errors.New("ppos base not pinned")
+
+// pposSnapshot is a snapshotdb base pinned for the range syncing peers.
+type pposSnapshot struct {
+	*snapshotdb.BaseSnapshot
+	served time.Time // Last time a range of the base was served
+}
+
+// pposSnapshots pins the snapshotdb bases served to the range syncing peers, so
+// that a peer keeps retrieving a consistent base while the local one moves on.
+type pposSnapshots struct {
+	pins map[uint64]*pposSnapshot
+	lock sync.Mutex
+}
+
+func newPPOSSnapshots() *pposSnapshots {
+	return &pposSnapshots{pins: make(map[uint64]*pposSnapshot)}
+}
+
+// serve retrieves a range of the pinned base of the pivot number. The current
+// base is pinned first if the number is 0. The range is served under the lock
+// so that a base is never released while it's iterated.
+func (ps *pposSnapshots) serve(number uint64, origin []byte, limit uint64) (uint64, []downloader.PPOSStorageKV, bool, error) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	now := time.Now()
+	ps.expire(now)
+
+	if number == 0 {
+		base, err := snapshotdb.Instance().GetBaseSnapshot()
+		if err != nil {
+			return 0, nil, false, err
+		}
+		number = base.Num.Uint64()
+		if pinned, ok := ps.pins[number]; ok {
+			base.Release()
+			pinned.served = now
+		} else {
+			ps.evict()
+			ps.pins[number] = &pposSnapshot{BaseSnapshot: base, served: now}
+		}
+	}
+	pinned, ok := ps.pins[number]
+	if !ok {
+		return 0, nil, false, errPPOSSnapshotMissing
+	}
+	pinned.served = now
+
+	iter := pinned.NewIterator(&util.Range{Start: origin})
+	defer iter.Release()
+	kvs, more := downloader.ServePPOSRange(iter, limit)
+	return number, kvs, more, iter.Error()
+}
+
+// expire releases the bases not served for longer than the TTL.
+func (ps *pposSnapshots) expire(now time.Time) {
+	for number, pinned := range ps.pins {
+		if now.Sub(pinned.served) > pposSnapshotTTL {
+			pinned.Release()
+			delete(ps.pins, number)
+		}
+	}
+}
+
+// evict releases the least recently served base if the pins are full.
+func (ps *pposSnapshots) evict() {
+	if len(ps.pins) < maxPPOSSnapshots {
+		return
+	}
+	var (
+		oldest uint64
+		served time.Time
+	)
+	for number, pinned := range ps.pins {
+		if served.IsZero() || pinned.served.Before(served) {
+			oldest, served = number, pinned.served
+		}
+	}
+	ps.pins[oldest].Release()
+	delete(ps.pins, oldest)
+}
+
+// close releases all the pinned bases.
+func (ps *pposSnapshots) close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for number, pinned := range ps.pins {
+		pinned.Release()
+		delete(ps.pins, number)
+	}
+}
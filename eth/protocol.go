@@ -23,6 +23,7 @@ import (
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/event"
 	"github.com/PlatONnetwork/PlatON-Go/rlp"
@@ -33,16 +34,17 @@ const (
 	eth62 = 62
 	eth63 = 63
 	eth65 = 65
+	eth66 = 66
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
 var protocolName = "platon"
 
 // ProtocolVersions are the upported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth65, eth63, eth62}
+var ProtocolVersions = []uint{eth66, eth65, eth63, eth62}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = []uint64{40, 23, 8}
+var protocolLengths = []uint64{40, 40, 23, 8}
 
 const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -77,6 +79,18 @@ const (
 	NewPooledTransactionHashesMsg = 0x16
 	GetPooledTransactionsMsg      = 0x17
 	PooledTransactionsMsg         = 0x18
+
+	// Protocol messages belonging to eth/66, for the range sync
+	GetAccountRangeMsg  = 0x19
+	AccountRangeMsg     = 0x1a
+	GetStorageRangesMsg = 0x1b
+	StorageRangesMsg    = 0x1c
+	GetByteCodesMsg     = 0x1d
+	ByteCodesMsg        = 0x1e
+	GetPPOSRangeMsg     = 0x1f
+	PPOSRangeMsg        = 0x20
+	GetBlockProofsMsg   = 0x21
+	BlockProofsMsg      = 0x22
 )
 
 type errCode int
@@ -92,6 +106,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -109,6 +124,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 // NewPooledTransactionHashesPacket represents a transaction announcement packet.
@@ -120,6 +136,44 @@ type GetPooledTransactionsPacket []common.Hash
 // PooledTransactionsPacket is the network packet for transaction distribution.
 type PooledTransactionsPacket []*types.Transaction
 
+// GetAccountRangePacket represents an account range query.
+type GetAccountRangePacket struct {
+	ID     uint64      // Request ID to match up responses with
+	Root   common.Hash // Root hash of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// GetStorageRangesPacket represents a storage ranges query.
+type GetStorageRangesPacket struct {
+	ID       uint64        // Request ID to match up responses with
+	Root     common.Hash   // Root hash of the account trie to serve
+	Accounts []common.Hash // Account hashes of the storage tries to serve
+	Origin   common.Hash   // Hash of the first storage slot of the first account
+	Bytes    uint64        // Soft limit at which to stop returning data
+}
+
+// GetByteCodesPacket represents a contract codes query.
+type GetByteCodesPacket struct {
+	ID     uint64        // Request ID to match up responses with
+	Hashes []common.Hash // Code hashes to retrieve the code for
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// GetPPOSRangePacket represents a query of a range of the snapshotdb base.
+type GetPPOSRangePacket struct {
+	ID     uint64 // Request ID to match up responses with
+	Number uint64 // Pivot of the base to serve, 0 to pin the current base
+	Origin []byte // First key to retrieve
+	Bytes  uint64 // Soft limit at which to stop returning data
+}
+
+// GetBlockProofsPacket represents a query of the proofs of canonical blocks.
+type GetBlockProofsPacket struct {
+	ID      uint64   // Request ID to match up responses with
+	Numbers []uint64 // Numbers of the blocks to prove
+}
+
 type txPool interface {
 	// Has returns an indicator whether txpool has a transaction
 	// cached with the given hash.
@@ -148,6 +202,7 @@ type statusData struct {
 	BN              *big.Int
 	CurrentBlock    common.Hash
 	GenesisBlock    common.Hash
+	ForkID          forkid.ID `rlp:"optional"` // Exchanged since eth/66
 }
 
 // newBlockHashesData is the network packet for the block announcements.
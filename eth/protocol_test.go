@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/forkid"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
@@ -39,6 +40,7 @@ var testAccount, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6
 // Tests that handshake failures are detected and reported correctly.
 func TestStatusMsgErrors62(t *testing.T) { testStatusMsgErrors(t, 62) }
 func TestStatusMsgErrors63(t *testing.T) { testStatusMsgErrors(t, 63) }
+func TestStatusMsgErrors66(t *testing.T) { testStatusMsgErrors(t, 66) }
 
 func testStatusMsgErrors(t *testing.T, protocol int) {
 	//db := ethdb.NewMemDatabase()
@@ -47,33 +49,43 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 		genesis = pm.blockchain.Genesis()
 		head    = pm.blockchain.CurrentHeader()
 		td      = new(big.Int).SetUint64(99999999999999)
+		forkID  = forkid.NewIDFromChain(pm.blockchain)
 	)
 	defer pm.Stop()
 
 	tests := []struct {
-		code      uint64
-		data      interface{}
-		wantError error
+		code       uint64
+		data       interface{}
+		wantError  error
+		minVersion int // Lowest protocol version the test applies to
 	}{
 		{
 			code: TransactionMsg, data: []interface{}{},
 			wantError: errResp(ErrNoStatusMsg, "first msg has code 2 (!= 0)"),
 		},
 		{
-			code: StatusMsg, data: statusData{10, DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{10, DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash(), forkID},
 			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", protocol),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), 999, td, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{uint32(protocol), 999, td, head.Hash(), genesis.Hash(), forkID},
 			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 1)"),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), DefaultConfig.NetworkId, td, head.Hash(), common.Hash{3}},
+			code: StatusMsg, data: statusData{uint32(protocol), DefaultConfig.NetworkId, td, head.Hash(), common.Hash{3}, forkID},
 			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis.Hash().Bytes()[:8]),
 		},
+		{
+			code: StatusMsg, data: statusData{uint32(protocol), DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash(), forkid.ID{Hash: [4]byte{0x01}, Version: forkID.Version, Code: forkID.Code}},
+			wantError:  errResp(ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale),
+			minVersion: eth66,
+		},
 	}
 
 	for i, test := range tests {
+		if protocol < test.minVersion {
+			continue
+		}
 		p, errc := newTestPeer("peer", protocol, pm, false)
 		// The send call might hang until reset because
 		// the protocol might not read the payload.
@@ -93,6 +105,24 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that eth/66 peers on the same chain exchange and accept their fork IDs.
+func TestStatusMsgForkID66(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	p, errc := newTestPeer("peer", eth66, pm, true)
+	defer p.close()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("protocol returned error: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if pm.peers.Peer(p.id) == nil {
+		t.Errorf("peer not registered after the handshake")
+	}
+}
+
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
@@ -34,6 +34,8 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
 	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
 	"github.com/PlatONnetwork/PlatON-Go/core"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/eth"
@@ -43,7 +45,11 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/miner"
 	"github.com/PlatONnetwork/PlatON-Go/node"
 	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
 	"github.com/gorilla/websocket"
 )
 
@@ -59,6 +65,13 @@ const (
 	chainHeadChanSize = 10
 )
 
+// The roles of the node reported in the consensus stats.
+const (
+	roleValidator = "validator" // a validator of the current epoch
+	roleCandidate = "candidate" // a valid PPOS candidate, not a validator
+	roleObserver  = "observer"
+)
+
 // backend encompasses the bare-minimum functionality needed for ethstats reporting
 type backend interface {
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
@@ -79,12 +92,26 @@ type fullNodeBackend interface {
 	SuggestPrice(ctx context.Context) (*big.Int, error)
 }
 
+// cbftEngine is the functionality of the CBFT engine needed to report the
+// consensus state of the node.
+type cbftEngine interface {
+	NodeID() discover.NodeID
+	IsConsensusNode() bool
+	CurrentView() (uint64, uint64)
+}
+
+// candidateReader reads the committed PPOS staking state of a candidate.
+type candidateReader interface {
+	GetCanMutableByIrr(addr common.NodeAddress) (*staking.CandidateMutable, error)
+}
+
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
 	server  *p2p.Server // Peer-to-peer server to retrieve networking infos
 	backend backend
 	engine  consensus.Engine // Consensus engine to retrieve variadic block fields
+	ppos    candidateReader  // Staking state to retrieve the candidate status of the node
 
 	node string // Name of the node to display on the monitoring page
 	pass string // Password to authorize access to the monitoring page
@@ -150,6 +177,7 @@ func New(node *node.Node, backend backend, engine consensus.Engine, url string)
 	ethstats := &Service{
 		backend: backend,
 		engine:  engine,
+		ppos:    plugin.StakingInstance(),
 		server:  node.Server(),
 		node:    parts[1],
 		pass:    parts[3],
@@ -309,6 +337,9 @@ func (s *Service) loop() {
 					if err = s.reportPending(conn); err != nil {
 						log.Warn("Post-block transaction stats report failed", "err", err)
 					}
+					if err = s.reportConsensus(conn); err != nil {
+						log.Warn("Post-block consensus stats report failed", "err", err)
+					}
 				case <-txCh:
 					if err = s.reportPending(conn); err != nil {
 						log.Warn("Transaction stats report failed", "err", err)
@@ -493,6 +524,9 @@ func (s *Service) report(conn *connWrapper) error {
 	if err := s.reportStats(conn); err != nil {
 		return err
 	}
+	if err := s.reportConsensus(conn); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -545,6 +579,17 @@ type blockStats struct {
 	Txs        []txStats      `json:"transactions"`
 	TxHash     common.Hash    `json:"transactionsRoot"`
 	Root       common.Hash    `json:"stateRoot"`
+	QC         *qcStats       `json:"qc,omitempty"`
+}
+
+// qcStats is the information to report about the quorum certificate of a
+// block, the votes bitmap has an x for each validator that signed it.
+type qcStats struct {
+	Epoch      uint64          `json:"epoch"`
+	ViewNumber uint64          `json:"viewNumber"`
+	BlockIndex uint32          `json:"blockIndex"`
+	Signers    int             `json:"signers"`
+	Votes      *utils.BitArray `json:"votes"`
 }
 
 // txStats is the information to report about individual transactions.
@@ -615,6 +660,26 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		Txs:        txs,
 		TxHash:     header.TxHash,
 		Root:       header.Root,
+		QC:         assembleQCStats(block),
+	}
+}
+
+// assembleQCStats decodes the quorum certificate of a committed block, nil
+// if the block doesn't carry one.
+func assembleQCStats(block *types.Block) *qcStats {
+	if block == nil || len(block.ExtraData()) == 0 {
+		return nil
+	}
+	_, qc, err := ctypes.DecodeExtra(block.ExtraData())
+	if err != nil || qc.ValidatorSet == nil {
+		return nil
+	}
+	return &qcStats{
+		Epoch:      qc.Epoch,
+		ViewNumber: qc.ViewNumber,
+		BlockIndex: qc.BlockIndex,
+		Signers:    qc.Len(),
+		Votes:      qc.ValidatorSet,
 	}
 }
 
@@ -752,3 +817,75 @@ func (s *Service) reportStats(conn *connWrapper) error {
 	}
 	return conn.WriteJSON(report)
 }
+
+// consensusStats is the information to report about the CBFT and PPOS state
+// of the local node.
+type consensusStats struct {
+	Epoch      uint64          `json:"epoch"`
+	ViewNumber uint64          `json:"viewNumber"`
+	Role       string          `json:"role"`
+	Candidate  *candidateStats `json:"candidate,omitempty"`
+}
+
+// candidateStats is the PPOS staking state of the local node.
+type candidateStats struct {
+	Status        uint32   `json:"status"` // the bits of staking.CandidateStatus
+	Valid         bool     `json:"valid"`
+	Shares        *big.Int `json:"shares"`
+	DelegateTotal *big.Int `json:"delegateTotal"`
+}
+
+// reportConsensus retrieves the view of the CBFT engine and the candidate
+// status of the local node and reports them to the stats server. Nothing is
+// reported if the engine isn't CBFT.
+func (s *Service) reportConsensus(conn *connWrapper) error {
+	engine, ok := s.engine.(cbftEngine)
+	if !ok {
+		return nil
+	}
+	epoch, viewNumber := engine.CurrentView()
+	details := &consensusStats{
+		Epoch:      epoch,
+		ViewNumber: viewNumber,
+		Role:       roleObserver,
+		Candidate:  s.assembleCandidateStats(engine.NodeID()),
+	}
+	if engine.IsConsensusNode() {
+		details.Role = roleValidator
+	} else if details.Candidate != nil && details.Candidate.Valid {
+		details.Role = roleCandidate
+	}
+	// Assemble the consensus stats and send it to the server
+	log.Trace("Sending consensus stats to ethstats", "epoch", epoch, "view", viewNumber, "role", details.Role)
+
+	stats := map[string]interface{}{
+		"id":        s.node,
+		"consensus": details,
+	}
+	report := map[string][]interface{}{
+		"emit": {"consensus", stats},
+	}
+	return conn.WriteJSON(report)
+}
+
+// assembleCandidateStats reads the committed staking state of the node, nil
+// if the node isn't a candidate.
+func (s *Service) assembleCandidateStats(nodeID discover.NodeID) *candidateStats {
+	if s.ppos == nil {
+		return nil
+	}
+	addr, err := xutil.NodeId2Addr(nodeID)
+	if err != nil {
+		return nil
+	}
+	can, err := s.ppos.GetCanMutableByIrr(addr)
+	if err != nil || can.IsEmpty() {
+		return nil
+	}
+	return &candidateStats{
+		Status:        uint32(can.Status),
+		Valid:         can.IsValid(),
+		Shares:        can.Shares,
+		DelegateTotal: can.DelegateTotal,
+	}
+}
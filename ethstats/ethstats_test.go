@@ -0,0 +1,236 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethstats
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/consensus"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/eth/downloader"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+)
+
+// statsServer is a stand-in of the netstats server collecting the reports.
+type statsServer struct {
+	*httptest.Server
+	reports chan map[string][]json.RawMessage
+}
+
+func newStatsServer(t *testing.T) *statsServer {
+	s := &statsServer{reports: make(chan map[string][]json.RawMessage, 16)}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg map[string][]json.RawMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			s.reports <- msg
+		}
+	}))
+	return s
+}
+
+func (s *statsServer) dial(t *testing.T) *connWrapper {
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/api"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	return newConnectionWrapper(conn)
+}
+
+// next returns the payload of the next report of the kind.
+func (s *statsServer) next(t *testing.T, kind string, v interface{}) {
+	select {
+	case msg := <-s.reports:
+		require.Len(t, msg["emit"], 2)
+		var emit string
+		require.NoError(t, json.Unmarshal(msg["emit"][0], &emit))
+		require.Equal(t, kind, emit)
+		require.NoError(t, json.Unmarshal(msg["emit"][1], v))
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s report", kind)
+	}
+}
+
+type testBackend struct{}
+
+func (testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return nil
+}
+func (testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription { return nil }
+func (testBackend) CurrentHeader() *types.Header                                       { return nil }
+func (testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	return nil, nil
+}
+func (testBackend) Stats() (pending int, queued int)   { return 0, 0 }
+func (testBackend) Downloader() *downloader.Downloader { return nil }
+
+type testEngine struct {
+	*consensus.BftMock
+	nodeID    discover.NodeID
+	validator bool
+}
+
+func (e *testEngine) NodeID() discover.NodeID       { return e.nodeID }
+func (e *testEngine) IsConsensusNode() bool         { return e.validator }
+func (e *testEngine) CurrentView() (uint64, uint64) { return 3, 42 }
+
+type testCandidates map[common.NodeAddress]*staking.CandidateMutable
+
+func (c testCandidates) GetCanMutableByIrr(addr common.NodeAddress) (*staking.CandidateMutable, error) {
+	if can, ok := c[addr]; ok {
+		return can, nil
+	}
+	return nil, staking.ErrCanNoExist
+}
+
+func TestReportBlockQC(t *testing.T) {
+	server := newStatsServer(t)
+	defer server.Close()
+	conn := server.dial(t)
+	defer conn.Close()
+
+	s := &Service{node: "test", backend: testBackend{}, engine: consensus.NewFaker()}
+
+	votes := utils.NewBitArray(4)
+	votes.SetIndex(0, true)
+	votes.SetIndex(2, true)
+	votes.SetIndex(3, true)
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)})
+	extra, err := ctypes.EncodeExtra(1, &ctypes.QuorumCert{
+		Epoch:        2,
+		ViewNumber:   7,
+		BlockHash:    block.Hash(),
+		BlockNumber:  10,
+		BlockIndex:   5,
+		ValidatorSet: votes,
+	})
+	require.NoError(t, err)
+	block.SetExtraData(extra)
+	require.NoError(t, s.reportBlock(conn, block))
+
+	var report struct {
+		ID    string `json:"id"`
+		Block struct {
+			Number *big.Int `json:"number"`
+			QC     *struct {
+				Epoch      uint64          `json:"epoch"`
+				ViewNumber uint64          `json:"viewNumber"`
+				BlockIndex uint32          `json:"blockIndex"`
+				Signers    int             `json:"signers"`
+				Votes      *utils.BitArray `json:"votes"`
+			} `json:"qc"`
+		} `json:"block"`
+	}
+	server.next(t, "block", &report)
+	assert.Equal(t, "test", report.ID)
+	assert.Equal(t, int64(10), report.Block.Number.Int64())
+	require.NotNil(t, report.Block.QC)
+	assert.Equal(t, uint64(2), report.Block.QC.Epoch)
+	assert.Equal(t, uint64(7), report.Block.QC.ViewNumber)
+	assert.Equal(t, uint32(5), report.Block.QC.BlockIndex)
+	assert.Equal(t, 3, report.Block.QC.Signers)
+	assert.Equal(t, votes.String(), report.Block.QC.Votes.String())
+
+	// Blocks without a QC are reported without one
+	require.NoError(t, s.reportBlock(conn, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11)})))
+	var raw struct {
+		Block map[string]json.RawMessage `json:"block"`
+	}
+	server.next(t, "block", &raw)
+	assert.NotContains(t, raw.Block, "qc")
+}
+
+func TestReportConsensus(t *testing.T) {
+	server := newStatsServer(t)
+	defer server.Close()
+	conn := server.dial(t)
+	defer conn.Close()
+
+	candidateKey, _ := crypto.GenerateKey()
+	observerKey, _ := crypto.GenerateKey()
+	candidate, observer := discover.PubkeyID(&candidateKey.PublicKey), discover.PubkeyID(&observerKey.PublicKey)
+	engine := &testEngine{BftMock: consensus.NewFaker(), nodeID: candidate}
+	candidates := testCandidates{
+		common.NodeAddress(crypto.PubkeyToAddress(candidateKey.PublicKey)): &staking.CandidateMutable{
+			Status:        staking.Valided,
+			Shares:        big.NewInt(1000),
+			DelegateTotal: big.NewInt(100),
+		},
+	}
+	s := &Service{node: "test", backend: testBackend{}, engine: engine, ppos: candidates}
+
+	var report struct {
+		ID        string         `json:"id"`
+		Consensus consensusStats `json:"consensus"`
+	}
+	require.NoError(t, s.reportConsensus(conn))
+	server.next(t, "consensus", &report)
+	assert.Equal(t, uint64(3), report.Consensus.Epoch)
+	assert.Equal(t, uint64(42), report.Consensus.ViewNumber)
+	assert.Equal(t, roleCandidate, report.Consensus.Role)
+	require.NotNil(t, report.Consensus.Candidate)
+	assert.True(t, report.Consensus.Candidate.Valid)
+	assert.Equal(t, int64(1000), report.Consensus.Candidate.Shares.Int64())
+
+	engine.validator = true
+	require.NoError(t, s.reportConsensus(conn))
+	server.next(t, "consensus", &report)
+	assert.Equal(t, roleValidator, report.Consensus.Role)
+
+	engine.validator, engine.nodeID = false, observer
+	report.Consensus = consensusStats{}
+	require.NoError(t, s.reportConsensus(conn))
+	server.next(t, "consensus", &report)
+	assert.Equal(t, roleObserver, report.Consensus.Role)
+	assert.Nil(t, report.Consensus.Candidate)
+
+	// Engines other than CBFT report nothing
+	s.engine = consensus.NewFaker()
+	require.NoError(t, s.reportConsensus(conn))
+	select {
+	case msg := <-server.reports:
+		t.Fatalf("unexpected report %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
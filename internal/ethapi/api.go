@@ -137,6 +137,102 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
+// ContentFrom returns the transactions contained within the transaction pool
+// sent by the address.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	content := make(map[string]map[string]*RPCTransaction, 2)
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	// Build the pending transactions
+	dump := make(map[string]*RPCTransaction, len(pending))
+	for _, tx := range pending {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["pending"] = dump
+
+	// Build the queued transactions
+	dump = make(map[string]*RPCTransaction, len(queue))
+	for _, tx := range queue {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["queued"] = dump
+
+	return content
+}
+
+// RPCDroppedTransaction represents a transaction dropped from the pool that will
+// serialize to the RPC representation.
+type RPCDroppedTransaction struct {
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	Reason     string         `json:"reason"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+	Time       hexutil.Uint64 `json:"time"`
+}
+
+func newRPCDroppedTransaction(drop *core.DroppedTx) *RPCDroppedTransaction {
+	return &RPCDroppedTransaction{
+		Hash:       drop.Hash,
+		From:       drop.From,
+		Nonce:      hexutil.Uint64(drop.Nonce),
+		Reason:     drop.Reason,
+		ReplacedBy: drop.ReplacedBy,
+		Time:       hexutil.Uint64(drop.Time.Unix()),
+	}
+}
+
+// Dropped returns why the transaction was dropped from the pool, nil if it isn't
+// among the latest dropped transactions.
+func (s *PublicTxPoolAPI) Dropped(hash common.Hash) *RPCDroppedTransaction {
+	if drop := s.b.TxPoolDropped(hash); drop != nil {
+		return newRPCDroppedTransaction(drop)
+	}
+	return nil
+}
+
+// DropLog returns the latest transactions dropped from the pool, the oldest first.
+func (s *PublicTxPoolAPI) DropLog() []*RPCDroppedTransaction {
+	drops := s.b.TxPoolDropLog()
+	result := make([]*RPCDroppedTransaction, 0, len(drops))
+	for _, drop := range drops {
+		result = append(result, newRPCDroppedTransaction(drop))
+	}
+	return result
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction is dropped from the pool, telling the reason.
+func (s *PublicTxPoolAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		dropsCh := make(chan core.DroppedTxsEvent, 128)
+		dropsSub := s.b.SubscribeDroppedTxsEvent(dropsCh)
+		defer dropsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-dropsCh:
+				for _, drop := range ev.Drops {
+					notifier.Notify(rpcSub.ID, newRPCDroppedTransaction(drop))
+				}
+			case <-dropsSub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := s.b.Stats()
@@ -349,10 +349,50 @@ web3._extend({
 			params: 2,
 			inputFormatter:[null, null],
 		}),
+		new web3._extend.Method({
+			name: 'stateDiffByNumber',
+			call: 'debug_stateDiffByNumber',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'stateDiffByHash',
+			call: 'debug_stateDiffByHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stateDiffTransaction',
+			call: 'debug_stateDiffTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dumpPPOS',
+			call: 'debug_dumpPPOS',
+		}),
+		new web3._extend.Method({
+			name: 'pposStateHash',
+			call: 'debug_pposStateHash',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null],
+		}),
 		new web3._extend.Method({
 			name: 'consensusStatus',
 			call: 'debug_consensusStatus',
 		}),
+		new web3._extend.Method({
+			name: 'cbftTrace',
+			call: 'debug_cbftTrace',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'cbftRejectedTrace',
+			call: 'debug_cbftRejectedTrace',
+		}),
+		new web3._extend.Method({
+			name: 'exportCbftTrace',
+			call: 'debug_exportCbftTrace',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'economicConfig',
 			call: 'debug_economicConfig',
@@ -573,7 +613,18 @@ web3._extend({
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'dropped',
+			call: 'txpool_dropped',
+			params: 1,
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -593,6 +644,10 @@ web3._extend({
 				return status;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'dropLog',
+			getter: 'txpool_dropLog'
+		}),
 	]
 });
 `
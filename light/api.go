@@ -0,0 +1,141 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/internal/ethapi"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+)
+
+var errUnknownBlock = errors.New("unknown block")
+
+// PublicLightAPI provides the chain data of the light client, the state is
+// retrieved with proofs against the verified headers.
+type PublicLightAPI struct {
+	lp *LightPlatON
+}
+
+// NewPublicLightAPI creates a new light client API.
+func NewPublicLightAPI(lp *LightPlatON) *PublicLightAPI {
+	return &PublicLightAPI{lp: lp}
+}
+
+// BlockNumber returns the number of the latest verified header.
+func (api *PublicLightAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.lp.CurrentHeader().Number.Uint64())
+}
+
+// GetHeaderByNumber returns the verified canonical header of the number.
+func (api *PublicLightAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) map[string]interface{} {
+	if header := api.header(number); header != nil {
+		return ethapi.RPCMarshalHeader(header)
+	}
+	return nil
+}
+
+// GetHeaderByHash returns the verified header of the hash.
+func (api *PublicLightAPI) GetHeaderByHash(ctx context.Context, hash common.Hash) map[string]interface{} {
+	if header := api.lp.GetHeaderByHash(hash); header != nil {
+		return ethapi.RPCMarshalHeader(header)
+	}
+	return nil
+}
+
+// GetBalance returns the balance of the account at the state of the block.
+func (api *PublicLightAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	header, err := api.headerByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	account, _, err := api.lp.GetState(ctx, header, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return (*hexutil.Big)(common.Big0), nil
+	}
+	return (*hexutil.Big)(account.Balance), nil
+}
+
+// GetTransactionCount returns the nonce of the account at the state of the block.
+func (api *PublicLightAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	header, err := api.headerByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	account, _, err := api.lp.GetState(ctx, header, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	var nonce hexutil.Uint64
+	if account != nil {
+		nonce = hexutil.Uint64(account.Nonce)
+	}
+	return &nonce, nil
+}
+
+// GetStorageAt returns the storage of the account at the key at the state of the block.
+func (api *PublicLightAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	header, err := api.headerByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	_, values, err := api.lp.GetState(ctx, header, address, [][]byte{common.HexToHash(key).Bytes()})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// GetCode returns the code of the contract at the state of the block.
+func (api *PublicLightAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	header, err := api.headerByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	account, _, err := api.lp.GetState(ctx, header, address, nil)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	return api.lp.GetCode(ctx, common.BytesToHash(account.CodeHash))
+}
+
+func (api *PublicLightAPI) header(number rpc.BlockNumber) *types.Header {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return api.lp.CurrentHeader()
+	}
+	return api.lp.GetHeaderByNumber(uint64(number))
+}
+
+func (api *PublicLightAPI) headerByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		header = api.header(number)
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.lp.GetHeaderByHash(hash)
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
@@ -0,0 +1,568 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/eth"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/node"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+// forceSyncCycle is the interval of the synchronisation when no head is announced.
+const forceSyncCycle = 10 * time.Second
+
+// validatorsPrefix + epoch (uint64 big endian) -> the proven validators of the epoch
+var validatorsPrefix = []byte("light-validators-")
+
+// storedValidators is the form of the proven validators in the database.
+type storedValidators struct {
+	Start uint64
+	Queue staking.ValidatorQueue
+}
+
+// LightPlatON is the light client of PlatON. It syncs the headers verified
+// by their QuorumCert and retrieves the state from the light servers on
+// demand, verifying it against the state root of the verified headers.
+type LightPlatON struct {
+	chainDb     ethdb.Database
+	chainConfig *params.ChainConfig
+	genesis     common.Hash
+	networkID   uint64
+	verifier    *verify.Verifier
+	peers       *peerSet
+
+	reqID   uint64
+	pending map[uint64]chan interface{}
+	reqLock sync.Mutex
+
+	headLock sync.RWMutex
+	head     *types.Header
+
+	syncCh chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a light client and registers it on the node.
+func New(stack *node.Node, config *eth.Config) (*LightPlatON, error) {
+	chainDb, err := stack.OpenDatabase("lightchaindata", config.DatabaseCache, config.DatabaseHandles, "eth/db/lightchaindata/")
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, genesis, genesisErr := core.SetupGenesisBlock(chainDb, snapshotdb.NewMemBaseDB(), config.Genesis)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
+	}
+	log.Info("Initialised chain configuration", "config", chainConfig)
+	stack.SetP2pChainID(chainConfig.ChainID, chainConfig.PIP7ChainID)
+
+	lp := &LightPlatON{
+		chainDb:     chainDb,
+		chainConfig: chainConfig,
+		genesis:     genesis,
+		networkID:   config.NetworkId,
+		peers:       newPeerSet(),
+		pending:     make(map[uint64]chan interface{}),
+		syncCh:      make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+	switch chainConfig.Cbft.ValidatorMode {
+	case "", common.STATIC_VALIDATOR_MODE:
+		lp.verifier = verify.NewStaticVerifier(verify.GenesisValidators(chainConfig.Cbft.InitialNodes, 0))
+	case common.PPOS_VALIDATOR_MODE:
+		lp.verifier = verify.NewVerifier(verify.GenesisValidators(chainConfig.Cbft.InitialNodes, int(xcom.MaxConsensusVals())))
+		if err := lp.loadValidators(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("light client does not support the validator mode %s", chainConfig.Cbft.ValidatorMode)
+	}
+
+	head := rawdb.ReadHeadHeaderHash(chainDb)
+	if number := rawdb.ReadHeaderNumber(chainDb, head); number != nil {
+		lp.head = rawdb.ReadHeader(chainDb, head, *number)
+	}
+	if lp.head == nil {
+		lp.head = rawdb.ReadHeader(chainDb, genesis, 0)
+	}
+	log.Info("Loaded the light chain", "number", lp.head.Number, "hash", lp.head.Hash())
+
+	stack.RegisterProtocols(lp.Protocols())
+	stack.RegisterAPIs(lp.APIs())
+	stack.RegisterLifecycle(lp)
+	return lp, nil
+}
+
+// Protocols returns the plight protocols of the client.
+func (lp *LightPlatON) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(ProtocolVersions))
+	for _, version := range ProtocolVersions {
+		version := version // Closure for the run
+		protocols = append(protocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  protocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return lp.runPeer(newPeer(int(version), p, rw))
+			},
+		})
+	}
+	return protocols
+}
+
+// APIs returns the RPC services of the light client.
+func (lp *LightPlatON) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "platon",
+			Version:   "1.0",
+			Service:   NewPublicLightAPI(lp),
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Lifecycle, starting the synchronisation of the headers.
+func (lp *LightPlatON) Start() error {
+	lp.wg.Add(1)
+	go lp.syncLoop()
+	log.Info("Light client started")
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the light client.
+func (lp *LightPlatON) Stop() error {
+	close(lp.quit)
+	lp.peers.Close()
+	lp.wg.Wait()
+	lp.chainDb.Close()
+	log.Info("Light client stopped")
+	return nil
+}
+
+// CurrentHeader returns the latest verified header.
+func (lp *LightPlatON) CurrentHeader() *types.Header {
+	lp.headLock.RLock()
+	defer lp.headLock.RUnlock()
+	return lp.head
+}
+
+// GetHeaderByNumber returns the verified canonical header of the number.
+func (lp *LightPlatON) GetHeaderByNumber(number uint64) *types.Header {
+	hash := rawdb.ReadCanonicalHash(lp.chainDb, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadHeader(lp.chainDb, hash, number)
+}
+
+// GetHeaderByHash returns the verified header of the hash.
+func (lp *LightPlatON) GetHeaderByHash(hash common.Hash) *types.Header {
+	number := rawdb.ReadHeaderNumber(lp.chainDb, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadHeader(lp.chainDb, hash, *number)
+}
+
+func (lp *LightPlatON) runPeer(p *peer) error {
+	head := lp.CurrentHeader()
+	if err := p.Handshake(lp.networkID, lp.genesis, head.Hash(), head.Number.Uint64(), false); err != nil {
+		p.Log().Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if !p.serve {
+		return p2p.DiscUselessPeer
+	}
+	if err := lp.peers.Register(p); err != nil {
+		return err
+	}
+	defer lp.peers.Unregister(p.id)
+	p.Log().Debug("Light server connected", "peer", p)
+	lp.triggerSync()
+
+	for {
+		if err := lp.handleMsg(p); err != nil {
+			p.Log().Debug("Light message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg handles the announcements and the responses of a light server.
+func (lp *LightPlatON) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > protocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case AnnounceMsg:
+		var announce announceData
+		if err := msg.Decode(&announce); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.SetHead(announce.Hash, announce.Number)
+		if announce.Number > lp.CurrentHeader().Number.Uint64() {
+			lp.triggerSync()
+		}
+
+	case HeadersMsg:
+		var resp headersData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		lp.deliver(resp.ReqID, &resp)
+
+	case ProofsMsg:
+		var resp proofsData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		lp.deliver(resp.ReqID, &resp)
+
+	case PPOSWritesMsg:
+		var resp pposWritesData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		lp.deliver(resp.ReqID, &resp)
+
+	case CodeMsg:
+		var resp codeData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		lp.deliver(resp.ReqID, &resp)
+
+	case GetHeadersMsg, GetProofsMsg, GetPPOSWritesMsg, GetCodeMsg:
+		return errResp(ErrRequestRejected, "light client does not serve")
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
+// request sends a request built for a new request id to the peer and waits for the response.
+func (lp *LightPlatON) request(ctx context.Context, p *peer, code uint64, build func(reqID uint64) interface{}) (interface{}, error) {
+	reqID := atomic.AddUint64(&lp.reqID, 1)
+	ch := make(chan interface{}, 1)
+	lp.reqLock.Lock()
+	lp.pending[reqID] = ch
+	lp.reqLock.Unlock()
+	defer func() {
+		lp.reqLock.Lock()
+		delete(lp.pending, reqID)
+		lp.reqLock.Unlock()
+	}()
+
+	if err := p2p.Send(p.rw, code, build(reqID)); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(requestTimeout)
+	defer timeout.Stop()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-timeout.C:
+		return nil, errRequestTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-lp.quit:
+		return nil, errClosed
+	}
+}
+
+// deliver hands the response over to the pending request.
+func (lp *LightPlatON) deliver(reqID uint64, resp interface{}) {
+	lp.reqLock.Lock()
+	ch := lp.pending[reqID]
+	lp.reqLock.Unlock()
+	if ch != nil {
+		select {
+		case ch <- resp:
+		default:
+		}
+	}
+}
+
+func (lp *LightPlatON) triggerSync() {
+	select {
+	case lp.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+func (lp *LightPlatON) syncLoop() {
+	defer lp.wg.Done()
+	ticker := time.NewTicker(forceSyncCycle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lp.syncCh:
+		case <-ticker.C:
+		case <-lp.quit:
+			return
+		}
+		p := lp.peers.BestServer()
+		if p == nil {
+			continue
+		}
+		if err := lp.synchronise(p); err != nil {
+			log.Debug("Light synchronisation failed", "peer", p.id, "err", err)
+			if err != errRequestTimeout && err != errClosed {
+				p.Disconnect(p2p.DiscSubprotocolError)
+			}
+		}
+	}
+}
+
+// synchronise fetches the headers from the peer up to its head, each header
+// is verified by its QuorumCert before it is written.
+func (lp *LightPlatON) synchronise(p *peer) error {
+	for {
+		head := lp.CurrentHeader()
+		if _, number := p.Head(); number <= head.Number.Uint64() {
+			return nil
+		}
+		resp, err := lp.request(context.Background(), p, GetHeadersMsg, func(reqID uint64) interface{} {
+			return &getHeadersData{ReqID: reqID, Origin: head.Number.Uint64() + 1, Amount: maxHeadersServe}
+		})
+		if err != nil {
+			return err
+		}
+		headers := resp.(*headersData)
+		if len(headers.Headers) == 0 || len(headers.Headers) != len(headers.Extras) {
+			return nil
+		}
+		for i, header := range headers.Headers {
+			if err := lp.insertHeader(p, header, headers.Extras[i]); err != nil {
+				return err
+			}
+		}
+		log.Debug("Synchronised light headers", "peer", p.id, "count", len(headers.Headers), "number", lp.CurrentHeader().Number)
+	}
+}
+
+// insertHeader verifies the header following the current head and writes it.
+// The validators of the next round are proven at the election blocks.
+func (lp *LightPlatON) insertHeader(p *peer, header *types.Header, extra []byte) error {
+	head := lp.CurrentHeader()
+	if header.ParentHash != head.Hash() || header.Number.Uint64() != head.Number.Uint64()+1 {
+		return fmt.Errorf("%w: header %d is not the child of the head", errInvalidResponse, header.Number.Uint64())
+	}
+	qc, err := lp.verifier.VerifyHeader(header, extra)
+	if err != nil {
+		return fmt.Errorf("invalid header %d: %w", header.Number.Uint64(), err)
+	}
+	if !lp.verifier.Static() && xutil.IsElection(header.Number.Uint64()) {
+		if err := lp.proveElection(p, header, qc.Epoch+1); err != nil {
+			return err
+		}
+	}
+
+	batch := lp.chainDb.NewBatch()
+	rawdb.WriteHeader(batch, header)
+	rawdb.WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64())
+	rawdb.WriteHeadHeaderHash(batch, header.Hash())
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	lp.headLock.Lock()
+	lp.head = header
+	lp.headLock.Unlock()
+	return nil
+}
+
+// proveElection retrieves and verifies the proof of the election block,
+// and keeps the validators of the epoch elected.
+func (lp *LightPlatON) proveElection(p *peer, header *types.Header, epoch uint64) error {
+	ctx := context.Background()
+	proof, err := lp.requestProof(ctx, p, header.Hash(), cvm.StakingContractAddr, [][]byte{staking.GetPPOSHASHKey()})
+	if err != nil {
+		return err
+	}
+	pposHash, err := verify.PPOSHash(header, proof.AccountProof, proof.StorageProofs[0])
+	if err != nil {
+		return err
+	}
+	resp, err := lp.request(ctx, p, GetPPOSWritesMsg, func(reqID uint64) interface{} {
+		return &getHashesData{ReqID: reqID, Hashes: []common.Hash{pposHash}}
+	})
+	if err != nil {
+		return err
+	}
+	writes := resp.(*pposWritesData)
+	if len(writes.Writes) != 1 {
+		return errInvalidResponse
+	}
+	validators, err := verify.VerifyElection(header, &verify.ElectionProof{
+		AccountProof: proof.AccountProof,
+		StorageProof: proof.StorageProofs[0],
+		Writes:       writes.Writes[0],
+	})
+	if err != nil {
+		return fmt.Errorf("invalid election of block %d: %w", header.Number.Uint64(), err)
+	}
+	lp.verifier.AddValidators(epoch, validators)
+	log.Info("Proved the validators of the next round", "epoch", epoch, "start", validators.ValidBlockNumber, "validators", validators.Len())
+	return lp.storeValidators(epoch, validators)
+}
+
+func validatorsKey(epoch uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, epoch)
+	return append(validatorsPrefix, enc...)
+}
+
+// storeValidators persists the validators proven for the epoch.
+func (lp *LightPlatON) storeValidators(epoch uint64, validators *cbfttypes.Validators) error {
+	queue := make(staking.ValidatorQueue, validators.Len())
+	for _, node := range validators.Nodes {
+		var blsPubKey bls.PublicKeyHex
+		copy(blsPubKey[:], node.BlsPubKey.Serialize())
+		queue[node.Index] = &staking.Validator{
+			NodeAddress: node.Address,
+			NodeId:      node.NodeID,
+			BlsPubKey:   blsPubKey,
+		}
+	}
+	enc, err := rlp.EncodeToBytes(&storedValidators{Start: validators.ValidBlockNumber, Queue: queue})
+	if err != nil {
+		return err
+	}
+	return lp.chainDb.Put(validatorsKey(epoch), enc)
+}
+
+// loadValidators loads the validators proven before.
+func (lp *LightPlatON) loadValidators() error {
+	it := lp.chainDb.NewIterator(validatorsPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if !bytes.HasPrefix(it.Key(), validatorsPrefix) || len(it.Key()) != len(validatorsPrefix)+8 {
+			continue
+		}
+		var stored storedValidators
+		if err := rlp.DecodeBytes(it.Value(), &stored); err != nil {
+			return err
+		}
+		epoch := binary.BigEndian.Uint64(it.Key()[len(validatorsPrefix):])
+		lp.verifier.AddValidators(epoch, plugin.BuildCbftValidators(stored.Start, stored.Queue))
+	}
+	return it.Error()
+}
+
+// requestProof retrieves the proof of the account and its storage slots at
+// the block, the proof is not verified.
+func (lp *LightPlatON) requestProof(ctx context.Context, p *peer, block common.Hash, addr common.Address, keys [][]byte) (*proofResponse, error) {
+	resp, err := lp.request(ctx, p, GetProofsMsg, func(reqID uint64) interface{} {
+		return &getProofsData{ReqID: reqID, Requests: []proofRequest{{BlockHash: block, Address: addr, Keys: keys}}}
+	})
+	if err != nil {
+		return nil, err
+	}
+	proofs := resp.(*proofsData)
+	if len(proofs.Proofs) != 1 || len(proofs.Proofs[0].StorageProofs) != len(keys) {
+		return nil, errInvalidResponse
+	}
+	return &proofs.Proofs[0], nil
+}
+
+// GetState retrieves the account and the values of its storage slots at the
+// state of the header. The account is nil if it does not exist.
+func (lp *LightPlatON) GetState(ctx context.Context, header *types.Header, addr common.Address, keys [][]byte) (*state.Account, [][]byte, error) {
+	p := lp.peers.BestServer()
+	if p == nil {
+		return nil, nil, errNoPeers
+	}
+	proof, err := lp.requestProof(ctx, p, header.Hash(), addr, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := verify.VerifyAccountProof(header.Root, addr, proof.AccountProof)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([][]byte, len(keys))
+	if account == nil {
+		return nil, values, nil
+	}
+	for i, key := range keys {
+		if values[i], err = verify.VerifyStorageProof(account.Root, key, proof.StorageProofs[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return account, values, nil
+}
+
+// GetCode retrieves the contract code of the hash.
+func (lp *LightPlatON) GetCode(ctx context.Context, codeHash common.Hash) ([]byte, error) {
+	if codeHash == crypto.Keccak256Hash(nil) {
+		return nil, nil
+	}
+	p := lp.peers.BestServer()
+	if p == nil {
+		return nil, errNoPeers
+	}
+	resp, err := lp.request(ctx, p, GetCodeMsg, func(reqID uint64) interface{} {
+		return &getHashesData{ReqID: reqID, Hashes: []common.Hash{codeHash}}
+	})
+	if err != nil {
+		return nil, err
+	}
+	codes := resp.(*codeData)
+	if len(codes.Codes) != 1 || crypto.Keccak256Hash(codes.Codes[0]) != codeHash {
+		return nil, errInvalidResponse
+	}
+	return codes.Codes[0], nil
+}
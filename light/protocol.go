@@ -0,0 +1,187 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package light implements the light client of PlatON. The light client only
+// syncs the headers and checks the finality of each of them by its CBFT
+// QuorumCert, the state is fetched on demand with Merkle proofs. The full
+// nodes serve the light clients by the plight protocol.
+package light
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	plight1 = 1
+)
+
+// ProtocolName is the official short name of the protocol used during capability negotiation.
+const ProtocolName = "plight"
+
+// ProtocolVersions are the supported versions of the plight protocol (first is primary).
+var ProtocolVersions = []uint{plight1}
+
+// protocolLengths are the number of implemented message corresponding to different protocol versions.
+var protocolLengths = map[uint]uint64{plight1: 10}
+
+const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// plight protocol message codes
+const (
+	StatusMsg        = 0x00
+	AnnounceMsg      = 0x01
+	GetHeadersMsg    = 0x02
+	HeadersMsg       = 0x03
+	GetProofsMsg     = 0x04
+	ProofsMsg        = 0x05
+	GetPPOSWritesMsg = 0x06
+	PPOSWritesMsg    = 0x07
+	GetCodeMsg       = 0x08
+	CodeMsg          = 0x09
+)
+
+const (
+	// The maximum number of items served by one request.
+	maxHeadersServe = 192
+	maxProofsServe  = 64
+	maxWritesServe  = 16
+	maxCodeServe    = 16
+
+	handshakeTimeout = 5 * time.Second
+	requestTimeout   = 10 * time.Second
+)
+
+var (
+	errNotRegistered     = errors.New("peer is not registered")
+	errAlreadyRegistered = errors.New("peer is already registered")
+	errClosed            = errors.New("light client closed")
+	errNoPeers           = errors.New("no light server connected")
+	errRequestTimeout    = errors.New("request timed out")
+	errInvalidResponse   = errors.New("invalid response")
+)
+
+type errCode int
+
+const (
+	ErrMsgTooLarge = iota
+	ErrDecode
+	ErrInvalidMsgCode
+	ErrProtocolVersionMismatch
+	ErrNetworkIdMismatch
+	ErrGenesisBlockMismatch
+	ErrNoStatusMsg
+	ErrExtraStatusMsg
+	ErrRequestRejected
+)
+
+func (e errCode) String() string {
+	return errorToString[int(e)]
+}
+
+var errorToString = map[int]string{
+	ErrMsgTooLarge:             "Message too long",
+	ErrDecode:                  "Invalid message",
+	ErrInvalidMsgCode:          "Invalid message code",
+	ErrProtocolVersionMismatch: "Protocol version mismatch",
+	ErrNetworkIdMismatch:       "NetworkId mismatch",
+	ErrGenesisBlockMismatch:    "Genesis block mismatch",
+	ErrNoStatusMsg:             "No status message",
+	ErrExtraStatusMsg:          "Extra status message",
+	ErrRequestRejected:         "Request rejected",
+}
+
+func errResp(code errCode, format string, v ...interface{}) error {
+	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+}
+
+// statusData is the network packet for the status message.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	Genesis         common.Hash
+	Head            common.Hash
+	Number          uint64
+	Serve           bool // Whether the peer serves the light clients.
+}
+
+// announceData is the network packet announcing a new head of the server.
+type announceData struct {
+	Hash   common.Hash
+	Number uint64
+}
+
+// getHeadersData requests the canonical headers from the origin on.
+type getHeadersData struct {
+	ReqID  uint64
+	Origin uint64
+	Amount uint64
+}
+
+// headersData carries the headers and the extra data of the blocks,
+// the extra data hold the QuorumCert of the block.
+type headersData struct {
+	ReqID   uint64
+	Headers []*types.Header
+	Extras  [][]byte
+}
+
+// proofRequest requests the proof of an account and some of its storage
+// slots at the state of a block.
+type proofRequest struct {
+	BlockHash common.Hash
+	Address   common.Address
+	Keys      [][]byte
+}
+
+type getProofsData struct {
+	ReqID    uint64
+	Requests []proofRequest
+}
+
+// proofResponse holds the nodes proving an account and its storage slots.
+type proofResponse struct {
+	AccountProof  [][]byte
+	StorageProofs [][][]byte
+}
+
+type proofsData struct {
+	ReqID  uint64
+	Proofs []proofResponse
+}
+
+// getHashesData requests items by their hashes.
+type getHashesData struct {
+	ReqID  uint64
+	Hashes []common.Hash
+}
+
+// pposWritesData carries the ordered ppos writes of the blocks.
+type pposWritesData struct {
+	ReqID  uint64
+	Writes [][][2][]byte
+}
+
+// codeData carries the contract codes.
+type codeData struct {
+	ReqID uint64
+	Codes [][]byte
+}
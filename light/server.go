@@ -0,0 +1,268 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb"
+	"github.com/PlatONnetwork/PlatON-Go/event"
+	"github.com/PlatONnetwork/PlatON-Go/light/verify"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+)
+
+// Server serves the light clients from the chain of a full node. Besides the
+// headers and the state proofs, it serves the ppos writes of the election
+// blocks, which prove the validators of the next round to the clients.
+type Server struct {
+	chain     *core.BlockChain
+	chainDb   ethdb.Database
+	networkID uint64
+	peers     *peerSet
+
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a light server of the chain.
+func NewServer(chain *core.BlockChain, chainDb ethdb.Database, networkID uint64) *Server {
+	return &Server{
+		chain:     chain,
+		chainDb:   chainDb,
+		networkID: networkID,
+		peers:     newPeerSet(),
+		quit:      make(chan struct{}),
+	}
+}
+
+// Protocols returns the plight protocols served.
+func (s *Server) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(ProtocolVersions))
+	for _, version := range ProtocolVersions {
+		version := version // Closure for the run
+		protocols = append(protocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  protocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return s.runPeer(newPeer(int(version), p, rw))
+			},
+		})
+	}
+	return protocols
+}
+
+// Start implements node.Lifecycle, starting the announcement of the new heads.
+func (s *Server) Start() error {
+	headCh := make(chan core.ChainHeadEvent, 16)
+	s.headSub = s.chain.SubscribeChainHeadEvent(headCh)
+	s.wg.Add(1)
+	go s.announceLoop(headCh)
+	log.Info("Light server started", "protocol", ProtocolName)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the server.
+func (s *Server) Stop() error {
+	s.headSub.Unsubscribe()
+	close(s.quit)
+	s.peers.Close()
+	s.wg.Wait()
+	log.Info("Light server stopped")
+	return nil
+}
+
+func (s *Server) announceLoop(headCh chan core.ChainHeadEvent) {
+	defer s.wg.Done()
+	for {
+		select {
+		case ev := <-headCh:
+			announce := &announceData{Hash: ev.Block.Hash(), Number: ev.Block.NumberU64()}
+			for _, p := range s.peers.AllPeers() {
+				if err := p2p.Send(p.rw, AnnounceMsg, announce); err != nil {
+					p.Log().Debug("Failed to announce the head", "err", err)
+				}
+			}
+		case <-s.headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *Server) runPeer(p *peer) error {
+	head := s.chain.CurrentHeader()
+	if err := p.Handshake(s.networkID, s.chain.Genesis().Hash(), head.Hash(), head.Number.Uint64(), true); err != nil {
+		p.Log().Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if err := s.peers.Register(p); err != nil {
+		return err
+	}
+	defer s.peers.Unregister(p.id)
+	p.Log().Debug("Light peer connected", "peer", p)
+
+	for {
+		if err := s.handleMsg(p); err != nil {
+			p.Log().Debug("Light message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg serves a request of the light client.
+func (s *Server) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > protocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case AnnounceMsg:
+		// The light clients have nothing to announce.
+		return nil
+
+	case GetHeadersMsg:
+		var req getHeadersData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		amount := req.Amount
+		if amount > maxHeadersServe {
+			amount = maxHeadersServe
+		}
+		resp := &headersData{ReqID: req.ReqID}
+		for number := req.Origin; number < req.Origin+amount; number++ {
+			block := s.chain.GetBlockByNumber(number)
+			if block == nil {
+				break
+			}
+			resp.Headers = append(resp.Headers, block.Header())
+			resp.Extras = append(resp.Extras, block.ExtraData())
+		}
+		// A block without the QC in its extra data can't be verified by the clients.
+		if n := len(resp.Headers); n > 0 && len(resp.Extras[n-1]) == 0 {
+			resp.Headers, resp.Extras = resp.Headers[:n-1], resp.Extras[:n-1]
+		}
+		return p2p.Send(p.rw, HeadersMsg, resp)
+
+	case GetProofsMsg:
+		var req getProofsData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(req.Requests) > maxProofsServe {
+			return errResp(ErrRequestRejected, "%d proofs requested", len(req.Requests))
+		}
+		resp := &proofsData{ReqID: req.ReqID}
+		for _, r := range req.Requests {
+			proof, err := s.proof(r)
+			if err != nil {
+				p.Log().Debug("Failed to serve the proof", "block", r.BlockHash, "address", r.Address, "err", err)
+				break
+			}
+			resp.Proofs = append(resp.Proofs, *proof)
+		}
+		return p2p.Send(p.rw, ProofsMsg, resp)
+
+	case GetPPOSWritesMsg:
+		var req getHashesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(req.Hashes) > maxWritesServe {
+			return errResp(ErrRequestRejected, "%d ppos writes requested", len(req.Hashes))
+		}
+		resp := &pposWritesData{ReqID: req.ReqID}
+		for _, hash := range req.Hashes {
+			resp.Writes = append(resp.Writes, rawdb.ReadPPOSWrites(s.chainDb, hash))
+		}
+		return p2p.Send(p.rw, PPOSWritesMsg, resp)
+
+	case GetCodeMsg:
+		var req getHashesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(req.Hashes) > maxCodeServe {
+			return errResp(ErrRequestRejected, "%d codes requested", len(req.Hashes))
+		}
+		resp := &codeData{ReqID: req.ReqID}
+		for _, hash := range req.Hashes {
+			code, _ := s.chain.StateCache().ContractCode(common.Hash{}, hash)
+			resp.Codes = append(resp.Codes, code)
+		}
+		return p2p.Send(p.rw, CodeMsg, resp)
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+}
+
+// proof returns the proof of the account and its storage slots at the state of the block.
+func (s *Server) proof(req proofRequest) (*proofResponse, error) {
+	header := s.chain.GetHeaderByHash(req.BlockHash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	statedb, err := s.chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	accountProof, err := statedb.GetProof(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	resp := &proofResponse{AccountProof: accountProof}
+	if len(req.Keys) == 0 {
+		return resp, nil
+	}
+	// The storage trie is opened from the account root, the one of the state
+	// object is only loaded once the storage is accessed.
+	account, err := verify.VerifyAccountProof(header.Root, req.Address, accountProof)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range req.Keys {
+		var proof verify.ProofList
+		if account != nil {
+			storage, err := statedb.Database().OpenStorageTrie(crypto.Keccak256Hash(req.Address.Bytes()), account.Root)
+			if err != nil {
+				return nil, err
+			}
+			if err := storage.Prove(crypto.Keccak256(key), 0, &proof); err != nil {
+				return nil, err
+			}
+		}
+		resp.StorageProofs = append(resp.StorageProofs, proof)
+	}
+	return resp, nil
+}
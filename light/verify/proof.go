@@ -0,0 +1,85 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package verify
+
+import (
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/ethdb/memorydb"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/trie"
+)
+
+// ProofList collects the nodes of a Merkle proof in order.
+type ProofList [][]byte
+
+func (n *ProofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *ProofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// proofDB returns a database holding the nodes of the proof keyed by their hash.
+func proofDB(proof [][]byte) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// VerifyAccountProof checks the proof of the account against the state root,
+// and returns the account or nil if the proof shows that it does not exist.
+func VerifyAccountProof(root common.Hash, addr common.Address, proof [][]byte) (*state.Account, error) {
+	value, err := trie.VerifyProof(root, crypto.Keccak256(addr.Bytes()), proofDB(proof))
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// VerifyStorageProof checks the proof of the storage slot against the
+// storage root of an account, and returns the value of the slot. The values
+// in the storage trie are prefixed by a hash, it is removed here.
+func VerifyStorageProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	enc, err := trie.VerifyProof(root, crypto.Keccak256(key), proofDB(proof))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return []byte{}, nil
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > common.HashLength {
+		return content[common.HashLength:], nil
+	}
+	return []byte{}, nil
+}
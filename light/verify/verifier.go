@@ -0,0 +1,280 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package verify checks the CBFT QuorumCerts and the election proofs of the
+// blocks fetched from untrusted peers. It is shared by the light client and the
+// checkpoint sync of the downloader.
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/core/cbfttypes"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/plugin"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+var (
+	errUnknownEpoch   = errors.New("unknown validators of the epoch")
+	errQCMismatch     = errors.New("qc does not match the header")
+	errNotEnoughVotes = errors.New("qc has not enough signatures")
+	errInvalidQCSign  = errors.New("invalid aggregated signature of qc")
+	errNoElection     = errors.New("no validators elected in the ppos writes")
+	errPPOSHash       = errors.New("ppos writes do not match the ppos hash")
+)
+
+// Verifier checks the finality of the headers by the QuorumCert of the block,
+// the QC must be signed by more than two thirds of the validators of its epoch.
+// In the ppos validator mode the validators change every consensus round,
+// the validators of the next round are proven at the election block.
+type Verifier struct {
+	lock   sync.RWMutex
+	static *cbfttypes.Validators // The validators of all the epochs in the static validator mode
+	epochs map[uint64]*cbfttypes.Validators
+}
+
+// NewVerifier creates a verifier for the validators of the ppos mode
+// starting from the validators of the genesis.
+func NewVerifier(genesis *cbfttypes.Validators) *Verifier {
+	return &Verifier{epochs: map[uint64]*cbfttypes.Validators{1: genesis}}
+}
+
+// NewStaticVerifier creates a verifier for the validators never changing.
+func NewStaticVerifier(validators *cbfttypes.Validators) *Verifier {
+	return &Verifier{static: validators}
+}
+
+// GenesisValidators returns the validators of the first round of the chain,
+// they are the first initial nodes of the config up to the limit.
+func GenesisValidators(nodes []params.CbftNode, limit int) *cbfttypes.Validators {
+	if limit > 0 && len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	validators := &cbfttypes.Validators{
+		Nodes:            make(cbfttypes.ValidateNodeMap, len(nodes)),
+		ValidBlockNumber: 1,
+	}
+	for i, node := range nodes {
+		pubKey, err := node.Node.ID.Pubkey()
+		if err != nil {
+			continue
+		}
+		blsPubKey := node.BlsPubKey
+		validators.Nodes[node.Node.ID] = &cbfttypes.ValidateNode{
+			Index:     uint32(i),
+			Address:   crypto.PubkeyToNodeAddress(*pubKey),
+			PubKey:    pubKey,
+			NodeID:    node.Node.ID,
+			BlsPubKey: &blsPubKey,
+		}
+	}
+	return validators
+}
+
+// Validators returns the validators of the epoch, nil if they are unknown.
+func (v *Verifier) Validators(epoch uint64) *cbfttypes.Validators {
+	if v.static != nil {
+		return v.static
+	}
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.epochs[epoch]
+}
+
+// AddValidators adds the validators proven for the epoch.
+func (v *Verifier) AddValidators(epoch uint64, validators *cbfttypes.Validators) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.epochs[epoch] = validators
+}
+
+// Static returns whether the validators never change.
+func (v *Verifier) Static() bool {
+	return v.static != nil
+}
+
+// threshold returns the number of signatures a QC needs, it is the same as the one of cbft.
+func threshold(num int) int {
+	return num - (num-1)/3
+}
+
+// VerifyHeader checks that the QC in the extra data of the block is signed
+// by enough validators of its epoch, and returns the QC.
+func (v *Verifier) VerifyHeader(header *types.Header, extra []byte) (*ctypes.QuorumCert, error) {
+	qc, err := decodeQC(header, extra)
+	if err != nil {
+		return nil, err
+	}
+	validators := v.Validators(qc.Epoch)
+	if validators == nil {
+		return nil, errUnknownEpoch
+	}
+	if err := v.verify(header, qc, validators); err != nil {
+		return nil, err
+	}
+	return qc, nil
+}
+
+// VerifyCheckpoint checks the QC of a trusted checkpoint header with the
+// validators of its round, they are added for the epoch of the QC. The
+// validators of the following rounds are proven from the checkpoint on.
+func (v *Verifier) VerifyCheckpoint(header *types.Header, extra []byte, validators *cbfttypes.Validators) (*ctypes.QuorumCert, error) {
+	qc, err := decodeQC(header, extra)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.verify(header, qc, validators); err != nil {
+		return nil, err
+	}
+	if !v.Static() {
+		v.AddValidators(qc.Epoch, validators)
+	}
+	return qc, nil
+}
+
+// decodeQC decodes the QC of the block from its extra data.
+func decodeQC(header *types.Header, extra []byte) (*ctypes.QuorumCert, error) {
+	_, qc, err := ctypes.DecodeExtra(extra)
+	if err != nil {
+		return nil, err
+	}
+	if qc.BlockHash != header.Hash() || qc.BlockNumber != header.Number.Uint64() {
+		return nil, errQCMismatch
+	}
+	return qc, nil
+}
+
+func (v *Verifier) verify(header *types.Header, qc *ctypes.QuorumCert, validators *cbfttypes.Validators) error {
+	if !v.Static() {
+		if number := header.Number.Uint64(); number < validators.ValidBlockNumber || number >= validators.ValidBlockNumber+xutil.ConsensusSize() {
+			return fmt.Errorf("block %d is out of the round of epoch %d", number, qc.Epoch)
+		}
+	}
+	return verifyQC(validators, qc)
+}
+
+func verifyQC(validators *cbfttypes.Validators, qc *ctypes.QuorumCert) error {
+	if qc.ValidatorSet == nil || qc.ValidatorSet.Size() != uint32(validators.Len()) {
+		return errQCMismatch
+	}
+	if qc.Len() < threshold(validators.Len()) {
+		return errNotEnoughVotes
+	}
+	nodes, err := validators.NodeListByBitArray(qc.ValidatorSet)
+	if err != nil || len(nodes) == 0 {
+		return errNotEnoughVotes
+	}
+	var pub bls.PublicKey
+	pub = *nodes[0].BlsPubKey
+	for _, node := range nodes[1:] {
+		pub.Add(node.BlsPubKey)
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(qc.Signature.Bytes()); err != nil {
+		return err
+	}
+	msg, err := qc.CannibalizeBytes()
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(&pub, string(msg)) {
+		return errInvalidQCSign
+	}
+	return nil
+}
+
+// ElectionProof proves the validators elected at an election block. The
+// ppos hash stored in the state of the block is the hash chain of the ordered
+// ppos writes of the block, the writes hold the validators of the next round.
+type ElectionProof struct {
+	AccountProof [][]byte    // The proof of the staking contract account
+	StorageProof [][]byte    // The proof of the ppos hash in the storage of the staking contract
+	Writes       [][2][]byte // The ordered ppos writes of the block
+}
+
+// electedRound returns the first and the last block of the round whose
+// validators are elected at the election block.
+func electedRound(election uint64) (uint64, uint64) {
+	start := election + xcom.ElectionDistance() + 1
+	return start, start + xutil.ConsensusSize() - 1
+}
+
+// PPOSHash returns the ppos hash proven in the state of the header.
+func PPOSHash(header *types.Header, accountProof, storageProof [][]byte) (common.Hash, error) {
+	account, err := VerifyAccountProof(header.Root, cvm.StakingContractAddr, accountProof)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if account == nil {
+		return common.Hash{}, errors.New("staking contract account not found")
+	}
+	value, err := VerifyStorageProof(account.Root, staking.GetPPOSHASHKey(), storageProof)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// VerifyElection checks the proof of the election block and returns the
+// validators of the next round.
+func VerifyElection(header *types.Header, proof *ElectionProof) (*cbfttypes.Validators, error) {
+	if !xutil.IsElection(header.Number.Uint64()) {
+		return nil, fmt.Errorf("block %d is not an election block", header.Number.Uint64())
+	}
+	pposHash, err := PPOSHash(header, proof.AccountProof, proof.StorageProof)
+	if err != nil {
+		return nil, err
+	}
+	hash := common.ZeroHash
+	for _, kv := range proof.Writes {
+		hash = common.GenerateKVHash(kv[0], kv[1], hash)
+	}
+	if hash != pposHash {
+		return nil, errPPOSHash
+	}
+	start, end := electedRound(header.Number.Uint64())
+	key := staking.GetRoundValArrKey(start, end)
+	var value []byte
+	for _, kv := range proof.Writes {
+		if bytes.Equal(kv[0], key) {
+			value = kv[1]
+		}
+	}
+	if len(value) == 0 {
+		return nil, errNoElection
+	}
+	var queue staking.ValidatorQueue
+	if err := rlp.DecodeBytes(value, &queue); err != nil {
+		return nil, err
+	}
+	if len(queue) == 0 {
+		return nil, errNoElection
+	}
+	return plugin.BuildCbftValidators(start, queue), nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package verify
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	cvm "github.com/PlatONnetwork/PlatON-Go/common/vm"
+	ctypes "github.com/PlatONnetwork/PlatON-Go/consensus/cbft/types"
+	"github.com/PlatONnetwork/PlatON-Go/consensus/cbft/utils"
+	"github.com/PlatONnetwork/PlatON-Go/core/rawdb"
+	"github.com/PlatONnetwork/PlatON-Go/core/state"
+	"github.com/PlatONnetwork/PlatON-Go/core/types"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/crypto/bls"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+func newTestNodes(t *testing.T, num int) ([]params.CbftNode, []*bls.SecretKey) {
+	bls.Init(bls.BLS12_381)
+	nodes := make([]params.CbftNode, num)
+	keys := make([]*bls.SecretKey, num)
+	for i := 0; i < num; i++ {
+		pk, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sk bls.SecretKey
+		sk.SetByCSPRNG()
+		nodes[i].Node = *discover.NewNode(discover.PubkeyID(&pk.PublicKey), nil, 0, 0)
+		nodes[i].BlsPubKey = *sk.GetPublicKey()
+		keys[i] = &sk
+	}
+	return nodes, keys
+}
+
+// signQC returns the extra data of the header holding a QC signed by the keys of the indexes.
+func signQC(t *testing.T, header *types.Header, epoch uint64, keys []*bls.SecretKey, indexes ...uint32) []byte {
+	qc := &ctypes.QuorumCert{
+		Epoch:        epoch,
+		BlockHash:    header.Hash(),
+		BlockNumber:  header.Number.Uint64(),
+		ValidatorSet: utils.NewBitArray(uint32(len(keys))),
+	}
+	msg, err := qc.CannibalizeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var aggSig bls.Sign
+	for i, index := range indexes {
+		sig := keys[index].Sign(string(msg))
+		if i == 0 {
+			aggSig = *sig
+		} else {
+			aggSig.Add(sig)
+		}
+		qc.ValidatorSet.SetIndex(index, true)
+	}
+	qc.Signature.SetBytes(aggSig.Serialize())
+	extra, err := ctypes.EncodeExtra(1, qc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extra
+}
+
+func TestVerifyHeader(t *testing.T) {
+	nodes, keys := newTestNodes(t, 4)
+	verifier := NewVerifier(GenesisValidators(nodes, 0))
+	header := &types.Header{Number: big.NewInt(1), Extra: make([]byte, 32)}
+
+	qc, err := verifier.VerifyHeader(header, signQC(t, header, 1, keys, 0, 1, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, qc.Len())
+
+	_, err = verifier.VerifyHeader(header, signQC(t, header, 1, keys, 0, 1))
+	assert.Equal(t, errNotEnoughVotes, err)
+
+	_, err = verifier.VerifyHeader(header, signQC(t, header, 2, keys, 0, 1, 2))
+	assert.Equal(t, errUnknownEpoch, err)
+
+	other := &types.Header{Number: big.NewInt(1), Extra: make([]byte, 33)}
+	_, err = verifier.VerifyHeader(other, signQC(t, header, 1, keys, 0, 1, 2))
+	assert.Equal(t, errQCMismatch, err)
+
+	// The signature must match the signers of the bit array.
+	_, qcForged, _ := ctypes.DecodeExtra(signQC(t, header, 1, keys, 0, 1, 2))
+	qcForged.ValidatorSet.SetIndex(2, false)
+	qcForged.ValidatorSet.SetIndex(3, true)
+	forged, _ := ctypes.EncodeExtra(1, qcForged)
+	_, err = verifier.VerifyHeader(header, forged)
+	assert.Equal(t, errInvalidQCSign, err)
+
+	// The blocks out of the round of the epoch are rejected.
+	late := &types.Header{Number: new(big.Int).SetUint64(xutil.ConsensusSize() + 1), Extra: make([]byte, 32)}
+	_, err = verifier.VerifyHeader(late, signQC(t, late, 1, keys, 0, 1, 2))
+	assert.NotNil(t, err)
+
+	// The static validators verify the blocks of any epoch.
+	static := NewStaticVerifier(GenesisValidators(nodes, 0))
+	_, err = static.VerifyHeader(late, signQC(t, late, 5, keys, 0, 1, 2))
+	assert.Nil(t, err)
+}
+
+func TestVerifyElection(t *testing.T) {
+	nodes, _ := newTestNodes(t, 4)
+	number := xutil.ConsensusSize() - xcom.ElectionDistance()
+	assert.True(t, xutil.IsElection(number))
+
+	queue := make(staking.ValidatorQueue, len(nodes))
+	for i, node := range nodes {
+		var blsPubKey bls.PublicKeyHex
+		copy(blsPubKey[:], node.BlsPubKey.Serialize())
+		pubKey, _ := node.Node.ID.Pubkey()
+		queue[i] = &staking.Validator{
+			NodeAddress: crypto.PubkeyToNodeAddress(*pubKey),
+			NodeId:      node.Node.ID,
+			BlsPubKey:   blsPubKey,
+		}
+	}
+	value, err := rlp.EncodeToBytes(queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := electedRound(number)
+	writes := [][2][]byte{
+		{[]byte("key"), []byte("value")},
+		{staking.GetRoundValArrKey(start, end), value},
+	}
+	pposHash := common.ZeroHash
+	for _, kv := range writes {
+		pposHash = common.GenerateKVHash(kv[0], kv[1], pposHash)
+	}
+
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetState(cvm.StakingContractAddr, staking.GetPPOSHASHKey(), pposHash.Bytes())
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb, _ = state.New(root, db)
+	accountProof, err := statedb.GetProof(cvm.StakingContractAddr)
+	assert.Nil(t, err)
+	account, err := VerifyAccountProof(root, cvm.StakingContractAddr, accountProof)
+	assert.Nil(t, err)
+	storage, err := db.OpenStorageTrie(crypto.Keccak256Hash(cvm.StakingContractAddr.Bytes()), account.Root)
+	assert.Nil(t, err)
+	var storageProof ProofList
+	assert.Nil(t, storage.Prove(crypto.Keccak256(staking.GetPPOSHASHKey()), 0, &storageProof))
+
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Root: root}
+	proof := &ElectionProof{AccountProof: accountProof, StorageProof: storageProof, Writes: writes}
+	validators, err := VerifyElection(header, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, start, validators.ValidBlockNumber)
+	assert.Equal(t, len(nodes), validators.Len())
+	for i, node := range nodes {
+		vn, err := validators.FindNodeByID(node.Node.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(i), vn.Index)
+	}
+
+	// The writes must be complete and in order.
+	proof.Writes = [][2][]byte{writes[1], writes[0]}
+	_, err = VerifyElection(header, proof)
+	assert.Equal(t, errPPOSHash, err)
+
+	// The proof must match the state root.
+	proof.Writes = writes
+	header.Root = common.Hash{1}
+	_, err = VerifyElection(header, proof)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LabeledName appends labels to a metric name in the Prometheus notation,
+// name{key="value",...}. The labels are given as key, value pairs. The
+// Prometheus exporter exports them as labels, the other reporters see them
+// as a part of the name.
+func LabeledName(name string, labels ...string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// SplitLabels splits a name built by LabeledName into the bare name and the
+// labels without the braces.
+func SplitLabels(name string) (string, string) {
+	i := strings.IndexByte(name, '{')
+	if i < 0 || !strings.HasSuffix(name, "}") {
+		return name, ""
+	}
+	return name[:i], name[i+1 : len(name)-1]
+}
+
+// LabeledSet registers labeled metrics whose label values move on over
+// time, like the epoch. Reset unregisters all of them so that the series of
+// the past values stop being exported.
+type LabeledSet struct {
+	r     Registry
+	mu    sync.Mutex
+	names map[string]struct{}
+}
+
+// NewLabeledSet creates a set registering into r, or into the
+// DefaultRegistry if r is nil.
+func NewLabeledSet(r Registry) *LabeledSet {
+	if r == nil {
+		r = DefaultRegistry
+	}
+	return &LabeledSet{r: r, names: make(map[string]struct{})}
+}
+
+func (s *LabeledSet) getOrRegister(name string, labels []string, metric interface{}) interface{} {
+	name = LabeledName(name, labels...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.names[name] = struct{}{}
+	return s.r.GetOrRegister(name, metric)
+}
+
+// Counter returns the counter of the name and the labels.
+func (s *LabeledSet) Counter(name string, labels ...string) Counter {
+	return s.getOrRegister(name, labels, NewCounter).(Counter)
+}
+
+// Gauge returns the gauge of the name and the labels.
+func (s *LabeledSet) Gauge(name string, labels ...string) Gauge {
+	return s.getOrRegister(name, labels, NewGauge).(Gauge)
+}
+
+// GaugeFloat64 returns the float gauge of the name and the labels.
+func (s *LabeledSet) GaugeFloat64(name string, labels ...string) GaugeFloat64 {
+	return s.getOrRegister(name, labels, NewGaugeFloat64).(GaugeFloat64)
+}
+
+// Timer returns the timer of the name and the labels.
+func (s *LabeledSet) Timer(name string, labels ...string) Timer {
+	return s.getOrRegister(name, labels, NewTimer).(Timer)
+}
+
+// Reset unregisters all the metrics of the set.
+func (s *LabeledSet) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.names {
+		s.r.Unregister(name)
+	}
+	s.names = make(map[string]struct{})
+}
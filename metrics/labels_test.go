@@ -0,0 +1,31 @@
+package metrics
+
+import "testing"
+
+func TestLabeledName(t *testing.T) {
+	name := LabeledName("cbft/view/changes", "epoch", "3", "role", "validator")
+	if name != `cbft/view/changes{epoch="3",role="validator"}` {
+		t.Fatalf("unexpected name %s", name)
+	}
+	if bare, labels := SplitLabels(name); bare != "cbft/view/changes" || labels != `epoch="3",role="validator"` {
+		t.Fatalf("unexpected split %s %s", bare, labels)
+	}
+	if bare, labels := SplitLabels("cbft/view/changes"); bare != "cbft/view/changes" || labels != "" {
+		t.Fatalf("unexpected split %s %s", bare, labels)
+	}
+}
+
+func TestLabeledSet(t *testing.T) {
+	r := NewRegistry()
+	s := NewLabeledSet(r)
+	s.Counter("counter", "epoch", "1").Inc(1)
+	s.Counter("counter", "epoch", "1").Inc(1)
+	s.GaugeFloat64("gauge", "epoch", "1").Update(0.5)
+	if c, ok := r.Get(`counter{epoch="1"}`).(Counter); !ok || c.Count() != 2 {
+		t.Fatalf("counter not registered: %v", r.Get(`counter{epoch="1"}`))
+	}
+	s.Reset()
+	if r.Get(`counter{epoch="1"}`) != nil || r.Get(`gauge{epoch="1"}`) != nil {
+		t.Fatal("metrics not unregistered")
+	}
+}
@@ -31,18 +31,23 @@ var (
 	typeSummaryTpl         = "# TYPE %s summary\n"
 	keyValueTpl            = "%s %v\n\n"
 	keyQuantileTagValueTpl = "%s {quantile=\"%s\"} %v\n"
+
+	keyLabelsValueTpl            = "%s{%s} %v\n"
+	keyLabelsQuantileTagValueTpl = "%s{%s,quantile=\"%s\"} %v\n"
 )
 
 // collector is a collection of byte buffers that aggregate Prometheus reports
 // for different metric types.
 type collector struct {
-	buff *bytes.Buffer
+	buff  *bytes.Buffer
+	typed map[string]bool // the labeled metrics written a TYPE line for
 }
 
 // newCollector creates a new Prometheus metric aggregator.
 func newCollector() *collector {
 	return &collector{
-		buff: &bytes.Buffer{},
+		buff:  &bytes.Buffer{},
+		typed: make(map[string]bool),
 	}
 }
 
@@ -61,6 +66,9 @@ func (c *collector) addGaugeFloat64(name string, m metrics.GaugeFloat64) {
 func (c *collector) addHistogram(name string, m metrics.Histogram) {
 	pv := []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
 	ps := m.Percentiles(pv)
+	if c.writeLabeledSummary(name, pv, ps, m.Count()) {
+		return
+	}
 	c.writeSummaryCounter(name, m.Count())
 	c.buff.WriteString(fmt.Sprintf(typeSummaryTpl, mutateKey(name)))
 	for i := range pv {
@@ -76,6 +84,9 @@ func (c *collector) addMeter(name string, m metrics.Meter) {
 func (c *collector) addTimer(name string, m metrics.Timer) {
 	pv := []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
 	ps := m.Percentiles(pv)
+	if c.writeLabeledSummary(name, pv, ps, m.Count()) {
+		return
+	}
 	c.writeSummaryCounter(name, m.Count())
 	c.buff.WriteString(fmt.Sprintf(typeSummaryTpl, mutateKey(name)))
 	for i := range pv {
@@ -99,7 +110,16 @@ func (c *collector) addResettingTimer(name string, m metrics.ResettingTimer) {
 }
 
 func (c *collector) writeGaugeCounter(name string, value interface{}) {
+	name, labels := metrics.SplitLabels(name)
 	name = mutateKey(name)
+	if labels != "" {
+		if !c.typed[name] {
+			c.typed[name] = true
+			c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
+		}
+		c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, name, labels, value))
+		return
+	}
 	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
 	c.buff.WriteString(fmt.Sprintf(keyValueTpl, name, value))
 }
@@ -115,6 +135,27 @@ func (c *collector) writeSummaryPercentile(name, p string, value interface{}) {
 	c.buff.WriteString(fmt.Sprintf(keyQuantileTagValueTpl, name, p, value))
 }
 
+// writeLabeledSummary writes the series of a labeled summary. The count is
+// written into the summary itself, a separate count metric would split the
+// series of the summary into interleaved groups. It reports false if the
+// name has no labels.
+func (c *collector) writeLabeledSummary(name string, pv, ps []float64, count int64) bool {
+	name, labels := metrics.SplitLabels(name)
+	if labels == "" {
+		return false
+	}
+	name = mutateKey(name)
+	if !c.typed[name] {
+		c.typed[name] = true
+		c.buff.WriteString(fmt.Sprintf(typeSummaryTpl, name))
+	}
+	for i := range pv {
+		c.buff.WriteString(fmt.Sprintf(keyLabelsQuantileTagValueTpl, name, labels, strconv.FormatFloat(pv[i], 'f', -1, 64), ps[i]))
+	}
+	c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, name+"_count", labels, count))
+	return true
+}
+
 func mutateKey(key string) string {
 	return strings.Replace(key, "/", "_", -1)
 }
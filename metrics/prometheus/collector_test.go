@@ -108,3 +108,45 @@ test_resetting_timer {quantile="0.99"} 120000000
 		t.Fatal("unexpected collector output")
 	}
 }
+
+func TestCollectorLabels(t *testing.T) {
+	c := newCollector()
+
+	for i, epoch := range []string{"1", "2"} {
+		gauge := metrics.NewGauge()
+		gauge.Update(int64(i + 1))
+		c.addGauge(metrics.LabeledName("test/gauge", "epoch", epoch, "role", "validator"), gauge)
+	}
+	for _, role := range []string{"observer", "validator"} {
+		timer := metrics.NewTimer()
+		timer.Update(10 * time.Millisecond)
+		c.addTimer(metrics.LabeledName("test/timer", "role", role), timer)
+		timer.Stop()
+	}
+
+	const expectedOutput = `# TYPE test_gauge gauge
+test_gauge{epoch="1",role="validator"} 1
+test_gauge{epoch="2",role="validator"} 2
+# TYPE test_timer summary
+test_timer{role="observer",quantile="0.5"} 1e+07
+test_timer{role="observer",quantile="0.75"} 1e+07
+test_timer{role="observer",quantile="0.95"} 1e+07
+test_timer{role="observer",quantile="0.99"} 1e+07
+test_timer{role="observer",quantile="0.999"} 1e+07
+test_timer{role="observer",quantile="0.9999"} 1e+07
+test_timer_count{role="observer"} 1
+test_timer{role="validator",quantile="0.5"} 1e+07
+test_timer{role="validator",quantile="0.75"} 1e+07
+test_timer{role="validator",quantile="0.95"} 1e+07
+test_timer{role="validator",quantile="0.99"} 1e+07
+test_timer{role="validator",quantile="0.999"} 1e+07
+test_timer{role="validator",quantile="0.9999"} 1e+07
+test_timer_count{role="validator"} 1
+`
+	exp := c.buff.String()
+	if exp != expectedOutput {
+		t.Log("Expected Output:\n", expectedOutput)
+		t.Log("Actual Output:\n", exp)
+		t.Fatal("unexpected collector output")
+	}
+}
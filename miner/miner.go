@@ -46,6 +46,7 @@ type Config struct {
 	GasPrice  *big.Int      // Minimum gas price for mining a transaction
 	Recommit  time.Duration // The time interval for miner to re-create mining work.
 	Noverify  bool          // Disable remote mining solution verification(only useful in ethash).
+	OnDemand  bool          // Seal blocks only when there are transactions to include (single validator developer mode only).
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -182,6 +182,8 @@ type worker struct {
 	running int32 // The indicator whether the consensus engine is running or not.
 	newTxs  int32 // New arrival transaction count since last sealing work submitting.
 
+	txBlock uint64 // Number of the last block sealed with transactions, atomically accessed
+
 	// External functions
 	isLocalBlock func(block *types.Block) bool // Function used to determine whether the specified block is mined by local miner.
 
@@ -450,7 +452,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 			status := atomic.LoadInt32(&w.commitWorkEnv.commitStatus)
 			if w.isRunning() {
 				if cbftEngine, ok := w.engine.(consensus.Bft); ok {
-					if status == commitStatusIdle {
+					if status == commitStatusIdle && w.hasWork() {
 						if shouldSeal, err := cbftEngine.ShouldSeal(timestamp); err == nil {
 							if shouldSeal {
 								if shouldCommit, commitBlock := w.shouldCommit(timestamp); shouldCommit {
@@ -1115,6 +1117,22 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64,
 	return nil
 }
 
+// hasWork reports whether there is a block to seal. That's always the case
+// unless the miner seals on demand, then transactions must be pending or the
+// blocks sealed before not committed yet, as CBFT commits a block once two
+// descendants reached their QC. Sealing on demand is only safe for the single
+// validator of the developer mode, the other validators of a network would count
+// the skipped views as zero produced blocks.
+func (w *worker) hasWork() bool {
+	if !w.config.OnDemand {
+		return true
+	}
+	if pending, _ := w.eth.TxPool().Stats(); pending > 0 {
+		return true
+	}
+	return atomic.LoadUint64(&w.txBlock) > w.chain.CurrentBlock().NumberU64()
+}
+
 // commit runs any post-transaction state modifications, assembles the final block
 // and commits new work if consensus engine is running.
 func (w *worker) commit(interval func(), update bool, start time.Time) error {
@@ -1153,6 +1171,9 @@ func (w *worker) commit(interval func(), update bool, start time.Time) error {
 		select {
 		case w.taskCh <- &task{receipts: receipts, state: s, block: block, createdAt: time.Now()}:
 			w.unconfirmed.Shift(block.NumberU64() - 1)
+			if len(block.Transactions()) > 0 {
+				atomic.StoreUint64(&w.txBlock, block.NumberU64())
+			}
 
 			feesWei := new(big.Int)
 			for i, tx := range block.Transactions() {
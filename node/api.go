@@ -155,6 +155,7 @@ func (api *privateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		Auth:               api.node.config.HTTPAuth,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -214,6 +215,7 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		Auth:    api.node.config.WSAuth,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
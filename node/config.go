@@ -138,6 +138,10 @@ type Config struct {
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
 
+	// HTTPAuth is the JWT authentication and the rate limiting of the HTTP RPC
+	// interface.
+	HTTPAuth RPCAuthConfig
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
@@ -164,6 +168,10 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSAuth is the JWT authentication and the rate limiting of the websocket RPC
+	// interface.
+	WSAuth RPCAuthConfig
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
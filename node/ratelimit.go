@@ -0,0 +1,113 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
+)
+
+// rateLimitSweepInterval is the interval of the removal of the buckets refilled
+// since the last call of their client.
+const rateLimitSweepInterval = time.Minute
+
+var (
+	rpcLimitedMeter        = metrics.NewRegisteredMeter("rpc/ratelimit/limited", nil)
+	rpcLimitedClientsGauge = metrics.NewRegisteredGauge("rpc/ratelimit/clients", nil)
+)
+
+// limitExceededError is returned for the calls exceeding the rate limit.
+type limitExceededError struct{}
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return "rate limit exceeded" }
+
+// tokenBucket is the bucket of a client, holding the calls it may make.
+type tokenBucket struct {
+	tokens float64
+	last   mclock.AbsTime // Time of the last refill
+}
+
+// rateLimiter limits the rate of the calls of each client with a token bucket.
+type rateLimiter struct {
+	rate  float64 // Tokens added to each bucket per second
+	burst float64 // Capacity of the buckets
+	clock mclock.Clock
+
+	lock      sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep mclock.AbsTime
+}
+
+func newRateLimiter(rate float64, burst int, clock mclock.Clock) *rateLimiter {
+	return &rateLimiter{
+		rate:      rate,
+		burst:     float64(burst),
+		clock:     clock,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: clock.Now(),
+	}
+}
+
+// allow takes a token from the bucket of the client, reporting whether there
+// was any left.
+func (l *rateLimiter) allow(client string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+	bucket := l.buckets[client]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+		rpcLimitedClientsGauge.Update(int64(len(l.buckets)))
+	} else {
+		bucket.tokens = l.refill(bucket, now)
+		bucket.last = now
+	}
+	if bucket.tokens < 1 {
+		rpcLimitedMeter.Mark(1)
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// refill returns the tokens of the bucket at the given time.
+func (l *rateLimiter) refill(bucket *tokenBucket, now mclock.AbsTime) float64 {
+	return math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+}
+
+// sweep drops the full buckets, they're recreated as is on the next call of
+// their client. The lock must be held.
+func (l *rateLimiter) sweep(now mclock.AbsTime) {
+	for client, bucket := range l.buckets {
+		if l.refill(bucket, now) >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+	rpcLimitedClientsGauge.Update(int64(len(l.buckets)))
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := new(mclock.Simulated)
+	limiter := newRateLimiter(2, 3, clock)
+
+	// The burst is available right away, then the client has to wait.
+	for i := 0; i < 3; i++ {
+		if !limiter.allow("a") {
+			t.Fatalf("call %d of the burst limited", i)
+		}
+	}
+	if limiter.allow("a") {
+		t.Fatal("call over the burst allowed")
+	}
+	// Other clients have their own bucket.
+	if !limiter.allow("b") {
+		t.Fatal("call of another client limited")
+	}
+	// The bucket is refilled at the rate.
+	clock.Run(500 * time.Millisecond)
+	if !limiter.allow("a") {
+		t.Fatal("call after refill limited")
+	}
+	if limiter.allow("a") {
+		t.Fatal("call over the refill allowed")
+	}
+	// Idle clients are swept once their bucket is full.
+	clock.Run(rateLimitSweepInterval)
+	limiter.allow("c")
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("bucket count mismatch after sweep: have %d, want 1", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
+	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+)
+
+const (
+	jwtSecretLength = 32              // Length of the HS256 secrets, in bytes
+	jwtClockSkew    = 5 * time.Second // Tolerated clock difference with the token issuers
+)
+
+var (
+	rpcAuthFailureMeter = metrics.NewRegisteredMeter("rpc/auth/failure", nil)
+	rpcForbiddenMeter   = metrics.NewRegisteredMeter("rpc/auth/forbidden", nil)
+
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid token")
+	errTokenExpired = errors.New("token expired")
+	errTokenNotYet  = errors.New("token not valid yet")
+)
+
+// RPCAuthConfig is the access control of an RPC endpoint.
+type RPCAuthConfig struct {
+	// JWTSecret is the path of the file holding the hex encoded secret of the HS256
+	// tokens authenticating the requests, authentication is disabled if empty. A
+	// new secret is generated if the file doesn't exist.
+	JWTSecret string `toml:",omitempty"`
+
+	// RateLimit is the number of calls per second allowed to each client, told
+	// apart by the subject of their token or else by their IP. Rate limiting is
+	// disabled if zero.
+	RateLimit float64 `toml:",omitempty"`
+
+	// RateBurst is the number of calls each client may make in a burst, defaults
+	// to the rate limit.
+	RateBurst int `toml:",omitempty"`
+}
+
+// JWTClaims are the claims of the tokens authenticating the RPC requests.
+type JWTClaims struct {
+	Subject   string   `json:"sub,omitempty"`     // Client the token is issued to
+	IssuedAt  int64    `json:"iat,omitempty"`     // Unix time the token was issued at
+	NotBefore int64    `json:"nbf,omitempty"`     // Unix time the token is valid from
+	Expiry    int64    `json:"exp,omitempty"`     // Unix time the token expires at, zero if never
+	Modules   []string `json:"modules,omitempty"` // Modules the token may call, all of the endpoint's if omitted
+}
+
+// jwtHeader is the only header of the supported tokens.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignJWT returns the token of the claims signed with the HS256 secret.
+func SignJWT(secret []byte, claims JWTClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, unsigned)), nil
+}
+
+// parseJWT verifies the token against the HS256 secret and returns its claims.
+func parseJWT(secret []byte, token string, now time.Time) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	var head struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &head); err != nil || head.Alg != "HS256" {
+		return nil, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, jwtSignature(secret, parts[0]+"."+parts[1])) {
+		return nil, errInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	claims := new(JWTClaims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errInvalidToken
+	}
+	if claims.Expiry != 0 && now.Add(-jwtClockSkew).Unix() >= claims.Expiry {
+		return nil, errTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(jwtClockSkew).Unix() < claims.NotBefore {
+		return nil, errTokenNotYet
+	}
+	return claims, nil
+}
+
+func jwtSignature(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// ObtainJWTSecret loads the hex encoded HS256 secret from the file, generating
+// and storing a new one if the file doesn't exist.
+func ObtainJWTSecret(path string) ([]byte, error) {
+	if data, err := ioutil.ReadFile(path); err == nil {
+		secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret %s: %v", path, err)
+		}
+		if len(secret) != jwtSecretLength {
+			return nil, fmt.Errorf("invalid JWT secret %s: length %d (!= %d)", path, len(secret), jwtSecretLength)
+		}
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	secret := make([]byte, jwtSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
+
+// forbiddenError is returned for the calls of the modules the token isn't
+// allowed to use.
+type forbiddenError struct{ method string }
+
+func (e *forbiddenError) ErrorCode() int { return -32001 }
+
+func (e *forbiddenError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed by the token", e.method)
+}
+
+// rpcAuthHandler authenticates the RPC requests and checks their calls against
+// the modules of their token and the rate limit of their client.
+type rpcAuthHandler struct {
+	next    http.Handler
+	secret  []byte       // nil if authentication is disabled
+	limiter *rateLimiter // nil if rate limiting is disabled
+}
+
+// newRPCAuthHandler wraps the RPC handler with the access control of the config,
+// it's returned as is if there's none.
+func newRPCAuthHandler(next http.Handler, config RPCAuthConfig) (http.Handler, error) {
+	if config.JWTSecret == "" && config.RateLimit <= 0 {
+		return next, nil
+	}
+	h := &rpcAuthHandler{next: next}
+	if config.JWTSecret != "" {
+		secret, err := ObtainJWTSecret(config.JWTSecret)
+		if err != nil {
+			return nil, err
+		}
+		h.secret = secret
+	}
+	if config.RateLimit > 0 {
+		burst := config.RateBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(config.RateLimit))
+		}
+		h.limiter = newRateLimiter(config.RateLimit, burst, mclock.System{})
+	}
+	return h, nil
+}
+
+func (h *rpcAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		client = clientIP(r)
+		claims *JWTClaims
+	)
+	// Let the empty health checks through, they don't call anything.
+	if h.secret != nil && !(r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" && !isWebsocket(r)) {
+		var err error
+		if claims, err = h.authenticate(r); err != nil {
+			rpcAuthFailureMeter.Mark(1)
+			log.Debug("Rejected unauthenticated RPC request", "remote", r.RemoteAddr, "err", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if claims.Subject != "" {
+			client = "sub:" + claims.Subject
+		}
+	}
+	if filter := h.filter(client, claims); filter != nil {
+		r = r.WithContext(rpc.WithCallFilter(r.Context(), filter))
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// authenticate verifies the bearer token of the request.
+func (h *rpcAuthHandler) authenticate(r *http.Request) (*JWTClaims, error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, errMissingToken
+	}
+	return parseJWT(h.secret, strings.TrimPrefix(auth, "Bearer "), time.Now())
+}
+
+// filter returns the call filter of the client, nil if its calls aren't checked.
+func (h *rpcAuthHandler) filter(client string, claims *JWTClaims) rpc.CallFilter {
+	var modules map[string]bool
+	if claims != nil && claims.Modules != nil {
+		modules = make(map[string]bool, len(claims.Modules))
+		for _, module := range claims.Modules {
+			modules[module] = true
+		}
+	}
+	if modules == nil && h.limiter == nil {
+		return nil
+	}
+	return func(method string) error {
+		// The rpc module only describes the endpoint, it's always allowed.
+		if module := rpc.MethodModule(method); modules != nil && module != "rpc" && !modules[module] {
+			rpcForbiddenMeter.Mark(1)
+			return &forbiddenError{method}
+		}
+		if h.limiter != nil && !h.limiter.allow(client) {
+			return &limitExceededError{}
+		}
+		return nil
+	}
+}
+
+// clientIP returns the IP the request comes from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/rpc"
+)
+
+type authTestService struct{}
+
+func (s *authTestService) Echo(str string) string { return str }
+
+func TestJWT(t *testing.T) {
+	var (
+		secret = bytes.Repeat([]byte{1}, jwtSecretLength)
+		now    = time.Unix(1600000000, 0)
+	)
+	tests := []struct {
+		claims JWTClaims
+		secret []byte
+		err    error
+	}{
+		{claims: JWTClaims{Subject: "a", Modules: []string{"platon"}}, secret: secret},
+		{claims: JWTClaims{Expiry: now.Unix() + 60}, secret: secret},
+		{claims: JWTClaims{Expiry: now.Unix() + 1}, secret: secret}, // within the clock skew
+		{claims: JWTClaims{Expiry: now.Unix() - 60}, secret: secret, err: errTokenExpired},
+		{claims: JWTClaims{NotBefore: now.Unix() + 60}, secret: secret, err: errTokenNotYet},
+		{claims: JWTClaims{}, secret: bytes.Repeat([]byte{2}, jwtSecretLength), err: errInvalidToken},
+	}
+	for i, test := range tests {
+		token, err := SignJWT(test.secret, test.claims)
+		if err != nil {
+			t.Fatalf("test %d: can't sign: %v", i, err)
+		}
+		claims, err := parseJWT(secret, token, now)
+		if err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+			continue
+		}
+		if err == nil && claims.Subject != test.claims.Subject {
+			t.Errorf("test %d: subject mismatch: have %q, want %q", i, claims.Subject, test.claims.Subject)
+		}
+	}
+	for _, token := range []string{"", "a.b", "a.b.c", jwtHeader + ".e30."} {
+		if _, err := parseJWT(secret, token, now); err != errInvalidToken {
+			t.Errorf("malformed token %q accepted: %v", token, err)
+		}
+	}
+}
+
+func TestObtainJWTSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "jwtsecret")
+	secret, err := ObtainJWTSecret(path)
+	if err != nil {
+		t.Fatalf("can't generate secret: %v", err)
+	}
+	loaded, err := ObtainJWTSecret(path)
+	if err != nil {
+		t.Fatalf("can't load secret: %v", err)
+	}
+	if !bytes.Equal(secret, loaded) {
+		t.Fatalf("loaded secret mismatch: have %x, want %x", loaded, secret)
+	}
+	ioutil.WriteFile(path, []byte("0x1234"), 0600)
+	if _, err := ObtainJWTSecret(path); err == nil {
+		t.Fatal("short secret accepted")
+	}
+}
+
+// Tests that the requests are authenticated and that their calls are restricted
+// to the modules of their token.
+func TestRPCAuthHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwtsecret")
+	server := rpc.NewServer()
+	defer server.Stop()
+	server.RegisterName("platon", new(authTestService))
+	server.RegisterName("personal", new(authTestService))
+
+	handler, err := newRPCAuthHandler(server, RPCAuthConfig{JWTSecret: path})
+	if err != nil {
+		t.Fatalf("can't create handler: %v", err)
+	}
+	httpsrv := httptest.NewServer(handler)
+	defer httpsrv.Close()
+
+	// Requests without a valid token are rejected before reaching the server.
+	for _, auth := range []string{"", "Bearer nonsense"} {
+		req, _ := http.NewRequest("POST", httpsrv.URL, bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`)))
+		req.Header.Set("content-type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("auth %q: status mismatch: have %d, want %d", auth, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	secret, err := ObtainJWTSecret(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := SignJWT(secret, JWTClaims{Subject: "scraper", Modules: []string{"platon"}})
+	client, err := rpc.DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("Authorization", "Bearer "+token)
+
+	var result string
+	if err := client.Call(&result, "platon_echo", "x"); err != nil || result != "x" {
+		t.Errorf("allowed call failed: %q %v", result, err)
+	}
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Errorf("rpc module call failed: %v", err)
+	}
+	err = client.Call(&result, "personal_echo", "x")
+	if rerr, ok := err.(rpc.Error); !ok || rerr.ErrorCode() != -32001 {
+		t.Errorf("forbidden call not rejected: %v", err)
+	}
+}
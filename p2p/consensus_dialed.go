@@ -51,6 +51,27 @@ func (tasks *dialedTasks) AddTask(task *dialTask) error {
 	return nil
 }
 
+// UpdateTask replaces the destination of the queued task of the node with the
+// complete one found by the discovery, so that the consensus node is dialed
+// without resolving it first. Nodes without a queued task are ignored.
+func (tasks *dialedTasks) UpdateTask(node *discover.Node) bool {
+	if node.Incomplete() {
+		return false
+	}
+	for i, t := range tasks.queue {
+		if t.dest.ID == node.ID {
+			if t.dest.IP.Equal(node.IP) && t.dest.TCP == node.TCP {
+				return false
+			}
+			log.Debug("Consensus dialed task endpoint updated", "id", node.ID.TerminalString(), "ip", node.IP, "tcp", node.TCP)
+			// The queued task might be running, it's replaced rather than modified
+			tasks.queue[i] = &dialTask{flags: t.flags, dest: node}
+			return true
+		}
+	}
+	return false
+}
+
 func (tasks *dialedTasks) RemoveTask(NodeID discover.NodeID) error {
 
 	log.Info("[before remove]Consensus dialed task list before RemoveTask operation", "task queue", tasks.description())
@@ -171,6 +171,12 @@ func (s *dialstate) addConsensus(n *discover.Node) {
 	s.consensus.AddTask(&dialTask{flags: consensusDialedConn, dest: n})
 }
 
+// updateConsensus updates the endpoint of a queued consensus node with the one
+// found by the discovery.
+func (s *dialstate) updateConsensus(n *discover.Node) {
+	s.consensus.UpdateTask(n)
+}
+
 func (s *dialstate) removeConsensus(n *discover.Node) {
 	//delete(s.consensus, n.ID)
 	s.consensus.RemoveTask(n.ID)
@@ -624,6 +624,26 @@ func TestDialResolve(t *testing.T) {
 	}
 }
 
+// Tests that the consensus tasks take the endpoint found by the discovery.
+func TestDialConsensusUpdate(t *testing.T) {
+	state := newDialState(nil, nil, nil, 0, nil, 75)
+	state.addConsensus(discover.NewNode(uintID(1), nil, 0, 0))
+
+	// Unknown and incomplete nodes are ignored
+	state.updateConsensus(discover.NewNode(uintID(2), net.IP{127, 0, 0, 2}, 30303, 30303))
+	state.updateConsensus(discover.NewNode(uintID(1), nil, 0, 0))
+	if tasks := state.consensus.ListTask(); len(tasks) != 1 || !tasks[0].dest.Incomplete() {
+		t.Fatalf("consensus tasks updated: %v", tasks)
+	}
+
+	found := discover.NewNode(uintID(1), net.IP{127, 0, 0, 1}, 30303, 30304)
+	state.updateConsensus(found)
+	tasks := state.newTasks(0, nil, time.Time{})
+	if !reflect.DeepEqual(tasks, []task{&dialTask{flags: consensusDialedConn, dest: found}}) {
+		t.Fatalf("expected dial task with the found endpoint, got %#v", tasks)
+	}
+}
+
 // compares task lists but doesn't care about the order.
 func sametasks(a, b []task) bool {
 	if len(a) != len(b) {
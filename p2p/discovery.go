@@ -0,0 +1,193 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discv5"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
+)
+
+const (
+	// consensusTopic is the discovery topic the validators register under, so
+	// that they find each other without waiting for random lookups.
+	consensusTopic = discv5.Topic("platon-consensus")
+
+	consensusSearchPeriod = 10 * time.Second // Interval of the consensus topic lookups
+	consensusFoundBuffer  = 64               // Buffered consensus nodes found by the topic search
+)
+
+// v5Table makes the topic discovery the primary discovery mechanism of the
+// dialer. The nodes advertising a different chain in their records are skipped,
+// the v4 table, if running, is only used as a fallback.
+type v5Table struct {
+	net      *discv5.Network
+	fallback discoverTable // v4 table, nil if disabled
+	chainIDs []*big.Int    // Chain IDs accepted in the records, any if empty
+	self     *discover.Node
+}
+
+func newV5Table(net *discv5.Network, fallback discoverTable, chainIDs ...*big.Int) *v5Table {
+	t := &v5Table{net: net, fallback: fallback, self: v4Node(net.Self())}
+	for _, id := range chainIDs {
+		if id != nil {
+			t.chainIDs = append(t.chainIDs, id)
+		}
+	}
+	return t
+}
+
+// v4Node converts a v5 node to the node type used by the dialer.
+func v4Node(n *discv5.Node) *discover.Node {
+	return discover.NewNode(discover.NodeID(n.ID), n.IP, n.UDP, n.TCP)
+}
+
+// recordNode returns the node with the endpoint advertised in its record, if
+// any. The TCP port seen by the discovery is not reliable.
+func recordNode(n *discv5.Node, r *enr.Record) *discover.Node {
+	node := v4Node(n)
+	if r == nil {
+		return node
+	}
+	var (
+		ip  enr.IP
+		tcp enr.TCP
+	)
+	if r.Load(&ip) == nil && !net.IP(ip).IsUnspecified() {
+		node.IP = net.IP(ip)
+	}
+	if r.Load(&tcp) == nil && tcp != 0 {
+		node.TCP = uint16(tcp)
+	}
+	return node
+}
+
+// accept reports whether the record is compatible with the local chain.
+func (t *v5Table) accept(r *enr.Record) bool {
+	if r == nil || len(t.chainIDs) == 0 {
+		return true
+	}
+	entry := LoadPlatONEntry(r)
+	if entry == nil || entry.ChainID == nil {
+		return true
+	}
+	for _, id := range t.chainIDs {
+		if id.Cmp(entry.ChainID) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// filter converts the v5 nodes, dropping the ones on another chain.
+func (t *v5Table) filter(nodes []*discv5.Node) []*discover.Node {
+	res := make([]*discover.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n == nil || n.ID == t.net.Self().ID {
+			continue
+		}
+		r := t.net.NodeRecord(n.ID)
+		if t.accept(r) {
+			res = append(res, recordNode(n, r))
+		}
+	}
+	return res
+}
+
+func (t *v5Table) Self() *discover.Node {
+	if t.fallback != nil {
+		return t.fallback.Self()
+	}
+	return t.self
+}
+
+func (t *v5Table) Close() {
+	if t.fallback != nil {
+		t.fallback.Close()
+	}
+}
+
+func (t *v5Table) Resolve(target discover.NodeID) *discover.Node {
+	if n := t.net.Resolve(discv5.NodeID(target)); n != nil {
+		if r := t.net.NodeRecord(n.ID); t.accept(r) {
+			return recordNode(n, r)
+		}
+	}
+	if t.fallback != nil {
+		return t.fallback.Resolve(target)
+	}
+	return nil
+}
+
+func (t *v5Table) Lookup(target discover.NodeID) []*discover.Node {
+	nodes := t.filter(t.net.Lookup(discv5.NodeID(target)))
+	if len(nodes) == 0 && t.fallback != nil {
+		return t.fallback.Lookup(target)
+	}
+	return nodes
+}
+
+func (t *v5Table) ReadRandomNodes(buf []*discover.Node) int {
+	nodes := make([]*discv5.Node, len(buf))
+	nodes = nodes[:t.net.ReadRandomNodes(nodes)]
+	n := copy(buf, t.filter(nodes))
+	if n < len(buf) && t.fallback != nil {
+		n += t.fallback.ReadRandomNodes(buf[n:])
+	}
+	return n
+}
+
+// discoverConsensus registers the local validator under the consensus topic
+// and searches the other validators, feeding them to the consensus dialing
+// until stop is closed.
+func (srv *Server) discoverConsensus(stop <-chan struct{}) {
+	defer srv.loopWG.Done()
+
+	var (
+		found     = make(chan *discv5.Node, consensusFoundBuffer)
+		setPeriod = make(chan time.Duration, 1)
+	)
+	go srv.DiscV5.RegisterTopic(consensusTopic, stop)
+	go srv.DiscV5.SearchTopic(consensusTopic, setPeriod, found, nil)
+	setPeriod <- consensusSearchPeriod
+	defer close(setPeriod)
+
+	for {
+		select {
+		case n := <-found:
+			r := srv.DiscV5.NodeRecord(n.ID)
+			if entry := LoadPlatONEntry(r); r != nil && (entry == nil || entry.Role != RoleValidator) {
+				continue
+			}
+			select {
+			case srv.foundconsensus <- recordNode(n, r):
+			case <-stop:
+				return
+			case <-srv.quit:
+				return
+			}
+		case <-stop:
+			return
+		case <-srv.quit:
+			return
+		}
+	}
+}
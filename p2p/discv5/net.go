@@ -29,6 +29,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common/mclock"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/netutil"
 	"github.com/PlatONnetwork/PlatON-Go/rlp"
 )
@@ -77,6 +78,7 @@ type Network struct {
 	nursery       []*Node
 	nodes         map[NodeID]*Node // tracks active nodes with state != known
 	timeoutTimers map[timeoutEvent]*time.Timer
+	localRecord   *enr.Record // signed record of the local node, nil if not set
 }
 
 // transport is implemented by the UDP transport.
@@ -176,6 +178,23 @@ func (net *Network) Self() *Node {
 	return net.tab.self
 }
 
+// SetLocalRecord sets the signed record of the local node, which is served to
+// the remote nodes and advertised through its sequence number in pings.
+func (net *Network) SetLocalRecord(r *enr.Record) {
+	net.reqTableOp(func() { net.localRecord = r })
+}
+
+// NodeRecord returns the latest record retrieved from the given node, nil if
+// the node is unknown or hasn't advertised a record.
+func (net *Network) NodeRecord(id NodeID) (r *enr.Record) {
+	net.reqTableOp(func() {
+		if n := net.nodes[id]; n != nil {
+			r = n.record
+		}
+	})
+	return r
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -773,6 +792,9 @@ type nodeNetGuts struct {
 	deferredQueries   []*findnodeQuery // queries that can't be sent yet
 	pendingNeighbours *findnodeQuery   // current query, waiting for reply
 	queryTimeouts     int
+	record            *enr.Record // latest record retrieved from the node
+	recordSeq         uint64      // latest record sequence number advertised by the node
+	recordEcho        []byte      // hash of last record request sent by us
 }
 
 func (n *nodeNetGuts) deferQuery(q *findnodeQuery) {
@@ -829,6 +851,8 @@ const (
 	topicRegisterPacket
 	topicQueryPacket
 	topicNodesPacket
+	enrRequestPacket
+	enrResponsePacket
 
 	// Non-packet events.
 	// Event values in this category are allocated outside
@@ -939,6 +963,10 @@ func init() {
 			case pingPacket:
 				net.handlePing(n, pkt)
 				return remoteverifywait, nil
+			case enrRequestPacket:
+				// The node answered our ping, it may query our record already.
+				err := net.handleRecordRequest(n, pkt)
+				return remoteverifywait, err
 			case pingTimeout:
 				return known, nil
 			default:
@@ -960,14 +988,17 @@ func init() {
 				// TODO: do this asynchronously
 				net.transition(last, contested)
 			}
+			net.requestRecord(n)
 		},
 		handle: func(net *Network, n *Node, ev nodeEvent, pkt *ingressPacket) (*nodeState, error) {
 			switch ev {
 			case pingPacket:
 				net.handlePing(n, pkt)
+				net.requestRecord(n)
 				return known, nil
 			case pongPacket:
 				err := net.handleKnownPong(n, pkt)
+				net.requestRecord(n)
 				return known, err
 			default:
 				return net.handleQueryEvent(n, ev, pkt)
@@ -1110,12 +1141,14 @@ func (net *Network) handlePing(n *Node, pkt *ingressPacket) {
 	log.Trace("Handling remote ping", "node", n.ID)
 	ping := pkt.data.(*ping)
 	n.TCP = ping.From.TCP
+	n.advertiseRecord(ping.ENRSeq)
 	t := net.topictab.getTicket(n, ping.Topics)
 
 	pong := &pong{
 		To:         makeEndpoint(n.addr(), n.TCP), // TODO: maybe use known TCP port from DB
 		ReplyTok:   pkt.hash,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		ENRSeq:     net.localSeq(),
 	}
 	ticketToPong(t, pong)
 	net.conn.send(n, pongPacket, pong)
@@ -1134,9 +1167,70 @@ func (net *Network) handleKnownPong(n *Node, pkt *ingressPacket) error {
 	}
 	n.pingEcho = nil
 	n.pingTopics = nil
+	n.advertiseRecord(pkt.data.(*pong).ENRSeq)
 	return err
 }
 
+// localSeq returns the sequence number of the local node record.
+func (net *Network) localSeq() uint64 {
+	if net.localRecord == nil {
+		return 0
+	}
+	return net.localRecord.Seq()
+}
+
+// advertiseRecord tracks the record sequence number advertised by the node.
+func (n *Node) advertiseRecord(seq uint64) {
+	if seq > n.recordSeq {
+		n.recordSeq = seq
+	}
+}
+
+// requestRecord requests the record of a verified node if it advertised a newer
+// one than the record retrieved so far.
+func (net *Network) requestRecord(n *Node) {
+	if n.recordSeq == 0 || (n.record != nil && n.record.Seq() >= n.recordSeq) {
+		return
+	}
+	n.recordEcho = net.conn.send(n, enrRequestPacket, enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	})
+}
+
+// handleRecordRequest replies to a record request with the local record.
+func (net *Network) handleRecordRequest(n *Node, pkt *ingressPacket) error {
+	if net.localRecord == nil {
+		return errors.New("no local record")
+	}
+	net.conn.send(n, enrResponsePacket, enrResponse{ReplyTok: pkt.hash, Record: *net.localRecord})
+	return nil
+}
+
+// handleRecord stores the record in a response to our last record request, if
+// it's signed by the node and newer than the one retrieved so far.
+func (net *Network) handleRecord(n *Node, pkt *ingressPacket) error {
+	res := pkt.data.(*enrResponse)
+	if n.recordEcho == nil || !bytes.Equal(res.ReplyTok, n.recordEcho) {
+		return errors.New("unsolicited record")
+	}
+	n.recordEcho = nil
+
+	var pubkey enr.Secp256k1
+	if err := res.Record.Load(&pubkey); err != nil {
+		return err
+	}
+	if PubkeyID((*ecdsa.PublicKey)(&pubkey)) != n.ID {
+		return errors.New("record not signed by node")
+	}
+	if n.record != nil && n.record.Seq() >= res.Record.Seq() {
+		return nil
+	}
+	record := res.Record
+	n.record = &record
+	n.advertiseRecord(record.Seq())
+	return nil
+}
+
 func (net *Network) handleQueryEvent(n *Node, ev nodeEvent, pkt *ingressPacket) (*nodeState, error) {
 	switch ev {
 	case findnodePacket:
@@ -1188,6 +1282,12 @@ func (net *Network) handleQueryEvent(n *Node, ev nodeEvent, pkt *ingressPacket)
 		copy(hash[:], pkt.hash)
 		net.conn.sendTopicNodes(n, hash, results)
 		return n.state, nil
+	case enrRequestPacket:
+		err := net.handleRecordRequest(n, pkt)
+		return n.state, err
+	case enrResponsePacket:
+		err := net.handleRecord(n, pkt)
+		return n.state, err
 	case topicNodesPacket:
 		p := pkt.data.(*topicNodes)
 		if net.ticketStore.gotTopicNodes(n, p.Echo, p.Nodes) {
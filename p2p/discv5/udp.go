@@ -27,6 +27,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/netutil"
 	"github.com/PlatONnetwork/PlatON-Go/rlp"
 )
@@ -55,6 +56,9 @@ type (
 		// v5
 		Topics []Topic
 
+		// Sequence number of the sender's node record
+		ENRSeq uint64 `rlp:"optional"`
+
 		// Ignore additional fields (for forward compatibility).
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
@@ -74,6 +78,9 @@ type (
 		TicketSerial uint32
 		WaitPeriods  []uint32
 
+		// Sequence number of the sender's node record
+		ENRSeq uint64 `rlp:"optional"`
+
 		// Ignore additional fields (for forward compatibility).
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
@@ -119,6 +126,21 @@ type (
 		Nodes []rpcNode
 	}
 
+	// enrRequest queries the node record of the recipient.
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// reply to enrRequest
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -260,6 +282,7 @@ func (t *udp) sendPing(remote *Node, toaddr *net.UDPAddr, topics []Topic) (hash
 		To:         makeEndpoint(toaddr, uint16(toaddr.Port)), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 		Topics:     topics,
+		ENRSeq:     t.net.localSeq(),
 	})
 	return hash
 }
@@ -419,6 +442,10 @@ func decodePacket(buffer []byte, pkt *ingressPacket) error {
 		pkt.data = new(topicQuery)
 	case topicNodesPacket:
 		pkt.data = new(topicNodes)
+	case enrRequestPacket:
+		pkt.data = new(enrRequest)
+	case enrResponsePacket:
+		pkt.data = new(enrResponse)
 	default:
 		return fmt.Errorf("unknown packet type: %d", sigdata[0])
 	}
@@ -0,0 +1,73 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package discv5
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
+)
+
+// Tests that the nodes exchange their records once they verified each other.
+func TestUDP_NodeRecords(t *testing.T) {
+	nets := make([]*Network, 2)
+	for i := range nets {
+		key, _ := crypto.GenerateKey()
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		realaddr := conn.LocalAddr().(*net.UDPAddr)
+		if nets[i], err = ListenUDP(key, conn, realaddr, "", nil); err != nil {
+			t.Fatal(err)
+		}
+		defer nets[i].Close()
+
+		var r enr.Record
+		r.Set(enr.IP(realaddr.IP))
+		r.Set(enr.UDP(realaddr.Port))
+		r.Set(enr.WithEntry("test", uint(i)))
+		if err := enr.SignV4(&r, key); err != nil {
+			t.Fatal(err)
+		}
+		nets[i].SetLocalRecord(&r)
+	}
+	if err := nets[1].SetFallbackNodes([]*Node{nets[0].Self()}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for i, net := range nets {
+		remote := nets[1-i]
+		for {
+			if r := net.NodeRecord(remote.Self().ID); r != nil {
+				var value uint
+				if err := r.Load(enr.WithEntry("test", &value)); err != nil || value != uint(1-i) {
+					t.Fatalf("node %d: record entry mismatch: have %d, want %d, err %v", i, value, 1-i, err)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("node %d: record of the remote node not retrieved", i)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+)
+
+// NodeRole is the role a node advertises in its record.
+type NodeRole uint8
+
+const (
+	RoleSync      NodeRole = iota // Node synchronising and serving the chain
+	RoleArchive                   // Node keeping the whole history of the state
+	RoleValidator                 // Node taking part in the consensus
+)
+
+func (r NodeRole) String() string {
+	switch r {
+	case RoleSync:
+		return "sync"
+	case RoleArchive:
+		return "archive"
+	case RoleValidator:
+		return "validator"
+	default:
+		return fmt.Sprintf("role(%d)", uint8(r))
+	}
+}
+
+// PlatONEntry is the "platon" entry of the node records, advertising the chain
+// the node is on and the role it plays.
+type PlatONEntry struct {
+	ChainID *big.Int
+	ForkID  []byte // Identifier of the forks activated on the chain, empty if unknown
+	Role    NodeRole
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e PlatONEntry) ENRKey() string { return "platon" }
+
+// LoadPlatONEntry returns the "platon" entry of the record, nil if the record
+// is nil or doesn't have a valid one.
+func LoadPlatONEntry(r *enr.Record) *PlatONEntry {
+	if r == nil {
+		return nil
+	}
+	var entry PlatONEntry
+	if err := r.Load(&entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// localRecord maintains the signed record of the local node. A new record with
+// a higher sequence number is signed whenever the advertised content changes.
+type localRecord struct {
+	key       *ecdsa.PrivateKey
+	ip        net.IP
+	udp, tcp  int
+	entry     PlatONEntry
+	role      NodeRole // Role advertised while the node isn't a validator
+	validator bool
+	seq       uint64
+	record    *enr.Record
+	update    func(*enr.Record) // Called with each new record, may be nil
+
+	lock sync.Mutex
+}
+
+func newLocalRecord(key *ecdsa.PrivateKey, ip net.IP, udp, tcp int, chainID *big.Int) (*localRecord, error) {
+	r := &localRecord{key: key, ip: ip, udp: udp, tcp: tcp, entry: PlatONEntry{ChainID: chainID}}
+	if err := r.sign(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record returns the current signed record.
+func (r *localRecord) Record() *enr.Record {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.record
+}
+
+// setUpdate sets the callback receiving each new record.
+func (r *localRecord) setUpdate(update func(*enr.Record)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.update = update
+}
+
+// setRole sets the role advertised while the node isn't a validator.
+func (r *localRecord) setRole(role NodeRole) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.role == role {
+		return nil
+	}
+	r.role = role
+	return r.sign()
+}
+
+// setValidator sets whether the node advertises itself as a validator.
+func (r *localRecord) setValidator(validator bool) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.validator == validator {
+		return nil
+	}
+	r.validator = validator
+	return r.sign()
+}
+
+// setForkID sets the fork identifier of the chain.
+func (r *localRecord) setForkID(id []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if bytes.Equal(r.entry.ForkID, id) {
+		return nil
+	}
+	r.entry.ForkID = id
+	return r.sign()
+}
+
+// sign signs a new record with the current content, the lock must be held.
+// A fresh record is created every time so the records handed out are never
+// modified.
+func (r *localRecord) sign() error {
+	entry := r.entry
+	entry.Role = r.role
+	if r.validator {
+		entry.Role = RoleValidator
+	}
+	record := new(enr.Record)
+	if r.ip != nil && !r.ip.IsUnspecified() {
+		record.Set(enr.IP(r.ip))
+	}
+	record.Set(enr.UDP(r.udp))
+	record.Set(enr.TCP(r.tcp))
+	record.Set(&entry)
+	record.SetSeq(r.seq + 1)
+	if err := enr.SignV4(record, r.key); err != nil {
+		return err
+	}
+	r.seq, r.record = record.Seq(), record
+	if r.update != nil {
+		r.update(record)
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/rlpx"
 	"math/big"
@@ -37,8 +38,10 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/log"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discv5"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/nat"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/netutil"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
 )
 
 const (
@@ -199,6 +202,11 @@ type Server struct {
 
 	eventMux  *event.TypeMux
 	consensus bool
+
+	record         *localRecord        // Signed record of the local node
+	role           NodeRole            // Role advertised in the record while not a validator
+	forkID         []byte              // Fork identifier advertised in the record
+	foundconsensus chan *discover.Node // Validators found by the consensus topic search
 }
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
@@ -379,6 +387,46 @@ func (srv *Server) RemoveTrustedPeer(node *discover.Node) {
 	}
 }
 
+// NodeRecord returns the signed record of the local node, nil if the server
+// isn't running.
+func (srv *Server) NodeRecord() *enr.Record {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running || srv.record == nil {
+		return nil
+	}
+	return srv.record.Record()
+}
+
+// SetNodeRole sets the role advertised in the record of the local node while
+// it isn't a validator.
+func (srv *Server) SetNodeRole(role NodeRole) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.role = role
+	if srv.record != nil {
+		if err := srv.record.setRole(role); err != nil {
+			srv.log.Error("Failed to update node record", "err", err)
+		}
+	}
+}
+
+// SetForkID sets the fork identifier of the chain advertised in the record of
+// the local node.
+func (srv *Server) SetForkID(id []byte) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.forkID = id
+	if srv.record != nil {
+		if err := srv.record.setForkID(id); err != nil {
+			srv.log.Error("Failed to update node record", "err", err)
+		}
+	}
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -494,6 +542,7 @@ func (srv *Server) Start() (err error) {
 	srv.removeconsensus = make(chan *discover.Node)
 	srv.addtrusted = make(chan *discover.Node)
 	srv.removetrusted = make(chan *discover.Node)
+	srv.foundconsensus = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -566,6 +615,8 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 		srv.DiscV5 = ntab
+		// The topic discovery is the primary discovery mechanism once enabled
+		srv.ntab = newV5Table(ntab, srv.ntab, srv.ChainID, srv.PIP7ChainID)
 	}
 
 	dynPeers := srv.maxDialedConns()
@@ -585,6 +636,9 @@ func (srv *Server) Start() (err error) {
 	if srv.NoDial && srv.ListenAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
+	if err := srv.setupLocalRecord(realaddr); err != nil {
+		return err
+	}
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
@@ -592,6 +646,37 @@ func (srv *Server) Start() (err error) {
 	return nil
 }
 
+// setupLocalRecord signs the record of the local node, advertised by the topic
+// discovery if enabled.
+func (srv *Server) setupLocalRecord(realaddr *net.UDPAddr) error {
+	var (
+		ip       net.IP
+		udp, tcp int
+	)
+	if realaddr != nil {
+		ip, udp = realaddr.IP, realaddr.Port
+	}
+	if srv.listener != nil {
+		tcp = srv.listener.Addr().(*net.TCPAddr).Port
+	}
+	record, err := newLocalRecord(srv.PrivateKey, ip, udp, tcp, srv.ChainID)
+	if err != nil {
+		return err
+	}
+	if err := record.setRole(srv.role); err != nil {
+		return err
+	}
+	if err := record.setForkID(srv.forkID); err != nil {
+		return err
+	}
+	if srv.DiscV5 != nil {
+		srv.DiscV5.SetLocalRecord(record.Record())
+		record.setUpdate(srv.DiscV5.SetLocalRecord)
+	}
+	srv.record = record
+	return nil
+}
+
 func (srv *Server) startListening() error {
 	// Launch the TCP listener.
 	listener, err := net.Listen("tcp", srv.ListenAddr)
@@ -620,6 +705,7 @@ type dialer interface {
 	addStatic(*discover.Node)
 	removeStatic(*discover.Node)
 	addConsensus(*discover.Node)
+	updateConsensus(*discover.Node)
 	removeConsensus(*discover.Node)
 	removeConsensusFromQueue(*discover.Node)
 	initRemoveConsensusPeerFn(removeConsensusPeerFn removeConsensusPeerFn)
@@ -635,10 +721,32 @@ func (srv *Server) run(dialstate dialer) {
 		taskdone       = make(chan task, maxActiveDialTasks)
 		tick           = time.NewTicker(30 * time.Second)
 		runningTasks   []task
-		queuedTasks    []task // tasks that can't run yet
+		queuedTasks    []task        // tasks that can't run yet
+		consensusStop  chan struct{} // closed to stop the consensus topic discovery
 	)
 	defer tick.Stop()
 
+	// setValidator advertises whether the local node is a validator, running
+	// the consensus topic discovery while it is.
+	setValidator := func(validator bool) {
+		if srv.record != nil {
+			if err := srv.record.setValidator(validator); err != nil {
+				srv.log.Error("Failed to update node record", "err", err)
+			}
+		}
+		if srv.DiscV5 == nil || validator == (consensusStop != nil) {
+			return
+		}
+		if validator {
+			consensusStop = make(chan struct{})
+			srv.loopWG.Add(1)
+			go srv.discoverConsensus(consensusStop)
+		} else {
+			close(consensusStop)
+			consensusStop = nil
+		}
+	}
+
 	// Put trusted nodes into a map to speed up checks.
 	// Trusted peers are loaded on startup or added via AddTrustedPeer RPC.
 	for _, n := range srv.TrustedNodes {
@@ -716,6 +824,7 @@ running:
 			if n.ID == srv.ourHandshake.ID {
 				srv.log.Debug("We are become an consensus node")
 				srv.consensus = true
+				setValidator(true)
 			} else {
 				dialstate.addConsensus(n)
 			}
@@ -731,6 +840,7 @@ running:
 			if n.ID == srv.ourHandshake.ID {
 				srv.log.Debug("We are not an consensus node")
 				srv.consensus = false
+				setValidator(false)
 			}
 			dialstate.removeConsensus(n)
 			if _, ok := consensusNodes[n.ID]; ok {
@@ -747,6 +857,11 @@ running:
 					p.Disconnect(DiscRequested)
 				}
 			}
+		case n := <-srv.foundconsensus:
+			// A validator was found by the consensus topic discovery, the
+			// endpoint is used if we are to dial it.
+			srv.log.Trace("Found consensus node", "node", n)
+			dialstate.updateConsensus(n)
 		case n := <-srv.addtrusted:
 			// This channel is used by AddTrustedPeer to add an enode
 			// to the trusted node set.
@@ -835,6 +950,10 @@ running:
 
 	srv.log.Trace("P2P networking is spinning down")
 
+	if consensusStop != nil {
+		close(consensusStop)
+	}
+
 	// Terminate discovery. If there is a running lookup it will terminate soon.
 	if srv.ntab != nil {
 		srv.ntab.Close()
@@ -911,7 +1030,7 @@ func (srv *Server) maxInboundConns() int {
 }
 
 func (srv *Server) maxDialedConns() int {
-	if srv.NoDiscovery || srv.NoDial {
+	if (srv.NoDiscovery && !srv.DiscoveryV5) || srv.NoDial {
 		return 0
 	}
 	r := srv.DialRatio
@@ -1135,6 +1254,7 @@ type NodeInfo struct {
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
 	ListenAddr string                 `json:"listenAddr"`
+	ENR        string                 `json:"enr,omitempty"` // Signed node record, base64 encoded
 	Protocols  map[string]interface{} `json:"protocols"`
 }
 
@@ -1157,6 +1277,12 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	blskey, _ := srv.BlsPublicKey.MarshalText()
 	info.BlsPub = string(blskey)
 
+	if record := srv.NodeRecord(); record != nil {
+		if enc, err := rlp.EncodeToBytes(record); err == nil {
+			info.ENR = "enr:" + base64.RawURLEncoding.EncodeToString(enc)
+		}
+	}
+
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
 		if _, ok := info.Protocols[proto.Name]; !ok {
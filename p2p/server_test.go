@@ -17,12 +17,15 @@
 package p2p
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"errors"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/rlpx"
+	"math/big"
 	"net"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +34,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/crypto"
 	"github.com/PlatONnetwork/PlatON-Go/log"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/enr"
 )
 
 func init() {
@@ -125,6 +129,56 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+// Tests that the record of the local node advertises the chain and the role,
+// signing a new record on every change.
+func TestServerNodeRecord(t *testing.T) {
+	srv := &Server{Config: Config{
+		Name:        "test",
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		PrivateKey:  newkey(),
+		ChainID:     big.NewInt(100),
+		NoDiscovery: true,
+		DiscoveryV5: true,
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	record := srv.NodeRecord()
+	entry := LoadPlatONEntry(record)
+	if entry == nil || entry.ChainID.Uint64() != 100 || entry.Role != RoleSync {
+		t.Fatalf("record entry mismatch: %+v", entry)
+	}
+	var tcp enr.TCP
+	if err := record.Load(&tcp); err != nil || int(tcp) != srv.listener.Addr().(*net.TCPAddr).Port {
+		t.Fatalf("record tcp port mismatch: %d, err %v", tcp, err)
+	}
+
+	srv.SetNodeRole(RoleArchive)
+	updated := srv.NodeRecord()
+	if entry := LoadPlatONEntry(updated); updated.Seq() <= record.Seq() || entry.Role != RoleArchive {
+		t.Fatalf("record not updated: seq %d, role %v", updated.Seq(), entry.Role)
+	}
+	srv.SetForkID([]byte{0x01, 0x02})
+	forked := srv.NodeRecord()
+	if entry := LoadPlatONEntry(forked); forked.Seq() <= updated.Seq() || !bytes.Equal(entry.ForkID, []byte{0x01, 0x02}) || entry.Role != RoleArchive {
+		t.Fatalf("record fork ID not updated: seq %d, entry %+v", forked.Seq(), entry)
+	}
+	if srv.SetForkID([]byte{0x01, 0x02}); srv.NodeRecord().Seq() != forked.Seq() {
+		t.Fatalf("record signed again with the same fork ID")
+	}
+
+	// The record is advertised by the discovery
+	if srv.ntab.Self().ID != srv.Self().ID {
+		t.Fatalf("discovery self mismatch")
+	}
+	if !strings.HasPrefix(srv.NodeInfo().ENR, "enr:") {
+		t.Fatalf("node info without record: %q", srv.NodeInfo().ENR)
+	}
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -338,6 +392,8 @@ func (tg taskgen) removeStatic(*discover.Node) {
 }
 func (tg taskgen) addConsensus(*discover.Node) {
 }
+func (tg taskgen) updateConsensus(*discover.Node) {
+}
 func (tg taskgen) removeConsensus(*discover.Node) {
 }
 func (tg taskgen) removeConsensusFromQueue(*discover.Node) {
@@ -104,6 +104,8 @@ func (e *ExecAdapter) NewNode(config *NodeConfig) (Node, error) {
 	conf.Stack.WSExposeAll = true
 	conf.Stack.P2P.EnableMsgEvents = config.EnableMsgEvents
 	conf.Stack.P2P.NoDiscovery = true
+	conf.Stack.P2P.DiscoveryV5 = config.Discovery
+	conf.Stack.P2P.BootstrapNodesV5 = config.BootnodesV5
 	conf.Stack.P2P.NAT = nil
 	conf.Stack.NoUSB = true
 
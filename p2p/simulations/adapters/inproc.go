@@ -43,6 +43,9 @@ type SimAdapter struct {
 	lifecycles LifecycleConstructors
 }
 
+// simDiscoveryMaxPeers is the peer limit of the nodes running the discovery.
+const simDiscoveryMaxPeers = 50
+
 // NewSimAdapter creates a SimAdapter which is capable of running in-memory
 // simulation nodes running any of the given services (the services to run on a
 // particular node are passed to the NewNode function in the NodeConfig)
@@ -81,14 +84,24 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 		}
 	}
 
+	p2pConfig := p2p.Config{
+		PrivateKey:      config.PrivateKey,
+		MaxPeers:        math.MaxInt32,
+		NoDiscovery:     true,
+		Dialer:          s,
+		EnableMsgEvents: config.EnableMsgEvents,
+	}
+	if config.Discovery {
+		// The discovery runs over localhost UDP, the connections are still
+		// simulated by the adapter. The peers are bounded as the dialer sizes
+		// its buffers after them.
+		p2pConfig.MaxPeers = simDiscoveryMaxPeers
+		p2pConfig.DiscoveryV5 = true
+		p2pConfig.ListenAddr = "127.0.0.1:0"
+		p2pConfig.BootstrapNodesV5 = config.BootnodesV5
+	}
 	n, err := node.New(&node.Config{
-		P2P: p2p.Config{
-			PrivateKey:      config.PrivateKey,
-			MaxPeers:        math.MaxInt32,
-			NoDiscovery:     true,
-			Dialer:          s,
-			EnableMsgEvents: config.EnableMsgEvents,
-		},
+		P2P:            p2pConfig,
 		ExternalSigner: config.ExternalSigner,
 		NoUSB:          true,
 		Logger:         log.New("node.id", id.String()),
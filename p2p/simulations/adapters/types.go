@@ -30,6 +30,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/node"
 	"github.com/PlatONnetwork/PlatON-Go/p2p"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discv5"
 	"github.com/PlatONnetwork/PlatON-Go/rpc"
 	"github.com/docker/docker/pkg/reexec"
 
@@ -117,6 +118,13 @@ type NodeConfig struct {
 	//
 	// The default verbosity is INFO.
 	LogVerbosity log.Lvl
+
+	// Discovery enables the topic discovery of the node, listening on a
+	// localhost UDP port, instead of only connecting the nodes explicitly.
+	Discovery bool
+
+	// BootnodesV5 are the nodes the discovery is bootstrapped from.
+	BootnodesV5 []*discv5.Node
 }
 
 // nodeConfigJSON is used to encode and decode NodeConfig as JSON by encoding
@@ -130,6 +138,8 @@ type nodeConfigJSON struct {
 	Port            uint16   `json:"port"`
 	LogFile         string   `json:"logfile"`
 	LogVerbosity    int      `json:"log_verbosity"`
+	Discovery       bool     `json:"discovery"`
+	BootnodesV5     []string `json:"bootnodes_v5"`
 }
 
 // MarshalJSON implements the json.Marshaler interface by encoding the config
@@ -143,6 +153,10 @@ func (n *NodeConfig) MarshalJSON() ([]byte, error) {
 		EnableMsgEvents: n.EnableMsgEvents,
 		LogFile:         n.LogFile,
 		LogVerbosity:    int(n.LogVerbosity),
+		Discovery:       n.Discovery,
+	}
+	for _, bootnode := range n.BootnodesV5 {
+		confJSON.BootnodesV5 = append(confJSON.BootnodesV5, bootnode.String())
 	}
 	if n.PrivateKey != nil {
 		confJSON.PrivateKey = hex.EncodeToString(crypto.FromECDSA(n.PrivateKey))
@@ -184,6 +198,14 @@ func (n *NodeConfig) UnmarshalJSON(data []byte) error {
 	n.EnableMsgEvents = confJSON.EnableMsgEvents
 	n.LogFile = confJSON.LogFile
 	n.LogVerbosity = log.Lvl(confJSON.LogVerbosity)
+	n.Discovery = confJSON.Discovery
+	for _, url := range confJSON.BootnodesV5 {
+		bootnode, err := discv5.ParseNode(url)
+		if err != nil {
+			return err
+		}
+		n.BootnodesV5 = append(n.BootnodesV5, bootnode)
+	}
 
 	return nil
 }
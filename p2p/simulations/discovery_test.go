@@ -0,0 +1,121 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlatONnetwork/PlatON-Go/node"
+	"github.com/PlatONnetwork/PlatON-Go/p2p"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discv5"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/simulations/adapters"
+)
+
+// discoveryService runs a protocol idling until the peer is dropped. Unlike the
+// test service, it copes with the connections dropped before their handshake.
+type discoveryService struct{}
+
+func newDiscoveryService(ctx *adapters.ServiceContext, stack *node.Node) (node.Lifecycle, error) {
+	svc := new(discoveryService)
+	stack.RegisterProtocols([]p2p.Protocol{{
+		Name:    "idle",
+		Version: 1,
+		Length:  1,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			for {
+				if _, err := rw.ReadMsg(); err != nil {
+					return err
+				}
+			}
+		},
+	}})
+	return svc, nil
+}
+
+func (s *discoveryService) Start() error { return nil }
+func (s *discoveryService) Stop() error  { return nil }
+
+// TestDiscoverySimulation starts nodes knowing only a bootnode and checks that
+// they exchange their records and connect through the topic discovery.
+func TestDiscoverySimulation(t *testing.T) {
+	adapter := adapters.NewSimAdapter(adapters.LifecycleConstructors{
+		"discovery": newDiscoveryService,
+	})
+	network := NewNetwork(adapter, &NetworkConfig{
+		DefaultService: "discovery",
+	})
+	defer network.Shutdown()
+
+	var (
+		nodeCount = 5
+		ids       = make([]discover.NodeID, nodeCount)
+		servers   = make([]*p2p.Server, nodeCount)
+		bootnode  *discv5.Node
+	)
+	for i := 0; i < nodeCount; i++ {
+		conf := adapters.RandomNodeConfig()
+		conf.Discovery = true
+		if bootnode != nil {
+			conf.BootnodesV5 = []*discv5.Node{bootnode}
+		}
+		node, err := network.NewNodeWithConfig(conf)
+		if err != nil {
+			t.Fatalf("error creating node: %s", err)
+		}
+		if err := network.Start(node.ID()); err != nil {
+			t.Fatalf("error starting node: %s", err)
+		}
+		ids[i] = node.ID()
+		servers[i] = node.Node.(*adapters.SimNode).Server()
+		if servers[i].DiscV5 == nil {
+			t.Fatalf("node %d: discovery not running", i)
+		}
+		if bootnode == nil {
+			bootnode = servers[i].DiscV5.Self()
+		}
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for i, srv := range servers {
+		for {
+			if srv.PeerCount() > 0 && hasRecords(srv, ids, i) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("node %d: peers %d, records of the other nodes not all retrieved", i, srv.PeerCount())
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// hasRecords reports whether the server retrieved the record of the bootnode,
+// or of all the other nodes if it is the bootnode.
+func hasRecords(srv *p2p.Server, ids []discover.NodeID, self int) bool {
+	for i, id := range ids {
+		if i == self || (self != 0 && i != 0) {
+			continue
+		}
+		entry := p2p.LoadPlatONEntry(srv.DiscV5.NodeRecord(discv5.NodeID(id)))
+		if entry == nil || entry.Role != p2p.RoleSync {
+			return false
+		}
+	}
+	return true
+}
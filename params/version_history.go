@@ -6,4 +6,5 @@ const (
 	FORKVERSION_1_2_0  = uint32(1<<16 | 2<<8 | 0)
 	FORKVERSION_1_3_0  = uint32(1<<16 | 3<<8 | 0)
 	FORKVERSION_1_4_0  = uint32(1<<16 | 4<<8 | 0)
+	FORKVERSION_1_5_0  = uint32(1<<16 | 5<<8 | 0)
 )
@@ -0,0 +1,49 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"strings"
+)
+
+// CallFilter is consulted before serving each call of a connection. A non-nil
+// error rejects the call and is sent back to the caller, implement Error to
+// control the error code.
+type CallFilter func(method string) error
+
+type callFilterKey struct{}
+
+// WithCallFilter returns a copy of ctx checking the calls of the connections
+// served with it, such as the HTTP and WebSocket requests.
+func WithCallFilter(ctx context.Context, filter CallFilter) context.Context {
+	return context.WithValue(ctx, callFilterKey{}, filter)
+}
+
+// callFilterFromContext returns the call filter of the context, nil if none.
+func callFilterFromContext(ctx context.Context) CallFilter {
+	filter, _ := ctx.Value(callFilterKey{}).(CallFilter)
+	return filter
+}
+
+// MethodModule returns the module, or namespace, of the method name.
+func MethodModule(method string) string {
+	if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+		return method[:i]
+	}
+	return method
+}
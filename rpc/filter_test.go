@@ -0,0 +1,94 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type rejectedError struct{ method string }
+
+func (e *rejectedError) ErrorCode() int { return -32001 }
+
+func (e *rejectedError) Error() string { return "rejected " + e.method }
+
+// filterHandler installs a call filter rejecting the calls of the given module.
+func filterHandler(next http.Handler, module string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithCallFilter(r.Context(), func(method string) error {
+			if MethodModule(method) == module {
+				return &rejectedError{method}
+			}
+			return nil
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Tests that the call filters of the HTTP and WebSocket requests are applied to
+// each of their calls.
+func TestCallFilter(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	defer srv.Stop()
+
+	httpsrv := httptest.NewServer(filterHandler(srv, "nftest"))
+	defer httpsrv.Close()
+	wssrv := httptest.NewServer(filterHandler(srv.WebsocketHandler([]string{"*"}), "nftest"))
+	defer wssrv.Close()
+
+	httpClient, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer httpClient.Close()
+	wsClient, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(wssrv.URL, "http:"), "")
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer wsClient.Close()
+
+	for name, client := range map[string]*Client{"http": httpClient, "ws": wsClient} {
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+			t.Errorf("%s: allowed call failed: %v", name, err)
+		}
+		err := client.Call(&result, "nftest_echo", "x", 1)
+		if rerr, ok := err.(Error); !ok || rerr.ErrorCode() != -32001 || rerr.Error() != "rejected nftest_echo" {
+			t.Errorf("%s: filtered call not rejected: %v", name, err)
+		}
+	}
+}
+
+func TestMethodModule(t *testing.T) {
+	tests := map[string]string{
+		"platon_getBalance": "platon",
+		"debug_traceBlock":  "debug",
+		"rpc_modules":       "rpc",
+		"nomodule":          "nomodule",
+	}
+	for method, want := range tests {
+		if have := MethodModule(method); have != want {
+			t.Errorf("%s: module mismatch: have %q, want %q", method, have, want)
+		}
+	}
+}
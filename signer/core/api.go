@@ -222,6 +222,7 @@ type (
 	SignTxRequest struct {
 		Transaction SendTxArgs       `json:"transaction"`
 		Callinfo    []ValidationInfo `json:"call_info"`
+		Ppos        *PposCall        `json:"ppos_call,omitempty"`
 		Meta        Metadata         `json:"meta"`
 	}
 	// SignTxResponse result from SignTxRequest
@@ -536,6 +537,10 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 		Meta:        MetadataFromContext(ctx),
 		Callinfo:    msgs.Messages,
 	}
+	// Decode calls to the PPOS contracts, the validator warns about invalid ones
+	if args.To != nil && args.Data != nil {
+		req.Ppos, _ = DecodePposCall(args.To.Address(), *args.Data)
+	}
 	// Process approval
 	result, err = api.UI.ApproveTx(&req)
 	if err != nil {
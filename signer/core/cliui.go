@@ -111,10 +111,13 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 		if !to.ValidChecksum() {
 			fmt.Printf("\nWARNING: Invalid checksum on to-address!\n\n")
 		}
+		if to.Original() != to.Address().Bech32() {
+			fmt.Printf("       %v\n", to.Address().Bech32())
+		}
 	} else {
 		fmt.Printf("to:    <contact creation>\n")
 	}
-	fmt.Printf("from:     %v\n", request.Transaction.From.String())
+	fmt.Printf("from:     %v\n", request.Transaction.From.Address().Bech32())
 	fmt.Printf("value:    %v wei\n", weival)
 	fmt.Printf("gas:      %v (%v)\n", request.Transaction.Gas, uint64(request.Transaction.Gas))
 	fmt.Printf("gasprice: %v wei\n", request.Transaction.GasPrice.ToInt())
@@ -125,6 +128,13 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 			fmt.Printf("data:     %v\n", hexutil.Encode(d))
 		}
 	}
+	if call := request.Ppos; call != nil {
+		fmt.Printf("\nPPOS %s call %s (%d):\n", call.Contract, call.Function, call.FuncType)
+		for _, p := range call.Params {
+			fmt.Printf("  %s [%s]: %v\n", p.Name, p.Typ, p.Value)
+		}
+		fmt.Printf("\nIntent: %s\n", call.Intent)
+	}
 	if request.Callinfo != nil {
 		fmt.Printf("\nTransaction validation:\n")
 		for _, m := range request.Callinfo {
@@ -0,0 +1,276 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/byteutil"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/params"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/restricting"
+)
+
+// PposCall is a call to one of the PPOS system contracts, decoded for the
+// user and for the rules.
+type PposCall struct {
+	Contract string           `json:"contract"`
+	FuncType uint16           `json:"func_type"`
+	Function string           `json:"function"`
+	Params   []*NameValueType `json:"params"`
+	Amount   *hexutil.Big     `json:"amount,omitempty"` // the von staked, delegated, withdrawn or restricted
+	NodeID   *discover.NodeID `json:"node_id,omitempty"`
+	Intent   string           `json:"intent"`
+}
+
+type pposParam struct {
+	name string
+	typ  string // the type name used by byteutil.Bytes2X_CMD
+}
+
+type pposFunc struct {
+	name   string
+	params []pposParam
+}
+
+type pposContract struct {
+	name  string
+	funcs map[uint16]pposFunc
+}
+
+var pposContracts = map[common.Address]pposContract{
+	vm.StakingContractAddr: {"staking", map[uint16]pposFunc{
+		1000: {"createStaking", []pposParam{{"typ", "uint16"}, {"benefitAddress", "common.Address"}, {"nodeId", "discover.NodeID"},
+			{"externalId", "string"}, {"nodeName", "string"}, {"website", "string"}, {"details", "string"}, {"amount", "*big.Int"},
+			{"rewardPer", "uint16"}, {"programVersion", "uint32"}, {"programVersionSign", "common.VersionSign"},
+			{"blsPubKey", "bls.PublicKeyHex"}, {"blsProof", "bls.SchnorrProofHex"}}},
+		1001: {"editCandidate", []pposParam{{"benefitAddress", "*common.Address"}, {"nodeId", "discover.NodeID"}, {"rewardPer", "*uint16"},
+			{"externalId", "*string"}, {"nodeName", "*string"}, {"website", "*string"}, {"details", "*string"}}},
+		1002: {"increaseStaking", []pposParam{{"nodeId", "discover.NodeID"}, {"typ", "uint16"}, {"amount", "*big.Int"}}},
+		1003: {"withdrewStaking", []pposParam{{"nodeId", "discover.NodeID"}}},
+		1004: {"delegate", []pposParam{{"typ", "uint16"}, {"nodeId", "discover.NodeID"}, {"amount", "*big.Int"}}},
+		1005: {"withdrewDelegation", []pposParam{{"stakingBlockNum", "uint64"}, {"nodeId", "discover.NodeID"}, {"amount", "*big.Int"}}},
+		1006: {"redeemDelegation", nil},
+	}},
+	vm.GovContractAddr: {"gov", map[uint16]pposFunc{
+		2000: {"submitText", []pposParam{{"verifier", "discover.NodeID"}, {"pipID", "string"}}},
+		2001: {"submitVersion", []pposParam{{"verifier", "discover.NodeID"}, {"pipID", "string"}, {"newVersion", "uint32"}, {"endVotingRounds", "uint64"}}},
+		2002: {"submitParam", []pposParam{{"verifier", "discover.NodeID"}, {"pipID", "string"}, {"module", "string"}, {"name", "string"}, {"newValue", "string"}}},
+		2003: {"vote", []pposParam{{"verifier", "discover.NodeID"}, {"proposalID", "common.Hash"}, {"option", "uint8"},
+			{"programVersion", "uint32"}, {"versionSign", "common.VersionSign"}}},
+		2004: {"declareVersion", []pposParam{{"activeNode", "discover.NodeID"}, {"programVersion", "uint32"}, {"versionSign", "common.VersionSign"}}},
+		2005: {"submitCancel", []pposParam{{"verifier", "discover.NodeID"}, {"pipID", "string"}, {"endVotingRounds", "uint64"}, {"tobeCanceled", "common.Hash"}}},
+	}},
+	vm.SlashingContractAddr: {"slashing", map[uint16]pposFunc{
+		3000: {"reportDuplicateSign", []pposParam{{"duplicateSignType", "uint8"}, {"data", "string"}}},
+	}},
+	vm.RestrictingContractAddr: {"restricting", map[uint16]pposFunc{
+		4000: {"createRestrictingPlan", []pposParam{{"account", "common.Address"}, {"plans", "[]restricting.RestrictingPlan"}}},
+	}},
+	vm.DelegateRewardPoolAddr: {"reward", map[uint16]pposFunc{
+		5000: {"withdrawDelegateReward", nil},
+	}},
+}
+
+// IsPposContract returns whether the address is one of the PPOS system contracts.
+func IsPposContract(addr common.Address) bool {
+	_, ok := pposContracts[addr]
+	return ok
+}
+
+// DecodePposCall decodes the input of a call to a PPOS system contract, it
+// returns nil if the address is not one of them.
+func DecodePposCall(to common.Address, data []byte) (*PposCall, error) {
+	contract, ok := pposContracts[to]
+	if !ok {
+		return nil, nil
+	}
+	var args [][]byte
+	if err := rlp.DecodeBytes(data, &args); err != nil {
+		return nil, fmt.Errorf("invalid %s call: %v", contract.name, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("invalid %s call: missing function type", contract.name)
+	}
+	values, err := decodePposArgs(args[:1], []pposParam{{"funcType", "uint16"}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s call: %v", contract.name, err)
+	}
+	funcType := values[0].(uint16)
+	fn, ok := contract.funcs[funcType]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s function type %d", contract.name, funcType)
+	}
+	if len(args)-1 != len(fn.params) {
+		return nil, fmt.Errorf("invalid %s call: %d params, want %d", fn.name, len(args)-1, len(fn.params))
+	}
+	if values, err = decodePposArgs(args[1:], fn.params); err != nil {
+		return nil, fmt.Errorf("invalid %s call: %v", fn.name, err)
+	}
+	call := &PposCall{
+		Contract: contract.name,
+		FuncType: funcType,
+		Function: fn.name,
+		Params:   make([]*NameValueType, len(fn.params)),
+	}
+	named := make(map[string]interface{}, len(fn.params))
+	for i, p := range fn.params {
+		named[p.name] = values[i]
+		call.Params[i] = &NameValueType{Name: p.name, Typ: p.typ, Value: formatPposValue(values[i])}
+	}
+	if amount := pposAmount(named); amount != nil {
+		call.Amount = (*hexutil.Big)(amount)
+	}
+	for _, name := range []string{"nodeId", "verifier", "activeNode"} {
+		if id, ok := named[name].(discover.NodeID); ok {
+			call.NodeID = &id
+			break
+		}
+	}
+	call.Intent = pposIntent(call, named)
+	return call, nil
+}
+
+// decodePposArgs decodes the args like the PPOS contracts do, the decoders
+// panic on invalid input.
+func decodePposArgs(args [][]byte, params []pposParam) (values []interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			values, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+	for i, p := range params {
+		out := reflect.ValueOf(byteutil.Bytes2X_CMD[p.typ]).Call([]reflect.Value{reflect.ValueOf(args[i])})
+		values = append(values, out[0].Interface())
+	}
+	return values, nil
+}
+
+func pposAmount(named map[string]interface{}) *big.Int {
+	if amount, ok := named["amount"].(*big.Int); ok {
+		return amount
+	}
+	if plans, ok := named["plans"].([]restricting.RestrictingPlan); ok {
+		total := new(big.Int)
+		for _, plan := range plans {
+			if plan.Amount != nil {
+				total.Add(total, plan.Amount)
+			}
+		}
+		return total
+	}
+	return nil
+}
+
+func formatPposValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case common.Address:
+		return v.Bech32()
+	case *common.Address:
+		if v == nil {
+			return nil
+		}
+		return v.Bech32()
+	case *big.Int:
+		return v.String()
+	case discover.NodeID:
+		return v.String()
+	case common.Hash:
+		return v.Hex()
+	case *uint16:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case []restricting.RestrictingPlan:
+		plans := make([]string, len(v))
+		for i, plan := range v {
+			plans[i] = fmt.Sprintf("epoch %d: %s LAT", plan.Epoch, FormatLAT(plan.Amount))
+		}
+		return strings.Join(plans, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// pposIntent describes the call in a sentence.
+func pposIntent(call *PposCall, named map[string]interface{}) string {
+	var node string
+	if call.NodeID != nil {
+		node = call.NodeID.TerminalString()
+	}
+	var amount string
+	if call.Amount != nil {
+		amount = FormatLAT(call.Amount.ToInt()) + " LAT"
+	}
+	from := "free balance"
+	if typ, ok := named["typ"].(uint16); ok && typ != 0 {
+		from = "restricted balance"
+	}
+	switch call.Function {
+	case "createStaking":
+		return fmt.Sprintf("stake %s from %s for new node %s (%q), rewards to %s", amount, from, node, named["nodeName"], named["benefitAddress"].(common.Address).Bech32())
+	case "editCandidate":
+		return fmt.Sprintf("edit the candidate information of node %s", node)
+	case "increaseStaking":
+		return fmt.Sprintf("add %s from %s to the stake of node %s", amount, from, node)
+	case "withdrewStaking":
+		return fmt.Sprintf("withdraw the stake of node %s", node)
+	case "delegate":
+		return fmt.Sprintf("delegate %s from %s to node %s", amount, from, node)
+	case "withdrewDelegation":
+		return fmt.Sprintf("withdraw %s delegated to node %s at block %d", amount, node, named["stakingBlockNum"])
+	case "redeemDelegation":
+		return "redeem the unlocked delegations"
+	case "submitText", "submitVersion", "submitParam", "submitCancel":
+		return fmt.Sprintf("%s proposal %q as node %s", call.Function, named["pipID"], node)
+	case "vote":
+		return fmt.Sprintf("vote option %d on proposal %s as node %s", named["option"], named["proposalID"].(common.Hash).TerminalString(), node)
+	case "declareVersion":
+		return fmt.Sprintf("declare version %s for node %s", params.FormatVersion(named["programVersion"].(uint32)), node)
+	case "reportDuplicateSign":
+		return fmt.Sprintf("report a duplicate signature of type %d", named["duplicateSignType"])
+	case "createRestrictingPlan":
+		return fmt.Sprintf("lock %s for %s in %d restricting plans", amount, named["account"].(common.Address).Bech32(), len(named["plans"].([]restricting.RestrictingPlan)))
+	case "withdrawDelegateReward":
+		return "withdraw all delegation rewards"
+	}
+	return call.Function
+}
+
+// FormatLAT formats an amount in von as LAT.
+func FormatLAT(von *big.Int) string {
+	if von == nil {
+		return "0"
+	}
+	lat := new(big.Rat).SetFrac(von, big.NewInt(params.LAT))
+	s := lat.FloatString(18)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -73,11 +73,31 @@ func (db *Database) ValidateTransaction(selector *string, tx *core.SendTxArgs) (
 	if bytes.Equal(tx.To.Address().Bytes(), common.Address{}.Bytes()) {
 		messages.Crit("Transaction recipient is the zero address")
 	}
+	// The PPOS contracts don't take ABI call data, decode them natively
+	if core.IsPposContract(tx.To.Address()) {
+		validatePposCallData(tx.To.Address(), data, messages)
+		return messages, nil
+	}
 	// Semantic fields validated, try to make heads or tails of the call data
 	db.ValidateCallData(selector, data, messages)
 	return messages, nil
 }
 
+// validatePposCallData checks that the call data of a PPOS contract call
+// can be decoded, and shows what the call does.
+func validatePposCallData(to common.Address, data []byte, messages *core.ValidationMessages) {
+	if len(data) == 0 {
+		messages.Warn("Transaction sends value to a PPOS contract without calling it")
+		return
+	}
+	call, err := core.DecodePposCall(to, data)
+	if err != nil {
+		messages.Crit(fmt.Sprintf("Transaction data is not a valid PPOS call: %v", err))
+		return
+	}
+	messages.Info(fmt.Sprintf("Transaction invokes the PPOS %s contract: %s", call.Contract, call.Intent))
+}
+
 // ValidateCallData checks if the ABI call-data + method selector (if given) can
 // be parsed and seems to match.
 func (db *Database) ValidateCallData(selector *string, data []byte, messages *core.ValidationMessages) {
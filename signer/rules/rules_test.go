@@ -24,10 +24,14 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/accounts"
 	"github.com/PlatONnetwork/PlatON-Go/common"
 	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/common/vm"
 	"github.com/PlatONnetwork/PlatON-Go/core/types"
 	"github.com/PlatONnetwork/PlatON-Go/internal/ethapi"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
 	"github.com/PlatONnetwork/PlatON-Go/signer/core"
 	"github.com/PlatONnetwork/PlatON-Go/signer/storage"
+	"github.com/PlatONnetwork/PlatON-Go/x/restricting"
 )
 
 const JS = `
@@ -193,6 +197,115 @@ func TestSignTxRequest(t *testing.T) {
 	}
 }
 
+func pposData(t *testing.T, fn uint16, params ...interface{}) *hexutil.Bytes {
+	args := make([][]byte, 0, len(params)+1)
+	for _, param := range append([]interface{}{fn}, params...) {
+		arg, err := rlp.EncodeToBytes(param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, arg)
+	}
+	data, err := rlp.EncodeToBytes(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (*hexutil.Bytes)(&data)
+}
+
+func TestPposTxRequest(t *testing.T) {
+	js := `
+	function big(str){
+		if(str.slice(0,2) == "0x"){ return new BigNumber(str.slice(2),16)}
+		return new BigNumber(str)
+	}
+	function ApproveTx(r){
+		var call = r.ppos_call;
+		if(!call){ return "Reject" }
+		console.log("ppos", call.intent);
+		if(call.function == "delegate" && big(call.amount).gt(big("1000000000000000000000"))){ return "Reject" }
+		if(call.contract == "staking" || call.func_type == 4000){ return "Approve" }
+	}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	from, err := mixAddr("0000000000000000000000000000000000001337")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lat := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	nodeID := discover.MustHexID("0x4fcc251cf6bf3ea53a748971a223f5676225ee4380b65c7889a2b491e1551d45fe9fcc19c6af54dcf0d5323b5aa8ee1d919791695082bae1f86dd282dba4150f")
+	account := common.HexToAddress("0x000000000000000000000000000000000000dead")
+
+	tests := []struct {
+		to       common.Address
+		data     *hexutil.Bytes
+		intent   string
+		approved bool
+	}{
+		{
+			to:       vm.StakingContractAddr,
+			data:     pposData(t, 1004, uint16(0), nodeID, new(big.Int).Mul(big.NewInt(1000), lat)),
+			intent:   "delegate 1000 LAT from free balance to node " + nodeID.TerminalString(),
+			approved: true,
+		},
+		{
+			to:       vm.StakingContractAddr,
+			data:     pposData(t, 1004, uint16(1), nodeID, new(big.Int).Mul(big.NewInt(1001), lat)),
+			intent:   "delegate 1001 LAT from restricted balance to node " + nodeID.TerminalString(),
+			approved: false,
+		},
+		{
+			to: vm.RestrictingContractAddr,
+			data: pposData(t, 4000, account, []restricting.RestrictingPlan{
+				{Epoch: 1, Amount: new(big.Int).Div(lat, big.NewInt(2))},
+				{Epoch: 2, Amount: lat},
+			}),
+			intent:   "lock 1.5 LAT for " + account.Bech32() + " in 2 restricting plans",
+			approved: true,
+		},
+		{
+			to:       vm.GovContractAddr,
+			data:     pposData(t, 2000, nodeID, "pip-1"),
+			intent:   `submitText proposal "pip-1" as node ` + nodeID.TerminalString(),
+			approved: false,
+		},
+	}
+	for i, test := range tests {
+		call, err := core.DecodePposCall(test.to, *test.data)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if call.Intent != test.intent {
+			t.Errorf("test %d: intent mismatch, have %q, want %q", i, call.Intent, test.intent)
+		}
+		to := common.NewMixedcaseAddress(test.to)
+		resp, err := r.ApproveTx(&core.SignTxRequest{
+			Transaction: core.SendTxArgs{From: *from, To: &to, Data: test.data},
+			Ppos:        call,
+			Meta:        core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+		})
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if resp.Approved != test.approved {
+			t.Errorf("test %d: approved mismatch, have %v, want %v", i, resp.Approved, test.approved)
+		}
+	}
+
+	// Invalid call data is reported
+	if _, err := core.DecodePposCall(vm.StakingContractAddr, []byte{0x01, 0x02}); err == nil {
+		t.Errorf("expected error for invalid call data")
+	}
+	if _, err := core.DecodePposCall(vm.StakingContractAddr, *pposData(t, 1004, uint16(0))); err == nil {
+		t.Errorf("expected error for missing params")
+	}
+	if call, err := core.DecodePposCall(account, *pposData(t, 1004)); call != nil || err != nil {
+		t.Errorf("expected no call for other contracts, have %v, %v", call, err)
+	}
+}
+
 type dummyUI struct {
 	calls []string
 }
@@ -28,7 +28,12 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/log"
 )
 
-var True = true
+// dirtyFlag returns the cache flag of a node modified during the range proof
+// verification, the hash must be allocated so that the node can be hashed.
+func dirtyFlag() nodeFlag {
+	dirty := true
+	return nodeFlag{hash: &hashNode{}, dirty: &dirty}
+}
 
 // Prove constructs a merkle proof for key. The result contains all encoded nodes
 // on the path to the value at key. The value itself is also included in the last
@@ -239,7 +244,7 @@ findFork:
 	for {
 		switch rn := (n).(type) {
 		case *shortNode:
-			rn.flags = nodeFlag{dirty: &True}
+			rn.flags = dirtyFlag()
 
 			// If either the key of left proof or right proof doesn't match with
 			// shortnode, stop here and the forkpoint is the shortnode.
@@ -259,7 +264,7 @@ findFork:
 			parent = n
 			n, pos = rn.Val, pos+len(rn.Key)
 		case *fullNode:
-			rn.flags = nodeFlag{dirty: &True}
+			rn.flags = dirtyFlag()
 
 			// If either the node pointed by left proof or right proof is nil,
 			// stop here and the forkpoint is the fullnode.
@@ -345,12 +350,12 @@ func unset(parent node, child node, key []byte, pos int, removeLeft bool) error
 			for i := 0; i < int(key[pos]); i++ {
 				cld.Children[i] = nil
 			}
-			cld.flags = nodeFlag{dirty: &True}
+			cld.flags = dirtyFlag()
 		} else {
 			for i := key[pos] + 1; i < 16; i++ {
 				cld.Children[i] = nil
 			}
-			cld.flags = nodeFlag{dirty: &True}
+			cld.flags = dirtyFlag()
 		}
 		return unset(cld, cld.Children[key[pos]], key, pos+1, removeLeft)
 	case *shortNode:
@@ -388,7 +393,7 @@ func unset(parent node, child node, key []byte, pos int, removeLeft bool) error
 			fn.Children[key[pos-1]] = nil
 			return nil
 		}
-		cld.flags = nodeFlag{dirty: &True}
+		cld.flags = dirtyFlag()
 		return unset(cld, cld.Val, key, pos+len(cld.Key), removeLeft)
 	case nil:
 		// If the node is nil, then it's a child of the fork point
@@ -41,6 +41,16 @@ var (
 	keyGovernHASHKey           = []byte("GovernHASH")
 )
 
+// KeyPrefixes returns the prefixes of all the keys the governance keeps in the
+// snapshot db.
+func KeyPrefixes() [][]byte {
+	return [][]byte{
+		keyPrefixProposal, keyPrefixVote, keyPrefixTallyResult, keyPrefixVotingProposals, keyPrefixEndProposals,
+		keyPrefixPreActiveProposal, keyPrefixPreActiveVersion, keyPrefixActiveVersions, keyPrefixActiveNodes,
+		keyPrefixAccuVerifiers, keyPrefixPIPIDs, keyPrefixParamItems, keyPrefixParamValue, keyGovernHASHKey,
+	}
+}
+
 func KeyProposal(proposalID common.Hash) []byte {
 	return bytes.Join([][]byte{
 		keyPrefixProposal,
@@ -114,3 +124,29 @@ func KeyParamValue(module, name string) []byte {
 func KeyGovernHASHKey() []byte {
 	return keyGovernHASHKey
 }
+
+// DecodeKeyProposal returns the proposal ID of a proposal key, false if the key
+// isn't one.
+func DecodeKeyProposal(key []byte) (common.Hash, bool) {
+	return decodeProposalKey(keyPrefixProposal, key)
+}
+
+// DecodeKeyVote returns the proposal ID of a vote key, false if the key isn't
+// one.
+func DecodeKeyVote(key []byte) (common.Hash, bool) {
+	return decodeProposalKey(keyPrefixVote, key)
+}
+
+// DecodeKeyTallyResult returns the proposal ID of a tally result key, false if
+// the key isn't one.
+func DecodeKeyTallyResult(key []byte) (common.Hash, bool) {
+	return decodeProposalKey(keyPrefixTallyResult, key)
+}
+
+func decodeProposalKey(prefix, key []byte) (common.Hash, bool) {
+	prefix = append(append([]byte{}, prefix...), KeyDelimiter...)
+	if len(key) != len(prefix)+common.HashLength || !bytes.HasPrefix(key, prefix) {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(key[len(prefix):]), true
+}
@@ -134,7 +134,12 @@ func (govPlugin *GovPlugin) BeginBlock(blockHash common.Hash, header *types.Head
 				return err
 			}
 			if versionProposal.NewVersion == params.FORKVERSION_1_3_0 {
-				if err = gov.Set130Param(header.Number.Uint64(), blockHash, snapshotdb.Instance(), govPlugin.chainDB); err != nil {
+				// A replayed activation must leave the economic model and the chain db alone
+				chainDB := govPlugin.chainDB
+				if snapshotdb.Instance().IsReplayBlock(blockHash) {
+					chainDB = nil
+				}
+				if err = gov.Set130Param(header.Number.Uint64(), blockHash, snapshotdb.Instance(), chainDB); err != nil {
 					log.Error("save  version 130 Param failed.", "blockNumber", blockNumber, "blockHash", blockHash, "preActiveProposalID", preActiveVersionProposalID, "err", err)
 					return err
 				}
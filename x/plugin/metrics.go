@@ -0,0 +1,63 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
+)
+
+var (
+	// the balance of the reward pool and the rewards of the epoch, in von
+	rewardGauges = newEpochGauges()
+	// the stake and the size of the validators of the current round, the stake in von
+	stakeGauges = newEpochGauges()
+)
+
+// epochGauges are gauges labeled with the epoch. The series of an epoch are
+// dropped once a value of a later epoch is recorded.
+type epochGauges struct {
+	set   *metrics.LabeledSet
+	lock  sync.Mutex
+	epoch uint64
+}
+
+func newEpochGauges() *epochGauges {
+	return &epochGauges{set: metrics.NewLabeledSet(nil)}
+}
+
+// update records the value of the gauge of the name for the epoch, the
+// values of the earlier epochs are ignored.
+func (g *epochGauges) update(name string, epoch uint64, value *big.Int) {
+	if !metrics.Enabled || value == nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if epoch < g.epoch {
+		return
+	}
+	if epoch > g.epoch {
+		g.set.Reset()
+		g.epoch = epoch
+	}
+	f, _ := new(big.Float).SetInt(value).Float64()
+	g.set.GaugeFloat64(name, "epoch", strconv.FormatUint(epoch, 10)).Update(f)
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/common/vm"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/restricting"
+	"github.com/PlatONnetwork/PlatON-Go/x/reward"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+// PPOSDump is the decoded ppos data committed to the base of the snapshot db.
+// The proposals and the restricting plans are kept in the storage of the system
+// contracts, they're taken from the state of the base block: the proposals are
+// the ones listed by the governance in the snapshot db, the restricting accounts
+// are found through the preimages of the storage keys of the contract.
+type PPOSDump struct {
+	BaseNumber      uint64               `json:"baseNumber"`
+	Candidates      []*staking.Candidate `json:"candidates"`
+	Delegations     []*DumpDelegation    `json:"delegations"`
+	EpochValidators []*DumpValidators    `json:"epochValidators"`
+	RoundValidators []*DumpValidators    `json:"roundValidators"`
+	Proposals       []*DumpProposal      `json:"proposals"`
+	Votes           []*DumpVotes         `json:"votes"`
+	Restrictings    []*DumpRestricting   `json:"restrictings"`
+	RewardPers      []*DumpRewardPer     `json:"rewardPers"`
+	Others          []*DumpEntry         `json:"others"` // Entries of the other families, undecoded
+}
+
+// DumpDelegation is a delegation along with the fields of its key.
+type DumpDelegation struct {
+	Delegator       common.Address  `json:"delegator"`
+	NodeId          discover.NodeID `json:"nodeId"`
+	StakingBlockNum uint64          `json:"stakingBlockNum"`
+	*staking.Delegation
+}
+
+// DumpValidators is the validators of an epoch or a round.
+type DumpValidators struct {
+	Start      uint64                 `json:"start"`
+	End        uint64                 `json:"end"`
+	Validators staking.ValidatorQueue `json:"validators"`
+}
+
+// DumpProposal is a proposal along with its tally result, if tallied.
+type DumpProposal struct {
+	Proposal    gov.Proposal     `json:"proposal"`
+	TallyResult *gov.TallyResult `json:"tallyResult"`
+}
+
+// DumpVotes is the votes cast on a proposal.
+type DumpVotes struct {
+	ProposalID common.Hash     `json:"proposalID"`
+	Votes      []gov.VoteValue `json:"votes"`
+}
+
+// DumpRestricting is the restricting plans of an account.
+type DumpRestricting struct {
+	Account common.Address                `json:"account"`
+	Info    *restricting.RestrictingInfo  `json:"info"`
+	Plans   []restricting.RestrictingPlan `json:"plans"`
+}
+
+// DumpRewardPer is a chunk of the delegate reward per records of a node.
+type DumpRewardPer struct {
+	NodeAddress     common.NodeAddress          `json:"nodeAddress"`
+	StakingBlockNum uint64                      `json:"stakingBlockNum"`
+	Index           uint32                      `json:"index"`
+	Pers            []*reward.DelegateRewardPer `json:"pers"`
+}
+
+// DumpEntry is an undecoded entry.
+type DumpEntry struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
+
+// isSnapshotMetaKey returns whether the key is kept by the snapshot db for itself
+// rather than by the plugins.
+func isSnapshotMetaKey(key []byte) bool {
+	return bytes.Equal(key, []byte(snapshotdb.CurrentHighestBlock)) || bytes.Equal(key, []byte(snapshotdb.CurrentBaseNum)) ||
+		bytes.Equal(key, []byte(snapshotdb.CurrentSet)) || bytes.HasPrefix(key, []byte(snapshotdb.WalKeyPrefix))
+}
+
+// DumpPPOS decodes the ppos data committed to the base of the snapshot db.
+//
+// The state of the base block is looked up with stateAt to read the proposals
+// and the restricting plans, a nil state leaves them out.
+func DumpPPOS(db snapshotdb.DB, stateAt func(number uint64) (xcom.StateDB, error)) (*PPOSDump, error) {
+	var (
+		dump        = new(PPOSDump)
+		proposalIDs []common.Hash
+	)
+	err := db.WalkBaseDB(nil, func(num *big.Int, iter iterator.Iterator) (err error) {
+		dump.BaseNumber = num.Uint64()
+		proposalIDs, err = dumpBaseDB(dump, iter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := stateAt(dump.BaseNumber)
+	if err != nil {
+		return nil, err
+	}
+	if statedb != nil {
+		if dump.Proposals, err = dumpProposals(statedb, proposalIDs); err != nil {
+			return nil, err
+		}
+		if dump.Restrictings, err = dumpRestrictings(statedb); err != nil {
+			return nil, err
+		}
+	}
+	return dump, nil
+}
+
+// dumpBaseDB decodes the entries of the snapshot db base into the dump. It
+// returns the IDs of the proposals listed by the governance: the voting, the
+// pre-active and the ended ones.
+func dumpBaseDB(dump *PPOSDump, iter iterator.Iterator) ([]common.Hash, error) {
+	var (
+		bases       = make(map[string]*staking.CandidateBase)
+		mutables    = make(map[string]*staking.CandidateMutable)
+		suffixes    []string
+		proposalIDs []common.Hash
+	)
+	for iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		if isSnapshotMetaKey(key) {
+			continue
+		}
+		var err error
+		switch {
+		case bytes.HasPrefix(key, staking.CanBaseKeyPrefix):
+			base := new(staking.CandidateBase)
+			if err = rlp.DecodeBytes(value, base); err == nil {
+				suffix := string(key[len(staking.CanBaseKeyPrefix):])
+				bases[suffix] = base
+				suffixes = append(suffixes, suffix)
+			}
+
+		case bytes.HasPrefix(key, staking.CanMutableKeyPrefix):
+			mutable := new(staking.CandidateMutable)
+			if err = rlp.DecodeBytes(value, mutable); err == nil {
+				mutables[string(key[len(staking.CanMutableKeyPrefix):])] = mutable
+			}
+
+		case bytes.HasPrefix(key, staking.DelegateKeyPrefix) && len(key) == len(staking.DelegateKeyPrefix)+common.AddressLength+len(discover.NodeID{})+8:
+			del := new(staking.Delegation)
+			if err = rlp.DecodeBytes(value, del); err == nil {
+				delAddr, nodeId, stakingNum := staking.DecodeDelegateKey(key)
+				dump.Delegations = append(dump.Delegations, &DumpDelegation{Delegator: delAddr, NodeId: nodeId, StakingBlockNum: stakingNum, Delegation: del})
+			}
+
+		case bytes.HasPrefix(key, staking.EpochValArrPrefix) && len(key) == len(staking.EpochValArrPrefix)+16:
+			var queue staking.ValidatorQueue
+			if err = rlp.DecodeBytes(value, &queue); err == nil {
+				dump.EpochValidators = append(dump.EpochValidators, newDumpValidators(key[len(staking.EpochValArrPrefix):], queue))
+			}
+
+		case bytes.HasPrefix(key, staking.RoundValArrPrefix) && len(key) == len(staking.RoundValArrPrefix)+16:
+			var queue staking.ValidatorQueue
+			if err = rlp.DecodeBytes(value, &queue); err == nil {
+				dump.RoundValidators = append(dump.RoundValidators, newDumpValidators(key[len(staking.RoundValArrPrefix):], queue))
+			}
+
+		case bytes.Equal(key, gov.KeyVotingProposals()) || bytes.Equal(key, gov.KeyEndProposals()):
+			var ids []common.Hash
+			if err = rlp.DecodeBytes(value, &ids); err == nil {
+				proposalIDs = append(proposalIDs, ids...)
+			}
+
+		case bytes.Equal(key, gov.KeyPreActiveProposal()):
+			var id common.Hash
+			if err = rlp.DecodeBytes(value, &id); err == nil {
+				proposalIDs = append(proposalIDs, id)
+			}
+
+		default:
+			if id, ok := gov.DecodeKeyVote(key); ok {
+				var votes []gov.VoteValue
+				if err = rlp.DecodeBytes(value, &votes); err == nil {
+					dump.Votes = append(dump.Votes, &DumpVotes{ProposalID: id, Votes: votes})
+				}
+				break
+			}
+			if nodeAddr, stakingNum, index, ok := reward.DecodeDelegateRewardPerKey(key); ok {
+				list := reward.NewDelegateRewardPerList()
+				if err = rlp.DecodeBytes(value, list); err == nil {
+					dump.RewardPers = append(dump.RewardPers, &DumpRewardPer{NodeAddress: nodeAddr, StakingBlockNum: stakingNum, Index: index, Pers: list.Pers})
+				}
+				break
+			}
+			dump.Others = append(dump.Others, &DumpEntry{Key: common.CopyBytes(key), Value: common.CopyBytes(value)})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the value of key %x: %v", key, err)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	// Assemble the candidates, the base and the mutable parts are stored apart
+	for _, suffix := range suffixes {
+		dump.Candidates = append(dump.Candidates, &staking.Candidate{CandidateBase: bases[suffix], CandidateMutable: mutables[suffix]})
+	}
+	return proposalIDs, nil
+}
+
+// newDumpValidators returns the validators keyed by the start and end numbers.
+func newDumpValidators(startEnd []byte, queue staking.ValidatorQueue) *DumpValidators {
+	return &DumpValidators{
+		Start:      common.BytesToUint64(startEnd[:8]),
+		End:        common.BytesToUint64(startEnd[8:]),
+		Validators: queue,
+	}
+}
+
+// storageKeys returns the storage keys of the contract, sorted. The keys are
+// the preimages of the trie keys, a missing one fails the whole lookup rather
+// than leaving entries out.
+func storageKeys(statedb xcom.StateDB, addr common.Address) ([][]byte, error) {
+	var (
+		keys    [][]byte
+		missing int
+	)
+	statedb.ForEachStorage(addr, func(key, value []byte) bool {
+		if key == nil {
+			missing++
+			return true
+		}
+		keys = append(keys, common.CopyBytes(key))
+		return true
+	})
+	if missing > 0 {
+		return nil, fmt.Errorf("the preimages of %d storage keys of %x are missing", missing, addr)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys, nil
+}
+
+// dumpProposals returns the proposals of the given IDs, checking that every
+// passed proposal recorded by its PIP ID is among them.
+func dumpProposals(statedb xcom.StateDB, ids []common.Hash) ([]*DumpProposal, error) {
+	var (
+		proposals []*DumpProposal
+		seen      = make(map[common.Hash]bool)
+		pipIDs    = make(map[string]bool)
+	)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		proposal, err := gov.GetProposal(id, statedb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode proposal %x: %v", id, err)
+		}
+		if proposal == nil {
+			return nil, fmt.Errorf("proposal %x listed by the governance is missing from the state", id)
+		}
+		result, err := gov.GetTallyResult(id, statedb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the tally result of proposal %x: %v", id, err)
+		}
+		proposals = append(proposals, &DumpProposal{Proposal: proposal, TallyResult: result})
+		pipIDs[proposal.GetPIPID()] = true
+	}
+	passed, err := gov.ListPIPID(statedb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the PIP IDs: %v", err)
+	}
+	for _, pipID := range passed {
+		if !pipIDs[pipID] {
+			return nil, fmt.Errorf("proposal of PIP ID %s is missing from the governance lists", pipID)
+		}
+	}
+	return proposals, nil
+}
+
+// dumpRestrictings returns the restricting plans kept by the restricting contract.
+func dumpRestrictings(statedb xcom.StateDB) ([]*DumpRestricting, error) {
+	keys, err := storageKeys(statedb, vm.RestrictingContractAddr)
+	if err != nil {
+		return nil, err
+	}
+	var restrictings []*DumpRestricting
+	for _, key := range keys {
+		// The release amounts share the prefix, with the epoch before the account
+		if !bytes.HasPrefix(key, restricting.RestrictingKeyPrefix) || len(key) != len(restricting.RestrictingKeyPrefix)+common.AddressLength {
+			continue
+		}
+		account := common.BytesToAddress(key[len(restricting.RestrictingKeyPrefix):])
+		info := new(restricting.RestrictingInfo)
+		if err := rlp.DecodeBytes(statedb.GetState(vm.RestrictingContractAddr, key), info); err != nil {
+			return nil, fmt.Errorf("failed to decode the restricting info of %x: %v", account, err)
+		}
+		plans := make([]restricting.RestrictingPlan, 0, len(info.ReleaseList))
+		for _, epoch := range info.ReleaseList {
+			amount := statedb.GetState(vm.RestrictingContractAddr, restricting.GetReleaseAmountKey(epoch, account))
+			plans = append(plans, restricting.RestrictingPlan{Epoch: epoch, Amount: new(big.Int).SetBytes(amount)})
+		}
+		restrictings = append(restrictings, &DumpRestricting{Account: account, Info: info, Plans: plans})
+	}
+	return restrictings, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/reward"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
+)
+
+func mustEncode(t *testing.T, val interface{}) []byte {
+	enc, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatalf("failed to encode %T: %v", val, err)
+	}
+	return enc
+}
+
+func TestDumpPPOS(t *testing.T) {
+	sdb := snapshotdb.Instance()
+	defer sdb.Clear()
+
+	base := big.NewInt(5)
+	if err := sdb.SetCurrent(common.ZeroHash, *base, *base); err != nil {
+		t.Fatalf("failed to set the current block: %v", err)
+	}
+	nodeAddr, _ := xutil.NodeId2Addr(nodeIdArr[0])
+	var (
+		pid      = common.Hash{1}
+		canBase  = &staking.CandidateBase{NodeId: nodeIdArr[0], StakingAddress: addrArr[0], Description: staking.Description{NodeName: "node"}}
+		canMut   = &staking.CandidateMutable{Shares: big.NewInt(10), Released: big.NewInt(10), ReleasedHes: common.Big0, RestrictingPlan: common.Big0, RestrictingPlanHes: common.Big0}
+		del      = &staking.Delegation{DelegateEpoch: 2, Released: big.NewInt(3)}
+		queue    = staking.ValidatorQueue{{NodeId: nodeIdArr[0], NodeAddress: nodeAddr, Shares: big.NewInt(10)}}
+		votes    = []gov.VoteValue{{VoteNodeID: nodeIdArr[0], VoteOption: gov.Yes}}
+		pers     = reward.NewDelegateRewardPerList()
+		otherKey = []byte("Other")
+	)
+	pers.AppendDelegateRewardPer(&reward.DelegateRewardPer{Epoch: 1, Left: big.NewInt(1), Delegate: big.NewInt(2), Reward: big.NewInt(3)})
+	kvs := [][2][]byte{
+		{staking.CanBaseKeyByAddr(nodeAddr), mustEncode(t, canBase)},
+		{staking.CanMutableKeyByAddr(nodeAddr), mustEncode(t, canMut)},
+		{staking.GetDelegateKey(addrArr[1], nodeIdArr[0], 7), mustEncode(t, del)},
+		{staking.GetEpochValArrKey(1, 100), mustEncode(t, queue)},
+		{staking.GetRoundValArrKey(1, 10), mustEncode(t, queue)},
+		{gov.KeyVote(pid), mustEncode(t, votes)},
+		{gov.KeyVotingProposals(), mustEncode(t, []common.Hash{pid})},
+		{reward.DelegateRewardPerKey(nodeIdArr[0], 7, 1), mustEncode(t, pers)},
+		{otherKey, []byte{1}},
+	}
+	if err := sdb.WriteBaseDB(kvs); err != nil {
+		t.Fatalf("failed to write the base db: %v", err)
+	}
+
+	stateDB, _, _ := newChainState()
+	buildDbRestrictingPlan(addrArr[2], t, stateDB)
+	if err := gov.SetProposal(&gov.TextProposal{ProposalID: pid, ProposalType: gov.Text, PIPID: "pip", SubmitBlock: 1, Proposer: nodeIdArr[0]}, stateDB); err != nil {
+		t.Fatalf("failed to set the proposal: %v", err)
+	}
+	dump, err := DumpPPOS(sdb, func(number uint64) (xcom.StateDB, error) {
+		if number != base.Uint64() {
+			t.Errorf("state number mismatch: have %d, want %d", number, base)
+		}
+		return stateDB, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to dump: %v", err)
+	}
+	if dump.BaseNumber != base.Uint64() {
+		t.Errorf("base number mismatch: have %d, want %d", dump.BaseNumber, base)
+	}
+	if len(dump.Candidates) != 1 || dump.Candidates[0].NodeName != "node" || dump.Candidates[0].Shares.Cmp(canMut.Shares) != 0 {
+		t.Errorf("candidates mismatch: %v", dump.Candidates)
+	}
+	if len(dump.Delegations) != 1 || dump.Delegations[0].Delegator != addrArr[1] || dump.Delegations[0].StakingBlockNum != 7 || dump.Delegations[0].DelegateEpoch != 2 {
+		t.Errorf("delegations mismatch: %v", dump.Delegations)
+	}
+	if len(dump.EpochValidators) != 1 || dump.EpochValidators[0].End != 100 || len(dump.EpochValidators[0].Validators) != 1 {
+		t.Errorf("epoch validators mismatch: %v", dump.EpochValidators)
+	}
+	if len(dump.RoundValidators) != 1 || dump.RoundValidators[0].End != 10 {
+		t.Errorf("round validators mismatch: %v", dump.RoundValidators)
+	}
+	if len(dump.Votes) != 1 || dump.Votes[0].ProposalID != pid || len(dump.Votes[0].Votes) != 1 {
+		t.Errorf("votes mismatch: %v", dump.Votes)
+	}
+	if len(dump.RewardPers) != 1 || dump.RewardPers[0].NodeAddress != nodeAddr || dump.RewardPers[0].StakingBlockNum != 7 || len(dump.RewardPers[0].Pers) != 1 {
+		t.Errorf("reward pers mismatch: %v", dump.RewardPers)
+	}
+	if len(dump.Others) != 1 || string(dump.Others[0].Key) != string(otherKey) {
+		t.Errorf("other entries mismatch: %v", dump.Others)
+	}
+	if len(dump.Proposals) != 1 || dump.Proposals[0].Proposal.GetProposalID() != pid {
+		t.Errorf("proposals mismatch: %v", dump.Proposals)
+	}
+	if len(dump.Restrictings) != 1 || dump.Restrictings[0].Account != addrArr[2] || len(dump.Restrictings[0].Plans) != 5 {
+		t.Errorf("restrictings mismatch: %v", dump.Restrictings)
+	}
+
+	// The proposals must match the governance lists
+	if _, err := dumpProposals(stateDB, []common.Hash{pid, {2}}); err == nil {
+		t.Errorf("a listed proposal missing from the state must fail the dump")
+	}
+	if err := gov.AddPIPID("passed", stateDB); err != nil {
+		t.Fatalf("failed to add the PIP ID: %v", err)
+	}
+	if _, err := dumpProposals(stateDB, []common.Hash{pid}); err == nil {
+		t.Errorf("a passed proposal missing from the lists must fail the dump")
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/common/hexutil"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/crypto"
+	"github.com/PlatONnetwork/PlatON-Go/rlp"
+	"github.com/PlatONnetwork/PlatON-Go/x/gov"
+	"github.com/PlatONnetwork/PlatON-Go/x/handler"
+	"github.com/PlatONnetwork/PlatON-Go/x/reward"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+// The domain of the keys matching none of the known prefixes.
+const pposDomainOther = "other"
+
+// pposDomains are the key prefixes of the plugins keeping data in the snapshot
+// db. A key belongs to the domain of the longest prefix it matches, since some
+// prefixes are prefixes of others, "Del" of "DelegateRewardPerKey" for one.
+var pposDomains = map[string][][]byte{
+	"staking": {
+		staking.CanBaseKeyPrefix, staking.CanMutableKeyPrefix, staking.CanPowerKeyPrefix, staking.UnStakeCountKey,
+		staking.UnStakeItemKey, staking.DelegateKeyPrefix, staking.DelegationLockKeyPrefix, staking.EpochIndexKey,
+		staking.EpochValArrPrefix, staking.RoundIndexKey, staking.RoundValArrPrefix, staking.AccountStakeRcPrefix,
+		staking.PPOSHASHKey, staking.RoundValAddrArrPrefix, staking.RoundAddrBoundaryPrefix,
+	},
+	"gov":      gov.KeyPrefixes(),
+	"reward":   reward.KeyPrefixes(),
+	"slashing": {packAmountPrefix, waitSlashingNodeListKey},
+	"common":   {xcom.AvgPackTimeKey, xcom.IncIssuanceNumberKey, xcom.IncIssuanceTimeKey, handler.NonceStorageKey},
+}
+
+// PPOSDomain returns the name of the plugin domain the snapshot db key belongs
+// to.
+func PPOSDomain(key []byte) string {
+	domain, longest := pposDomainOther, 0
+	for name, prefixes := range pposDomains {
+		for _, prefix := range prefixes {
+			if len(prefix) > longest && bytes.HasPrefix(key, prefix) {
+				domain, longest = name, len(prefix)
+			}
+		}
+	}
+	return domain
+}
+
+// PPOSStateHash is the digest of the snapshot db entries under a key prefix at
+// a block, broken down by plugin domain and by the byte following the prefix.
+//
+// A digest is the keccak256 hash of the ordered leaf hashes of the entries, a
+// leaf hash being the keccak256 hash of the rlp encoded key and value. The
+// digest of a child is thus the root of the child prefix, which lets two nodes
+// be bisected down to the first key they disagree on.
+type PPOSStateHash struct {
+	Number   uint64                 `json:"number"`
+	Hash     common.Hash            `json:"hash"`
+	Prefix   hexutil.Bytes          `json:"prefix"`
+	Count    uint64                 `json:"count"`
+	Root     common.Hash            `json:"root"`
+	Value    hexutil.Bytes          `json:"value,omitempty"` // Value of the key equal to the prefix, if any
+	Domains  map[string]*PPOSDigest `json:"domains"`
+	Children []*PPOSDigest          `json:"children"`
+}
+
+// PPOSDigest is the digest of a group of entries, a child prefix or a domain.
+type PPOSDigest struct {
+	Key    hexutil.Bytes `json:"key,omitempty"`
+	Count  uint64        `json:"count"`
+	Digest common.Hash   `json:"digest"`
+}
+
+// pposHasher accumulates the leaf hashes of a group of entries.
+type pposHasher struct {
+	count uint64
+	state hash.Hash
+}
+
+func newPPOSHasher() *pposHasher {
+	return &pposHasher{state: sha3.NewLegacyKeccak256()}
+}
+
+func (h *pposHasher) add(leaf []byte) {
+	h.count++
+	h.state.Write(leaf)
+}
+
+func (h *pposHasher) digest(key []byte) *PPOSDigest {
+	return &PPOSDigest{Key: key, Count: h.count, Digest: common.BytesToHash(h.state.Sum(nil))}
+}
+
+// PPOSStateHashAt computes the digests of the snapshot db entries under the
+// prefix at the block, which must be a canonical block between the base and
+// the highest block of the snapshot db. The entries are streamed, only the
+// digests of the children are kept in memory.
+func PPOSStateHashAt(db snapshotdb.DB, number uint64, hash common.Hash, prefix []byte) (*PPOSStateHash, error) {
+	if err := checkSnapshotRange(db, number); err != nil {
+		return nil, err
+	}
+	var (
+		root     = newPPOSHasher()
+		domains  = make(map[string]*pposHasher)
+		children []*pposHasher
+		keys     [][]byte
+		value    []byte
+	)
+	iter := db.NewIterator(hash, prefix)
+	for iter.Next() {
+		key, val := iter.Key(), iter.Value()
+		if isSnapshotMetaKey(key) {
+			continue
+		}
+		enc, err := rlp.EncodeToBytes([][]byte{key, val})
+		if err != nil {
+			iter.Release()
+			return nil, err
+		}
+		leaf := crypto.Keccak256(enc)
+		root.add(leaf)
+
+		domain := PPOSDomain(key)
+		if domains[domain] == nil {
+			domains[domain] = newPPOSHasher()
+		}
+		domains[domain].add(leaf)
+
+		if len(key) == len(prefix) {
+			value = common.CopyBytes(val)
+			continue
+		}
+		child := key[:len(prefix)+1]
+		if len(keys) == 0 || !bytes.Equal(keys[len(keys)-1], child) {
+			keys = append(keys, common.CopyBytes(child))
+			children = append(children, newPPOSHasher())
+		}
+		children[len(children)-1].add(leaf)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	// The block may have been compacted into the base while walking
+	if err := checkSnapshotRange(db, number); err != nil {
+		return nil, err
+	}
+
+	result := &PPOSStateHash{
+		Number:   number,
+		Hash:     hash,
+		Prefix:   common.CopyBytes(prefix),
+		Value:    value,
+		Domains:  make(map[string]*PPOSDigest, len(domains)),
+		Children: make([]*PPOSDigest, 0, len(children)),
+	}
+	digest := root.digest(nil)
+	result.Count, result.Root = digest.Count, digest.Digest
+	for name, hasher := range domains {
+		result.Domains[name] = hasher.digest(nil)
+	}
+	for i, hasher := range children {
+		result.Children = append(result.Children, hasher.digest(keys[i]))
+	}
+	return result, nil
+}
+
+// checkSnapshotRange returns an error if the snapshot db doesn't hold the data
+// of the block.
+func checkSnapshotRange(db snapshotdb.DB, number uint64) error {
+	base, err := db.BaseNum()
+	if err != nil {
+		return err
+	}
+	if number < base.Uint64() {
+		return fmt.Errorf("block #%d is below the base #%d of the snapshot db", number, base)
+	}
+	if highest := db.GetCurrent().GetHighest(true).Num; number > highest.Uint64() {
+		return fmt.Errorf("block #%d is above the highest block #%d of the snapshot db", number, highest)
+	}
+	return nil
+}
+
+// PPOSDivergence is the first key two nodes disagree on, an empty value meaning
+// the key is missing.
+type PPOSDivergence struct {
+	Key    hexutil.Bytes `json:"key"`
+	Domain string        `json:"domain"`
+	A      hexutil.Bytes `json:"a"`
+	B      hexutil.Bytes `json:"b"`
+}
+
+// BisectPPOS compares the digests of two nodes under the prefix and descends
+// into the first differing child until it reaches the first differing key. It
+// returns nil if the nodes agree on all the entries under the prefix.
+//
+// The lookups must be made at the same block, both nodes being asked for one
+// prefix per level.
+func BisectPPOS(a, b func(prefix []byte) (*PPOSStateHash, error), prefix []byte) (*PPOSDivergence, error) {
+	for depth := 0; ; depth++ {
+		ha, err := a(prefix)
+		if err != nil {
+			return nil, err
+		}
+		hb, err := b(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if ha.Root == hb.Root && ha.Count == hb.Count {
+			if depth == 0 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("digests of prefix %#x agree while its parent's differ, the data changed during the walk", prefix)
+		}
+		if !bytes.Equal(ha.Value, hb.Value) {
+			return &PPOSDivergence{Key: common.CopyBytes(prefix), Domain: PPOSDomain(prefix), A: ha.Value, B: hb.Value}, nil
+		}
+		child := firstDifferingChild(ha.Children, hb.Children)
+		if child == nil {
+			return nil, fmt.Errorf("digests of prefix %#x differ while its children agree", prefix)
+		}
+		prefix = child
+	}
+}
+
+// firstDifferingChild returns the lowest child key whose digests differ or
+// which is missing on one side, nil if none.
+func firstDifferingChild(a, b []*PPOSDigest) []byte {
+	digests := make(map[string]*PPOSDigest, len(a))
+	for _, child := range a {
+		digests[string(child.Key)] = child
+	}
+	var differing [][]byte
+	for _, child := range b {
+		other, ok := digests[string(child.Key)]
+		delete(digests, string(child.Key))
+		if ok && other.Digest == child.Digest && other.Count == child.Count {
+			continue
+		}
+		differing = append(differing, child.Key)
+	}
+	for key := range digests {
+		differing = append(differing, []byte(key))
+	}
+	if len(differing) == 0 {
+		return nil
+	}
+	sort.Slice(differing, func(i, j int) bool { return bytes.Compare(differing[i], differing[j]) < 0 })
+	return differing[0]
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The PlatON Network Authors
+// This file is part of the PlatON-Go library.
+//
+// The PlatON-Go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The PlatON-Go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the PlatON-Go library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/PlatONnetwork/PlatON-Go/common"
+	"github.com/PlatONnetwork/PlatON-Go/core/snapshotdb"
+	"github.com/PlatONnetwork/PlatON-Go/x/reward"
+	"github.com/PlatONnetwork/PlatON-Go/x/staking"
+	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
+)
+
+// newHashTestDB opens a snapshot db holding the entries in its base at block 5
+// and the changes in the committed block 6.
+func newHashTestDB(t *testing.T, base [][2][]byte, changes map[string][]byte) snapshotdb.DB {
+	db, err := snapshotdb.Open(t.TempDir(), 0, 0, false)
+	if err != nil {
+		t.Fatalf("failed to open the snapshot db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SetCurrent(common.ZeroHash, *big.NewInt(5), *big.NewInt(5)); err != nil {
+		t.Fatalf("failed to set the current block: %v", err)
+	}
+	if err := db.WriteBaseDB(base); err != nil {
+		t.Fatalf("failed to write the base db: %v", err)
+	}
+	hash := common.Hash{6}
+	if err := db.NewBlock(big.NewInt(6), common.ZeroHash, hash); err != nil {
+		t.Fatalf("failed to create block: %v", err)
+	}
+	for key, value := range changes {
+		if err := db.Put(hash, []byte(key), value); err != nil {
+			t.Fatalf("failed to put %q: %v", key, err)
+		}
+	}
+	if err := db.Commit(hash); err != nil {
+		t.Fatalf("failed to commit block: %v", err)
+	}
+	return db
+}
+
+func TestPPOSDomain(t *testing.T) {
+	tests := []struct {
+		key    []byte
+		domain string
+	}{
+		{staking.GetDelegateKey(addrArr[0], nodeIdArr[0], 1), "staking"},
+		{reward.DelegateRewardPerKey(nodeIdArr[0], 1, 1), "reward"},
+		{xcom.AvgPackTimeKey, "common"},
+		{[]byte("nodePackAmount1"), "slashing"},
+		{[]byte("x"), "other"},
+	}
+	for _, test := range tests {
+		if domain := PPOSDomain(test.key); domain != test.domain {
+			t.Errorf("domain of %q mismatch: have %s, want %s", test.key, domain, test.domain)
+		}
+	}
+}
+
+func TestPPOSStateHash(t *testing.T) {
+	base := [][2][]byte{
+		{[]byte("ab"), []byte{1}},
+		{[]byte("abc"), []byte{2}},
+		{[]byte("abd"), []byte{3}},
+		{[]byte("b"), []byte{4}},
+		{xcom.AvgPackTimeKey, []byte{5}},
+	}
+	db := newHashTestDB(t, base, map[string][]byte{"abd": {6}, "abe": {7}})
+
+	top, err := PPOSStateHashAt(db, 6, common.Hash{6}, nil)
+	if err != nil {
+		t.Fatalf("failed to hash: %v", err)
+	}
+	if top.Count != 6 || len(top.Children) != 3 {
+		t.Fatalf("top level mismatch: have %d entries %d children, want 6 entries 3 children", top.Count, len(top.Children))
+	}
+	if domain := top.Domains["common"]; domain == nil || domain.Count != 1 {
+		t.Errorf("common domain mismatch: %v", domain)
+	}
+	if domain := top.Domains["other"]; domain == nil || domain.Count != 5 {
+		t.Errorf("other domain mismatch: %v", domain)
+	}
+	// The digest of a child is the root of the next level
+	child := top.Children[1]
+	if !bytes.Equal(child.Key, []byte("a")) || child.Count != 4 {
+		t.Fatalf("child mismatch: have %q with %d entries, want \"a\" with 4", child.Key, child.Count)
+	}
+	next, err := PPOSStateHashAt(db, 6, common.Hash{6}, []byte("ab"))
+	if err != nil {
+		t.Fatalf("failed to hash: %v", err)
+	}
+	if !bytes.Equal(next.Value, []byte{1}) || len(next.Children) != 3 {
+		t.Errorf("next level mismatch: have value %x and %d children, want 01 and 3", next.Value, len(next.Children))
+	}
+	if prev, err := PPOSStateHashAt(db, 6, common.Hash{6}, []byte("a")); err != nil || prev.Children[0].Digest != next.Root {
+		t.Errorf("child digest mismatch: have %v, want %x (%v)", prev.Children, next.Root, err)
+	}
+	// The base block doesn't see the changes of block 6
+	if old, err := PPOSStateHashAt(db, 5, common.ZeroHash, []byte("ab")); err != nil || old.Count != 3 || old.Root == next.Root {
+		t.Errorf("base block mismatch: %v, %v", old, err)
+	}
+	if _, err := PPOSStateHashAt(db, 4, common.ZeroHash, nil); err == nil {
+		t.Error("block below the base hashed")
+	}
+	if _, err := PPOSStateHashAt(db, 7, common.ZeroHash, nil); err == nil {
+		t.Error("block above the highest block hashed")
+	}
+}
+
+func TestBisectPPOS(t *testing.T) {
+	base := [][2][]byte{
+		{[]byte("ab"), []byte{1}},
+		{[]byte("abc"), []byte{2}},
+		{[]byte("b"), []byte{3}},
+	}
+	var (
+		a = newHashTestDB(t, base, map[string][]byte{"abd": {4}, "c": {5}})
+		b = newHashTestDB(t, base, map[string][]byte{"abd": {6}, "abe": {7}})
+		c = newHashTestDB(t, base, map[string][]byte{"abd": {4}, "c": {5}})
+	)
+	hasher := func(db snapshotdb.DB) func(prefix []byte) (*PPOSStateHash, error) {
+		return func(prefix []byte) (*PPOSStateHash, error) {
+			return PPOSStateHashAt(db, 6, common.Hash{6}, prefix)
+		}
+	}
+	div, err := BisectPPOS(hasher(a), hasher(b), nil)
+	if err != nil {
+		t.Fatalf("failed to bisect: %v", err)
+	}
+	if div == nil || string(div.Key) != "abd" || !bytes.Equal(div.A, []byte{4}) || !bytes.Equal(div.B, []byte{6}) {
+		t.Errorf("divergence mismatch: %v", div)
+	}
+	// Keys missing on one side are reported with an empty value
+	if div, err := BisectPPOS(hasher(a), hasher(b), []byte("c")); err != nil || div == nil || string(div.Key) != "c" || len(div.B) != 0 {
+		t.Errorf("missing key mismatch: %v, %v", div, err)
+	}
+	if div, err := BisectPPOS(hasher(a), hasher(c), nil); err != nil || div != nil {
+		t.Errorf("equal nodes diverge: %v, %v", div, err)
+	}
+}
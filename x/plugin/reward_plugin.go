@@ -121,6 +121,10 @@ func (rmp *RewardMgrPlugin) EndBlock(blockHash common.Hash, head *types.Header,
 		return err
 	}
 
+	epoch := xutil.CalculateEpoch(blockNumber)
+	rewardGauges.update("ppos/reward/epoch/staking", epoch, stakingReward)
+	rewardGauges.update("ppos/reward/epoch/block", epoch, packageReward)
+
 	if xutil.IsEndOfEpoch(blockNumber) {
 		verifierList, err := rmp.AllocateStakingReward(blockNumber, blockHash, stakingReward, state)
 		if err != nil {
@@ -139,6 +143,7 @@ func (rmp *RewardMgrPlugin) EndBlock(blockHash common.Hash, head *types.Header,
 			return err
 		}
 	}
+	rewardGauges.update("ppos/reward/pool", epoch, state.GetBalance(vm.RewardManagerPoolAddr))
 
 	return nil
 }
@@ -48,6 +48,7 @@ import (
 	"github.com/PlatONnetwork/PlatON-Go/crypto/vrf"
 	"github.com/PlatONnetwork/PlatON-Go/event"
 	"github.com/PlatONnetwork/PlatON-Go/log"
+	"github.com/PlatONnetwork/PlatON-Go/metrics"
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
 	"github.com/PlatONnetwork/PlatON-Go/x/staking"
 	"github.com/PlatONnetwork/PlatON-Go/x/xcom"
@@ -121,6 +122,9 @@ func (sk *StakingPlugin) EnableValidatorsHistory() {
 func (sk *StakingPlugin) BeginBlock(blockHash common.Hash, header *types.Header, state xcom.StateDB) error {
 	// adjust rewardPer and nextRewardPer
 	blockNumber := header.Number.Uint64()
+	if metrics.Enabled && xutil.IsBeginOfConsensus(blockNumber) {
+		sk.updateValidatorMetrics(blockHash, blockNumber)
+	}
 	if xutil.IsBeginOfEpoch(blockNumber) {
 		current, err := sk.getVerifierList(blockHash, blockNumber, QueryStartNotIrr)
 		if err != nil {
@@ -213,7 +217,7 @@ func (sk *StakingPlugin) BeginBlock(blockHash common.Hash, header *types.Header,
 }
 
 func (sk *StakingPlugin) writeHistoryValidator(id common.Hash, hv *staking.HistoryValidator, blockHash common.Hash, header *types.Header, state xcom.StateDB) error {
-	if sk.enableValidatorsHistory {
+	if sk.enableValidatorsHistory && !sk.db.GetDB().IsReplayBlock(blockHash) {
 		blockNumber := header.Number.Uint64()
 		dbKey := staking.HistoryValidatorDBKey(id)
 		// Check that the simplified historical node information has been stored in the DB.
@@ -237,7 +241,7 @@ func (sk *StakingPlugin) writeHistoryValidator(id common.Hash, hv *staking.Histo
 }
 
 func (sk *StakingPlugin) writeHistoryValidatorIDList(idList staking.HistoryValidatorIDList, nextStart uint64, blockHash common.Hash, header *types.Header, state xcom.StateDB) error {
-	if sk.enableValidatorsHistory {
+	if sk.enableValidatorsHistory && !sk.db.GetDB().IsReplayBlock(blockHash) {
 		blockNumber := header.Number.Uint64()
 		// rlp encoded and written to DB
 		hvIDListEnVal, err := idList.Encode()
@@ -294,6 +298,25 @@ func (sk *StakingPlugin) GetValidatorHistoryList(targetBlockNumber uint64) ([]*s
 	return resultList, nil
 }
 
+// updateValidatorMetrics records the stake of the validators of the round
+// starting at the block.
+func (sk *StakingPlugin) updateValidatorMetrics(blockHash common.Hash, blockNumber uint64) {
+	curr, err := sk.getCurrValList(blockHash, blockNumber, QueryStartNotIrr)
+	if nil != err {
+		log.Debug("Failed to query the current round validators for the metrics", "blockNumber", blockNumber, "err", err)
+		return
+	}
+	stake := new(big.Int)
+	for _, v := range curr.Arr {
+		if v.Shares != nil {
+			stake.Add(stake, v.Shares)
+		}
+	}
+	epoch := xutil.CalculateEpoch(blockNumber)
+	stakeGauges.update("ppos/staking/validators/stake", epoch, stake)
+	stakeGauges.update("ppos/staking/validators/count", epoch, big.NewInt(int64(len(curr.Arr))))
+}
+
 func (sk *StakingPlugin) EndBlock(blockHash common.Hash, header *types.Header, state xcom.StateDB) error {
 
 	epoch := xutil.CalculateEpoch(header.Number.Uint64())
@@ -2890,7 +2913,27 @@ func (sk *StakingPlugin) GetValidator(blockNumber uint64) (*cbfttypes.Validators
 	}
 
 	if nil == err && nil != valArr {
-		return buildCbftValidators(valArr.Start, valArr.Arr), nil
+		return BuildCbftValidators(valArr.Start, valArr.Arr), nil
+	}
+	return nil, fmt.Errorf("Not Found Validators by blockNumber: %d", blockNumber)
+}
+
+// LoadValidators returns the validators of the round of the block number from
+// the committed ppos data of the db, without the staking plugin being set up.
+func LoadValidators(db snapshotdb.DB, blockNumber uint64) (*cbfttypes.Validators, error) {
+	stakingDB := staking.NewStakingDBWithDB(db)
+	indexs, err := stakingDB.GetRoundValIndexByIrr()
+	if nil != err {
+		return nil, err
+	}
+	for _, index := range indexs {
+		if index.Start <= blockNumber && index.End >= blockNumber {
+			queue, err := stakingDB.GetRoundValListByIrr(index.Start, index.End)
+			if nil != err {
+				return nil, err
+			}
+			return BuildCbftValidators(index.Start, queue), nil
+		}
 	}
 	return nil, fmt.Errorf("Not Found Validators by blockNumber: %d", blockNumber)
 }
@@ -2925,7 +2968,8 @@ label:
 	return isCandidate
 }
 
-func buildCbftValidators(start uint64, arr staking.ValidatorQueue) *cbfttypes.Validators {
+// BuildCbftValidators converts the validators of a round into the validators of cbft.
+func BuildCbftValidators(start uint64, arr staking.ValidatorQueue) *cbfttypes.Validators {
 	valMap := make(cbfttypes.ValidateNodeMap, len(arr))
 
 	for i, v := range arr {
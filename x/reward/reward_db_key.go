@@ -18,6 +18,8 @@
 package reward
 
 import (
+	"bytes"
+
 	"github.com/PlatONnetwork/PlatON-Go/x/xutil"
 
 	"github.com/PlatONnetwork/PlatON-Go/p2p/discover"
@@ -39,6 +41,15 @@ var (
 	delegateRewardPerKey     = []byte("DelegateRewardPerKey")
 )
 
+// KeyPrefixes returns the prefixes of all the keys the reward keeps in the
+// snapshot db.
+func KeyPrefixes() [][]byte {
+	return [][]byte{
+		HistoryIncreasePrefix, LastYearEndBalancePrefix, YearStartBlockNumberKey, YearStartTimeKey, RemainingRewardKey,
+		NewBlockRewardKey, StakingRewardKey, ChainYearNumberKey, delegateRewardPerKey,
+	}
+}
+
 // GetHistoryIncreaseKey used for search the balance of reward pool at last year
 func GetHistoryIncreaseKey(year uint32) []byte {
 	return append(HistoryIncreasePrefix, common.Uint32ToBytes(year)...)
@@ -88,3 +99,19 @@ func DelegateRewardPerKeys(nodeID discover.NodeID, stakingNum, fromEpoch, toEpoc
 	}
 	return keys
 }
+
+// DecodeDelegateRewardPerKey returns the node address, the staking block number
+// and the epoch index of a delegate reward per key, false if the key isn't one.
+func DecodeDelegateRewardPerKey(key []byte) (nodeAddr common.NodeAddress, stakingNum uint64, index uint32, ok bool) {
+	perKeyLength := len(delegateRewardPerKey)
+	lengthUint32, lengthUint64 := 4, 8
+	if len(key) != perKeyLength+common.AddressLength+lengthUint64+lengthUint32 || !bytes.HasPrefix(key, delegateRewardPerKey) {
+		return common.NodeAddress{}, 0, 0, false
+	}
+	n := perKeyLength
+	nodeAddr = common.NodeAddress(common.BytesToAddress(key[n : n+common.AddressLength]))
+	n += common.AddressLength
+	stakingNum = common.BytesToUint64(key[n : n+lengthUint64])
+	index = common.BytesToUint32(key[n+lengthUint64:])
+	return nodeAddr, stakingNum, index, true
+}
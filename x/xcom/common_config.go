@@ -218,9 +218,10 @@ func ResetEconomicExtendConfigUnDelegateFreezeDuration(UnDelegateFreezeDuration
 }
 
 const (
-	DefaultMainNet     = iota // PlatON default main net flag
-	DefaultTestNet            // PlatON default test net flag
-	DefaultUnitTestNet        // PlatON default unit test
+	DefaultMainNet      = iota // PlatON default main net flag
+	DefaultTestNet             // PlatON default test net flag
+	DefaultUnitTestNet         // PlatON default unit test
+	DefaultDeveloperNet        // PlatON single node developer network
 )
 
 func getDefaultEMConfig(netId int8) *EconomicModel {
@@ -417,6 +418,8 @@ func getDefaultEMConfig(netId int8) *EconomicModel {
 				UnDelegateFreezeDuration: 2,
 			},
 		}
+	case DefaultDeveloperNet:
+		ec, ece = DeveloperEconomicModel()
 	default: // DefaultTestNet
 		log.Error("not support chainID", "netId", netId)
 		return nil
@@ -425,6 +428,76 @@ func getDefaultEMConfig(netId int8) *EconomicModel {
 	return ec
 }
 
+// DeveloperEconomicModel returns the economic model of the single node developer
+// network. Unlike GetEc it leaves the global model untouched, the developer
+// mode installs it when it sets up the genesis.
+func DeveloperEconomicModel() (*EconomicModel, *EconomicModelExtend) {
+	// 3.22361981  thousand millions LAT
+	cdfundBalance, _ := new(big.Int).SetString("322361981000000000000000000", 10)
+
+	ec := &EconomicModel{
+		// The shortest periods the checks allow: 20 blocks per consensus
+		// round, 4 rounds per epoch and 4 epochs per issuance period.
+		Common: commonConfig{
+			MaxEpochMinutes:     uint64(3),  // 3 minutes
+			NodeBlockTimeWindow: uint64(10), // 10 seconds
+			PerRoundBlocks:      uint64(5),
+			MaxConsensusVals:    uint64(4),
+			AdditionalCycleTime: uint64(11),
+		},
+		Staking: stakingConfig{
+			StakeThreshold:          new(big.Int).Set(StakeLowerLimit),
+			OperatingThreshold:      new(big.Int).Set(DelegateLowerLimit),
+			MaxValidators:           uint64(25),
+			UnStakeFreezeDuration:   uint64(2),
+			RewardPerMaxChangeRange: uint16(500),
+			RewardPerChangeInterval: uint16(10),
+		},
+		Slashing: slashingConfig{
+			SlashFractionDuplicateSign: uint32(10),
+			DuplicateSignReportReward:  uint32(50),
+			MaxEvidenceAge:             uint32(1),
+			SlashBlocksReward:          uint32(0),
+			ZeroProduceCumulativeTime:  uint16(3),
+			ZeroProduceNumberThreshold: uint16(2),
+			ZeroProduceFreezeDuration:  uint64(1),
+		},
+		Gov: governanceConfig{
+			VersionProposalVoteDurationSeconds: uint64(160),
+			VersionProposalSupportRate:         6670,
+			TextProposalVoteDurationSeconds:    uint64(160),
+			TextProposalVoteRate:               5000,
+			TextProposalSupportRate:            6670,
+			CancelProposalVoteRate:             5000,
+			CancelProposalSupportRate:          6670,
+			ParamProposalVoteDurationSeconds:   uint64(160),
+			ParamProposalVoteRate:              5000,
+			ParamProposalSupportRate:           6670,
+		},
+		Reward: rewardConfig{
+			NewBlockRate:                 50,
+			PlatONFoundationYear:         10,
+			IncreaseIssuanceRatio:        250,
+			TheNumberOfDelegationsReward: 2,
+		},
+		Restricting: restrictingConfig{
+			MinimumRelease: new(big.Int).Set(FloorMinimumRelease),
+		},
+		InnerAcc: innerAccount{
+			PlatONFundAccount: common.HexToAddress("0x493301712671Ada506ba6Ca7891F436D29185821"),
+			PlatONFundBalance: new(big.Int).SetInt64(0),
+			CDFAccount:        common.HexToAddress("0xC1f330B214668beAc2E6418Dd651B09C759a4Bf5"),
+			CDFBalance:        new(big.Int).Set(cdfundBalance),
+		},
+	}
+	ece := &EconomicModelExtend{
+		Staking: stakingConfigExtend{
+			UnDelegateFreezeDuration: 2,
+		},
+	}
+	return ec, ece
+}
+
 func CheckStakeThreshold(threshold *big.Int) error {
 
 	if threshold.Cmp(StakeLowerLimit) < 0 || threshold.Cmp(StakeUpperLimit) > 0 {